@@ -0,0 +1,329 @@
+// Command backtest replays market_data candles through a registered strategy and prints the
+// metrics; -out writes the full result as JSON and -trades the trade list as CSV. With -space it
+// searches the parameters instead, and with -wf-in and -wf-out it runs a walk-forward analysis;
+// -results then writes the ranked table or the windows as CSV and -equity the equity curve.
+// With -ai it backtests the AI recommender instead of a strategy, rebuilding its context from
+// stored candles, archived news and the calendar as of each decision.
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"log"
+	"net/http"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/jedi116/go-trader/internal/ai"
+	"github.com/jedi116/go-trader/internal/backtest"
+	"github.com/jedi116/go-trader/internal/config"
+	"github.com/jedi116/go-trader/internal/database"
+	"github.com/jedi116/go-trader/internal/strategy"
+	"github.com/jedi116/go-trader/internal/ticks"
+)
+
+// paramFlags collects repeated -param key=value flags.
+type paramFlags strategy.Params
+
+func (p paramFlags) String() string { return fmt.Sprint(map[string]interface{}(p)) }
+
+func (p paramFlags) Set(v string) error {
+	key, value, ok := strings.Cut(v, "=")
+	if !ok || key == "" {
+		return fmt.Errorf("want key=value, got %q", v)
+	}
+	p[strings.ToLower(key)] = value
+	return nil
+}
+
+// spaceFlags collects repeated -space flags: name=min:max[:step] or name=v1,v2,...
+type spaceFlags backtest.Space
+
+func (s spaceFlags) String() string { return fmt.Sprint(map[string]backtest.Range(s)) }
+
+func (s spaceFlags) Set(v string) error {
+	key, value, ok := strings.Cut(v, "=")
+	if !ok || key == "" || value == "" {
+		return fmt.Errorf("want name=min:max[:step] or name=v1,v2, got %q", v)
+	}
+	var r backtest.Range
+	if parts := strings.Split(value, ":"); len(parts) > 1 {
+		if len(parts) > 3 {
+			return fmt.Errorf("want min:max[:step], got %q", value)
+		}
+		nums := make([]float64, len(parts))
+		for i, part := range parts {
+			f, err := strconv.ParseFloat(part, 64)
+			if err != nil {
+				return fmt.Errorf("bad number %q in %q", part, v)
+			}
+			nums[i] = f
+		}
+		r.Min, r.Max = &nums[0], &nums[1]
+		if len(nums) == 3 {
+			r.Step = nums[2]
+		}
+	} else {
+		for _, part := range strings.Split(value, ",") {
+			if f, err := strconv.ParseFloat(part, 64); err == nil {
+				r.Values = append(r.Values, f)
+			} else {
+				r.Values = append(r.Values, part)
+			}
+		}
+	}
+	s[strings.ToLower(key)] = r
+	return nil
+}
+
+func main() {
+	cfg, err := config.Load()
+	if err != nil {
+		log.Fatal(err)
+	}
+	defaults := backtest.OptionsFromConfig(cfg.Backtest)
+	params := paramFlags{}
+	name := flag.String("strategy", "", "strategy type, one of "+strings.Join(strategy.Types(), ", "))
+	flag.Var(params, "param", "strategy parameter key=value (repeatable)")
+	instruments := flag.String("instruments", "EUR_USD", "comma-separated instruments")
+	timeframe := flag.String("timeframe", "H1", "candle granularity")
+	source := flag.String("data", "candles", "candles replays market_data; ticks builds M1 to H1 candles from recorded bid/ask ticks")
+	fromFlag := flag.String("from", time.Now().AddDate(-1, 0, 0).Format("2006-01-02"), "start, RFC3339 or YYYY-MM-DD")
+	toFlag := flag.String("to", time.Now().Format("2006-01-02"), "end (exclusive), RFC3339 or YYYY-MM-DD")
+	balance := flag.Float64("balance", defaults.InitialBalance, "initial balance")
+	currency := flag.String("currency", defaults.Currency, "account currency")
+	spread := flag.Float64("spread", defaults.Costs.SpreadPips, "spread in pips for candles without bid/ask")
+	slippage := flag.Float64("slippage", defaults.Costs.SlippagePips, "slippage in pips on market and stop fills")
+	commission := flag.Float64("commission", defaults.Costs.CommissionPerMillion, "commission per million units")
+	intrabar := flag.String("intrabar", defaults.Intrabar, "stop_first, target_first or nearest")
+	out := flag.String("out", "", "write the full result as JSON to this file")
+	tradesOut := flag.String("trades", "", "write the trades as CSV to this file")
+	verbose := flag.Bool("v", false, "log strategy messages")
+	space := spaceFlags{}
+	flag.Var(space, "space", "searched parameter name=min:max[:step] or name=v1,v2 (repeatable)")
+	method := flag.String("method", backtest.Grid, "search method, grid or random")
+	samples := flag.Int("samples", 100, "combinations drawn by random search")
+	seed := flag.Int64("seed", 1, "random search seed")
+	workers := flag.Int("workers", 0, "parallel runs (default: number of CPUs)")
+	objective := flag.String("objective", backtest.ObjectiveSharpe, "ranking objective: sharpe, sortino, cagr, total_return, net_profit or profit_factor")
+	minTrades := flag.Int("min-trades", 0, "drop combinations with fewer trades")
+	wfIn := flag.Int("wf-in", 0, "walk-forward in-sample days")
+	wfOut := flag.Int("wf-out", 0, "walk-forward out-of-sample days")
+	wfStep := flag.Int("wf-step", 0, "walk-forward step days (default: -wf-out)")
+	anchored := flag.Bool("anchored", false, "anchor every in-sample period at -from")
+	resultsOut := flag.String("results", "", "write the ranked candidates or walk-forward windows as CSV to this file")
+	equityOut := flag.String("equity", "", "write the equity curve as CSV to this file")
+	aiMode := flag.Bool("ai", false, "backtest the AI recommender instead of -strategy")
+	aiEvery := flag.Duration("ai-every", 4*time.Hour, "time between AI decisions")
+	aiRisk := flag.String("ai-risk", "medium", "risk level sent to the model; sets the bracket distance")
+	aiHorizon := flag.String("ai-horizon", "short", "time horizon sent to the model")
+	aiUnits := flag.Int64("ai-units", 10000, "units per AI trade; 0 uses -ai-risk-percent or the model's units")
+	aiRiskPercent := flag.Float64("ai-risk-percent", 0, "size AI trades to lose this fraction of the initial balance at the stop")
+	aiMinConfidence := flag.Float64("ai-min-confidence", 0, "skip recommendations below this confidence")
+	aiMaxHold := flag.Duration("ai-max-hold", 0, "close AI positions held this long (0: brackets only)")
+	aiMaxCalls := flag.Int("ai-max-calls", 500, "maximum model calls")
+	aiGranularity := flag.String("ai-granularity", "", "candles in the AI market context (default: -timeframe)")
+	aiRecord := flag.String("ai-record", "", "answer from and append model responses to this file")
+	aiReplay := flag.String("ai-replay", "", "answer only from responses recorded in this file; never call the model")
+	flag.Parse()
+
+	from, err := backtest.ParseTime(*fromFlag)
+	if err != nil {
+		log.Fatal(err)
+	}
+	to, err := backtest.ParseTime(*toFlag)
+	if err != nil {
+		log.Fatal(err)
+	}
+	db, err := database.Open(cfg)
+	if err != nil {
+		log.Fatal(err)
+	}
+	var list []string
+	for _, inst := range strings.Split(*instruments, ",") {
+		if inst = strings.ToUpper(strings.TrimSpace(inst)); inst != "" {
+			list = append(list, inst)
+		}
+	}
+	var store backtest.Store = db
+	switch *source {
+	case "candles":
+	case "ticks":
+		store = ticks.CandleStore{Ticks: db}
+	default:
+		log.Fatalf("-data must be candles or ticks, got %q", *source)
+	}
+	data, err := backtest.Load(context.Background(), store, list, strings.ToUpper(*timeframe), from, to)
+	if err != nil {
+		log.Fatal(err)
+	}
+	opts := backtest.Options{
+		Name:           *name,
+		Params:         strategy.Params(params),
+		InitialBalance: *balance,
+		Currency:       *currency,
+		Costs:          backtest.Costs{SpreadPips: *spread, SlippagePips: *slippage, CommissionPerMillion: *commission},
+		Intrabar:       *intrabar,
+		Verbose:        *verbose,
+	}
+	oo := backtest.OptimizeOptions{Method: *method, Samples: *samples, Seed: *seed, Workers: *workers, Objective: *objective, MinTrades: *minTrades}
+
+	var res interface{}
+	var trades []backtest.Trade
+	var equity []backtest.EquityPoint
+	switch {
+	case *aiMode:
+		var client ai.ClaudeClient = ai.NewClaudeClient(http.DefaultClient)
+		switch {
+		case *aiReplay != "":
+			rec, err := ai.OpenRecorder(*aiReplay, nil)
+			if err != nil {
+				log.Fatal(err)
+			}
+			client = rec
+		case *aiRecord != "":
+			rec, err := ai.OpenRecorder(*aiRecord, client)
+			if err != nil {
+				log.Fatal(err)
+			}
+			defer rec.Close()
+			client = rec
+		}
+		if *aiReplay == "" && os.Getenv("ANTHROPIC_API_KEY") == "" {
+			log.Printf("[BACKTEST] ANTHROPIC_API_KEY is not set; the model client answers with its fallback heuristic")
+		}
+		granularity := strings.ToUpper(*aiGranularity)
+		if granularity == "" {
+			granularity = data.Timeframe
+		}
+		svc := ai.NewService(backtest.NewAIAggregator(db, backtest.AIContextOptions{Granularity: granularity}), client)
+		opts.Name = "ai"
+		replay, err := backtest.RunAI(context.Background(), data, svc, opts, backtest.AIReplayOptions{
+			Request:       ai.RecommendationRequest{RiskLevel: *aiRisk, TimeHorizon: *aiHorizon, Units: *aiUnits, RiskPercent: *aiRiskPercent},
+			Every:         *aiEvery,
+			MinConfidence: *aiMinConfidence,
+			MaxHold:       *aiMaxHold,
+			MaxCalls:      *aiMaxCalls,
+			Pricing:       ai.PriceTableFromConfig(cfg.AI.Pricing),
+		})
+		if err != nil {
+			log.Fatal(err)
+		}
+		actions := make(map[string]int)
+		for _, d := range replay.Decisions {
+			actions[d.Action]++
+		}
+		fmt.Printf("ai replay, %d calls (%d errors), cost $%.4f, actions %v\n", replay.Calls, replay.Errors, replay.CostUSD, actions)
+		printSummary(replay.Result)
+		res, trades, equity = replay, replay.Trades, replay.Equity
+	case *wfIn > 0 || *wfOut > 0:
+		wf, err := backtest.WalkForward(context.Background(), data, *name, backtest.Space(space), opts, oo,
+			backtest.WalkForwardOptions{InSampleDays: *wfIn, OutSampleDays: *wfOut, StepDays: *wfStep, Anchored: *anchored})
+		if err != nil {
+			log.Fatal(err)
+		}
+		printWindows(wf.Windows)
+		printSummary(&backtest.Result{Name: wf.Name, Instruments: wf.Instruments, Timeframe: wf.Timeframe, From: wf.From, To: wf.To,
+			Bars: len(wf.Equity), Currency: wf.Currency, InitialBalance: wf.InitialBalance, FinalEquity: wf.FinalEquity, Metrics: wf.Metrics})
+		res, trades, equity = wf, wf.Trades, wf.Equity
+		if *resultsOut != "" {
+			writeCSV(*resultsOut, func(f *os.File) error { return backtest.WriteWindowsCSV(f, wf.Windows) })
+		}
+	case len(space) > 0:
+		opt, err := backtest.Optimize(context.Background(), data, *name, backtest.Space(space), opts, oo)
+		if err != nil {
+			log.Fatal(err)
+		}
+		fmt.Printf("%s search, %d evaluated, %d skipped, ranked by %s\n", opt.Method, opt.Evaluated, opt.Skipped, opt.Objective)
+		for _, c := range opt.Candidates[:min(10, len(opt.Candidates))] {
+			fmt.Printf("  #%-3d %8.3f  %v\n", c.Rank, c.Score, c.Params)
+		}
+		strat, err := strategy.New(*name, opt.Best().Params)
+		if err != nil {
+			log.Fatal(err)
+		}
+		opts.Params = opt.Best().Params
+		best, err := backtest.Run(data, strat, opts)
+		if err != nil {
+			log.Fatal(err)
+		}
+		printSummary(best)
+		res, trades, equity = opt, best.Trades, best.Equity
+		if *resultsOut != "" {
+			writeCSV(*resultsOut, func(f *os.File) error { return backtest.WriteCandidatesCSV(f, opt.Candidates) })
+		}
+	default:
+		strat, err := strategy.New(*name, strategy.Params(params))
+		if err != nil {
+			log.Fatal(err)
+		}
+		run, err := backtest.Run(data, strat, opts)
+		if err != nil {
+			log.Fatal(err)
+		}
+		printSummary(run)
+		res, trades, equity = run, run.Trades, run.Equity
+	}
+
+	if *out != "" {
+		b, err := json.MarshalIndent(res, "", "  ")
+		if err != nil {
+			log.Fatal(err)
+		}
+		if err := os.WriteFile(*out, b, 0o644); err != nil {
+			log.Fatal(err)
+		}
+	}
+	if *tradesOut != "" {
+		writeCSV(*tradesOut, func(f *os.File) error { return backtest.WriteTradesCSV(f, trades) })
+	}
+	if *equityOut != "" {
+		writeCSV(*equityOut, func(f *os.File) error { return backtest.WriteEquityCSV(f, equity) })
+	}
+}
+
+func printWindows(windows []backtest.Window) {
+	for _, w := range windows {
+		if w.Error != "" {
+			fmt.Printf("  window %d  %s .. %s  %s\n", w.Index, w.OutSampleFrom.Format("2006-01-02"), w.OutSampleTo.Format("2006-01-02"), w.Error)
+			continue
+		}
+		fmt.Printf("  window %d  %s .. %s  IS Sharpe %6.2f  OOS return %6.2f%%  %v\n", w.Index, w.OutSampleFrom.Format("2006-01-02"),
+			w.OutSampleTo.Format("2006-01-02"), w.InSample.Sharpe, w.OutSample.TotalReturn*100, w.Params)
+	}
+}
+
+func printSummary(r *backtest.Result) {
+	m := r.Metrics
+	fmt.Printf("%s %v %s %s .. %s (%d bars)\n", r.Name, r.Instruments, r.Timeframe, r.From.Format(time.RFC3339), r.To.Format(time.RFC3339), r.Bars)
+	fmt.Printf("  equity         %.2f -> %.2f %s\n", r.InitialBalance, r.FinalEquity, r.Currency)
+	fmt.Printf("  total return   %.2f%%\n", m.TotalReturn*100)
+	fmt.Printf("  CAGR           %.2f%%\n", m.CAGR*100)
+	fmt.Printf("  Sharpe         %.2f\n", m.Sharpe)
+	fmt.Printf("  Sortino        %.2f\n", m.Sortino)
+	fmt.Printf("  max drawdown   %.2f%%\n", m.MaxDrawdown*100)
+	if m.ProfitFactor != nil {
+		fmt.Printf("  profit factor  %.2f\n", *m.ProfitFactor)
+	} else {
+		fmt.Printf("  profit factor  n/a\n")
+	}
+	fmt.Printf("  trades         %d (win rate %.1f%%, expectancy %.2f)\n", m.Trades, m.WinRate*100, m.Expectancy)
+	fmt.Printf("  commission     %.2f\n", m.Commission)
+}
+
+func writeCSV(path string, write func(*os.File) error) {
+	f, err := os.Create(path)
+	if err != nil {
+		log.Fatal(err)
+	}
+	if err := write(f); err != nil {
+		log.Fatal(err)
+	}
+	if err := f.Close(); err != nil {
+		log.Fatal(err)
+	}
+}
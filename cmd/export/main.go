@@ -0,0 +1,102 @@
+// Command export streams a stored dataset (trades, ai_recommendations, audit_logs, market_data or
+// ticks) as CSV or NDJSON to a file or stdout; -list prints the datasets and columns.
+package main
+
+import (
+	"bufio"
+	"context"
+	"flag"
+	"fmt"
+	"io"
+	"log"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/jedi116/go-trader/internal/backtest"
+	"github.com/jedi116/go-trader/internal/config"
+	"github.com/jedi116/go-trader/internal/database"
+	"github.com/jedi116/go-trader/internal/export"
+)
+
+func main() {
+	dataset := flag.String("dataset", "", "dataset, one of "+strings.Join(export.Datasets(), ", "))
+	formatFlag := flag.String("format", "csv", "csv, ndjson or parquet")
+	fromFlag := flag.String("from", "", "start, RFC3339 or YYYY-MM-DD")
+	toFlag := flag.String("to", "", "end (exclusive), RFC3339 or YYYY-MM-DD")
+	instrument := flag.String("instrument", "", "instrument filter (trades, ai_recommendations, market_data, ticks)")
+	timeframe := flag.String("timeframe", "", "timeframe filter (market_data)")
+	entity := flag.String("entity", "", "entity filter (audit_logs)")
+	limit := flag.Int("limit", 0, "maximum rows (0: all)")
+	tz := flag.String("tz", "UTC", "IANA zone times are written in")
+	out := flag.String("out", "", "output file (default stdout)")
+	list := flag.Bool("list", false, "list the datasets and their columns")
+	flag.Parse()
+
+	if *list {
+		for _, name := range export.Datasets() {
+			cols, _ := export.Columns(name)
+			fmt.Printf("%s: %s\n", name, strings.Join(cols, ", "))
+		}
+		return
+	}
+	format, err := export.ParseFormat(*formatFlag)
+	if err != nil {
+		log.Fatal(err)
+	}
+	f := export.Filter{
+		Instrument: strings.ToUpper(*instrument),
+		Timeframe:  strings.ToUpper(*timeframe),
+		Entity:     *entity,
+		Limit:      *limit,
+	}
+	for _, p := range []struct {
+		value string
+		dst   **time.Time
+	}{{*fromFlag, &f.From}, {*toFlag, &f.To}} {
+		if p.value == "" {
+			continue
+		}
+		t, err := backtest.ParseTime(p.value)
+		if err != nil {
+			log.Fatal(err)
+		}
+		*p.dst = &t
+	}
+	if err := export.Check(*dataset, f); err != nil {
+		log.Fatal(err)
+	}
+	loc, err := time.LoadLocation(*tz)
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	cfg, err := config.Load()
+	if err != nil {
+		log.Fatal(err)
+	}
+	db, err := database.Open(cfg)
+	if err != nil {
+		log.Fatal(err)
+	}
+	defer db.Close()
+
+	var w io.Writer = os.Stdout
+	if *out != "" {
+		file, err := os.Create(*out)
+		if err != nil {
+			log.Fatal(err)
+		}
+		defer file.Close()
+		w = file
+	}
+	bw := bufio.NewWriterSize(w, 64*1024)
+	n, err := export.Export(context.Background(), db, bw, *dataset, f, export.Options{Format: format, Location: loc})
+	if ferr := bw.Flush(); err == nil {
+		err = ferr
+	}
+	if err != nil {
+		log.Fatalf("export %s after %d rows: %v", *dataset, n, err)
+	}
+	log.Printf("exported %d %s rows", n, *dataset)
+}
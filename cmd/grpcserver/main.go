@@ -4,32 +4,42 @@ package main
 
 import (
 	"context"
+	"crypto/subtle"
+	"errors"
 	"log"
 	"net"
 	"os"
 	"strconv"
+	"time"
 
 	"google.golang.org/grpc"
 	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
 	"google.golang.org/grpc/status"
 
 	"github.com/jedi116/go-trader/internal/broker"
+	"github.com/jedi116/go-trader/internal/calendar"
 	"github.com/jedi116/go-trader/internal/config"
 	"github.com/jedi116/go-trader/internal/database"
+	"github.com/jedi116/go-trader/internal/markethours"
+	"github.com/jedi116/go-trader/internal/notify"
+	"github.com/jedi116/go-trader/internal/orders"
+	"github.com/jedi116/go-trader/internal/portfolio"
+	"github.com/jedi116/go-trader/internal/risk"
 	"github.com/jedi116/go-trader/pkg/models"
 	v1 "github.com/jedi116/go-trader/proto/gotrader/v1"
 )
 
 type tradeServer struct {
 	v1.UnimplementedTradeServiceServer
-	oanda *broker.OandaMT4Client
-	db    *database.Postgres
+	store  database.Store
+	orders *orders.Service
 }
 
 type recServer struct {
 	v1.UnimplementedRecommendationServiceServer
-	db    *database.Postgres
-	oanda *broker.OandaMT4Client
+	store  database.Store
+	orders *orders.Service
 }
 
 type analysisServer struct {
@@ -37,16 +47,26 @@ type analysisServer struct {
 	oanda *broker.OandaMT4Client
 }
 
+type accountServer struct {
+	v1.UnimplementedAccountServiceServer
+	oanda    *broker.OandaMT4Client
+	store    database.Store
+	guardian *risk.Guardian
+}
+
+type adminServer struct {
+	v1.UnimplementedAdminServiceServer
+	guardian *risk.Guardian
+	token    string
+}
+
 func (s *tradeServer) PlaceOrder(ctx context.Context, req *v1.PlaceOrderRequest) (*v1.PlaceOrderResponse, error) {
-	resp, err := s.oanda.PlaceMarketOrder(req.Instrument, req.Units)
+	res, err := s.orders.Submit(ctx, risk.Order{Instrument: req.Instrument, Units: req.Units, StopLoss: req.StopLoss, TakeProfit: req.TakeProfit, Source: "grpc"})
 	if err != nil {
-		return nil, err
-	}
-	if s.db != nil && resp != nil {
-		tr := structToModelTrade(resp.OrderCreateTransaction.ID, req.Instrument, req.Units)
-		_ = s.db.CreateTrade(ctx, &tr)
+		return nil, orderStatus(err)
 	}
-	return &v1.PlaceOrderResponse{Trade: &v1.Trade{Id: resp.OrderCreateTransaction.ID, Instrument: req.Instrument, Units: req.Units}}, nil
+	logWarnings(req.Instrument, res.Warnings)
+	return &v1.PlaceOrderResponse{Trade: &v1.Trade{Id: res.Order.OrderCreateTransaction.ID, Instrument: req.Instrument, Units: req.Units}}, nil
 }
 
 func (s *tradeServer) ListTrades(ctx context.Context, req *v1.ListTradesRequest) (*v1.ListTradesResponse, error) {
@@ -54,7 +74,7 @@ func (s *tradeServer) ListTrades(ctx context.Context, req *v1.ListTradesRequest)
 	if limit == 0 {
 		limit = 200
 	}
-	trs, err := s.db.ListTrades(ctx, limit)
+	trs, err := s.store.ListTrades(ctx, limit)
 	if err != nil {
 		return nil, err
 	}
@@ -68,7 +88,7 @@ func (s *tradeServer) ListTrades(ctx context.Context, req *v1.ListTradesRequest)
 func (s *recServer) CreateRecommendation(ctx context.Context, req *v1.CreateRecommendationRequest) (*v1.CreateRecommendationResponse, error) {
 	// Simplified
 	rec := recReqToModel(req)
-	id, err := s.db.CreateRecommendation(ctx, &rec)
+	id, err := s.store.CreateRecommendation(ctx, &rec)
 	if err != nil {
 		return nil, err
 	}
@@ -76,7 +96,7 @@ func (s *recServer) CreateRecommendation(ctx context.Context, req *v1.CreateReco
 }
 
 func (s *recServer) ListRecommendations(ctx context.Context, req *v1.ListRecommendationsRequest) (*v1.ListRecommendationsResponse, error) {
-	list, err := s.db.ListRecommendations(ctx)
+	list, err := s.store.ListRecommendations(ctx)
 	if err != nil {
 		return nil, err
 	}
@@ -92,30 +112,17 @@ func (s *recServer) ListRecommendations(ctx context.Context, req *v1.ListRecomme
 }
 
 func (s *recServer) AcceptRecommendation(ctx context.Context, req *v1.AcceptRecommendationRequest) (*v1.AcceptRecommendationResponse, error) {
-	list, err := s.db.ListRecommendations(ctx)
+	rec, res, err := s.orders.AcceptRecommendation(ctx, req.Id, "grpc_recommendation")
 	if err != nil {
-		return nil, err
+		return nil, orderStatus(err)
 	}
-	var found *v1.Recommendation
-	var instr string
-	var units float64
-	for _, r := range list {
-		if r.ID == req.Id {
-			instr = r.Instrument
-			units = r.Units
-			found = &v1.Recommendation{Id: r.ID, Instrument: r.Instrument, Units: r.Units}
-			break
-		}
-	}
-	if found == nil {
-		return nil, status.Errorf(codes.NotFound, "not found")
-	}
-	ord, err := s.oanda.PlaceMarketOrder(instr, units)
-	if err != nil {
-		return nil, err
-	}
-	_ = s.db.MarkRecommendationExecuted(ctx, req.Id, ord.OrderCreateTransaction.ID)
-	return &v1.AcceptRecommendationResponse{Trade: &v1.Trade{Id: ord.OrderCreateTransaction.ID, Instrument: instr, Units: units}, Recommendation: found}, nil
+	logWarnings(rec.Instrument, res.Warnings)
+	units := rec.SignedUnits()
+	return &v1.AcceptRecommendationResponse{
+		Trade: &v1.Trade{Id: res.Order.OrderCreateTransaction.ID, Instrument: rec.Instrument, Units: units},
+		Recommendation: &v1.Recommendation{Id: rec.ID, Instrument: rec.Instrument, Units: rec.Units, Rationale: rec.Rationale,
+			StopLoss: rec.StopLoss, TakeProfit: rec.TakeProfit, Source: rec.Source},
+	}, nil
 }
 
 func (s *analysisServer) GetCandles(ctx context.Context, req *v1.GetCandlesRequest) (*v1.GetCandlesResponse, error) {
@@ -130,17 +137,184 @@ func (s *analysisServer) GetCandles(ctx context.Context, req *v1.GetCandlesReque
 	return out, nil
 }
 
+func (s *accountServer) GetAccountSummary(ctx context.Context, _ *v1.GetAccountSummaryRequest) (*v1.AccountSummary, error) {
+	sum, err := s.oanda.GetAccountSummary()
+	if err != nil {
+		return nil, status.Error(codes.Unavailable, err.Error())
+	}
+	realized, n, err := s.store.SumRealizedPL(ctx, s.guardian.DayStart(time.Now()))
+	if err != nil {
+		return nil, err
+	}
+	sum.DailyRealizedPL = &realized
+	sum.DailyClosedTrades = n
+	out := &v1.AccountSummary{
+		AccountId:         sum.AccountID,
+		Currency:          sum.Currency,
+		Balance:           sum.Balance,
+		Nav:               sum.NAV,
+		Equity:            sum.Equity,
+		UnrealizedPl:      sum.UnrealizedPL,
+		MarginUsed:        sum.MarginUsed,
+		MarginAvailable:   sum.MarginAvailable,
+		FreeMargin:        sum.FreeMargin,
+		MarginLevel:       sum.MarginLevel,
+		MarginUsedPercent: sum.MarginUsedPercent,
+		PositionValue:     sum.PositionValue,
+		Leverage:          sum.Leverage,
+		OpenTrades:        int32(sum.OpenTrades),
+		OpenPositions:     int32(sum.OpenPositions),
+		TotalProfit:       sum.TotalProfit,
+		TotalLoss:         sum.TotalLoss,
+		DailyRealizedPl:   sum.DailyRealizedPL,
+		DailyClosedTrades: int32(sum.DailyClosedTrades),
+	}
+	for _, in := range sum.Instruments {
+		out.Instruments = append(out.Instruments, &v1.InstrumentPL{
+			Instrument:   in.Instrument,
+			LongUnits:    in.LongUnits,
+			ShortUnits:   in.ShortUnits,
+			UnrealizedPl: in.UnrealizedPL,
+			MarginUsed:   in.MarginUsed,
+		})
+	}
+	return out, nil
+}
+
+// authorize refuses every call when no admin token is configured.
+func (s *adminServer) authorize(ctx context.Context) error {
+	if s.token == "" {
+		return status.Error(codes.PermissionDenied, "admin calls are disabled; set admin.token")
+	}
+	md, _ := metadata.FromIncomingContext(ctx)
+	for _, v := range md.Get("x-admin-token") {
+		if subtle.ConstantTimeCompare([]byte(v), []byte(s.token)) == 1 {
+			return nil
+		}
+	}
+	return status.Error(codes.Unauthenticated, "admin token required")
+}
+
+func (s *adminServer) GetKillSwitch(ctx context.Context, _ *v1.Empty) (*v1.KillSwitchStatus, error) {
+	if err := s.authorize(ctx); err != nil {
+		return nil, err
+	}
+	st, err := s.guardian.Evaluate(ctx)
+	if err != nil {
+		return nil, status.Error(codes.Unavailable, err.Error())
+	}
+	return killSwitchToProto(st), nil
+}
+
+func (s *adminServer) HaltTrading(ctx context.Context, req *v1.KillSwitchRequest) (*v1.KillSwitchStatus, error) {
+	if err := s.authorize(ctx); err != nil {
+		return nil, err
+	}
+	by := req.By
+	if by == "" {
+		by = "admin"
+	}
+	st, err := s.guardian.Halt(ctx, by, req.Reason)
+	if err != nil {
+		return nil, status.Error(codes.Unavailable, err.Error())
+	}
+	return killSwitchToProto(st), nil
+}
+
+func (s *adminServer) ResetKillSwitch(ctx context.Context, req *v1.KillSwitchRequest) (*v1.KillSwitchStatus, error) {
+	if err := s.authorize(ctx); err != nil {
+		return nil, err
+	}
+	if req.By == "" {
+		return nil, status.Error(codes.InvalidArgument, "by is required")
+	}
+	st, err := s.guardian.Reset(ctx, req.By, req.Reason)
+	if err != nil {
+		return nil, status.Error(codes.Unavailable, err.Error())
+	}
+	return killSwitchToProto(st), nil
+}
+
+func killSwitchToProto(st *risk.GuardianStatus) *v1.KillSwitchStatus {
+	out := &v1.KillSwitchStatus{
+		Halted:        st.Halted,
+		TradingDay:    st.TradingDay.Format("2006-01-02"),
+		Nav:           st.NAV,
+		StartOfDayNav: st.StartOfDayNAV,
+		PeakNav:       st.PeakNAV,
+		DailyPl:       st.DailyPL,
+		DailyLoss:     st.DailyLoss,
+		Drawdown:      st.Drawdown,
+		MaxDailyLoss:  st.MaxDailyLoss,
+		MaxDrawdown:   st.MaxDrawdown,
+	}
+	if st.HaltReason != nil {
+		out.HaltReason = *st.HaltReason
+	}
+	if st.HaltedAt != nil {
+		out.HaltedAt = st.HaltedAt.Format(time.RFC3339)
+	}
+	return out
+}
+
 func main() {
 	cfg, err := config.Load()
 	if err != nil {
 		log.Fatal(err)
 	}
 	oanda := broker.NewOandaMT4Client(os.Getenv("OANDA_API_KEY"), os.Getenv("OANDA_ACCOUNT_ID"), false)
-	db, _ := database.NewPostgres(cfg)
+	hours, err := markethours.FromConfig(cfg.Market)
+	if err != nil {
+		log.Fatalf("market_hours: %v", err)
+	}
+	oanda.MarketHours = hours
+	// gRPC callers are never queued, so anything but "allow" refuses orders while closed.
+	gate := hours
+	if cfg.Market.ClosedOrders == "allow" {
+		gate = nil
+	}
+	store, err := database.OpenOrMemory(cfg)
+	if err != nil {
+		log.Fatalf("[DB] %v", err)
+	}
+	guard, err := calendar.NewGuard(store, calendar.GuardOptions{
+		Mode:      calendar.BlackoutMode(cfg.Calendar.Blackout.Mode),
+		Before:    cfg.Calendar.Blackout.Before,
+		After:     cfg.Calendar.Blackout.After,
+		MinImpact: models.EventImpact(cfg.Calendar.Blackout.MinImpact),
+	})
+	if err != nil {
+		log.Fatalf("calendar: %v", err)
+	}
+
+	notifier, err := notify.FromConfig(cfg.Notify, store)
+	if err != nil {
+		log.Printf("[NOTIFY] %v (notifications disabled)", err)
+	}
+	go notifier.Run(context.Background())
+	pc := cfg.Risk.Portfolio
+	engine := risk.NewEngine(oanda, store, risk.LimitsFromConfig(cfg.Risk)).WithNotifier(notifier).WithCorrelator(portfolio.NewAnalyzer(oanda, store, portfolio.Options{
+		Timeframe:    pc.Timeframe,
+		Lookback:     pc.Lookback,
+		Confidence:   pc.Confidence,
+		Horizon:      pc.Horizon,
+		MinSamples:   pc.MinSamples,
+		MaxStaleBars: pc.MaxStaleBars,
+	}))
+	guardianOpts, err := risk.GuardianOptionsFromConfig(cfg.Risk.Guardian)
+	if err != nil {
+		log.Fatalf("risk.guardian: %v", err)
+	}
+	guardian := risk.NewGuardian(oanda, store, store, guardianOpts).WithNotifier(notifier)
+	go guardian.Run(context.Background(), cfg.Risk.Guardian.CheckInterval)
+
+	svc := orders.New(oanda, store, gate, guard, engine, guardian, notifier)
 
 	s := grpc.NewServer()
-	v1.RegisterTradeServiceServer(s, &tradeServer{oanda: oanda, db: db})
-	v1.RegisterRecommendationServiceServer(s, &recServer{oanda: oanda, db: db})
+	v1.RegisterTradeServiceServer(s, &tradeServer{store: store, orders: svc})
+	v1.RegisterRecommendationServiceServer(s, &recServer{store: store, orders: svc})
+	v1.RegisterAccountServiceServer(s, &accountServer{oanda: oanda, store: store, guardian: guardian})
+	v1.RegisterAdminServiceServer(s, &adminServer{guardian: guardian, token: cfg.Admin.Token})
 	v1.RegisterAnalysisServiceServer(s, &analysisServer{oanda: oanda})
 
 	lis, err := net.Listen("tcp", ":9090")
@@ -153,12 +327,33 @@ func main() {
 	}
 }
 
-func structToModelTrade(id string, instrument string, units float64) models.Trade {
-	dir := "BUY"
-	if units < 0 {
-		dir = "SELL"
+// orderStatus maps a refused order (market closed, kill switch, blackout, risk rejection or a
+// recommendation that is no longer pending) to FailedPrecondition, a check that could not run to Unavailable and an unknown recommendation to
+// NotFound.
+func orderStatus(err error) error {
+	var (
+		ce *markethours.ClosedError
+		he *risk.HaltedError
+		be *calendar.BlackoutError
+		re *risk.RejectionError
+		ue *orders.CheckError
+	)
+	switch {
+	case errors.As(err, &ce), errors.As(err, &he), errors.As(err, &be), errors.As(err, &re), errors.Is(err, orders.ErrNotPending):
+		return status.Error(codes.FailedPrecondition, err.Error())
+	case errors.As(err, &ue):
+		return status.Error(codes.Unavailable, err.Error())
+	case errors.Is(err, orders.ErrNotFound):
+		return status.Error(codes.NotFound, err.Error())
+	}
+	return err
+}
+
+// logWarnings logs warn-mode calendar hits, since the responses have no warnings field.
+func logWarnings(instrument string, events []models.EconomicEvent) {
+	for _, e := range events {
+		log.Printf("[CALENDAR] warning instrument=%s event=%s %s at %s", instrument, e.Currency, e.Title, e.EventTime.Format(time.RFC3339))
 	}
-	return models.Trade{ID: id, Instrument: instrument, Direction: dir, Units: units, Status: models.TradeStatusOpen}
 }
 
 func recReqToModel(req *v1.CreateRecommendationRequest) models.Recommendation {
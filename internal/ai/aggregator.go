@@ -9,6 +9,7 @@ type Aggregator interface {
 	GatherMarketData(ctx context.Context, instruments []string) (*MarketContext, error)
 	GatherNewsData(ctx context.Context, instruments []string) ([]NewsItem, error)
 	GatherHistoricalData(ctx context.Context, instruments []string) (*HistoricalContext, error)
+	GatherEconomicEvents(ctx context.Context, instruments []string) ([]EconomicEvent, error)
 	AssembleContext(market *MarketContext, news []NewsItem, historical *HistoricalContext) *TradingContext
 }
 
@@ -16,14 +17,16 @@ type aggregatorImpl struct {
 	marketFetcher func(ctx context.Context, instruments []string) (*MarketContext, error)
 	newsFetcher   func(ctx context.Context, instruments []string) ([]NewsItem, error)
 	histFetcher   func(ctx context.Context, instruments []string) (*HistoricalContext, error)
+	eventsFetcher func(ctx context.Context, instruments []string) ([]EconomicEvent, error)
 }
 
 func NewAggregator(
 	marketFetcher func(ctx context.Context, instruments []string) (*MarketContext, error),
 	newsFetcher func(ctx context.Context, instruments []string) ([]NewsItem, error),
 	histFetcher func(ctx context.Context, instruments []string) (*HistoricalContext, error),
+	eventsFetcher func(ctx context.Context, instruments []string) ([]EconomicEvent, error),
 ) Aggregator {
-	return &aggregatorImpl{marketFetcher: marketFetcher, newsFetcher: newsFetcher, histFetcher: histFetcher}
+	return &aggregatorImpl{marketFetcher: marketFetcher, newsFetcher: newsFetcher, histFetcher: histFetcher, eventsFetcher: eventsFetcher}
 }
 
 func (a *aggregatorImpl) GatherMarketData(ctx context.Context, instruments []string) (*MarketContext, error) {
@@ -38,11 +41,45 @@ func (a *aggregatorImpl) GatherHistoricalData(ctx context.Context, instruments [
 	return a.histFetcher(ctx, instruments)
 }
 
+// GatherEconomicEvents returns no events when no calendar is wired.
+func (a *aggregatorImpl) GatherEconomicEvents(ctx context.Context, instruments []string) ([]EconomicEvent, error) {
+	if a.eventsFetcher == nil {
+		return nil, nil
+	}
+	return a.eventsFetcher(ctx, instruments)
+}
+
 func (a *aggregatorImpl) AssembleContext(market *MarketContext, news []NewsItem, historical *HistoricalContext) *TradingContext {
 	return &TradingContext{
-		Timestamp:    time.Now(),
-		MarketData:   market,
-		NewsAnalysis: news,
-		Historical:   historical,
+		Timestamp:         time.Now(),
+		MarketData:        market,
+		NewsAnalysis:      news,
+		Historical:        historical,
+		CurrencySentiment: AggregateSentiment(news),
+	}
+}
+
+// AggregateSentiment averages item sentiment per currency, weighted by relevance.
+func AggregateSentiment(news []NewsItem) map[string]CurrencySentiment {
+	out := make(map[string]CurrencySentiment)
+	for _, item := range news {
+		for code, rel := range item.Relevance {
+			if rel <= 0 {
+				continue
+			}
+			cs := out[code]
+			cs.Score += item.Sentiment * rel
+			cs.Weight += rel
+			cs.Articles++
+			out[code] = cs
+		}
+	}
+	for code, cs := range out {
+		cs.Score /= cs.Weight
+		out[code] = cs
+	}
+	if len(out) == 0 {
+		return nil
 	}
+	return out
 }
@@ -0,0 +1,45 @@
+package ai
+
+import (
+	"math"
+	"strings"
+
+	"github.com/jedi116/go-trader/pkg/models"
+)
+
+// pipValuePerUnit approximates the USD value of one pip per unit, as for EUR_USD ($10 per pip
+// per 100k units).
+const pipValuePerUnit = 10.0 / 100000.0
+
+// ApplyBrackets sets the recommendation's stop loss and take profit around mid. The stop is 20
+// pips away, 30 for low and 10 for high risk, and the target twice as far on the other side.
+func ApplyBrackets(rec *Recommendation, mid float64, riskLevel string) {
+	pip := models.PipSize(rec.Instrument)
+	distPips := 20.0
+	switch strings.ToLower(riskLevel) {
+	case "low":
+		distPips = 30
+	case "high":
+		distPips = 10
+	}
+	rr := 2.0
+	sl, tp := mid, mid
+	if strings.ToUpper(rec.Direction) == "BUY" {
+		sl = mid - distPips*pip
+		tp = mid + rr*distPips*pip
+	} else {
+		sl = mid + distPips*pip
+		tp = mid - rr*distPips*pip
+	}
+	rec.StopLoss = &sl
+	rec.TakeProfit = &tp
+}
+
+// RiskUnits sizes a position so a stop slPips away loses riskPercent of nav, at least one unit.
+func RiskUnits(nav, riskPercent, slPips float64) int64 {
+	units := nav * riskPercent / (slPips * pipValuePerUnit)
+	if units < 1 {
+		units = 1
+	}
+	return int64(math.Round(units))
+}
@@ -0,0 +1,158 @@
+package ai
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"log"
+	"sort"
+	"strings"
+	"time"
+)
+
+// AnalysisCache persists gathered context so repeated recommendations can skip upstream calls.
+type AnalysisCache interface {
+	GetMarketAnalysisCache(ctx context.Context, key string) ([]byte, bool, error)
+	InsertMarketAnalysisCache(ctx context.Context, key string, instruments string, analysisData []byte, expiresAt time.Time) error
+	PurgeExpiredMarketAnalysisCache(ctx context.Context) (int64, error)
+}
+
+// CacheOptions describes what the wrapped fetchers request, so cache keys change when they do.
+// Every option that changes the gathered context must be listed here.
+type CacheOptions struct {
+	Granularity string
+	CandleCount int
+	// NewsSources identifies the news providers and feeds, e.g. "brave,rss:https://...".
+	NewsSources string
+	// NewsScorer is the sentiment scorer, "lexicon" or "llm".
+	NewsScorer       string
+	NewsPerCurrency  int
+	NewsMaxAge       time.Duration
+	NewsMinRelevance float64
+	MarketTTL        time.Duration
+	NewsTTL          time.Duration
+}
+
+type cachedAggregator struct {
+	Aggregator
+	cache AnalysisCache
+	opts  CacheOptions
+}
+
+// NewCachedAggregator wraps an Aggregator so market and news context is served from cache until it
+// expires. Replayed requests (see WithAsOf) bypass the cache.
+func NewCachedAggregator(inner Aggregator, cache AnalysisCache, opts CacheOptions) Aggregator {
+	if opts.MarketTTL <= 0 {
+		opts.MarketTTL = 5 * time.Minute
+	}
+	if opts.NewsTTL <= 0 {
+		opts.NewsTTL = 15 * time.Minute
+	}
+	return &cachedAggregator{Aggregator: inner, cache: cache, opts: opts}
+}
+
+func (a *cachedAggregator) GatherMarketData(ctx context.Context, instruments []string) (*MarketContext, error) {
+	key := a.marketKey(instruments)
+	var out MarketContext
+	if a.lookup(ctx, key, &out) {
+		return &out, nil
+	}
+	market, err := a.Aggregator.GatherMarketData(ctx, instruments)
+	if err != nil {
+		return nil, err
+	}
+	a.store(ctx, key, instruments, market, a.opts.MarketTTL)
+	return market, nil
+}
+
+func (a *cachedAggregator) GatherNewsData(ctx context.Context, instruments []string) ([]NewsItem, error) {
+	key := a.newsKey(instruments)
+	var out []NewsItem
+	if a.lookup(ctx, key, &out) {
+		return out, nil
+	}
+	news, err := a.Aggregator.GatherNewsData(ctx, instruments)
+	if err != nil {
+		return nil, err
+	}
+	a.store(ctx, key, instruments, news, a.opts.NewsTTL)
+	return news, nil
+}
+
+// marketKey is order-insensitive in instruments: "market|EUR_USD,GBP_USD|M5|50".
+func (a *cachedAggregator) marketKey(instruments []string) string {
+	return fmt.Sprintf("market|%s|%s|%d", sortedList(instruments), a.opts.Granularity, a.opts.CandleCount)
+}
+
+// newsKey covers the news options and a hash of the sources:
+// "news|EUR_USD|lexicon|5|24h0m0s|0.3|1a2b3c4d".
+func (a *cachedAggregator) newsKey(instruments []string) string {
+	sources := sha256.Sum256([]byte(a.opts.NewsSources))
+	return fmt.Sprintf("news|%s|%s|%d|%s|%g|%s", sortedList(instruments), a.opts.NewsScorer,
+		a.opts.NewsPerCurrency, a.opts.NewsMaxAge, a.opts.NewsMinRelevance, hex.EncodeToString(sources[:4]))
+}
+
+func sortedList(instruments []string) string {
+	sorted := append([]string(nil), instruments...)
+	sort.Strings(sorted)
+	return strings.Join(sorted, ",")
+}
+
+func (a *cachedAggregator) lookup(ctx context.Context, key string, dst interface{}) bool {
+	if _, replay := AsOf(ctx); replay {
+		return false
+	}
+	data, ok, err := a.cache.GetMarketAnalysisCache(ctx, key)
+	if err != nil {
+		log.Printf("[AI] cache lookup error key=%s: %v", key, err)
+		return false
+	}
+	if !ok {
+		return false
+	}
+	if err := json.Unmarshal(data, dst); err != nil {
+		log.Printf("[AI] cache decode error key=%s: %v", key, err)
+		return false
+	}
+	log.Printf("[AI] cache hit key=%s", key)
+	return true
+}
+
+func (a *cachedAggregator) store(ctx context.Context, key string, instruments []string, v interface{}, ttl time.Duration) {
+	if _, replay := AsOf(ctx); replay {
+		return
+	}
+	data, err := json.Marshal(v)
+	if err != nil {
+		return
+	}
+	if err := a.cache.InsertMarketAnalysisCache(ctx, key, strings.Join(instruments, ","), data, time.Now().Add(ttl)); err != nil {
+		log.Printf("[AI] cache store error key=%s: %v", key, err)
+	}
+}
+
+// RunCacheJanitor purges expired cache rows every interval until ctx is done.
+func RunCacheJanitor(ctx context.Context, cache AnalysisCache, interval time.Duration) {
+	if interval <= 0 {
+		interval = time.Hour
+	}
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			n, err := cache.PurgeExpiredMarketAnalysisCache(ctx)
+			if err != nil {
+				log.Printf("[AI] cache janitor error: %v", err)
+				continue
+			}
+			if n > 0 {
+				log.Printf("[AI] cache janitor purged rows=%d", n)
+			}
+		}
+	}
+}
@@ -1,11 +1,14 @@
 package ai
 
 import (
+	"bytes"
 	"context"
 	"encoding/json"
 	"fmt"
+	"io"
 	"net/http"
 	"os"
+	"strings"
 )
 
 type ClaudeClient interface {
@@ -27,6 +30,7 @@ type claudeRequest struct {
 	Model       string      `json:"model"`
 	MaxTokens   int         `json:"max_tokens"`
 	Temperature float64     `json:"temperature"`
+	System      string      `json:"system,omitempty"`
 	Messages    []claudeMsg `json:"messages"`
 }
 
@@ -35,59 +39,173 @@ type claudeMsg struct {
 	Content string `json:"content"`
 }
 
+type claudeResponse struct {
+	Model   string `json:"model"`
+	Content []struct {
+		Type string `json:"type"`
+		Text string `json:"text"`
+	} `json:"content"`
+	Usage struct {
+		InputTokens              int `json:"input_tokens"`
+		OutputTokens             int `json:"output_tokens"`
+		CacheCreationInputTokens int `json:"cache_creation_input_tokens"`
+		CacheReadInputTokens     int `json:"cache_read_input_tokens"`
+	} `json:"usage"`
+}
+
+// claudeAnswer is the JSON object the model is asked to reply with.
+type claudeAnswer struct {
+	Instrument string  `json:"instrument"`
+	Direction  string  `json:"direction"`
+	Confidence float64 `json:"confidence"`
+	Rationale  string  `json:"rationale"`
+}
+
+// defaultModel is used when ANTHROPIC_MODEL is unset.
+const defaultModel = "claude-opus-4-1-20250805"
+
+// RecommendationModel is the model GenerateRecommendation calls, or "" when ANTHROPIC_API_KEY is
+// unset and the fallback heuristic answers instead.
+func RecommendationModel() string {
+	if os.Getenv("ANTHROPIC_API_KEY") == "" {
+		return ""
+	}
+	return getenvDefault("ANTHROPIC_MODEL", defaultModel)
+}
+
+// newsScorerModel is the model the LLM news scorer calls.
+func newsScorerModel() string {
+	return getenvDefault("ANTHROPIC_NEWS_MODEL", getenvDefault("ANTHROPIC_MODEL", defaultModel))
+}
+
+const claudeSystemPrompt = "You are a professional forex trading analyst with 20+ years of experience. " +
+	"Reply with a single JSON object with fields instrument, direction (BUY or SELL), confidence (0..1) and rationale."
+
 func (c *claudeClientImpl) GenerateRecommendation(ctx context.Context, tradingContext *TradingContext, request *RecommendationRequest) (*Recommendation, error) {
+	defaultInstrument := "EUR_USD"
+	if len(request.Instruments) > 0 {
+		defaultInstrument = request.Instruments[0]
+	}
 	apiKey := os.Getenv("ANTHROPIC_API_KEY")
 	if apiKey == "" {
 		// Fallback minimal heuristic
 		return &Recommendation{
-			ID: "fallback",
-			Instrument: func() string {
-				if len(request.Instruments) > 0 {
-					return request.Instruments[0]
-				}
-				return "EUR_USD"
-			}(),
+			ID:          "fallback",
+			Instrument:  defaultInstrument,
 			Direction:   "BUY",
 			Units:       100,
 			Confidence:  0.5,
 			Rationale:   "Fallback heuristic recommendation (no ANTHROPIC_API_KEY)",
 			MarketData:  tradingContext.MarketData,
 			NewsContext: tradingContext.NewsAnalysis,
+			Usage:       &Usage{Model: "fallback"},
 		}, nil
 	}
 
-	// Compose a compact prompt
-	prompt := fmt.Sprintf("Generate a forex trade recommendation given context. Instruments: %v. Risk: %s. Horizon: %s.", request.Instruments, request.RiskLevel, request.TimeHorizon)
+	contextJSON, err := json.Marshal(tradingContext)
+	if err != nil {
+		return nil, fmt.Errorf("marshal trading context: %w", err)
+	}
+	prompt := fmt.Sprintf("Generate a forex trade recommendation given context. Instruments: %v. Risk: %s. Horizon: %s.\nContext: %s",
+		request.Instruments, request.RiskLevel, request.TimeHorizon, contextJSON)
 	reqBody := claudeRequest{
-		Model:       getenvDefault("ANTHROPIC_MODEL", "claude-opus-4-1-20250805"),
+		Model:       getenvDefault("ANTHROPIC_MODEL", defaultModel),
 		MaxTokens:   getenvIntDefault("ANTHROPIC_MAX_TOKENS", 2000),
 		Temperature: getenvFloatDefault("ANTHROPIC_TEMPERATURE", 0.3),
+		System:      claudeSystemPrompt,
 		Messages: []claudeMsg{
-			{Role: "system", Content: "You are a professional forex trading analyst with 20+ years of experience."},
 			{Role: "user", Content: prompt},
 		},
 	}
-
-	// Placeholder: not performing the real HTTP call to Anthropic to keep compile without external dep.
-	// Serialize request to reflect in rationale
-	b, _ := json.Marshal(reqBody)
+	text, usage, err := postMessages(ctx, c.http, apiKey, reqBody)
+	if err != nil {
+		return nil, err
+	}
+	answer, err := parseClaudeAnswer(text)
+	if err != nil {
+		// The tokens were billed even though the answer is unusable.
+		return nil, &UsageError{Usage: usage, Err: err}
+	}
+	if answer.Instrument == "" {
+		answer.Instrument = defaultInstrument
+	}
 	return &Recommendation{
-		ID: "simulated",
-		Instrument: func() string {
-			if len(request.Instruments) > 0 {
-				return request.Instruments[0]
-			}
-			return "EUR_USD"
-		}(),
-		Direction:   "BUY",
+		Instrument:  answer.Instrument,
+		Direction:   strings.ToUpper(answer.Direction),
 		Units:       100,
-		Confidence:  0.7,
-		Rationale:   "Simulated Claude call with payload: " + string(b),
+		Confidence:  answer.Confidence,
+		Rationale:   answer.Rationale,
 		MarketData:  tradingContext.MarketData,
 		NewsContext: tradingContext.NewsAnalysis,
+		Usage:       usage,
 	}, nil
 }
 
+// postMessages calls the Anthropic Messages API and returns the concatenated text blocks and usage.
+func postMessages(ctx context.Context, httpClient *http.Client, apiKey string, reqBody claudeRequest) (string, *Usage, error) {
+	b, err := json.Marshal(reqBody)
+	if err != nil {
+		return "", nil, err
+	}
+	baseURL := getenvDefault("ANTHROPIC_BASE_URL", "https://api.anthropic.com")
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, baseURL+"/v1/messages", bytes.NewReader(b))
+	if err != nil {
+		return "", nil, err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("x-api-key", apiKey)
+	req.Header.Set("anthropic-version", "2023-06-01")
+	resp, err := httpClient.Do(req)
+	if err != nil {
+		return "", nil, err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		body, _ := io.ReadAll(resp.Body)
+		return "", nil, fmt.Errorf("anthropic api status %d: %s", resp.StatusCode, string(body))
+	}
+	var out claudeResponse
+	if err := json.NewDecoder(resp.Body).Decode(&out); err != nil {
+		return "", nil, err
+	}
+	usage := &Usage{
+		Model:                    out.Model,
+		InputTokens:              out.Usage.InputTokens,
+		OutputTokens:             out.Usage.OutputTokens,
+		CacheCreationInputTokens: out.Usage.CacheCreationInputTokens,
+		CacheReadInputTokens:     out.Usage.CacheReadInputTokens,
+	}
+	if usage.Model == "" {
+		usage.Model = reqBody.Model
+	}
+	var text strings.Builder
+	for _, block := range out.Content {
+		if block.Type == "text" {
+			text.WriteString(block.Text)
+		}
+	}
+	return text.String(), usage, nil
+}
+
+// parseClaudeAnswer extracts the outermost JSON object from the model's text reply.
+func parseClaudeAnswer(text string) (*claudeAnswer, error) {
+	start := strings.Index(text, "{")
+	end := strings.LastIndex(text, "}")
+	if start < 0 || end < start {
+		return nil, fmt.Errorf("anthropic reply has no JSON object: %q", text)
+	}
+	var a claudeAnswer
+	if err := json.Unmarshal([]byte(text[start:end+1]), &a); err != nil {
+		return nil, fmt.Errorf("decode anthropic reply: %w", err)
+	}
+	switch strings.ToUpper(a.Direction) {
+	case "BUY", "SELL":
+	default:
+		return nil, fmt.Errorf("anthropic reply has invalid direction %q", a.Direction)
+	}
+	return &a, nil
+}
+
 func getenvDefault(key, def string) string {
 	if v := os.Getenv(key); v != "" {
 		return v
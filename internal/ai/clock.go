@@ -0,0 +1,28 @@
+package ai
+
+import (
+	"context"
+	"time"
+)
+
+type asOfKey struct{}
+
+// WithAsOf runs a request as if it were made at t. Replay aggregators read it to avoid using
+// data from after t, and the trading context is stamped with it.
+func WithAsOf(ctx context.Context, t time.Time) context.Context {
+	return context.WithValue(ctx, asOfKey{}, t)
+}
+
+// AsOf returns the simulated time of a replayed request.
+func AsOf(ctx context.Context) (time.Time, bool) {
+	t, ok := ctx.Value(asOfKey{}).(time.Time)
+	return t, ok
+}
+
+// Now is the simulated time of a replayed request, otherwise the wall clock.
+func Now(ctx context.Context) time.Time {
+	if t, ok := AsOf(ctx); ok {
+		return t
+	}
+	return time.Now()
+}
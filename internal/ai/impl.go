@@ -2,6 +2,7 @@ package ai
 
 import (
 	"context"
+	"log"
 )
 
 type serviceImpl struct {
@@ -27,6 +28,13 @@ func (s *serviceImpl) GenerateRecommendation(ctx context.Context, request *Recom
 		return nil, err
 	}
 	ctxObj := s.agg.AssembleContext(market, news, hist)
+	ctxObj.Timestamp = Now(ctx)
+	// The calendar is advisory context; a failure should not block a recommendation.
+	if events, err := s.agg.GatherEconomicEvents(ctx, request.Instruments); err != nil {
+		log.Printf("[AI] economic events error: %v", err)
+	} else {
+		ctxObj.UpcomingEvents = events
+	}
 	return s.claude.GenerateRecommendation(ctx, ctxObj, request)
 }
 
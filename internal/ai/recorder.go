@@ -0,0 +1,136 @@
+package ai
+
+import (
+	"bufio"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+// ErrNotRecorded is returned by a replay-only Recorder for requests it has no response for.
+var ErrNotRecorded = errors.New("ai: no recorded response")
+
+// Recording is one model response kept in a recorder file, one JSON object per line.
+type Recording struct {
+	Key            string          `json:"key"`
+	AsOf           time.Time       `json:"as_of"`
+	Instruments    []string        `json:"instruments"`
+	Recommendation *Recommendation `json:"recommendation"`
+	RecordedAt     time.Time       `json:"recorded_at"`
+}
+
+// Recorder is a ClaudeClient that answers from recorded responses, so a replay can be rerun
+// without calling the model again. Responses are keyed by the request and its simulated time.
+// With an inner client, requests that were not recorded go to it and its answers are appended
+// to the file; without one they fail with ErrNotRecorded.
+type Recorder struct {
+	inner ClaudeClient
+	mu    sync.Mutex
+	byKey map[string]*Recommendation
+	file  *os.File
+}
+
+// OpenRecorder loads the responses recorded in path. The file is created when inner is set and
+// it does not exist yet.
+func OpenRecorder(path string, inner ClaudeClient) (*Recorder, error) {
+	r := &Recorder{inner: inner, byKey: make(map[string]*Recommendation)}
+	f, err := os.Open(path)
+	switch {
+	case err == nil:
+		sc := bufio.NewScanner(f)
+		sc.Buffer(make([]byte, 0, 64*1024), 16*1024*1024)
+		for line := 1; sc.Scan(); line++ {
+			if strings.TrimSpace(sc.Text()) == "" {
+				continue
+			}
+			var rec Recording
+			if err := json.Unmarshal(sc.Bytes(), &rec); err != nil {
+				f.Close()
+				return nil, fmt.Errorf("ai: %s line %d: %w", path, line, err)
+			}
+			r.byKey[rec.Key] = rec.Recommendation
+		}
+		f.Close()
+		if err := sc.Err(); err != nil {
+			return nil, err
+		}
+	case errors.Is(err, os.ErrNotExist) && inner != nil:
+	default:
+		return nil, err
+	}
+	if inner != nil {
+		if r.file, err = os.OpenFile(path, os.O_CREATE|os.O_APPEND|os.O_WRONLY, 0o644); err != nil {
+			return nil, err
+		}
+	}
+	return r, nil
+}
+
+// Len is the number of recorded responses.
+func (r *Recorder) Len() int {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return len(r.byKey)
+}
+
+// Close closes the recording file.
+func (r *Recorder) Close() error {
+	if r.file == nil {
+		return nil
+	}
+	return r.file.Close()
+}
+
+func (r *Recorder) GenerateRecommendation(ctx context.Context, tradingContext *TradingContext, request *RecommendationRequest) (*Recommendation, error) {
+	asOf := Now(ctx)
+	key := recordingKey(asOf, request)
+	r.mu.Lock()
+	recorded, ok := r.byKey[key]
+	r.mu.Unlock()
+	if ok {
+		out := *recorded
+		// Recorded answers cost nothing to replay.
+		out.Usage = nil
+		out.MarketData, out.NewsContext = tradingContext.MarketData, tradingContext.NewsAnalysis
+		return &out, nil
+	}
+	if r.inner == nil {
+		return nil, fmt.Errorf("%w for %v at %s", ErrNotRecorded, request.Instruments, asOf.Format(time.RFC3339))
+	}
+	rec, err := r.inner.GenerateRecommendation(ctx, tradingContext, request)
+	if err != nil {
+		return nil, err
+	}
+	stored := *rec
+	stored.MarketData, stored.NewsContext = nil, nil
+	line, err := json.Marshal(Recording{Key: key, AsOf: asOf, Instruments: request.Instruments, Recommendation: &stored, RecordedAt: time.Now()})
+	if err != nil {
+		return nil, err
+	}
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.byKey[key] = &stored
+	if _, err := r.file.Write(append(line, '\n')); err != nil {
+		return nil, fmt.Errorf("ai: record response: %w", err)
+	}
+	return rec, nil
+}
+
+// recordingKey hashes the fields that shape the prompt, so a changed request is not answered
+// from an old recording.
+func recordingKey(asOf time.Time, req *RecommendationRequest) string {
+	instruments := append([]string(nil), req.Instruments...)
+	sort.Strings(instruments)
+	h := sha256.New()
+	fmt.Fprintf(h, "%s|%s|%s|%s|%s", asOf.UTC().Format(time.RFC3339), strings.Join(instruments, ","),
+		strings.ToLower(req.RiskLevel), strings.ToLower(req.TimeHorizon), req.Context)
+	return hex.EncodeToString(h.Sum(nil))[:32]
+}
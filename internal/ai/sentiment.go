@@ -0,0 +1,88 @@
+package ai
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/jedi116/go-trader/internal/news"
+)
+
+type claudeNewsScorer struct {
+	http  *http.Client
+	meter *Meter
+}
+
+// NewClaudeNewsScorer returns a news.Scorer that asks the model to rate sentiment and relevance.
+// It requires ANTHROPIC_API_KEY; the news pipeline falls back to the lexicon scorer on any error.
+// With a meter, every call is recorded and counted against the AI budget, and the scorer refuses
+// once the budget is exhausted.
+func NewClaudeNewsScorer(httpClient *http.Client, meter *Meter) news.Scorer {
+	if httpClient == nil {
+		httpClient = http.DefaultClient
+	}
+	return &claudeNewsScorer{http: httpClient, meter: meter}
+}
+
+const newsScorerSystemPrompt = "You rate forex news. Reply with a single JSON object: " +
+	`{"sentiment": -1..1 (bearish..bullish for the currencies mentioned), "relevance": {"<ISO code>": 0..1}}.`
+
+func (s *claudeNewsScorer) Score(ctx context.Context, item news.Article, currencies []string) (news.Score, error) {
+	apiKey := os.Getenv("ANTHROPIC_API_KEY")
+	if apiKey == "" {
+		return news.Score{}, fmt.Errorf("ANTHROPIC_API_KEY not set")
+	}
+	model := newsScorerModel()
+	if s.meter != nil {
+		if err := s.meter.Allow(ctx, model); err != nil {
+			return news.Score{}, err
+		}
+	}
+	reqBody := claudeRequest{
+		Model:       model,
+		MaxTokens:   200,
+		Temperature: 0,
+		System:      newsScorerSystemPrompt,
+		Messages: []claudeMsg{
+			{Role: "user", Content: fmt.Sprintf("Currencies: %s\nTitle: %s\nSnippet: %s", strings.Join(currencies, ","), item.Title, item.Snippet)},
+		},
+	}
+	began := time.Now()
+	text, usage, err := postMessages(ctx, s.http, apiKey, reqBody)
+	if err != nil {
+		return news.Score{}, err
+	}
+	if s.meter != nil {
+		s.meter.Record(ctx, usage, time.Since(began), "")
+	}
+	start := strings.Index(text, "{")
+	end := strings.LastIndex(text, "}")
+	if start < 0 || end < start {
+		return news.Score{}, fmt.Errorf("scorer reply has no JSON object: %q", text)
+	}
+	var score news.Score
+	if err := json.Unmarshal([]byte(text[start:end+1]), &score); err != nil {
+		return news.Score{}, fmt.Errorf("decode scorer reply: %w", err)
+	}
+	score.Sentiment = clamp(score.Sentiment, -1, 1)
+	relevance := make(map[string]float64, len(score.Relevance))
+	for code, v := range score.Relevance {
+		relevance[strings.ToUpper(code)] = clamp(v, 0, 1)
+	}
+	score.Relevance = relevance
+	return score, nil
+}
+
+func clamp(v, lo, hi float64) float64 {
+	if v < lo {
+		return lo
+	}
+	if v > hi {
+		return hi
+	}
+	return v
+}
@@ -27,11 +27,20 @@ type MarketContext struct {
 }
 
 type NewsItem struct {
-	Title     string `json:"title"`
-	Url       string `json:"url"`
-	Snippet   string `json:"snippet"`
-	Source    string `json:"source"`
-	Published string `json:"published"`
+	Title     string             `json:"title"`
+	Url       string             `json:"url"`
+	Snippet   string             `json:"snippet"`
+	Source    string             `json:"source"`
+	Published string             `json:"published"`
+	Sentiment float64            `json:"sentiment"`
+	Relevance map[string]float64 `json:"relevance,omitempty"`
+}
+
+// CurrencySentiment is the relevance-weighted news sentiment for one currency.
+type CurrencySentiment struct {
+	Score    float64 `json:"score"`
+	Articles int     `json:"articles"`
+	Weight   float64 `json:"weight"`
 }
 
 type HistoricalContext struct {
@@ -43,6 +52,19 @@ type TradingContext struct {
 	MarketData   *MarketContext     `json:"market_data"`
 	NewsAnalysis []NewsItem         `json:"news_analysis"`
 	Historical   *HistoricalContext `json:"historical"`
+	// CurrencySentiment is keyed by ISO currency code.
+	CurrencySentiment map[string]CurrencySentiment `json:"currency_sentiment,omitempty"`
+	UpcomingEvents    []EconomicEvent              `json:"upcoming_events,omitempty"`
+}
+
+// EconomicEvent is a scheduled release or decision affecting a currency.
+type EconomicEvent struct {
+	Title    string    `json:"title"`
+	Currency string    `json:"currency"`
+	Impact   string    `json:"impact"`
+	Time     time.Time `json:"time"`
+	Forecast string    `json:"forecast,omitempty"`
+	Previous string    `json:"previous,omitempty"`
 }
 
 type Recommendation struct {
@@ -57,6 +79,7 @@ type Recommendation struct {
 	TimeToLive  time.Time      `json:"time_to_live"`
 	MarketData  *MarketContext `json:"market_data"`
 	NewsContext []NewsItem     `json:"news_context"`
+	Usage       *Usage         `json:"usage,omitempty"`
 }
 
 type RecommendationStatus struct {
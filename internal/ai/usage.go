@@ -0,0 +1,228 @@
+package ai
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"sync"
+	"time"
+
+	"github.com/jedi116/go-trader/internal/config"
+	"github.com/jedi116/go-trader/pkg/models"
+)
+
+// Usage is the token accounting reported by the model provider for a single call.
+type Usage struct {
+	Model                    string `json:"model"`
+	InputTokens              int    `json:"input_tokens"`
+	OutputTokens             int    `json:"output_tokens"`
+	CacheCreationInputTokens int    `json:"cache_creation_input_tokens"`
+	CacheReadInputTokens     int    `json:"cache_read_input_tokens"`
+}
+
+// TotalTokens sums every billed token category.
+func (u Usage) TotalTokens() int {
+	return u.InputTokens + u.OutputTokens + u.CacheCreationInputTokens + u.CacheReadInputTokens
+}
+
+// ModelPrice holds USD prices per million tokens for one model.
+type ModelPrice struct {
+	InputPerMTok      float64 `json:"input_per_mtok"`
+	OutputPerMTok     float64 `json:"output_per_mtok"`
+	CacheWritePerMTok float64 `json:"cache_write_per_mtok"`
+	CacheReadPerMTok  float64 `json:"cache_read_per_mtok"`
+}
+
+// PriceTable maps model names to their prices.
+type PriceTable map[string]ModelPrice
+
+// PriceTableFromConfig converts the configured per-model prices.
+func PriceTableFromConfig(prices map[string]config.AIModelPrice) PriceTable {
+	out := make(PriceTable, len(prices))
+	for model, p := range prices {
+		out[model] = ModelPrice{
+			InputPerMTok:      p.InputPerMTok,
+			OutputPerMTok:     p.OutputPerMTok,
+			CacheWritePerMTok: p.CacheWritePerMTok,
+			CacheReadPerMTok:  p.CacheReadPerMTok,
+		}
+	}
+	return out
+}
+
+// unpricedWarned remembers the models already warned about, so the log is not flooded.
+var unpricedWarned sync.Map
+
+// Cost returns the USD cost of a call. Models missing from the table cost 0 and are logged once,
+// since an unpriced model silently escapes budget enforcement.
+func (p PriceTable) Cost(u Usage) float64 {
+	price, ok := p[u.Model]
+	if !ok {
+		if u.TotalTokens() > 0 {
+			if _, warned := unpricedWarned.LoadOrStore(u.Model, true); !warned {
+				log.Printf("[AI] WARNING: model %q has no entry in ai.pricing; its usage is recorded at 0 USD", u.Model)
+			}
+		}
+		return 0
+	}
+	return (float64(u.InputTokens)*price.InputPerMTok +
+		float64(u.OutputTokens)*price.OutputPerMTok +
+		float64(u.CacheCreationInputTokens)*price.CacheWritePerMTok +
+		float64(u.CacheReadInputTokens)*price.CacheReadPerMTok) / 1_000_000
+}
+
+// Budget caps model spend in USD. A zero limit disables that period.
+type Budget struct {
+	DailyUSD   float64 `json:"daily_usd"`
+	MonthlyUSD float64 `json:"monthly_usd"`
+}
+
+// ErrBudgetExhausted is returned (wrapped in *BudgetError) once a spend limit is reached.
+var ErrBudgetExhausted = errors.New("ai budget exhausted")
+
+// BudgetError describes which period ran out.
+type BudgetError struct {
+	Period   string  `json:"period"`
+	SpentUSD float64 `json:"spent_usd"`
+	LimitUSD float64 `json:"limit_usd"`
+}
+
+func (e *BudgetError) Error() string {
+	return fmt.Sprintf("%s: %s spend %.4f USD of %.4f USD", ErrBudgetExhausted, e.Period, e.SpentUSD, e.LimitUSD)
+}
+
+func (e *BudgetError) Unwrap() error { return ErrBudgetExhausted }
+
+// Check returns a *BudgetError when either period's spend has reached its limit.
+func (b Budget) Check(dailySpent, monthlySpent float64) error {
+	if b.DailyUSD > 0 && dailySpent >= b.DailyUSD {
+		return &BudgetError{Period: "daily", SpentUSD: dailySpent, LimitUSD: b.DailyUSD}
+	}
+	if b.MonthlyUSD > 0 && monthlySpent >= b.MonthlyUSD {
+		return &BudgetError{Period: "monthly", SpentUSD: monthlySpent, LimitUSD: b.MonthlyUSD}
+	}
+	return nil
+}
+
+// Enabled reports whether either period has a limit.
+func (b Budget) Enabled() bool {
+	return b.DailyUSD > 0 || b.MonthlyUSD > 0
+}
+
+// ErrUnpricedModel is returned by Meter.Allow for a model missing from the price table while a
+// budget is set: its spend could not be counted.
+var ErrUnpricedModel = errors.New("ai model has no price entry")
+
+// UsageError is returned when the provider billed a call whose answer could not be used, so the
+// spent tokens can still be recorded. It unwraps to the underlying error.
+type UsageError struct {
+	Usage *Usage
+	Err   error
+}
+
+func (e *UsageError) Error() string { return e.Err.Error() }
+
+func (e *UsageError) Unwrap() error { return e.Err }
+
+// UsageFromError returns the usage carried by a *UsageError in err's chain, or nil.
+func UsageFromError(err error) *Usage {
+	var ue *UsageError
+	if errors.As(err, &ue) {
+		return ue.Usage
+	}
+	return nil
+}
+
+// UsageStore persists usage rows and sums them per model.
+type UsageStore interface {
+	CreateAIUsageLog(ctx context.Context, l *models.AIUsageLog) error
+	SummarizeAIUsage(ctx context.Context, since time.Time) ([]models.AIUsageTotals, error)
+}
+
+// Meter prices and records every model call and enforces the budget against recorded spend.
+type Meter struct {
+	store   UsageStore
+	pricing PriceTable
+	budget  Budget
+}
+
+// NewMeter builds a meter over store.
+func NewMeter(store UsageStore, pricing PriceTable, budget Budget) *Meter {
+	return &Meter{store: store, pricing: pricing, budget: budget}
+}
+
+// Budget returns the configured limits.
+func (m *Meter) Budget() Budget { return m.budget }
+
+// Pricing returns the price table.
+func (m *Meter) Pricing() PriceTable { return m.pricing }
+
+// Allow returns a *BudgetError once a period's spend has reached its limit, and ErrUnpricedModel
+// when a budget is set and model has no price. An empty model skips the price check.
+func (m *Meter) Allow(ctx context.Context, model string) error {
+	if !m.budget.Enabled() {
+		return nil
+	}
+	if _, ok := m.pricing[model]; model != "" && !ok {
+		return fmt.Errorf("%w: %q (add it to ai.pricing or remove ai.budget)", ErrUnpricedModel, model)
+	}
+	daily, monthly, err := m.Spend(ctx, time.Now())
+	if err != nil {
+		return err
+	}
+	return m.budget.Check(daily, monthly)
+}
+
+// Spend returns the USD spent since the start of the current UTC day and month.
+func (m *Meter) Spend(ctx context.Context, now time.Time) (float64, float64, error) {
+	dayStart, monthStart := BudgetPeriodStarts(now)
+	daily, err := m.store.SummarizeAIUsage(ctx, dayStart)
+	if err != nil {
+		return 0, 0, err
+	}
+	monthly, err := m.store.SummarizeAIUsage(ctx, monthStart)
+	if err != nil {
+		return 0, 0, err
+	}
+	return SumUsageCost(daily), SumUsageCost(monthly), nil
+}
+
+// Record writes one usage row. recommendationID may be empty, e.g. for news scoring calls.
+func (m *Meter) Record(ctx context.Context, u *Usage, elapsed time.Duration, recommendationID string) {
+	if u == nil {
+		return
+	}
+	entry := &models.AIUsageLog{
+		PromptTokens:        u.InputTokens,
+		CompletionTokens:    u.OutputTokens,
+		CacheCreationTokens: u.CacheCreationInputTokens,
+		CacheReadTokens:     u.CacheReadInputTokens,
+		TotalTokens:         u.TotalTokens(),
+		ResponseTimeMs:      int(elapsed.Milliseconds()),
+		Model:               u.Model,
+		CostUSD:             m.pricing.Cost(*u),
+	}
+	if recommendationID != "" {
+		entry.RecommendationID = &recommendationID
+	}
+	if err := m.store.CreateAIUsageLog(ctx, entry); err != nil {
+		log.Printf("[AI] usage log error: %v", err)
+	}
+}
+
+// BudgetPeriodStarts returns the start of the current UTC day and month.
+func BudgetPeriodStarts(now time.Time) (time.Time, time.Time) {
+	now = now.UTC()
+	return time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, time.UTC),
+		time.Date(now.Year(), now.Month(), 1, 0, 0, 0, 0, time.UTC)
+}
+
+// SumUsageCost totals the cost over per-model rows.
+func SumUsageCost(totals []models.AIUsageTotals) float64 {
+	var sum float64
+	for _, t := range totals {
+		sum += t.CostUSD
+	}
+	return sum
+}
@@ -0,0 +1,339 @@
+// Package alerts evaluates persisted alert rules against live prices and completed candles.
+package alerts
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"math"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/jedi116/go-trader/internal/broker"
+	"github.com/jedi116/go-trader/internal/indicators"
+	"github.com/jedi116/go-trader/internal/notify"
+	"github.com/jedi116/go-trader/pkg/models"
+)
+
+// Broker is the market data the engine polls.
+type Broker interface {
+	GetPrices(instruments []string) ([]broker.Price, error)
+	GetCandles(instrument, granularity string, count int, from, to *time.Time) (*broker.CandlesResponse, error)
+	GetInstruments() ([]broker.Instrument, error)
+}
+
+// Store holds the rules and their firings; database.Store implements it.
+type Store interface {
+	ListAlertRules(ctx context.Context, activeOnly bool) ([]models.AlertRule, error)
+	RecordAlertFired(ctx context.Context, e *models.AlertEvent, deactivate bool) error
+}
+
+type sample struct {
+	at  time.Time
+	mid float64
+}
+
+type series struct {
+	lastBar time.Time
+	closes  []float64
+}
+
+// Engine evaluates active rules on each Tick. Price, move and spread rules use the current
+// quote; indicator rules are evaluated once per completed candle of their timeframe.
+//
+// Quotes are polled with GetPrices on each Tick rather than read from the price stream, so the
+// engine only sees the market every alerts.interval: a price that crosses a level and comes back
+// between two polls does not fire, crosses compare consecutive polls and percent moves are
+// measured from the polled mids.
+type Engine struct {
+	broker   Broker
+	store    Store
+	notifier notify.Notifier
+	now      func() time.Time
+
+	mu sync.Mutex
+	// prev is the last observed value per rule, for cross conditions.
+	prev map[string]float64
+	// history holds recent mids per instrument for percent-move rules.
+	history map[string][]sample
+	// bars is the last candle each indicator rule was evaluated on.
+	bars map[string]time.Time
+	// candles caches closes per instrument and timeframe until the next bar completes.
+	candles     map[string]*series
+	pips        map[string]float64
+	pipsAt      time.Time
+	maxLookback time.Duration
+}
+
+func NewEngine(b Broker, store Store) *Engine {
+	return &Engine{
+		broker:  b,
+		store:   store,
+		now:     time.Now,
+		prev:    make(map[string]float64),
+		history: make(map[string][]sample),
+		bars:    make(map[string]time.Time),
+		candles: make(map[string]*series),
+	}
+}
+
+// WithNotifier announces each firing.
+func (e *Engine) WithNotifier(n notify.Notifier) *Engine {
+	e.notifier = n
+	return e
+}
+
+// Tick loads the active rules, evaluates them and records any that fire.
+func (e *Engine) Tick(ctx context.Context) {
+	rules, err := e.store.ListAlertRules(ctx, true)
+	if err != nil {
+		log.Printf("[ALERTS] list rules: %v", err)
+		return
+	}
+	if len(rules) == 0 {
+		return
+	}
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	now := e.now()
+	seen := make(map[string]bool)
+	var instruments []string
+	e.maxLookback = 0
+	for _, r := range rules {
+		if !seen[r.Instrument] {
+			seen[r.Instrument] = true
+			instruments = append(instruments, r.Instrument)
+		}
+		if w := time.Duration(r.WindowSeconds) * time.Second; w > e.maxLookback {
+			e.maxLookback = w
+		}
+	}
+	prices, err := e.broker.GetPrices(instruments)
+	if err != nil {
+		log.Printf("[ALERTS] load prices: %v", err)
+		return
+	}
+	quotes := make(map[string]broker.Price, len(prices))
+	for _, p := range prices {
+		quotes[p.Instrument] = p
+		if mid, _, ok := midSpread(p); ok {
+			e.remember(p.Instrument, now, mid)
+		}
+	}
+
+	for i := range rules {
+		r := &rules[i]
+		value, ok := e.observe(r, quotes, now)
+		if !ok {
+			continue
+		}
+		var prev *float64
+		if v, had := e.prev[r.ID]; had {
+			prev = &v
+		}
+		e.prev[r.ID] = value
+		threshold := r.Threshold
+		if r.Kind == models.AlertPercentMove && r.Condition == models.AlertBelow {
+			threshold = -threshold
+		}
+		if !triggered(r.Condition, threshold, value, prev) {
+			continue
+		}
+		if r.LastFiredAt != nil && now.Sub(*r.LastFiredAt) < time.Duration(r.CooldownSeconds)*time.Second {
+			continue
+		}
+		e.fire(ctx, r, value, now)
+	}
+	e.forget(rules)
+}
+
+// observe returns the value a rule compares against its threshold, false when there is
+// nothing new to evaluate.
+func (e *Engine) observe(r *models.AlertRule, quotes map[string]broker.Price, now time.Time) (float64, bool) {
+	if r.Kind == models.AlertIndicator {
+		return e.indicator(r, now)
+	}
+	q, ok := quotes[r.Instrument]
+	if !ok {
+		return 0, false
+	}
+	mid, spread, ok := midSpread(q)
+	if !ok {
+		return 0, false
+	}
+	switch r.Kind {
+	case models.AlertPriceCross:
+		return mid, true
+	case models.AlertSpread:
+		pip, ok := e.pipSize(r.Instrument)
+		if !ok {
+			return 0, false
+		}
+		return spread / pip, true
+	case models.AlertPercentMove:
+		ref, ok := e.reference(r.Instrument, now.Add(-time.Duration(r.WindowSeconds)*time.Second))
+		if !ok || ref <= 0 {
+			return 0, false
+		}
+		return (mid - ref) / ref * 100, true
+	}
+	return 0, false
+}
+
+// indicator returns the indicator value on the latest completed candle, only when that candle
+// has not been evaluated for this rule before.
+func (e *Engine) indicator(r *models.AlertRule, now time.Time) (float64, bool) {
+	tf, period, name := *r.Timeframe, *r.Period, *r.Indicator
+	bar, _ := models.Granularity(tf)
+	key := r.Instrument + "|" + tf
+	s := e.candles[key]
+	need := indicators.Warmup(name, period) + 1
+	// A new candle can only have completed once a full bar has passed since the last one closed.
+	if s == nil || len(s.closes) < need || !now.Before(s.lastBar.Add(2*bar)) {
+		count := need
+		if count < 100 {
+			count = 100
+		}
+		resp, err := e.broker.GetCandles(r.Instrument, tf, count, nil, nil)
+		if err != nil {
+			log.Printf("[ALERTS] candles %s %s: %v", r.Instrument, tf, err)
+			return 0, false
+		}
+		fresh := &series{}
+		for _, c := range resp.Candles {
+			if !c.Complete {
+				continue
+			}
+			v, err := strconv.ParseFloat(c.Mid.Close, 64)
+			if err != nil {
+				continue
+			}
+			fresh.closes = append(fresh.closes, v)
+			fresh.lastBar = c.Time
+		}
+		s = fresh
+		e.candles[key] = s
+	}
+	if len(s.closes) == 0 {
+		return 0, false
+	}
+	if last, ok := e.bars[r.ID]; ok && last.Equal(s.lastBar) {
+		return 0, false
+	}
+	values, err := indicators.Compute(name, s.closes, period)
+	if err != nil {
+		return 0, false
+	}
+	v := indicators.Last(values)
+	if math.IsNaN(v) {
+		return 0, false
+	}
+	e.bars[r.ID] = s.lastBar
+	return v, true
+}
+
+func (e *Engine) fire(ctx context.Context, r *models.AlertRule, value float64, now time.Time) {
+	ev := &models.AlertEvent{RuleID: r.ID, Instrument: r.Instrument, Value: value, Message: message(r, value), FiredAt: now.UTC()}
+	if err := e.store.RecordAlertFired(ctx, ev, !r.Repeat); err != nil {
+		log.Printf("[ALERTS] record %s: %v", r.ID, err)
+		return
+	}
+	log.Printf("[ALERTS] fired rule=%s %s", r.ID, ev.Message)
+	if e.notifier != nil {
+		e.notifier.Notify(ctx, notify.Event{
+			Type:   notify.AlertFired,
+			Time:   ev.FiredAt,
+			Title:  "Alert: " + r.Name,
+			Text:   ev.Message,
+			Fields: map[string]interface{}{"rule_id": r.ID, "instrument": r.Instrument, "kind": string(r.Kind), "value": value, "threshold": r.Threshold},
+		})
+	}
+}
+
+func message(r *models.AlertRule, value float64) string {
+	what := "price"
+	unit := ""
+	switch r.Kind {
+	case models.AlertSpread:
+		what, unit = "spread", " pips"
+	case models.AlertPercentMove:
+		what, unit = fmt.Sprintf("move over %s", time.Duration(r.WindowSeconds)*time.Second), "%"
+	case models.AlertIndicator:
+		what = fmt.Sprintf("%s(%d) %s", *r.Indicator, *r.Period, *r.Timeframe)
+	}
+	return fmt.Sprintf("%s %s %s %g%s (now %.6g%s)", r.Instrument, what, r.Condition, r.Threshold, unit, value, unit)
+}
+
+func (e *Engine) remember(instrument string, at time.Time, mid float64) {
+	h := append(e.history[instrument], sample{at: at, mid: mid})
+	cutoff := at.Add(-e.maxLookback)
+	i := 0
+	for i < len(h)-1 && h[i+1].at.Before(cutoff) {
+		i++
+	}
+	e.history[instrument] = h[i:]
+}
+
+// reference is the oldest mid seen at or after since. Nothing is reported until a second quote
+// has been seen, so a fresh engine does not measure a move from its own first quote.
+func (e *Engine) reference(instrument string, since time.Time) (float64, bool) {
+	h := e.history[instrument]
+	if len(h) < 2 {
+		return 0, false
+	}
+	for _, s := range h {
+		if !s.at.Before(since) {
+			return s.mid, true
+		}
+	}
+	return 0, false
+}
+
+// forget drops state for rules that are no longer active.
+func (e *Engine) forget(active []models.AlertRule) {
+	keep := make(map[string]bool, len(active))
+	for _, r := range active {
+		keep[r.ID] = true
+	}
+	for id := range e.prev {
+		if !keep[id] {
+			delete(e.prev, id)
+		}
+	}
+	for id := range e.bars {
+		if !keep[id] {
+			delete(e.bars, id)
+		}
+	}
+}
+
+func (e *Engine) pipSize(instrument string) (float64, bool) {
+	if e.pips == nil || time.Since(e.pipsAt) > time.Hour {
+		list, err := e.broker.GetInstruments()
+		if err != nil {
+			log.Printf("[ALERTS] load instruments: %v", err)
+		} else {
+			e.pips = make(map[string]float64, len(list))
+			for _, in := range list {
+				e.pips[in.Name] = models.PipFromLocation(in.PipLocation)
+			}
+			e.pipsAt = time.Now()
+		}
+	}
+	p, ok := e.pips[instrument]
+	return p, ok && p > 0
+}
+
+func midSpread(p broker.Price) (float64, float64, bool) {
+	if len(p.Bids) == 0 || len(p.Asks) == 0 {
+		return 0, 0, false
+	}
+	bid, err1 := strconv.ParseFloat(p.Bids[0].Price, 64)
+	ask, err2 := strconv.ParseFloat(p.Asks[0].Price, 64)
+	if err1 != nil || err2 != nil || bid <= 0 || ask <= 0 {
+		return 0, 0, false
+	}
+	return (bid + ask) / 2, ask - bid, true
+}
@@ -0,0 +1,143 @@
+package alerts
+
+import (
+	"context"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/jedi116/go-trader/internal/broker"
+	"github.com/jedi116/go-trader/pkg/models"
+)
+
+// stubBroker quotes EUR_USD one pip either side of mid.
+type stubBroker struct{ mid float64 }
+
+func (b *stubBroker) GetPrices(instruments []string) ([]broker.Price, error) {
+	quote := func(v float64) []broker.Quote { return []broker.Quote{{Price: strconv.FormatFloat(v, 'f', -1, 64)}} }
+	return []broker.Price{{Instrument: "EUR_USD", Bids: quote(b.mid - 0.0001), Asks: quote(b.mid + 0.0001)}}, nil
+}
+
+func (b *stubBroker) GetCandles(instrument, granularity string, count int, from, to *time.Time) (*broker.CandlesResponse, error) {
+	return &broker.CandlesResponse{}, nil
+}
+
+func (b *stubBroker) GetInstruments() ([]broker.Instrument, error) {
+	return []broker.Instrument{{Name: "EUR_USD", PipLocation: -4}}, nil
+}
+
+// stubStore keeps one rule and applies firings to it as the database does.
+type stubStore struct {
+	rule  models.AlertRule
+	fired []float64
+}
+
+func (s *stubStore) ListAlertRules(ctx context.Context, activeOnly bool) ([]models.AlertRule, error) {
+	if activeOnly && !s.rule.Active {
+		return nil, nil
+	}
+	return []models.AlertRule{s.rule}, nil
+}
+
+func (s *stubStore) RecordAlertFired(ctx context.Context, e *models.AlertEvent, deactivate bool) error {
+	s.fired = append(s.fired, e.Value)
+	at := e.FiredAt
+	s.rule.LastFiredAt, s.rule.FireCount = &at, s.rule.FireCount+1
+	if deactivate {
+		s.rule.Active = false
+	}
+	return nil
+}
+
+// poll is one Tick: seconds after the first and the mid quoted.
+type poll struct {
+	at  int
+	mid float64
+}
+
+func TestEngineTick(t *testing.T) {
+	price := func(cond models.AlertCondition, threshold float64, repeat bool, cooldown int) models.AlertRule {
+		return models.AlertRule{Kind: models.AlertPriceCross, Condition: cond, Threshold: threshold, Repeat: repeat, CooldownSeconds: cooldown}
+	}
+	move := func(cond models.AlertCondition, pct float64) models.AlertRule {
+		return models.AlertRule{Kind: models.AlertPercentMove, Condition: cond, Threshold: pct, WindowSeconds: 60, Repeat: true}
+	}
+	tests := []struct {
+		name  string
+		rule  models.AlertRule
+		polls []poll
+		fires []int
+	}{
+		{"above fires on the first poll", price(models.AlertAbove, 1.1, false, 0), []poll{{0, 1.2}, {10, 1.2}}, []int{0}},
+		{"cross above waits for a previous poll", price(models.AlertCrossAbove, 1.1, true, 0), []poll{{0, 1.2}, {10, 1.0}, {20, 1.2}}, []int{2}},
+		{"cross below waits for a previous poll", price(models.AlertCrossBelow, 1.1, true, 0), []poll{{0, 1.0}, {10, 1.2}, {20, 1.0}}, []int{2}},
+		{"a cross between polls is missed", price(models.AlertCrossAbove, 1.1, true, 0), []poll{{0, 1.0}, {10, 1.05}}, nil},
+		{"cooldown spaces repeated firings", price(models.AlertAbove, 1.1, true, 60), []poll{{0, 1.2}, {30, 1.2}, {59, 1.2}, {60, 1.2}, {90, 1.2}}, []int{0, 3}},
+		{"one-shot rule deactivates", price(models.AlertAbove, 1.1, false, 0), []poll{{0, 1.2}, {100, 1.2}}, []int{0}},
+		// 1.1000 to 1.0989 is a 0.1% fall, so BELOW compares the move with -0.05.
+		{"percent move below fires on a fall", move(models.AlertBelow, 0.05), []poll{{0, 1.1}, {30, 1.0989}}, []int{1}},
+		{"percent move below ignores a rise", move(models.AlertBelow, 0.05), []poll{{0, 1.1}, {30, 1.1011}}, nil},
+		// Without the sign flip a 0.01% rise would count as a fall of less than 0.05%.
+		{"percent move below ignores a small rise", move(models.AlertBelow, 0.05), []poll{{0, 1.1}, {30, 1.10011}}, nil},
+		{"percent move below ignores a small fall", move(models.AlertBelow, 0.05), []poll{{0, 1.1}, {30, 1.09989}}, nil},
+		{"percent move above fires on a rise", move(models.AlertAbove, 0.05), []poll{{0, 1.1}, {30, 1.1011}}, []int{1}},
+		{"percent move above ignores a fall", move(models.AlertAbove, 0.05), []poll{{0, 1.1}, {30, 1.0989}}, nil},
+		// After the window the 1.1000 quote is no longer the reference.
+		{"percent move measures within the window", move(models.AlertBelow, 0.05), []poll{{0, 1.1}, {50, 1.099}, {120, 1.0989}}, []int{1}},
+	}
+	start := time.Date(2026, 3, 4, 10, 0, 0, 0, time.UTC)
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rule := tt.rule
+			rule.ID, rule.Instrument, rule.Active = "r1", "EUR_USD", true
+			store := &stubStore{rule: rule}
+			b := &stubBroker{}
+			e := NewEngine(b, store)
+			var now time.Time
+			e.now = func() time.Time { return now }
+			var fires []int
+			for i, p := range tt.polls {
+				now, b.mid = start.Add(time.Duration(p.at)*time.Second), p.mid
+				before := len(store.fired)
+				e.Tick(context.Background())
+				if len(store.fired) > before {
+					fires = append(fires, i)
+				}
+			}
+			if len(fires) != len(tt.fires) {
+				t.Fatalf("fired on polls %v, want %v", fires, tt.fires)
+			}
+			for i := range fires {
+				if fires[i] != tt.fires[i] {
+					t.Fatalf("fired on polls %v, want %v", fires, tt.fires)
+				}
+			}
+		})
+	}
+}
+
+func TestReference(t *testing.T) {
+	start := time.Date(2026, 3, 4, 10, 0, 0, 0, time.UTC)
+	at := func(s int) time.Time { return start.Add(time.Duration(s) * time.Second) }
+	tests := []struct {
+		name    string
+		history []sample
+		since   time.Time
+		want    float64
+		ok      bool
+	}{
+		{"no quotes", nil, at(0), 0, false},
+		{"a single quote is not a reference", []sample{{at(0), 1.1}}, at(0), 0, false},
+		{"oldest quote in the window", []sample{{at(0), 1.1}, {at(30), 1.2}, {at(60), 1.3}}, at(10), 1.2, true},
+		{"a quote at the window start counts", []sample{{at(0), 1.1}, {at(30), 1.2}}, at(0), 1.1, true},
+		{"no quote in the window", []sample{{at(0), 1.1}, {at(30), 1.2}}, at(40), 0, false},
+	}
+	for _, tt := range tests {
+		e := NewEngine(&stubBroker{}, &stubStore{})
+		e.history["EUR_USD"] = tt.history
+		got, ok := e.reference("EUR_USD", tt.since)
+		if got != tt.want || ok != tt.ok {
+			t.Errorf("%s: reference = %v, %t; want %v, %t", tt.name, got, ok, tt.want, tt.ok)
+		}
+	}
+}
@@ -0,0 +1,131 @@
+package alerts
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/jedi116/go-trader/pkg/models"
+)
+
+// ValidationError describes a rule that cannot be stored.
+type ValidationError struct {
+	Field   string
+	Message string
+}
+
+func (e *ValidationError) Error() string {
+	return e.Field + ": " + e.Message
+}
+
+func invalid(field, format string, args ...interface{}) error {
+	return &ValidationError{Field: field, Message: fmt.Sprintf(format, args...)}
+}
+
+// Normalize upper-cases identifiers, fills defaults and validates a rule. Repeating rules
+// without a cooldown get defaultCooldown so a level condition does not fire on every tick.
+func Normalize(r *models.AlertRule, defaultCooldown time.Duration) error {
+	r.Instrument = strings.ToUpper(strings.TrimSpace(r.Instrument))
+	r.Kind = models.AlertKind(strings.ToUpper(string(r.Kind)))
+	r.Condition = models.AlertCondition(strings.ToUpper(string(r.Condition)))
+	if r.Instrument == "" {
+		return invalid("instrument", "required")
+	}
+	if r.CooldownSeconds < 0 {
+		return invalid("cooldown_seconds", "must not be negative")
+	}
+	if r.Repeat && r.CooldownSeconds == 0 {
+		r.CooldownSeconds = int(defaultCooldown / time.Second)
+	}
+	switch r.Condition {
+	case models.AlertAbove, models.AlertBelow, models.AlertCrossAbove, models.AlertCrossBelow:
+	default:
+		return invalid("condition", "must be ABOVE, BELOW, CROSS_ABOVE or CROSS_BELOW")
+	}
+
+	switch r.Kind {
+	case models.AlertPriceCross:
+		if r.Threshold <= 0 {
+			return invalid("threshold", "must be a positive price")
+		}
+	case models.AlertSpread:
+		if r.Threshold <= 0 {
+			return invalid("threshold", "must be a positive number of pips")
+		}
+	case models.AlertPercentMove:
+		if r.Threshold <= 0 {
+			return invalid("threshold", "must be a positive percentage")
+		}
+		if r.Condition != models.AlertAbove && r.Condition != models.AlertBelow {
+			return invalid("condition", "PERCENT_MOVE takes ABOVE (rise) or BELOW (fall)")
+		}
+		if r.WindowSeconds <= 0 {
+			return invalid("window_seconds", "required for PERCENT_MOVE")
+		}
+	case models.AlertIndicator:
+		if r.Indicator == nil {
+			return invalid("indicator", "required for INDICATOR (RSI, SMA or EMA)")
+		}
+		ind := strings.ToUpper(*r.Indicator)
+		if ind != "RSI" && ind != "SMA" && ind != "EMA" {
+			return invalid("indicator", "must be RSI, SMA or EMA")
+		}
+		r.Indicator = &ind
+		if r.Period == nil {
+			p := 14
+			r.Period = &p
+		}
+		if *r.Period < 1 || *r.Period > 500 {
+			return invalid("period", "must be between 1 and 500")
+		}
+		tf := "H1"
+		if r.Timeframe != nil {
+			tf = strings.ToUpper(*r.Timeframe)
+		}
+		if _, ok := models.Granularity(tf); !ok {
+			return invalid("timeframe", "unsupported granularity %q", tf)
+		}
+		r.Timeframe = &tf
+	default:
+		return invalid("kind", "must be PRICE, PERCENT_MOVE, SPREAD or INDICATOR")
+	}
+	if r.Kind != models.AlertIndicator {
+		r.Indicator, r.Period, r.Timeframe = nil, nil, nil
+	}
+	if r.Kind != models.AlertPercentMove {
+		r.WindowSeconds = 0
+	}
+	if r.Name == "" {
+		r.Name = describe(r)
+	}
+	return nil
+}
+
+// describe is the default rule name, e.g. "EUR_USD RSI(14) H1 BELOW 30".
+func describe(r *models.AlertRule) string {
+	switch r.Kind {
+	case models.AlertIndicator:
+		return fmt.Sprintf("%s %s(%d) %s %s %g", r.Instrument, *r.Indicator, *r.Period, *r.Timeframe, r.Condition, r.Threshold)
+	case models.AlertPercentMove:
+		return fmt.Sprintf("%s move %s %g%% in %s", r.Instrument, r.Condition, r.Threshold, time.Duration(r.WindowSeconds)*time.Second)
+	case models.AlertSpread:
+		return fmt.Sprintf("%s spread %s %g pips", r.Instrument, r.Condition, r.Threshold)
+	}
+	return fmt.Sprintf("%s price %s %g", r.Instrument, r.Condition, r.Threshold)
+}
+
+// triggered compares the current observation with the threshold; crosses need the previous
+// observation and never fire on the first one.
+func triggered(cond models.AlertCondition, threshold, value float64, prev *float64) bool {
+	switch cond {
+	case models.AlertAbove:
+		return value > threshold
+	case models.AlertBelow:
+		return value < threshold
+	case models.AlertCrossAbove:
+		return prev != nil && *prev <= threshold && value > threshold
+	case models.AlertCrossBelow:
+		return prev != nil && *prev >= threshold && value < threshold
+	}
+	return false
+}
@@ -0,0 +1,88 @@
+package alerts
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/jedi116/go-trader/pkg/models"
+)
+
+func TestNormalize(t *testing.T) {
+	str := func(s string) *string { return &s }
+	num := func(n int) *int { return &n }
+	tests := []struct {
+		name     string
+		rule     models.AlertRule
+		field    string
+		want     string
+		cooldown int
+	}{
+		{"price", models.AlertRule{Instrument: " eur_usd ", Kind: "price", Condition: "cross_above", Threshold: 1.1}, "", "EUR_USD price CROSS_ABOVE 1.1", 0},
+		{"repeat without cooldown gets the default", models.AlertRule{Instrument: "EUR_USD", Kind: "SPREAD", Condition: "ABOVE", Threshold: 3, Repeat: true}, "",
+			"EUR_USD spread ABOVE 3 pips", 900},
+		{"repeat keeps its cooldown", models.AlertRule{Instrument: "EUR_USD", Kind: "SPREAD", Condition: "ABOVE", Threshold: 3, Repeat: true, CooldownSeconds: 60}, "",
+			"EUR_USD spread ABOVE 3 pips", 60},
+		{"indicator defaults", models.AlertRule{Instrument: "GBP_JPY", Kind: "INDICATOR", Condition: "BELOW", Threshold: 30, Indicator: str("rsi")}, "",
+			"GBP_JPY RSI(14) H1 BELOW 30", 0},
+		{"percent move", models.AlertRule{Instrument: "EUR_USD", Kind: "PERCENT_MOVE", Condition: "BELOW", Threshold: 0.5, WindowSeconds: 3600}, "",
+			"EUR_USD move BELOW 0.5% in 1h0m0s", 0},
+		{"missing instrument", models.AlertRule{Kind: "PRICE", Condition: "ABOVE", Threshold: 1}, "instrument", "", 0},
+		{"negative cooldown", models.AlertRule{Instrument: "EUR_USD", Kind: "PRICE", Condition: "ABOVE", Threshold: 1, CooldownSeconds: -1}, "cooldown_seconds", "", 0},
+		{"unknown condition", models.AlertRule{Instrument: "EUR_USD", Kind: "PRICE", Condition: "EQUAL", Threshold: 1}, "condition", "", 0},
+		{"unknown kind", models.AlertRule{Instrument: "EUR_USD", Kind: "VOLUME", Condition: "ABOVE", Threshold: 1}, "kind", "", 0},
+		{"non-positive price", models.AlertRule{Instrument: "EUR_USD", Kind: "PRICE", Condition: "ABOVE"}, "threshold", "", 0},
+		{"percent move cross", models.AlertRule{Instrument: "EUR_USD", Kind: "PERCENT_MOVE", Condition: "CROSS_ABOVE", Threshold: 1, WindowSeconds: 60}, "condition", "", 0},
+		{"percent move without window", models.AlertRule{Instrument: "EUR_USD", Kind: "PERCENT_MOVE", Condition: "ABOVE", Threshold: 1}, "window_seconds", "", 0},
+		{"indicator without indicator", models.AlertRule{Instrument: "EUR_USD", Kind: "INDICATOR", Condition: "ABOVE", Threshold: 1}, "indicator", "", 0},
+		{"indicator period", models.AlertRule{Instrument: "EUR_USD", Kind: "INDICATOR", Condition: "ABOVE", Threshold: 1, Indicator: str("SMA"), Period: num(0)}, "period", "", 0},
+		{"indicator timeframe", models.AlertRule{Instrument: "EUR_USD", Kind: "INDICATOR", Condition: "ABOVE", Threshold: 1, Indicator: str("EMA"), Timeframe: str("H7")}, "timeframe", "", 0},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r := tt.rule
+			err := Normalize(&r, 15*time.Minute)
+			var ve *ValidationError
+			if tt.field != "" {
+				if !errors.As(err, &ve) || ve.Field != tt.field {
+					t.Fatalf("err = %v, want a %s error", err, tt.field)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			if r.Name != tt.want || r.CooldownSeconds != tt.cooldown {
+				t.Errorf("name %q, cooldown %d; want %q, %d", r.Name, r.CooldownSeconds, tt.want, tt.cooldown)
+			}
+		})
+	}
+}
+
+func TestTriggered(t *testing.T) {
+	f := func(v float64) *float64 { return &v }
+	tests := []struct {
+		name  string
+		cond  models.AlertCondition
+		value float64
+		prev  *float64
+		want  bool
+	}{
+		{"above", models.AlertAbove, 1.2, nil, true},
+		{"not above at the level", models.AlertAbove, 1.1, nil, false},
+		{"below", models.AlertBelow, 1.0, nil, true},
+		{"cross above needs a previous value", models.AlertCrossAbove, 1.2, nil, false},
+		{"cross above", models.AlertCrossAbove, 1.2, f(1.0), true},
+		{"cross above from the level", models.AlertCrossAbove, 1.2, f(1.1), true},
+		{"already above", models.AlertCrossAbove, 1.2, f(1.15), false},
+		{"cross below needs a previous value", models.AlertCrossBelow, 1.0, nil, false},
+		{"cross below", models.AlertCrossBelow, 1.0, f(1.2), true},
+		{"already below", models.AlertCrossBelow, 1.0, f(1.05), false},
+		{"unknown condition", "EQUAL", 1.1, f(1.1), false},
+	}
+	for _, tt := range tests {
+		if got := triggered(tt.cond, 1.1, tt.value, tt.prev); got != tt.want {
+			t.Errorf("%s: triggered = %t, want %t", tt.name, got, tt.want)
+		}
+	}
+}
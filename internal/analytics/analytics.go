@@ -0,0 +1,161 @@
+package analytics
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"strings"
+	"time"
+
+	"github.com/jedi116/go-trader/internal/broker"
+	"github.com/jedi116/go-trader/pkg/models"
+)
+
+// Store reads recorded trades and marks them closed; database.Store implements it.
+type Store interface {
+	ListOpenTradeIDs(ctx context.Context) ([]string, error)
+	CloseTrade(ctx context.Context, oandaTradeID string, exit, pl, swap float64, closedAt time.Time) (bool, error)
+	ListClosedTrades(ctx context.Context, from, to *time.Time) ([]models.Trade, error)
+}
+
+// Broker supplies the outcome of closed trades.
+type Broker interface {
+	GetClosedTrades(count int) ([]broker.Trade, error)
+}
+
+// NAVPoint is the account NAV at the end of a day.
+type NAVPoint struct {
+	Time time.Time `json:"time"`
+	NAV  float64   `json:"nav"`
+}
+
+// NAVHistory supplies account snapshots, oldest first, keeping the last of each resolution
+// interval; database.Store implements it.
+type NAVHistory interface {
+	ListAccountSnapshots(ctx context.Context, accountID string, from, to time.Time, resolution time.Duration, limit int) ([]models.AccountSnapshot, error)
+}
+
+type Options struct {
+	// Location buckets trades by weekday and hour; nil means UTC.
+	Location *time.Location
+}
+
+// Service syncs closed trades from the broker and reports on them.
+type Service struct {
+	store  Store
+	broker Broker
+	nav    NAVHistory
+	opts   Options
+}
+
+// NewService builds a service; broker may be nil, in which case Sync does nothing.
+func NewService(store Store, b Broker, opts Options) *Service {
+	if opts.Location == nil {
+		opts.Location = time.UTC
+	}
+	return &Service{store: store, broker: b, opts: opts}
+}
+
+// WithNAVHistory anchors the equity curve to stored account snapshots and adds the daily NAV
+// to reports.
+func (s *Service) WithNAVHistory(h NAVHistory) *Service {
+	s.nav = h
+	return s
+}
+
+// Sync records the outcome of trades that are open in the store but closed at the broker. It
+// looks at the 500 most recently closed trades, so it should run more often than that many close.
+func (s *Service) Sync(ctx context.Context) (int, error) {
+	if s.broker == nil {
+		return 0, nil
+	}
+	ids, err := s.store.ListOpenTradeIDs(ctx)
+	if err != nil || len(ids) == 0 {
+		return 0, err
+	}
+	open := make(map[string]bool, len(ids))
+	for _, id := range ids {
+		open[id] = true
+	}
+	closed, err := s.broker.GetClosedTrades(500)
+	if err != nil {
+		return 0, err
+	}
+	n := 0
+	for _, t := range closed {
+		if !open[t.ID] || t.CloseTime == nil {
+			continue
+		}
+		ok, err := s.store.CloseTrade(ctx, t.ID, t.AverageClosePrice, t.RealizedPL, t.Financing, *t.CloseTime)
+		if err != nil {
+			return n, err
+		}
+		if ok {
+			n++
+		}
+	}
+	return n, nil
+}
+
+// Tick runs Sync and logs the outcome, for use as a background job.
+func (s *Service) Tick(ctx context.Context) {
+	n, err := s.Sync(ctx)
+	if err != nil {
+		log.Printf("[ANALYTICS] sync closed trades: %v", err)
+		return
+	}
+	if n > 0 {
+		log.Printf("[ANALYTICS] recorded %d closed trades", n)
+	}
+}
+
+// Filter selects the closed trades a report covers. From and To bound the closing time; the
+// string fields match exactly when set, Direction as BUY or SELL.
+type Filter struct {
+	From       *time.Time
+	To         *time.Time
+	Instrument string
+	Source     string
+	Direction  string
+}
+
+func (f Filter) match(t models.Trade) bool {
+	return (f.Instrument == "" || strings.EqualFold(t.Instrument, f.Instrument)) &&
+		(f.Source == "" || strings.EqualFold(t.Source, f.Source)) &&
+		(f.Direction == "" || strings.EqualFold(t.Direction, f.Direction))
+}
+
+// Report computes the statistics, breakdowns and curves over the trades matching f.
+func (s *Service) Report(ctx context.Context, f Filter) (*Report, error) {
+	trades, err := s.store.ListClosedTrades(ctx, f.From, f.To)
+	if err != nil {
+		return nil, err
+	}
+	kept := trades[:0]
+	for _, t := range trades {
+		if f.match(t) {
+			kept = append(kept, t)
+		}
+	}
+	var nav []NAVPoint
+	from, to := time.Time{}, time.Now()
+	if f.From != nil {
+		from = *f.From
+	} else if len(kept) > 0 {
+		from = *kept[0].ClosedAt
+	}
+	if f.To != nil {
+		to = *f.To
+	}
+	if s.nav != nil && !from.IsZero() {
+		// Start a day early so the day before the first close anchors the curve.
+		snaps, err := s.nav.ListAccountSnapshots(ctx, "", from.AddDate(0, 0, -1), to, 24*time.Hour, 10000)
+		if err != nil {
+			return nil, fmt.Errorf("analytics: nav history: %w", err)
+		}
+		for _, sn := range snaps {
+			nav = append(nav, NAVPoint{Time: sn.TakenAt, NAV: sn.NAV})
+		}
+	}
+	return Compute(kept, nav, s.opts.Location), nil
+}
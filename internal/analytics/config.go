@@ -0,0 +1,20 @@
+package analytics
+
+import (
+	"time"
+
+	"github.com/jedi116/go-trader/internal/config"
+)
+
+// OptionsFromConfig converts the analytics config section; an unknown timezone is an error.
+func OptionsFromConfig(cfg config.AnalyticsConfig) (Options, error) {
+	var opts Options
+	if cfg.Timezone != "" {
+		loc, err := time.LoadLocation(cfg.Timezone)
+		if err != nil {
+			return opts, err
+		}
+		opts.Location = loc
+	}
+	return opts, nil
+}
@@ -0,0 +1,279 @@
+package analytics
+
+import (
+	"math"
+	"sort"
+	"strconv"
+	"time"
+
+	"github.com/jedi116/go-trader/pkg/models"
+)
+
+// Stats summarizes a set of closed trades. P&L figures are net of swap and commission, in
+// account currency; a trade that nets exactly zero counts as neither win nor loss.
+type Stats struct {
+	Trades      int     `json:"trades"`
+	Wins        int     `json:"wins"`
+	Losses      int     `json:"losses"`
+	WinRate     float64 `json:"win_rate"`
+	GrossProfit float64 `json:"gross_profit"`
+	GrossLoss   float64 `json:"gross_loss"`
+	NetPL       float64 `json:"net_pl"`
+	AvgWin      float64 `json:"avg_win"`
+	AvgLoss     float64 `json:"avg_loss"`
+	// Expectancy is the mean net P&L per trade.
+	Expectancy float64 `json:"expectancy"`
+	// ProfitFactor is nil when there are no losing trades.
+	ProfitFactor *float64 `json:"profit_factor"`
+}
+
+// Summary is Stats over every trade in a report, plus the figures that depend on their order.
+type Summary struct {
+	Stats
+	Commission           float64 `json:"commission"`
+	Swap                 float64 `json:"swap"`
+	LargestWin           float64 `json:"largest_win"`
+	LargestLoss          float64 `json:"largest_loss"`
+	MaxConsecutiveWins   int     `json:"max_consecutive_wins"`
+	MaxConsecutiveLosses int     `json:"max_consecutive_losses"`
+	// MaxDrawdown is the deepest fall of the equity curve from its peak, in account currency;
+	// MaxDrawdownPct is the same as a fraction of the peak and only set when the curve has a
+	// balance.
+	MaxDrawdown    float64  `json:"max_drawdown"`
+	MaxDrawdownPct *float64 `json:"max_drawdown_pct,omitempty"`
+}
+
+// Group is Stats for the trades sharing one breakdown key.
+type Group struct {
+	Key string `json:"key"`
+	Stats
+}
+
+// Breakdowns split the trades by instrument, direction, source, and the weekday and hour of
+// entry in the service's location.
+type Breakdowns struct {
+	Instrument []Group `json:"instrument"`
+	Direction  []Group `json:"direction"`
+	Source     []Group `json:"source"`
+	Weekday    []Group `json:"weekday"`
+	Hour       []Group `json:"hour"`
+}
+
+// EquityPoint is the account after one closed trade. Balance is only set when NAV snapshots
+// anchor the curve.
+type EquityPoint struct {
+	Time         time.Time `json:"time"`
+	TradeID      string    `json:"trade_id"`
+	PL           float64   `json:"pl"`
+	CumulativePL float64   `json:"cumulative_pl"`
+	Balance      *float64  `json:"balance,omitempty"`
+	Drawdown     float64   `json:"drawdown"`
+	DrawdownPct  *float64  `json:"drawdown_pct,omitempty"`
+	OandaTradeID string    `json:"oanda_trade_id,omitempty"`
+	Instrument   string    `json:"instrument"`
+}
+
+// Report is the outcome of Service.Report.
+type Report struct {
+	Summary    Summary       `json:"summary"`
+	Breakdowns Breakdowns    `json:"breakdowns"`
+	Equity     []EquityPoint `json:"equity"`
+	// NAV holds the daily snapshots over the same period, when a NAV history is configured.
+	NAV []NAVPoint `json:"nav,omitempty"`
+}
+
+// NetPL is the trade's realized P&L plus swap less commission.
+func NetPL(t models.Trade) float64 {
+	pl := 0.0
+	if t.ProfitLoss != nil {
+		pl += *t.ProfitLoss
+	}
+	if t.Swap != nil {
+		pl += *t.Swap
+	}
+	if t.Commission != nil {
+		pl -= *t.Commission
+	}
+	return pl
+}
+
+type accumulator struct {
+	Stats
+}
+
+func (a *accumulator) add(pl float64) {
+	a.Trades++
+	a.NetPL += pl
+	switch {
+	case pl > 0:
+		a.Wins++
+		a.GrossProfit += pl
+	case pl < 0:
+		a.Losses++
+		a.GrossLoss += -pl
+	}
+}
+
+func (a *accumulator) stats() Stats {
+	s := a.Stats
+	if s.Trades > 0 {
+		s.WinRate = float64(s.Wins) / float64(s.Trades)
+		s.Expectancy = s.NetPL / float64(s.Trades)
+	}
+	if s.Wins > 0 {
+		s.AvgWin = s.GrossProfit / float64(s.Wins)
+	}
+	if s.Losses > 0 {
+		s.AvgLoss = -s.GrossLoss / float64(s.Losses)
+		pf := s.GrossProfit / s.GrossLoss
+		s.ProfitFactor = &pf
+	}
+	return s
+}
+
+// grouper accumulates Stats per key and lists them in key order.
+type grouper struct {
+	byKey map[string]*accumulator
+	less  func(a, b string) bool
+}
+
+func newGrouper(less func(a, b string) bool) *grouper {
+	if less == nil {
+		less = func(a, b string) bool { return a < b }
+	}
+	return &grouper{byKey: make(map[string]*accumulator), less: less}
+}
+
+func (g *grouper) add(key string, pl float64) {
+	a, ok := g.byKey[key]
+	if !ok {
+		a = &accumulator{}
+		g.byKey[key] = a
+	}
+	a.add(pl)
+}
+
+func (g *grouper) groups() []Group {
+	out := make([]Group, 0, len(g.byKey))
+	for k, a := range g.byKey {
+		out = append(out, Group{Key: k, Stats: a.stats()})
+	}
+	sort.Slice(out, func(i, j int) bool { return g.less(out[i].Key, out[j].Key) })
+	return out
+}
+
+func hourLess(a, b string) bool {
+	x, _ := strconv.Atoi(a)
+	y, _ := strconv.Atoi(b)
+	return x < y
+}
+
+func weekdayLess(a, b string) bool { return weekdayIndex(a) < weekdayIndex(b) }
+
+// weekdayIndex orders weekdays from Monday.
+func weekdayIndex(name string) int {
+	for d := time.Sunday; d <= time.Saturday; d++ {
+		if d.String() == name {
+			return (int(d) + 6) % 7
+		}
+	}
+	return 7
+}
+
+// Compute builds a report from closed trades in closing order. The equity curve starts from the
+// last NAV snapshot before the first close when nav has one; loc buckets entry times.
+func Compute(trades []models.Trade, nav []NAVPoint, loc *time.Location) *Report {
+	if loc == nil {
+		loc = time.UTC
+	}
+	var total accumulator
+	sum := Summary{}
+	byInstrument, byDirection, bySource := newGrouper(nil), newGrouper(nil), newGrouper(nil)
+	byWeekday, byHour := newGrouper(weekdayLess), newGrouper(hourLess)
+
+	var base *float64
+	if len(trades) > 0 && trades[0].ClosedAt != nil {
+		for _, p := range nav {
+			if p.Time.After(*trades[0].ClosedAt) {
+				break
+			}
+			v := p.NAV
+			base = &v
+		}
+	}
+
+	equity := make([]EquityPoint, 0, len(trades))
+	cum, peak := 0.0, 0.0
+	if base != nil {
+		peak = *base
+	}
+	wins, losses := 0, 0
+	for _, t := range trades {
+		pl := NetPL(t)
+		total.add(pl)
+		if t.Commission != nil {
+			sum.Commission += *t.Commission
+		}
+		if t.Swap != nil {
+			sum.Swap += *t.Swap
+		}
+		sum.LargestWin = math.Max(sum.LargestWin, pl)
+		sum.LargestLoss = math.Min(sum.LargestLoss, pl)
+		switch {
+		case pl > 0:
+			wins, losses = wins+1, 0
+		case pl < 0:
+			wins, losses = 0, losses+1
+		default:
+			wins, losses = 0, 0
+		}
+		sum.MaxConsecutiveWins = max(sum.MaxConsecutiveWins, wins)
+		sum.MaxConsecutiveLosses = max(sum.MaxConsecutiveLosses, losses)
+
+		entry := t.CreatedAt.In(loc)
+		byInstrument.add(t.Instrument, pl)
+		byDirection.add(t.Direction, pl)
+		bySource.add(t.Source, pl)
+		byWeekday.add(entry.Weekday().String(), pl)
+		byHour.add(strconv.Itoa(entry.Hour()), pl)
+
+		cum += pl
+		p := EquityPoint{TradeID: t.ID, PL: pl, CumulativePL: cum, Instrument: t.Instrument}
+		if t.ClosedAt != nil {
+			p.Time = *t.ClosedAt
+		}
+		if t.OandaTradeID != nil {
+			p.OandaTradeID = *t.OandaTradeID
+		}
+		level := cum
+		if base != nil {
+			bal := *base + cum
+			p.Balance, level = &bal, bal
+		}
+		peak = math.Max(peak, level)
+		p.Drawdown = peak - level
+		if base != nil && peak > 0 {
+			pct := p.Drawdown / peak
+			p.DrawdownPct = &pct
+			if sum.MaxDrawdownPct == nil || pct > *sum.MaxDrawdownPct {
+				sum.MaxDrawdownPct = &pct
+			}
+		}
+		sum.MaxDrawdown = math.Max(sum.MaxDrawdown, p.Drawdown)
+		equity = append(equity, p)
+	}
+	sum.Stats = total.stats()
+
+	return &Report{
+		Summary: sum,
+		Breakdowns: Breakdowns{
+			Instrument: byInstrument.groups(),
+			Direction:  byDirection.groups(),
+			Source:     bySource.groups(),
+			Weekday:    byWeekday.groups(),
+			Hour:       byHour.groups(),
+		},
+		Equity: equity,
+		NAV:    nav,
+	}
+}
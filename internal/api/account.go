@@ -0,0 +1,71 @@
+package api
+
+import (
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/jedi116/go-trader/internal/snapshots"
+	"github.com/jedi116/go-trader/pkg/models"
+)
+
+// getAccount returns the typed account summary with the realized P&L of trades closed since the
+// start of the trading day.
+func (s *Server) getAccount(c *gin.Context) {
+	summary, err := s.mt4Client.GetAccountSummary()
+	if err != nil {
+		c.JSON(500, gin.H{"error": err.Error()})
+		return
+	}
+	realized, n, err := s.store.SumRealizedPL(c.Request.Context(), s.guardian.DayStart(time.Now()))
+	if err != nil {
+		c.JSON(500, gin.H{"error": err.Error()})
+		return
+	}
+	summary.DailyRealizedPL = &realized
+	summary.DailyClosedTrades = n
+	c.JSON(200, summary)
+}
+
+// listAccountSnapshots returns account snapshots between ?from and ?to (RFC3339, default the
+// last 7 days). ?resolution keeps the last snapshot of each interval ("1h", "1d", "1w"; default
+// raw) and ?limit caps the rows, 1000 by default and 10000 at most.
+func (s *Server) listAccountSnapshots(c *gin.Context) {
+	to := time.Now().UTC()
+	from := to.Add(-7 * 24 * time.Hour)
+	if v := c.Query("from"); v != "" {
+		t, err := time.Parse(time.RFC3339, v)
+		if err != nil {
+			c.JSON(400, gin.H{"error": "invalid from"})
+			return
+		}
+		from = t
+	}
+	if v := c.Query("to"); v != "" {
+		t, err := time.Parse(time.RFC3339, v)
+		if err != nil {
+			c.JSON(400, gin.H{"error": "invalid to"})
+			return
+		}
+		to = t
+	}
+	resolution, err := snapshots.ParseResolution(c.Query("resolution"))
+	if err != nil {
+		c.JSON(400, gin.H{"error": err.Error()})
+		return
+	}
+	limit, _ := strconv.Atoi(c.Query("limit"))
+	snaps, err := s.store.ListAccountSnapshots(c.Request.Context(), c.Query("account_id"), from, to, resolution, limit)
+	if err != nil {
+		c.JSON(500, gin.H{"error": err.Error()})
+		return
+	}
+	if snaps == nil {
+		snaps = []models.AccountSnapshot{}
+	}
+	res := "raw"
+	if resolution > 0 {
+		res = c.Query("resolution")
+	}
+	c.JSON(200, gin.H{"from": from, "to": to, "resolution": res, "snapshots": snaps})
+}
@@ -0,0 +1,95 @@
+package api
+
+import (
+	"crypto/subtle"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	"github.com/jedi116/go-trader/internal/notify"
+	"github.com/jedi116/go-trader/pkg/models"
+)
+
+// requireAdmin rejects the request unless it carries the configured admin token. With no token
+// configured the admin routes are refused outright rather than left open.
+func (s *Server) requireAdmin(c *gin.Context) {
+	token := s.config.Admin.Token
+	if token == "" {
+		c.AbortWithStatusJSON(403, gin.H{"error": "admin routes are disabled; set admin.token"})
+		return
+	}
+	if subtle.ConstantTimeCompare([]byte(c.GetHeader("X-Admin-Token")), []byte(token)) != 1 {
+		c.AbortWithStatusJSON(401, gin.H{"error": "admin token required"})
+		return
+	}
+	c.Next()
+}
+
+func (s *Server) getKillSwitch(c *gin.Context) {
+	st, err := s.guardian.Evaluate(c.Request.Context())
+	if err != nil {
+		c.JSON(503, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(200, st)
+}
+
+type killSwitchRequest struct {
+	By     string `json:"by"`
+	Reason string `json:"reason"`
+}
+
+func (s *Server) haltTrading(c *gin.Context) {
+	var req killSwitchRequest
+	_ = c.ShouldBindJSON(&req)
+	if req.By == "" {
+		req.By = "admin"
+	}
+	st, err := s.guardian.Halt(c.Request.Context(), req.By, req.Reason)
+	if err != nil {
+		c.JSON(503, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(200, st)
+}
+
+func (s *Server) resetKillSwitch(c *gin.Context) {
+	var req killSwitchRequest
+	if err := c.ShouldBindJSON(&req); err != nil || req.By == "" {
+		c.JSON(400, gin.H{"error": "by is required"})
+		return
+	}
+	st, err := s.guardian.Reset(c.Request.Context(), req.By, req.Reason)
+	if err != nil {
+		c.JSON(503, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(200, st)
+}
+
+// listNotifications returns recent deliveries, optionally only ?status=FAILED.
+func (s *Server) listNotifications(c *gin.Context) {
+	list, err := s.store.ListNotificationDeliveries(c.Request.Context(), models.NotificationStatus(strings.ToUpper(c.Query("status"))), 100)
+	if err != nil {
+		c.JSON(500, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(200, list)
+}
+
+// testNotification sends a "test" event synchronously and reports the outcome per channel.
+func (s *Server) testNotification(c *gin.Context) {
+	if s.notifier == nil {
+		c.JSON(503, gin.H{"error": "notifications not configured"})
+		return
+	}
+	var req struct {
+		Text string `json:"text"`
+	}
+	_ = c.ShouldBindJSON(&req)
+	results := s.notifier.Deliver(c.Request.Context(), notify.Event{Type: notify.Test, Title: "Test notification", Text: req.Text})
+	if len(results) == 0 {
+		c.JSON(422, gin.H{"error": `no route matches event type "test"`})
+		return
+	}
+	c.JSON(200, gin.H{"results": results})
+}
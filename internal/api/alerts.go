@@ -0,0 +1,105 @@
+package api
+
+import (
+	"errors"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+	"github.com/jedi116/go-trader/internal/alerts"
+	"github.com/jedi116/go-trader/pkg/models"
+)
+
+// bindAlertRule decodes and validates a rule body; it writes a 400 and returns false on failure.
+func (s *Server) bindAlertRule(c *gin.Context, r *models.AlertRule) bool {
+	if err := c.ShouldBindJSON(r); err != nil {
+		c.JSON(400, gin.H{"error": "invalid request"})
+		return false
+	}
+	if err := alerts.Normalize(r, s.config.Alerts.DefaultCooldown); err != nil {
+		var ve *alerts.ValidationError
+		if errors.As(err, &ve) {
+			c.JSON(400, gin.H{"error": ve.Message, "field": ve.Field})
+			return false
+		}
+		c.JSON(400, gin.H{"error": err.Error()})
+		return false
+	}
+	return true
+}
+
+func (s *Server) listAlerts(c *gin.Context) {
+	list, err := s.store.ListAlertRules(c.Request.Context(), c.Query("active") == "true")
+	if err != nil {
+		c.JSON(500, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(200, list)
+}
+
+func (s *Server) createAlert(c *gin.Context) {
+	r := models.AlertRule{Active: true}
+	if !s.bindAlertRule(c, &r) {
+		return
+	}
+	if err := s.store.CreateAlertRule(c.Request.Context(), &r); err != nil {
+		c.JSON(500, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(201, r)
+}
+
+func (s *Server) getAlert(c *gin.Context) {
+	r, err := s.store.GetAlertRule(c.Request.Context(), c.Param("id"))
+	if err != nil {
+		c.JSON(500, gin.H{"error": err.Error()})
+		return
+	}
+	if r == nil {
+		c.JSON(404, gin.H{"error": "not found"})
+		return
+	}
+	c.JSON(200, r)
+}
+
+// updateAlert replaces a rule; send "active": true to re-arm a one-shot rule that has fired.
+func (s *Server) updateAlert(c *gin.Context) {
+	r := models.AlertRule{Active: true}
+	if !s.bindAlertRule(c, &r) {
+		return
+	}
+	r.ID = c.Param("id")
+	ok, err := s.store.UpdateAlertRule(c.Request.Context(), &r)
+	if err != nil {
+		c.JSON(500, gin.H{"error": err.Error()})
+		return
+	}
+	if !ok {
+		c.JSON(404, gin.H{"error": "not found"})
+		return
+	}
+	c.JSON(200, r)
+}
+
+func (s *Server) deleteAlert(c *gin.Context) {
+	ok, err := s.store.SoftDeleteAlertRule(c.Request.Context(), c.Param("id"))
+	if err != nil {
+		c.JSON(500, gin.H{"error": err.Error()})
+		return
+	}
+	if !ok {
+		c.JSON(404, gin.H{"error": "not found"})
+		return
+	}
+	c.JSON(200, gin.H{"status": "deleted"})
+}
+
+// listAlertEvents returns the firings of one rule, newest first, up to ?limit (default 100).
+func (s *Server) listAlertEvents(c *gin.Context) {
+	limit, _ := strconv.Atoi(c.Query("limit"))
+	list, err := s.store.ListAlertEvents(c.Request.Context(), c.Param("id"), limit)
+	if err != nil {
+		c.JSON(500, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(200, list)
+}
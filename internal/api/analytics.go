@@ -0,0 +1,99 @@
+package api
+
+import (
+	"encoding/csv"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/jedi116/go-trader/internal/analytics"
+)
+
+// analyticsReport builds the report for ?from and ?to (RFC3339, bounding the closing time),
+// ?instrument, ?source and ?direction. When it returns nil the response has been written.
+func (s *Server) analyticsReport(c *gin.Context) *analytics.Report {
+	f := analytics.Filter{
+		Instrument: strings.ToUpper(c.Query("instrument")),
+		Source:     strings.ToLower(c.Query("source")),
+		Direction:  strings.ToUpper(c.Query("direction")),
+	}
+	if f.Direction != "" && f.Direction != "BUY" && f.Direction != "SELL" {
+		c.JSON(400, gin.H{"error": "direction must be BUY or SELL"})
+		return nil
+	}
+	for _, p := range []struct {
+		name string
+		dst  **time.Time
+	}{{"from", &f.From}, {"to", &f.To}} {
+		if v := c.Query(p.name); v != "" {
+			t, err := time.Parse(time.RFC3339, v)
+			if err != nil {
+				c.JSON(400, gin.H{"error": "invalid " + p.name})
+				return nil
+			}
+			*p.dst = &t
+		}
+	}
+	rep, err := s.analytics.Report(c.Request.Context(), f)
+	if err != nil {
+		c.JSON(500, gin.H{"error": err.Error()})
+		return nil
+	}
+	return rep
+}
+
+// getAnalytics reports the performance statistics and breakdowns of closed trades.
+func (s *Server) getAnalytics(c *gin.Context) {
+	rep := s.analyticsReport(c)
+	if rep == nil {
+		return
+	}
+	c.JSON(200, gin.H{"summary": rep.Summary, "breakdowns": rep.Breakdowns})
+}
+
+// getAnalyticsEquity returns the equity and drawdown series built from closed trades, with the
+// daily NAV snapshots when available; ?format=csv downloads the trade series.
+func (s *Server) getAnalyticsEquity(c *gin.Context) {
+	rep := s.analyticsReport(c)
+	if rep == nil {
+		return
+	}
+	if c.Query("format") == "csv" {
+		csvAttachment(c, "equity.csv")
+		cw := csv.NewWriter(c.Writer)
+		_ = cw.Write([]string{"time", "trade_id", "instrument", "pl", "cumulative_pl", "balance", "drawdown", "drawdown_pct"})
+		for _, p := range rep.Equity {
+			bal, pct := "", ""
+			if p.Balance != nil {
+				bal = strconv.FormatFloat(*p.Balance, 'f', 2, 64)
+			}
+			if p.DrawdownPct != nil {
+				pct = strconv.FormatFloat(*p.DrawdownPct, 'f', -1, 64)
+			}
+			_ = cw.Write([]string{p.Time.Format(time.RFC3339), p.TradeID, p.Instrument,
+				strconv.FormatFloat(p.PL, 'f', 2, 64), strconv.FormatFloat(p.CumulativePL, 'f', 2, 64),
+				bal, strconv.FormatFloat(p.Drawdown, 'f', 2, 64), pct})
+		}
+		cw.Flush()
+		return
+	}
+	out := gin.H{"equity": rep.Equity, "max_drawdown": rep.Summary.MaxDrawdown}
+	if rep.Summary.MaxDrawdownPct != nil {
+		out["max_drawdown_pct"] = *rep.Summary.MaxDrawdownPct
+	}
+	if rep.NAV != nil {
+		out["nav"] = rep.NAV
+	}
+	c.JSON(200, out)
+}
+
+// syncAnalytics records trades closed at OANDA without waiting for the background sync.
+func (s *Server) syncAnalytics(c *gin.Context) {
+	n, err := s.analytics.Sync(c.Request.Context())
+	if err != nil {
+		c.JSON(502, gin.H{"error": err.Error(), "closed": n})
+		return
+	}
+	c.JSON(200, gin.H{"closed": n})
+}
@@ -0,0 +1,153 @@
+package api
+
+import (
+	"errors"
+	"slices"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	"github.com/jedi116/go-trader/internal/backtest"
+	"github.com/jedi116/go-trader/internal/strategy"
+	"github.com/jedi116/go-trader/internal/ticks"
+	"github.com/jedi116/go-trader/pkg/models"
+)
+
+type backtestRequest struct {
+	Strategy    string          `json:"strategy"`
+	Params      strategy.Params `json:"params"`
+	Instruments []string        `json:"instruments"`
+	Timeframe   string          `json:"timeframe"`
+	From        string          `json:"from"`
+	To          string          `json:"to"`
+	// Data is "candles" (default) to replay market_data or "ticks" to build M1 to H1 candles
+	// from recorded ticks, with their real bid and ask.
+	Data string `json:"data"`
+	// The rest override the backtest section of config.
+	InitialBalance *float64        `json:"initial_balance"`
+	Currency       string          `json:"currency"`
+	Costs          *backtest.Costs `json:"costs"`
+	Intrabar       string          `json:"intrabar"`
+	// Equity set to false leaves the equity curve out of the response.
+	Equity *bool `json:"equity"`
+}
+
+// runBacktest replays stored candles through a registered strategy type and returns the result.
+func (s *Server) runBacktest(c *gin.Context) {
+	var req backtestRequest
+	if err := c.BindJSON(&req); err != nil {
+		c.JSON(400, gin.H{"error": "invalid request"})
+		return
+	}
+	data, opts, ok := s.loadBacktest(c, &req)
+	if !ok {
+		return
+	}
+	strat, err := strategy.New(req.Strategy, req.Params)
+	if err != nil {
+		c.JSON(400, gin.H{"error": err.Error(), "types": strategy.Types()})
+		return
+	}
+	res, err := backtest.Run(data, strat, opts)
+	if err != nil {
+		c.JSON(400, gin.H{"error": err.Error()})
+		return
+	}
+	if req.Equity != nil && !*req.Equity {
+		res.Equity = nil
+	}
+	c.JSON(200, res)
+}
+
+// loadBacktest validates req, normalizing it in place, and loads its candles. It writes the error
+// response and reports false when the request cannot run.
+func (s *Server) loadBacktest(c *gin.Context, req *backtestRequest) (backtest.Data, backtest.Options, bool) {
+	var data backtest.Data
+	var opts backtest.Options
+	req.Timeframe = strings.ToUpper(req.Timeframe)
+	if req.Timeframe == "" {
+		req.Timeframe = "H1"
+	}
+	if _, ok := models.Granularity(req.Timeframe); !ok {
+		c.JSON(400, gin.H{"error": "unsupported timeframe " + req.Timeframe})
+		return data, opts, false
+	}
+	var store backtest.Store = s.store
+	switch req.Data {
+	case "", "candles":
+	case "ticks":
+		if _, err := ticks.Timeframe(req.Timeframe); err != nil {
+			c.JSON(400, gin.H{"error": err.Error()})
+			return data, opts, false
+		}
+		store = ticks.CandleStore{Ticks: s.store}
+	default:
+		c.JSON(400, gin.H{"error": "data must be candles or ticks"})
+		return data, opts, false
+	}
+	var instruments []string
+	for _, inst := range req.Instruments {
+		if inst = strings.ToUpper(strings.TrimSpace(inst)); inst != "" {
+			instruments = append(instruments, inst)
+		}
+	}
+	if len(instruments) == 0 {
+		c.JSON(400, gin.H{"error": "instruments are required"})
+		return data, opts, false
+	}
+	req.Instruments = instruments
+	from, err := backtest.ParseTime(req.From)
+	if err != nil {
+		c.JSON(400, gin.H{"error": "from: " + err.Error()})
+		return data, opts, false
+	}
+	to, err := backtest.ParseTime(req.To)
+	if err != nil {
+		c.JSON(400, gin.H{"error": "to: " + err.Error()})
+		return data, opts, false
+	}
+	if !to.After(from) {
+		c.JSON(400, gin.H{"error": "to must be after from"})
+		return data, opts, false
+	}
+	if req.Params == nil {
+		req.Params = strategy.Params{}
+	}
+	if !slices.Contains(strategy.Types(), req.Strategy) {
+		c.JSON(400, gin.H{"error": "unknown strategy type " + req.Strategy, "types": strategy.Types()})
+		return data, opts, false
+	}
+
+	opts = backtest.OptionsFromConfig(s.config.Backtest)
+	opts.Name, opts.Params = req.Strategy, req.Params
+	if req.InitialBalance != nil {
+		opts.InitialBalance = *req.InitialBalance
+	}
+	if req.Currency != "" {
+		opts.Currency = req.Currency
+	}
+	if req.Costs != nil {
+		opts.Costs = *req.Costs
+	}
+	if req.Intrabar != "" {
+		opts.Intrabar = req.Intrabar
+	}
+
+	data, err = backtest.Load(c.Request.Context(), store, instruments, req.Timeframe, from, to)
+	if errors.Is(err, backtest.ErrNoData) {
+		c.JSON(422, gin.H{"error": err.Error()})
+		return data, opts, false
+	}
+	if err != nil {
+		c.JSON(500, gin.H{"error": err.Error()})
+		return data, opts, false
+	}
+	maxBars := s.config.Backtest.MaxBars
+	if maxBars <= 0 {
+		maxBars = backtest.DefaultMaxBars
+	}
+	if data.Len() > maxBars {
+		c.JSON(422, gin.H{"error": "too many candles for one run; narrow the range or use cmd/backtest", "bars": data.Len(), "max_bars": maxBars})
+		return data, opts, false
+	}
+	return data, opts, true
+}
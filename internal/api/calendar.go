@@ -0,0 +1,75 @@
+package api
+
+import (
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/jedi116/go-trader/internal/calendar"
+	"github.com/jedi116/go-trader/pkg/models"
+)
+
+// listCalendar returns events between ?from and ?to (RFC3339, default now..+7d),
+// optionally filtered by ?currency=USD,EUR and ?min_impact=HIGH.
+func (s *Server) listCalendar(c *gin.Context) {
+	from := time.Now().UTC()
+	to := from.Add(7 * 24 * time.Hour)
+	if v := c.Query("from"); v != "" {
+		t, err := time.Parse(time.RFC3339, v)
+		if err != nil {
+			c.JSON(400, gin.H{"error": "invalid from"})
+			return
+		}
+		from = t
+	}
+	if v := c.Query("to"); v != "" {
+		t, err := time.Parse(time.RFC3339, v)
+		if err != nil {
+			c.JSON(400, gin.H{"error": "invalid to"})
+			return
+		}
+		to = t
+	}
+	var currencies []string
+	if v := c.Query("currency"); v != "" {
+		for _, cur := range strings.Split(v, ",") {
+			currencies = append(currencies, strings.ToUpper(strings.TrimSpace(cur)))
+		}
+	}
+	minImpact := models.EventImpactLow
+	if v := c.Query("min_impact"); v != "" {
+		impact, err := calendar.ParseImpact(v)
+		if err != nil {
+			c.JSON(400, gin.H{"error": err.Error()})
+			return
+		}
+		minImpact = impact
+	}
+	events, err := s.store.ListEconomicEvents(c.Request.Context(), from, to, currencies)
+	if err != nil {
+		c.JSON(500, gin.H{"error": err.Error()})
+		return
+	}
+	out := make([]models.EconomicEvent, 0, len(events))
+	for _, e := range events {
+		if e.Impact.Rank() >= minImpact.Rank() {
+			out = append(out, e)
+		}
+	}
+	c.JSON(200, out)
+}
+
+// importCalendar ingests a calendar file sent as the request body; ?format=csv|json|ics.
+func (s *Server) importCalendar(c *gin.Context) {
+	format := calendar.Format(strings.ToLower(c.Query("format")))
+	events, err := calendar.Parse(c.Request.Body, format, "upload")
+	if err != nil {
+		c.JSON(400, gin.H{"error": err.Error()})
+		return
+	}
+	if err := s.store.UpsertEconomicEvents(c.Request.Context(), events); err != nil {
+		c.JSON(500, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(200, gin.H{"imported": len(events)})
+}
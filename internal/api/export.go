@@ -0,0 +1,68 @@
+package api
+
+import (
+	"log"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/jedi116/go-trader/internal/export"
+)
+
+// exportDataset streams trades, ai_recommendations, audit_logs, market_data or ticks as CSV,
+// NDJSON or Parquet (?format). ?from and ?to (RFC3339) bound the time column; ?instrument, ?timeframe,
+// ?entity and ?limit narrow it further and ?tz picks the IANA zone times are written in, UTC by
+// default.
+func (s *Server) exportDataset(c *gin.Context) {
+	name := c.Param("dataset")
+	format, err := export.ParseFormat(c.Query("format"))
+	if err != nil {
+		c.JSON(400, gin.H{"error": err.Error()})
+		return
+	}
+	f := export.Filter{
+		Instrument: strings.ToUpper(c.Query("instrument")),
+		Timeframe:  strings.ToUpper(c.Query("timeframe")),
+		Entity:     c.Query("entity"),
+	}
+	for _, p := range []struct {
+		name string
+		dst  **time.Time
+	}{{"from", &f.From}, {"to", &f.To}} {
+		if v := c.Query(p.name); v != "" {
+			t, err := time.Parse(time.RFC3339, v)
+			if err != nil {
+				c.JSON(400, gin.H{"error": "invalid " + p.name})
+				return
+			}
+			*p.dst = &t
+		}
+	}
+	if v := c.Query("limit"); v != "" {
+		if f.Limit, err = strconv.Atoi(v); err != nil || f.Limit < 0 {
+			c.JSON(400, gin.H{"error": "invalid limit"})
+			return
+		}
+	}
+	opts := export.Options{Format: format}
+	if v := c.Query("tz"); v != "" {
+		if opts.Location, err = time.LoadLocation(v); err != nil {
+			c.JSON(400, gin.H{"error": "invalid tz"})
+			return
+		}
+	}
+	if err := export.Check(name, f); err != nil {
+		c.JSON(400, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.Header("Content-Type", format.ContentType())
+	c.Header("Content-Disposition", `attachment; filename="`+name+"."+string(format)+`"`)
+	c.Status(200)
+	n, err := export.Export(c.Request.Context(), s.store, c.Writer, name, f, opts)
+	if err != nil {
+		// The response is already streaming; a short file is all the client can be told.
+		log.Printf("[EXPORT] %s after %d rows: %v", name, n, err)
+	}
+}
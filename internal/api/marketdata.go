@@ -0,0 +1,57 @@
+package api
+
+import (
+	"errors"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/jedi116/go-trader/internal/marketdata"
+)
+
+// importMarketData upserts candles from the request body into market_data. ?layout is auto,
+// metatrader, generic or ndjson; ?instrument and ?timeframe cover files without those columns;
+// ?symbols maps file symbols (EURUSD.m=EUR_USD,GBPUSD.m=GBP_USD); ?tz is the IANA zone of times
+// without an offset; ?max_errors aborts after that many bad rows; ?dry_run=true only validates.
+func (s *Server) importMarketData(c *gin.Context) {
+	layout, err := marketdata.ParseLayout(c.Query("layout"))
+	if err != nil {
+		c.JSON(400, gin.H{"error": err.Error()})
+		return
+	}
+	opts := marketdata.Options{
+		Layout:     layout,
+		Instrument: c.Query("instrument"),
+		Timeframe:  c.Query("timeframe"),
+		DryRun:     c.Query("dry_run") == "true",
+	}
+	if v := c.Query("symbols"); v != "" {
+		if opts.Symbols, err = marketdata.ParseSymbols(strings.Split(v, ",")); err != nil {
+			c.JSON(400, gin.H{"error": err.Error()})
+			return
+		}
+	}
+	if v := c.Query("tz"); v != "" {
+		if opts.Location, err = time.LoadLocation(v); err != nil {
+			c.JSON(400, gin.H{"error": "invalid tz"})
+			return
+		}
+	}
+	if v := c.Query("max_errors"); v != "" {
+		if opts.MaxErrors, err = strconv.Atoi(v); err != nil || opts.MaxErrors < 0 {
+			c.JSON(400, gin.H{"error": "invalid max_errors"})
+			return
+		}
+	}
+	res, err := marketdata.Import(c.Request.Context(), c.Request.Body, s.store, opts)
+	if err != nil {
+		code := 400
+		if errors.Is(err, marketdata.ErrStore) {
+			code = 500
+		}
+		c.JSON(code, gin.H{"error": err.Error(), "result": res})
+		return
+	}
+	c.JSON(200, res)
+}
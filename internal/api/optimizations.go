@@ -0,0 +1,265 @@
+package api
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log"
+	"runtime"
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/jedi116/go-trader/internal/backtest"
+	"github.com/jedi116/go-trader/internal/config"
+	"github.com/jedi116/go-trader/internal/strategy"
+	"github.com/jedi116/go-trader/pkg/models"
+)
+
+// optimizationRequest is a backtest request plus the parameter space. Params holds the fixed
+// parameters. With walk_forward set the run is a walk-forward analysis, otherwise a plain search
+// over the whole range.
+type optimizationRequest struct {
+	backtestRequest
+	Space       backtest.Space               `json:"space"`
+	Search      backtest.OptimizeOptions     `json:"search"`
+	WalkForward *backtest.WalkForwardOptions `json:"walk_forward"`
+}
+
+// createOptimization validates the request, loads its candles and starts the run in the
+// background; poll GET /optimizations/:id for the outcome.
+func (s *Server) createOptimization(c *gin.Context) {
+	var req optimizationRequest
+	if err := c.BindJSON(&req); err != nil {
+		c.JSON(400, gin.H{"error": "invalid request"})
+		return
+	}
+	data, opts, ok := s.loadBacktest(c, &req.backtestRequest)
+	if !ok {
+		return
+	}
+	req.Search.Workers = optimizationWorkers(req.Search.Workers, cap(s.optimizations))
+	if _, err := backtest.Combinations(req.Space, opts.Params, req.Search); err != nil {
+		c.JSON(400, gin.H{"error": err.Error()})
+		return
+	}
+	kind := models.OptimizationSearch
+	if req.WalkForward != nil {
+		kind = models.OptimizationWalkForward
+	}
+	cfg, err := json.Marshal(req)
+	if err != nil {
+		c.JSON(500, gin.H{"error": err.Error()})
+		return
+	}
+	from, to := data.Span()
+	run := &models.OptimizationRun{
+		Kind:        kind,
+		Strategy:    req.Strategy,
+		Instruments: req.Instruments,
+		Timeframe:   req.Timeframe,
+		From:        from,
+		To:          to,
+		Config:      cfg,
+	}
+	select {
+	case s.optimizations <- struct{}{}:
+	default:
+		c.JSON(429, gin.H{"error": "too many optimizations running", "max": cap(s.optimizations)})
+		return
+	}
+	if err := s.store.CreateOptimizationRun(c.Request.Context(), run); err != nil {
+		<-s.optimizations
+		c.JSON(500, gin.H{"error": err.Error()})
+		return
+	}
+	s.background.Add(1)
+	go func() {
+		defer s.background.Done()
+		defer func() { <-s.optimizations }()
+		s.runOptimization(s.ctx, run.ID, req, data, opts)
+	}()
+	c.JSON(202, run)
+}
+
+// maxOptimizations is how many optimizations may run at once; zero in config means 2.
+func maxOptimizations(cfg config.BacktestConfig) int {
+	if cfg.MaxOptimizations > 0 {
+		return cfg.MaxOptimizations
+	}
+	return 2
+}
+
+// optimizationWorkers shares the CPUs between the concurrent runs; a request may ask for fewer.
+func optimizationWorkers(requested, runs int) int {
+	limit := max(1, runtime.NumCPU()/runs)
+	if requested <= 0 || requested > limit {
+		return limit
+	}
+	return requested
+}
+
+// runOptimization runs under the server context, so Shutdown cancels it; the outcome is still
+// recorded.
+func (s *Server) runOptimization(ctx context.Context, id string, req optimizationRequest, data backtest.Data, opts backtest.Options) {
+	var metrics, result, equity []byte
+	err := func() error {
+		var m backtest.Metrics
+		var res interface{}
+		var curve []backtest.EquityPoint
+		if req.WalkForward != nil {
+			wf, err := backtest.WalkForward(ctx, data, req.Strategy, req.Space, opts, req.Search, *req.WalkForward)
+			if err != nil {
+				return err
+			}
+			m, curve = wf.Metrics, wf.Equity
+			wf.Equity = nil
+			res = wf
+		} else {
+			opt, err := backtest.Optimize(ctx, data, req.Strategy, req.Space, opts, req.Search)
+			if err != nil {
+				return err
+			}
+			best := opt.Best()
+			// Replay the winner to keep its equity curve; the search itself keeps none.
+			strat, err := strategy.New(req.Strategy, best.Params)
+			if err != nil {
+				return err
+			}
+			opts.Params = best.Params
+			run, err := backtest.Run(data, strat, opts)
+			if err != nil {
+				return err
+			}
+			m, curve, res = best.Metrics, run.Equity, opt
+		}
+		var err error
+		if metrics, err = json.Marshal(m); err != nil {
+			return err
+		}
+		if result, err = json.Marshal(res); err != nil {
+			return err
+		}
+		equity, err = json.Marshal(curve)
+		return err
+	}()
+
+	status := models.OptimizationCompleted
+	var errMsg *string
+	if err != nil {
+		if ctx.Err() != nil {
+			err = fmt.Errorf("cancelled by server shutdown: %w", err)
+		}
+		status = models.OptimizationFailed
+		msg := err.Error()
+		errMsg = &msg
+		metrics, result, equity = nil, nil, nil
+		log.Printf("[OPTIMIZE] %s %s failed: %v", id, req.Strategy, err)
+	} else {
+		log.Printf("[OPTIMIZE] %s %s completed", id, req.Strategy)
+	}
+	saveCtx, cancel := context.WithTimeout(context.WithoutCancel(ctx), 10*time.Second)
+	defer cancel()
+	if err := s.store.CompleteOptimizationRun(saveCtx, id, status, metrics, result, equity, errMsg); err != nil {
+		log.Printf("[OPTIMIZE] %s: save result: %v", id, err)
+	}
+}
+
+func (s *Server) listOptimizations(c *gin.Context) {
+	limit, _ := strconv.Atoi(c.Query("limit"))
+	runs, err := s.store.ListOptimizationRuns(c.Request.Context(), limit)
+	if err != nil {
+		c.JSON(500, gin.H{"error": err.Error()})
+		return
+	}
+	if runs == nil {
+		runs = []models.OptimizationRun{}
+	}
+	c.JSON(200, gin.H{"optimizations": runs})
+}
+
+func (s *Server) getOptimization(c *gin.Context) {
+	run, ok := s.findOptimization(c)
+	if !ok {
+		return
+	}
+	c.JSON(200, run)
+}
+
+// getOptimizationResultsCSV downloads the ranked candidates of a search or the windows of a
+// walk-forward analysis.
+func (s *Server) getOptimizationResultsCSV(c *gin.Context) {
+	run, ok := s.completedOptimization(c)
+	if !ok {
+		return
+	}
+	if run.Kind == models.OptimizationWalkForward {
+		var wf backtest.WalkForwardResult
+		if err := json.Unmarshal(run.Result, &wf); err != nil {
+			c.JSON(500, gin.H{"error": err.Error()})
+			return
+		}
+		csvAttachment(c, run.ID+"-windows.csv")
+		_ = backtest.WriteWindowsCSV(c.Writer, wf.Windows)
+		return
+	}
+	var opt backtest.Optimization
+	if err := json.Unmarshal(run.Result, &opt); err != nil {
+		c.JSON(500, gin.H{"error": err.Error()})
+		return
+	}
+	csvAttachment(c, run.ID+"-results.csv")
+	_ = backtest.WriteCandidatesCSV(c.Writer, opt.Candidates)
+}
+
+// getOptimizationEquityCSV downloads the stitched out-of-sample curve of a walk-forward analysis
+// or the curve of a search's best parameters.
+func (s *Server) getOptimizationEquityCSV(c *gin.Context) {
+	run, ok := s.completedOptimization(c)
+	if !ok {
+		return
+	}
+	var equity []backtest.EquityPoint
+	if err := json.Unmarshal(run.Equity, &equity); err != nil {
+		c.JSON(500, gin.H{"error": err.Error()})
+		return
+	}
+	csvAttachment(c, run.ID+"-equity.csv")
+	_ = backtest.WriteEquityCSV(c.Writer, equity)
+}
+
+func (s *Server) findOptimization(c *gin.Context) (*models.OptimizationRun, bool) {
+	id := c.Param("id")
+	if !isUUIDLike(id) {
+		c.JSON(404, gin.H{"error": "not found"})
+		return nil, false
+	}
+	run, err := s.store.GetOptimizationRun(c.Request.Context(), id)
+	if err != nil {
+		c.JSON(500, gin.H{"error": err.Error()})
+		return nil, false
+	}
+	if run == nil {
+		c.JSON(404, gin.H{"error": "not found"})
+		return nil, false
+	}
+	return run, true
+}
+
+func (s *Server) completedOptimization(c *gin.Context) (*models.OptimizationRun, bool) {
+	run, ok := s.findOptimization(c)
+	if !ok {
+		return nil, false
+	}
+	if run.Status != models.OptimizationCompleted {
+		c.JSON(409, gin.H{"error": "optimization is " + string(run.Status), "status": run.Status})
+		return nil, false
+	}
+	return run, true
+}
+
+func csvAttachment(c *gin.Context, filename string) {
+	c.Header("Content-Type", "text/csv")
+	c.Header("Content-Disposition", `attachment; filename="`+filename+`"`)
+	c.Status(200)
+}
@@ -0,0 +1,180 @@
+package api
+
+import (
+	"context"
+	"errors"
+	"log"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/jedi116/go-trader/internal/broker"
+	"github.com/jedi116/go-trader/internal/calendar"
+	"github.com/jedi116/go-trader/internal/markethours"
+	"github.com/jedi116/go-trader/internal/orders"
+	"github.com/jedi116/go-trader/internal/risk"
+	"github.com/jedi116/go-trader/pkg/models"
+)
+
+// submitOrder handles a closed market per market_hours.closed_orders, then sends the order
+// through the shared order path. When it returns false the response has been written.
+func (s *Server) submitOrder(c *gin.Context, o risk.Order) (*broker.OrderCreateResponse, []models.EconomicEvent, bool) {
+	if !s.checkMarketOpen(c, &o, true) {
+		return nil, nil, false
+	}
+	res, err := s.orders.Submit(c.Request.Context(), o)
+	if err != nil {
+		writeOrderError(c, err)
+		return nil, nil, false
+	}
+	return res.Order, res.Warnings, true
+}
+
+// executeOrder is submitOrder for callers without a request to answer, such as strategies:
+// closed-market orders are refused rather than queued, and every refusal is returned as an error.
+func (s *Server) executeOrder(ctx context.Context, o risk.Order) (*broker.OrderCreateResponse, error) {
+	res, err := s.orders.Submit(ctx, o)
+	if err != nil {
+		return nil, err
+	}
+	return res.Order, nil
+}
+
+// writeOrderError answers a failed order: 409 for a closed market, a calendar blackout or a
+// recommendation that is no longer pending, 423 while halted, 422 for a risk rejection, 503 when
+// a check could not run, 404 for an unknown recommendation and 500 otherwise.
+func writeOrderError(c *gin.Context, err error) {
+	var ce *markethours.ClosedError
+	var he *risk.HaltedError
+	var be *calendar.BlackoutError
+	var re *risk.RejectionError
+	var ue *orders.CheckError
+	switch {
+	case errors.As(err, &ce):
+		c.JSON(409, gin.H{"error": ce.Error(), "market": ce.Status})
+	case errors.As(err, &he):
+		c.JSON(423, gin.H{"error": he.Error(), "halted_at": he.Since})
+	case errors.As(err, &be):
+		c.JSON(409, gin.H{"error": be.Error(), "events": be.Events})
+	case errors.As(err, &re):
+		c.JSON(422, gin.H{"error": re.Error(), "rejections": re.Decision.Rejections()})
+	case errors.As(err, &ue):
+		c.JSON(503, gin.H{"error": ue.Error()})
+	case errors.Is(err, orders.ErrNotFound):
+		c.JSON(404, gin.H{"error": "not found"})
+	case errors.Is(err, orders.ErrNotPending):
+		c.JSON(409, gin.H{"error": err.Error()})
+	default:
+		c.JSON(500, gin.H{"error": err.Error()})
+	}
+}
+
+const (
+	closedReject = "reject"
+	closedQueue  = "queue"
+	closedAllow  = "allow"
+)
+
+// checkMarketOpen handles orders outside market hours according to market_hours.closed_orders.
+// It returns true when the order should go ahead now; otherwise the response has been written,
+// either a 409 refusal or a 202 with the queued order. Only plain orders are queued.
+func (s *Server) checkMarketOpen(c *gin.Context, o *risk.Order, queueable bool) bool {
+	err := s.hours.Check(time.Now())
+	var ce *markethours.ClosedError
+	if !errors.As(err, &ce) {
+		return true
+	}
+	mode := s.config.Market.ClosedOrders
+	if mode == closedAllow {
+		return true
+	}
+	if mode == closedQueue && queueable {
+		q := &models.QueuedOrder{Instrument: o.Instrument, Units: o.Units, StopLoss: o.StopLoss, TakeProfit: o.TakeProfit, Source: o.Source}
+		if err := s.store.CreateQueuedOrder(c.Request.Context(), q); err != nil {
+			c.JSON(500, gin.H{"error": err.Error()})
+			return false
+		}
+		c.JSON(202, gin.H{"queued": q, "market": ce.Status})
+		return false
+	}
+	c.JSON(409, gin.H{"error": ce.Error(), "market": ce.Status})
+	return false
+}
+
+func (s *Server) getMarketHours(c *gin.Context) {
+	st := s.hours.Status(time.Now())
+	if instruments := c.Query("instruments"); instruments != "" {
+		status, err := s.mt4Client.GetMarketStatus(splitCSV(instruments))
+		if err != nil {
+			c.JSON(500, gin.H{"error": err.Error()})
+			return
+		}
+		c.JSON(200, status)
+		return
+	}
+	c.JSON(200, st)
+}
+
+func (s *Server) listQueuedOrders(c *gin.Context) {
+	list, err := s.store.ListQueuedOrders(c.Request.Context(), models.QueuedOrderStatus(c.Query("status")), 200)
+	if err != nil {
+		c.JSON(500, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(200, list)
+}
+
+func (s *Server) cancelQueuedOrder(c *gin.Context) {
+	ok, err := s.store.TransitionQueuedOrder(c.Request.Context(), c.Param("id"), models.QueuedOrderPending, models.QueuedOrderCancelled, nil, nil)
+	if err != nil {
+		c.JSON(500, gin.H{"error": err.Error()})
+		return
+	}
+	if !ok {
+		c.JSON(404, gin.H{"error": "no queued order with that id"})
+		return
+	}
+	c.JSON(200, gin.H{"status": "cancelled"})
+}
+
+// submitQueued sends queued orders through the shared order path. Orders held back by the
+// market hours, kill switch or a calendar blackout, or whose checks could not run, stay queued;
+// risk rejections and broker errors are final.
+func (s *Server) submitQueued(ctx context.Context) {
+	queued, err := s.store.ListQueuedOrders(ctx, models.QueuedOrderPending, 100)
+	if err != nil {
+		log.Printf("[QUEUE] list error: %v", err)
+		return
+	}
+	for _, q := range queued {
+		o := risk.Order{Instrument: q.Instrument, Units: q.Units, StopLoss: q.StopLoss, TakeProfit: q.TakeProfit, Source: "queue:" + q.Source}
+		claimed := false
+		res, err := s.orders.SubmitClaimed(ctx, o, func() bool {
+			ok, err := s.store.TransitionQueuedOrder(ctx, q.ID, models.QueuedOrderPending, models.QueuedOrderSubmitted, nil, nil)
+			claimed = err == nil && ok
+			return claimed
+		})
+		var re *risk.RejectionError
+		var he *risk.HaltedError
+		switch {
+		case err != nil && claimed:
+			msg := err.Error()
+			_, _ = s.store.TransitionQueuedOrder(ctx, q.ID, models.QueuedOrderSubmitted, models.QueuedOrderRejected, nil, &msg)
+			log.Printf("[QUEUE] order %s failed: %v", q.ID, err)
+		case errors.As(err, &re):
+			msg := re.Error()
+			_, _ = s.store.TransitionQueuedOrder(ctx, q.ID, models.QueuedOrderPending, models.QueuedOrderRejected, nil, &msg)
+		case errors.As(err, &he):
+			log.Printf("[QUEUE] holding %d orders: %v", len(queued), err)
+			return
+		case err != nil:
+			log.Printf("[QUEUE] holding %s: %v", q.ID, err)
+		case res != nil:
+			id := res.Order.OrderCreateTransaction.ID
+			_, _ = s.store.TransitionQueuedOrder(ctx, q.ID, models.QueuedOrderSubmitted, models.QueuedOrderSubmitted, &id, nil)
+			for _, ev := range res.Warnings {
+				log.Printf("[QUEUE] %s submitted near %s %s event %q at %s", q.ID, ev.Currency, ev.Impact, ev.Title, ev.EventTime.Format(time.RFC3339))
+			}
+			log.Printf("[QUEUE] submitted %s %s units=%.0f oanda_order=%s", q.ID, q.Instrument, q.Units, id)
+		}
+	}
+}
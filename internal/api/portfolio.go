@@ -0,0 +1,15 @@
+package api
+
+import (
+	"github.com/gin-gonic/gin"
+)
+
+// getPortfolio returns per-currency exposure, correlations between held instruments and VaR.
+func (s *Server) getPortfolio(c *gin.Context) {
+	report, err := s.portfolio.Analyze(c.Request.Context())
+	if err != nil {
+		c.JSON(500, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(200, report)
+}
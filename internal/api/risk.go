@@ -0,0 +1,24 @@
+package api
+
+import (
+	"errors"
+
+	"github.com/gin-gonic/gin"
+	"github.com/jedi116/go-trader/internal/risk"
+)
+
+// checkRisk runs the pre-trade checks and writes the error response when the order may not
+// proceed: 422 with the failed checks on rejection, 503 when account state is unavailable.
+func (s *Server) checkRisk(c *gin.Context, o risk.Order) bool {
+	_, err := s.risk.Evaluate(c.Request.Context(), o)
+	var re *risk.RejectionError
+	if errors.As(err, &re) {
+		c.JSON(422, gin.H{"error": re.Error(), "rejections": re.Decision.Rejections()})
+		return false
+	}
+	if err != nil {
+		c.JSON(503, gin.H{"error": err.Error()})
+		return false
+	}
+	return true
+}
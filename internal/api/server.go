@@ -1,19 +1,35 @@
 package api
 
 import (
+	"context"
 	"encoding/json"
+	"errors"
+	"fmt"
 	"log"
 	"math"
-	"strings"
+	"net/http"
+	"sync"
 	"time"
 
 	"github.com/gin-contrib/cors"
 	"github.com/gin-gonic/gin"
 	"github.com/jedi116/go-trader/internal/ai"
+	"github.com/jedi116/go-trader/internal/alerts"
+	"github.com/jedi116/go-trader/internal/analytics"
 	"github.com/jedi116/go-trader/internal/broker"
+	"github.com/jedi116/go-trader/internal/calendar"
 	"github.com/jedi116/go-trader/internal/config"
 	"github.com/jedi116/go-trader/internal/database"
+	"github.com/jedi116/go-trader/internal/markethours"
 	"github.com/jedi116/go-trader/internal/news"
+	"github.com/jedi116/go-trader/internal/notify"
+	"github.com/jedi116/go-trader/internal/orders"
+	"github.com/jedi116/go-trader/internal/portfolio"
+	"github.com/jedi116/go-trader/internal/risk"
+	"github.com/jedi116/go-trader/internal/signals"
+	"github.com/jedi116/go-trader/internal/snapshots"
+	"github.com/jedi116/go-trader/internal/strategy"
+	"github.com/jedi116/go-trader/internal/ticks"
 	"github.com/jedi116/go-trader/pkg/models"
 )
 
@@ -21,28 +37,160 @@ type Server struct {
 	config    *config.Config
 	router    *gin.Engine
 	mt4Client *broker.OandaMT4Client
-	brave     *news.BraveClient
-	db        *database.Postgres
+	news      news.NewsProvider
+	// store is the configured Postgres or SQLite store, otherwise an in-memory one.
+	store     database.Store
 	ai        ai.Service
+	aiMeter   *ai.Meter
+	calendar  *calendar.Guard
+	risk      *risk.Engine
+	guardian  *risk.Guardian
+	portfolio *portfolio.Analyzer
+	hours     *markethours.Calendar
+	alerts    *alerts.Engine
+	notifier  *notify.Dispatcher
+	signals   *signals.Resolver
+	signalIDs *signals.Seen
+	// strategies is nil when no strategy instances are configured.
+	strategies *strategy.Runtime
+	analytics  *analytics.Service
+	// snapshots is nil without a broker or when snapshots are disabled.
+	snapshots *snapshots.Snapshotter
+	// ticks is nil without a broker or when tick recording is disabled.
+	ticks *ticks.Recorder
+	// orders is the shared order path, also used by the gRPC server.
+	orders *orders.Service
+
+	http *http.Server
+	// ctx is cancelled by Shutdown; background work runs under it.
+	ctx  context.Context
+	stop context.CancelFunc
+	// optimizations holds a slot per running optimization; background tracks them for Shutdown.
+	optimizations chan struct{}
+	background    sync.WaitGroup
 }
 
-func NewServer(cfg *config.Config, mt4Client *broker.OandaMT4Client, brave *news.BraveClient, db *database.Postgres, aiSvc ai.Service) *Server {
+// NewServer wires the REST API. aiMeter may be nil, in which case one is built over store from
+// the configured pricing and budget. Invalid market hours, guardian timezone or blackout
+// settings are returned as errors rather than replaced by defaults.
+func NewServer(cfg *config.Config, mt4Client *broker.OandaMT4Client, newsProvider news.NewsProvider, store database.Store, aiSvc ai.Service, aiMeter *ai.Meter) (*Server, error) {
+	if store == nil {
+		log.Printf("[DB] not configured; everything is kept in memory and lost on restart")
+		store = database.NewMemory()
+	}
+	hours, err := markethours.FromConfig(cfg.Market)
+	if err != nil {
+		return nil, fmt.Errorf("market_hours: %w", err)
+	}
+	guardianOpts, err := risk.GuardianOptionsFromConfig(cfg.Risk.Guardian)
+	if err != nil {
+		return nil, fmt.Errorf("risk.guardian: %w", err)
+	}
+	guard, err := calendar.NewGuard(store, calendar.GuardOptions{
+		Mode:      calendar.BlackoutMode(cfg.Calendar.Blackout.Mode),
+		Before:    cfg.Calendar.Blackout.Before,
+		After:     cfg.Calendar.Blackout.After,
+		MinImpact: models.EventImpact(cfg.Calendar.Blackout.MinImpact),
+	})
+	if err != nil {
+		return nil, fmt.Errorf("calendar: %w", err)
+	}
 	router := gin.Default()
 
 	// CORS middleware
 	router.Use(cors.Default())
 
+	if aiMeter == nil {
+		aiMeter = ai.NewMeter(store, ai.PriceTableFromConfig(cfg.AI.Pricing), ai.Budget{DailyUSD: cfg.AI.Budget.DailyUSD, MonthlyUSD: cfg.AI.Budget.MonthlyUSD})
+	}
+
 	server := &Server{
 		config:    cfg,
 		router:    router,
 		mt4Client: mt4Client,
-		brave:     brave,
-		db:        db,
+		news:      newsProvider,
+		store:     store,
 		ai:        aiSvc,
+		aiMeter:   aiMeter,
+		http:      &http.Server{Addr: cfg.Server.Host + ":" + cfg.Server.Port, Handler: router},
+	}
+	server.ctx, server.stop = context.WithCancel(context.Background())
+	server.optimizations = make(chan struct{}, maxOptimizations(cfg.Backtest))
+	server.calendar = guard
+
+	notifier, err := notify.FromConfig(cfg.Notify, store)
+	if err != nil {
+		log.Printf("[NOTIFY] %v (notifications disabled)", err)
+	}
+	server.notifier = notifier
+	pc := cfg.Risk.Portfolio
+	server.portfolio = portfolio.NewAnalyzer(mt4Client, store, portfolio.Options{
+		Timeframe:    pc.Timeframe,
+		Lookback:     pc.Lookback,
+		Confidence:   pc.Confidence,
+		Horizon:      pc.Horizon,
+		MinSamples:   pc.MinSamples,
+		MaxStaleBars: pc.MaxStaleBars,
+	})
+	server.risk = risk.NewEngine(mt4Client, store, risk.LimitsFromConfig(cfg.Risk)).WithNotifier(notifier).WithCorrelator(server.portfolio)
+	server.guardian = risk.NewGuardian(mt4Client, store, store, guardianOpts).WithNotifier(notifier)
+	server.hours = hours
+	if mt4Client != nil {
+		mt4Client.MarketHours = hours
+	}
+	if cfg.Alerts.Enabled {
+		server.alerts = alerts.NewEngine(mt4Client, store).WithNotifier(notifier)
+	}
+	// A nil hours gate lets orders through while closed; queueing is decided before the order path.
+	gate := hours
+	if cfg.Market.ClosedOrders == closedAllow {
+		gate = nil
+	}
+	server.orders = orders.New(mt4Client, store, gate, server.calendar, server.risk, server.guardian, notifier)
+	server.signals = signals.NewResolver(mt4Client)
+	server.signalIDs = signals.NewSeen(signalDedupeWindow(cfg.Signals))
+	if instances, err := strategy.FromConfig(cfg.Strategies); err != nil {
+		log.Printf("[STRATEGY] %v (strategies disabled)", err)
+	} else if len(instances) > 0 {
+		if server.strategies, err = strategy.NewRuntime(mt4Client, strategyExecutor{s: server}, instances); err != nil {
+			log.Printf("[STRATEGY] %v (strategies disabled)", err)
+		}
+	}
+
+	analyticsOpts, err := analytics.OptionsFromConfig(cfg.Analytics)
+	if err != nil {
+		log.Printf("[ANALYTICS] %v (using UTC)", err)
+	}
+	var closed analytics.Broker
+	if mt4Client != nil {
+		closed = mt4Client
+	}
+	server.analytics = analytics.NewService(store, closed, analyticsOpts)
+	server.analytics.WithNAVHistory(store)
+	if cfg.Snapshots.Enabled && mt4Client != nil {
+		server.snapshots = snapshots.New(mt4Client, store, snapshots.Options{
+			Interval:  cfg.Snapshots.Interval,
+			Retention: cfg.Snapshots.Retention,
+		})
+	}
+	if tc := cfg.Ticks; tc.Enabled && mt4Client != nil {
+		recorder, err := ticks.New(mt4Client, mt4Client, store, ticks.Options{
+			Instruments:   tc.Instruments,
+			Timeframes:    tc.Timeframes,
+			BatchSize:     tc.BatchSize,
+			FlushInterval: tc.FlushInterval,
+			Retention:     tc.Retention,
+			Compare:       tc.Compare,
+			CompareDelay:  tc.CompareDelay,
+		})
+		if err != nil {
+			log.Printf("[TICKS] %v (tick recording disabled)", err)
+		}
+		server.ticks = recorder
 	}
 
 	server.setupRoutes()
-	return server
+	return server, nil
 }
 
 func (s *Server) setupRoutes() {
@@ -52,9 +200,36 @@ func (s *Server) setupRoutes() {
 		api.GET("/health/db", s.dbHealth)
 		api.GET("/market/:symbol", s.getMarketData)
 		api.POST("/orders", s.placeOrder)
+		api.GET("/orders/queued", s.listQueuedOrders)
+		api.DELETE("/orders/queued/:id", s.cancelQueuedOrder)
+		api.GET("/market-hours", s.getMarketHours)
+		api.POST("/signals", s.receiveSignal)
+		api.POST("/signals/tradingview", s.receiveTradingViewSignal)
+		api.GET("/strategies", s.listStrategies)
+		api.GET("/strategies/:name", s.getStrategy)
+		api.POST("/backtests", s.runBacktest)
+		api.POST("/optimizations", s.createOptimization)
+		api.GET("/optimizations", s.listOptimizations)
+		api.GET("/optimizations/:id", s.getOptimization)
+		api.GET("/optimizations/:id/results.csv", s.getOptimizationResultsCSV)
+		api.GET("/optimizations/:id/equity.csv", s.getOptimizationEquityCSV)
+		api.GET("/alerts", s.listAlerts)
+		api.POST("/alerts", s.createAlert)
+		api.GET("/alerts/:id", s.getAlert)
+		api.PUT("/alerts/:id", s.updateAlert)
+		api.DELETE("/alerts/:id", s.deleteAlert)
+		api.GET("/alerts/:id/events", s.listAlertEvents)
+		api.GET("/account", s.getAccount)
+		api.GET("/account/snapshots", s.listAccountSnapshots)
 		api.GET("/positions", s.getPositions)
 		api.GET("/trades", s.listTrades)
 		api.DELETE("/trades/:id", s.deleteTrade)
+		api.GET("/analytics", s.getAnalytics)
+		api.GET("/analytics/equity", s.getAnalyticsEquity)
+		api.GET("/ticks/status", s.getTickStatus)
+		api.GET("/ticks/:instrument", s.listTicks)
+		api.GET("/ticks/:instrument/spread", s.getTickSpreads)
+		api.GET("/ticks/:instrument/candles", s.getTickCandles)
 		api.GET("/news/:query", s.searchNews)
 		api.POST("/recommendations", s.createRecommendation)
 		api.GET("/recommendations", s.listRecommendations)
@@ -63,23 +238,83 @@ func (s *Server) setupRoutes() {
 		// AI endpoints
 		api.POST("/ai/recommend", s.aiGenerateRecommendation)
 		api.GET("/ai/status", s.aiStatus)
+		api.GET("/ai/usage", s.aiUsage)
+		// Economic calendar
+		api.GET("/calendar", s.listCalendar)
+		api.POST("/calendar/import", s.requireAdmin, s.importCalendar)
+		api.GET("/portfolio", s.getPortfolio)
+		// Kill switch
+		admin := api.Group("/admin", s.requireAdmin)
+		admin.GET("/kill-switch", s.getKillSwitch)
+		admin.POST("/kill-switch/halt", s.haltTrading)
+		admin.POST("/kill-switch/reset", s.resetKillSwitch)
+		admin.GET("/notifications", s.listNotifications)
+		admin.POST("/notifications/test", s.testNotification)
+		admin.POST("/strategies/:name/enable", s.enableStrategy)
+		admin.POST("/strategies/:name/disable", s.disableStrategy)
+		admin.POST("/analytics/sync", s.syncAnalytics)
+		admin.GET("/export/:dataset", s.exportDataset)
+		admin.POST("/market-data/import", s.importMarketData)
 	}
 }
 
+// Run starts the background jobs and serves the API until Shutdown.
 func (s *Server) Run() error {
-	return s.router.Run(s.config.Server.Host + ":" + s.config.Server.Port)
+	go s.notifier.Run(s.ctx)
+	go s.guardian.Run(s.ctx, s.config.Risk.Guardian.CheckInterval)
+	if s.config.Market.ClosedOrders == closedQueue {
+		go s.hours.RunWhileOpen(s.ctx, s.config.Market.QueueInterval, s.submitQueued)
+	}
+	if s.alerts != nil {
+		go s.hours.RunWhileOpen(s.ctx, s.config.Alerts.Interval, s.alerts.Tick)
+	}
+	if s.strategies != nil {
+		go s.strategies.Stream(s.ctx)
+		go s.hours.RunWhileOpen(s.ctx, s.config.Strategies.Interval, s.strategies.PollCandles)
+	}
+	if s.snapshots != nil {
+		go s.snapshots.Run(s.ctx)
+	}
+	if s.ticks != nil {
+		go s.ticks.Run(s.ctx)
+	}
+	go s.hours.RunWhileOpen(s.ctx, s.config.Analytics.SyncInterval, s.analytics.Tick)
+	if err := s.http.ListenAndServe(); !errors.Is(err, http.ErrServerClosed) {
+		return err
+	}
+	return nil
+}
+
+// Shutdown stops accepting requests, cancels background jobs, including running
+// optimizations, and waits for them to record their outcome or for ctx to end.
+func (s *Server) Shutdown(ctx context.Context) error {
+	s.stop()
+	err := s.http.Shutdown(ctx)
+	done := make(chan struct{})
+	go func() {
+		s.background.Wait()
+		close(done)
+	}()
+	select {
+	case <-done:
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+	return err
 }
 
 func (s *Server) healthCheck(c *gin.Context) {
 	c.JSON(200, gin.H{"status": "ok"})
 }
 
+// dbHealth pings the configured database, Postgres or SQLite; the in-memory store has none.
 func (s *Server) dbHealth(c *gin.Context) {
-	if s.db == nil {
+	db, ok := s.store.(interface{ Health(context.Context) error })
+	if !ok {
 		c.JSON(503, gin.H{"status": "db not configured"})
 		return
 	}
-	if err := s.db.Health(c.Request.Context()); err != nil {
+	if err := db.Health(c.Request.Context()); err != nil {
 		c.JSON(503, gin.H{"status": "db error", "error": err.Error()})
 		return
 	}
@@ -89,13 +324,13 @@ func (s *Server) dbHealth(c *gin.Context) {
 // Placeholder handlers
 func (s *Server) getMarketData(c *gin.Context) {
 	symbol := c.Param("symbol")
-	// fetch candles and return latest price; also persist snapshot to DB if configured
+	// fetch candles and return latest price; also persist snapshot to the store
 	candles, err := s.mt4Client.GetCandles(symbol, "M5", 50, nil, nil)
 	if err != nil {
 		c.JSON(500, gin.H{"error": err.Error()})
 		return
 	}
-	if s.db != nil && candles != nil {
+	if candles != nil {
 		// map to market_data upsert
 		rows := make([]models.MarketData, 0, len(candles.Candles))
 		for _, cdl := range candles.Candles {
@@ -114,7 +349,7 @@ func (s *Server) getMarketData(c *gin.Context) {
 				Timeframe:  candles.Granularity,
 			})
 		}
-		_ = s.db.UpsertMarketData(c.Request.Context(), rows)
+		_ = s.store.UpsertMarketData(c.Request.Context(), rows)
 	}
 	c.JSON(200, candles)
 }
@@ -130,42 +365,14 @@ func (s *Server) placeOrder(c *gin.Context) {
 		c.JSON(400, gin.H{"error": "invalid request"})
 		return
 	}
-	var resp *broker.OrderCreateResponse
-	var err error
-	if req.StopLoss != nil || req.TakeProfit != nil {
-		resp, err = s.mt4Client.PlaceMarketOrderWithBrackets(req.Instrument, req.Units, req.StopLoss, req.TakeProfit)
-	} else {
-		resp, err = s.mt4Client.PlaceMarketOrder(req.Instrument, req.Units)
-	}
-	if err != nil {
-		c.JSON(500, gin.H{"error": err.Error()})
+	order := risk.Order{Instrument: req.Instrument, Units: req.Units, StopLoss: req.StopLoss, TakeProfit: req.TakeProfit, Source: "rest"}
+	resp, warnings, ok := s.submitOrder(c, order)
+	if !ok {
 		return
 	}
-	if s.db != nil && resp != nil {
-		// Compute entry price from current mid
-		entry := 0.0
-		if prices, perr := s.mt4Client.GetPrices([]string{req.Instrument}); perr == nil && len(prices) > 0 && len(prices[0].Bids) > 0 && len(prices[0].Asks) > 0 {
-			b := parseDecimal(prices[0].Bids[0].Price)
-			a := parseDecimal(prices[0].Asks[0].Price)
-			if b > 0 && a > 0 {
-				entry = (b + a) / 2
-			}
-		}
-		tr := &models.Trade{
-			ID:         "", // let DB assign UUID
-			Instrument: req.Instrument,
-			Direction: func() string {
-				if req.Units >= 0 {
-					return "BUY"
-				}
-				return "SELL"
-			}(),
-			Units:        req.Units,
-			EntryPrice:   &entry,
-			Status:       models.TradeStatusOpen,
-			OandaTradeID: func() *string { id := resp.OrderCreateTransaction.ID; return &id }(),
-		}
-		_ = s.db.CreateTrade(c.Request.Context(), tr)
+	if len(warnings) > 0 {
+		c.JSON(200, gin.H{"order": resp, "calendar_warnings": warnings})
+		return
 	}
 	c.JSON(200, gin.H{"order": resp})
 }
@@ -181,11 +388,7 @@ func (s *Server) getPositions(c *gin.Context) {
 }
 
 func (s *Server) listTrades(c *gin.Context) {
-	if s.db == nil {
-		c.JSON(503, gin.H{"error": "db not configured"})
-		return
-	}
-	trades, err := s.db.ListTrades(c.Request.Context(), 200)
+	trades, err := s.store.ListTrades(c.Request.Context(), 200)
 	if err != nil {
 		c.JSON(500, gin.H{"error": err.Error()})
 		return
@@ -194,12 +397,8 @@ func (s *Server) listTrades(c *gin.Context) {
 }
 
 func (s *Server) deleteTrade(c *gin.Context) {
-	if s.db == nil {
-		c.JSON(503, gin.H{"error": "db not configured"})
-		return
-	}
 	id := c.Param("id")
-	if err := s.db.SoftDeleteTrade(c.Request.Context(), id); err != nil {
+	if err := s.store.SoftDeleteTrade(c.Request.Context(), id); err != nil {
 		c.JSON(500, gin.H{"error": err.Error()})
 		return
 	}
@@ -208,7 +407,7 @@ func (s *Server) deleteTrade(c *gin.Context) {
 
 func (s *Server) searchNews(c *gin.Context) {
 	query := c.Param("query")
-	items, err := s.brave.SearchNews(c.Request.Context(), query, 10)
+	items, err := s.news.SearchNews(c.Request.Context(), query, 10)
 	if err != nil {
 		c.JSON(500, gin.H{"error": err.Error()})
 		return
@@ -216,7 +415,6 @@ func (s *Server) searchNews(c *gin.Context) {
 	c.JSON(200, items)
 }
 
-// --- Simple in-memory recommendations store (temporary placeholder) ---
 type recommendation struct {
 	ID         string  `json:"id"`
 	Instrument string  `json:"instrument"`
@@ -226,170 +424,65 @@ type recommendation struct {
 	CreatedAt  int64   `json:"createdAt"`
 }
 
-var recs = make(map[string]recommendation)
-
 func (s *Server) createRecommendation(c *gin.Context) {
 	var r recommendation
 	if err := c.BindJSON(&r); err != nil {
 		c.JSON(400, gin.H{"error": "invalid request"})
 		return
 	}
-	if r.ID == "" {
-		r.ID = time.Now().Format("20060102150405")
+	// the store generates the ID unless the client supplied a UUID
+	if !isUUIDLike(r.ID) {
+		r.ID = ""
 	}
-	r.CreatedAt = time.Now().Unix()
-	// persist to DB primarily; keep in-memory as fallback only
-	if s.db != nil {
-		rationale := r.Rationale
-		status := models.RecommendationStatusPending
-		rec := &models.Recommendation{
-			ID:         r.ID,
-			Instrument: r.Instrument,
-			Direction:  r.Direction,
-			Units:      r.Units,
-			Rationale:  &rationale,
-			Status:     status,
-		}
-		if id, err := s.db.CreateRecommendation(c.Request.Context(), rec); err == nil {
-			r.ID = id
-		} else {
-			// fallback to memory if DB fails
-			recs[r.ID] = r
-		}
-	} else {
-		recs[r.ID] = r
+	rationale := r.Rationale
+	rec := &models.Recommendation{
+		ID:         r.ID,
+		Instrument: r.Instrument,
+		Direction:  r.Direction,
+		Units:      r.Units,
+		Rationale:  &rationale,
+		Status:     models.RecommendationStatusPending,
 	}
-
+	id, err := s.store.CreateRecommendation(c.Request.Context(), rec)
+	if err != nil {
+		c.JSON(500, gin.H{"error": err.Error()})
+		return
+	}
+	r.ID = id
+	r.CreatedAt = time.Now().Unix()
 	c.JSON(201, r)
 }
 
 func (s *Server) listRecommendations(c *gin.Context) {
-	if s.db != nil {
-		recsDB, err := s.db.ListRecommendations(c.Request.Context())
-		if err != nil {
-			c.JSON(500, gin.H{"error": err.Error()})
-			return
-		}
-		c.JSON(200, recsDB)
+	list, err := s.store.ListRecommendations(c.Request.Context())
+	if err != nil {
+		c.JSON(500, gin.H{"error": err.Error()})
 		return
 	}
-	list := make([]recommendation, 0, len(recs))
-	for _, v := range recs {
-		list = append(list, v)
+	if list == nil {
+		list = []models.Recommendation{}
 	}
 	c.JSON(200, list)
 }
 
 func (s *Server) acceptRecommendation(c *gin.Context) {
 	id := c.Param("id")
-	var r recommendation
-	var ok bool
-	var sl, tp *float64
-	// First try legacy recommendations table
-	if s.db != nil {
-		list, err := s.db.ListRecommendations(c.Request.Context())
-		if err == nil {
-			for _, item := range list {
-				if item.ID == id {
-					r = recommendation{ID: item.ID, Instrument: item.Instrument, Direction: item.Direction, Units: item.Units, Rationale: func() string {
-						if item.Rationale != nil {
-							return *item.Rationale
-						}
-						return ""
-					}(), CreatedAt: item.CreatedAt.Unix()}
-					ok = true
-					break
-				}
-			}
-		}
-	}
-	// If not found, try AI recommendations table
-	isAI := false
-	if s.db != nil && !ok {
-		if list, err := s.db.ListAIRecommendations(c.Request.Context(), 200); err == nil {
-			for _, item := range list {
-				if item.ID == id {
-					isAI = true
-					dir := item.Direction
-					units := item.Units
-					r = recommendation{ID: item.ID, Instrument: item.Instrument, Direction: dir, Units: units, Rationale: item.Rationale, CreatedAt: item.CreatedAt.Unix()}
-					// capture SL/TP from AI rec
-					sl = item.StopLoss
-					tp = item.TakeProfit
-					ok = true
-					break
-				}
-			}
-		}
-	}
-	if !ok {
-		if mem, found := recs[id]; found {
-			r = mem
-			ok = true
-		}
-	}
-	if !ok {
-		c.JSON(404, gin.H{"error": "not found"})
-		return
-	}
-	units := r.Units
-	if strings.ToUpper(r.Direction) == "SELL" {
-		units = -units
-	}
-	var resp *broker.OrderCreateResponse
-	var err error
-	// Use brackets if we have SL/TP from AI
-	if sl != nil || tp != nil {
-		resp, err = s.mt4Client.PlaceMarketOrderWithBrackets(r.Instrument, units, sl, tp)
-	} else {
-		resp, err = s.mt4Client.PlaceMarketOrder(r.Instrument, units)
-	}
+	rec, res, err := s.orders.AcceptRecommendation(c.Request.Context(), id, "recommendation")
 	if err != nil {
-		c.JSON(500, gin.H{"error": err.Error()})
+		writeOrderError(c, err)
 		return
 	}
-	// mark executed in DB and create trade record
-	if s.db != nil && resp != nil {
-		if isAI {
-			_ = s.db.MarkAIRecommendationExecuted(c.Request.Context(), id, resp.OrderCreateTransaction.ID)
-		} else {
-			_ = s.db.MarkRecommendationExecuted(c.Request.Context(), id, resp.OrderCreateTransaction.ID)
-		}
-		// Create trade row
-		entry := 0.0
-		if prices, perr := s.mt4Client.GetPrices([]string{r.Instrument}); perr == nil && len(prices) > 0 && len(prices[0].Bids) > 0 && len(prices[0].Asks) > 0 {
-			b := parseDecimal(prices[0].Bids[0].Price)
-			a := parseDecimal(prices[0].Asks[0].Price)
-			if b > 0 && a > 0 {
-				entry = (b + a) / 2
-			}
-		}
-		trade := &models.Trade{
-			ID:         "",
-			Instrument: r.Instrument,
-			Direction: func() string {
-				if units >= 0 {
-					return "BUY"
-				}
-				return "SELL"
-			}(),
-			Units:        units,
-			EntryPrice:   &entry,
-			Status:       models.TradeStatusOpen,
-			OandaTradeID: func() *string { id := resp.OrderCreateTransaction.ID; return &id }(),
-		}
-		_ = s.db.CreateTrade(c.Request.Context(), trade)
+	accepted := recommendation{ID: rec.ID, Instrument: rec.Instrument, Direction: rec.Direction, Units: rec.Units, Rationale: rec.Rationale, CreatedAt: rec.CreatedAt.Unix()}
+	if len(res.Warnings) > 0 {
+		c.JSON(200, gin.H{"accepted": accepted, "order": res.Order, "calendar_warnings": res.Warnings})
+		return
 	}
-	c.JSON(200, gin.H{"accepted": r, "order": resp})
+	c.JSON(200, gin.H{"accepted": accepted, "order": res.Order})
 }
 
 func (s *Server) deleteRecommendation(c *gin.Context) {
-	if s.db == nil {
-		c.JSON(503, gin.H{"error": "db not configured"})
-		return
-	}
 	id := c.Param("id")
-	if err := s.db.SoftDeleteRecommendation(c.Request.Context(), id); err != nil {
+	if err := s.store.SoftDeleteRecommendation(c.Request.Context(), id); err != nil {
 		c.JSON(500, gin.H{"error": err.Error()})
 		return
 	}
@@ -407,10 +500,24 @@ func (s *Server) aiGenerateRecommendation(c *gin.Context) {
 		c.JSON(503, gin.H{"error": "ai service not configured"})
 		return
 	}
+	if err := s.aiMeter.Allow(c.Request.Context(), ai.RecommendationModel()); err != nil {
+		var be *ai.BudgetError
+		switch {
+		case errors.As(err, &be):
+			c.JSON(429, gin.H{"error": err.Error(), "period": be.Period, "spent_usd": be.SpentUSD, "limit_usd": be.LimitUSD})
+		case errors.Is(err, ai.ErrUnpricedModel):
+			c.JSON(503, gin.H{"error": err.Error()})
+		default:
+			c.JSON(500, gin.H{"error": err.Error()})
+		}
+		return
+	}
 	log.Printf("[AI] recommend start instruments=%v risk=%s horizon=%s units=%d risk_percent=%.4f sl_pips=%.2f", req.Instruments, req.RiskLevel, req.TimeHorizon, req.Units, req.RiskPercent, req.StopLossPips)
 	start := time.Now()
 	rec, err := s.ai.GenerateRecommendation(c.Request.Context(), &req)
 	if err != nil {
+		// A billed call with an unusable answer still counts against the budget.
+		s.aiMeter.Record(c.Request.Context(), ai.UsageFromError(err), time.Since(start), "")
 		c.JSON(500, gin.H{"error": err.Error()})
 		return
 	}
@@ -430,37 +537,13 @@ func (s *Server) aiGenerateRecommendation(c *gin.Context) {
 		}
 	}
 	if mid > 0 {
-		pip := 0.0001
-		if strings.Contains(rec.Instrument, "JPY") {
-			pip = 0.01
-		}
-		distPips := 20.0
-		switch strings.ToLower(req.RiskLevel) {
-		case "low":
-			distPips = 30
-		case "high":
-			distPips = 10
-		}
-		rr := 2.0
-		sl := mid
-		tp := mid
-		if strings.ToUpper(rec.Direction) == "BUY" {
-			sl = mid - distPips*pip
-			tp = mid + rr*distPips*pip
-		} else {
-			sl = mid + distPips*pip
-			tp = mid - rr*distPips*pip
-		}
-		rec.StopLoss = &sl
-		rec.TakeProfit = &tp
+		ai.ApplyBrackets(rec, mid, req.RiskLevel)
 	}
 
 	// Position sizing
 	if req.Units > 0 {
 		rec.Units = req.Units
 	} else if (req.RiskPercent > 0 && req.StopLossPips > 0) || (req.RiskPercent > 0 && rec.StopLoss != nil) {
-		// Estimate pip value for EUR/USD ~ $10 per pip per 100k units; scale linearly
-		pipValuePerUnit := 10.0 / 100000.0 // USD per pip per unit
 		// Determine stop distance in pips
 		var slPips float64
 		if req.StopLossPips > 0 {
@@ -476,114 +559,102 @@ func (s *Server) aiGenerateRecommendation(c *gin.Context) {
 				}
 			}
 			if mid > 0 {
-				pip := 0.0001
-				if strings.Contains(rec.Instrument, "JPY") {
-					pip = 0.01
-				}
-				slPips = math.Abs(mid-*rec.StopLoss) / pip
+				slPips = math.Abs(mid-*rec.StopLoss) / models.PipSize(rec.Instrument)
 			}
 		}
 		// Get account NAV
 		account, accErr := s.mt4Client.GetAccount()
 		if accErr == nil && slPips > 0 {
-			riskUSD := account.NAV * req.RiskPercent
-			units := riskUSD / (slPips * pipValuePerUnit)
-			if units < 1 {
-				units = 1
-			}
-			rec.Units = int64(math.Round(units))
+			rec.Units = ai.RiskUnits(account.NAV, req.RiskPercent, slPips)
 		}
 	}
 
 	// Attempt to persist AI recommendation with contexts
 	var persistedID string
-	if s.db != nil {
-		marketJSON, _ := json.Marshal(rec.MarketData)
-		newsJSON, _ := json.Marshal(rec.NewsContext)
-		histJSON, _ := json.Marshal(struct {
-			Notes string `json:"notes"`
-		}{Notes: "pending"})
-
-		// Ensure we don't pass a non-UUID ID (e.g., "simulated") to the DB
-		safeID := rec.ID
-		if !isUUIDLike(safeID) {
-			safeID = ""
+	marketJSON, _ := json.Marshal(rec.MarketData)
+	newsJSON, _ := json.Marshal(rec.NewsContext)
+	histJSON, _ := json.Marshal(struct {
+		Notes string `json:"notes"`
+	}{Notes: "pending"})
+
+	// Ensure we don't pass a non-UUID ID (e.g., "simulated") to the store
+	safeID := rec.ID
+	if !isUUIDLike(safeID) {
+		safeID = ""
+	}
+
+	aiRow := &models.AIRecommendation{
+		ID:                safeID,
+		Instrument:        rec.Instrument,
+		Direction:         rec.Direction,
+		Units:             float64(rec.Units),
+		Confidence:        rec.Confidence,
+		Rationale:         rec.Rationale,
+		StopLoss:          rec.StopLoss,
+		TakeProfit:        rec.TakeProfit,
+		TimeToLive:        rec.TimeToLive,
+		MarketContext:     marketJSON,
+		NewsContext:       newsJSON,
+		HistoricalContext: histJSON,
+		Status:            models.AIRecommendationStatusPending,
+	}
+	if id, err := s.store.CreateAIRecommendation(c.Request.Context(), aiRow); err == nil {
+		rec.ID = id
+		persistedID = id
+		log.Printf("[AI] recommendation persisted id=%s instrument=%s dir=%s units=%d", id, rec.Instrument, rec.Direction, rec.Units)
+
+		// Mirror into legacy recommendations for compatibility with existing endpoints
+		rationale := rec.Rationale
+		status := models.RecommendationStatusPending
+		var confPtr *float64
+		if rec.Confidence > 0 {
+			v := rec.Confidence
+			confPtr = &v
 		}
-
-		aiRow := &models.AIRecommendation{
-			ID:                safeID,
-			Instrument:        rec.Instrument,
-			Direction:         rec.Direction,
-			Units:             float64(rec.Units),
-			Confidence:        rec.Confidence,
-			Rationale:         rec.Rationale,
-			StopLoss:          rec.StopLoss,
-			TakeProfit:        rec.TakeProfit,
-			TimeToLive:        rec.TimeToLive,
-			MarketContext:     marketJSON,
-			NewsContext:       newsJSON,
-			HistoricalContext: histJSON,
-			Status:            models.AIRecommendationStatusPending,
+		legacy := &models.Recommendation{
+			ID:               "", // let the store generate
+			Instrument:       rec.Instrument,
+			Direction:        rec.Direction,
+			Units:            float64(rec.Units),
+			Rationale:        &rationale,
+			ConfidenceScore:  confPtr,
+			MarketConditions: mirrorConditions(marketJSON, id, rec.StopLoss, rec.TakeProfit),
+			Status:           status,
 		}
-		if id, err := s.db.CreateAIRecommendation(c.Request.Context(), aiRow); err == nil {
-			rec.ID = id
-			persistedID = id
-			log.Printf("[AI] recommendation persisted id=%s instrument=%s dir=%s units=%d", id, rec.Instrument, rec.Direction, rec.Units)
-
-			// Mirror into legacy recommendations for compatibility with existing endpoints
-			rationale := rec.Rationale
-			status := models.RecommendationStatusPending
-			var confPtr *float64
-			if rec.Confidence > 0 {
-				v := rec.Confidence
-				confPtr = &v
-			}
-			legacy := &models.Recommendation{
-				ID:               "", // let DB generate
-				Instrument:       rec.Instrument,
-				Direction:        rec.Direction,
-				Units:            float64(rec.Units),
-				Rationale:        &rationale,
-				ConfidenceScore:  confPtr,
-				MarketConditions: marketJSON,
-				Status:           status,
-			}
-			if rid, err := s.db.CreateRecommendation(c.Request.Context(), legacy); err == nil {
-				log.Printf("[AI] legacy recommendation mirrored id=%s from ai_id=%s", rid, id)
-			} else {
-				log.Printf("[AI] mirror to legacy recommendations failed: %v", err)
-			}
+		if rid, err := s.store.CreateRecommendation(c.Request.Context(), legacy); err == nil {
+			log.Printf("[AI] legacy recommendation mirrored id=%s from ai_id=%s", rid, id)
 		} else {
-			log.Printf("[AI] persist recommendation error: %v", err)
+			log.Printf("[AI] mirror to legacy recommendations failed: %v", err)
 		}
+	} else {
+		log.Printf("[AI] persist recommendation error: %v", err)
 	}
 
 	elapsed := time.Since(start)
 	log.Printf("[AI] recommend done instrument=%s dir=%s units=%d elapsed=%s", rec.Instrument, rec.Direction, rec.Units, elapsed)
 
-	// Write AI usage log (approximate tokens based on payload sizes)
-	if s.db != nil && persistedID != "" {
-		promptTokens := len(req.Instruments)*4 + 20
-		completionTokens := 60
-		total := promptTokens + completionTokens
-		model := "simulated"
-		_ = s.db.CreateAIUsageLog(c.Request.Context(), persistedID, promptTokens, completionTokens, total, int(elapsed.Milliseconds()), model)
-	}
-
-	// Optional: write a small market analysis cache record for the instrument
-	if s.db != nil && len(req.Instruments) > 0 {
-		inst := req.Instruments[0]
-		if candles, err := s.mt4Client.GetCandles(inst, "M5", 20, nil, nil); err == nil && candles != nil {
-			summary := map[string]interface{}{"instrument": inst, "granularity": candles.Granularity, "count": len(candles.Candles)}
-			buf, _ := json.Marshal(summary)
-			expires := time.Now().Add(10 * time.Minute)
-			_ = s.db.InsertMarketAnalysisCache(c.Request.Context(), inst, buf, expires)
-		}
-	}
+	// Write AI usage log from the provider-reported token counts
+	s.aiMeter.Record(c.Request.Context(), rec.Usage, elapsed, persistedID)
 
 	c.JSON(200, rec)
 }
 
+// mirrorConditions adds what accepting a mirrored AI recommendation needs to its market data:
+// the ai source, the ai_recommendations row and the brackets.
+func mirrorConditions(market []byte, aiID string, stopLoss, takeProfit *float64) []byte {
+	conditions := make(map[string]interface{})
+	_ = json.Unmarshal(market, &conditions)
+	conditions["source"] = models.TradeSourceAI
+	conditions["ai_recommendation_id"] = aiID
+	conditions["stop_loss"] = stopLoss
+	conditions["take_profit"] = takeProfit
+	out, err := json.Marshal(conditions)
+	if err != nil {
+		return market
+	}
+	return out
+}
+
 // isUUIDLike performs a lightweight UUID format validation (xxxxxxxx-xxxx-xxxx-xxxx-xxxxxxxxxxxx)
 func isUUIDLike(s string) bool {
 	if len(s) != 36 {
@@ -607,3 +678,31 @@ func isUUIDLike(s string) bool {
 func (s *Server) aiStatus(c *gin.Context) {
 	c.JSON(200, gin.H{"status": "ok"})
 }
+
+func (s *Server) aiUsage(c *gin.Context) {
+	dayStart, monthStart := ai.BudgetPeriodStarts(time.Now())
+	daily, err := s.store.SummarizeAIUsage(c.Request.Context(), dayStart)
+	if err != nil {
+		c.JSON(500, gin.H{"error": err.Error()})
+		return
+	}
+	monthly, err := s.store.SummarizeAIUsage(c.Request.Context(), monthStart)
+	if err != nil {
+		c.JSON(500, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(200, gin.H{
+		"daily": gin.H{
+			"since":     dayStart,
+			"spent_usd": ai.SumUsageCost(daily),
+			"limit_usd": s.aiMeter.Budget().DailyUSD,
+			"by_model":  daily,
+		},
+		"monthly": gin.H{
+			"since":     monthStart,
+			"spent_usd": ai.SumUsageCost(monthly),
+			"limit_usd": s.aiMeter.Budget().MonthlyUSD,
+			"by_model":  monthly,
+		},
+	})
+}
@@ -0,0 +1,158 @@
+package api
+
+import (
+	"encoding/json"
+	"errors"
+	"io"
+	"log"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/jedi116/go-trader/internal/config"
+	"github.com/jedi116/go-trader/internal/risk"
+	"github.com/jedi116/go-trader/internal/signals"
+	"github.com/jedi116/go-trader/pkg/models"
+)
+
+const maxSignalBody = 64 << 10
+
+func (s *Server) receiveSignal(c *gin.Context)            { s.handleSignal(c, false) }
+func (s *Server) receiveTradingViewSignal(c *gin.Context) { s.handleSignal(c, true) }
+
+// handleSignal authenticates a signal, resolves it into an order and either trades it or stores
+// it as a recommendation, depending on signals.mode.
+func (s *Server) handleSignal(c *gin.Context, tradingView bool) {
+	cfg := s.config.Signals
+	if cfg.Secret == "" {
+		c.JSON(503, gin.H{"error": "signals not configured"})
+		return
+	}
+	body, err := io.ReadAll(io.LimitReader(c.Request.Body, maxSignalBody))
+	if err != nil {
+		c.JSON(400, gin.H{"error": "invalid request"})
+		return
+	}
+	authed := signals.VerifyHMAC(cfg.Secret, body, c.GetHeader(signals.SignatureHeader)) ||
+		(cfg.AllowSharedSecret && signals.VerifySecret(cfg.Secret, c.GetHeader("X-Signal-Secret")))
+	sig, passphrase, err := signals.Parse(body, tradingView)
+	if !authed && (err != nil || !cfg.AllowSharedSecret || !signals.VerifySecret(cfg.Secret, passphrase)) {
+		c.JSON(401, gin.H{"error": "signal authentication failed"})
+		return
+	}
+	if err != nil {
+		c.JSON(400, gin.H{"error": err.Error()})
+		return
+	}
+	if age := time.Since(sig.Time); age > signalMaxAge(cfg) || age < -signalMaxAge(cfg) {
+		c.JSON(422, gin.H{"error": "signal time outside the accepted window", "time": sig.Time, "max_age": signalMaxAge(cfg).String()})
+		return
+	}
+	if !s.signalIDs.Add(sig.ID) {
+		c.JSON(409, gin.H{"error": "duplicate signal", "duplicate": true, "id": sig.ID})
+		return
+	}
+	order, err := s.signals.Resolve(sig)
+	var ie *signals.InvalidError
+	if errors.As(err, &ie) {
+		s.signalIDs.Forget(sig.ID)
+		c.JSON(422, gin.H{"error": ie.Error()})
+		return
+	}
+	if err != nil {
+		s.signalIDs.Forget(sig.ID)
+		c.JSON(503, gin.H{"error": err.Error()})
+		return
+	}
+
+	if strings.ToLower(cfg.Mode) == "order" {
+		resp, warnings, ok := s.submitOrder(c, order)
+		if !ok {
+			// A queued order (202) counts as handled; anything refused may be resent.
+			if c.Writer.Status() >= 300 {
+				s.signalIDs.Forget(sig.ID)
+			}
+			return
+		}
+		s.auditSignal(c, sig, order, "SIGNAL_ORDER")
+		out := gin.H{"signal": sig, "order": resp}
+		if len(warnings) > 0 {
+			out["calendar_warnings"] = warnings
+		}
+		c.JSON(200, out)
+		return
+	}
+
+	if !s.checkRisk(c, order) {
+		s.signalIDs.Forget(sig.ID)
+		return
+	}
+	rec, err := s.createSignalRecommendation(c, sig, order)
+	if err != nil {
+		s.signalIDs.Forget(sig.ID)
+		c.JSON(500, gin.H{"error": err.Error()})
+		return
+	}
+	s.auditSignal(c, sig, order, "SIGNAL_RECOMMENDATION")
+	c.JSON(201, gin.H{"signal": sig, "recommendation": rec})
+}
+
+// signalMaxAge is how far a signal's time may be from now, either way, to be accepted.
+func signalMaxAge(cfg config.SignalsConfig) time.Duration {
+	if cfg.MaxAge > 0 {
+		return cfg.MaxAge
+	}
+	return signals.DefaultMaxAge
+}
+
+// signalDedupeWindow remembers IDs at least as long as a signal can be inside the time window,
+// so a replayed body is always caught either as stale or as a duplicate.
+func signalDedupeWindow(cfg config.SignalsConfig) time.Duration {
+	return max(cfg.DedupeWindow, 2*signalMaxAge(cfg))
+}
+
+// createSignalRecommendation stores the resolved order as a pending recommendation. Brackets go
+// into market_conditions, from where acceptRecommendation applies them.
+func (s *Server) createSignalRecommendation(c *gin.Context, sig *signals.Signal, o risk.Order) (*models.Recommendation, error) {
+	conditions, err := json.Marshal(map[string]interface{}{
+		"source":      "signal",
+		"signal_id":   sig.ID,
+		"strategy":    sig.Strategy,
+		"stop_loss":   o.StopLoss,
+		"take_profit": o.TakeProfit,
+	})
+	if err != nil {
+		return nil, err
+	}
+	rationale := "signal"
+	if sig.Strategy != "" {
+		rationale += " from " + sig.Strategy
+	}
+	if sig.Comment != "" {
+		rationale += ": " + sig.Comment
+	}
+	units := o.Units
+	if units < 0 {
+		units = -units
+	}
+	rec := &models.Recommendation{
+		Instrument:       o.Instrument,
+		Direction:        sig.Action,
+		Units:            units,
+		Rationale:        &rationale,
+		MarketConditions: conditions,
+		Status:           models.RecommendationStatusPending,
+	}
+	id, err := s.store.CreateRecommendation(c.Request.Context(), rec)
+	if err != nil {
+		return nil, err
+	}
+	rec.ID = id
+	return rec, nil
+}
+
+func (s *Server) auditSignal(c *gin.Context, sig *signals.Signal, o risk.Order, action string) {
+	if err := s.store.LogAudit(c.Request.Context(), "signals", "", action, map[string]interface{}{"signal": sig, "order": o}); err != nil {
+		log.Printf("[SIGNALS] audit error: %v", err)
+	}
+}
@@ -0,0 +1,124 @@
+package api
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"log"
+
+	"github.com/gin-gonic/gin"
+	"github.com/jedi116/go-trader/internal/risk"
+	"github.com/jedi116/go-trader/internal/strategy"
+	"github.com/jedi116/go-trader/pkg/models"
+)
+
+// strategyExecutor routes strategy orders through the live order pipeline or stores them as
+// pending recommendations, by instance execution mode.
+type strategyExecutor struct {
+	s *Server
+}
+
+func (e strategyExecutor) Execute(ctx context.Context, in *strategy.Instance, o strategy.OrderRequest) (*strategy.Fill, error) {
+	order := risk.Order{Instrument: o.Instrument, Units: o.Units, StopLoss: o.StopLoss, TakeProfit: o.TakeProfit, Source: "strategy:" + in.Name}
+	if in.Execution == strategy.ExecOrder {
+		resp, err := e.s.executeOrder(ctx, order)
+		if err != nil {
+			return nil, err
+		}
+		log.Printf("[STRATEGY] %s order %s units=%.0f oanda_order=%s reason=%q", in.Name, o.Instrument, o.Units, resp.OrderCreateTransaction.ID, o.Reason)
+		f := resp.OrderFillTransaction
+		if f == nil {
+			return nil, nil
+		}
+		return &strategy.Fill{OrderID: resp.OrderCreateTransaction.ID, Instrument: o.Instrument, Units: f.Units, Price: f.Price, Time: f.Time, Reason: o.Reason}, nil
+	}
+	if _, err := e.s.risk.Evaluate(ctx, order); err != nil {
+		return nil, err
+	}
+	id, err := e.s.createStrategyRecommendation(ctx, in, o)
+	if err != nil {
+		return nil, err
+	}
+	log.Printf("[STRATEGY] %s recommendation %s %s units=%.0f reason=%q", in.Name, id, o.Instrument, o.Units, o.Reason)
+	return nil, nil
+}
+
+// createStrategyRecommendation stores a strategy order as a pending recommendation, with its
+// brackets in market_conditions like signal recommendations.
+func (s *Server) createStrategyRecommendation(ctx context.Context, in *strategy.Instance, o strategy.OrderRequest) (string, error) {
+	conditions, err := json.Marshal(map[string]interface{}{
+		"source":      "strategy",
+		"strategy":    in.Name,
+		"type":        in.Type,
+		"timeframe":   in.Timeframe,
+		"stop_loss":   o.StopLoss,
+		"take_profit": o.TakeProfit,
+	})
+	if err != nil {
+		return "", err
+	}
+	rationale := "strategy " + in.Name
+	if o.Reason != "" {
+		rationale += ": " + o.Reason
+	}
+	direction, units := "BUY", o.Units
+	if units < 0 {
+		direction, units = "SELL", -units
+	}
+	return s.store.CreateRecommendation(ctx, &models.Recommendation{
+		Instrument:       o.Instrument,
+		Direction:        direction,
+		Units:            units,
+		Rationale:        &rationale,
+		MarketConditions: conditions,
+		Status:           models.RecommendationStatusPending,
+	})
+}
+
+func (s *Server) listStrategies(c *gin.Context) {
+	if s.strategies == nil {
+		c.JSON(200, gin.H{"strategies": []strategy.Status{}, "types": strategy.Types()})
+		return
+	}
+	c.JSON(200, gin.H{"strategies": s.strategies.Instances(), "types": strategy.Types()})
+}
+
+func (s *Server) getStrategy(c *gin.Context) {
+	if s.strategies == nil {
+		c.JSON(404, gin.H{"error": "no strategy with that name"})
+		return
+	}
+	st, err := s.strategies.Instance(c.Param("name"))
+	if err != nil {
+		c.JSON(404, gin.H{"error": "no strategy with that name"})
+		return
+	}
+	c.JSON(200, st)
+}
+
+func (s *Server) enableStrategy(c *gin.Context)  { s.setStrategyEnabled(c, true) }
+func (s *Server) disableStrategy(c *gin.Context) { s.setStrategyEnabled(c, false) }
+
+func (s *Server) setStrategyEnabled(c *gin.Context, enabled bool) {
+	if s.strategies == nil {
+		c.JSON(404, gin.H{"error": "no strategy with that name"})
+		return
+	}
+	st, err := s.strategies.SetEnabled(c.Param("name"), enabled)
+	if errors.Is(err, strategy.ErrUnknownStrategy) {
+		c.JSON(404, gin.H{"error": "no strategy with that name"})
+		return
+	}
+	if err != nil {
+		c.JSON(500, gin.H{"error": err.Error()})
+		return
+	}
+	action := "STRATEGY_DISABLED"
+	if enabled {
+		action = "STRATEGY_ENABLED"
+	}
+	if err := s.store.LogAudit(c.Request.Context(), "strategies", "", action, map[string]interface{}{"strategy": st.Name}); err != nil {
+		log.Printf("[STRATEGY] audit error: %v", err)
+	}
+	c.JSON(200, st)
+}
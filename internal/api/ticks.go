@@ -0,0 +1,134 @@
+package api
+
+import (
+	"encoding/csv"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/jedi116/go-trader/internal/ticks"
+	"github.com/jedi116/go-trader/pkg/models"
+)
+
+// tickRange reads ?from and ?to (RFC3339), defaulting to the span before now. It writes the
+// error response and reports false when either is invalid.
+func tickRange(c *gin.Context, span time.Duration) (time.Time, time.Time, bool) {
+	to := time.Now().UTC()
+	if v := c.Query("to"); v != "" {
+		t, err := time.Parse(time.RFC3339, v)
+		if err != nil {
+			c.JSON(400, gin.H{"error": "invalid to"})
+			return time.Time{}, time.Time{}, false
+		}
+		to = t
+	}
+	from := to.Add(-span)
+	if v := c.Query("from"); v != "" {
+		t, err := time.Parse(time.RFC3339, v)
+		if err != nil {
+			c.JSON(400, gin.H{"error": "invalid from"})
+			return time.Time{}, time.Time{}, false
+		}
+		from = t
+	}
+	if !to.After(from) {
+		c.JSON(400, gin.H{"error": "to must be after from"})
+		return time.Time{}, time.Time{}, false
+	}
+	return from, to, true
+}
+
+// getTickStatus reports the tick recorder: stream state, counters, the candles being built and
+// how built candles compare with OANDA's.
+func (s *Server) getTickStatus(c *gin.Context) {
+	if s.ticks == nil {
+		c.JSON(503, gin.H{"error": "tick recording disabled"})
+		return
+	}
+	c.JSON(200, s.ticks.Status())
+}
+
+// listTicks returns an instrument's ticks between ?from and ?to (RFC3339, default the last
+// hour), oldest first. ?limit caps the rows, 10000 by default and 100000 at most; ?format=csv
+// downloads them. Longer ranges stream through the ticks export.
+func (s *Server) listTicks(c *gin.Context) {
+	from, to, ok := tickRange(c, time.Hour)
+	if !ok {
+		return
+	}
+	instrument := strings.ToUpper(c.Param("instrument"))
+	limit, _ := strconv.Atoi(c.Query("limit"))
+	rows, err := s.store.ListTicks(c.Request.Context(), instrument, from, to, limit)
+	if err != nil {
+		c.JSON(500, gin.H{"error": err.Error()})
+		return
+	}
+	if c.Query("format") == "csv" {
+		csvAttachment(c, instrument+"_ticks.csv")
+		cw := csv.NewWriter(c.Writer)
+		_ = cw.Write([]string{"time", "bid", "ask", "spread"})
+		for _, t := range rows {
+			_ = cw.Write([]string{t.Time.UTC().Format(time.RFC3339Nano), strconv.FormatFloat(t.Bid, 'f', -1, 64),
+				strconv.FormatFloat(t.Ask, 'f', -1, 64), strconv.FormatFloat(t.Spread(), 'f', -1, 64)})
+		}
+		cw.Flush()
+		return
+	}
+	if rows == nil {
+		rows = []models.Tick{}
+	}
+	c.JSON(200, gin.H{"instrument": instrument, "from": from, "to": to, "ticks": rows})
+}
+
+// getTickSpreads summarizes an instrument's spreads between ?from and ?to (default the last
+// day) per ?interval (a Go duration, default 1h, at least a second), in price units and pips.
+func (s *Server) getTickSpreads(c *gin.Context) {
+	from, to, ok := tickRange(c, 24*time.Hour)
+	if !ok {
+		return
+	}
+	interval := time.Hour
+	if v := c.Query("interval"); v != "" {
+		d, err := time.ParseDuration(v)
+		if err != nil || d < time.Second {
+			c.JSON(400, gin.H{"error": "invalid interval"})
+			return
+		}
+		interval = d
+	}
+	instrument := strings.ToUpper(c.Param("instrument"))
+	stats, err := s.store.TickSpreadStats(c.Request.Context(), instrument, from, to, interval)
+	if err != nil {
+		c.JSON(500, gin.H{"error": err.Error()})
+		return
+	}
+	if stats == nil {
+		stats = []models.SpreadStats{}
+	}
+	c.JSON(200, gin.H{"instrument": instrument, "from": from, "to": to, "interval": interval.String(), "pip_size": models.PipSize(instrument), "spreads": stats})
+}
+
+// getTickCandles builds ?timeframe candles (M1 to H1, default M1) with mid, bid and ask prices
+// from the ticks between ?from and ?to (default the last day).
+func (s *Server) getTickCandles(c *gin.Context) {
+	from, to, ok := tickRange(c, 24*time.Hour)
+	if !ok {
+		return
+	}
+	tf := strings.ToUpper(c.DefaultQuery("timeframe", "M1"))
+	if _, err := ticks.Timeframe(tf); err != nil {
+		c.JSON(400, gin.H{"error": err.Error()})
+		return
+	}
+	instrument := strings.ToUpper(c.Param("instrument"))
+	candles, err := ticks.CandleStore{Ticks: s.store}.ListMarketDataRange(c.Request.Context(), instrument, tf, from, to)
+	if err != nil {
+		c.JSON(500, gin.H{"error": err.Error()})
+		return
+	}
+	if candles == nil {
+		candles = []models.MarketData{}
+	}
+	c.JSON(200, gin.H{"instrument": instrument, "timeframe": tf, "from": from, "to": to, "candles": candles})
+}
@@ -2,6 +2,7 @@ package api
 
 import (
 	"strconv"
+	"strings"
 )
 
 func parseDecimal(s string) float64 {
@@ -14,3 +15,14 @@ func parseDecimal(s string) float64 {
 	}
 	return v
 }
+
+// splitCSV splits a comma-separated query value, trimming blanks and upper-casing items.
+func splitCSV(v string) []string {
+	var out []string
+	for _, item := range strings.Split(v, ",") {
+		if item = strings.ToUpper(strings.TrimSpace(item)); item != "" {
+			out = append(out, item)
+		}
+	}
+	return out
+}
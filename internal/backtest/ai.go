@@ -0,0 +1,385 @@
+package backtest
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/jedi116/go-trader/internal/ai"
+	"github.com/jedi116/go-trader/internal/strategy"
+	"github.com/jedi116/go-trader/pkg/models"
+)
+
+// AIStore reads everything an AI replay rebuilds its context from; database.Store implements
+// it.
+type AIStore interface {
+	Store
+	ListNewsArticles(ctx context.Context, from, to time.Time, currencies []string, limit int) ([]models.NewsArticle, error)
+	ListEconomicEvents(ctx context.Context, from, to time.Time, currencies []string) ([]models.EconomicEvent, error)
+}
+
+// AIContextOptions shape the context a replay aggregator rebuilds. Zero values match the live
+// aggregator: the last 50 candles, 5 articles per currency from the last day, and calendar events
+// over the next 48 hours.
+type AIContextOptions struct {
+	Granularity     string
+	CandleCount     int
+	NewsMaxAge      time.Duration
+	NewsPerCurrency int
+	EventLookahead  time.Duration
+}
+
+func (o AIContextOptions) withDefaults() AIContextOptions {
+	if o.Granularity == "" {
+		o.Granularity = "M5"
+	}
+	if o.CandleCount <= 0 {
+		o.CandleCount = 50
+	}
+	if o.NewsMaxAge <= 0 {
+		o.NewsMaxAge = 24 * time.Hour
+	}
+	if o.NewsPerCurrency <= 0 {
+		o.NewsPerCurrency = 5
+	}
+	if o.EventLookahead <= 0 {
+		o.EventLookahead = 48 * time.Hour
+	}
+	return o
+}
+
+// NewAIAggregator builds an aggregator that reads stored market data, archived news and the
+// calendar as of the simulated time set with ai.WithAsOf, so nothing after that time reaches the
+// model. Only candles that had closed by then are used.
+func NewAIAggregator(store AIStore, opts AIContextOptions) ai.Aggregator {
+	opts = opts.withDefaults()
+	asOf := func(ctx context.Context) (time.Time, error) {
+		t, ok := ai.AsOf(ctx)
+		if !ok {
+			return t, fmt.Errorf("backtest: replay aggregator needs ai.WithAsOf")
+		}
+		return t, nil
+	}
+	return ai.NewAggregator(
+		func(ctx context.Context, instruments []string) (*ai.MarketContext, error) {
+			t, err := asOf(ctx)
+			if err != nil {
+				return nil, err
+			}
+			bar, ok := models.Granularity(opts.Granularity)
+			if !ok {
+				return nil, fmt.Errorf("backtest: unsupported granularity %s", opts.Granularity)
+			}
+			// Leave room for weekends and holidays before the last candle.
+			from := t.Add(-time.Duration(opts.CandleCount)*bar - 96*time.Hour)
+			to := t.Add(-bar + time.Nanosecond)
+			marketInfo := map[string]interface{}{"list": instruments}
+			for _, inst := range instruments {
+				rows, err := store.ListMarketDataRange(ctx, inst, opts.Granularity, from, to)
+				if err != nil {
+					return nil, err
+				}
+				if len(rows) == 0 {
+					continue
+				}
+				if len(rows) > opts.CandleCount {
+					rows = rows[len(rows)-opts.CandleCount:]
+				}
+				marketInfo[inst] = map[string]interface{}{
+					"granularity": opts.Granularity,
+					"last_close":  rows[len(rows)-1].ClosePrice,
+					"count":       len(rows),
+				}
+			}
+			return &ai.MarketContext{Instruments: marketInfo}, nil
+		},
+		func(ctx context.Context, instruments []string) ([]ai.NewsItem, error) {
+			t, err := asOf(ctx)
+			if err != nil {
+				return nil, err
+			}
+			codes := models.CurrenciesFor(instruments)
+			articles, err := store.ListNewsArticles(ctx, t.Add(-opts.NewsMaxAge), t, codes, opts.NewsPerCurrency*len(codes))
+			if err != nil {
+				return nil, err
+			}
+			out := make([]ai.NewsItem, 0, len(articles))
+			for _, a := range articles {
+				item := ai.NewsItem{Title: a.Title, Url: a.URL, Snippet: a.Snippet, Source: a.Source, Sentiment: a.Sentiment, Relevance: a.Relevance}
+				if a.PublishedAt != nil {
+					item.Published = a.PublishedAt.Format(time.RFC3339)
+				}
+				out = append(out, item)
+			}
+			return out, nil
+		},
+		func(ctx context.Context, instruments []string) (*ai.HistoricalContext, error) {
+			return &ai.HistoricalContext{Notes: "pending"}, nil
+		},
+		func(ctx context.Context, instruments []string) ([]ai.EconomicEvent, error) {
+			t, err := asOf(ctx)
+			if err != nil {
+				return nil, err
+			}
+			var currencies []string
+			for _, inst := range instruments {
+				currencies = append(currencies, models.InstrumentCurrencies(inst)...)
+			}
+			events, err := store.ListEconomicEvents(ctx, t, t.Add(opts.EventLookahead), currencies)
+			if err != nil {
+				return nil, err
+			}
+			out := make([]ai.EconomicEvent, 0, len(events))
+			for _, e := range events {
+				// Actual values are left out; they were not known yet.
+				ev := ai.EconomicEvent{Title: e.Title, Currency: e.Currency, Impact: string(e.Impact), Time: e.EventTime}
+				if e.Forecast != nil {
+					ev.Forecast = *e.Forecast
+				}
+				if e.Previous != nil {
+					ev.Previous = *e.Previous
+				}
+				out = append(out, ev)
+			}
+			return out, nil
+		},
+	)
+}
+
+// AIReplayOptions configure how recommendations are requested and traded.
+type AIReplayOptions struct {
+	// Request is sent at every decision; its Instruments default to the replayed ones. Units
+	// sizes every trade; otherwise RiskPercent with StopLossPips or the bracket sizes it from the
+	// initial balance, and failing that the recommendation's own units are used.
+	Request ai.RecommendationRequest `json:"request"`
+	// Every is the time between decisions, default 4h. Decisions are made at candle closes.
+	Every time.Duration `json:"every"`
+	// MinConfidence skips recommendations the model is less sure of.
+	MinConfidence float64 `json:"min_confidence,omitempty"`
+	// MaxHold closes positions held this long; zero leaves exits to the brackets and reversals.
+	MaxHold time.Duration `json:"max_hold,omitempty"`
+	// MaxCalls bounds model calls, default 500.
+	MaxCalls int `json:"max_calls"`
+	// Pricing prices the reported token usage.
+	Pricing ai.PriceTable `json:"-"`
+}
+
+// Decision is one recommendation request during a replay and what was done with it.
+type Decision struct {
+	Time       time.Time `json:"time"`
+	Instrument string    `json:"instrument,omitempty"`
+	Direction  string    `json:"direction,omitempty"`
+	Confidence float64   `json:"confidence,omitempty"`
+	Units      float64   `json:"units,omitempty"`
+	StopLoss   *float64  `json:"stop_loss,omitempty"`
+	TakeProfit *float64  `json:"take_profit,omitempty"`
+	Rationale  string    `json:"rationale,omitempty"`
+	// Action is opened, reversed, held (already positioned that way) or skipped.
+	Action  string  `json:"action"`
+	Error   string  `json:"error,omitempty"`
+	CostUSD float64 `json:"cost_usd,omitempty"`
+}
+
+// Decision actions.
+const (
+	ActionOpened   = "opened"
+	ActionReversed = "reversed"
+	ActionHeld     = "held"
+	ActionSkipped  = "skipped"
+)
+
+// ExitMaxHold closes positions held for AIReplayOptions.MaxHold.
+const ExitMaxHold = "max_hold"
+
+// AIReplayResult is a backtest of the recommendations plus every decision behind it.
+type AIReplayResult struct {
+	*Result
+	Decisions []Decision `json:"decisions"`
+	Calls     int        `json:"calls"`
+	Errors    int        `json:"errors"`
+	CostUSD   float64    `json:"cost_usd"`
+}
+
+// aiStrategy asks the AI service for a recommendation at each decision time and trades it.
+type aiStrategy struct {
+	strategy.Base
+	ctx      context.Context
+	svc      ai.Service
+	opts     AIReplayOptions
+	bar      time.Duration
+	balance  float64
+	lead     string
+	last     time.Time
+	openedAt map[string]time.Time
+	// closedAt marks max-hold exits still pending, so a decision at the same close sees the
+	// instrument as flat.
+	closedAt map[string]time.Time
+	res      *AIReplayResult
+}
+
+// RunAI replays data through the AI service. At each decision the service sees only what was
+// known at that candle close; the recommendation is bracketed and sized as the live endpoint does
+// and traded through the simulated broker. Build svc with NewAIAggregator, and with an
+// ai.Recorder to reuse recorded answers instead of calling the model.
+func RunAI(ctx context.Context, data Data, svc ai.Service, opts Options, ro AIReplayOptions) (*AIReplayResult, error) {
+	bar, ok := models.Granularity(data.Timeframe)
+	if !ok {
+		return nil, fmt.Errorf("backtest: unsupported timeframe %s", data.Timeframe)
+	}
+	if ro.Every <= 0 {
+		ro.Every = 4 * time.Hour
+	}
+	if ro.MaxCalls <= 0 {
+		ro.MaxCalls = 500
+	}
+	instruments := data.Instruments()
+	if len(instruments) == 0 {
+		return nil, fmt.Errorf("backtest: no bars to replay")
+	}
+	if len(ro.Request.Instruments) == 0 {
+		ro.Request.Instruments = instruments
+	}
+	if opts.Name == "" {
+		opts.Name = "ai"
+	}
+	opts = opts.withDefaults()
+	s := &aiStrategy{
+		ctx:      ctx,
+		svc:      svc,
+		opts:     ro,
+		bar:      bar,
+		balance:  opts.InitialBalance,
+		lead:     instruments[0],
+		openedAt: make(map[string]time.Time),
+		closedAt: make(map[string]time.Time),
+		res:      &AIReplayResult{Decisions: []Decision{}},
+	}
+	res, err := Run(data, s, opts)
+	if err != nil {
+		return nil, err
+	}
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	s.res.Result = res
+	return s.res, nil
+}
+
+func (s *aiStrategy) OnFill(ctx strategy.Context, f strategy.Fill) error {
+	if ctx.Position(f.Instrument).Units == 0 {
+		delete(s.openedAt, f.Instrument)
+	} else if _, ok := s.openedAt[f.Instrument]; !ok {
+		s.openedAt[f.Instrument] = f.Time
+	}
+	return nil
+}
+
+func (s *aiStrategy) OnCandle(ctx strategy.Context, c strategy.Candle) error {
+	asOf := c.Time.Add(s.bar)
+	if s.opts.MaxHold > 0 {
+		if opened, ok := s.openedAt[c.Instrument]; ok && !asOf.Before(opened.Add(s.opts.MaxHold)) {
+			delete(s.openedAt, c.Instrument)
+			s.closedAt[c.Instrument] = asOf
+			if err := strategy.ClosePosition(ctx, c.Instrument, ExitMaxHold); err != nil {
+				return err
+			}
+		}
+	}
+	// One decision per timestamp, taken on the lead instrument's candle.
+	if c.Instrument != s.lead || s.ctx.Err() != nil || s.res.Calls >= s.opts.MaxCalls {
+		return nil
+	}
+	if !s.last.IsZero() && asOf.Sub(s.last) < s.opts.Every {
+		return nil
+	}
+	s.last = asOf
+	s.res.Calls++
+	d := s.decide(ctx, asOf)
+	if d.Error != "" {
+		s.res.Errors++
+	}
+	s.res.CostUSD += d.CostUSD
+	s.res.Decisions = append(s.res.Decisions, d)
+	return nil
+}
+
+func (s *aiStrategy) decide(ctx strategy.Context, asOf time.Time) Decision {
+	d := Decision{Time: asOf, Action: ActionSkipped}
+	req := s.opts.Request
+	rec, err := s.svc.GenerateRecommendation(ai.WithAsOf(s.ctx, asOf), &req)
+	if err != nil {
+		if u := ai.UsageFromError(err); u != nil {
+			d.CostUSD = s.opts.Pricing.Cost(*u)
+		}
+		d.Error = err.Error()
+		return d
+	}
+	if rec.Usage != nil {
+		d.CostUSD = s.opts.Pricing.Cost(*rec.Usage)
+	}
+	d.Instrument, d.Confidence, d.Rationale = rec.Instrument, rec.Confidence, rec.Rationale
+	d.Direction = strings.ToUpper(rec.Direction)
+	var sign float64
+	switch d.Direction {
+	case "BUY":
+		sign = 1
+	case "SELL":
+		sign = -1
+	default:
+		return d
+	}
+	if rec.Confidence < s.opts.MinConfidence {
+		return d
+	}
+	bars := ctx.Candles(rec.Instrument)
+	if len(bars) == 0 {
+		d.Error = fmt.Sprintf("%s is not part of the replay", rec.Instrument)
+		return d
+	}
+	mid := bars[len(bars)-1].Close
+	ai.ApplyBrackets(rec, mid, req.RiskLevel)
+	d.StopLoss, d.TakeProfit = rec.StopLoss, rec.TakeProfit
+
+	units := float64(rec.Units)
+	switch {
+	case req.Units > 0:
+		units = float64(req.Units)
+	case req.RiskPercent > 0:
+		slPips := req.StopLossPips
+		if slPips <= 0 {
+			slPips = (mid - *rec.StopLoss) * sign / models.PipSize(rec.Instrument)
+		}
+		if slPips > 0 {
+			units = float64(ai.RiskUnits(s.balance, req.RiskPercent, slPips))
+		}
+	}
+	if units <= 0 {
+		d.Error = "recommendation has no units"
+		return d
+	}
+	d.Units = units
+
+	held := ctx.Position(rec.Instrument).Units
+	if s.closedAt[rec.Instrument].Equal(asOf) {
+		held = 0
+	}
+	switch {
+	case held*sign > 0:
+		d.Action = ActionHeld
+		return d
+	case held != 0:
+		if err := strategy.ClosePosition(ctx, rec.Instrument, "reversal"); err != nil {
+			d.Error = err.Error()
+			return d
+		}
+		d.Action = ActionReversed
+	default:
+		d.Action = ActionOpened
+	}
+	err = ctx.Submit(strategy.OrderRequest{Instrument: rec.Instrument, Units: sign * units, StopLoss: rec.StopLoss, TakeProfit: rec.TakeProfit, Reason: "ai"})
+	if err != nil {
+		d.Action, d.Error = ActionSkipped, err.Error()
+	}
+	return d
+}
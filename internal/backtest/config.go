@@ -0,0 +1,37 @@
+package backtest
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/jedi116/go-trader/internal/config"
+)
+
+// DefaultMaxBars bounds API-started runs when backtest.max_bars is unset.
+const DefaultMaxBars = 200000
+
+// OptionsFromConfig returns the configured defaults; callers fill in the strategy.
+func OptionsFromConfig(cfg config.BacktestConfig) Options {
+	return Options{
+		InitialBalance: cfg.InitialBalance,
+		Currency:       cfg.Currency,
+		Costs: Costs{
+			SpreadPips:           cfg.SpreadPips,
+			SlippagePips:         cfg.SlippagePips,
+			CommissionPerMillion: cfg.CommissionPerMillion,
+		},
+		Intrabar: cfg.Intrabar,
+	}
+}
+
+// ParseTime accepts RFC3339 timestamps and YYYY-MM-DD dates (midnight UTC).
+func ParseTime(s string) (time.Time, error) {
+	if t, err := time.Parse(time.RFC3339, s); err == nil {
+		return t, nil
+	}
+	t, err := time.Parse("2006-01-02", s)
+	if err != nil {
+		return time.Time{}, fmt.Errorf("time %q must be RFC3339 or YYYY-MM-DD", s)
+	}
+	return t, nil
+}
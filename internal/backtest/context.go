@@ -0,0 +1,65 @@
+package backtest
+
+import (
+	"fmt"
+	"log"
+	"time"
+
+	"github.com/jedi116/go-trader/internal/strategy"
+)
+
+// simContext is the strategy.Context of a replay. Positions, prices and candles reflect the
+// simulation up to the bar being handled.
+type simContext struct {
+	sm *sim
+}
+
+func (c *simContext) Now() time.Time          { return c.sm.now }
+func (c *simContext) Name() string            { return c.sm.opts.Name }
+func (c *simContext) Params() strategy.Params { return c.sm.opts.Params }
+
+func (c *simContext) Candles(instrument string) []strategy.Candle {
+	return c.sm.history.Candles(instrument, c.sm.data.Timeframe)
+}
+
+func (c *simContext) Indicator(instrument, name string, period int) []float64 {
+	return strategy.Indicator(c.Candles(instrument), name, period)
+}
+
+func (c *simContext) Position(instrument string) strategy.Position {
+	pos := strategy.Position{Instrument: instrument}
+	p := c.sm.positions[instrument]
+	if p == nil || p.units == 0 {
+		return pos
+	}
+	b := c.sm.last[instrument]
+	px := c.sm.exitPrice(b, p.units, b.Mid.Close, side(b.Bid, b.Ask, p.units < 0, closeOf))
+	pos.Units, pos.AvgPrice = p.units, p.avg
+	pos.UnrealizedPL = p.units * (px - p.avg) * c.sm.rate(instrument)
+	return pos
+}
+
+func (c *simContext) Price(instrument string) (strategy.Tick, bool) {
+	b, ok := c.sm.last[instrument]
+	if !ok {
+		return strategy.Tick{}, false
+	}
+	return c.sm.tick(b), true
+}
+
+func (c *simContext) Submit(o strategy.OrderRequest) error {
+	if o.Units == 0 {
+		return fmt.Errorf("strategy: order needs non-zero units")
+	}
+	if !c.sm.set[o.Instrument] {
+		return fmt.Errorf("strategy: %s is not part of the backtest", o.Instrument)
+	}
+	c.sm.pending[o.Instrument] = append(c.sm.pending[o.Instrument], o)
+	return nil
+}
+
+func (c *simContext) Logf(format string, args ...interface{}) {
+	if c.sm.opts.Verbose {
+		log.Printf("[BACKTEST] %s %s: %s", c.sm.opts.Name, c.sm.now.Format(time.RFC3339), fmt.Sprintf(format, args...))
+	}
+}
@@ -0,0 +1,134 @@
+package backtest
+
+import (
+	"encoding/csv"
+	"fmt"
+	"io"
+	"sort"
+	"strconv"
+	"time"
+)
+
+func ftoa(f float64) string { return strconv.FormatFloat(f, 'f', -1, 64) }
+
+var metricsHeader = []string{"net_profit", "total_return", "cagr", "sharpe", "sortino", "max_drawdown", "profit_factor", "trades", "win_rate", "expectancy"}
+
+func metricsRow(m *Metrics) []string {
+	if m == nil {
+		return make([]string, len(metricsHeader))
+	}
+	pf := ""
+	if m.ProfitFactor != nil {
+		pf = ftoa(*m.ProfitFactor)
+	}
+	return []string{ftoa(m.NetProfit), ftoa(m.TotalReturn), ftoa(m.CAGR), ftoa(m.Sharpe), ftoa(m.Sortino),
+		ftoa(m.MaxDrawdown), pf, strconv.Itoa(m.Trades), ftoa(m.WinRate), ftoa(m.Expectancy)}
+}
+
+// paramNames is the sorted union of the parameter names.
+func paramNames(params ...map[string]interface{}) []string {
+	seen := make(map[string]bool)
+	var out []string
+	for _, p := range params {
+		for k := range p {
+			if !seen[k] {
+				seen[k] = true
+				out = append(out, k)
+			}
+		}
+	}
+	sort.Strings(out)
+	return out
+}
+
+func paramValue(p map[string]interface{}, k string) string {
+	v, ok := p[k]
+	if !ok || v == nil {
+		return ""
+	}
+	if f, ok := v.(float64); ok {
+		return ftoa(f)
+	}
+	return fmt.Sprint(v)
+}
+
+// WriteCandidatesCSV writes the ranked table of a search, one column per parameter.
+func WriteCandidatesCSV(w io.Writer, cands []Candidate) error {
+	params := make([]map[string]interface{}, len(cands))
+	for i, c := range cands {
+		params[i] = c.Params
+	}
+	names := paramNames(params...)
+	cw := csv.NewWriter(w)
+	header := append([]string{"rank", "score"}, names...)
+	header = append(header, "final_equity")
+	_ = cw.Write(append(header, metricsHeader...))
+	for _, c := range cands {
+		row := []string{strconv.Itoa(c.Rank), ftoa(c.Score)}
+		for _, k := range names {
+			row = append(row, paramValue(c.Params, k))
+		}
+		row = append(row, ftoa(c.FinalEquity))
+		_ = cw.Write(append(row, metricsRow(&c.Metrics)...))
+	}
+	cw.Flush()
+	return cw.Error()
+}
+
+// WriteWindowsCSV writes one row per walk-forward window with its chosen parameters and its
+// out-of-sample metrics.
+func WriteWindowsCSV(w io.Writer, windows []Window) error {
+	params := make([]map[string]interface{}, len(windows))
+	for i, win := range windows {
+		params[i] = win.Params
+	}
+	names := paramNames(params...)
+	cw := csv.NewWriter(w)
+	header := append([]string{"index", "in_sample_from", "in_sample_to", "out_sample_from", "out_sample_to"}, names...)
+	header = append(header, "in_sample_sharpe")
+	header = append(header, metricsHeader...)
+	_ = cw.Write(append(header, "error"))
+	for _, win := range windows {
+		row := []string{strconv.Itoa(win.Index), win.InSampleFrom.Format(time.RFC3339), win.InSampleTo.Format(time.RFC3339),
+			win.OutSampleFrom.Format(time.RFC3339), win.OutSampleTo.Format(time.RFC3339)}
+		for _, k := range names {
+			row = append(row, paramValue(win.Params, k))
+		}
+		isSharpe := ""
+		if win.InSample != nil {
+			isSharpe = ftoa(win.InSample.Sharpe)
+		}
+		row = append(row, isSharpe)
+		row = append(row, metricsRow(win.OutSample)...)
+		_ = cw.Write(append(row, win.Error))
+	}
+	cw.Flush()
+	return cw.Error()
+}
+
+// WriteEquityCSV writes an equity curve.
+func WriteEquityCSV(w io.Writer, equity []EquityPoint) error {
+	cw := csv.NewWriter(w)
+	_ = cw.Write([]string{"time", "balance", "equity"})
+	for _, p := range equity {
+		_ = cw.Write([]string{p.Time.Format(time.RFC3339), strconv.FormatFloat(p.Balance, 'f', 2, 64), strconv.FormatFloat(p.Equity, 'f', 2, 64)})
+	}
+	cw.Flush()
+	return cw.Error()
+}
+
+// WriteTradesCSV writes round-trip trades.
+func WriteTradesCSV(w io.Writer, trades []Trade) error {
+	cw := csv.NewWriter(w)
+	_ = cw.Write([]string{"instrument", "side", "units", "entry_time", "entry_price", "exit_time", "exit_price", "exit_reason", "commission", "pl"})
+	for _, t := range trades {
+		_ = cw.Write([]string{
+			t.Instrument, t.Side, ftoa(t.Units),
+			t.EntryTime.Format(time.RFC3339), ftoa(t.EntryPrice),
+			t.ExitTime.Format(time.RFC3339), ftoa(t.ExitPrice),
+			t.ExitReason, strconv.FormatFloat(t.Commission, 'f', 2, 64), strconv.FormatFloat(t.PL, 'f', 2, 64),
+		})
+	}
+	cw.Flush()
+	return cw.Error()
+}
@@ -0,0 +1,115 @@
+package backtest
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sort"
+	"time"
+
+	"github.com/jedi116/go-trader/pkg/models"
+)
+
+// Bar is one replayed candle. Time is the bar's open time, as stored by OANDA. Bid and Ask are
+// optional; without them prices are derived from Mid and the spread model.
+type Bar struct {
+	Instrument string       `json:"instrument"`
+	Time       time.Time    `json:"time"`
+	Mid        models.OHLC  `json:"mid"`
+	Bid        *models.OHLC `json:"bid,omitempty"`
+	Ask        *models.OHLC `json:"ask,omitempty"`
+	Volume     int64        `json:"volume,omitempty"`
+}
+
+// Data holds the bars of each instrument, oldest first.
+type Data struct {
+	Timeframe string
+	Bars      map[string][]Bar
+}
+
+// Instruments lists the instruments in name order.
+func (d Data) Instruments() []string {
+	out := make([]string, 0, len(d.Bars))
+	for inst := range d.Bars {
+		out = append(out, inst)
+	}
+	sort.Strings(out)
+	return out
+}
+
+// Slice returns the bars with from <= time < to; zero bounds are open. The bars are shared.
+func (d Data) Slice(from, to time.Time) Data {
+	out := Data{Timeframe: d.Timeframe, Bars: make(map[string][]Bar, len(d.Bars))}
+	for inst, bars := range d.Bars {
+		lo, hi := 0, len(bars)
+		if !from.IsZero() {
+			lo = sort.Search(len(bars), func(i int) bool { return !bars[i].Time.Before(from) })
+		}
+		if !to.IsZero() {
+			hi = sort.Search(len(bars), func(i int) bool { return !bars[i].Time.Before(to) })
+		}
+		if lo > hi {
+			lo = hi
+		}
+		out.Bars[inst] = bars[lo:hi]
+	}
+	return out
+}
+
+// Span returns the first and last bar times across all instruments.
+func (d Data) Span() (time.Time, time.Time) {
+	var first, last time.Time
+	for _, bars := range d.Bars {
+		if len(bars) == 0 {
+			continue
+		}
+		if first.IsZero() || bars[0].Time.Before(first) {
+			first = bars[0].Time
+		}
+		if t := bars[len(bars)-1].Time; t.After(last) {
+			last = t
+		}
+	}
+	return first, last
+}
+
+// Len is the total number of bars.
+func (d Data) Len() int {
+	n := 0
+	for _, bars := range d.Bars {
+		n += len(bars)
+	}
+	return n
+}
+
+// ErrNoData is wrapped by Load when an instrument has no candles in the range.
+var ErrNoData = errors.New("backtest: no candles")
+
+// Store reads stored candles; database.Store implements it.
+type Store interface {
+	ListMarketDataRange(ctx context.Context, instrument, timeframe string, from, to time.Time) ([]models.MarketData, error)
+}
+
+// Load reads the market_data candles of each instrument with from <= time < to.
+func Load(ctx context.Context, store Store, instruments []string, timeframe string, from, to time.Time) (Data, error) {
+	d := Data{Timeframe: timeframe, Bars: make(map[string][]Bar, len(instruments))}
+	for _, inst := range instruments {
+		rows, err := store.ListMarketDataRange(ctx, inst, timeframe, from, to)
+		if err != nil {
+			return d, fmt.Errorf("backtest: load %s %s: %w", inst, timeframe, err)
+		}
+		if len(rows) == 0 {
+			return d, fmt.Errorf("%w for %s %s between %s and %s", ErrNoData, inst, timeframe, from.Format(time.RFC3339), to.Format(time.RFC3339))
+		}
+		bars := make([]Bar, 0, len(rows))
+		for _, r := range rows {
+			b := Bar{Instrument: inst, Time: r.Timestamp, Mid: models.OHLC{Open: r.OpenPrice, High: r.HighPrice, Low: r.LowPrice, Close: r.ClosePrice}, Bid: r.Bid, Ask: r.Ask}
+			if r.Volume != nil {
+				b.Volume = *r.Volume
+			}
+			bars = append(bars, b)
+		}
+		d.Bars[inst] = bars
+	}
+	return d, nil
+}
@@ -0,0 +1,515 @@
+// Package backtest replays stored candles through the live strategy interface with a simulated
+// broker, and reports the equity curve, the round-trip trades and performance metrics.
+package backtest
+
+import (
+	"fmt"
+	"log"
+	"math"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/jedi116/go-trader/internal/portfolio"
+	"github.com/jedi116/go-trader/internal/strategy"
+	"github.com/jedi116/go-trader/pkg/models"
+)
+
+// Intrabar policies decide which bracket filled when a bar touched both the stop and the target.
+const (
+	StopFirst   = "stop_first"
+	TargetFirst = "target_first"
+	Nearest     = "nearest"
+)
+
+// Costs model execution. Spread only applies to bars stored without bid/ask.
+type Costs struct {
+	SpreadPips float64 `json:"spread_pips"`
+	// SlippagePips moves market and stop fills against the trader; take-profits fill at their price.
+	SlippagePips float64 `json:"slippage_pips"`
+	// CommissionPerMillion is charged in the account currency per million units traded.
+	CommissionPerMillion float64 `json:"commission_per_million"`
+}
+
+type Options struct {
+	// Name identifies the strategy in its Context and in the result.
+	Name   string          `json:"name"`
+	Params strategy.Params `json:"params,omitempty"`
+	// InitialBalance defaults to 10000 and Currency, the account currency, to USD.
+	InitialBalance float64 `json:"initial_balance"`
+	Currency       string  `json:"currency"`
+	Costs          Costs   `json:"costs"`
+	// Intrabar is StopFirst (default), TargetFirst or Nearest, which assumes the level closer to
+	// the bar's open was reached first.
+	Intrabar string `json:"intrabar"`
+	// Start, when set, makes the bars before it warm-up only: they fill the strategy's history
+	// but no handlers run, nothing trades and no equity is recorded.
+	Start time.Time `json:"start,omitempty"`
+	// Verbose sends strategy Logf output to the log.
+	Verbose bool `json:"-"`
+}
+
+func (o Options) withDefaults() Options {
+	if o.InitialBalance <= 0 {
+		o.InitialBalance = 10000
+	}
+	if o.Currency == "" {
+		o.Currency = "USD"
+	}
+	o.Currency = strings.ToUpper(o.Currency)
+	if o.Intrabar == "" {
+		o.Intrabar = StopFirst
+	}
+	return o
+}
+
+// Trade is a round trip from opening a position to flat. Units is the largest size held, and
+// the prices are unit-weighted averages of the entries and exits. PL is in the account currency,
+// net of commission.
+type Trade struct {
+	Instrument string    `json:"instrument"`
+	Side       string    `json:"side"`
+	Units      float64   `json:"units"`
+	EntryTime  time.Time `json:"entry_time"`
+	EntryPrice float64   `json:"entry_price"`
+	ExitTime   time.Time `json:"exit_time"`
+	ExitPrice  float64   `json:"exit_price"`
+	ExitReason string    `json:"exit_reason"`
+	Commission float64   `json:"commission"`
+	PL         float64   `json:"pl"`
+}
+
+// EquityPoint is the account after each replayed timestamp, open positions marked at the close.
+type EquityPoint struct {
+	Time    time.Time `json:"time"`
+	Balance float64   `json:"balance"`
+	Equity  float64   `json:"equity"`
+}
+
+type Result struct {
+	Name           string          `json:"name"`
+	Params         strategy.Params `json:"params,omitempty"`
+	Instruments    []string        `json:"instruments"`
+	Timeframe      string          `json:"timeframe"`
+	From           time.Time       `json:"from"`
+	To             time.Time       `json:"to"`
+	Bars           int             `json:"bars"`
+	Currency       string          `json:"currency"`
+	InitialBalance float64         `json:"initial_balance"`
+	FinalEquity    float64         `json:"final_equity"`
+	Metrics        Metrics         `json:"metrics"`
+	Trades         []Trade         `json:"trades"`
+	Equity         []EquityPoint   `json:"equity,omitempty"`
+}
+
+// Exit reasons.
+const (
+	ExitSignal     = "signal"
+	ExitStopLoss   = "stop_loss"
+	ExitTakeProfit = "take_profit"
+	ExitEndOfData  = "end_of_data"
+)
+
+type position struct {
+	units, avg float64
+	sl, tp     *float64
+	trade      *Trade
+	exitUnits  float64
+	exitValue  float64
+}
+
+type sim struct {
+	data    Data
+	strat   strategy.Strategy
+	opts    Options
+	history *strategy.History
+	set     map[string]bool
+
+	now       time.Time
+	last      map[string]Bar
+	positions map[string]*position
+	pending   map[string][]strategy.OrderRequest
+	balance   float64
+	trades    []Trade
+	equity    []EquityPoint
+}
+
+// Run replays data through s. Orders submitted while handling a bar fill at the next bar's open
+// of their instrument, so strategies cannot trade on prices they have not seen. At each
+// timestamp pending orders fill first, then brackets are checked against the bar's range, then
+// OnCandle and OnTick (at the close) run. Positions still open at the end are closed at the last
+// close. Every instrument's quote currency must convert to the account currency through the
+// replayed instruments.
+func Run(data Data, s strategy.Strategy, opts Options) (*Result, error) {
+	opts = opts.withDefaults()
+	switch opts.Intrabar {
+	case StopFirst, TargetFirst, Nearest:
+	default:
+		return nil, fmt.Errorf("backtest: intrabar must be %s, %s or %s", StopFirst, TargetFirst, Nearest)
+	}
+	if data.Len() == 0 {
+		return nil, fmt.Errorf("backtest: no bars to replay")
+	}
+	if !opts.Start.IsZero() && data.Slice(opts.Start, time.Time{}).Len() == 0 {
+		return nil, fmt.Errorf("backtest: no bars after %s", opts.Start.Format(time.RFC3339))
+	}
+	sm := &sim{
+		data:      data,
+		strat:     s,
+		opts:      opts,
+		history:   strategy.NewHistory(strategy.DefaultHistory),
+		set:       make(map[string]bool),
+		last:      make(map[string]Bar),
+		positions: make(map[string]*position),
+		pending:   make(map[string][]strategy.OrderRequest),
+		balance:   opts.InitialBalance,
+	}
+	instruments := data.Instruments()
+	first := make(map[string]float64)
+	for _, inst := range instruments {
+		sm.set[inst] = true
+		if bars := data.Bars[inst]; len(bars) > 0 {
+			first[inst] = bars[0].Mid.Close
+		}
+	}
+	for _, inst := range instruments {
+		_, quote, ok := models.SplitInstrument(inst)
+		if !ok {
+			return nil, fmt.Errorf("backtest: unsupported instrument %s", inst)
+		}
+		if _, ok := portfolio.RatesFromMids(first).Rate(quote, opts.Currency); !ok {
+			return nil, fmt.Errorf("backtest: cannot convert %s to %s; add a %s_%s or %s_%s series", quote, opts.Currency, quote, opts.Currency, opts.Currency, quote)
+		}
+	}
+
+	for _, step := range timeline(data) {
+		sm.step(step)
+	}
+	for _, inst := range instruments {
+		if p := sm.positions[inst]; p != nil && p.units != 0 {
+			b := sm.last[inst]
+			sm.fill(inst, -p.units, sm.exitPrice(b, p.units, b.Mid.Close, side(b.Bid, b.Ask, p.units < 0, closeOf)), b.Time, ExitEndOfData)
+		}
+	}
+	if len(sm.equity) > 0 {
+		// Record the forced exits at the final timestamp.
+		sm.equity[len(sm.equity)-1].Balance = sm.balance
+		sm.equity[len(sm.equity)-1].Equity = sm.balance
+	}
+
+	from, to := data.Span()
+	if len(sm.equity) > 0 {
+		from = sm.equity[0].Time
+	}
+	res := &Result{
+		Name:           opts.Name,
+		Params:         opts.Params,
+		Instruments:    instruments,
+		Timeframe:      data.Timeframe,
+		From:           from,
+		To:             to,
+		Bars:           data.Slice(from, time.Time{}).Len(),
+		Currency:       opts.Currency,
+		InitialBalance: opts.InitialBalance,
+		FinalEquity:    sm.balance,
+		Trades:         sm.trades,
+		Equity:         sm.equity,
+	}
+	if res.Trades == nil {
+		res.Trades = []Trade{}
+	}
+	res.Metrics = Compute(opts.InitialBalance, sm.equity, sm.trades)
+	return res, nil
+}
+
+// timeline groups the bars of all instruments by timestamp, in time order.
+func timeline(data Data) [][]Bar {
+	byTime := make(map[int64][]Bar)
+	for _, inst := range data.Instruments() {
+		for _, b := range data.Bars[inst] {
+			k := b.Time.UnixNano()
+			byTime[k] = append(byTime[k], b)
+		}
+	}
+	keys := make([]int64, 0, len(byTime))
+	for k := range byTime {
+		keys = append(keys, k)
+	}
+	sort.Slice(keys, func(i, j int) bool { return keys[i] < keys[j] })
+	out := make([][]Bar, len(keys))
+	for i, k := range keys {
+		out[i] = byTime[k]
+	}
+	return out
+}
+
+func (sm *sim) step(bars []Bar) {
+	sm.now = bars[0].Time
+	for _, b := range bars {
+		sm.last[b.Instrument] = b
+	}
+	if sm.now.Before(sm.opts.Start) {
+		for _, b := range bars {
+			sm.history.Add(candleOf(sm.data.Timeframe, b))
+		}
+		return
+	}
+	for _, b := range bars {
+		sm.fillPending(b)
+		sm.checkBrackets(b)
+	}
+	for _, b := range bars {
+		sm.history.Add(candleOf(sm.data.Timeframe, b))
+	}
+	for _, b := range bars {
+		c, _ := sm.history.Last(b.Instrument, sm.data.Timeframe)
+		sm.call(func(ctx strategy.Context) error { return sm.strat.OnCandle(ctx, c) })
+	}
+	for _, b := range bars {
+		t := sm.tick(b)
+		sm.call(func(ctx strategy.Context) error { return sm.strat.OnTick(ctx, t) })
+	}
+	sm.equity = append(sm.equity, EquityPoint{Time: sm.now, Balance: sm.balance, Equity: sm.balance + sm.unrealized()})
+}
+
+func candleOf(timeframe string, b Bar) strategy.Candle {
+	return strategy.Candle{Instrument: b.Instrument, Timeframe: timeframe, Time: b.Time,
+		Open: b.Mid.Open, High: b.Mid.High, Low: b.Mid.Low, Close: b.Mid.Close, Volume: int(b.Volume)}
+}
+
+// call runs a handler, logging errors and panics like the live runtime does.
+func (sm *sim) call(handler func(strategy.Context) error) {
+	ctx := &simContext{sm: sm}
+	err := func() (err error) {
+		defer func() {
+			if p := recover(); p != nil {
+				err = fmt.Errorf("panic: %v", p)
+			}
+		}()
+		return handler(ctx)
+	}()
+	if err != nil && sm.opts.Verbose {
+		log.Printf("[BACKTEST] %s %s: %v", sm.opts.Name, sm.now.Format(time.RFC3339), err)
+	}
+}
+
+func (sm *sim) fillPending(b Bar) {
+	orders := sm.pending[b.Instrument]
+	delete(sm.pending, b.Instrument)
+	for _, o := range orders {
+		px := side(b.Bid, b.Ask, o.Units > 0, openOf)
+		if px == 0 {
+			px = sm.withSpread(b.Instrument, b.Mid.Open, o.Units > 0)
+		}
+		px += sm.slippage(b.Instrument, o.Units > 0)
+		f := sm.fill(b.Instrument, o.Units, px, b.Time, ExitSignal)
+		if p := sm.positions[b.Instrument]; p != nil && p.units != 0 {
+			if o.StopLoss != nil {
+				p.sl = o.StopLoss
+			}
+			if o.TakeProfit != nil {
+				p.tp = o.TakeProfit
+			}
+		}
+		f.Reason = o.Reason
+		sm.call(func(ctx strategy.Context) error { return sm.strat.OnFill(ctx, f) })
+	}
+}
+
+// checkBrackets closes a position whose stop or target lies within the bar's range. A bar that
+// opens beyond a level fills at the open.
+func (sm *sim) checkBrackets(b Bar) {
+	p := sm.positions[b.Instrument]
+	if p == nil || p.units == 0 || (p.sl == nil && p.tp == nil) {
+		return
+	}
+	long := p.units > 0
+	// A long exits by selling at the bid, a short by buying at the ask.
+	q := b.Ask
+	if long {
+		q = b.Bid
+	}
+	var open, high, low float64
+	if q != nil {
+		open, high, low = q.Open, q.High, q.Low
+	} else {
+		open = sm.withSpread(b.Instrument, b.Mid.Open, !long)
+		high = sm.withSpread(b.Instrument, b.Mid.High, !long)
+		low = sm.withSpread(b.Instrument, b.Mid.Low, !long)
+	}
+	adverse := func(level float64) bool { // the stop side
+		if long {
+			return level >= low
+		}
+		return level <= high
+	}
+	favourable := func(level float64) bool {
+		if long {
+			return level <= high
+		}
+		return level >= low
+	}
+	stopHit := p.sl != nil && adverse(*p.sl)
+	targetHit := p.tp != nil && favourable(*p.tp)
+	if !stopHit && !targetHit {
+		return
+	}
+	useStop := stopHit
+	if stopHit && targetHit {
+		switch sm.opts.Intrabar {
+		case TargetFirst:
+			useStop = false
+		case Nearest:
+			useStop = math.Abs(open-*p.sl) <= math.Abs(open-*p.tp)
+		}
+	}
+	units := -p.units
+	var px float64
+	var reason string
+	if useStop {
+		px, reason = *p.sl, ExitStopLoss
+		if (long && open < px) || (!long && open > px) {
+			px = open
+		}
+		px += sm.slippage(b.Instrument, !long)
+	} else {
+		px, reason = *p.tp, ExitTakeProfit
+		if (long && open > px) || (!long && open < px) {
+			px = open
+		}
+	}
+	f := sm.fill(b.Instrument, units, px, b.Time, reason)
+	sm.call(func(ctx strategy.Context) error { return sm.strat.OnFill(ctx, f) })
+}
+
+// fill applies an execution to the position, books realized P&L and commission, and closes or
+// opens round-trip trades.
+func (sm *sim) fill(inst string, units, px float64, at time.Time, reason string) strategy.Fill {
+	p := sm.positions[inst]
+	if p == nil {
+		p = &position{}
+		sm.positions[inst] = p
+	}
+	commission := math.Abs(units) / 1e6 * sm.opts.Costs.CommissionPerMillion
+	sm.balance -= commission
+	remaining := units
+	if p.units != 0 && (p.units > 0) != (units > 0) {
+		closing := math.Min(math.Abs(units), math.Abs(p.units))
+		dir := sign(p.units)
+		pl := closing * dir * (px - p.avg) * sm.rate(inst)
+		sm.balance += pl
+		share := commission * closing / math.Abs(units)
+		p.trade.PL += pl - share
+		p.trade.Commission += share
+		p.exitUnits += closing
+		p.exitValue += closing * px
+		p.units -= dir * closing
+		remaining = units + dir*closing
+		commission -= share
+		if p.units == 0 {
+			p.trade.ExitTime, p.trade.ExitPrice, p.trade.ExitReason = at, p.exitValue/p.exitUnits, reason
+			sm.trades = append(sm.trades, *p.trade)
+			*p = position{}
+		}
+	}
+	if remaining != 0 {
+		if p.units == 0 {
+			p.trade = &Trade{Instrument: inst, Side: "LONG", EntryTime: at}
+			if remaining < 0 {
+				p.trade.Side = "SHORT"
+			}
+			p.avg = px
+		} else {
+			p.avg = (p.avg*p.units + px*remaining) / (p.units + remaining)
+		}
+		p.units += remaining
+		p.trade.EntryPrice = p.avg
+		p.trade.Units = math.Max(p.trade.Units, math.Abs(p.units))
+		p.trade.PL -= commission
+		p.trade.Commission += commission
+	}
+	return strategy.Fill{Instrument: inst, Units: units, Price: px, Time: at, Reason: reason}
+}
+
+func (sm *sim) unrealized() float64 {
+	total := 0.0
+	for inst, p := range sm.positions {
+		if p.units == 0 {
+			continue
+		}
+		b := sm.last[inst]
+		px := sm.exitPrice(b, p.units, b.Mid.Close, side(b.Bid, b.Ask, p.units < 0, closeOf))
+		total += p.units * (px - p.avg) * sm.rate(inst)
+	}
+	return total
+}
+
+// exitPrice is the price a position of units would close at: quoted if the bar has bid/ask,
+// otherwise mid adjusted by the spread model.
+func (sm *sim) exitPrice(b Bar, units, mid, quoted float64) float64 {
+	if quoted != 0 {
+		return quoted
+	}
+	return sm.withSpread(b.Instrument, mid, units < 0)
+}
+
+// rate converts the instrument's quote currency to the account currency at the latest closes.
+func (sm *sim) rate(inst string) float64 {
+	mids := make(map[string]float64, len(sm.last))
+	for name, b := range sm.last {
+		mids[name] = b.Mid.Close
+	}
+	_, quote, _ := models.SplitInstrument(inst)
+	r, ok := portfolio.RatesFromMids(mids).Rate(quote, sm.opts.Currency)
+	if !ok {
+		return 0
+	}
+	return r
+}
+
+func (sm *sim) withSpread(inst string, mid float64, buy bool) float64 {
+	half := sm.opts.Costs.SpreadPips * models.PipSize(inst) / 2
+	if buy {
+		return mid + half
+	}
+	return mid - half
+}
+
+func (sm *sim) slippage(inst string, buy bool) float64 {
+	s := sm.opts.Costs.SlippagePips * models.PipSize(inst)
+	if buy {
+		return s
+	}
+	return -s
+}
+
+func (sm *sim) tick(b Bar) strategy.Tick {
+	t := strategy.Tick{Instrument: b.Instrument, Time: b.Time, Bid: side(b.Bid, b.Ask, false, closeOf), Ask: side(b.Bid, b.Ask, true, closeOf)}
+	if t.Bid == 0 || t.Ask == 0 {
+		t.Bid = sm.withSpread(b.Instrument, b.Mid.Close, false)
+		t.Ask = sm.withSpread(b.Instrument, b.Mid.Close, true)
+	}
+	return t
+}
+
+func openOf(o *models.OHLC) float64  { return o.Open }
+func closeOf(o *models.OHLC) float64 { return o.Close }
+
+// side picks the ask for buys and the bid for sells, 0 when the bar has no such side.
+func side(bid, ask *models.OHLC, buy bool, field func(*models.OHLC) float64) float64 {
+	q := bid
+	if buy {
+		q = ask
+	}
+	if q == nil {
+		return 0
+	}
+	return field(q)
+}
+
+func sign(x float64) float64 {
+	if x < 0 {
+		return -1
+	}
+	return 1
+}
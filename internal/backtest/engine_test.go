@@ -0,0 +1,159 @@
+package backtest
+
+import (
+	"math"
+	"testing"
+	"time"
+
+	"github.com/jedi116/go-trader/internal/strategy"
+	"github.com/jedi116/go-trader/pkg/models"
+)
+
+var start = time.Date(2026, 3, 2, 10, 0, 0, 0, time.UTC)
+
+// scripted submits the order keyed by the index of the bar being handled.
+type scripted struct {
+	strategy.Base
+	orders map[int]strategy.OrderRequest
+}
+
+func (s *scripted) OnCandle(ctx strategy.Context, c strategy.Candle) error {
+	if o, ok := s.orders[barIndex(c.Time)]; ok {
+		return ctx.Submit(o)
+	}
+	return nil
+}
+
+func barIndex(t time.Time) int { return int(t.Sub(start) / time.Hour) }
+
+// hourly builds EUR_USD H1 bars from open, high, low, close rows. Quoted bars get a bid and ask
+// one pip either side of mid.
+func hourly(rows [][4]float64, quoted bool) Data {
+	bars := make([]Bar, len(rows))
+	for i, r := range rows {
+		b := Bar{Instrument: "EUR_USD", Time: start.Add(time.Duration(i) * time.Hour), Mid: models.OHLC{Open: r[0], High: r[1], Low: r[2], Close: r[3]}}
+		if quoted {
+			b.Bid = &models.OHLC{Open: r[0] - 0.0001, High: r[1] - 0.0001, Low: r[2] - 0.0001, Close: r[3] - 0.0001}
+			b.Ask = &models.OHLC{Open: r[0] + 0.0001, High: r[1] + 0.0001, Low: r[2] + 0.0001, Close: r[3] + 0.0001}
+		}
+		bars[i] = b
+	}
+	return Data{Timeframe: "H1", Bars: map[string][]Bar{"EUR_USD": bars}}
+}
+
+func buy(units float64, sl, tp *float64) strategy.OrderRequest {
+	return strategy.OrderRequest{Instrument: "EUR_USD", Units: units, StopLoss: sl, TakeProfit: tp}
+}
+
+func price(v float64) *float64 { return &v }
+
+type wantTrade struct {
+	side            string
+	entry, exit     int
+	entryPx, exitPx float64
+	reason          string
+	pl              float64
+}
+
+func TestRunFills(t *testing.T) {
+	// Each bar opens 10 pips above the last; only the bar at index 2 is varied per case.
+	base := [][4]float64{
+		{1.1000, 1.1010, 1.0990, 1.1005},
+		{1.1010, 1.1020, 1.1000, 1.1015},
+		{1.1020, 1.1030, 1.1010, 1.1025},
+		{1.1030, 1.1040, 1.1020, 1.1035},
+	}
+	bracket := map[int]strategy.OrderRequest{0: buy(10000, price(1.0950), price(1.1050))}
+	tests := []struct {
+		name     string
+		bar2     *[4]float64
+		quoted   bool
+		orders   map[int]strategy.OrderRequest
+		costs    Costs
+		intrabar string
+		trades   []wantTrade
+		equity   []float64
+	}{
+		{"fills at the next bar's open", nil, false, map[int]strategy.OrderRequest{0: buy(10000, nil, nil), 2: buy(-10000, nil, nil)}, Costs{}, "",
+			[]wantTrade{{"LONG", 1, 3, 1.1010, 1.1030, ExitSignal, 20}}, []float64{10000, 10005, 10015, 10020}},
+		// Half the 2 pip spread plus 1 pip of slippage on each side, and 0.50 commission per fill. Open
+		// positions are marked at the bid.
+		{"spread, slippage and commission", nil, false, map[int]strategy.OrderRequest{0: buy(10000, nil, nil), 2: buy(-10000, nil, nil)},
+			Costs{SpreadPips: 2, SlippagePips: 1, CommissionPerMillion: 50}, "",
+			[]wantTrade{{"LONG", 1, 3, 1.1012, 1.1028, ExitSignal, 15}}, []float64{10000, 10001.5, 10011.5, 10015}},
+		{"quoted bars ignore the spread model", nil, true, map[int]strategy.OrderRequest{0: buy(10000, nil, nil), 2: buy(-10000, nil, nil)},
+			Costs{SpreadPips: 5}, "",
+			[]wantTrade{{"LONG", 1, 3, 1.1011, 1.1029, ExitSignal, 18}}, nil},
+		{"open positions close at the last close", nil, false, map[int]strategy.OrderRequest{0: buy(10000, nil, nil)}, Costs{}, "",
+			[]wantTrade{{"LONG", 1, 3, 1.1010, 1.1035, ExitEndOfData, 25}}, []float64{10000, 10005, 10015, 10025}},
+		{"stop first when both levels are hit", &[4]float64{1.1000, 1.1060, 1.0940, 1.1000}, false, bracket, Costs{}, StopFirst,
+			[]wantTrade{{"LONG", 1, 2, 1.1010, 1.0950, ExitStopLoss, -60}}, nil},
+		{"target first when both levels are hit", &[4]float64{1.1000, 1.1060, 1.0940, 1.1000}, false, bracket, Costs{}, TargetFirst,
+			[]wantTrade{{"LONG", 1, 2, 1.1010, 1.1050, ExitTakeProfit, 40}}, nil},
+		{"nearest picks the target closer to the open", &[4]float64{1.1040, 1.1060, 1.0940, 1.1000}, false, bracket, Costs{}, Nearest,
+			[]wantTrade{{"LONG", 1, 2, 1.1010, 1.1050, ExitTakeProfit, 40}}, nil},
+		{"nearest picks the stop closer to the open", &[4]float64{1.0960, 1.1060, 1.0940, 1.1000}, false, bracket, Costs{}, Nearest,
+			[]wantTrade{{"LONG", 1, 2, 1.1010, 1.0950, ExitStopLoss, -60}}, nil},
+		{"gap through the stop fills at the open", &[4]float64{1.0900, 1.0920, 1.0880, 1.0910}, false, bracket, Costs{}, StopFirst,
+			[]wantTrade{{"LONG", 1, 2, 1.1010, 1.0900, ExitStopLoss, -110}}, nil},
+		{"target only", &[4]float64{1.1020, 1.1060, 1.1010, 1.1040}, false, bracket, Costs{}, StopFirst,
+			[]wantTrade{{"LONG", 1, 2, 1.1010, 1.1050, ExitTakeProfit, 40}}, nil},
+		// The short enters at the bid less slippage and its stop fills a pip above the level.
+		{"short stop with slippage", &[4]float64{1.1020, 1.1060, 1.1010, 1.1040}, false,
+			map[int]strategy.OrderRequest{0: buy(-10000, price(1.1050), nil)}, Costs{SlippagePips: 1}, StopFirst,
+			[]wantTrade{{"SHORT", 1, 2, 1.1009, 1.1051, ExitStopLoss, -42}}, nil},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rows := append([][4]float64(nil), base...)
+			if tt.bar2 != nil {
+				rows[2] = *tt.bar2
+			}
+			res, err := Run(hourly(rows, tt.quoted), &scripted{orders: tt.orders}, Options{Name: "test", Costs: tt.costs, Intrabar: tt.intrabar})
+			if err != nil {
+				t.Fatal(err)
+			}
+			if len(res.Trades) != len(tt.trades) {
+				t.Fatalf("trades = %+v", res.Trades)
+			}
+			final := 10000.0
+			for i, w := range tt.trades {
+				tr := res.Trades[i]
+				if tr.Side != w.side || barIndex(tr.EntryTime) != w.entry || barIndex(tr.ExitTime) != w.exit || !near(tr.EntryPrice, w.entryPx) ||
+					!near(tr.ExitPrice, w.exitPx) || tr.ExitReason != w.reason || !near(tr.PL, w.pl) || tr.Units != 10000 {
+					t.Errorf("trade %d = %+v, want %+v", i, tr, w)
+				}
+				final += w.pl
+			}
+			if !near(res.FinalEquity, final) || !near(res.Equity[len(res.Equity)-1].Equity, final) {
+				t.Errorf("final equity %.4f, last point %+v, want %.4f", res.FinalEquity, res.Equity[len(res.Equity)-1], final)
+			}
+			for i, want := range tt.equity {
+				if !near(res.Equity[i].Equity, want) {
+					t.Errorf("equity[%d] = %.4f, want %.4f", i, res.Equity[i].Equity, want)
+				}
+			}
+		})
+	}
+}
+
+func TestRunRejects(t *testing.T) {
+	data := hourly([][4]float64{{1.1, 1.1, 1.1, 1.1}}, false)
+	tests := []struct {
+		name string
+		data Data
+		opts Options
+	}{
+		{"unknown intrabar policy", data, Options{Intrabar: "random"}},
+		{"no bars", Data{Timeframe: "H1"}, Options{}},
+		{"no bars after start", data, Options{Start: start.Add(time.Hour)}},
+		{"no conversion to the account currency", data, Options{Currency: "JPY"}},
+	}
+	for _, tt := range tests {
+		if _, err := Run(tt.data, &scripted{}, tt.opts); err == nil {
+			t.Errorf("%s: Run succeeded", tt.name)
+		}
+	}
+}
+
+func near(a, b float64) bool { return math.Abs(a-b) < 1e-6 }
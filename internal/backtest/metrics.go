@@ -0,0 +1,130 @@
+package backtest
+
+import (
+	"math"
+	"time"
+
+	"github.com/jedi116/go-trader/internal/markethours"
+)
+
+// tradingDays annualizes daily Sharpe and Sortino ratios: FX trades five days a week, about 260
+// trading days a year, rather than the 252 of an exchange calendar.
+const tradingDays = 260
+
+// Metrics summarizes a run. Returns and drawdown are fractions (0.1 is 10%); Sharpe and Sortino
+// are annualized from returns per FX trading day with a zero risk-free rate. ProfitFactor is omitted
+// when no trade lost.
+type Metrics struct {
+	NetProfit    float64  `json:"net_profit"`
+	TotalReturn  float64  `json:"total_return"`
+	CAGR         float64  `json:"cagr"`
+	Sharpe       float64  `json:"sharpe"`
+	Sortino      float64  `json:"sortino"`
+	MaxDrawdown  float64  `json:"max_drawdown"`
+	ProfitFactor *float64 `json:"profit_factor,omitempty"`
+	Trades       int      `json:"trades"`
+	WinRate      float64  `json:"win_rate"`
+	AvgWin       float64  `json:"avg_win"`
+	AvgLoss      float64  `json:"avg_loss"`
+	Expectancy   float64  `json:"expectancy"`
+	Commission   float64  `json:"commission"`
+}
+
+// Compute derives the metrics from an equity curve and its trades.
+func Compute(initial float64, equity []EquityPoint, trades []Trade) Metrics {
+	var m Metrics
+	if len(equity) == 0 || initial <= 0 {
+		return m
+	}
+	final := equity[len(equity)-1].Equity
+	m.NetProfit = final - initial
+	m.TotalReturn = final/initial - 1
+	if years := equity[len(equity)-1].Time.Sub(equity[0].Time).Hours() / 24 / 365.25; years > 0 {
+		if final <= 0 {
+			m.CAGR = -1
+		} else {
+			m.CAGR = math.Pow(final/initial, 1/years) - 1
+		}
+	}
+
+	peak := initial
+	for _, p := range equity {
+		peak = math.Max(peak, p.Equity)
+		if dd := (peak - p.Equity) / peak; dd > m.MaxDrawdown {
+			m.MaxDrawdown = dd
+		}
+	}
+
+	returns := dailyReturns(initial, equity)
+	if n := float64(len(returns)); n >= 2 {
+		mean, downside := 0.0, 0.0
+		for _, r := range returns {
+			mean += r
+			if r < 0 {
+				downside += r * r
+			}
+		}
+		mean /= n
+		variance := 0.0
+		for _, r := range returns {
+			variance += (r - mean) * (r - mean)
+		}
+		if sd := math.Sqrt(variance / (n - 1)); sd > 0 {
+			m.Sharpe = mean / sd * math.Sqrt(tradingDays)
+		}
+		if dd := math.Sqrt(downside / n); dd > 0 {
+			m.Sortino = mean / dd * math.Sqrt(tradingDays)
+		}
+	}
+
+	var grossWin, grossLoss float64
+	var wins, losses int
+	for _, t := range trades {
+		m.Commission += t.Commission
+		switch {
+		case t.PL > 0:
+			wins++
+			grossWin += t.PL
+		case t.PL < 0:
+			losses++
+			grossLoss -= t.PL
+		}
+	}
+	m.Trades = len(trades)
+	if m.Trades > 0 {
+		m.WinRate = float64(wins) / float64(m.Trades)
+		m.Expectancy = (grossWin - grossLoss) / float64(m.Trades)
+	}
+	if wins > 0 {
+		m.AvgWin = grossWin / float64(wins)
+	}
+	if losses > 0 {
+		m.AvgLoss = -grossLoss / float64(losses)
+		pf := grossWin / grossLoss
+		m.ProfitFactor = &pf
+	}
+	return m
+}
+
+// dailyReturns takes the last equity of each trading day, which rolls over at 17:00 New York, so
+// Sunday evening's bars count toward Monday rather than as a day of their own. It starts from the
+// initial balance.
+func dailyReturns(initial float64, equity []EquityPoint) []float64 {
+	var out []float64
+	prev := initial
+	for i, p := range equity {
+		if i+1 < len(equity) && sameTradingDay(p.Time, equity[i+1].Time) {
+			continue
+		}
+		if prev > 0 {
+			out = append(out, p.Equity/prev-1)
+		}
+		prev = p.Equity
+	}
+	return out
+}
+
+func sameTradingDay(a, b time.Time) bool {
+	cal := markethours.Default()
+	return cal.TradingDay(a).Equal(cal.TradingDay(b))
+}
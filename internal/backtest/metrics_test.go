@@ -0,0 +1,94 @@
+package backtest
+
+import (
+	"testing"
+	"time"
+)
+
+// curve spreads the values evenly over days, starting at start.
+func curve(days float64, values ...float64) []EquityPoint {
+	out := make([]EquityPoint, len(values))
+	step := time.Duration(days * 24 * float64(time.Hour) / float64(len(values)-1))
+	for i, v := range values {
+		out[i] = EquityPoint{Time: start.Add(time.Duration(i) * step), Equity: v}
+	}
+	return out
+}
+
+func TestComputeReturns(t *testing.T) {
+	tests := []struct {
+		name     string
+		equity   []EquityPoint
+		total    float64
+		cagr     float64
+		drawdown float64
+	}{
+		{"two years of growth", curve(730.5, 10000, 12100), 0.21, 0.1, 0},
+		{"drawdown from the running peak", curve(730.5, 10000, 11000, 9900, 10500, 12100), 0.21, 0.1, 0.1},
+		{"drawdown from the initial balance", curve(730.5, 9000, 9500, 8100), -0.19, -0.1, 0.19},
+		{"no time elapsed", curve(0, 10000, 10000), 0, 0, 0},
+	}
+	for _, tt := range tests {
+		m := Compute(10000, tt.equity, nil)
+		if !near(m.TotalReturn, tt.total) || !near(m.CAGR, tt.cagr) || !near(m.MaxDrawdown, tt.drawdown) {
+			t.Errorf("%s: total %.4f, CAGR %.4f, drawdown %.4f; want %.4f, %.4f, %.4f", tt.name, m.TotalReturn, m.CAGR, m.MaxDrawdown, tt.total, tt.cagr, tt.drawdown)
+		}
+	}
+}
+
+func TestComputeTrades(t *testing.T) {
+	tests := []struct {
+		name                     string
+		pl                       []float64
+		profitFactor             *float64
+		winRate, avgWin, avgLoss float64
+		expectancy               float64
+	}{
+		{"wins and losses", []float64{300, -100, -100, 0}, price(1.5), 0.25, 300, -100, 25},
+		{"no losses omits the profit factor", []float64{100, 50}, nil, 1, 75, 0, 75},
+		{"no trades", nil, nil, 0, 0, 0, 0},
+	}
+	for _, tt := range tests {
+		trades := make([]Trade, len(tt.pl))
+		for i, pl := range tt.pl {
+			trades[i] = Trade{PL: pl, Commission: 1}
+		}
+		m := Compute(10000, curve(1, 10000, 10000), trades)
+		pfOK := (m.ProfitFactor == nil) == (tt.profitFactor == nil) && (m.ProfitFactor == nil || near(*m.ProfitFactor, *tt.profitFactor))
+		if !pfOK || m.Trades != len(tt.pl) || !near(m.WinRate, tt.winRate) || !near(m.AvgWin, tt.avgWin) || !near(m.AvgLoss, tt.avgLoss) ||
+			!near(m.Expectancy, tt.expectancy) || !near(m.Commission, float64(len(tt.pl))) {
+			t.Errorf("%s: %+v", tt.name, m)
+		}
+	}
+}
+
+func TestDailyReturns(t *testing.T) {
+	at := func(day, hour int) time.Time { return time.Date(2026, 3, day, hour, 0, 0, 0, time.UTC) }
+	tests := []struct {
+		name   string
+		equity []EquityPoint
+		want   []float64
+	}{
+		// 22:00 UTC is the 17:00 New York rollover in winter, so Sunday evening is Monday.
+		{"Sunday evening belongs to Monday", []EquityPoint{{Time: at(1, 22), Equity: 10100}, {Time: at(2, 12), Equity: 10200}, {Time: at(2, 23), Equity: 10404}},
+			[]float64{0.02, 0.02}},
+		{"Friday close ends the week", []EquityPoint{{Time: at(6, 20), Equity: 9900}, {Time: at(8, 22), Equity: 9900}},
+			[]float64{-0.01, 0}},
+		// After the March change the rollover is 21:00 UTC.
+		{"rollover follows daylight saving", []EquityPoint{{Time: at(9, 20), Equity: 10100}, {Time: at(9, 21), Equity: 10201}},
+			[]float64{0.01, 0.01}},
+	}
+	for _, tt := range tests {
+		got := dailyReturns(10000, tt.equity)
+		if len(got) != len(tt.want) {
+			t.Errorf("%s: returns %v, want %v", tt.name, got, tt.want)
+			continue
+		}
+		for i := range got {
+			if !near(got[i], tt.want[i]) {
+				t.Errorf("%s: returns %v, want %v", tt.name, got, tt.want)
+				break
+			}
+		}
+	}
+}
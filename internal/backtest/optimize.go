@@ -0,0 +1,351 @@
+package backtest
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math"
+	"math/rand"
+	"runtime"
+	"sort"
+	"sync"
+
+	"github.com/jedi116/go-trader/internal/strategy"
+)
+
+// Search methods.
+const (
+	Grid   = "grid"
+	Random = "random"
+)
+
+// Objectives rank optimization candidates; higher is better for all of them.
+const (
+	ObjectiveSharpe       = "sharpe"
+	ObjectiveSortino      = "sortino"
+	ObjectiveCAGR         = "cagr"
+	ObjectiveTotalReturn  = "total_return"
+	ObjectiveNetProfit    = "net_profit"
+	ObjectiveProfitFactor = "profit_factor"
+)
+
+// maxProfitFactorScore is the profit_factor score of a run whose trades all won.
+const maxProfitFactorScore = 100
+
+// DefaultMaxCombinations bounds a grid search when OptimizeOptions.MaxCombinations is unset.
+const DefaultMaxCombinations = 10000
+
+// ErrNoCandidate is returned when no parameter combination built a strategy and met MinTrades.
+var ErrNoCandidate = errors.New("backtest: no parameter combination qualified")
+
+// Range is the search space of one parameter: either explicit Values, or Min to Max inclusive
+// by Step. Random search draws uniformly between Min and Max when Step is zero.
+type Range struct {
+	Values []interface{} `json:"values,omitempty"`
+	Min    *float64      `json:"min,omitempty"`
+	Max    *float64      `json:"max,omitempty"`
+	Step   float64       `json:"step,omitempty"`
+}
+
+// Space maps parameter names to their ranges.
+type Space map[string]Range
+
+func (r Range) validate(name string) error {
+	if len(r.Values) > 0 {
+		return nil
+	}
+	if r.Min == nil || r.Max == nil {
+		return fmt.Errorf("backtest: parameter %s needs values or min and max", name)
+	}
+	if *r.Max < *r.Min {
+		return fmt.Errorf("backtest: parameter %s has max below min", name)
+	}
+	if r.Step < 0 {
+		return fmt.Errorf("backtest: parameter %s has a negative step", name)
+	}
+	return nil
+}
+
+// points lists the grid values of the range.
+func (r Range) points() []interface{} {
+	if len(r.Values) > 0 {
+		return r.Values
+	}
+	if r.Step == 0 {
+		if *r.Min == *r.Max {
+			return []interface{}{*r.Min}
+		}
+		return []interface{}{*r.Min, *r.Max}
+	}
+	n := int(math.Floor((*r.Max-*r.Min)/r.Step+1e-9)) + 1
+	out := make([]interface{}, n)
+	for i := range out {
+		// Rounding keeps 0.1 steps from printing as 0.30000000000000004.
+		out[i] = math.Round((*r.Min+float64(i)*r.Step)*1e9) / 1e9
+	}
+	return out
+}
+
+func (r Range) sample(rng *rand.Rand) interface{} {
+	if len(r.Values) > 0 || r.Step > 0 {
+		pts := r.points()
+		return pts[rng.Intn(len(pts))]
+	}
+	return *r.Min + rng.Float64()*(*r.Max-*r.Min)
+}
+
+// OptimizeOptions configures a parameter search.
+type OptimizeOptions struct {
+	// Method is Grid (default), which tries every combination, or Random, which draws Samples
+	// combinations (default 100) from a generator seeded with Seed.
+	Method  string `json:"method"`
+	Samples int    `json:"samples,omitempty"`
+	Seed    int64  `json:"seed,omitempty"`
+	// Workers defaults to the number of CPUs.
+	Workers int `json:"workers,omitempty"`
+	// Objective defaults to ObjectiveSharpe.
+	Objective string `json:"objective"`
+	// MinTrades drops runs with fewer trades from the ranking.
+	MinTrades int `json:"min_trades,omitempty"`
+	// MaxCombinations refuses larger grids; it defaults to DefaultMaxCombinations.
+	MaxCombinations int `json:"max_combinations,omitempty"`
+}
+
+func (o OptimizeOptions) withDefaults() OptimizeOptions {
+	if o.Method == "" {
+		o.Method = Grid
+	}
+	if o.Samples <= 0 {
+		o.Samples = 100
+	}
+	if o.Workers <= 0 {
+		o.Workers = runtime.NumCPU()
+	}
+	if o.Objective == "" {
+		o.Objective = ObjectiveSharpe
+	}
+	if o.MaxCombinations <= 0 {
+		o.MaxCombinations = DefaultMaxCombinations
+	}
+	return o
+}
+
+func (o OptimizeOptions) validate() error {
+	switch o.Method {
+	case Grid, Random:
+	default:
+		return fmt.Errorf("backtest: method must be %s or %s", Grid, Random)
+	}
+	switch o.Objective {
+	case ObjectiveSharpe, ObjectiveSortino, ObjectiveCAGR, ObjectiveTotalReturn, ObjectiveNetProfit, ObjectiveProfitFactor:
+		return nil
+	}
+	return fmt.Errorf("backtest: unknown objective %q", o.Objective)
+}
+
+// Candidate is one evaluated parameter combination.
+type Candidate struct {
+	Rank   int             `json:"rank"`
+	Params strategy.Params `json:"params"`
+	Score  float64         `json:"score"`
+	// FinalEquity and Metrics describe the run of these parameters.
+	FinalEquity float64 `json:"final_equity"`
+	Metrics     Metrics `json:"metrics"`
+}
+
+// Optimization is the ranked outcome of a search. Evaluated counts the runs; Skipped counts the
+// combinations the strategy rejected or that traded fewer than MinTrades times.
+type Optimization struct {
+	Method     string      `json:"method"`
+	Objective  string      `json:"objective"`
+	Evaluated  int         `json:"evaluated"`
+	Skipped    int         `json:"skipped"`
+	Candidates []Candidate `json:"candidates"`
+}
+
+// Best is the top-ranked candidate.
+func (o *Optimization) Best() Candidate { return o.Candidates[0] }
+
+// Score reads the objective from m.
+func Score(objective string, m Metrics) float64 {
+	switch objective {
+	case ObjectiveSortino:
+		return m.Sortino
+	case ObjectiveCAGR:
+		return m.CAGR
+	case ObjectiveTotalReturn:
+		return m.TotalReturn
+	case ObjectiveNetProfit:
+		return m.NetProfit
+	case ObjectiveProfitFactor:
+		if m.ProfitFactor != nil {
+			return math.Min(*m.ProfitFactor, maxProfitFactorScore)
+		}
+		if m.Trades > 0 {
+			return maxProfitFactorScore
+		}
+		return 0
+	}
+	return m.Sharpe
+}
+
+// Combinations expands the space for the search method. base holds fixed parameters that every
+// combination inherits unless the space overrides them.
+func Combinations(space Space, base strategy.Params, oo OptimizeOptions) ([]strategy.Params, error) {
+	oo = oo.withDefaults()
+	if err := oo.validate(); err != nil {
+		return nil, err
+	}
+	if len(space) == 0 {
+		return nil, fmt.Errorf("backtest: empty parameter space")
+	}
+	names := make([]string, 0, len(space))
+	for name, r := range space {
+		if err := r.validate(name); err != nil {
+			return nil, err
+		}
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	with := func(values map[string]interface{}) strategy.Params {
+		p := make(strategy.Params, len(base)+len(values))
+		for k, v := range base {
+			p[k] = v
+		}
+		for k, v := range values {
+			p[k] = v
+		}
+		return p
+	}
+
+	if oo.Method == Random {
+		rng := rand.New(rand.NewSource(oo.Seed))
+		out := make([]strategy.Params, oo.Samples)
+		for i := range out {
+			values := make(map[string]interface{}, len(names))
+			for _, name := range names {
+				values[name] = space[name].sample(rng)
+			}
+			out[i] = with(values)
+		}
+		return out, nil
+	}
+
+	total := 1
+	axes := make([][]interface{}, len(names))
+	for i, name := range names {
+		axes[i] = space[name].points()
+		total *= len(axes[i])
+		if total > oo.MaxCombinations {
+			return nil, fmt.Errorf("backtest: grid exceeds %d combinations; narrow it or use random search", oo.MaxCombinations)
+		}
+	}
+	out := make([]strategy.Params, 0, total)
+	idx := make([]int, len(names))
+	for {
+		values := make(map[string]interface{}, len(names))
+		for i, name := range names {
+			values[name] = axes[i][idx[i]]
+		}
+		out = append(out, with(values))
+		// Advance the odometer, last parameter fastest.
+		i := len(idx) - 1
+		for ; i >= 0; i-- {
+			if idx[i]++; idx[i] < len(axes[i]) {
+				break
+			}
+			idx[i] = 0
+		}
+		if i < 0 {
+			return out, nil
+		}
+	}
+}
+
+// Optimize backtests the strategy type with every combination in parallel and ranks them by the
+// objective, best first. opts.Params holds the fixed parameters. Each run gets a fresh strategy,
+// so strategies need not be safe for concurrent use.
+func Optimize(ctx context.Context, data Data, strategyType string, space Space, opts Options, oo OptimizeOptions) (*Optimization, error) {
+	oo = oo.withDefaults()
+	combos, err := Combinations(space, opts.Params, oo)
+	if err != nil {
+		return nil, err
+	}
+	opts.Verbose = false
+
+	type outcome struct {
+		cand Candidate
+		ok   bool
+		err  error
+	}
+	jobs := make(chan int)
+	results := make([]outcome, len(combos))
+	var wg sync.WaitGroup
+	for w := 0; w < oo.Workers && w < len(combos); w++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := range jobs {
+				strat, err := strategy.New(strategyType, combos[i])
+				if err != nil {
+					continue
+				}
+				o := opts
+				o.Params = combos[i]
+				res, err := Run(data, strat, o)
+				if err != nil {
+					results[i].err = err
+					continue
+				}
+				results[i] = outcome{ok: true, cand: Candidate{
+					Params:      combos[i],
+					Score:       Score(oo.Objective, res.Metrics),
+					FinalEquity: res.FinalEquity,
+					Metrics:     res.Metrics,
+				}}
+			}
+		}()
+	}
+feed:
+	for i := range combos {
+		select {
+		case jobs <- i:
+		case <-ctx.Done():
+			break feed
+		}
+	}
+	close(jobs)
+	wg.Wait()
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	opt := &Optimization{Method: oo.Method, Objective: oo.Objective}
+	for _, r := range results {
+		if r.err != nil {
+			// Run errors come from the data or options, which every combination shares.
+			return nil, r.err
+		}
+		if !r.ok {
+			opt.Skipped++
+			continue
+		}
+		opt.Evaluated++
+		if r.cand.Metrics.Trades < oo.MinTrades {
+			opt.Skipped++
+			continue
+		}
+		opt.Candidates = append(opt.Candidates, r.cand)
+	}
+	if len(opt.Candidates) == 0 {
+		return opt, ErrNoCandidate
+	}
+	sort.SliceStable(opt.Candidates, func(i, j int) bool {
+		return opt.Candidates[i].Score > opt.Candidates[j].Score
+	})
+	for i := range opt.Candidates {
+		opt.Candidates[i].Rank = i + 1
+	}
+	return opt, nil
+}
@@ -0,0 +1,161 @@
+package backtest
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/jedi116/go-trader/internal/strategy"
+)
+
+// WalkForwardOptions sizes the rolling windows in days. Each window optimizes on InSampleDays
+// and trades the winning parameters over the following OutSampleDays; windows advance by
+// StepDays, which defaults to OutSampleDays and may not be shorter so out-of-sample periods never
+// overlap. Anchored keeps every in-sample period starting at the first bar.
+type WalkForwardOptions struct {
+	InSampleDays  int  `json:"in_sample_days"`
+	OutSampleDays int  `json:"out_sample_days"`
+	StepDays      int  `json:"step_days,omitempty"`
+	Anchored      bool `json:"anchored,omitempty"`
+}
+
+func (w WalkForwardOptions) validate() error {
+	if w.InSampleDays <= 0 || w.OutSampleDays <= 0 {
+		return fmt.Errorf("backtest: in_sample_days and out_sample_days must be positive")
+	}
+	if w.StepDays != 0 && w.StepDays < w.OutSampleDays {
+		return fmt.Errorf("backtest: step_days may not be shorter than out_sample_days")
+	}
+	return nil
+}
+
+// Window is one in-sample optimization and its out-of-sample run. Params and the metrics are
+// empty when the in-sample search found no qualifying combination; Error says why.
+type Window struct {
+	Index         int             `json:"index"`
+	InSampleFrom  time.Time       `json:"in_sample_from"`
+	InSampleTo    time.Time       `json:"in_sample_to"`
+	OutSampleFrom time.Time       `json:"out_sample_from"`
+	OutSampleTo   time.Time       `json:"out_sample_to"`
+	Params        strategy.Params `json:"params,omitempty"`
+	Evaluated     int             `json:"evaluated"`
+	InSample      *Metrics        `json:"in_sample,omitempty"`
+	OutSample     *Metrics        `json:"out_sample,omitempty"`
+	Trades        int             `json:"trades"`
+	Error         string          `json:"error,omitempty"`
+}
+
+// WalkForwardResult stitches the out-of-sample runs into one account: each window starts with the
+// previous window's final equity. Metrics cover the stitched curve.
+type WalkForwardResult struct {
+	Name           string        `json:"name"`
+	Instruments    []string      `json:"instruments"`
+	Timeframe      string        `json:"timeframe"`
+	From           time.Time     `json:"from"`
+	To             time.Time     `json:"to"`
+	Currency       string        `json:"currency"`
+	InitialBalance float64       `json:"initial_balance"`
+	FinalEquity    float64       `json:"final_equity"`
+	Windows        []Window      `json:"windows"`
+	Metrics        Metrics       `json:"metrics"`
+	Trades         []Trade       `json:"trades"`
+	Equity         []EquityPoint `json:"equity,omitempty"`
+}
+
+// WalkForward runs rolling in-sample optimizations over data and trades each winner out of
+// sample. The out-of-sample run replays its in-sample bars first as warm-up, so indicators are
+// primed when trading starts.
+func WalkForward(ctx context.Context, data Data, strategyType string, space Space, opts Options, oo OptimizeOptions, wf WalkForwardOptions) (*WalkForwardResult, error) {
+	if err := wf.validate(); err != nil {
+		return nil, err
+	}
+	if _, err := Combinations(space, opts.Params, oo); err != nil {
+		return nil, err
+	}
+	if wf.StepDays == 0 {
+		wf.StepDays = wf.OutSampleDays
+	}
+	opts = opts.withDefaults()
+	first, last := data.Span()
+	if first.IsZero() {
+		return nil, fmt.Errorf("backtest: no bars to replay")
+	}
+	end := last.Add(time.Nanosecond)
+	days := func(n int) time.Duration { return time.Duration(n) * 24 * time.Hour }
+	if !first.Add(days(wf.InSampleDays)).Before(end) {
+		return nil, fmt.Errorf("backtest: data spans less than one in-sample period")
+	}
+
+	res := &WalkForwardResult{
+		Name:           opts.Name,
+		Instruments:    data.Instruments(),
+		Timeframe:      data.Timeframe,
+		Currency:       opts.Currency,
+		InitialBalance: opts.InitialBalance,
+		Trades:         []Trade{},
+	}
+	balance := opts.InitialBalance
+	for start := first; ; start = start.Add(days(wf.StepDays)) {
+		isFrom := start
+		if wf.Anchored {
+			isFrom = first
+		}
+		isTo := start.Add(days(wf.InSampleDays))
+		if !isTo.Before(end) || balance <= 0 {
+			break
+		}
+		oosTo := isTo.Add(days(wf.OutSampleDays))
+		if oosTo.After(end) {
+			oosTo = end
+		}
+		w := Window{Index: len(res.Windows), InSampleFrom: isFrom, InSampleTo: isTo, OutSampleFrom: isTo, OutSampleTo: oosTo}
+
+		isOpts := opts
+		isOpts.InitialBalance = balance
+		opt, err := Optimize(ctx, data.Slice(isFrom, isTo), strategyType, space, isOpts, oo)
+		if opt != nil {
+			w.Evaluated = opt.Evaluated
+		}
+		if err != nil {
+			if errors.Is(err, context.Canceled) || errors.Is(err, context.DeadlineExceeded) {
+				return nil, err
+			}
+			w.Error = err.Error()
+			res.Windows = append(res.Windows, w)
+			continue
+		}
+		best := opt.Best()
+		w.Params, w.InSample = best.Params, &best.Metrics
+
+		strat, err := strategy.New(strategyType, best.Params)
+		if err != nil {
+			return nil, err
+		}
+		oosOpts := opts
+		oosOpts.Params = best.Params
+		oosOpts.InitialBalance = balance
+		oosOpts.Start = isTo
+		oos, err := Run(data.Slice(isFrom, oosTo), strat, oosOpts)
+		if err != nil {
+			w.Error = err.Error()
+			res.Windows = append(res.Windows, w)
+			continue
+		}
+		w.OutSample, w.Trades = &oos.Metrics, len(oos.Trades)
+		res.Windows = append(res.Windows, w)
+		res.Trades = append(res.Trades, oos.Trades...)
+		res.Equity = append(res.Equity, oos.Equity...)
+		balance = oos.FinalEquity
+	}
+	if len(res.Windows) == 0 {
+		return nil, fmt.Errorf("backtest: no walk-forward windows fit the data")
+	}
+	if len(res.Equity) == 0 {
+		return nil, fmt.Errorf("%w in any walk-forward window", ErrNoCandidate)
+	}
+	res.From, res.To = res.Equity[0].Time, res.Equity[len(res.Equity)-1].Time
+	res.FinalEquity = balance
+	res.Metrics = Compute(opts.InitialBalance, res.Equity, res.Trades)
+	return res, nil
+}
@@ -2,6 +2,7 @@ package broker
 
 import (
 	"bytes"
+	"context"
 	"encoding/json"
 	"fmt"
 	"io"
@@ -11,14 +12,20 @@ import (
 	"strconv"
 	"strings"
 	"time"
+
+	"github.com/jedi116/go-trader/internal/markethours"
 )
 
 // OANDA MT4 API Client
 type OandaMT4Client struct {
-	APIKey     string
-	AccountID  string
-	BaseURL    string
+	APIKey    string
+	AccountID string
+	BaseURL   string
+	// StreamURL hosts the streaming endpoints.
+	StreamURL  string
 	HTTPClient *http.Client
+	// MarketHours decides market_open in GetMarketStatus; nil uses the standard FX week.
+	MarketHours *markethours.Calendar
 }
 
 // Data Structures for OANDA API Responses
@@ -57,15 +64,58 @@ type CandlesResponse struct {
 }
 
 type Account struct {
-	ID                string  `json:"id"`
-	Currency          string  `json:"currency"`
-	Balance           float64 `json:"balance,string"`
-	UnrealizedPL      float64 `json:"unrealizedPL,string"`
+	ID           string  `json:"id"`
+	Currency     string  `json:"currency"`
+	Balance      float64 `json:"balance,string"`
+	UnrealizedPL float64 `json:"unrealizedPL,string"`
+	// PL is the realized profit/loss over the account's lifetime; unlike Balance it does not
+	// move with deposits and withdrawals.
+	PL                float64 `json:"pl,string"`
 	NAV               float64 `json:"NAV,string"`
 	MarginUsed        float64 `json:"marginUsed,string"`
 	MarginAvailable   float64 `json:"marginAvailable,string"`
 	OpenTradeCount    int     `json:"openTradeCount"`
 	OpenPositionCount int     `json:"openPositionCount"`
+	PositionValue     float64 `json:"positionValue,string"`
+}
+
+// AccountSummary is the account snapshot with derived margin and P&L metrics. All amounts are in
+// account currency.
+type AccountSummary struct {
+	AccountID       string  `json:"account_id"`
+	Currency        string  `json:"currency"`
+	Balance         float64 `json:"balance"`
+	NAV             float64 `json:"nav"`
+	Equity          float64 `json:"equity"`
+	UnrealizedPL    float64 `json:"unrealized_pl"`
+	MarginUsed      float64 `json:"margin_used"`
+	MarginAvailable float64 `json:"margin_available"`
+	FreeMargin      float64 `json:"free_margin"`
+	// MarginLevel is equity over used margin in percent; nil when no margin is in use.
+	MarginLevel *float64 `json:"margin_level"`
+	// MarginUsedPercent is used margin over NAV in percent.
+	MarginUsedPercent float64 `json:"margin_used_percent"`
+	PositionValue     float64 `json:"position_value"`
+	// Leverage is position value over NAV.
+	Leverage      float64 `json:"leverage"`
+	OpenTrades    int     `json:"open_trades"`
+	OpenPositions int     `json:"open_positions"`
+	// TotalProfit and TotalLoss split unrealized P&L across positions by sign.
+	TotalProfit float64        `json:"total_profit"`
+	TotalLoss   float64        `json:"total_loss"`
+	Instruments []InstrumentPL `json:"instruments"`
+	// DailyRealizedPL comes from the local trades table and is filled in by callers that have one.
+	DailyRealizedPL   *float64 `json:"daily_realized_pl,omitempty"`
+	DailyClosedTrades int      `json:"daily_closed_trades"`
+}
+
+// InstrumentPL is one open position's unrealized P&L.
+type InstrumentPL struct {
+	Instrument   string  `json:"instrument"`
+	LongUnits    float64 `json:"long_units"`
+	ShortUnits   float64 `json:"short_units"`
+	UnrealizedPL float64 `json:"unrealized_pl"`
+	MarginUsed   float64 `json:"margin_used"`
 }
 
 type Position struct {
@@ -97,6 +147,11 @@ type Trade struct {
 	StopLossOrder         *Order    `json:"stopLossOrder,omitempty"`
 	TakeProfitOrder       *Order    `json:"takeProfitOrder,omitempty"`
 	TrailingStopLossOrder *Order    `json:"trailingStopLossOrder,omitempty"`
+	// Set once the trade is closed.
+	RealizedPL        float64    `json:"realizedPL,string"`
+	Financing         float64    `json:"financing,string"`
+	AverageClosePrice float64    `json:"averageClosePrice,string,omitempty"`
+	CloseTime         *time.Time `json:"closeTime,omitempty"`
 }
 
 type Order struct {
@@ -132,6 +187,20 @@ type OrderCreateResponse struct {
 	OrderCreateTransaction struct {
 		ID string `json:"id"`
 	} `json:"orderCreateTransaction"`
+	// OrderFillTransaction is set when the market order filled immediately.
+	OrderFillTransaction *OrderFill `json:"orderFillTransaction,omitempty"`
+}
+
+type OrderFill struct {
+	ID    string    `json:"id"`
+	Time  time.Time `json:"time"`
+	Units float64   `json:"units,string"`
+	Price float64   `json:"price,string"`
+	PL    float64   `json:"pl,string"`
+	// TradeOpened is set when the fill opened a trade, rather than only reducing one.
+	TradeOpened *struct {
+		TradeID string `json:"tradeID"`
+	} `json:"tradeOpened,omitempty"`
 }
 
 type Instrument struct {
@@ -152,14 +221,17 @@ type Instrument struct {
 // Constructor
 func NewOandaMT4Client(apiKey, accountID string, live bool) *OandaMT4Client {
 	baseURL := "https://api-fxpractice.oanda.com"
+	streamURL := "https://stream-fxpractice.oanda.com"
 	if live {
 		baseURL = "https://api-fxtrade.oanda.com"
+		streamURL = "https://stream-fxtrade.oanda.com"
 	}
 
 	return &OandaMT4Client{
 		APIKey:    apiKey,
 		AccountID: accountID,
 		BaseURL:   baseURL,
+		StreamURL: streamURL,
 		HTTPClient: &http.Client{
 			Timeout: 30 * time.Second,
 		},
@@ -228,6 +300,59 @@ func (c *OandaMT4Client) GetPrices(instruments []string) ([]Price, error) {
 	return result.Prices, nil
 }
 
+// streamStall is how long the pricing stream may stay silent; OANDA sends a heartbeat every
+// five seconds.
+const streamStall = 30 * time.Second
+
+// StreamPrices follows the pricing stream for instruments and calls fn with each price until ctx
+// is cancelled, fn returns an error, or the stream fails or stalls. It always returns an error;
+// callers reconnect.
+func (c *OandaMT4Client) StreamPrices(ctx context.Context, instruments []string, fn func(Price) error) error {
+	streamCtx, cancel := context.WithCancel(ctx)
+	defer cancel()
+	params := url.Values{}
+	params.Set("instruments", strings.Join(instruments, ","))
+	req, err := http.NewRequestWithContext(streamCtx, "GET", fmt.Sprintf("%s/v3/accounts/%s/pricing/stream?%s", c.StreamURL, c.AccountID, params.Encode()), nil)
+	if err != nil {
+		return fmt.Errorf("failed to create request: %w", err)
+	}
+	req.Header.Set("Authorization", "Bearer "+c.APIKey)
+	req.Header.Set("Accept-Datetime-Format", "RFC3339")
+
+	// The stream never ends, so it cannot share the client timeout; the stall timer replaces it.
+	stall := time.AfterFunc(streamStall, cancel)
+	defer stall.Stop()
+	resp, err := (&http.Client{Transport: c.HTTPClient.Transport}).Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("API request failed with status %d", resp.StatusCode)
+	}
+
+	dec := json.NewDecoder(resp.Body)
+	for {
+		var msg struct {
+			Type string `json:"type"`
+			Price
+		}
+		if err := dec.Decode(&msg); err != nil {
+			if ctx.Err() == nil && streamCtx.Err() != nil {
+				return fmt.Errorf("pricing stream silent for %s", streamStall)
+			}
+			return fmt.Errorf("pricing stream: %w", err)
+		}
+		stall.Reset(streamStall)
+		if msg.Type != "PRICE" {
+			continue
+		}
+		if err := fn(msg.Price); err != nil {
+			return err
+		}
+	}
+}
+
 // 2. Get Historical Candles
 func (c *OandaMT4Client) GetCandles(instrument, granularity string, count int, from, to *time.Time) (*CandlesResponse, error) {
 	params := url.Values{}
@@ -347,6 +472,33 @@ func (c *OandaMT4Client) GetTrades() ([]Trade, error) {
 	return result.Trades, nil
 }
 
+// 5b. Get Closed Trades, most recently closed first; OANDA caps count at 500.
+func (c *OandaMT4Client) GetClosedTrades(count int) ([]Trade, error) {
+	if count <= 0 || count > 500 {
+		count = 500
+	}
+	params := url.Values{}
+	params.Set("state", "CLOSED")
+	params.Set("count", strconv.Itoa(count))
+	resp, err := c.makeRequest("GET", fmt.Sprintf("/v3/accounts/%s/trades", c.AccountID), params, nil)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		bodyBytes, _ := io.ReadAll(resp.Body)
+		return nil, fmt.Errorf("closed trades failed status %d: %s", resp.StatusCode, string(bodyBytes))
+	}
+
+	var result struct {
+		Trades []Trade `json:"trades"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&result); err != nil {
+		return nil, err
+	}
+	return result.Trades, nil
+}
+
 // 6. Get Pending Orders
 func (c *OandaMT4Client) GetOrders() ([]Order, error) {
 	resp, err := c.makeRequest("GET", fmt.Sprintf("/v3/accounts/%s/orders", c.AccountID), nil, nil)
@@ -438,7 +590,7 @@ func (c *OandaMT4Client) GetPositionBook(instrument string) (map[string]interfac
 }
 
 // 10. Get Account Summary with Calculated Metrics
-func (c *OandaMT4Client) GetAccountSummary() (map[string]interface{}, error) {
+func (c *OandaMT4Client) GetAccountSummary() (*AccountSummary, error) {
 	account, err := c.GetAccount()
 	if err != nil {
 		return nil, err
@@ -454,37 +606,50 @@ func (c *OandaMT4Client) GetAccountSummary() (map[string]interface{}, error) {
 		return nil, err
 	}
 
-	// Calculate additional metrics
-	summary := map[string]interface{}{
-		"account_id":       account.ID,
-		"currency":         account.Currency,
-		"balance":          account.Balance,
-		"nav":              account.NAV,
-		"unrealized_pl":    account.UnrealizedPL,
-		"margin_used":      account.MarginUsed,
-		"margin_available": account.MarginAvailable,
-		"margin_rate":      (account.MarginUsed / account.NAV) * 100,
-		"equity":           account.Balance + account.UnrealizedPL,
-		"open_trades":      len(trades),
-		"open_positions":   len(positions),
-		"free_margin":      account.NAV - account.MarginUsed,
-		"margin_level":     (account.NAV / account.MarginUsed) * 100,
+	equity := account.Balance + account.UnrealizedPL
+	summary := &AccountSummary{
+		AccountID:       account.ID,
+		Currency:        account.Currency,
+		Balance:         account.Balance,
+		NAV:             account.NAV,
+		Equity:          equity,
+		UnrealizedPL:    account.UnrealizedPL,
+		MarginUsed:      account.MarginUsed,
+		MarginAvailable: account.MarginAvailable,
+		FreeMargin:      equity - account.MarginUsed,
+		PositionValue:   account.PositionValue,
+		OpenTrades:      len(trades),
+		Instruments:     make([]InstrumentPL, 0, len(positions)),
+	}
+	if account.MarginUsed > 0 {
+		level := equity / account.MarginUsed * 100
+		summary.MarginLevel = &level
+	}
+	if account.NAV > 0 {
+		summary.MarginUsedPercent = account.MarginUsed / account.NAV * 100
+		summary.Leverage = account.PositionValue / account.NAV
 	}
 
-	// Add position details
-	var totalProfit, totalLoss float64
+	// Per-instrument breakdown; OANDA also lists closed positions with zero units
 	for _, pos := range positions {
+		if pos.Long.Units == 0 && pos.Short.Units == 0 {
+			continue
+		}
+		summary.OpenPositions++
+		summary.Instruments = append(summary.Instruments, InstrumentPL{
+			Instrument:   pos.Instrument,
+			LongUnits:    pos.Long.Units,
+			ShortUnits:   pos.Short.Units,
+			UnrealizedPL: pos.UnrealizedPL,
+			MarginUsed:   pos.MarginUsed,
+		})
 		if pos.UnrealizedPL > 0 {
-			totalProfit += pos.UnrealizedPL
+			summary.TotalProfit += pos.UnrealizedPL
 		} else {
-			totalLoss += pos.UnrealizedPL
+			summary.TotalLoss += pos.UnrealizedPL
 		}
 	}
 
-	summary["total_profit"] = totalProfit
-	summary["total_loss"] = totalLoss
-	summary["net_exposure"] = totalProfit + totalLoss
-
 	return summary, nil
 }
 
@@ -510,15 +675,18 @@ func (c *OandaMT4Client) GetMarketStatus(instruments []string) (map[string]inter
 		return nil, err
 	}
 
+	now := time.Now()
+	hours := c.MarketHours.Status(now)
 	status := map[string]interface{}{
-		"timestamp":   time.Now(),
-		"market_open": len(prices) > 0,
+		"timestamp":   now,
+		"market_open": hours.Open,
+		"hours":       hours,
 		"instruments": make(map[string]interface{}),
 	}
 
 	for _, price := range prices {
 		status["instruments"].(map[string]interface{})[price.Instrument] = map[string]interface{}{
-			"tradeable":   len(price.Bids) > 0 && len(price.Asks) > 0,
+			"tradeable":   hours.Open && len(price.Bids) > 0 && len(price.Asks) > 0,
 			"last_update": price.Time,
 			"bid_liquidity": func() int {
 				if len(price.Bids) > 0 {
@@ -615,3 +783,43 @@ func (c *OandaMT4Client) PlaceMarketOrderWithBrackets(instrument string, units f
 	}
 	return &result, nil
 }
+
+// 13c. Close Position (both sides, all units)
+func (c *OandaMT4Client) ClosePosition(instrument string) error {
+	var payload struct {
+		LongUnits  string `json:"longUnits,omitempty"`
+		ShortUnits string `json:"shortUnits,omitempty"`
+	}
+	positions, err := c.GetPositions()
+	if err != nil {
+		return err
+	}
+	for _, p := range positions {
+		if p.Instrument != instrument {
+			continue
+		}
+		if p.Long.Units != 0 {
+			payload.LongUnits = "ALL"
+		}
+		if p.Short.Units != 0 {
+			payload.ShortUnits = "ALL"
+		}
+	}
+	if payload.LongUnits == "" && payload.ShortUnits == "" {
+		return nil
+	}
+
+	resp, err := c.makeRequest("PUT",
+		fmt.Sprintf("/v3/accounts/%s/positions/%s/close", c.AccountID, instrument),
+		nil, payload)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		bodyBytes, _ := io.ReadAll(resp.Body)
+		return fmt.Errorf("close position failed status %d: %s", resp.StatusCode, string(bodyBytes))
+	}
+	return nil
+}
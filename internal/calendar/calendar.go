@@ -0,0 +1,165 @@
+package calendar
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/jedi116/go-trader/pkg/models"
+)
+
+// Store persists calendar events.
+type Store interface {
+	UpsertEconomicEvents(ctx context.Context, events []models.EconomicEvent) error
+	ListEconomicEvents(ctx context.Context, from, to time.Time, currencies []string) ([]models.EconomicEvent, error)
+}
+
+// Provider is a source of calendar events, e.g. a file or a vendor API.
+type Provider interface {
+	Name() string
+	Events(ctx context.Context) ([]models.EconomicEvent, error)
+}
+
+// FileProvider reads a CSV, JSON or ICS calendar file, re-reading it on every call.
+type FileProvider struct {
+	Path string
+}
+
+func NewFileProvider(path string) *FileProvider { return &FileProvider{Path: path} }
+
+func (f *FileProvider) Name() string { return "file:" + f.Path }
+
+func (f *FileProvider) Events(ctx context.Context) ([]models.EconomicEvent, error) {
+	format, err := FormatFromPath(f.Path)
+	if err != nil {
+		return nil, err
+	}
+	fh, err := os.Open(f.Path)
+	if err != nil {
+		return nil, err
+	}
+	defer fh.Close()
+	return Parse(fh, format, f.Name())
+}
+
+// Ingest loads events from provider into store and returns how many were written.
+func Ingest(ctx context.Context, provider Provider, store Store) (int, error) {
+	events, err := provider.Events(ctx)
+	if err != nil {
+		return 0, fmt.Errorf("calendar provider %s: %w", provider.Name(), err)
+	}
+	if err := store.UpsertEconomicEvents(ctx, events); err != nil {
+		return 0, err
+	}
+	return len(events), nil
+}
+
+// RunIngester ingests immediately and then every interval until ctx is done.
+func RunIngester(ctx context.Context, provider Provider, store Store, interval time.Duration) {
+	if interval <= 0 {
+		interval = time.Hour
+	}
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		if n, err := Ingest(ctx, provider, store); err != nil {
+			log.Printf("[CALENDAR] ingest error: %v", err)
+		} else {
+			log.Printf("[CALENDAR] ingested events=%d from %s", n, provider.Name())
+		}
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// BlackoutMode controls what the order path does near a calendar event.
+type BlackoutMode string
+
+const (
+	BlackoutOff   BlackoutMode = "off"
+	BlackoutWarn  BlackoutMode = "warn"
+	BlackoutBlock BlackoutMode = "block"
+)
+
+type GuardOptions struct {
+	Mode      BlackoutMode
+	Before    time.Duration
+	After     time.Duration
+	MinImpact models.EventImpact
+}
+
+// Guard reports calendar events close enough to now to affect a new trade.
+type Guard struct {
+	store Store
+	opts  GuardOptions
+}
+
+// NewGuard builds a guard; an empty mode means warn and an empty impact HIGH. An unknown mode
+// or impact is an error rather than a silently different blackout.
+func NewGuard(store Store, opts GuardOptions) (*Guard, error) {
+	opts.Mode = BlackoutMode(strings.ToLower(strings.TrimSpace(string(opts.Mode))))
+	switch opts.Mode {
+	case "":
+		opts.Mode = BlackoutWarn
+	case BlackoutOff, BlackoutWarn, BlackoutBlock:
+	default:
+		return nil, fmt.Errorf("unknown blackout mode %q (want off, warn or block)", opts.Mode)
+	}
+	if strings.TrimSpace(string(opts.MinImpact)) == "" {
+		opts.MinImpact = models.EventImpactHigh
+	} else {
+		impact, err := ParseImpact(string(opts.MinImpact))
+		if err != nil {
+			return nil, fmt.Errorf("blackout min_impact: %w", err)
+		}
+		opts.MinImpact = impact
+	}
+	return &Guard{store: store, opts: opts}, nil
+}
+
+// BlackoutError is returned when a trade is refused because of nearby events.
+type BlackoutError struct {
+	Instrument string                 `json:"instrument"`
+	Events     []models.EconomicEvent `json:"events"`
+}
+
+func (e *BlackoutError) Error() string {
+	titles := make([]string, 0, len(e.Events))
+	for _, ev := range e.Events {
+		titles = append(titles, fmt.Sprintf("%s %s at %s", ev.Currency, ev.Title, ev.EventTime.Format(time.RFC3339)))
+	}
+	return fmt.Sprintf("economic event blackout for %s: %s", e.Instrument, strings.Join(titles, "; "))
+}
+
+// Check returns the events within the blackout window for instrument's currencies. In block
+// mode a non-empty result is returned together with a *BlackoutError; in warn mode the events
+// are returned with a nil error so the caller can surface them as warnings.
+func (g *Guard) Check(ctx context.Context, instrument string, now time.Time) ([]models.EconomicEvent, error) {
+	if g == nil || g.opts.Mode == BlackoutOff {
+		return nil, nil
+	}
+	currencies := models.InstrumentCurrencies(instrument)
+	if len(currencies) == 0 {
+		return nil, nil
+	}
+	events, err := g.store.ListEconomicEvents(ctx, now.Add(-g.opts.After), now.Add(g.opts.Before), currencies)
+	if err != nil {
+		return nil, err
+	}
+	var hits []models.EconomicEvent
+	for _, e := range events {
+		if e.Impact.Rank() >= g.opts.MinImpact.Rank() {
+			hits = append(hits, e)
+		}
+	}
+	if len(hits) > 0 && g.opts.Mode == BlackoutBlock {
+		return hits, &BlackoutError{Instrument: instrument, Events: hits}
+	}
+	return hits, nil
+}
@@ -0,0 +1,346 @@
+package calendar
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/jedi116/go-trader/pkg/models"
+)
+
+// Format is a supported calendar file format.
+type Format string
+
+const (
+	FormatCSV  Format = "csv"
+	FormatJSON Format = "json"
+	FormatICS  Format = "ics"
+)
+
+// FormatFromPath guesses the format from a file extension.
+func FormatFromPath(path string) (Format, error) {
+	lower := strings.ToLower(path)
+	switch {
+	case strings.HasSuffix(lower, ".csv"):
+		return FormatCSV, nil
+	case strings.HasSuffix(lower, ".json"):
+		return FormatJSON, nil
+	case strings.HasSuffix(lower, ".ics"), strings.HasSuffix(lower, ".ical"):
+		return FormatICS, nil
+	}
+	return "", fmt.Errorf("unknown calendar format for %s", path)
+}
+
+// Parse decodes events in the given format. source is recorded on every event.
+func Parse(r io.Reader, format Format, source string) ([]models.EconomicEvent, error) {
+	var events []models.EconomicEvent
+	var err error
+	switch format {
+	case FormatCSV:
+		events, err = parseCSV(r)
+	case FormatJSON:
+		events, err = parseJSON(r)
+	case FormatICS:
+		events, err = parseICS(r)
+	default:
+		return nil, fmt.Errorf("unsupported calendar format %q", format)
+	}
+	if err != nil {
+		return nil, err
+	}
+	for i := range events {
+		events[i].Source = source
+	}
+	return events, nil
+}
+
+var eventTimeLayouts = []string{
+	time.RFC3339,
+	"2006-01-02T15:04:05",
+	"2006-01-02 15:04:05",
+	"2006-01-02 15:04",
+	"20060102T150405Z",
+	"20060102T150405",
+	"20060102",
+}
+
+// parseEventTime parses a timestamp; values without a zone are taken as UTC.
+func parseEventTime(s string) (time.Time, error) {
+	return parseEventTimeIn(s, time.UTC)
+}
+
+// parseEventTimeIn parses a timestamp, taking values without a zone as local time in loc.
+func parseEventTimeIn(s string, loc *time.Location) (time.Time, error) {
+	s = strings.TrimSpace(s)
+	if strings.HasSuffix(s, "Z") {
+		// The ICS "...T150405Z" layout has Z as a literal, not a zone.
+		loc = time.UTC
+	}
+	for _, layout := range eventTimeLayouts {
+		if t, err := time.ParseInLocation(layout, s, loc); err == nil {
+			return t.UTC(), nil
+		}
+	}
+	return time.Time{}, fmt.Errorf("invalid event time %q", s)
+}
+
+// ParseImpact accepts LOW/MEDIUM/HIGH in any case plus common synonyms.
+func ParseImpact(s string) (models.EventImpact, error) {
+	switch strings.ToUpper(strings.TrimSpace(s)) {
+	case "HIGH", "H", "3", "RED":
+		return models.EventImpactHigh, nil
+	case "MEDIUM", "MED", "M", "2", "ORANGE":
+		return models.EventImpactMedium, nil
+	case "LOW", "L", "1", "YELLOW", "":
+		return models.EventImpactLow, nil
+	}
+	return "", fmt.Errorf("invalid impact %q", s)
+}
+
+func optional(s string) *string {
+	s = strings.TrimSpace(s)
+	if s == "" {
+		return nil
+	}
+	return &s
+}
+
+func validate(e *models.EconomicEvent) error {
+	e.Currency = strings.ToUpper(strings.TrimSpace(e.Currency))
+	e.Title = strings.TrimSpace(e.Title)
+	if len(e.Currency) != 3 {
+		return fmt.Errorf("event %q: invalid currency %q", e.Title, e.Currency)
+	}
+	if e.Title == "" {
+		return fmt.Errorf("event at %s: missing title", e.EventTime)
+	}
+	return nil
+}
+
+// parseCSV expects a header row with time, currency, impact and title columns;
+// forecast, previous and actual are optional.
+func parseCSV(r io.Reader) ([]models.EconomicEvent, error) {
+	cr := csv.NewReader(r)
+	cr.TrimLeadingSpace = true
+	header, err := cr.Read()
+	if err != nil {
+		return nil, err
+	}
+	col := make(map[string]int, len(header))
+	for i, h := range header {
+		col[strings.ToLower(strings.TrimSpace(h))] = i
+	}
+	for _, required := range []string{"time", "currency", "impact", "title"} {
+		if _, ok := col[required]; !ok {
+			return nil, fmt.Errorf("csv missing %q column", required)
+		}
+	}
+	get := func(rec []string, name string) string {
+		if i, ok := col[name]; ok && i < len(rec) {
+			return rec[i]
+		}
+		return ""
+	}
+	var out []models.EconomicEvent
+	line := 1
+	for {
+		rec, err := cr.Read()
+		if err == io.EOF {
+			break
+		}
+		line++
+		if err != nil {
+			return nil, err
+		}
+		t, err := parseEventTime(get(rec, "time"))
+		if err != nil {
+			return nil, fmt.Errorf("line %d: %w", line, err)
+		}
+		impact, err := ParseImpact(get(rec, "impact"))
+		if err != nil {
+			return nil, fmt.Errorf("line %d: %w", line, err)
+		}
+		e := models.EconomicEvent{
+			Title:     get(rec, "title"),
+			Currency:  get(rec, "currency"),
+			Impact:    impact,
+			EventTime: t,
+			Actual:    optional(get(rec, "actual")),
+			Forecast:  optional(get(rec, "forecast")),
+			Previous:  optional(get(rec, "previous")),
+		}
+		if err := validate(&e); err != nil {
+			return nil, fmt.Errorf("line %d: %w", line, err)
+		}
+		out = append(out, e)
+	}
+	return out, nil
+}
+
+// parseJSON expects an array of objects with the same fields as the CSV layout.
+func parseJSON(r io.Reader) ([]models.EconomicEvent, error) {
+	var raw []struct {
+		Time     string `json:"time"`
+		Currency string `json:"currency"`
+		Impact   string `json:"impact"`
+		Title    string `json:"title"`
+		Actual   string `json:"actual"`
+		Forecast string `json:"forecast"`
+		Previous string `json:"previous"`
+	}
+	if err := json.NewDecoder(r).Decode(&raw); err != nil {
+		return nil, err
+	}
+	out := make([]models.EconomicEvent, 0, len(raw))
+	for i, it := range raw {
+		t, err := parseEventTime(it.Time)
+		if err != nil {
+			return nil, fmt.Errorf("event %d: %w", i, err)
+		}
+		impact, err := ParseImpact(it.Impact)
+		if err != nil {
+			return nil, fmt.Errorf("event %d: %w", i, err)
+		}
+		e := models.EconomicEvent{
+			Title:     it.Title,
+			Currency:  it.Currency,
+			Impact:    impact,
+			EventTime: t,
+			Actual:    optional(it.Actual),
+			Forecast:  optional(it.Forecast),
+			Previous:  optional(it.Previous),
+		}
+		if err := validate(&e); err != nil {
+			return nil, fmt.Errorf("event %d: %w", i, err)
+		}
+		out = append(out, e)
+	}
+	return out, nil
+}
+
+var (
+	bracketCurrency = regexp.MustCompile(`^\[?([A-Z]{3})\]?[\s:-]+(.+)$`)
+	impactInText    = regexp.MustCompile(`(?i)impact:\s*(high|medium|low)`)
+)
+
+// parseICS reads VEVENTs. DTSTART is read in its TZID, which must be an IANA zone name, or UTC
+// without one. The currency comes from X-CURRENCY, CATEGORIES or a leading "[USD]"/"USD" in
+// SUMMARY; the impact from X-IMPACT, "Impact: High" in DESCRIPTION, or PRIORITY (1-4 high,
+// 5 medium, 6-9 low).
+func parseICS(r io.Reader) ([]models.EconomicEvent, error) {
+	// Unfold continuation lines (RFC 5545 3.1) before splitting properties.
+	data, err := io.ReadAll(r)
+	if err != nil {
+		return nil, err
+	}
+	data = bytes.ReplaceAll(data, []byte("\r\n"), []byte("\n"))
+	data = bytes.ReplaceAll(data, []byte("\n "), nil)
+	data = bytes.ReplaceAll(data, []byte("\n\t"), nil)
+
+	var out []models.EconomicEvent
+	var props map[string]string
+	sc := bufio.NewScanner(bytes.NewReader(data))
+	sc.Buffer(make([]byte, 64*1024), 1024*1024)
+	for sc.Scan() {
+		line := sc.Text()
+		switch {
+		case line == "BEGIN:VEVENT":
+			props = make(map[string]string)
+			continue
+		case line == "END:VEVENT":
+			if props == nil {
+				continue
+			}
+			e, err := icsEvent(props)
+			if err != nil {
+				return nil, err
+			}
+			out = append(out, e)
+			props = nil
+			continue
+		}
+		if props == nil {
+			continue
+		}
+		name, value, ok := strings.Cut(line, ":")
+		if !ok {
+			continue
+		}
+		// Keep only the TZID parameter, as "DTSTART;TZID"; drop the rest, e.g. VALUE=DATE.
+		name, params, _ := strings.Cut(name, ";")
+		name = strings.ToUpper(name)
+		for _, param := range strings.Split(params, ";") {
+			if k, v, ok := strings.Cut(param, "="); ok && strings.EqualFold(k, "TZID") {
+				props[name+";TZID"] = strings.Trim(v, `"`)
+			}
+		}
+		props[name] = unescapeICS(value)
+	}
+	return out, sc.Err()
+}
+
+func icsEvent(props map[string]string) (models.EconomicEvent, error) {
+	summary := strings.TrimSpace(props["SUMMARY"])
+	loc := time.UTC
+	if tz := props["DTSTART;TZID"]; tz != "" {
+		l, err := time.LoadLocation(tz)
+		if err != nil {
+			return models.EconomicEvent{}, fmt.Errorf("event %q: unknown TZID %q", summary, tz)
+		}
+		loc = l
+	}
+	t, err := parseEventTimeIn(props["DTSTART"], loc)
+	if err != nil {
+		return models.EconomicEvent{}, fmt.Errorf("event %q: %w", summary, err)
+	}
+	currency := props["X-CURRENCY"]
+	if currency == "" && len(strings.TrimSpace(props["CATEGORIES"])) == 3 {
+		currency = props["CATEGORIES"]
+	}
+	title := summary
+	if m := bracketCurrency.FindStringSubmatch(summary); m != nil {
+		if currency == "" {
+			currency = m[1]
+		}
+		if strings.EqualFold(currency, m[1]) {
+			title = m[2]
+		}
+	}
+	impactText := props["X-IMPACT"]
+	if impactText == "" {
+		if m := impactInText.FindStringSubmatch(props["DESCRIPTION"]); m != nil {
+			impactText = m[1]
+		}
+	}
+	if impactText == "" && props["PRIORITY"] != "" {
+		if p, err := strconv.Atoi(props["PRIORITY"]); err == nil {
+			switch {
+			case p >= 1 && p <= 4:
+				impactText = "HIGH"
+			case p == 5:
+				impactText = "MEDIUM"
+			}
+		}
+	}
+	impact, err := ParseImpact(impactText)
+	if err != nil {
+		return models.EconomicEvent{}, fmt.Errorf("event %q: %w", summary, err)
+	}
+	e := models.EconomicEvent{Title: title, Currency: currency, Impact: impact, EventTime: t}
+	if err := validate(&e); err != nil {
+		return models.EconomicEvent{}, err
+	}
+	return e, nil
+}
+
+func unescapeICS(s string) string {
+	r := strings.NewReplacer(`\n`, "\n", `\N`, "\n", `\,`, ",", `\;`, ";", `\\`, `\`)
+	return r.Replace(s)
+}
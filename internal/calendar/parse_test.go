@@ -0,0 +1,124 @@
+package calendar
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/jedi116/go-trader/pkg/models"
+)
+
+type wantEvent struct {
+	title    string
+	currency string
+	impact   models.EventImpact
+	at       time.Time
+	forecast string
+}
+
+func checkEvents(t *testing.T, got []models.EconomicEvent, want []wantEvent) {
+	t.Helper()
+	if len(got) != len(want) {
+		t.Fatalf("got %d events, want %d: %+v", len(got), len(want), got)
+	}
+	for i, w := range want {
+		e := got[i]
+		forecast := ""
+		if e.Forecast != nil {
+			forecast = *e.Forecast
+		}
+		if e.Title != w.title || e.Currency != w.currency || e.Impact != w.impact || !e.EventTime.Equal(w.at) || forecast != w.forecast || e.Source != "test" {
+			t.Errorf("event %d = %q %s %s %s forecast %q, want %q %s %s %s forecast %q", i,
+				e.Title, e.Currency, e.Impact, e.EventTime, forecast, w.title, w.currency, w.impact, w.at, w.forecast)
+		}
+	}
+}
+
+func parseFixture(t *testing.T, name string) []models.EconomicEvent {
+	t.Helper()
+	f, err := os.Open(filepath.Join("testdata", name))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+	format, err := FormatFromPath(name)
+	if err != nil {
+		t.Fatal(err)
+	}
+	events, err := Parse(f, format, "test")
+	if err != nil {
+		t.Fatal(err)
+	}
+	return events
+}
+
+func TestParseCSVAndJSON(t *testing.T) {
+	want := []wantEvent{
+		{"Non-Farm Payrolls", "USD", models.EventImpactHigh, time.Date(2025, 3, 7, 13, 30, 0, 0, time.UTC), "160K"},
+		{"ECB Rate Decision", "EUR", models.EventImpactMedium, time.Date(2025, 3, 6, 13, 15, 0, 0, time.UTC), "2.65%"},
+		{"GDP q/q", "JPY", models.EventImpactHigh, time.Date(2025, 3, 10, 0, 30, 0, 0, time.UTC), ""},
+	}
+	for _, name := range []string{"events.csv", "events.json"} {
+		t.Run(name, func(t *testing.T) { checkEvents(t, parseFixture(t, name), want) })
+	}
+}
+
+func TestParseICS(t *testing.T) {
+	checkEvents(t, parseFixture(t, "events.ics"), []wantEvent{
+		// 08:30 New York is 13:30 UTC before the March DST change and 12:30 UTC after it.
+		{"Non-Farm Payrolls", "USD", models.EventImpactHigh, time.Date(2025, 3, 7, 13, 30, 0, 0, time.UTC), ""},
+		{"Non-Farm Payrolls", "USD", models.EventImpactHigh, time.Date(2025, 6, 6, 12, 30, 0, 0, time.UTC), ""},
+		{"ECB Rate Decision", "EUR", models.EventImpactMedium, time.Date(2025, 3, 6, 13, 15, 0, 0, time.UTC), ""},
+		{"GDP q/q", "JPY", models.EventImpactLow, time.Date(2025, 3, 9, 23, 30, 0, 0, time.UTC), ""},
+	})
+}
+
+func TestParseRejects(t *testing.T) {
+	tests := []struct {
+		name   string
+		format Format
+		data   string
+		errHas string
+	}{
+		{"unknown TZID", FormatICS, "BEGIN:VEVENT\nDTSTART;TZID=Eastern Standard Time:20250307T083000\nSUMMARY:[USD] NFP\nEND:VEVENT\n", "unknown TZID"},
+		{"bad currency", FormatCSV, "time,currency,impact,title\n2025-03-07T13:30:00Z,US,high,NFP\n", "invalid currency"},
+		{"bad impact", FormatJSON, `[{"time":"2025-03-07T13:30:00Z","currency":"USD","impact":"extreme","title":"NFP"}]`, "invalid impact"},
+		{"bad time", FormatCSV, "time,currency,impact,title\nFriday,USD,high,NFP\n", "invalid event time"},
+		{"missing column", FormatCSV, "time,currency,title\n", `missing "impact"`},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := Parse(strings.NewReader(tt.data), tt.format, "test")
+			if err == nil || !strings.Contains(err.Error(), tt.errHas) {
+				t.Errorf("err = %v, want %q", err, tt.errHas)
+			}
+		})
+	}
+}
+
+func TestNewGuard(t *testing.T) {
+	tests := []struct {
+		mode    BlackoutMode
+		impact  models.EventImpact
+		want    GuardOptions
+		wantErr bool
+	}{
+		{"", "", GuardOptions{Mode: BlackoutWarn, MinImpact: models.EventImpactHigh}, false},
+		{"Block", "medium", GuardOptions{Mode: BlackoutBlock, MinImpact: models.EventImpactMedium}, false},
+		{"off", "LOW", GuardOptions{Mode: BlackoutOff, MinImpact: models.EventImpactLow}, false},
+		{"blocking", "HIGH", GuardOptions{}, true},
+		{"block", "hihg", GuardOptions{}, true},
+	}
+	for _, tt := range tests {
+		g, err := NewGuard(nil, GuardOptions{Mode: tt.mode, MinImpact: tt.impact})
+		if (err != nil) != tt.wantErr {
+			t.Errorf("NewGuard(%q, %q) err = %v", tt.mode, tt.impact, err)
+			continue
+		}
+		if err == nil && g.opts != tt.want {
+			t.Errorf("NewGuard(%q, %q) = %+v, want %+v", tt.mode, tt.impact, g.opts, tt.want)
+		}
+	}
+}
@@ -3,17 +3,32 @@ package config
 import (
 	"os"
 	"strings"
+	"time"
 
 	"github.com/joho/godotenv"
 	"github.com/spf13/viper"
 )
 
 type Config struct {
-	Server   ServerConfig   `mapstructure:"server"`
-	Database DatabaseConfig `mapstructure:"database"`
-	Redis    RedisConfig    `mapstructure:"redis"`
-	Broker   BrokerConfig   `mapstructure:"broker"`
-	Brave    BraveConfig    `mapstructure:"brave"`
+	Server     ServerConfig     `mapstructure:"server"`
+	Database   DatabaseConfig   `mapstructure:"database"`
+	Redis      RedisConfig      `mapstructure:"redis"`
+	Broker     BrokerConfig     `mapstructure:"broker"`
+	Brave      BraveConfig      `mapstructure:"brave"`
+	AI         AIConfig         `mapstructure:"ai"`
+	News       NewsConfig       `mapstructure:"news"`
+	Calendar   CalendarConfig   `mapstructure:"calendar"`
+	Risk       RiskConfig       `mapstructure:"risk"`
+	Market     MarketConfig     `mapstructure:"market_hours"`
+	Alerts     AlertsConfig     `mapstructure:"alerts"`
+	Notify     NotifyConfig     `mapstructure:"notify"`
+	Signals    SignalsConfig    `mapstructure:"signals"`
+	Strategies StrategiesConfig `mapstructure:"strategies"`
+	Backtest   BacktestConfig   `mapstructure:"backtest"`
+	Analytics  AnalyticsConfig  `mapstructure:"analytics"`
+	Snapshots  SnapshotsConfig  `mapstructure:"snapshots"`
+	Ticks      TicksConfig      `mapstructure:"ticks"`
+	Admin      AdminConfig      `mapstructure:"admin"`
 }
 
 type ServerConfig struct {
@@ -22,6 +37,10 @@ type ServerConfig struct {
 }
 
 type DatabaseConfig struct {
+	// Driver is "postgres" (default), "sqlite" or "memory"; SQLite keeps every table in the file
+	// at Path, memory keeps nothing across restarts.
+	Driver   string `mapstructure:"driver"`
+	Path     string `mapstructure:"path"`
 	Host     string `mapstructure:"host"`
 	Port     string `mapstructure:"port"`
 	User     string `mapstructure:"user"`
@@ -49,6 +68,278 @@ type BraveConfig struct {
 	BaseURL string `mapstructure:"base_url"`
 }
 
+type AIConfig struct {
+	// Pricing is keyed by model name; prices are USD per million tokens.
+	Pricing map[string]AIModelPrice `mapstructure:"pricing"`
+	Budget  AIBudgetConfig          `mapstructure:"budget"`
+	Cache   AICacheConfig           `mapstructure:"cache"`
+}
+
+type AIModelPrice struct {
+	InputPerMTok      float64 `mapstructure:"input_per_mtok"`
+	OutputPerMTok     float64 `mapstructure:"output_per_mtok"`
+	CacheWritePerMTok float64 `mapstructure:"cache_write_per_mtok"`
+	CacheReadPerMTok  float64 `mapstructure:"cache_read_per_mtok"`
+}
+
+// AIBudgetConfig limits model spend in USD; 0 means unlimited.
+type AIBudgetConfig struct {
+	DailyUSD   float64 `mapstructure:"daily_usd"`
+	MonthlyUSD float64 `mapstructure:"monthly_usd"`
+}
+
+// AICacheConfig controls market_analysis_cache lifetimes; zero values use defaults.
+type AICacheConfig struct {
+	MarketTTL       time.Duration `mapstructure:"market_ttl"`
+	NewsTTL         time.Duration `mapstructure:"news_ttl"`
+	JanitorInterval time.Duration `mapstructure:"janitor_interval"`
+}
+
+type NewsConfig struct {
+	// Scorer is "lexicon" (default, offline) or "llm".
+	Scorer       string        `mapstructure:"scorer"`
+	PerCurrency  int           `mapstructure:"per_currency"`
+	MaxAge       time.Duration `mapstructure:"max_age"`
+	MinRelevance float64       `mapstructure:"min_relevance"`
+	RSS          RSSConfig     `mapstructure:"rss"`
+}
+
+type RSSConfig struct {
+	// RefreshInterval is how long fetched feeds are reused before re-downloading.
+	RefreshInterval time.Duration `mapstructure:"refresh_interval"`
+	Feeds           []FeedConfig  `mapstructure:"feeds"`
+}
+
+// FeedConfig is an RSS/Atom feed; URL may also be a local file path.
+type FeedConfig struct {
+	Name string `mapstructure:"name"`
+	URL  string `mapstructure:"url"`
+}
+
+type CalendarConfig struct {
+	// File is a CSV, JSON or ICS calendar re-ingested every RefreshInterval; empty disables ingestion.
+	File            string        `mapstructure:"file"`
+	RefreshInterval time.Duration `mapstructure:"refresh_interval"`
+	// Lookahead is how far ahead events are added to the AI trading context.
+	Lookahead time.Duration  `mapstructure:"lookahead"`
+	Blackout  BlackoutConfig `mapstructure:"blackout"`
+}
+
+type BlackoutConfig struct {
+	// Mode is "off", "warn" or "block".
+	Mode      string        `mapstructure:"mode"`
+	Before    time.Duration `mapstructure:"before"`
+	After     time.Duration `mapstructure:"after"`
+	MinImpact string        `mapstructure:"min_impact"`
+}
+
+// RiskConfig holds pre-trade limits; a zero value disables the check. Notional and exposure
+// limits are in account currency, per-instrument and per-currency keys are case-insensitive.
+type RiskConfig struct {
+	MaxUnits              float64            `mapstructure:"max_units"`
+	MaxUnitsByInstrument  map[string]float64 `mapstructure:"max_units_by_instrument"`
+	MaxNotional           float64            `mapstructure:"max_notional"`
+	MaxOpenPositions      int                `mapstructure:"max_open_positions"`
+	MarginBuffer          float64            `mapstructure:"margin_buffer"`
+	RequireStopLoss       bool               `mapstructure:"require_stop_loss"`
+	MaxRiskPerTrade       float64            `mapstructure:"max_risk_per_trade"`
+	MaxCurrencyExposure   float64            `mapstructure:"max_currency_exposure"`
+	MaxExposureByCurrency map[string]float64 `mapstructure:"max_exposure_by_currency"`
+	// MaxCorrelation blocks stacking same-direction positions in instruments correlated at or
+	// above this value; 0 disables it.
+	MaxCorrelation float64 `mapstructure:"max_correlation"`
+	// CorrelationFailOpen allows the order when a correlation cannot be computed; the default
+	// rejects it.
+	CorrelationFailOpen bool            `mapstructure:"correlation_fail_open"`
+	Guardian            GuardianConfig  `mapstructure:"guardian"`
+	Portfolio           PortfolioConfig `mapstructure:"portfolio"`
+}
+
+// PortfolioConfig selects the stored candles used for correlations and VaR.
+type PortfolioConfig struct {
+	Timeframe  string  `mapstructure:"timeframe"`
+	Lookback   int     `mapstructure:"lookback"`
+	Confidence float64 `mapstructure:"confidence"`
+	// Horizon is in bars of Timeframe.
+	Horizon    int `mapstructure:"horizon"`
+	MinSamples int `mapstructure:"min_samples"`
+	// MaxStaleBars is how far, in bars, stored candles may lag before they are refetched from
+	// the broker.
+	MaxStaleBars int `mapstructure:"max_stale_bars"`
+}
+
+// GuardianConfig drives the daily loss/drawdown kill switch; thresholds are fractions of NAV.
+type GuardianConfig struct {
+	MaxDailyLoss  float64       `mapstructure:"max_daily_loss"`
+	MaxDrawdown   float64       `mapstructure:"max_drawdown"`
+	FlattenOnHalt bool          `mapstructure:"flatten_on_halt"`
+	CheckInterval time.Duration `mapstructure:"check_interval"`
+	// Timezone is an IANA name for the trading-day boundary; empty means UTC.
+	Timezone string `mapstructure:"timezone"`
+}
+
+// AdminConfig guards the admin REST routes and the gRPC AdminService.
+type AdminConfig struct {
+	// Token must be sent as X-Admin-Token (gRPC metadata x-admin-token); with no token the admin
+	// calls are refused.
+	Token string `mapstructure:"token"`
+}
+
+type MarketConfig struct {
+	// Timezone anchors the FX week and rollover; empty means America/New_York.
+	Timezone string `mapstructure:"timezone"`
+	// Rollover is "HH:MM" in Timezone; empty means 17:00.
+	Rollover       string        `mapstructure:"rollover"`
+	RolloverWindow time.Duration `mapstructure:"rollover_window"`
+	// ClosedOrders is "reject" (default), "queue" or "allow".
+	ClosedOrders string `mapstructure:"closed_orders"`
+	// QueueInterval is how often queued orders are retried while the market is open.
+	QueueInterval time.Duration   `mapstructure:"queue_interval"`
+	Holidays      []HolidayConfig `mapstructure:"holidays"`
+}
+
+// HolidayConfig closes the trading day ending on Date (YYYY-MM-DD), or from EarlyClose ("HH:MM") on it.
+type HolidayConfig struct {
+	Date       string `mapstructure:"date"`
+	Name       string `mapstructure:"name"`
+	EarlyClose string `mapstructure:"early_close"`
+}
+
+// AlertsConfig drives the alert engine, which needs a database for its rules.
+type AlertsConfig struct {
+	Enabled bool `mapstructure:"enabled"`
+	// Interval is how often quotes are polled and closed candles checked while the market is
+	// open; moves between two polls are not seen.
+	Interval time.Duration `mapstructure:"interval"`
+	// DefaultCooldown applies to repeating rules created without a cooldown.
+	DefaultCooldown time.Duration `mapstructure:"default_cooldown"`
+}
+
+// NotifyConfig routes events to channels; with no routes nothing is sent.
+type NotifyConfig struct {
+	// Attempts, Backoff and Timeout govern retries per channel; Backoff doubles per retry.
+	Attempts  int                   `mapstructure:"attempts"`
+	Backoff   time.Duration         `mapstructure:"backoff"`
+	Timeout   time.Duration         `mapstructure:"timeout"`
+	QueueSize int                   `mapstructure:"queue_size"`
+	Channels  []NotifyChannelConfig `mapstructure:"channels"`
+	Routes    []NotifyRouteConfig   `mapstructure:"routes"`
+}
+
+// NotifyChannelConfig is one destination. Type is "webhook", "slack", "discord" or "smtp";
+// URL and Secret apply to the HTTP types, the rest to smtp. A channel whose URL or Host is
+// empty (e.g. an unset env var) is skipped.
+type NotifyChannelConfig struct {
+	Name     string   `mapstructure:"name"`
+	Type     string   `mapstructure:"type"`
+	URL      string   `mapstructure:"url"`
+	Secret   string   `mapstructure:"secret"`
+	Host     string   `mapstructure:"host"`
+	Port     int      `mapstructure:"port"`
+	Username string   `mapstructure:"username"`
+	Password string   `mapstructure:"password"`
+	From     string   `mapstructure:"from"`
+	To       []string `mapstructure:"to"`
+}
+
+// NotifyRouteConfig sends events matching any pattern in Events (e.g. "order.*") to Channels.
+type NotifyRouteConfig struct {
+	Events   []string `mapstructure:"events"`
+	Channels []string `mapstructure:"channels"`
+}
+
+// SignalsConfig controls the inbound signal webhook.
+type SignalsConfig struct {
+	// Secret signs (X-Signature HMAC) or accompanies inbound signals; empty disables the endpoint.
+	Secret string `mapstructure:"secret"`
+	// AllowSharedSecret also accepts the secret itself in X-Signal-Secret or the payload's
+	// passphrase, for senders such as TradingView that cannot sign requests.
+	AllowSharedSecret bool `mapstructure:"allow_shared_secret"`
+	// Mode is "recommendation" (default) to store signals for acceptance, or "order" to trade them.
+	Mode string `mapstructure:"mode"`
+	// MaxAge rejects signals whose time field is further than this from now, in either direction;
+	// zero means 2m.
+	MaxAge time.Duration `mapstructure:"max_age"`
+	// DedupeWindow is how long signal IDs are remembered to refuse redelivered signals; it is
+	// raised to at least twice MaxAge.
+	DedupeWindow time.Duration `mapstructure:"dedupe_window"`
+}
+
+// StrategiesConfig runs the built-in strategy engine; see internal/strategy for the types.
+type StrategiesConfig struct {
+	// Interval is how often quotes are polled and closed candles checked while the market is
+	// open; moves between two polls are not seen.
+	Interval time.Duration `mapstructure:"interval"`
+	// Execution is "recommendation" (default) to store strategy orders for acceptance, or
+	// "order" to trade them; instances may override it.
+	Execution string           `mapstructure:"execution"`
+	Instances []StrategyConfig `mapstructure:"instances"`
+}
+
+// StrategyConfig is one strategy instance. Timeframe is an OANDA granularity, H1 by default;
+// Params are passed to the strategy type, which documents its own keys.
+type StrategyConfig struct {
+	Name        string                 `mapstructure:"name"`
+	Type        string                 `mapstructure:"type"`
+	Enabled     bool                   `mapstructure:"enabled"`
+	Instruments []string               `mapstructure:"instruments"`
+	Timeframe   string                 `mapstructure:"timeframe"`
+	Execution   string                 `mapstructure:"execution"`
+	Params      map[string]interface{} `mapstructure:"params"`
+}
+
+// BacktestConfig holds the defaults for backtests started from the API or cmd/backtest.
+type BacktestConfig struct {
+	InitialBalance float64 `mapstructure:"initial_balance"`
+	Currency       string  `mapstructure:"currency"`
+	// SpreadPips applies to candles stored without bid/ask.
+	SpreadPips           float64 `mapstructure:"spread_pips"`
+	SlippagePips         float64 `mapstructure:"slippage_pips"`
+	CommissionPerMillion float64 `mapstructure:"commission_per_million"`
+	// Intrabar is "stop_first" (default), "target_first" or "nearest".
+	Intrabar string `mapstructure:"intrabar"`
+	// MaxBars bounds runs started through the API; zero means 200000.
+	MaxBars int `mapstructure:"max_bars"`
+	// MaxOptimizations bounds the optimizations running at once, which share the CPUs; zero
+	// means 2.
+	MaxOptimizations int `mapstructure:"max_optimizations"`
+}
+
+// AnalyticsConfig drives the closed-trade sync and the trade performance reports.
+type AnalyticsConfig struct {
+	// SyncInterval is how often closed trades are fetched from OANDA while the market is open.
+	SyncInterval time.Duration `mapstructure:"sync_interval"`
+	// Timezone is an IANA name for the weekday and hour breakdowns; empty means UTC.
+	Timezone string `mapstructure:"timezone"`
+}
+
+// SnapshotsConfig drives the periodic account snapshots behind equity history.
+type SnapshotsConfig struct {
+	Enabled bool `mapstructure:"enabled"`
+	// Interval is the time between snapshots; zero means five minutes.
+	Interval time.Duration `mapstructure:"interval"`
+	// Retention is how long snapshots are kept; zero keeps them forever.
+	Retention time.Duration `mapstructure:"retention"`
+}
+
+// TicksConfig drives tick capture from the pricing stream and the candles built from it.
+type TicksConfig struct {
+	Enabled     bool     `mapstructure:"enabled"`
+	Instruments []string `mapstructure:"instruments"`
+	// Timeframes are built live from ticks, M1 to H1; empty means M1.
+	Timeframes []string `mapstructure:"timeframes"`
+	// BatchSize is how many ticks are written per insert; zero means 500.
+	BatchSize int `mapstructure:"batch_size"`
+	// FlushInterval bounds how long ticks wait for a full batch; zero means one second.
+	FlushInterval time.Duration `mapstructure:"flush_interval"`
+	// Retention is how long ticks are kept, in whole daily partitions; zero keeps them forever.
+	Retention time.Duration `mapstructure:"retention"`
+	// Compare checks each built candle against OANDA's once it completes.
+	Compare bool `mapstructure:"compare"`
+	// CompareDelay is how long after a candle ends OANDA's is fetched; zero means ten seconds.
+	CompareDelay time.Duration `mapstructure:"compare_delay"`
+}
+
 func Load() (*Config, error) {
 	// Load .env if it exists (silent fail)
 	_ = godotenv.Load()
@@ -0,0 +1,1176 @@
+package database
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/json"
+	"fmt"
+	"slices"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/jedi116/go-trader/pkg/models"
+)
+
+// Memory is a Store held in process memory, for running without a database and for tests. It
+// follows the Postgres behaviour: generated UUIDs, soft deletes, list limits and ordering, audit
+// entries for the same changes, and candle upserts that keep stored bid/ask prices. Logs and
+// series are capped (see the memoryMax constants) so a long-running process stays bounded.
+// StreamRows returns ErrUnsupported; exports read the typed records through the Export methods.
+type Memory struct {
+	mu sync.Mutex
+
+	trades        []models.Trade
+	deletedTrades map[string]bool
+	recs          []models.Recommendation
+	deletedRecs   map[string]bool
+	aiRecs        []models.AIRecommendation
+	candles       map[candleSeries]map[int64]models.MarketData
+	audit         []models.AuditLog
+	auditSeq      int64
+	usage         []models.AIUsageLog
+	events        []models.EconomicEvent
+	news          []models.NewsArticle
+	cache         []cacheEntry
+	guardian      map[string]models.GuardianState
+	queued        []models.QueuedOrder
+	alertRules    []models.AlertRule
+	deletedAlerts map[string]bool
+	alertEvents   []models.AlertEvent
+	deliveries    []models.NotificationDelivery
+	runs          []models.OptimizationRun
+	snapshots     []models.AccountSnapshot
+	ticks         map[string][]models.Tick
+}
+
+// The newest entries Memory keeps of each log or series; older ones are dropped.
+const (
+	memoryMaxAudit     = 10000
+	memoryMaxCandles   = 50000  // per instrument and timeframe
+	memoryMaxTicks     = 200000 // per instrument
+	memoryMaxLog       = 1000   // alert events, notification deliveries, news articles and cache entries
+	memoryMaxSnapshots = 10000
+	memoryMaxRuns      = 100
+)
+
+// trimOldest keeps the last max entries of a log held oldest first. It trims only once the log
+// is a tenth over, so the copy is spread over many appends.
+func trimOldest[T any](s []T, max int) []T {
+	if len(s) <= max+max/10 {
+		return s
+	}
+	return append([]T(nil), s[len(s)-max:]...)
+}
+
+type candleSeries struct {
+	instrument string
+	timeframe  string
+}
+
+type cacheEntry struct {
+	key         string
+	instruments string
+	data        []byte
+	expiresAt   time.Time
+	createdAt   time.Time
+}
+
+func NewMemory() *Memory {
+	return &Memory{
+		deletedTrades: make(map[string]bool),
+		deletedRecs:   make(map[string]bool),
+		candles:       make(map[candleSeries]map[int64]models.MarketData),
+		guardian:      make(map[string]models.GuardianState),
+		deletedAlerts: make(map[string]bool),
+		ticks:         make(map[string][]models.Tick),
+	}
+}
+
+// newID returns a random version 4 UUID.
+func newID() string {
+	var b [16]byte
+	_, _ = rand.Read(b[:])
+	b[6] = b[6]&0x0f | 0x40
+	b[8] = b[8]&0x3f | 0x80
+	return fmt.Sprintf("%x-%x-%x-%x-%x", b[0:4], b[4:6], b[6:8], b[8:10], b[10:])
+}
+
+// logAudit appends an audit entry; the caller holds mu.
+func (m *Memory) logAudit(entity, entityID, action string, details map[string]interface{}) {
+	raw, _ := json.Marshal(details)
+	m.auditSeq++
+	m.audit = trimOldest(append(m.audit, models.AuditLog{
+		ID: m.auditSeq, Entity: entity, EntityID: entityID, Action: action, Details: raw, CreatedAt: time.Now(),
+	}), memoryMaxAudit)
+}
+
+func (m *Memory) LogAudit(ctx context.Context, entity string, entityID string, action string, details map[string]interface{}) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.logAudit(entity, entityID, action, details)
+	return nil
+}
+
+// AuditLogs returns the retained audit entries in the order they were written.
+func (m *Memory) AuditLogs() []models.AuditLog {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return append([]models.AuditLog(nil), m.audit...)
+}
+
+// Trades
+
+func (m *Memory) CreateTrade(ctx context.Context, t *models.Trade) error {
+	if t.Source == "" {
+		t.Source = models.TradeSourceManual
+	}
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	row := *t
+	if row.ID == "" {
+		row.ID = newID()
+	}
+	row.CreatedAt = time.Now()
+	row.UpdatedAt = row.CreatedAt
+	m.trades = append(m.trades, row)
+	m.logAudit("trades", row.ID, "CREATE", map[string]interface{}{"instrument": row.Instrument, "direction": row.Direction, "units": row.Units, "source": row.Source})
+	return nil
+}
+
+func (m *Memory) ListTrades(ctx context.Context, limit int) ([]models.Trade, error) {
+	if limit <= 0 || limit > 500 {
+		limit = 200
+	}
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	var out []models.Trade
+	for i := len(m.trades) - 1; i >= 0 && len(out) < limit; i-- {
+		if !m.deletedTrades[m.trades[i].ID] {
+			out = append(out, m.trades[i])
+		}
+	}
+	return out, nil
+}
+
+func (m *Memory) SoftDeleteTrade(ctx context.Context, id string) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.deletedTrades[id] = true
+	m.logAudit("trades", id, "DELETE", map[string]interface{}{})
+	return nil
+}
+
+// ListOpenTradeIDs returns the OANDA trade IDs of trades still recorded as open.
+func (m *Memory) ListOpenTradeIDs(ctx context.Context) ([]string, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	var out []string
+	for _, t := range m.trades {
+		if !m.deletedTrades[t.ID] && t.Status == models.TradeStatusOpen && t.OandaTradeID != nil {
+			out = append(out, *t.OandaTradeID)
+		}
+	}
+	return out, nil
+}
+
+// CloseTrade records the outcome of the open trade with the given OANDA trade ID. It reports
+// false when no open trade matched.
+func (m *Memory) CloseTrade(ctx context.Context, oandaTradeID string, exit, pl, swap float64, closedAt time.Time) (bool, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	for i := range m.trades {
+		t := &m.trades[i]
+		if m.deletedTrades[t.ID] || t.Status != models.TradeStatusOpen || t.OandaTradeID == nil || *t.OandaTradeID != oandaTradeID {
+			continue
+		}
+		t.Status = models.TradeStatusClosed
+		t.ExitPrice, t.ProfitLoss, t.Swap, t.ClosedAt = &exit, &pl, &swap, &closedAt
+		t.UpdatedAt = time.Now()
+		m.logAudit("trades", t.ID, "CLOSE", map[string]interface{}{"oanda_trade_id": oandaTradeID, "profit_loss": pl})
+		return true, nil
+	}
+	return false, nil
+}
+
+// closedTrades returns the closed trades in order of closing; the caller holds mu.
+func (m *Memory) closedTrades() []models.Trade {
+	var out []models.Trade
+	for _, t := range m.trades {
+		if !m.deletedTrades[t.ID] && t.Status == models.TradeStatusClosed && t.ClosedAt != nil {
+			out = append(out, t)
+		}
+	}
+	sort.SliceStable(out, func(i, j int) bool { return out[i].ClosedAt.Before(*out[j].ClosedAt) })
+	return out
+}
+
+// ListClosedTrades returns closed trades in order of closing, optionally bounded by closing time.
+func (m *Memory) ListClosedTrades(ctx context.Context, from, to *time.Time) ([]models.Trade, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	var out []models.Trade
+	for _, t := range m.closedTrades() {
+		if (from == nil || !t.ClosedAt.Before(*from)) && (to == nil || t.ClosedAt.Before(*to)) {
+			out = append(out, t)
+		}
+	}
+	return out, nil
+}
+
+// SumRealizedPL totals profit_loss of trades closed at or after since and counts them.
+func (m *Memory) SumRealizedPL(ctx context.Context, since time.Time) (float64, int, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	var total float64
+	var n int
+	for _, t := range m.closedTrades() {
+		if t.ClosedAt.Before(since) {
+			continue
+		}
+		if t.ProfitLoss != nil {
+			total += *t.ProfitLoss
+		}
+		n++
+	}
+	return total, n, nil
+}
+
+// Recommendations
+
+func (m *Memory) CreateRecommendation(ctx context.Context, r *models.Recommendation) (string, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	row := *r
+	if row.ID == "" {
+		row.ID = newID()
+	}
+	row.CreatedAt = time.Now()
+	m.recs = append(m.recs, row)
+	m.logAudit("recommendations", row.ID, "CREATE", map[string]interface{}{"instrument": r.Instrument, "direction": r.Direction, "units": r.Units})
+	return row.ID, nil
+}
+
+func (m *Memory) ListRecommendations(ctx context.Context) ([]models.Recommendation, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	var out []models.Recommendation
+	for i := len(m.recs) - 1; i >= 0 && len(out) < 200; i-- {
+		if !m.deletedRecs[m.recs[i].ID] {
+			out = append(out, m.recs[i])
+		}
+	}
+	return out, nil
+}
+
+func (m *Memory) MarkRecommendationExecuted(ctx context.Context, id string, tradeID string) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	for i := range m.recs {
+		if r := &m.recs[i]; r.ID == id {
+			now := time.Now()
+			r.Status, r.TradeID, r.ExecutedAt = models.RecommendationStatusExecuted, &tradeID, &now
+		}
+	}
+	m.logAudit("recommendations", id, "EXECUTE", map[string]interface{}{"trade_id": tradeID})
+	return nil
+}
+
+// TransitionRecommendation moves a recommendation that is not deleted from one status to another,
+// setting executed_at when it becomes EXECUTED. It reports false when the recommendation was not
+// in the from status.
+func (m *Memory) TransitionRecommendation(ctx context.Context, id string, from, to models.RecommendationStatus) (bool, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if m.deletedRecs[id] {
+		return false, nil
+	}
+	for i := range m.recs {
+		r := &m.recs[i]
+		if r.ID != id || r.Status != from {
+			continue
+		}
+		r.Status, r.ExecutedAt = to, nil
+		if to == models.RecommendationStatusExecuted {
+			now := time.Now()
+			r.ExecutedAt = &now
+		}
+		return true, nil
+	}
+	return false, nil
+}
+
+func (m *Memory) SoftDeleteRecommendation(ctx context.Context, id string) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.deletedRecs[id] = true
+	m.logAudit("recommendations", id, "DELETE", map[string]interface{}{})
+	return nil
+}
+
+func (m *Memory) CreateAIRecommendation(ctx context.Context, r *models.AIRecommendation) (string, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	row := *r
+	if row.ID == "" {
+		row.ID = newID()
+	}
+	row.CreatedAt = time.Now()
+	row.UpdatedAt = row.CreatedAt
+	m.aiRecs = append(m.aiRecs, row)
+	m.logAudit("ai_recommendations", row.ID, "CREATE", map[string]interface{}{"instrument": r.Instrument, "direction": r.Direction, "units": r.Units})
+	return row.ID, nil
+}
+
+// updateAIRecommendation applies fn to the AI recommendation with the given ID; the caller
+// holds mu.
+func (m *Memory) updateAIRecommendation(id string, fn func(r *models.AIRecommendation)) {
+	for i := range m.aiRecs {
+		if r := &m.aiRecs[i]; r.ID == id {
+			fn(r)
+			r.UpdatedAt = time.Now()
+		}
+	}
+}
+
+func (m *Memory) UpdateAIRecommendationStatus(ctx context.Context, id string, status models.AIRecommendationStatus) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.updateAIRecommendation(id, func(r *models.AIRecommendation) { r.Status = status })
+	return nil
+}
+
+// MarkAIRecommendationExecuted sets status to EXECUTED and stores the executed trade id
+func (m *Memory) MarkAIRecommendationExecuted(ctx context.Context, id string, tradeID string) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.updateAIRecommendation(id, func(r *models.AIRecommendation) {
+		r.Status, r.ExecutedTradeID = models.AIRecommendationStatusExecuted, &tradeID
+	})
+	m.logAudit("ai_recommendations", id, "EXECUTE", map[string]interface{}{"trade_id": tradeID})
+	return nil
+}
+
+// ClaimAIRecommendation sets a PENDING or APPROVED AI recommendation whose time_to_live is after
+// now to EXECUTED. It reports false when the recommendation was not claimable.
+func (m *Memory) ClaimAIRecommendation(ctx context.Context, id string, now time.Time) (bool, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	for i := range m.aiRecs {
+		r := &m.aiRecs[i]
+		if r.ID != id || !r.TimeToLive.After(now) ||
+			(r.Status != models.AIRecommendationStatusPending && r.Status != models.AIRecommendationStatusApproved) {
+			continue
+		}
+		r.Status, r.UpdatedAt = models.AIRecommendationStatusExecuted, time.Now()
+		return true, nil
+	}
+	return false, nil
+}
+
+func (m *Memory) ListAIRecommendations(ctx context.Context, limit int) ([]models.AIRecommendation, error) {
+	if limit <= 0 || limit > 500 {
+		limit = 200
+	}
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	var out []models.AIRecommendation
+	for i := len(m.aiRecs) - 1; i >= 0 && len(out) < limit; i-- {
+		out = append(out, m.aiRecs[i])
+	}
+	return out, nil
+}
+
+// Market data
+
+// UpsertMarketData inserts candles or updates the stored ones with the same instrument, time
+// and timeframe, keeping stored bid/ask prices the incoming candle lacks.
+func (m *Memory) UpsertMarketData(ctx context.Context, rows []models.MarketData) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	for _, r := range rows {
+		key := candleSeries{r.Instrument, r.Timeframe}
+		series := m.candles[key]
+		if series == nil {
+			series = make(map[int64]models.MarketData)
+			m.candles[key] = series
+		}
+		ts := r.Timestamp.UnixNano()
+		if old, ok := series[ts]; ok {
+			r.ID, r.CreatedAt = old.ID, old.CreatedAt
+			if r.Bid == nil {
+				r.Bid = old.Bid
+			}
+			if r.Ask == nil {
+				r.Ask = old.Ask
+			}
+		} else {
+			if r.ID == "" {
+				r.ID = newID()
+			}
+			r.CreatedAt = time.Now()
+		}
+		series[ts] = r
+	}
+	for _, series := range m.candles {
+		trimSeries(series)
+	}
+	return nil
+}
+
+// trimSeries drops the oldest candles of a series over memoryMaxCandles, a tenth at a time.
+func trimSeries(series map[int64]models.MarketData) {
+	if len(series) <= memoryMaxCandles+memoryMaxCandles/10 {
+		return
+	}
+	keys := make([]int64, 0, len(series))
+	for ts := range series {
+		keys = append(keys, ts)
+	}
+	sort.Slice(keys, func(i, j int) bool { return keys[i] < keys[j] })
+	for _, ts := range keys[:len(keys)-memoryMaxCandles] {
+		delete(series, ts)
+	}
+}
+
+// series returns a series' candles oldest first; the caller holds mu.
+func (m *Memory) series(instrument, timeframe string) []models.MarketData {
+	series := m.candles[candleSeries{instrument, timeframe}]
+	out := make([]models.MarketData, 0, len(series))
+	for _, c := range series {
+		out = append(out, c)
+	}
+	sort.Slice(out, func(i, j int) bool { return out[i].Timestamp.Before(out[j].Timestamp) })
+	return out
+}
+
+// ListMarketDataRange returns candles with from <= timestamp < to, oldest first, including bid
+// and ask where stored.
+func (m *Memory) ListMarketDataRange(ctx context.Context, instrument, timeframe string, from, to time.Time) ([]models.MarketData, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	var out []models.MarketData
+	for _, c := range m.series(instrument, timeframe) {
+		if !c.Timestamp.Before(from) && c.Timestamp.Before(to) {
+			out = append(out, c)
+		}
+	}
+	return out, nil
+}
+
+// ListMarketData returns the latest candles, newest first, without bid and ask as Postgres does.
+func (m *Memory) ListMarketData(ctx context.Context, instrument string, timeframe string, limit int) ([]models.MarketData, error) {
+	if limit <= 0 || limit > 5000 {
+		limit = 500
+	}
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	all := m.series(instrument, timeframe)
+	var out []models.MarketData
+	for i := len(all) - 1; i >= 0 && len(out) < limit; i-- {
+		c := all[i]
+		c.Bid, c.Ask = nil, nil
+		out = append(out, c)
+	}
+	return out, nil
+}
+
+// AI usage logs
+
+func (m *Memory) CreateAIUsageLog(ctx context.Context, l *models.AIUsageLog) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	row := *l
+	row.ID = newID()
+	row.CreatedAt = time.Now()
+	m.usage = append(m.usage, row)
+	return nil
+}
+
+// SummarizeAIUsage aggregates usage per model for logs created at or after since.
+func (m *Memory) SummarizeAIUsage(ctx context.Context, since time.Time) ([]models.AIUsageTotals, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	byModel := make(map[string]*models.AIUsageTotals)
+	for _, l := range m.usage {
+		if l.CreatedAt.Before(since) {
+			continue
+		}
+		t := byModel[l.Model]
+		if t == nil {
+			t = &models.AIUsageTotals{Model: l.Model}
+			byModel[l.Model] = t
+		}
+		t.Requests++
+		t.PromptTokens += l.PromptTokens
+		t.CompletionTokens += l.CompletionTokens
+		t.CacheCreationTokens += l.CacheCreationTokens
+		t.CacheReadTokens += l.CacheReadTokens
+		t.TotalTokens += l.TotalTokens
+		t.CostUSD += l.CostUSD
+	}
+	var out []models.AIUsageTotals
+	for _, t := range byModel {
+		out = append(out, *t)
+	}
+	sort.Slice(out, func(i, j int) bool { return out[i].Model < out[j].Model })
+	return out, nil
+}
+
+func (m *Memory) Close() error { return nil }
+
+// Export
+
+// StreamRows returns ErrUnsupported: Memory has no SQL. internal/export reads it through the
+// Export methods below and filters the records itself.
+func (m *Memory) StreamRows(ctx context.Context, query string, args []interface{}, fn func(values []interface{}) error) error {
+	return ErrUnsupported
+}
+
+// ExportTrades returns the trades that are not deleted, in the order they were created.
+func (m *Memory) ExportTrades(ctx context.Context) ([]models.Trade, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	out := make([]models.Trade, 0, len(m.trades))
+	for _, t := range m.trades {
+		if !m.deletedTrades[t.ID] {
+			out = append(out, t)
+		}
+	}
+	return out, nil
+}
+
+// ExportAIRecommendations returns the AI recommendations in the order they were created.
+func (m *Memory) ExportAIRecommendations(ctx context.Context) ([]models.AIRecommendation, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return append([]models.AIRecommendation(nil), m.aiRecs...), nil
+}
+
+// ExportAuditLogs returns the retained audit entries in the order they were written.
+func (m *Memory) ExportAuditLogs(ctx context.Context) ([]models.AuditLog, error) {
+	return m.AuditLogs(), nil
+}
+
+// ExportTicks returns the retained ticks of every instrument.
+func (m *Memory) ExportTicks(ctx context.Context) ([]models.Tick, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	var out []models.Tick
+	for _, series := range m.ticks {
+		out = append(out, series...)
+	}
+	return out, nil
+}
+
+// ExportMarketData returns the retained candles of every series, including bid and ask where
+// stored.
+func (m *Memory) ExportMarketData(ctx context.Context) ([]models.MarketData, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	var out []models.MarketData
+	for key := range m.candles {
+		out = append(out, m.series(key.instrument, key.timeframe)...)
+	}
+	return out, nil
+}
+
+// Market analysis cache
+
+func (m *Memory) InsertMarketAnalysisCache(ctx context.Context, key string, instruments string, analysisData []byte, expiresAt time.Time) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.cache = trimOldest(append(m.cache, cacheEntry{key: key, instruments: instruments, data: analysisData, expiresAt: expiresAt, createdAt: time.Now()}), memoryMaxLog)
+	return nil
+}
+
+// GetMarketAnalysisCache returns the newest unexpired entry for key.
+func (m *Memory) GetMarketAnalysisCache(ctx context.Context, key string) ([]byte, bool, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	now := time.Now()
+	for i := len(m.cache) - 1; i >= 0; i-- {
+		if e := m.cache[i]; e.key == key && e.expiresAt.After(now) {
+			return e.data, true, nil
+		}
+	}
+	return nil, false, nil
+}
+
+func (m *Memory) PurgeExpiredMarketAnalysisCache(ctx context.Context) (int64, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	now := time.Now()
+	kept := m.cache[:0]
+	for _, e := range m.cache {
+		if e.expiresAt.After(now) {
+			kept = append(kept, e)
+		}
+	}
+	n := int64(len(m.cache) - len(kept))
+	m.cache = kept
+	return n, nil
+}
+
+// Economic calendar
+
+func (m *Memory) UpsertEconomicEvents(ctx context.Context, events []models.EconomicEvent) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	now := time.Now()
+	for _, e := range events {
+		i := slices.IndexFunc(m.events, func(o models.EconomicEvent) bool {
+			return o.Currency == e.Currency && o.Title == e.Title && o.EventTime.Equal(e.EventTime)
+		})
+		if i >= 0 {
+			e.ID, e.CreatedAt = m.events[i].ID, m.events[i].CreatedAt
+			m.events[i] = e
+			continue
+		}
+		e.ID, e.CreatedAt = newID(), now
+		m.events = append(m.events, e)
+	}
+	return nil
+}
+
+// ListEconomicEvents returns events in [from, to], optionally restricted to currencies, oldest first.
+func (m *Memory) ListEconomicEvents(ctx context.Context, from, to time.Time, currencies []string) ([]models.EconomicEvent, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	var out []models.EconomicEvent
+	for _, e := range m.events {
+		if e.EventTime.Before(from) || e.EventTime.After(to) || (len(currencies) > 0 && !slices.Contains(currencies, e.Currency)) {
+			continue
+		}
+		out = append(out, e)
+	}
+	sort.SliceStable(out, func(i, j int) bool { return out[i].EventTime.Before(out[j].EventTime) })
+	if len(out) > 1000 {
+		out = out[:1000]
+	}
+	return out, nil
+}
+
+// Account guardian
+
+// GetGuardianState returns nil when the account has no stored state yet.
+func (m *Memory) GetGuardianState(ctx context.Context, accountID string) (*models.GuardianState, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	s, ok := m.guardian[accountID]
+	if !ok {
+		return nil, nil
+	}
+	return &s, nil
+}
+
+func (m *Memory) SaveGuardianState(ctx context.Context, s *models.GuardianState) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	row := *s
+	row.TradingDay, row.UpdatedAt = dateOf(s.TradingDay), time.Now()
+	m.guardian[s.AccountID] = row
+	return nil
+}
+
+// dateOf returns t's calendar date at midnight UTC, as a Postgres DATE column reads back.
+func dateOf(t time.Time) time.Time {
+	y, mo, d := t.Date()
+	return time.Date(y, mo, d, 0, 0, 0, 0, time.UTC)
+}
+
+// Queued orders
+
+func (m *Memory) CreateQueuedOrder(ctx context.Context, o *models.QueuedOrder) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	o.ID, o.Status, o.CreatedAt = newID(), models.QueuedOrderPending, time.Now()
+	o.Error, o.OandaOrderID, o.SubmittedAt = nil, nil, nil
+	m.queued = append(m.queued, *o)
+	return nil
+}
+
+// ListQueuedOrders returns orders oldest first; an empty status lists all.
+func (m *Memory) ListQueuedOrders(ctx context.Context, status models.QueuedOrderStatus, limit int) ([]models.QueuedOrder, error) {
+	if limit <= 0 || limit > 1000 {
+		limit = 200
+	}
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	var out []models.QueuedOrder
+	for _, o := range m.queued {
+		if len(out) == limit {
+			break
+		}
+		if status == "" || o.Status == status {
+			out = append(out, o)
+		}
+	}
+	return out, nil
+}
+
+// TransitionQueuedOrder moves an order from one status to another and records the broker order
+// ID and error when given. It reports false when the order was not in the from status.
+func (m *Memory) TransitionQueuedOrder(ctx context.Context, id string, from, to models.QueuedOrderStatus, oandaOrderID, errMsg *string) (bool, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	for i := range m.queued {
+		o := &m.queued[i]
+		if o.ID != id || o.Status != from {
+			continue
+		}
+		o.Status = to
+		if oandaOrderID != nil {
+			o.OandaOrderID = oandaOrderID
+		}
+		if errMsg != nil {
+			o.Error = errMsg
+		}
+		if to == models.QueuedOrderSubmitted && o.SubmittedAt == nil {
+			now := time.Now()
+			o.SubmittedAt = &now
+		}
+		return true, nil
+	}
+	return false, nil
+}
+
+// Alerts
+
+func (m *Memory) CreateAlertRule(ctx context.Context, r *models.AlertRule) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	r.ID, r.FireCount, r.LastFiredAt = newID(), 0, nil
+	r.CreatedAt = time.Now()
+	r.UpdatedAt = r.CreatedAt
+	m.alertRules = append(m.alertRules, *r)
+	return nil
+}
+
+// alertRule returns the live rule with the given ID; the caller holds mu.
+func (m *Memory) alertRule(id string) *models.AlertRule {
+	if m.deletedAlerts[id] {
+		return nil
+	}
+	for i := range m.alertRules {
+		if m.alertRules[i].ID == id {
+			return &m.alertRules[i]
+		}
+	}
+	return nil
+}
+
+// GetAlertRule returns nil when the rule does not exist or was deleted.
+func (m *Memory) GetAlertRule(ctx context.Context, id string) (*models.AlertRule, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	r := m.alertRule(id)
+	if r == nil {
+		return nil, nil
+	}
+	row := *r
+	return &row, nil
+}
+
+func (m *Memory) ListAlertRules(ctx context.Context, activeOnly bool) ([]models.AlertRule, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	var out []models.AlertRule
+	for _, r := range m.alertRules {
+		if !m.deletedAlerts[r.ID] && (!activeOnly || r.Active) {
+			out = append(out, r)
+		}
+	}
+	return out, nil
+}
+
+// UpdateAlertRule replaces the editable fields of a rule; it reports false when no such rule exists.
+func (m *Memory) UpdateAlertRule(ctx context.Context, r *models.AlertRule) (bool, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	stored := m.alertRule(r.ID)
+	if stored == nil {
+		return false, nil
+	}
+	r.FireCount, r.LastFiredAt, r.CreatedAt, r.UpdatedAt = stored.FireCount, stored.LastFiredAt, stored.CreatedAt, time.Now()
+	*stored = *r
+	return true, nil
+}
+
+func (m *Memory) SoftDeleteAlertRule(ctx context.Context, id string) (bool, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	r := m.alertRule(id)
+	if r == nil {
+		return false, nil
+	}
+	r.Active = false
+	m.deletedAlerts[id] = true
+	return true, nil
+}
+
+// RecordAlertFired stores an event and bumps the rule's fire count, deactivating one-shot rules.
+func (m *Memory) RecordAlertFired(ctx context.Context, e *models.AlertEvent, deactivate bool) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	var r *models.AlertRule
+	for i := range m.alertRules {
+		if m.alertRules[i].ID == e.RuleID {
+			r = &m.alertRules[i]
+		}
+	}
+	if r == nil {
+		return fmt.Errorf("alert rule %s not found", e.RuleID)
+	}
+	e.ID = newID()
+	m.alertEvents = trimOldest(append(m.alertEvents, *e), memoryMaxLog)
+	firedAt := e.FiredAt
+	r.FireCount++
+	r.LastFiredAt = &firedAt
+	r.Active = r.Active && !deactivate
+	r.UpdatedAt = time.Now()
+	return nil
+}
+
+// ListAlertEvents returns firings newest first; an empty ruleID lists all rules.
+func (m *Memory) ListAlertEvents(ctx context.Context, ruleID string, limit int) ([]models.AlertEvent, error) {
+	if limit <= 0 || limit > 1000 {
+		limit = 100
+	}
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	var out []models.AlertEvent
+	for _, e := range m.alertEvents {
+		if ruleID == "" || e.RuleID == ruleID {
+			out = append(out, e)
+		}
+	}
+	sort.SliceStable(out, func(i, j int) bool { return out[i].FiredAt.After(out[j].FiredAt) })
+	if len(out) > limit {
+		out = out[:limit]
+	}
+	return out, nil
+}
+
+// Notifications
+
+func (m *Memory) LogNotificationDelivery(ctx context.Context, d *models.NotificationDelivery) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	d.ID, d.CreatedAt = newID(), time.Now()
+	m.deliveries = trimOldest(append(m.deliveries, *d), memoryMaxLog)
+	return nil
+}
+
+// ListNotificationDeliveries returns deliveries newest first; an empty status lists all.
+func (m *Memory) ListNotificationDeliveries(ctx context.Context, status models.NotificationStatus, limit int) ([]models.NotificationDelivery, error) {
+	if limit <= 0 || limit > 1000 {
+		limit = 100
+	}
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	var out []models.NotificationDelivery
+	for i := len(m.deliveries) - 1; i >= 0 && len(out) < limit; i-- {
+		if d := m.deliveries[i]; status == "" || d.Status == status {
+			out = append(out, d)
+		}
+	}
+	return out, nil
+}
+
+// Optimization runs
+
+func (m *Memory) CreateOptimizationRun(ctx context.Context, r *models.OptimizationRun) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	r.ID, r.Status, r.CreatedAt = newID(), models.OptimizationRunning, time.Now()
+	r.Metrics, r.Result, r.Equity, r.Error, r.CompletedAt = nil, nil, nil, nil, nil
+	m.runs = trimOldest(append(m.runs, *r), memoryMaxRuns)
+	return nil
+}
+
+// CompleteOptimizationRun records the outcome of a run; errMsg is set for failed runs.
+func (m *Memory) CompleteOptimizationRun(ctx context.Context, id string, status models.OptimizationStatus, metrics, result, equity []byte, errMsg *string) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	for i := range m.runs {
+		if r := &m.runs[i]; r.ID == id {
+			now := time.Now()
+			r.Status, r.Metrics, r.Result, r.Equity, r.Error, r.CompletedAt = status, metrics, result, equity, errMsg, &now
+		}
+	}
+	return nil
+}
+
+// GetOptimizationRun returns nil when the run does not exist.
+func (m *Memory) GetOptimizationRun(ctx context.Context, id string) (*models.OptimizationRun, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	for _, r := range m.runs {
+		if r.ID == id {
+			return &r, nil
+		}
+	}
+	return nil, nil
+}
+
+// ListOptimizationRuns returns runs newest first without their results and equity curves.
+func (m *Memory) ListOptimizationRuns(ctx context.Context, limit int) ([]models.OptimizationRun, error) {
+	if limit <= 0 || limit > 1000 {
+		limit = 100
+	}
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	var out []models.OptimizationRun
+	for i := len(m.runs) - 1; i >= 0 && len(out) < limit; i-- {
+		r := m.runs[i]
+		r.Result, r.Equity = nil, nil
+		out = append(out, r)
+	}
+	return out, nil
+}
+
+// News archive
+
+// ArchiveNews stores articles not seen before; an article keeps the score it had when first seen.
+func (m *Memory) ArchiveNews(ctx context.Context, articles []models.NewsArticle) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	now := time.Now()
+	for _, a := range articles {
+		if slices.ContainsFunc(m.news, func(o models.NewsArticle) bool { return o.URL == a.URL }) {
+			continue
+		}
+		a.ID, a.FirstSeenAt = newID(), now
+		if a.Currencies == nil {
+			a.Currencies = []string{}
+		}
+		m.news = append(m.news, a)
+	}
+	m.news = trimOldest(m.news, memoryMaxLog)
+	return nil
+}
+
+// ListNewsArticles returns articles available in [from, to], newest first, optionally
+// restricted to those relevant to one of currencies.
+func (m *Memory) ListNewsArticles(ctx context.Context, from, to time.Time, currencies []string, limit int) ([]models.NewsArticle, error) {
+	if limit <= 0 || limit > 1000 {
+		limit = 100
+	}
+	available := func(a models.NewsArticle) time.Time {
+		if a.PublishedAt != nil {
+			return *a.PublishedAt
+		}
+		return a.FirstSeenAt
+	}
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	var out []models.NewsArticle
+	for _, a := range m.news {
+		at := available(a)
+		if at.Before(from) || at.After(to) {
+			continue
+		}
+		if len(currencies) > 0 && !slices.ContainsFunc(a.Currencies, func(c string) bool { return slices.Contains(currencies, c) }) {
+			continue
+		}
+		out = append(out, a)
+	}
+	sort.SliceStable(out, func(i, j int) bool { return available(out[i]).After(available(out[j])) })
+	if len(out) > limit {
+		out = out[:limit]
+	}
+	return out, nil
+}
+
+// Account snapshots
+
+func (m *Memory) CreateAccountSnapshot(ctx context.Context, s *models.AccountSnapshot) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	s.ID = newID()
+	m.snapshots = trimOldest(append(m.snapshots, *s), memoryMaxSnapshots)
+	return nil
+}
+
+// ListAccountSnapshots returns snapshots taken in [from, to), oldest first, for one account or
+// for all when accountID is empty. A positive resolution keeps only the last snapshot of each
+// interval, with intervals aligned to the Unix epoch.
+func (m *Memory) ListAccountSnapshots(ctx context.Context, accountID string, from, to time.Time, resolution time.Duration, limit int) ([]models.AccountSnapshot, error) {
+	if limit <= 0 || limit > 10000 {
+		limit = 1000
+	}
+	m.mu.Lock()
+	var out []models.AccountSnapshot
+	for _, s := range m.snapshots {
+		if !s.TakenAt.Before(from) && s.TakenAt.Before(to) && (accountID == "" || s.AccountID == accountID) {
+			out = append(out, s)
+		}
+	}
+	m.mu.Unlock()
+	sort.SliceStable(out, func(i, j int) bool { return out[i].TakenAt.Before(out[j].TakenAt) })
+	if resolution > 0 {
+		out = lastPerInterval(out, resolution)
+	}
+	if len(out) > limit {
+		out = out[:limit]
+	}
+	return out, nil
+}
+
+// DeleteAccountSnapshotsBefore removes snapshots taken before the cutoff and returns how many.
+func (m *Memory) DeleteAccountSnapshotsBefore(ctx context.Context, before time.Time) (int64, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	kept := m.snapshots[:0]
+	for _, s := range m.snapshots {
+		if !s.TakenAt.Before(before) {
+			kept = append(kept, s)
+		}
+	}
+	n := int64(len(m.snapshots) - len(kept))
+	m.snapshots = kept
+	return n, nil
+}
+
+// lastPerInterval keeps the last of each account's snapshots in every interval aligned to the
+// Unix epoch; snaps are oldest first.
+func lastPerInterval(snaps []models.AccountSnapshot, interval time.Duration) []models.AccountSnapshot {
+	type bucket struct {
+		account string
+		start   time.Time
+	}
+	last := make(map[bucket]int)
+	for i, s := range snaps {
+		last[bucket{s.AccountID, epochBucket(s.TakenAt, interval)}] = i
+	}
+	kept := snaps[:0]
+	for i, s := range snaps {
+		if last[bucket{s.AccountID, epochBucket(s.TakenAt, interval)}] == i {
+			kept = append(kept, s)
+		}
+	}
+	return kept
+}
+
+// epochBucket returns the start of the interval holding t, with intervals aligned to the Unix
+// epoch as the Postgres queries align them.
+func epochBucket(t time.Time, interval time.Duration) time.Time {
+	ns := t.UnixNano()
+	rem := ns % int64(interval)
+	if rem < 0 {
+		rem += int64(interval)
+	}
+	return time.Unix(0, ns-rem).UTC()
+}
+
+// Ticks
+
+// EnsureTickPartitions does nothing; Memory keeps ticks in one series per instrument.
+func (m *Memory) EnsureTickPartitions(ctx context.Context, from, to time.Time) error { return nil }
+
+// DropTickPartitionsBefore drops the ticks of the UTC days that end at or before the cutoff and
+// returns how many days had ticks.
+func (m *Memory) DropTickPartitionsBefore(ctx context.Context, before time.Time) (int, error) {
+	cutoff := before.UTC().Truncate(24 * time.Hour)
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	days := make(map[time.Time]bool)
+	for instrument, series := range m.ticks {
+		i := sort.Search(len(series), func(i int) bool { return !series[i].Time.Before(cutoff) })
+		for _, t := range series[:i] {
+			days[t.Time.UTC().Truncate(24*time.Hour)] = true
+		}
+		m.ticks[instrument] = series[i:]
+	}
+	return len(days), nil
+}
+
+// InsertTicks stores ticks, skipping ones already stored, and returns how many were new.
+func (m *Memory) InsertTicks(ctx context.Context, ticks []models.Tick) (int64, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	var n int64
+	for _, t := range ticks {
+		series := m.ticks[t.Instrument]
+		i := sort.Search(len(series), func(i int) bool { return !series[i].Time.Before(t.Time) })
+		if i < len(series) && series[i].Time.Equal(t.Time) {
+			continue
+		}
+		m.ticks[t.Instrument] = slices.Insert(series, i, t)
+		n++
+	}
+	for instrument, series := range m.ticks {
+		m.ticks[instrument] = trimOldest(series, memoryMaxTicks)
+	}
+	return n, nil
+}
+
+// ticksIn returns an instrument's ticks with from <= time < to; the caller holds mu.
+func (m *Memory) ticksIn(instrument string, from, to time.Time) []models.Tick {
+	series := m.ticks[instrument]
+	lo := sort.Search(len(series), func(i int) bool { return !series[i].Time.Before(from) })
+	hi := sort.Search(len(series), func(i int) bool { return !series[i].Time.Before(to) })
+	if hi < lo {
+		hi = lo
+	}
+	return series[lo:hi]
+}
+
+// ListTicks returns an instrument's ticks with from <= time < to, oldest first. limit defaults to
+// 10000 and is capped at 100000.
+func (m *Memory) ListTicks(ctx context.Context, instrument string, from, to time.Time, limit int) ([]models.Tick, error) {
+	if limit <= 0 || limit > 100000 {
+		limit = 10000
+	}
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	in := m.ticksIn(instrument, from, to)
+	if len(in) > limit {
+		in = in[:limit]
+	}
+	return append([]models.Tick(nil), in...), nil
+}
+
+// TickSpreadStats summarizes an instrument's spreads over [from, to) in intervals aligned to the
+// Unix epoch, oldest first.
+func (m *Memory) TickSpreadStats(ctx context.Context, instrument string, from, to time.Time, interval time.Duration) ([]models.SpreadStats, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return spreadStats(m.ticksIn(instrument, from, to), interval), nil
+}
+
+// spreadStats summarizes the spreads of ticks, oldest first, per interval aligned to the Unix epoch.
+func spreadStats(ticks []models.Tick, interval time.Duration) []models.SpreadStats {
+	var out []models.SpreadStats
+	for start := 0; start < len(ticks); {
+		bucket := epochBucket(ticks[start].Time, interval)
+		end := start
+		for end < len(ticks) && epochBucket(ticks[end].Time, interval).Equal(bucket) {
+			end++
+		}
+		spreads := make([]float64, 0, end-start)
+		sum := 0.0
+		for _, t := range ticks[start:end] {
+			spreads = append(spreads, t.Spread())
+			sum += t.Spread()
+		}
+		sort.Float64s(spreads)
+		out = append(out, models.SpreadStats{
+			Time: bucket, Ticks: int64(len(spreads)), Min: spreads[0], Avg: sum / float64(len(spreads)),
+			P50: percentile(spreads, 0.5), P95: percentile(spreads, 0.95), Max: spreads[len(spreads)-1],
+		})
+		start = end
+	}
+	return out
+}
+
+// percentile interpolates between the closest ranks of sorted values, as percentile_cont does.
+func percentile(sorted []float64, p float64) float64 {
+	pos := p * float64(len(sorted)-1)
+	lo := int(pos)
+	if lo+1 >= len(sorted) {
+		return sorted[lo]
+	}
+	return sorted[lo] + (pos-float64(lo))*(sorted[lo+1]-sorted[lo])
+}
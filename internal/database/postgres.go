@@ -3,6 +3,7 @@ package database
 import (
 	"context"
 	"database/sql"
+	"encoding/json"
 	"fmt"
 	"log"
 	"net/url"
@@ -12,7 +13,7 @@ import (
 
 	"github.com/jedi116/go-trader/internal/config"
 	"github.com/jedi116/go-trader/pkg/models"
-	_ "github.com/lib/pq"
+	"github.com/lib/pq"
 )
 
 type Postgres struct {
@@ -20,10 +21,19 @@ type Postgres struct {
 }
 
 func (p *Postgres) audit(ctx context.Context, entity string, entityID string, action string, details map[string]interface{}) error {
-	_, err := p.DB.ExecContext(ctx, `INSERT INTO audit_logs(entity, entity_id, action, details) VALUES ($1,$2,$3,$4)`, entity, entityID, action, details)
+	detailsJSON, err := json.Marshal(details)
+	if err != nil {
+		return err
+	}
+	_, err = p.DB.ExecContext(ctx, `INSERT INTO audit_logs(entity, entity_id, action, details) VALUES ($1,NULLIF($2,'')::uuid,$3,$4)`, entity, entityID, action, detailsJSON)
 	return err
 }
 
+// LogAudit records an audit entry for callers outside the DB layer; entityID may be empty.
+func (p *Postgres) LogAudit(ctx context.Context, entity string, entityID string, action string, details map[string]interface{}) error {
+	return p.audit(ctx, entity, entityID, action, details)
+}
+
 func NewPostgres(cfg *config.Config) (*Postgres, error) {
 	dsn := os.Getenv("DATABASE_URL")
 	via := "env"
@@ -55,7 +65,12 @@ func NewPostgres(cfg *config.Config) (*Postgres, error) {
 	db.SetMaxOpenConns(10)
 	db.SetMaxIdleConns(5)
 	db.SetConnMaxLifetime(30 * time.Minute)
-	return &Postgres{DB: db}, nil
+	pg := &Postgres{DB: db}
+	if err := pg.Health(context.Background()); err != nil {
+		_ = db.Close()
+		return nil, fmt.Errorf("connect postgres: %w", err)
+	}
+	return pg, nil
 }
 
 func (p *Postgres) Health(ctx context.Context) error {
@@ -102,40 +117,128 @@ func (p *Postgres) MarkRecommendationExecuted(ctx context.Context, id string, tr
 	return err
 }
 
+// TransitionRecommendation moves a recommendation that is not deleted from one status to another,
+// setting executed_at when it becomes EXECUTED. It reports false when the recommendation was not
+// in the from status, so two accepts cannot both place its order.
+func (p *Postgres) TransitionRecommendation(ctx context.Context, id string, from, to models.RecommendationStatus) (bool, error) {
+	res, err := p.DB.ExecContext(ctx, `
+        UPDATE recommendations
+        SET status=$3, executed_at=CASE WHEN $3='EXECUTED' THEN NOW() END
+        WHERE id=$1 AND status=$2 AND deleted_at IS NULL
+    `, id, string(from), string(to))
+	if err != nil {
+		return false, err
+	}
+	n, err := res.RowsAffected()
+	return n > 0, err
+}
+
 // Trade persistence (minimal)
 func (p *Postgres) CreateTrade(ctx context.Context, t *models.Trade) error {
-	query := `INSERT INTO trades (id, instrument, direction, units, entry_price, exit_price, profit_loss, commission, swap, status, oanda_trade_id, created_at, updated_at, closed_at)
-              VALUES (COALESCE(NULLIF($1,'')::uuid, gen_random_uuid()),$2,$3,$4,$5,$6,$7,$8,$9,$10,$11,NOW(),NOW(),$12)`
-	_, err := p.DB.ExecContext(ctx, query, t.ID, t.Instrument, t.Direction, t.Units, t.EntryPrice, t.ExitPrice, t.ProfitLoss, t.Commission, t.Swap, t.Status, t.OandaTradeID, t.ClosedAt)
+	if t.Source == "" {
+		t.Source = models.TradeSourceManual
+	}
+	query := `INSERT INTO trades (id, instrument, direction, units, entry_price, exit_price, profit_loss, commission, swap, status, oanda_trade_id, source, created_at, updated_at, closed_at)
+              VALUES (COALESCE(NULLIF($1,'')::uuid, gen_random_uuid()),$2,$3,$4,$5,$6,$7,$8,$9,$10,$11,$12,NOW(),NOW(),$13)
+              RETURNING id`
+	var id string
+	err := p.DB.QueryRowContext(ctx, query, t.ID, t.Instrument, t.Direction, t.Units, t.EntryPrice, t.ExitPrice, t.ProfitLoss, t.Commission, t.Swap, t.Status, t.OandaTradeID, t.Source, t.ClosedAt).Scan(&id)
 	if err == nil {
-		_ = p.audit(ctx, "trades", t.ID, "CREATE", map[string]interface{}{"instrument": t.Instrument, "direction": t.Direction, "units": t.Units})
+		_ = p.audit(ctx, "trades", id, "CREATE", map[string]interface{}{"instrument": t.Instrument, "direction": t.Direction, "units": t.Units, "source": t.Source})
 	}
 	return err
 }
 
 func (p *Postgres) Close() error { return p.DB.Close() }
 
+const tradeColumns = `id, instrument, direction, units, entry_price, exit_price, profit_loss, commission, swap, status, oanda_trade_id, source, created_at, updated_at, closed_at`
+
+func scanTrades(rows *sql.Rows) ([]models.Trade, error) {
+	defer rows.Close()
+	var out []models.Trade
+	for rows.Next() {
+		var t models.Trade
+		if err := rows.Scan(&t.ID, &t.Instrument, &t.Direction, &t.Units, &t.EntryPrice, &t.ExitPrice, &t.ProfitLoss, &t.Commission, &t.Swap, &t.Status, &t.OandaTradeID, &t.Source, &t.CreatedAt, &t.UpdatedAt, &t.ClosedAt); err != nil {
+			return nil, err
+		}
+		out = append(out, t)
+	}
+	return out, rows.Err()
+}
+
 func (p *Postgres) ListTrades(ctx context.Context, limit int) ([]models.Trade, error) {
 	if limit <= 0 || limit > 500 {
 		limit = 200
 	}
-	rows, err := p.DB.QueryContext(ctx, `SELECT id, instrument, direction, units, entry_price, exit_price, profit_loss, commission, swap, status, oanda_trade_id, created_at, updated_at, closed_at FROM trades WHERE deleted_at IS NULL ORDER BY created_at DESC LIMIT $1`, limit)
+	rows, err := p.DB.QueryContext(ctx, `SELECT `+tradeColumns+` FROM trades WHERE deleted_at IS NULL ORDER BY created_at DESC LIMIT $1`, limit)
+	if err != nil {
+		return nil, err
+	}
+	return scanTrades(rows)
+}
+
+// ListOpenTradeIDs returns the OANDA trade IDs of trades still recorded as open.
+func (p *Postgres) ListOpenTradeIDs(ctx context.Context) ([]string, error) {
+	rows, err := p.DB.QueryContext(ctx, `SELECT oanda_trade_id FROM trades WHERE deleted_at IS NULL AND status = 'OPEN' AND oanda_trade_id IS NOT NULL`)
 	if err != nil {
 		return nil, err
 	}
 	defer rows.Close()
-	var out []models.Trade
+	var out []string
 	for rows.Next() {
-		var t models.Trade
-		if err := rows.Scan(&t.ID, &t.Instrument, &t.Direction, &t.Units, &t.EntryPrice, &t.ExitPrice, &t.ProfitLoss, &t.Commission, &t.Swap, &t.Status, &t.OandaTradeID, &t.CreatedAt, &t.UpdatedAt, &t.ClosedAt); err != nil {
+		var id string
+		if err := rows.Scan(&id); err != nil {
 			return nil, err
 		}
-		out = append(out, t)
+		out = append(out, id)
 	}
 	return out, rows.Err()
 }
 
+// CloseTrade records the outcome of the open trade with the given OANDA trade ID. It reports
+// false when no open trade matched.
+func (p *Postgres) CloseTrade(ctx context.Context, oandaTradeID string, exit, pl, swap float64, closedAt time.Time) (bool, error) {
+	var id string
+	err := p.DB.QueryRowContext(ctx, `
+        UPDATE trades SET status = 'CLOSED', exit_price = $2, profit_loss = $3, swap = $4, closed_at = $5, updated_at = NOW()
+        WHERE oanda_trade_id = $1 AND status = 'OPEN' AND deleted_at IS NULL
+        RETURNING id
+    `, oandaTradeID, exit, pl, swap, closedAt).Scan(&id)
+	if err == sql.ErrNoRows {
+		return false, nil
+	}
+	if err != nil {
+		return false, err
+	}
+	_ = p.audit(ctx, "trades", id, "CLOSE", map[string]interface{}{"oanda_trade_id": oandaTradeID, "profit_loss": pl})
+	return true, nil
+}
+
+// ListClosedTrades returns closed trades in order of closing, optionally bounded by closing time.
+func (p *Postgres) ListClosedTrades(ctx context.Context, from, to *time.Time) ([]models.Trade, error) {
+	rows, err := p.DB.QueryContext(ctx, `SELECT `+tradeColumns+` FROM trades
+        WHERE deleted_at IS NULL AND status = 'CLOSED' AND closed_at IS NOT NULL
+          AND ($1::timestamptz IS NULL OR closed_at >= $1) AND ($2::timestamptz IS NULL OR closed_at < $2)
+        ORDER BY closed_at, created_at`, from, to)
+	if err != nil {
+		return nil, err
+	}
+	return scanTrades(rows)
+}
+
 // Soft deletes
+// SumRealizedPL totals profit_loss of trades closed at or after since and counts them.
+func (p *Postgres) SumRealizedPL(ctx context.Context, since time.Time) (float64, int, error) {
+	var total float64
+	var n int
+	err := p.DB.QueryRowContext(ctx, `
+        SELECT COALESCE(SUM(profit_loss), 0), COUNT(*)
+        FROM trades
+        WHERE deleted_at IS NULL AND status = 'CLOSED' AND closed_at >= $1
+    `, since).Scan(&total, &n)
+	return total, n, err
+}
+
 func (p *Postgres) SoftDeleteRecommendation(ctx context.Context, id string) error {
 	_, err := p.DB.ExecContext(ctx, `UPDATE recommendations SET deleted_at = NOW() WHERE id = $1 AND deleted_at IS NULL`, id)
 	if err == nil {
@@ -153,27 +256,63 @@ func (p *Postgres) SoftDeleteTrade(ctx context.Context, id string) error {
 }
 
 // Market data persistence
+
+// marketDataColumns are the columns market data is written with, in bind order.
+var marketDataColumns = []string{"id", "instrument", "timestamp", "open_price", "high_price", "low_price", "close_price", "volume", "timeframe",
+	"bid_open", "bid_high", "bid_low", "bid_close", "ask_open", "ask_high", "ask_low", "ask_close"}
+
+// marketDataConflict updates a stored candle from an incoming one, keeping stored bid/ask
+// prices the incoming candle lacks.
+const marketDataConflict = `
+        ON CONFLICT (instrument, timestamp, timeframe)
+        DO UPDATE SET open_price=EXCLUDED.open_price, high_price=EXCLUDED.high_price, low_price=EXCLUDED.low_price, close_price=EXCLUDED.close_price, volume=EXCLUDED.volume,
+            bid_open=COALESCE(EXCLUDED.bid_open, market_data.bid_open), bid_high=COALESCE(EXCLUDED.bid_high, market_data.bid_high),
+            bid_low=COALESCE(EXCLUDED.bid_low, market_data.bid_low), bid_close=COALESCE(EXCLUDED.bid_close, market_data.bid_close),
+            ask_open=COALESCE(EXCLUDED.ask_open, market_data.ask_open), ask_high=COALESCE(EXCLUDED.ask_high, market_data.ask_high),
+            ask_low=COALESCE(EXCLUDED.ask_low, market_data.ask_low), ask_close=COALESCE(EXCLUDED.ask_close, market_data.ask_close)`
+
+func marketDataArgs(r models.MarketData) []interface{} {
+	args := []interface{}{r.ID, r.Instrument, r.Timestamp, r.OpenPrice, r.HighPrice, r.LowPrice, r.ClosePrice, r.Volume, r.Timeframe}
+	return append(append(args, ohlcArgs(r.Bid)...), ohlcArgs(r.Ask)...)
+}
+
+// copyMinRows is the batch size from which market data goes through COPY; below it the fixed
+// cost of the staging table outweighs the per-row round trips it saves.
+const copyMinRows = 100
+
+// UpsertMarketData inserts candles or updates the stored ones with the same instrument, time
+// and timeframe, in one transaction. Within a batch the last candle for a key wins, except that
+// bid/ask missing from it are kept from earlier candles or the stored row. Large batches are
+// copied into a staging table and merged with a single statement, with the same result.
 func (p *Postgres) UpsertMarketData(ctx context.Context, rows []models.MarketData) error {
 	if len(rows) == 0 {
 		return nil
 	}
+	if len(rows) >= copyMinRows {
+		return p.copyMarketData(ctx, rows)
+	}
+	return p.insertMarketData(ctx, rows)
+}
+
+// insertMarketData upserts rows one statement at a time; later rows overwrite earlier ones
+// with the same key.
+func (p *Postgres) insertMarketData(ctx context.Context, rows []models.MarketData) error {
 	tx, err := p.DB.BeginTx(ctx, &sql.TxOptions{})
 	if err != nil {
 		return err
 	}
 	stmt, err := tx.PrepareContext(ctx, `
-        INSERT INTO market_data (id, instrument, timestamp, open_price, high_price, low_price, close_price, volume, timeframe, created_at)
-        VALUES (COALESCE(NULLIF($1,'')::uuid, gen_random_uuid()),$2,$3,$4,$5,$6,$7,$8,$9,NOW())
-        ON CONFLICT (instrument, timestamp, timeframe)
-        DO UPDATE SET open_price=EXCLUDED.open_price, high_price=EXCLUDED.high_price, low_price=EXCLUDED.low_price, close_price=EXCLUDED.close_price, volume=EXCLUDED.volume
-    `)
+        INSERT INTO market_data (id, instrument, timestamp, open_price, high_price, low_price, close_price, volume, timeframe,
+                                 bid_open, bid_high, bid_low, bid_close, ask_open, ask_high, ask_low, ask_close, created_at)
+        VALUES (COALESCE(NULLIF($1,'')::uuid, gen_random_uuid()),$2,$3,$4,$5,$6,$7,$8,$9,$10,$11,$12,$13,$14,$15,$16,$17,NOW())
+    `+marketDataConflict)
 	if err != nil {
 		_ = tx.Rollback()
 		return err
 	}
 	defer stmt.Close()
 	for _, r := range rows {
-		if _, err := stmt.ExecContext(ctx, r.ID, r.Instrument, r.Timestamp, r.OpenPrice, r.HighPrice, r.LowPrice, r.ClosePrice, r.Volume, r.Timeframe); err != nil {
+		if _, err := stmt.ExecContext(ctx, marketDataArgs(r)...); err != nil {
 			_ = tx.Rollback()
 			return err
 		}
@@ -184,6 +323,132 @@ func (p *Postgres) UpsertMarketData(ctx context.Context, rows []models.MarketDat
 	return nil
 }
 
+// copyMarketData streams rows into a temporary staging table with COPY and merges them into
+// market_data with one INSERT ... SELECT. A single ON CONFLICT statement cannot update the same
+// row twice, so duplicate keys are merged first the way the row-by-row path applies them: seq
+// keeps the batch order, prices and volume come from the last row, bid/ask from the last row
+// that has them, and the id from the first.
+func (p *Postgres) copyMarketData(ctx context.Context, rows []models.MarketData) error {
+	tx, err := p.DB.BeginTx(ctx, &sql.TxOptions{})
+	if err != nil {
+		return err
+	}
+	fail := func(err error) error {
+		_ = tx.Rollback()
+		return err
+	}
+	if _, err := tx.ExecContext(ctx, `
+        CREATE TEMP TABLE market_data_staging (
+            seq INTEGER NOT NULL,
+            id TEXT,
+            instrument VARCHAR(50) NOT NULL,
+            timestamp TIMESTAMPTZ NOT NULL,
+            open_price DECIMAL(15,8) NOT NULL,
+            high_price DECIMAL(15,8) NOT NULL,
+            low_price DECIMAL(15,8) NOT NULL,
+            close_price DECIMAL(15,8) NOT NULL,
+            volume BIGINT,
+            timeframe VARCHAR(10) NOT NULL,
+            bid_open DECIMAL(15,8), bid_high DECIMAL(15,8), bid_low DECIMAL(15,8), bid_close DECIMAL(15,8),
+            ask_open DECIMAL(15,8), ask_high DECIMAL(15,8), ask_low DECIMAL(15,8), ask_close DECIMAL(15,8)
+        ) ON COMMIT DROP
+    `); err != nil {
+		return fail(err)
+	}
+	stmt, err := tx.PrepareContext(ctx, pq.CopyIn("market_data_staging", append([]string{"seq"}, marketDataColumns...)...))
+	if err != nil {
+		return fail(err)
+	}
+	for i, r := range rows {
+		if _, err := stmt.ExecContext(ctx, append([]interface{}{i}, marketDataArgs(r)...)...); err != nil {
+			_ = stmt.Close()
+			return fail(err)
+		}
+	}
+	// The final empty Exec flushes the COPY buffer.
+	if _, err := stmt.ExecContext(ctx); err != nil {
+		_ = stmt.Close()
+		return fail(err)
+	}
+	if err := stmt.Close(); err != nil {
+		return fail(err)
+	}
+	quoteCols := marketDataColumns[9:]
+	quotes := make([]string, len(quoteCols))
+	for i, c := range quoteCols {
+		quotes[i] = fmt.Sprintf("(array_agg(%[1]s ORDER BY seq DESC) FILTER (WHERE %[1]s IS NOT NULL))[1] AS %[1]s", c)
+	}
+	if _, err := tx.ExecContext(ctx, `
+        WITH latest AS (
+            SELECT DISTINCT ON (instrument, timestamp, timeframe) *
+            FROM market_data_staging
+            ORDER BY instrument, timestamp, timeframe, seq DESC
+        ), merged AS (
+            SELECT instrument, timestamp, timeframe, (array_agg(id ORDER BY seq))[1] AS id, `+strings.Join(quotes, ", ")+`
+            FROM market_data_staging
+            GROUP BY instrument, timestamp, timeframe
+        )
+        INSERT INTO market_data (id, `+strings.Join(marketDataColumns[1:], ", ")+`, created_at)
+        SELECT COALESCE(NULLIF(merged.id,'')::uuid, gen_random_uuid()), instrument, timestamp, latest.open_price, latest.high_price,
+               latest.low_price, latest.close_price, latest.volume, timeframe, merged.`+strings.Join(quoteCols, ", merged.")+`, NOW()
+        FROM latest JOIN merged USING (instrument, timestamp, timeframe)
+    `+marketDataConflict); err != nil {
+		return fail(err)
+	}
+	return tx.Commit()
+}
+
+// ohlcArgs returns the four bind values of an optional bid or ask candle, NULLs when absent.
+func ohlcArgs(o *models.OHLC) []interface{} {
+	if o == nil {
+		return []interface{}{nil, nil, nil, nil}
+	}
+	return []interface{}{o.Open, o.High, o.Low, o.Close}
+}
+
+// nullOHLC scans an optional bid or ask candle.
+type nullOHLC [4]sql.NullFloat64
+
+func (n *nullOHLC) dest() []interface{} { return []interface{}{&n[0], &n[1], &n[2], &n[3]} }
+
+func (n *nullOHLC) value() *models.OHLC {
+	for _, v := range n {
+		if !v.Valid {
+			return nil
+		}
+	}
+	return &models.OHLC{Open: n[0].Float64, High: n[1].Float64, Low: n[2].Float64, Close: n[3].Float64}
+}
+
+// ListMarketDataRange returns candles with from <= timestamp < to, oldest first, including bid
+// and ask where stored.
+func (p *Postgres) ListMarketDataRange(ctx context.Context, instrument, timeframe string, from, to time.Time) ([]models.MarketData, error) {
+	rows, err := p.DB.QueryContext(ctx, `
+        SELECT id, instrument, timestamp, open_price, high_price, low_price, close_price, volume, timeframe,
+               bid_open, bid_high, bid_low, bid_close, ask_open, ask_high, ask_low, ask_close, created_at
+        FROM market_data
+        WHERE deleted_at IS NULL AND instrument = $1 AND timeframe = $2 AND timestamp >= $3 AND timestamp < $4
+        ORDER BY timestamp
+    `, instrument, timeframe, from, to)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var out []models.MarketData
+	for rows.Next() {
+		var m models.MarketData
+		var bid, ask nullOHLC
+		dest := []interface{}{&m.ID, &m.Instrument, &m.Timestamp, &m.OpenPrice, &m.HighPrice, &m.LowPrice, &m.ClosePrice, &m.Volume, &m.Timeframe}
+		dest = append(append(dest, bid.dest()...), ask.dest()...)
+		if err := rows.Scan(append(dest, &m.CreatedAt)...); err != nil {
+			return nil, err
+		}
+		m.Bid, m.Ask = bid.value(), ask.value()
+		out = append(out, m)
+	}
+	return out, rows.Err()
+}
+
 func (p *Postgres) ListMarketData(ctx context.Context, instrument string, timeframe string, limit int) ([]models.MarketData, error) {
 	if limit <= 0 || limit > 5000 {
 		limit = 500
@@ -237,6 +502,20 @@ func (p *Postgres) MarkAIRecommendationExecuted(ctx context.Context, id string,
 	return err
 }
 
+// ClaimAIRecommendation sets a PENDING or APPROVED AI recommendation whose time_to_live is after
+// now to EXECUTED. It reports false when the recommendation was not claimable.
+func (p *Postgres) ClaimAIRecommendation(ctx context.Context, id string, now time.Time) (bool, error) {
+	res, err := p.DB.ExecContext(ctx, `
+        UPDATE ai_recommendations SET status='EXECUTED', updated_at=NOW()
+        WHERE id=$1 AND status IN ('PENDING', 'APPROVED') AND time_to_live > $2
+    `, id, now)
+	if err != nil {
+		return false, err
+	}
+	n, err := res.RowsAffected()
+	return n > 0, err
+}
+
 func (p *Postgres) ListAIRecommendations(ctx context.Context, limit int) ([]models.AIRecommendation, error) {
 	if limit <= 0 || limit > 500 {
 		limit = 200
@@ -258,13 +537,736 @@ func (p *Postgres) ListAIRecommendations(ctx context.Context, limit int) ([]mode
 }
 
 // AI usage logs
-func (p *Postgres) CreateAIUsageLog(ctx context.Context, recommendationID string, promptTokens, completionTokens, totalTokens, responseTimeMs int, model string) error {
-	_, err := p.DB.ExecContext(ctx, `INSERT INTO ai_usage_logs (recommendation_id, prompt_tokens, completion_tokens, total_tokens, response_time_ms, claude_model) VALUES ($1,$2,$3,$4,$5,$6)`, recommendationID, promptTokens, completionTokens, totalTokens, responseTimeMs, model)
+func (p *Postgres) CreateAIUsageLog(ctx context.Context, l *models.AIUsageLog) error {
+	_, err := p.DB.ExecContext(ctx, `INSERT INTO ai_usage_logs (recommendation_id, prompt_tokens, completion_tokens, cache_creation_tokens, cache_read_tokens, total_tokens, response_time_ms, claude_model, cost_usd) VALUES ($1,$2,$3,$4,$5,$6,$7,$8,$9)`,
+		l.RecommendationID, l.PromptTokens, l.CompletionTokens, l.CacheCreationTokens, l.CacheReadTokens, l.TotalTokens, l.ResponseTimeMs, l.Model, l.CostUSD)
 	return err
 }
 
-// Market analysis cache insert
-func (p *Postgres) InsertMarketAnalysisCache(ctx context.Context, instruments string, analysisData []byte, expiresAt time.Time) error {
-	_, err := p.DB.ExecContext(ctx, `INSERT INTO market_analysis_cache (instruments, analysis_data, expires_at) VALUES ($1,$2,$3)`, instruments, analysisData, expiresAt)
+// SummarizeAIUsage aggregates usage per model for logs created at or after since.
+func (p *Postgres) SummarizeAIUsage(ctx context.Context, since time.Time) ([]models.AIUsageTotals, error) {
+	rows, err := p.DB.QueryContext(ctx, `
+        SELECT claude_model, COUNT(*), COALESCE(SUM(prompt_tokens),0), COALESCE(SUM(completion_tokens),0),
+               COALESCE(SUM(cache_creation_tokens),0), COALESCE(SUM(cache_read_tokens),0), COALESCE(SUM(total_tokens),0), COALESCE(SUM(cost_usd),0)
+        FROM ai_usage_logs
+        WHERE created_at >= $1
+        GROUP BY claude_model
+        ORDER BY claude_model
+    `, since)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var out []models.AIUsageTotals
+	for rows.Next() {
+		var t models.AIUsageTotals
+		if err := rows.Scan(&t.Model, &t.Requests, &t.PromptTokens, &t.CompletionTokens, &t.CacheCreationTokens, &t.CacheReadTokens, &t.TotalTokens, &t.CostUSD); err != nil {
+			return nil, err
+		}
+		out = append(out, t)
+	}
+	return out, rows.Err()
+}
+
+// Market analysis cache
+func (p *Postgres) InsertMarketAnalysisCache(ctx context.Context, key string, instruments string, analysisData []byte, expiresAt time.Time) error {
+	_, err := p.DB.ExecContext(ctx, `INSERT INTO market_analysis_cache (cache_key, instruments, analysis_data, expires_at) VALUES ($1,$2,$3,$4)`, key, instruments, analysisData, expiresAt)
 	return err
 }
+
+// GetMarketAnalysisCache returns the newest unexpired entry for key.
+func (p *Postgres) GetMarketAnalysisCache(ctx context.Context, key string) ([]byte, bool, error) {
+	var data []byte
+	err := p.DB.QueryRowContext(ctx, `SELECT analysis_data FROM market_analysis_cache WHERE cache_key = $1 AND expires_at > NOW() ORDER BY created_at DESC LIMIT 1`, key).Scan(&data)
+	if err == sql.ErrNoRows {
+		return nil, false, nil
+	}
+	if err != nil {
+		return nil, false, err
+	}
+	return data, true, nil
+}
+
+func (p *Postgres) PurgeExpiredMarketAnalysisCache(ctx context.Context) (int64, error) {
+	res, err := p.DB.ExecContext(ctx, `DELETE FROM market_analysis_cache WHERE expires_at <= NOW()`)
+	if err != nil {
+		return 0, err
+	}
+	return res.RowsAffected()
+}
+
+// ---- Economic calendar ----
+func (p *Postgres) UpsertEconomicEvents(ctx context.Context, events []models.EconomicEvent) error {
+	if len(events) == 0 {
+		return nil
+	}
+	tx, err := p.DB.BeginTx(ctx, &sql.TxOptions{})
+	if err != nil {
+		return err
+	}
+	stmt, err := tx.PrepareContext(ctx, `
+        INSERT INTO economic_events (title, currency, impact, event_time, actual, forecast, previous, source)
+        VALUES ($1,$2,$3,$4,$5,$6,$7,$8)
+        ON CONFLICT (currency, title, event_time)
+        DO UPDATE SET impact=EXCLUDED.impact, actual=EXCLUDED.actual, forecast=EXCLUDED.forecast, previous=EXCLUDED.previous, source=EXCLUDED.source, updated_at=NOW()
+    `)
+	if err != nil {
+		_ = tx.Rollback()
+		return err
+	}
+	defer stmt.Close()
+	for _, e := range events {
+		if _, err := stmt.ExecContext(ctx, e.Title, e.Currency, e.Impact, e.EventTime, e.Actual, e.Forecast, e.Previous, e.Source); err != nil {
+			_ = tx.Rollback()
+			return err
+		}
+	}
+	return tx.Commit()
+}
+
+// ListEconomicEvents returns events in [from, to], optionally restricted to currencies, oldest first.
+func (p *Postgres) ListEconomicEvents(ctx context.Context, from, to time.Time, currencies []string) ([]models.EconomicEvent, error) {
+	rows, err := p.DB.QueryContext(ctx, `
+        SELECT id, title, currency, impact, event_time, actual, forecast, previous, source, created_at
+        FROM economic_events
+        WHERE event_time BETWEEN $1 AND $2 AND (COALESCE(cardinality($3::text[]), 0) = 0 OR currency = ANY($3::text[]))
+        ORDER BY event_time
+        LIMIT 1000
+    `, from, to, pq.Array(currencies))
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var out []models.EconomicEvent
+	for rows.Next() {
+		var e models.EconomicEvent
+		if err := rows.Scan(&e.ID, &e.Title, &e.Currency, &e.Impact, &e.EventTime, &e.Actual, &e.Forecast, &e.Previous, &e.Source, &e.CreatedAt); err != nil {
+			return nil, err
+		}
+		out = append(out, e)
+	}
+	return out, rows.Err()
+}
+
+// ---- Account guardian ----
+// GetGuardianState returns nil when the account has no stored state yet.
+func (p *Postgres) GetGuardianState(ctx context.Context, accountID string) (*models.GuardianState, error) {
+	var s models.GuardianState
+	err := p.DB.QueryRowContext(ctx, `
+        SELECT account_id, trading_day, start_of_day_nav, start_of_day_balance, start_of_day_pl, peak_nav, peak_pl, halted, halt_reason, halted_at, reset_by, reset_at, updated_at
+        FROM account_guardian_state WHERE account_id = $1
+    `, accountID).Scan(&s.AccountID, &s.TradingDay, &s.StartOfDayNAV, &s.StartOfDayBalance, &s.StartOfDayPL, &s.PeakNAV, &s.PeakPL, &s.Halted, &s.HaltReason, &s.HaltedAt, &s.ResetBy, &s.ResetAt, &s.UpdatedAt)
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &s, nil
+}
+
+func (p *Postgres) SaveGuardianState(ctx context.Context, s *models.GuardianState) error {
+	_, err := p.DB.ExecContext(ctx, `
+        INSERT INTO account_guardian_state (account_id, trading_day, start_of_day_nav, start_of_day_balance, start_of_day_pl, peak_nav, peak_pl, halted, halt_reason, halted_at, reset_by, reset_at, updated_at)
+        VALUES ($1,$2,$3,$4,$5,$6,$7,$8,$9,$10,$11,$12,NOW())
+        ON CONFLICT (account_id)
+        DO UPDATE SET trading_day=EXCLUDED.trading_day, start_of_day_nav=EXCLUDED.start_of_day_nav, start_of_day_balance=EXCLUDED.start_of_day_balance,
+            start_of_day_pl=EXCLUDED.start_of_day_pl, peak_nav=EXCLUDED.peak_nav, peak_pl=EXCLUDED.peak_pl, halted=EXCLUDED.halted,
+            halt_reason=EXCLUDED.halt_reason, halted_at=EXCLUDED.halted_at, reset_by=EXCLUDED.reset_by, reset_at=EXCLUDED.reset_at, updated_at=NOW()
+    `, s.AccountID, s.TradingDay, s.StartOfDayNAV, s.StartOfDayBalance, s.StartOfDayPL, s.PeakNAV, s.PeakPL, s.Halted, s.HaltReason, s.HaltedAt, s.ResetBy, s.ResetAt)
+	return err
+}
+
+// ---- Queued orders ----
+func (p *Postgres) CreateQueuedOrder(ctx context.Context, o *models.QueuedOrder) error {
+	return p.DB.QueryRowContext(ctx, `
+        INSERT INTO queued_orders (instrument, units, stop_loss, take_profit, source)
+        VALUES ($1,$2,$3,$4,$5)
+        RETURNING id, status, created_at
+    `, o.Instrument, o.Units, o.StopLoss, o.TakeProfit, o.Source).Scan(&o.ID, &o.Status, &o.CreatedAt)
+}
+
+// ListQueuedOrders returns orders oldest first; an empty status lists all.
+func (p *Postgres) ListQueuedOrders(ctx context.Context, status models.QueuedOrderStatus, limit int) ([]models.QueuedOrder, error) {
+	if limit <= 0 || limit > 1000 {
+		limit = 200
+	}
+	rows, err := p.DB.QueryContext(ctx, `
+        SELECT id, instrument, units, stop_loss, take_profit, source, status, error, oanda_order_id, created_at, submitted_at
+        FROM queued_orders
+        WHERE ($1 = '' OR status = $1)
+        ORDER BY created_at
+        LIMIT $2
+    `, string(status), limit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var out []models.QueuedOrder
+	for rows.Next() {
+		var o models.QueuedOrder
+		if err := rows.Scan(&o.ID, &o.Instrument, &o.Units, &o.StopLoss, &o.TakeProfit, &o.Source, &o.Status, &o.Error, &o.OandaOrderID, &o.CreatedAt, &o.SubmittedAt); err != nil {
+			return nil, err
+		}
+		out = append(out, o)
+	}
+	return out, rows.Err()
+}
+
+// TransitionQueuedOrder moves an order from one status to another and records the broker order
+// ID and error when given. It reports false when the order was not in the from status, so a
+// worker can claim an order without racing a cancel.
+func (p *Postgres) TransitionQueuedOrder(ctx context.Context, id string, from, to models.QueuedOrderStatus, oandaOrderID, errMsg *string) (bool, error) {
+	res, err := p.DB.ExecContext(ctx, `
+        UPDATE queued_orders
+        SET status=$3, oanda_order_id=COALESCE($4, oanda_order_id), error=COALESCE($5, error),
+            submitted_at=CASE WHEN $3='SUBMITTED' THEN COALESCE(submitted_at, NOW()) ELSE submitted_at END
+        WHERE id=$1 AND status=$2
+    `, id, string(from), string(to), oandaOrderID, errMsg)
+	if err != nil {
+		return false, err
+	}
+	n, err := res.RowsAffected()
+	return n > 0, err
+}
+
+// ---- Alerts ----
+const alertRuleColumns = `id, name, instrument, kind, condition, threshold, indicator, period, timeframe, window_seconds,
+            repeat, cooldown_seconds, active, fire_count, last_fired_at, created_at, updated_at`
+
+func scanAlertRule(row interface{ Scan(...interface{}) error }) (*models.AlertRule, error) {
+	var r models.AlertRule
+	err := row.Scan(&r.ID, &r.Name, &r.Instrument, &r.Kind, &r.Condition, &r.Threshold, &r.Indicator, &r.Period, &r.Timeframe, &r.WindowSeconds,
+		&r.Repeat, &r.CooldownSeconds, &r.Active, &r.FireCount, &r.LastFiredAt, &r.CreatedAt, &r.UpdatedAt)
+	if err != nil {
+		return nil, err
+	}
+	return &r, nil
+}
+
+func (p *Postgres) CreateAlertRule(ctx context.Context, r *models.AlertRule) error {
+	return p.DB.QueryRowContext(ctx, `
+        INSERT INTO alert_rules (name, instrument, kind, condition, threshold, indicator, period, timeframe, window_seconds, repeat, cooldown_seconds, active)
+        VALUES ($1,$2,$3,$4,$5,$6,$7,$8,$9,$10,$11,$12)
+        RETURNING id, fire_count, created_at, updated_at
+    `, r.Name, r.Instrument, string(r.Kind), string(r.Condition), r.Threshold, r.Indicator, r.Period, r.Timeframe, r.WindowSeconds, r.Repeat, r.CooldownSeconds, r.Active).
+		Scan(&r.ID, &r.FireCount, &r.CreatedAt, &r.UpdatedAt)
+}
+
+// GetAlertRule returns nil when the rule does not exist or was deleted.
+func (p *Postgres) GetAlertRule(ctx context.Context, id string) (*models.AlertRule, error) {
+	r, err := scanAlertRule(p.DB.QueryRowContext(ctx, `SELECT `+alertRuleColumns+` FROM alert_rules WHERE id=$1 AND deleted_at IS NULL`, id))
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	return r, err
+}
+
+func (p *Postgres) ListAlertRules(ctx context.Context, activeOnly bool) ([]models.AlertRule, error) {
+	rows, err := p.DB.QueryContext(ctx, `
+        SELECT `+alertRuleColumns+`
+        FROM alert_rules
+        WHERE deleted_at IS NULL AND (NOT $1 OR active)
+        ORDER BY created_at
+    `, activeOnly)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var out []models.AlertRule
+	for rows.Next() {
+		r, err := scanAlertRule(rows)
+		if err != nil {
+			return nil, err
+		}
+		out = append(out, *r)
+	}
+	return out, rows.Err()
+}
+
+// UpdateAlertRule replaces the editable fields of a rule; it reports false when no such rule exists.
+func (p *Postgres) UpdateAlertRule(ctx context.Context, r *models.AlertRule) (bool, error) {
+	err := p.DB.QueryRowContext(ctx, `
+        UPDATE alert_rules
+        SET name=$2, instrument=$3, kind=$4, condition=$5, threshold=$6, indicator=$7, period=$8, timeframe=$9,
+            window_seconds=$10, repeat=$11, cooldown_seconds=$12, active=$13, updated_at=NOW()
+        WHERE id=$1 AND deleted_at IS NULL
+        RETURNING fire_count, last_fired_at, created_at, updated_at
+    `, r.ID, r.Name, r.Instrument, string(r.Kind), string(r.Condition), r.Threshold, r.Indicator, r.Period, r.Timeframe,
+		r.WindowSeconds, r.Repeat, r.CooldownSeconds, r.Active).Scan(&r.FireCount, &r.LastFiredAt, &r.CreatedAt, &r.UpdatedAt)
+	if err == sql.ErrNoRows {
+		return false, nil
+	}
+	return err == nil, err
+}
+
+func (p *Postgres) SoftDeleteAlertRule(ctx context.Context, id string) (bool, error) {
+	res, err := p.DB.ExecContext(ctx, `UPDATE alert_rules SET deleted_at=NOW(), active=FALSE WHERE id=$1 AND deleted_at IS NULL`, id)
+	if err != nil {
+		return false, err
+	}
+	n, err := res.RowsAffected()
+	return n > 0, err
+}
+
+// RecordAlertFired stores an event and bumps the rule's fire count, deactivating one-shot rules.
+func (p *Postgres) RecordAlertFired(ctx context.Context, e *models.AlertEvent, deactivate bool) error {
+	tx, err := p.DB.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer func() { _ = tx.Rollback() }()
+	if err := tx.QueryRowContext(ctx, `
+        INSERT INTO alert_events (rule_id, instrument, value, message, fired_at)
+        VALUES ($1,$2,$3,$4,$5)
+        RETURNING id
+    `, e.RuleID, e.Instrument, e.Value, e.Message, e.FiredAt).Scan(&e.ID); err != nil {
+		return err
+	}
+	if _, err := tx.ExecContext(ctx, `
+        UPDATE alert_rules
+        SET fire_count=fire_count+1, last_fired_at=$2, active=CASE WHEN $3 THEN FALSE ELSE active END, updated_at=NOW()
+        WHERE id=$1
+    `, e.RuleID, e.FiredAt, deactivate); err != nil {
+		return err
+	}
+	return tx.Commit()
+}
+
+// ListAlertEvents returns firings newest first; an empty ruleID lists all rules.
+func (p *Postgres) ListAlertEvents(ctx context.Context, ruleID string, limit int) ([]models.AlertEvent, error) {
+	if limit <= 0 || limit > 1000 {
+		limit = 100
+	}
+	rows, err := p.DB.QueryContext(ctx, `
+        SELECT id, rule_id, instrument, value, message, fired_at
+        FROM alert_events
+        WHERE ($1 = '' OR rule_id = NULLIF($1,'')::uuid)
+        ORDER BY fired_at DESC
+        LIMIT $2
+    `, ruleID, limit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var out []models.AlertEvent
+	for rows.Next() {
+		var e models.AlertEvent
+		if err := rows.Scan(&e.ID, &e.RuleID, &e.Instrument, &e.Value, &e.Message, &e.FiredAt); err != nil {
+			return nil, err
+		}
+		out = append(out, e)
+	}
+	return out, rows.Err()
+}
+
+// ---- Notifications ----
+func (p *Postgres) LogNotificationDelivery(ctx context.Context, d *models.NotificationDelivery) error {
+	return p.DB.QueryRowContext(ctx, `
+        INSERT INTO notification_deliveries (event_id, event_type, channel, status, attempts, error, payload)
+        VALUES ($1,$2,$3,$4,$5,$6,$7)
+        RETURNING id, created_at
+    `, d.EventID, d.EventType, d.Channel, string(d.Status), d.Attempts, d.Error, []byte(d.Payload)).Scan(&d.ID, &d.CreatedAt)
+}
+
+// ListNotificationDeliveries returns deliveries newest first; an empty status lists all.
+func (p *Postgres) ListNotificationDeliveries(ctx context.Context, status models.NotificationStatus, limit int) ([]models.NotificationDelivery, error) {
+	if limit <= 0 || limit > 1000 {
+		limit = 100
+	}
+	rows, err := p.DB.QueryContext(ctx, `
+        SELECT id, event_id, event_type, channel, status, attempts, error, payload, created_at
+        FROM notification_deliveries
+        WHERE ($1 = '' OR status = $1)
+        ORDER BY created_at DESC
+        LIMIT $2
+    `, string(status), limit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var out []models.NotificationDelivery
+	for rows.Next() {
+		var d models.NotificationDelivery
+		var payload []byte
+		if err := rows.Scan(&d.ID, &d.EventID, &d.EventType, &d.Channel, &d.Status, &d.Attempts, &d.Error, &payload, &d.CreatedAt); err != nil {
+			return nil, err
+		}
+		d.Payload = payload
+		out = append(out, d)
+	}
+	return out, rows.Err()
+}
+
+// ---- Optimization runs ----
+func (p *Postgres) CreateOptimizationRun(ctx context.Context, r *models.OptimizationRun) error {
+	return p.DB.QueryRowContext(ctx, `
+        INSERT INTO optimization_runs (kind, strategy, instruments, timeframe, range_from, range_to, config)
+        VALUES ($1,$2,$3,$4,$5,$6,$7)
+        RETURNING id, status, created_at
+    `, string(r.Kind), r.Strategy, pq.Array(r.Instruments), r.Timeframe, r.From, r.To, []byte(r.Config)).Scan(&r.ID, &r.Status, &r.CreatedAt)
+}
+
+// CompleteOptimizationRun records the outcome of a run; errMsg is set for failed runs.
+func (p *Postgres) CompleteOptimizationRun(ctx context.Context, id string, status models.OptimizationStatus, metrics, result, equity []byte, errMsg *string) error {
+	_, err := p.DB.ExecContext(ctx, `
+        UPDATE optimization_runs
+        SET status=$2, metrics=$3, result=$4, equity=$5, error=$6, completed_at=NOW()
+        WHERE id=$1
+    `, id, string(status), metrics, result, equity, errMsg)
+	return err
+}
+
+// GetOptimizationRun returns nil when the run does not exist.
+func (p *Postgres) GetOptimizationRun(ctx context.Context, id string) (*models.OptimizationRun, error) {
+	var r models.OptimizationRun
+	var config, metrics, result, equity []byte
+	err := p.DB.QueryRowContext(ctx, `
+        SELECT id, kind, strategy, instruments, timeframe, range_from, range_to, config, status, metrics, result, equity, error, created_at, completed_at
+        FROM optimization_runs
+        WHERE id=$1
+    `, id).Scan(&r.ID, &r.Kind, &r.Strategy, pq.Array(&r.Instruments), &r.Timeframe, &r.From, &r.To, &config, &r.Status,
+		&metrics, &result, &equity, &r.Error, &r.CreatedAt, &r.CompletedAt)
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	r.Config, r.Metrics, r.Result, r.Equity = config, metrics, result, equity
+	return &r, nil
+}
+
+// ListOptimizationRuns returns runs newest first without their results and equity curves.
+func (p *Postgres) ListOptimizationRuns(ctx context.Context, limit int) ([]models.OptimizationRun, error) {
+	if limit <= 0 || limit > 1000 {
+		limit = 100
+	}
+	rows, err := p.DB.QueryContext(ctx, `
+        SELECT id, kind, strategy, instruments, timeframe, range_from, range_to, config, status, metrics, error, created_at, completed_at
+        FROM optimization_runs
+        ORDER BY created_at DESC
+        LIMIT $1
+    `, limit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var out []models.OptimizationRun
+	for rows.Next() {
+		var r models.OptimizationRun
+		var config, metrics []byte
+		if err := rows.Scan(&r.ID, &r.Kind, &r.Strategy, pq.Array(&r.Instruments), &r.Timeframe, &r.From, &r.To, &config, &r.Status,
+			&metrics, &r.Error, &r.CreatedAt, &r.CompletedAt); err != nil {
+			return nil, err
+		}
+		r.Config, r.Metrics = config, metrics
+		out = append(out, r)
+	}
+	return out, rows.Err()
+}
+
+// ---- News archive ----
+// ArchiveNews stores articles not seen before; an article keeps the score it had when first seen.
+func (p *Postgres) ArchiveNews(ctx context.Context, articles []models.NewsArticle) error {
+	if len(articles) == 0 {
+		return nil
+	}
+	tx, err := p.DB.BeginTx(ctx, &sql.TxOptions{})
+	if err != nil {
+		return err
+	}
+	stmt, err := tx.PrepareContext(ctx, `
+        INSERT INTO news_articles (url, title, snippet, source, published_at, sentiment, relevance, currencies)
+        VALUES ($1,$2,$3,$4,$5,$6,$7,$8)
+        ON CONFLICT (url) DO NOTHING
+    `)
+	if err != nil {
+		_ = tx.Rollback()
+		return err
+	}
+	defer stmt.Close()
+	for _, a := range articles {
+		relevance, err := json.Marshal(a.Relevance)
+		if err != nil {
+			_ = tx.Rollback()
+			return err
+		}
+		if _, err := stmt.ExecContext(ctx, a.URL, a.Title, a.Snippet, a.Source, a.PublishedAt, a.Sentiment, relevance, pq.Array(a.Currencies)); err != nil {
+			_ = tx.Rollback()
+			return err
+		}
+	}
+	return tx.Commit()
+}
+
+// ListNewsArticles returns articles available in [from, to], newest first, optionally
+// restricted to those relevant to one of currencies.
+func (p *Postgres) ListNewsArticles(ctx context.Context, from, to time.Time, currencies []string, limit int) ([]models.NewsArticle, error) {
+	if limit <= 0 || limit > 1000 {
+		limit = 100
+	}
+	rows, err := p.DB.QueryContext(ctx, `
+        SELECT id, url, title, snippet, source, published_at, first_seen_at, sentiment, relevance, currencies
+        FROM news_articles
+        WHERE COALESCE(published_at, first_seen_at) BETWEEN $1 AND $2
+          AND (COALESCE(cardinality($3::text[]), 0) = 0 OR currencies && $3::text[])
+        ORDER BY COALESCE(published_at, first_seen_at) DESC
+        LIMIT $4
+    `, from, to, pq.Array(currencies), limit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var out []models.NewsArticle
+	for rows.Next() {
+		var a models.NewsArticle
+		var relevance []byte
+		if err := rows.Scan(&a.ID, &a.URL, &a.Title, &a.Snippet, &a.Source, &a.PublishedAt, &a.FirstSeenAt, &a.Sentiment, &relevance, pq.Array(&a.Currencies)); err != nil {
+			return nil, err
+		}
+		if len(relevance) > 0 {
+			if err := json.Unmarshal(relevance, &a.Relevance); err != nil {
+				return nil, err
+			}
+		}
+		out = append(out, a)
+	}
+	return out, rows.Err()
+}
+
+func (p *Postgres) CreateAccountSnapshot(ctx context.Context, s *models.AccountSnapshot) error {
+	return p.DB.QueryRowContext(ctx, `
+        INSERT INTO account_snapshots (account_id, currency, balance, nav, unrealized_pl, margin_used, margin_available, open_trade_count, taken_at)
+        VALUES ($1,$2,$3,$4,$5,$6,$7,$8,$9)
+        RETURNING id
+    `, s.AccountID, s.Currency, s.Balance, s.NAV, s.UnrealizedPL, s.MarginUsed, s.MarginAvailable, s.OpenTradeCount, s.TakenAt).Scan(&s.ID)
+}
+
+// ListAccountSnapshots returns snapshots taken in [from, to), oldest first, for one account or
+// for all when accountID is empty. A positive resolution keeps only the last snapshot of each
+// interval, with intervals aligned to the Unix epoch, so 24h yields end-of-day readings in UTC.
+func (p *Postgres) ListAccountSnapshots(ctx context.Context, accountID string, from, to time.Time, resolution time.Duration, limit int) ([]models.AccountSnapshot, error) {
+	if limit <= 0 || limit > 10000 {
+		limit = 1000
+	}
+	const cols = `id, account_id, currency, balance, nav, unrealized_pl, margin_used, margin_available, open_trade_count, taken_at`
+	query := `SELECT ` + cols + ` FROM account_snapshots
+        WHERE taken_at >= $1 AND taken_at < $2 AND ($3 = '' OR account_id = $3)
+        ORDER BY taken_at LIMIT $4`
+	args := []interface{}{from, to, accountID, limit}
+	if resolution > 0 {
+		query = `SELECT ` + cols + ` FROM (
+            SELECT DISTINCT ON (account_id, floor(extract(epoch FROM taken_at) / $5)) ` + cols + `
+            FROM account_snapshots
+            WHERE taken_at >= $1 AND taken_at < $2 AND ($3 = '' OR account_id = $3)
+            ORDER BY account_id, floor(extract(epoch FROM taken_at) / $5), taken_at DESC
+        ) s ORDER BY taken_at LIMIT $4`
+		args = append(args, resolution.Seconds())
+	}
+	rows, err := p.DB.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var out []models.AccountSnapshot
+	for rows.Next() {
+		var s models.AccountSnapshot
+		if err := rows.Scan(&s.ID, &s.AccountID, &s.Currency, &s.Balance, &s.NAV, &s.UnrealizedPL, &s.MarginUsed, &s.MarginAvailable, &s.OpenTradeCount, &s.TakenAt); err != nil {
+			return nil, err
+		}
+		out = append(out, s)
+	}
+	return out, rows.Err()
+}
+
+// DeleteAccountSnapshotsBefore removes snapshots taken before the cutoff and returns how many.
+func (p *Postgres) DeleteAccountSnapshotsBefore(ctx context.Context, before time.Time) (int64, error) {
+	res, err := p.DB.ExecContext(ctx, `DELETE FROM account_snapshots WHERE taken_at < $1`, before)
+	if err != nil {
+		return 0, err
+	}
+	return res.RowsAffected()
+}
+
+// StreamRows runs a query and hands each row to fn as it is read, so large results are never
+// held in memory. The values slice is reused between rows.
+func (p *Postgres) StreamRows(ctx context.Context, query string, args []interface{}, fn func(values []interface{}) error) error {
+	rows, err := p.DB.QueryContext(ctx, query, args...)
+	if err != nil {
+		return err
+	}
+	defer rows.Close()
+	cols, err := rows.Columns()
+	if err != nil {
+		return err
+	}
+	values := make([]interface{}, len(cols))
+	ptrs := make([]interface{}, len(cols))
+	for i := range values {
+		ptrs[i] = &values[i]
+	}
+	for rows.Next() {
+		if err := rows.Scan(ptrs...); err != nil {
+			return err
+		}
+		if err := fn(values); err != nil {
+			return err
+		}
+	}
+	return rows.Err()
+}
+
+// tickPartition names the daily partition of ticks holding day.
+func tickPartition(day time.Time) string {
+	return "ticks_" + day.UTC().Format("20060102")
+}
+
+// EnsureTickPartitions creates the daily ticks partitions covering [from, to], UTC days.
+func (p *Postgres) EnsureTickPartitions(ctx context.Context, from, to time.Time) error {
+	day := from.UTC().Truncate(24 * time.Hour)
+	for ; !day.After(to); day = day.AddDate(0, 0, 1) {
+		_, err := p.DB.ExecContext(ctx, fmt.Sprintf(`CREATE TABLE IF NOT EXISTS %s PARTITION OF ticks FOR VALUES FROM ('%s') TO ('%s')`,
+			pq.QuoteIdentifier(tickPartition(day)), day.Format(time.RFC3339), day.AddDate(0, 0, 1).Format(time.RFC3339)))
+		if err != nil {
+			return fmt.Errorf("create partition for %s: %w", day.Format("2006-01-02"), err)
+		}
+	}
+	return nil
+}
+
+// DropTickPartitionsBefore drops the daily ticks partitions that end at or before the cutoff
+// and returns how many were dropped.
+func (p *Postgres) DropTickPartitionsBefore(ctx context.Context, before time.Time) (int, error) {
+	rows, err := p.DB.QueryContext(ctx, `
+        SELECT c.relname FROM pg_inherits i
+        JOIN pg_class c ON c.oid = i.inhrelid
+        WHERE i.inhparent = 'ticks'::regclass
+    `)
+	if err != nil {
+		return 0, err
+	}
+	var names []string
+	for rows.Next() {
+		var name string
+		if err := rows.Scan(&name); err != nil {
+			rows.Close()
+			return 0, err
+		}
+		names = append(names, name)
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return 0, err
+	}
+	n := 0
+	for _, name := range names {
+		day, err := time.Parse("20060102", strings.TrimPrefix(name, "ticks_"))
+		if err != nil || day.AddDate(0, 0, 1).After(before) {
+			continue
+		}
+		if _, err := p.DB.ExecContext(ctx, `DROP TABLE IF EXISTS `+pq.QuoteIdentifier(name)); err != nil {
+			return n, err
+		}
+		n++
+	}
+	return n, nil
+}
+
+// tickBatch bounds the rows per INSERT, well inside the 65535 bind parameter limit.
+const tickBatch = 1000
+
+// InsertTicks stores ticks with multi-row inserts, skipping ones already stored, and returns
+// how many were new. The partitions for their days must exist.
+func (p *Postgres) InsertTicks(ctx context.Context, ticks []models.Tick) (int64, error) {
+	if len(ticks) == 0 {
+		return 0, nil
+	}
+	tx, err := p.DB.BeginTx(ctx, &sql.TxOptions{})
+	if err != nil {
+		return 0, err
+	}
+	var n int64
+	var sb strings.Builder
+	args := make([]interface{}, 0, 4*tickBatch)
+	for start := 0; start < len(ticks); start += tickBatch {
+		batch := ticks[start:min(start+tickBatch, len(ticks))]
+		sb.Reset()
+		args = args[:0]
+		sb.WriteString(`INSERT INTO ticks (instrument, time, bid, ask) VALUES `)
+		for i, t := range batch {
+			if i > 0 {
+				sb.WriteByte(',')
+			}
+			fmt.Fprintf(&sb, "($%d,$%d,$%d,$%d)", 4*i+1, 4*i+2, 4*i+3, 4*i+4)
+			args = append(args, t.Instrument, t.Time, t.Bid, t.Ask)
+		}
+		sb.WriteString(` ON CONFLICT (instrument, time) DO NOTHING`)
+		res, err := tx.ExecContext(ctx, sb.String(), args...)
+		if err != nil {
+			_ = tx.Rollback()
+			return 0, err
+		}
+		affected, _ := res.RowsAffected()
+		n += affected
+	}
+	if err := tx.Commit(); err != nil {
+		return 0, err
+	}
+	return n, nil
+}
+
+// ListTicks returns an instrument's ticks with from <= time < to, oldest first. limit defaults to
+// 10000 and is capped at 100000.
+func (p *Postgres) ListTicks(ctx context.Context, instrument string, from, to time.Time, limit int) ([]models.Tick, error) {
+	if limit <= 0 || limit > 100000 {
+		limit = 10000
+	}
+	rows, err := p.DB.QueryContext(ctx, `
+        SELECT instrument, time, bid, ask FROM ticks
+        WHERE instrument = $1 AND time >= $2 AND time < $3
+        ORDER BY time LIMIT $4
+    `, instrument, from, to, limit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var out []models.Tick
+	for rows.Next() {
+		var t models.Tick
+		if err := rows.Scan(&t.Instrument, &t.Time, &t.Bid, &t.Ask); err != nil {
+			return nil, err
+		}
+		out = append(out, t)
+	}
+	return out, rows.Err()
+}
+
+// TickSpreadStats summarizes an instrument's spreads over [from, to) in intervals aligned to the
+// Unix epoch, oldest first.
+func (p *Postgres) TickSpreadStats(ctx context.Context, instrument string, from, to time.Time, interval time.Duration) ([]models.SpreadStats, error) {
+	rows, err := p.DB.QueryContext(ctx, `
+        SELECT to_timestamp(floor(extract(epoch FROM time) / $4) * $4) AS bucket, count(*),
+               min(ask - bid), avg(ask - bid),
+               percentile_cont(0.5) WITHIN GROUP (ORDER BY ask - bid),
+               percentile_cont(0.95) WITHIN GROUP (ORDER BY ask - bid),
+               max(ask - bid)
+        FROM ticks
+        WHERE instrument = $1 AND time >= $2 AND time < $3
+        GROUP BY bucket ORDER BY bucket
+    `, instrument, from, to, interval.Seconds())
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var out []models.SpreadStats
+	for rows.Next() {
+		var s models.SpreadStats
+		if err := rows.Scan(&s.Time, &s.Ticks, &s.Min, &s.Avg, &s.P50, &s.P95, &s.Max); err != nil {
+			return nil, err
+		}
+		s.Time = s.Time.UTC()
+		out = append(out, s)
+	}
+	return out, rows.Err()
+}
@@ -0,0 +1,161 @@
+package database
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"math"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/jedi116/go-trader/pkg/models"
+)
+
+// These run against a migrated database named by TEST_DATABASE_URL and are skipped without it.
+// Every run writes its own instrument and deletes it afterwards.
+
+func testPostgres(tb testing.TB) (*Postgres, string) {
+	tb.Helper()
+	dsn := os.Getenv("TEST_DATABASE_URL")
+	if dsn == "" {
+		tb.Skip("TEST_DATABASE_URL is not set")
+	}
+	db, err := sql.Open("postgres", dsn)
+	if err != nil {
+		tb.Fatal(err)
+	}
+	if err := db.Ping(); err != nil {
+		tb.Fatal(err)
+	}
+	instrument := fmt.Sprintf("TEST_%d", time.Now().UnixNano())
+	tb.Cleanup(func() {
+		_, _ = db.Exec(`DELETE FROM market_data WHERE instrument=$1`, instrument)
+		_ = db.Close()
+	})
+	return &Postgres{DB: db}, instrument
+}
+
+// candleBatch returns n M1 candles where every key appears dup times in a row; the volume of
+// each copy is its position in the batch, so the survivor shows which copy won.
+func candleBatch(instrument string, n, dup int, start time.Time) []models.MarketData {
+	rows := make([]models.MarketData, n)
+	for i := range rows {
+		px, volume := 1+float64(i)/1e5, int64(i)
+		rows[i] = models.MarketData{
+			Instrument: instrument,
+			Timestamp:  start.Add(time.Duration(i/dup) * time.Minute),
+			OpenPrice:  px, HighPrice: px, LowPrice: px, ClosePrice: px,
+			Volume:    &volume,
+			Timeframe: "M1",
+			Bid:       &models.OHLC{Open: px, High: px, Low: px, Close: px},
+			Ask:       &models.OHLC{Open: px, High: px, Low: px, Close: px},
+		}
+	}
+	return rows
+}
+
+func TestUpsertMarketDataKeepsLast(t *testing.T) {
+	p, instrument := testPostgres(t)
+	ctx := context.Background()
+	tests := []struct {
+		name string
+		n    int
+	}{
+		{"rows", copyMinRows / 2},
+		{"copy", copyMinRows * 3},
+	}
+	for i, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			start := time.Date(2026, 1, 5+i, 0, 0, 0, 0, time.UTC)
+			const dup = 3
+			// The first write is overwritten by the second, so conflicts with stored rows are covered too.
+			for _, n := range []int{tt.n, tt.n} {
+				if err := p.UpsertMarketData(ctx, candleBatch(instrument, n, dup, start)); err != nil {
+					t.Fatal(err)
+				}
+			}
+			got, err := p.ListMarketDataRange(ctx, instrument, "M1", start, start.Add(24*time.Hour))
+			if err != nil {
+				t.Fatal(err)
+			}
+			keys := (tt.n + dup - 1) / dup
+			if len(got) != keys {
+				t.Fatalf("stored %d candles, want %d", len(got), keys)
+			}
+			for k, c := range got {
+				last := (k+1)*dup - 1
+				if last >= tt.n {
+					last = tt.n - 1
+				}
+				if c.Volume == nil || *c.Volume != int64(last) || c.Bid == nil || c.Bid.Close != c.ClosePrice {
+					t.Fatalf("candle %s = %+v, want the copy at %d", c.Timestamp, c, last)
+				}
+			}
+		})
+	}
+}
+
+// A duplicate without bid/ask keeps those of the copy before it, whether the batch goes through
+// the row path or COPY.
+func TestUpsertMarketDataMergesQuotes(t *testing.T) {
+	p, instrument := testPostgres(t)
+	ctx := context.Background()
+	tests := []struct {
+		name string
+		n    int
+	}{
+		{"rows", copyMinRows / 2},
+		{"copy", copyMinRows * 3},
+	}
+	for i, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			start := time.Date(2026, 1, 12+i, 0, 0, 0, 0, time.UTC)
+			rows := candleBatch(instrument, tt.n, 2, start)
+			for j := 1; j < len(rows); j += 2 {
+				rows[j].Bid, rows[j].Ask = nil, nil
+			}
+			if err := p.UpsertMarketData(ctx, rows); err != nil {
+				t.Fatal(err)
+			}
+			got, err := p.ListMarketDataRange(ctx, instrument, "M1", start, start.Add(24*time.Hour))
+			if err != nil {
+				t.Fatal(err)
+			}
+			if len(got) != tt.n/2 {
+				t.Fatalf("stored %d candles, want %d", len(got), tt.n/2)
+			}
+			// Each copy's prices are 0.00001 above the one before, so the quotes trail the close.
+			for k, c := range got {
+				if c.Volume == nil || *c.Volume != int64(2*k+1) || c.Bid == nil || c.Ask == nil ||
+					math.Abs(c.ClosePrice-c.Bid.Close-1e-5) > 1e-9 || c.Ask.Close != c.Bid.Close {
+					t.Fatalf("candle %s = %+v, want copy %d with the quotes of copy %d", c.Timestamp, c, 2*k+1, 2*k)
+				}
+			}
+		})
+	}
+}
+
+func benchmarkInsertMarketData(b *testing.B, insert func(*Postgres, context.Context, []models.MarketData) error) {
+	p, instrument := testPostgres(b)
+	ctx := context.Background()
+	const n = 5000
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		// Half the batch repeats a timestamp, and every iteration after the first hits stored rows.
+		rows := candleBatch(instrument, n, 2, time.Date(2026, 2, 2, 0, 0, 0, 0, time.UTC))
+		if err := insert(p, ctx, rows); err != nil {
+			b.Fatal(err)
+		}
+	}
+	b.ReportMetric(float64(n*b.N)/b.Elapsed().Seconds(), "rows/s")
+}
+
+func BenchmarkInsertMarketDataCopy(b *testing.B) {
+	benchmarkInsertMarketData(b, (*Postgres).copyMarketData)
+}
+
+func BenchmarkInsertMarketDataRows(b *testing.B) {
+	benchmarkInsertMarketData(b, (*Postgres).insertMarketData)
+}
@@ -0,0 +1,219 @@
+package database
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"os"
+	"time"
+
+	"github.com/jedi116/go-trader/internal/config"
+
+	"github.com/jedi116/go-trader/pkg/models"
+)
+
+// TradeRepo persists trades and their outcomes.
+type TradeRepo interface {
+	CreateTrade(ctx context.Context, t *models.Trade) error
+	ListTrades(ctx context.Context, limit int) ([]models.Trade, error)
+	SoftDeleteTrade(ctx context.Context, id string) error
+	ListOpenTradeIDs(ctx context.Context) ([]string, error)
+	CloseTrade(ctx context.Context, oandaTradeID string, exit, pl, swap float64, closedAt time.Time) (bool, error)
+	ListClosedTrades(ctx context.Context, from, to *time.Time) ([]models.Trade, error)
+	SumRealizedPL(ctx context.Context, since time.Time) (float64, int, error)
+}
+
+// RecommendationRepo persists manual, signal and strategy recommendations along with the AI
+// recommendations and their context.
+type RecommendationRepo interface {
+	CreateRecommendation(ctx context.Context, r *models.Recommendation) (string, error)
+	ListRecommendations(ctx context.Context) ([]models.Recommendation, error)
+	MarkRecommendationExecuted(ctx context.Context, id string, tradeID string) error
+	TransitionRecommendation(ctx context.Context, id string, from, to models.RecommendationStatus) (bool, error)
+	SoftDeleteRecommendation(ctx context.Context, id string) error
+	CreateAIRecommendation(ctx context.Context, r *models.AIRecommendation) (string, error)
+	UpdateAIRecommendationStatus(ctx context.Context, id string, status models.AIRecommendationStatus) error
+	MarkAIRecommendationExecuted(ctx context.Context, id string, tradeID string) error
+	ClaimAIRecommendation(ctx context.Context, id string, now time.Time) (bool, error)
+	ListAIRecommendations(ctx context.Context, limit int) ([]models.AIRecommendation, error)
+}
+
+// MarketDataRepo persists candles.
+type MarketDataRepo interface {
+	UpsertMarketData(ctx context.Context, rows []models.MarketData) error
+	ListMarketDataRange(ctx context.Context, instrument, timeframe string, from, to time.Time) ([]models.MarketData, error)
+	ListMarketData(ctx context.Context, instrument string, timeframe string, limit int) ([]models.MarketData, error)
+}
+
+// AuditRepo records audit entries; entityID may be empty.
+type AuditRepo interface {
+	LogAudit(ctx context.Context, entity string, entityID string, action string, details map[string]interface{}) error
+}
+
+// UsageRepo records model calls and totals their cost.
+type UsageRepo interface {
+	CreateAIUsageLog(ctx context.Context, l *models.AIUsageLog) error
+	SummarizeAIUsage(ctx context.Context, since time.Time) ([]models.AIUsageTotals, error)
+}
+
+// CalendarRepo persists economic calendar events.
+type CalendarRepo interface {
+	UpsertEconomicEvents(ctx context.Context, events []models.EconomicEvent) error
+	ListEconomicEvents(ctx context.Context, from, to time.Time, currencies []string) ([]models.EconomicEvent, error)
+}
+
+// NewsRepo archives scored news so AI replays can rebuild past news context.
+type NewsRepo interface {
+	ArchiveNews(ctx context.Context, articles []models.NewsArticle) error
+	ListNewsArticles(ctx context.Context, from, to time.Time, currencies []string, limit int) ([]models.NewsArticle, error)
+}
+
+// AnalysisCacheRepo caches aggregated AI context until it expires.
+type AnalysisCacheRepo interface {
+	InsertMarketAnalysisCache(ctx context.Context, key string, instruments string, analysisData []byte, expiresAt time.Time) error
+	GetMarketAnalysisCache(ctx context.Context, key string) ([]byte, bool, error)
+	PurgeExpiredMarketAnalysisCache(ctx context.Context) (int64, error)
+}
+
+// GuardianRepo persists the kill-switch state of each account.
+type GuardianRepo interface {
+	GetGuardianState(ctx context.Context, accountID string) (*models.GuardianState, error)
+	SaveGuardianState(ctx context.Context, s *models.GuardianState) error
+}
+
+// QueuedOrderRepo persists orders received while the market is closed.
+type QueuedOrderRepo interface {
+	CreateQueuedOrder(ctx context.Context, o *models.QueuedOrder) error
+	ListQueuedOrders(ctx context.Context, status models.QueuedOrderStatus, limit int) ([]models.QueuedOrder, error)
+	TransitionQueuedOrder(ctx context.Context, id string, from, to models.QueuedOrderStatus, oandaOrderID, errMsg *string) (bool, error)
+}
+
+// AlertRepo persists alert rules and their firings.
+type AlertRepo interface {
+	CreateAlertRule(ctx context.Context, r *models.AlertRule) error
+	GetAlertRule(ctx context.Context, id string) (*models.AlertRule, error)
+	ListAlertRules(ctx context.Context, activeOnly bool) ([]models.AlertRule, error)
+	UpdateAlertRule(ctx context.Context, r *models.AlertRule) (bool, error)
+	SoftDeleteAlertRule(ctx context.Context, id string) (bool, error)
+	RecordAlertFired(ctx context.Context, e *models.AlertEvent, deactivate bool) error
+	ListAlertEvents(ctx context.Context, ruleID string, limit int) ([]models.AlertEvent, error)
+}
+
+// NotificationRepo records the outcome of each notification delivery.
+type NotificationRepo interface {
+	LogNotificationDelivery(ctx context.Context, d *models.NotificationDelivery) error
+	ListNotificationDeliveries(ctx context.Context, status models.NotificationStatus, limit int) ([]models.NotificationDelivery, error)
+}
+
+// OptimizationRepo persists parameter searches and walk-forward analyses.
+type OptimizationRepo interface {
+	CreateOptimizationRun(ctx context.Context, r *models.OptimizationRun) error
+	CompleteOptimizationRun(ctx context.Context, id string, status models.OptimizationStatus, metrics, result, equity []byte, errMsg *string) error
+	GetOptimizationRun(ctx context.Context, id string) (*models.OptimizationRun, error)
+	ListOptimizationRuns(ctx context.Context, limit int) ([]models.OptimizationRun, error)
+}
+
+// SnapshotRepo persists periodic account snapshots.
+type SnapshotRepo interface {
+	CreateAccountSnapshot(ctx context.Context, s *models.AccountSnapshot) error
+	ListAccountSnapshots(ctx context.Context, accountID string, from, to time.Time, resolution time.Duration, limit int) ([]models.AccountSnapshot, error)
+	DeleteAccountSnapshotsBefore(ctx context.Context, before time.Time) (int64, error)
+}
+
+// TickRepo persists ticks from the pricing stream. Postgres keeps them in daily partitions; the
+// other stores treat a partition as the day's ticks.
+type TickRepo interface {
+	EnsureTickPartitions(ctx context.Context, from, to time.Time) error
+	InsertTicks(ctx context.Context, ticks []models.Tick) (int64, error)
+	DropTickPartitionsBefore(ctx context.Context, before time.Time) (int, error)
+	ListTicks(ctx context.Context, instrument string, from, to time.Time, limit int) ([]models.Tick, error)
+	TickSpreadStats(ctx context.Context, instrument string, from, to time.Time, interval time.Duration) ([]models.SpreadStats, error)
+}
+
+// ExportRepo streams SQL query results for internal/export. Memory has no SQL and returns
+// ErrUnsupported; export reads it through its per-dataset Export methods instead.
+type ExportRepo interface {
+	StreamRows(ctx context.Context, query string, args []interface{}, fn func(values []interface{}) error) error
+}
+
+// ErrUnsupported is returned by the operations a store cannot perform.
+var ErrUnsupported = errors.New("not supported by the in-memory store")
+
+// Store is everything the API and gRPC servers persist: Postgres or SQLite as configured,
+// otherwise Memory.
+type Store interface {
+	TradeRepo
+	RecommendationRepo
+	MarketDataRepo
+	AuditRepo
+	UsageRepo
+	CalendarRepo
+	NewsRepo
+	AnalysisCacheRepo
+	GuardianRepo
+	QueuedOrderRepo
+	AlertRepo
+	NotificationRepo
+	OptimizationRepo
+	SnapshotRepo
+	TickRepo
+	ExportRepo
+	Close() error
+}
+
+var (
+	_ Store = (*Postgres)(nil)
+	_ Store = (*SQLite)(nil)
+	_ Store = (*Memory)(nil)
+)
+
+// ErrNotConfigured is returned by Open when the postgres driver has neither DATABASE_URL nor
+// database.host to connect to.
+var ErrNotConfigured = errors.New("no database configured")
+
+// Open connects the backend named by database.driver: "postgres" (the default), "sqlite",
+// which opens database.path, or "memory". A configured backend that cannot be opened is an
+// error; callers must not fall back to memory for it.
+func Open(cfg *config.Config) (Store, error) {
+	switch cfg.Database.Driver {
+	case "memory":
+		return NewMemory(), nil
+	case "", "postgres":
+		if os.Getenv("DATABASE_URL") == "" && cfg.Database.Host == "" {
+			return nil, ErrNotConfigured
+		}
+		pg, err := NewPostgres(cfg)
+		if err != nil {
+			return nil, err
+		}
+		return pg, nil
+	case "sqlite":
+		s, err := NewSQLite(cfg.Database.Path)
+		if err != nil {
+			return nil, err
+		}
+		return s, nil
+	}
+	return nil, fmt.Errorf("unknown database.driver %q (want postgres, sqlite or memory)", cfg.Database.Driver)
+}
+
+// OpenOrMemory is Open for the servers: with no database configured it runs on a Memory store,
+// saying so, rather than failing.
+func OpenOrMemory(cfg *config.Config) (Store, error) {
+	driver := cfg.Database.Driver
+	if driver == "" {
+		driver = "postgres"
+	}
+	store, err := Open(cfg)
+	switch {
+	case errors.Is(err, ErrNotConfigured):
+		log.Printf("[DB] no database configured; everything is kept in memory and lost on restart")
+		return NewMemory(), nil
+	case err != nil:
+		return nil, fmt.Errorf("open %s database: %w", driver, err)
+	case driver == "memory":
+		log.Printf("[DB] driver memory; everything is kept in memory and lost on restart")
+	}
+	return store, nil
+}
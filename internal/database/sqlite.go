@@ -0,0 +1,1286 @@
+package database
+
+import (
+	"context"
+	"database/sql"
+	"embed"
+	"encoding/json"
+	"fmt"
+	"io/fs"
+	"log"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/jedi116/go-trader/pkg/models"
+)
+
+// sqliteDriver is the database/sql name of the pure-Go SQLite driver, registered by
+// sqlite_driver.go.
+const sqliteDriver = "sqlite"
+
+//go:embed sqlite_migrations/*.sql
+var sqliteMigrations embed.FS
+
+// SQLite is a Store in a single SQLite file, for single-user deployments without Postgres. It
+// covers every table; ticks live in one table instead of daily partitions.
+type SQLite struct {
+	DB *sql.DB
+}
+
+// NewSQLite opens the database file at path, creating it if needed, and applies the embedded
+// migrations that have not run yet.
+func NewSQLite(path string) (*SQLite, error) {
+	if path == "" {
+		path = "go-trader.db"
+	}
+	log.Printf("[DB] Opening sqlite path=%s", path)
+	db, err := sql.Open(sqliteDriver, "file:"+path+"?_pragma=busy_timeout(5000)&_pragma=journal_mode(WAL)")
+	if err != nil {
+		return nil, err
+	}
+	// SQLite allows one writer; a single connection serializes writes instead of failing them
+	// with SQLITE_BUSY.
+	db.SetMaxOpenConns(1)
+	s := &SQLite{DB: db}
+	if err := s.migrate(context.Background()); err != nil {
+		_ = db.Close()
+		return nil, fmt.Errorf("sqlite migrate: %w", err)
+	}
+	return s, nil
+}
+
+// migrate applies the embedded migrations in name order, recording them in schema_migrations
+// as cmd/migrate does for Postgres.
+func (s *SQLite) migrate(ctx context.Context) error {
+	if _, err := s.DB.ExecContext(ctx, `CREATE TABLE IF NOT EXISTS schema_migrations (filename TEXT PRIMARY KEY, applied_at TEXT NOT NULL)`); err != nil {
+		return err
+	}
+	entries, err := fs.ReadDir(sqliteMigrations, "sqlite_migrations")
+	if err != nil {
+		return err
+	}
+	sort.Slice(entries, func(i, j int) bool { return entries[i].Name() < entries[j].Name() })
+	for _, e := range entries {
+		var exists bool
+		if err := s.DB.QueryRowContext(ctx, `SELECT EXISTS(SELECT 1 FROM schema_migrations WHERE filename=?)`, e.Name()).Scan(&exists); err != nil {
+			return err
+		}
+		if exists {
+			continue
+		}
+		body, err := sqliteMigrations.ReadFile("sqlite_migrations/" + e.Name())
+		if err != nil {
+			return err
+		}
+		tx, err := s.DB.BeginTx(ctx, nil)
+		if err != nil {
+			return err
+		}
+		if _, err := tx.ExecContext(ctx, string(body)); err != nil {
+			_ = tx.Rollback()
+			return fmt.Errorf("%s: %w", e.Name(), err)
+		}
+		if _, err := tx.ExecContext(ctx, `INSERT INTO schema_migrations(filename, applied_at) VALUES (?,?)`, e.Name(), sqliteTime(time.Now())); err != nil {
+			_ = tx.Rollback()
+			return err
+		}
+		if err := tx.Commit(); err != nil {
+			return err
+		}
+		log.Printf("[DB] sqlite applied %s", e.Name())
+	}
+	return nil
+}
+
+func (s *SQLite) Health(ctx context.Context) error {
+	ctx, cancel := context.WithTimeout(ctx, 3*time.Second)
+	defer cancel()
+	return s.DB.PingContext(ctx)
+}
+
+func (s *SQLite) Close() error { return s.DB.Close() }
+
+// sqliteTimeLayout stores times as fixed-width UTC text, so comparing the text compares the times.
+const sqliteTimeLayout = "2006-01-02T15:04:05.000000000Z"
+
+func sqliteTime(t time.Time) string { return t.UTC().Format(sqliteTimeLayout) }
+
+func sqliteNullTime(t *time.Time) interface{} {
+	if t == nil {
+		return nil
+	}
+	return sqliteTime(*t)
+}
+
+// sqliteJSON binds a JSON column as text, NULL when empty.
+func sqliteJSON(b []byte) interface{} {
+	if len(b) == 0 {
+		return nil
+	}
+	return string(b)
+}
+
+// timeText scans a time stored with sqliteTime; drivers that convert it to time.Time themselves
+// are accepted too.
+type timeText struct{ dst *time.Time }
+
+func (t timeText) Scan(v interface{}) error {
+	switch x := v.(type) {
+	case nil:
+		*t.dst = time.Time{}
+	case time.Time:
+		*t.dst = x.UTC()
+	case string:
+		return t.parse(x)
+	case []byte:
+		return t.parse(string(x))
+	default:
+		return fmt.Errorf("sqlite: cannot scan %T into time", v)
+	}
+	return nil
+}
+
+func (t timeText) parse(s string) error {
+	v, err := time.Parse(time.RFC3339Nano, s)
+	if err != nil {
+		return err
+	}
+	*t.dst = v.UTC()
+	return nil
+}
+
+// nullTimeText scans a nullable time stored with sqliteTime.
+type nullTimeText struct{ dst **time.Time }
+
+func (t nullTimeText) Scan(v interface{}) error {
+	if v == nil {
+		*t.dst = nil
+		return nil
+	}
+	var tm time.Time
+	if err := (timeText{&tm}).Scan(v); err != nil {
+		return err
+	}
+	*t.dst = &tm
+	return nil
+}
+
+func (s *SQLite) audit(ctx context.Context, entity string, entityID string, action string, details map[string]interface{}) error {
+	detailsJSON, err := json.Marshal(details)
+	if err != nil {
+		return err
+	}
+	_, err = s.DB.ExecContext(ctx, `INSERT INTO audit_logs(entity, entity_id, action, details, created_at) VALUES (?,NULLIF(?,''),?,?,?)`,
+		entity, entityID, action, string(detailsJSON), sqliteTime(time.Now()))
+	return err
+}
+
+// LogAudit records an audit entry for callers outside the DB layer; entityID may be empty.
+func (s *SQLite) LogAudit(ctx context.Context, entity string, entityID string, action string, details map[string]interface{}) error {
+	return s.audit(ctx, entity, entityID, action, details)
+}
+
+// Recommendations
+
+func (s *SQLite) CreateRecommendation(ctx context.Context, r *models.Recommendation) (string, error) {
+	id := r.ID
+	if id == "" {
+		id = newID()
+	}
+	_, err := s.DB.ExecContext(ctx, `INSERT INTO recommendations (id, instrument, direction, units, rationale, confidence_score, market_conditions, status, trade_id, created_at, executed_at)
+              VALUES (?,?,?,?,?,?,?,?,?,?,?)`,
+		id, r.Instrument, r.Direction, r.Units, r.Rationale, r.ConfidenceScore, sqliteJSON(r.MarketConditions), r.Status, r.TradeID, sqliteTime(time.Now()), sqliteNullTime(r.ExecutedAt))
+	if err != nil {
+		return "", err
+	}
+	_ = s.audit(ctx, "recommendations", id, "CREATE", map[string]interface{}{"instrument": r.Instrument, "direction": r.Direction, "units": r.Units})
+	return id, nil
+}
+
+func (s *SQLite) ListRecommendations(ctx context.Context) ([]models.Recommendation, error) {
+	rows, err := s.DB.QueryContext(ctx, `SELECT id, instrument, direction, units, rationale, confidence_score, market_conditions, status, trade_id, created_at, executed_at FROM recommendations WHERE deleted_at IS NULL ORDER BY created_at DESC LIMIT 200`)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var out []models.Recommendation
+	for rows.Next() {
+		var r models.Recommendation
+		if err := rows.Scan(&r.ID, &r.Instrument, &r.Direction, &r.Units, &r.Rationale, &r.ConfidenceScore, &r.MarketConditions, &r.Status, &r.TradeID, timeText{&r.CreatedAt}, nullTimeText{&r.ExecutedAt}); err != nil {
+			return nil, err
+		}
+		out = append(out, r)
+	}
+	return out, rows.Err()
+}
+
+func (s *SQLite) MarkRecommendationExecuted(ctx context.Context, id string, tradeID string) error {
+	_, err := s.DB.ExecContext(ctx, `UPDATE recommendations SET status='EXECUTED', trade_id=?, executed_at=? WHERE id=?`, tradeID, sqliteTime(time.Now()), id)
+	if err == nil {
+		_ = s.audit(ctx, "recommendations", id, "EXECUTE", map[string]interface{}{"trade_id": tradeID})
+	}
+	return err
+}
+
+// TransitionRecommendation moves a recommendation that is not deleted from one status to another,
+// setting executed_at when it becomes EXECUTED. It reports false when the recommendation was not
+// in the from status.
+func (s *SQLite) TransitionRecommendation(ctx context.Context, id string, from, to models.RecommendationStatus) (bool, error) {
+	return sqliteRowsAffected(s.DB.ExecContext(ctx, `
+        UPDATE recommendations
+        SET status=?3, executed_at=CASE WHEN ?3='EXECUTED' THEN ?4 END
+        WHERE id=?1 AND status=?2 AND deleted_at IS NULL
+    `, id, string(from), string(to), sqliteTime(time.Now())))
+}
+
+func (s *SQLite) SoftDeleteRecommendation(ctx context.Context, id string) error {
+	_, err := s.DB.ExecContext(ctx, `UPDATE recommendations SET deleted_at = ? WHERE id = ? AND deleted_at IS NULL`, sqliteTime(time.Now()), id)
+	if err == nil {
+		_ = s.audit(ctx, "recommendations", id, "DELETE", map[string]interface{}{})
+	}
+	return err
+}
+
+// Trades
+
+func (s *SQLite) CreateTrade(ctx context.Context, t *models.Trade) error {
+	if t.Source == "" {
+		t.Source = models.TradeSourceManual
+	}
+	id := t.ID
+	if id == "" {
+		id = newID()
+	}
+	now := sqliteTime(time.Now())
+	_, err := s.DB.ExecContext(ctx, `INSERT INTO trades (id, instrument, direction, units, entry_price, exit_price, profit_loss, commission, swap, status, oanda_trade_id, source, created_at, updated_at, closed_at)
+              VALUES (?,?,?,?,?,?,?,?,?,?,?,?,?,?,?)`,
+		id, t.Instrument, t.Direction, t.Units, t.EntryPrice, t.ExitPrice, t.ProfitLoss, t.Commission, t.Swap, t.Status, t.OandaTradeID, t.Source, now, now, sqliteNullTime(t.ClosedAt))
+	if err == nil {
+		_ = s.audit(ctx, "trades", id, "CREATE", map[string]interface{}{"instrument": t.Instrument, "direction": t.Direction, "units": t.Units, "source": t.Source})
+	}
+	return err
+}
+
+func scanSQLiteTrades(rows *sql.Rows) ([]models.Trade, error) {
+	defer rows.Close()
+	var out []models.Trade
+	for rows.Next() {
+		var t models.Trade
+		if err := rows.Scan(&t.ID, &t.Instrument, &t.Direction, &t.Units, &t.EntryPrice, &t.ExitPrice, &t.ProfitLoss, &t.Commission, &t.Swap, &t.Status, &t.OandaTradeID, &t.Source,
+			timeText{&t.CreatedAt}, timeText{&t.UpdatedAt}, nullTimeText{&t.ClosedAt}); err != nil {
+			return nil, err
+		}
+		out = append(out, t)
+	}
+	return out, rows.Err()
+}
+
+func (s *SQLite) ListTrades(ctx context.Context, limit int) ([]models.Trade, error) {
+	if limit <= 0 || limit > 500 {
+		limit = 200
+	}
+	rows, err := s.DB.QueryContext(ctx, `SELECT `+tradeColumns+` FROM trades WHERE deleted_at IS NULL ORDER BY created_at DESC LIMIT ?`, limit)
+	if err != nil {
+		return nil, err
+	}
+	return scanSQLiteTrades(rows)
+}
+
+func (s *SQLite) SoftDeleteTrade(ctx context.Context, id string) error {
+	_, err := s.DB.ExecContext(ctx, `UPDATE trades SET deleted_at = ? WHERE id = ? AND deleted_at IS NULL`, sqliteTime(time.Now()), id)
+	if err == nil {
+		_ = s.audit(ctx, "trades", id, "DELETE", map[string]interface{}{})
+	}
+	return err
+}
+
+// ListOpenTradeIDs returns the OANDA trade IDs of trades still recorded as open.
+func (s *SQLite) ListOpenTradeIDs(ctx context.Context) ([]string, error) {
+	rows, err := s.DB.QueryContext(ctx, `SELECT oanda_trade_id FROM trades WHERE deleted_at IS NULL AND status = 'OPEN' AND oanda_trade_id IS NOT NULL`)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var out []string
+	for rows.Next() {
+		var id string
+		if err := rows.Scan(&id); err != nil {
+			return nil, err
+		}
+		out = append(out, id)
+	}
+	return out, rows.Err()
+}
+
+// CloseTrade records the outcome of the open trade with the given OANDA trade ID. It reports
+// false when no open trade matched.
+func (s *SQLite) CloseTrade(ctx context.Context, oandaTradeID string, exit, pl, swap float64, closedAt time.Time) (bool, error) {
+	var id string
+	err := s.DB.QueryRowContext(ctx, `
+        UPDATE trades SET status = 'CLOSED', exit_price = ?, profit_loss = ?, swap = ?, closed_at = ?, updated_at = ?
+        WHERE oanda_trade_id = ? AND status = 'OPEN' AND deleted_at IS NULL
+        RETURNING id
+    `, exit, pl, swap, sqliteTime(closedAt), sqliteTime(time.Now()), oandaTradeID).Scan(&id)
+	if err == sql.ErrNoRows {
+		return false, nil
+	}
+	if err != nil {
+		return false, err
+	}
+	_ = s.audit(ctx, "trades", id, "CLOSE", map[string]interface{}{"oanda_trade_id": oandaTradeID, "profit_loss": pl})
+	return true, nil
+}
+
+// ListClosedTrades returns closed trades in order of closing, optionally bounded by closing time.
+func (s *SQLite) ListClosedTrades(ctx context.Context, from, to *time.Time) ([]models.Trade, error) {
+	rows, err := s.DB.QueryContext(ctx, `SELECT `+tradeColumns+` FROM trades
+        WHERE deleted_at IS NULL AND status = 'CLOSED' AND closed_at IS NOT NULL
+          AND (?1 IS NULL OR closed_at >= ?1) AND (?2 IS NULL OR closed_at < ?2)
+        ORDER BY closed_at, created_at`, sqliteNullTime(from), sqliteNullTime(to))
+	if err != nil {
+		return nil, err
+	}
+	return scanSQLiteTrades(rows)
+}
+
+// SumRealizedPL totals profit_loss of trades closed at or after since and counts them.
+func (s *SQLite) SumRealizedPL(ctx context.Context, since time.Time) (float64, int, error) {
+	var total float64
+	var n int
+	err := s.DB.QueryRowContext(ctx, `
+        SELECT COALESCE(SUM(profit_loss), 0), COUNT(*)
+        FROM trades
+        WHERE deleted_at IS NULL AND status = 'CLOSED' AND closed_at >= ?
+    `, sqliteTime(since)).Scan(&total, &n)
+	return total, n, err
+}
+
+// Market data
+
+// UpsertMarketData inserts candles or updates the stored ones with the same instrument, time
+// and timeframe, in one transaction; within a batch the last candle for a key wins. SQLite runs
+// in process, so a prepared statement per row is as fast as Postgres' COPY path.
+func (s *SQLite) UpsertMarketData(ctx context.Context, rows []models.MarketData) error {
+	if len(rows) == 0 {
+		return nil
+	}
+	tx, err := s.DB.BeginTx(ctx, &sql.TxOptions{})
+	if err != nil {
+		return err
+	}
+	placeholders := strings.TrimSuffix(strings.Repeat("?,", len(marketDataColumns)+1), ",")
+	stmt, err := tx.PrepareContext(ctx, `INSERT INTO market_data (`+strings.Join(marketDataColumns, ", ")+`, created_at) VALUES (`+placeholders+`)`+marketDataConflict)
+	if err != nil {
+		_ = tx.Rollback()
+		return err
+	}
+	defer stmt.Close()
+	now := sqliteTime(time.Now())
+	for _, r := range rows {
+		if r.ID == "" {
+			r.ID = newID()
+		}
+		args := marketDataArgs(r)
+		args[2] = sqliteTime(r.Timestamp)
+		if _, err := stmt.ExecContext(ctx, append(args, now)...); err != nil {
+			_ = tx.Rollback()
+			return err
+		}
+	}
+	return tx.Commit()
+}
+
+// ListMarketDataRange returns candles with from <= timestamp < to, oldest first, including bid
+// and ask where stored.
+func (s *SQLite) ListMarketDataRange(ctx context.Context, instrument, timeframe string, from, to time.Time) ([]models.MarketData, error) {
+	rows, err := s.DB.QueryContext(ctx, `
+        SELECT id, instrument, timestamp, open_price, high_price, low_price, close_price, volume, timeframe,
+               bid_open, bid_high, bid_low, bid_close, ask_open, ask_high, ask_low, ask_close, created_at
+        FROM market_data
+        WHERE deleted_at IS NULL AND instrument = ? AND timeframe = ? AND timestamp >= ? AND timestamp < ?
+        ORDER BY timestamp
+    `, instrument, timeframe, sqliteTime(from), sqliteTime(to))
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var out []models.MarketData
+	for rows.Next() {
+		var m models.MarketData
+		var bid, ask nullOHLC
+		dest := []interface{}{&m.ID, &m.Instrument, timeText{&m.Timestamp}, &m.OpenPrice, &m.HighPrice, &m.LowPrice, &m.ClosePrice, &m.Volume, &m.Timeframe}
+		dest = append(append(dest, bid.dest()...), ask.dest()...)
+		if err := rows.Scan(append(dest, timeText{&m.CreatedAt})...); err != nil {
+			return nil, err
+		}
+		m.Bid, m.Ask = bid.value(), ask.value()
+		out = append(out, m)
+	}
+	return out, rows.Err()
+}
+
+func (s *SQLite) ListMarketData(ctx context.Context, instrument string, timeframe string, limit int) ([]models.MarketData, error) {
+	if limit <= 0 || limit > 5000 {
+		limit = 500
+	}
+	rows, err := s.DB.QueryContext(ctx, `
+        SELECT id, instrument, timestamp, open_price, high_price, low_price, close_price, volume, timeframe, created_at
+        FROM market_data
+        WHERE deleted_at IS NULL AND instrument = ? AND timeframe = ?
+        ORDER BY timestamp DESC
+        LIMIT ?
+    `, instrument, timeframe, limit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var out []models.MarketData
+	for rows.Next() {
+		var m models.MarketData
+		if err := rows.Scan(&m.ID, &m.Instrument, timeText{&m.Timestamp}, &m.OpenPrice, &m.HighPrice, &m.LowPrice, &m.ClosePrice, &m.Volume, &m.Timeframe, timeText{&m.CreatedAt}); err != nil {
+			return nil, err
+		}
+		out = append(out, m)
+	}
+	return out, rows.Err()
+}
+
+// AI tables
+
+func (s *SQLite) CreateAIRecommendation(ctx context.Context, r *models.AIRecommendation) (string, error) {
+	id := r.ID
+	if id == "" {
+		id = newID()
+	}
+	now := sqliteTime(time.Now())
+	_, err := s.DB.ExecContext(ctx, `INSERT INTO ai_recommendations (id, instrument, direction, units, confidence, rationale, stop_loss, take_profit, time_to_live, market_context, news_context, historical_context, status, approved_at, executed_trade_id, created_at, updated_at)
+              VALUES (?,?,?,?,?,?,?,?,?,?,?,?,?,?,?,?,?)`,
+		id, r.Instrument, r.Direction, r.Units, r.Confidence, r.Rationale, r.StopLoss, r.TakeProfit, sqliteTime(r.TimeToLive),
+		sqliteJSON(r.MarketContext), sqliteJSON(r.NewsContext), sqliteJSON(r.HistoricalContext), r.Status, sqliteNullTime(r.ApprovedAt), r.ExecutedTradeID, now, now)
+	if err != nil {
+		return "", err
+	}
+	_ = s.audit(ctx, "ai_recommendations", id, "CREATE", map[string]interface{}{"instrument": r.Instrument, "direction": r.Direction, "units": r.Units})
+	return id, nil
+}
+
+func (s *SQLite) UpdateAIRecommendationStatus(ctx context.Context, id string, status models.AIRecommendationStatus) error {
+	_, err := s.DB.ExecContext(ctx, `UPDATE ai_recommendations SET status=?, updated_at=? WHERE id=?`, status, sqliteTime(time.Now()), id)
+	return err
+}
+
+// MarkAIRecommendationExecuted sets status to EXECUTED and stores the executed trade id
+func (s *SQLite) MarkAIRecommendationExecuted(ctx context.Context, id string, tradeID string) error {
+	_, err := s.DB.ExecContext(ctx, `UPDATE ai_recommendations SET status='EXECUTED', executed_trade_id=?, updated_at=? WHERE id=?`, tradeID, sqliteTime(time.Now()), id)
+	if err == nil {
+		_ = s.audit(ctx, "ai_recommendations", id, "EXECUTE", map[string]interface{}{"trade_id": tradeID})
+	}
+	return err
+}
+
+// ClaimAIRecommendation sets a PENDING or APPROVED AI recommendation whose time_to_live is after
+// now to EXECUTED. It reports false when the recommendation was not claimable.
+func (s *SQLite) ClaimAIRecommendation(ctx context.Context, id string, now time.Time) (bool, error) {
+	return sqliteRowsAffected(s.DB.ExecContext(ctx, `
+        UPDATE ai_recommendations SET status='EXECUTED', updated_at=?3
+        WHERE id=?1 AND status IN ('PENDING', 'APPROVED') AND time_to_live > ?2
+    `, id, sqliteTime(now), sqliteTime(time.Now())))
+}
+
+func (s *SQLite) ListAIRecommendations(ctx context.Context, limit int) ([]models.AIRecommendation, error) {
+	if limit <= 0 || limit > 500 {
+		limit = 200
+	}
+	rows, err := s.DB.QueryContext(ctx, `SELECT id, instrument, direction, units, confidence, rationale, stop_loss, take_profit, time_to_live, market_context, news_context, historical_context, status, approved_at, executed_trade_id, created_at, updated_at FROM ai_recommendations ORDER BY created_at DESC LIMIT ?`, limit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var out []models.AIRecommendation
+	for rows.Next() {
+		var r models.AIRecommendation
+		if err := rows.Scan(&r.ID, &r.Instrument, &r.Direction, &r.Units, &r.Confidence, &r.Rationale, &r.StopLoss, &r.TakeProfit, timeText{&r.TimeToLive},
+			&r.MarketContext, &r.NewsContext, &r.HistoricalContext, &r.Status, nullTimeText{&r.ApprovedAt}, &r.ExecutedTradeID, timeText{&r.CreatedAt}, timeText{&r.UpdatedAt}); err != nil {
+			return nil, err
+		}
+		out = append(out, r)
+	}
+	return out, rows.Err()
+}
+
+// AI usage logs
+
+func (s *SQLite) CreateAIUsageLog(ctx context.Context, l *models.AIUsageLog) error {
+	_, err := s.DB.ExecContext(ctx, `INSERT INTO ai_usage_logs (id, recommendation_id, prompt_tokens, completion_tokens, cache_creation_tokens, cache_read_tokens, total_tokens, response_time_ms, claude_model, cost_usd, created_at) VALUES (?,?,?,?,?,?,?,?,?,?,?)`,
+		newID(), l.RecommendationID, l.PromptTokens, l.CompletionTokens, l.CacheCreationTokens, l.CacheReadTokens, l.TotalTokens, l.ResponseTimeMs, l.Model, l.CostUSD, sqliteTime(time.Now()))
+	return err
+}
+
+// SummarizeAIUsage aggregates usage per model for logs created at or after since.
+func (s *SQLite) SummarizeAIUsage(ctx context.Context, since time.Time) ([]models.AIUsageTotals, error) {
+	rows, err := s.DB.QueryContext(ctx, `
+        SELECT claude_model, COUNT(*), COALESCE(SUM(prompt_tokens),0), COALESCE(SUM(completion_tokens),0),
+               COALESCE(SUM(cache_creation_tokens),0), COALESCE(SUM(cache_read_tokens),0), COALESCE(SUM(total_tokens),0), COALESCE(SUM(cost_usd),0)
+        FROM ai_usage_logs
+        WHERE created_at >= ?
+        GROUP BY claude_model
+        ORDER BY claude_model
+    `, sqliteTime(since))
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var out []models.AIUsageTotals
+	for rows.Next() {
+		var t models.AIUsageTotals
+		if err := rows.Scan(&t.Model, &t.Requests, &t.PromptTokens, &t.CompletionTokens, &t.CacheCreationTokens, &t.CacheReadTokens, &t.TotalTokens, &t.CostUSD); err != nil {
+			return nil, err
+		}
+		out = append(out, t)
+	}
+	return out, rows.Err()
+}
+
+// sqliteStrings binds a text array as a JSON array.
+func sqliteStrings(v []string) (string, error) {
+	if v == nil {
+		v = []string{}
+	}
+	b, err := json.Marshal(v)
+	return string(b), err
+}
+
+// stringsText scans a text array stored with sqliteStrings.
+type stringsText struct{ dst *[]string }
+
+func (s stringsText) Scan(v interface{}) error {
+	switch x := v.(type) {
+	case nil:
+		*s.dst = nil
+		return nil
+	case string:
+		return json.Unmarshal([]byte(x), s.dst)
+	case []byte:
+		return json.Unmarshal(x, s.dst)
+	}
+	return fmt.Errorf("sqlite: cannot scan %T into []string", v)
+}
+
+// sqliteRowsAffected reports whether an Exec changed a row.
+func sqliteRowsAffected(res sql.Result, err error) (bool, error) {
+	if err != nil {
+		return false, err
+	}
+	n, err := res.RowsAffected()
+	return n > 0, err
+}
+
+// Market analysis cache
+
+func (s *SQLite) InsertMarketAnalysisCache(ctx context.Context, key string, instruments string, analysisData []byte, expiresAt time.Time) error {
+	_, err := s.DB.ExecContext(ctx, `INSERT INTO market_analysis_cache (id, cache_key, instruments, analysis_data, expires_at, created_at) VALUES (?,?,?,?,?,?)`,
+		newID(), key, instruments, string(analysisData), sqliteTime(expiresAt), sqliteTime(time.Now()))
+	return err
+}
+
+// GetMarketAnalysisCache returns the newest unexpired entry for key.
+func (s *SQLite) GetMarketAnalysisCache(ctx context.Context, key string) ([]byte, bool, error) {
+	var data []byte
+	err := s.DB.QueryRowContext(ctx, `SELECT analysis_data FROM market_analysis_cache WHERE cache_key = ? AND expires_at > ? ORDER BY created_at DESC LIMIT 1`,
+		key, sqliteTime(time.Now())).Scan(&data)
+	if err == sql.ErrNoRows {
+		return nil, false, nil
+	}
+	if err != nil {
+		return nil, false, err
+	}
+	return data, true, nil
+}
+
+func (s *SQLite) PurgeExpiredMarketAnalysisCache(ctx context.Context) (int64, error) {
+	res, err := s.DB.ExecContext(ctx, `DELETE FROM market_analysis_cache WHERE expires_at <= ?`, sqliteTime(time.Now()))
+	if err != nil {
+		return 0, err
+	}
+	return res.RowsAffected()
+}
+
+// Economic calendar
+
+func (s *SQLite) UpsertEconomicEvents(ctx context.Context, events []models.EconomicEvent) error {
+	if len(events) == 0 {
+		return nil
+	}
+	tx, err := s.DB.BeginTx(ctx, &sql.TxOptions{})
+	if err != nil {
+		return err
+	}
+	stmt, err := tx.PrepareContext(ctx, `
+        INSERT INTO economic_events (id, title, currency, impact, event_time, actual, forecast, previous, source, created_at, updated_at)
+        VALUES (?,?,?,?,?,?,?,?,?,?,?)
+        ON CONFLICT (currency, title, event_time)
+        DO UPDATE SET impact=excluded.impact, actual=excluded.actual, forecast=excluded.forecast, previous=excluded.previous, source=excluded.source, updated_at=excluded.updated_at
+    `)
+	if err != nil {
+		_ = tx.Rollback()
+		return err
+	}
+	defer stmt.Close()
+	now := sqliteTime(time.Now())
+	for _, e := range events {
+		if _, err := stmt.ExecContext(ctx, newID(), e.Title, e.Currency, e.Impact, sqliteTime(e.EventTime), e.Actual, e.Forecast, e.Previous, e.Source, now, now); err != nil {
+			_ = tx.Rollback()
+			return err
+		}
+	}
+	return tx.Commit()
+}
+
+// ListEconomicEvents returns events in [from, to], optionally restricted to currencies, oldest first.
+func (s *SQLite) ListEconomicEvents(ctx context.Context, from, to time.Time, currencies []string) ([]models.EconomicEvent, error) {
+	filter, err := sqliteStrings(currencies)
+	if err != nil {
+		return nil, err
+	}
+	rows, err := s.DB.QueryContext(ctx, `
+        SELECT id, title, currency, impact, event_time, actual, forecast, previous, source, created_at
+        FROM economic_events
+        WHERE event_time BETWEEN ?1 AND ?2 AND (json_array_length(?3) = 0 OR currency IN (SELECT value FROM json_each(?3)))
+        ORDER BY event_time
+        LIMIT 1000
+    `, sqliteTime(from), sqliteTime(to), filter)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var out []models.EconomicEvent
+	for rows.Next() {
+		var e models.EconomicEvent
+		if err := rows.Scan(&e.ID, &e.Title, &e.Currency, &e.Impact, timeText{&e.EventTime}, &e.Actual, &e.Forecast, &e.Previous, &e.Source, timeText{&e.CreatedAt}); err != nil {
+			return nil, err
+		}
+		out = append(out, e)
+	}
+	return out, rows.Err()
+}
+
+// Account guardian
+
+// GetGuardianState returns nil when the account has no stored state yet.
+func (s *SQLite) GetGuardianState(ctx context.Context, accountID string) (*models.GuardianState, error) {
+	var g models.GuardianState
+	err := s.DB.QueryRowContext(ctx, `
+        SELECT account_id, trading_day, start_of_day_nav, start_of_day_balance, start_of_day_pl, peak_nav, peak_pl, halted, halt_reason, halted_at, reset_by, reset_at, updated_at
+        FROM account_guardian_state WHERE account_id = ?
+    `, accountID).Scan(&g.AccountID, timeText{&g.TradingDay}, &g.StartOfDayNAV, &g.StartOfDayBalance, &g.StartOfDayPL, &g.PeakNAV, &g.PeakPL, &g.Halted, &g.HaltReason,
+		nullTimeText{&g.HaltedAt}, &g.ResetBy, nullTimeText{&g.ResetAt}, timeText{&g.UpdatedAt})
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &g, nil
+}
+
+func (s *SQLite) SaveGuardianState(ctx context.Context, g *models.GuardianState) error {
+	_, err := s.DB.ExecContext(ctx, `
+        INSERT INTO account_guardian_state (account_id, trading_day, start_of_day_nav, start_of_day_balance, start_of_day_pl, peak_nav, peak_pl, halted, halt_reason, halted_at, reset_by, reset_at, updated_at)
+        VALUES (?,?,?,?,?,?,?,?,?,?,?,?,?)
+        ON CONFLICT (account_id)
+        DO UPDATE SET trading_day=excluded.trading_day, start_of_day_nav=excluded.start_of_day_nav, start_of_day_balance=excluded.start_of_day_balance,
+            start_of_day_pl=excluded.start_of_day_pl, peak_nav=excluded.peak_nav, peak_pl=excluded.peak_pl, halted=excluded.halted,
+            halt_reason=excluded.halt_reason, halted_at=excluded.halted_at, reset_by=excluded.reset_by, reset_at=excluded.reset_at, updated_at=excluded.updated_at
+    `, g.AccountID, sqliteTime(dateOf(g.TradingDay)), g.StartOfDayNAV, g.StartOfDayBalance, g.StartOfDayPL, g.PeakNAV, g.PeakPL, g.Halted, g.HaltReason,
+		sqliteNullTime(g.HaltedAt), g.ResetBy, sqliteNullTime(g.ResetAt), sqliteTime(time.Now()))
+	return err
+}
+
+// Queued orders
+
+func (s *SQLite) CreateQueuedOrder(ctx context.Context, o *models.QueuedOrder) error {
+	id, now := newID(), time.Now()
+	_, err := s.DB.ExecContext(ctx, `INSERT INTO queued_orders (id, instrument, units, stop_loss, take_profit, source, status, created_at) VALUES (?,?,?,?,?,?,?,?)`,
+		id, o.Instrument, o.Units, o.StopLoss, o.TakeProfit, o.Source, models.QueuedOrderPending, sqliteTime(now))
+	if err != nil {
+		return err
+	}
+	o.ID, o.Status, o.CreatedAt = id, models.QueuedOrderPending, now.UTC()
+	return nil
+}
+
+// ListQueuedOrders returns orders oldest first; an empty status lists all.
+func (s *SQLite) ListQueuedOrders(ctx context.Context, status models.QueuedOrderStatus, limit int) ([]models.QueuedOrder, error) {
+	if limit <= 0 || limit > 1000 {
+		limit = 200
+	}
+	rows, err := s.DB.QueryContext(ctx, `
+        SELECT id, instrument, units, stop_loss, take_profit, source, status, error, oanda_order_id, created_at, submitted_at
+        FROM queued_orders
+        WHERE (?1 = '' OR status = ?1)
+        ORDER BY created_at
+        LIMIT ?2
+    `, string(status), limit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var out []models.QueuedOrder
+	for rows.Next() {
+		var o models.QueuedOrder
+		if err := rows.Scan(&o.ID, &o.Instrument, &o.Units, &o.StopLoss, &o.TakeProfit, &o.Source, &o.Status, &o.Error, &o.OandaOrderID,
+			timeText{&o.CreatedAt}, nullTimeText{&o.SubmittedAt}); err != nil {
+			return nil, err
+		}
+		out = append(out, o)
+	}
+	return out, rows.Err()
+}
+
+// TransitionQueuedOrder moves an order from one status to another and records the broker order
+// ID and error when given. It reports false when the order was not in the from status.
+func (s *SQLite) TransitionQueuedOrder(ctx context.Context, id string, from, to models.QueuedOrderStatus, oandaOrderID, errMsg *string) (bool, error) {
+	return sqliteRowsAffected(s.DB.ExecContext(ctx, `
+        UPDATE queued_orders
+        SET status=?3, oanda_order_id=COALESCE(?4, oanda_order_id), error=COALESCE(?5, error),
+            submitted_at=CASE WHEN ?3='SUBMITTED' THEN COALESCE(submitted_at, ?6) ELSE submitted_at END
+        WHERE id=?1 AND status=?2
+    `, id, string(from), string(to), oandaOrderID, errMsg, sqliteTime(time.Now())))
+}
+
+// Alerts
+
+func scanSQLiteAlertRule(row interface{ Scan(...interface{}) error }) (*models.AlertRule, error) {
+	var r models.AlertRule
+	err := row.Scan(&r.ID, &r.Name, &r.Instrument, &r.Kind, &r.Condition, &r.Threshold, &r.Indicator, &r.Period, &r.Timeframe, &r.WindowSeconds,
+		&r.Repeat, &r.CooldownSeconds, &r.Active, &r.FireCount, nullTimeText{&r.LastFiredAt}, timeText{&r.CreatedAt}, timeText{&r.UpdatedAt})
+	if err != nil {
+		return nil, err
+	}
+	return &r, nil
+}
+
+func (s *SQLite) CreateAlertRule(ctx context.Context, r *models.AlertRule) error {
+	id, now := newID(), time.Now().UTC()
+	_, err := s.DB.ExecContext(ctx, `
+        INSERT INTO alert_rules (id, name, instrument, kind, condition, threshold, indicator, period, timeframe, window_seconds, repeat, cooldown_seconds, active, created_at, updated_at)
+        VALUES (?,?,?,?,?,?,?,?,?,?,?,?,?,?,?)
+    `, id, r.Name, r.Instrument, string(r.Kind), string(r.Condition), r.Threshold, r.Indicator, r.Period, r.Timeframe, r.WindowSeconds, r.Repeat, r.CooldownSeconds, r.Active,
+		sqliteTime(now), sqliteTime(now))
+	if err != nil {
+		return err
+	}
+	r.ID, r.FireCount, r.LastFiredAt, r.CreatedAt, r.UpdatedAt = id, 0, nil, now, now
+	return nil
+}
+
+// GetAlertRule returns nil when the rule does not exist or was deleted.
+func (s *SQLite) GetAlertRule(ctx context.Context, id string) (*models.AlertRule, error) {
+	r, err := scanSQLiteAlertRule(s.DB.QueryRowContext(ctx, `SELECT `+alertRuleColumns+` FROM alert_rules WHERE id=? AND deleted_at IS NULL`, id))
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	return r, err
+}
+
+func (s *SQLite) ListAlertRules(ctx context.Context, activeOnly bool) ([]models.AlertRule, error) {
+	rows, err := s.DB.QueryContext(ctx, `
+        SELECT `+alertRuleColumns+`
+        FROM alert_rules
+        WHERE deleted_at IS NULL AND (NOT ? OR active)
+        ORDER BY created_at
+    `, activeOnly)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var out []models.AlertRule
+	for rows.Next() {
+		r, err := scanSQLiteAlertRule(rows)
+		if err != nil {
+			return nil, err
+		}
+		out = append(out, *r)
+	}
+	return out, rows.Err()
+}
+
+// UpdateAlertRule replaces the editable fields of a rule; it reports false when no such rule exists.
+func (s *SQLite) UpdateAlertRule(ctx context.Context, r *models.AlertRule) (bool, error) {
+	err := s.DB.QueryRowContext(ctx, `
+        UPDATE alert_rules
+        SET name=?2, instrument=?3, kind=?4, condition=?5, threshold=?6, indicator=?7, period=?8, timeframe=?9,
+            window_seconds=?10, repeat=?11, cooldown_seconds=?12, active=?13, updated_at=?14
+        WHERE id=?1 AND deleted_at IS NULL
+        RETURNING fire_count, last_fired_at, created_at, updated_at
+    `, r.ID, r.Name, r.Instrument, string(r.Kind), string(r.Condition), r.Threshold, r.Indicator, r.Period, r.Timeframe,
+		r.WindowSeconds, r.Repeat, r.CooldownSeconds, r.Active, sqliteTime(time.Now())).
+		Scan(&r.FireCount, nullTimeText{&r.LastFiredAt}, timeText{&r.CreatedAt}, timeText{&r.UpdatedAt})
+	if err == sql.ErrNoRows {
+		return false, nil
+	}
+	return err == nil, err
+}
+
+func (s *SQLite) SoftDeleteAlertRule(ctx context.Context, id string) (bool, error) {
+	return sqliteRowsAffected(s.DB.ExecContext(ctx, `UPDATE alert_rules SET deleted_at=?, active=FALSE WHERE id=? AND deleted_at IS NULL`, sqliteTime(time.Now()), id))
+}
+
+// RecordAlertFired stores an event and bumps the rule's fire count, deactivating one-shot rules.
+func (s *SQLite) RecordAlertFired(ctx context.Context, e *models.AlertEvent, deactivate bool) error {
+	tx, err := s.DB.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer func() { _ = tx.Rollback() }()
+	id := newID()
+	if _, err := tx.ExecContext(ctx, `INSERT INTO alert_events (id, rule_id, instrument, value, message, fired_at) VALUES (?,?,?,?,?,?)`,
+		id, e.RuleID, e.Instrument, e.Value, e.Message, sqliteTime(e.FiredAt)); err != nil {
+		return err
+	}
+	if _, err := tx.ExecContext(ctx, `
+        UPDATE alert_rules
+        SET fire_count=fire_count+1, last_fired_at=?2, active=CASE WHEN ?3 THEN FALSE ELSE active END, updated_at=?4
+        WHERE id=?1
+    `, e.RuleID, sqliteTime(e.FiredAt), deactivate, sqliteTime(time.Now())); err != nil {
+		return err
+	}
+	if err := tx.Commit(); err != nil {
+		return err
+	}
+	e.ID = id
+	return nil
+}
+
+// ListAlertEvents returns firings newest first; an empty ruleID lists all rules.
+func (s *SQLite) ListAlertEvents(ctx context.Context, ruleID string, limit int) ([]models.AlertEvent, error) {
+	if limit <= 0 || limit > 1000 {
+		limit = 100
+	}
+	rows, err := s.DB.QueryContext(ctx, `
+        SELECT id, rule_id, instrument, value, message, fired_at
+        FROM alert_events
+        WHERE (?1 = '' OR rule_id = ?1)
+        ORDER BY fired_at DESC
+        LIMIT ?2
+    `, ruleID, limit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var out []models.AlertEvent
+	for rows.Next() {
+		var e models.AlertEvent
+		if err := rows.Scan(&e.ID, &e.RuleID, &e.Instrument, &e.Value, &e.Message, timeText{&e.FiredAt}); err != nil {
+			return nil, err
+		}
+		out = append(out, e)
+	}
+	return out, rows.Err()
+}
+
+// Notifications
+
+func (s *SQLite) LogNotificationDelivery(ctx context.Context, d *models.NotificationDelivery) error {
+	id, now := newID(), time.Now().UTC()
+	_, err := s.DB.ExecContext(ctx, `
+        INSERT INTO notification_deliveries (id, event_id, event_type, channel, status, attempts, error, payload, created_at)
+        VALUES (?,?,?,?,?,?,?,?,?)
+    `, id, d.EventID, d.EventType, d.Channel, string(d.Status), d.Attempts, d.Error, sqliteJSON(d.Payload), sqliteTime(now))
+	if err != nil {
+		return err
+	}
+	d.ID, d.CreatedAt = id, now
+	return nil
+}
+
+// ListNotificationDeliveries returns deliveries newest first; an empty status lists all.
+func (s *SQLite) ListNotificationDeliveries(ctx context.Context, status models.NotificationStatus, limit int) ([]models.NotificationDelivery, error) {
+	if limit <= 0 || limit > 1000 {
+		limit = 100
+	}
+	rows, err := s.DB.QueryContext(ctx, `
+        SELECT id, event_id, event_type, channel, status, attempts, error, payload, created_at
+        FROM notification_deliveries
+        WHERE (?1 = '' OR status = ?1)
+        ORDER BY created_at DESC
+        LIMIT ?2
+    `, string(status), limit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var out []models.NotificationDelivery
+	for rows.Next() {
+		var d models.NotificationDelivery
+		var payload []byte
+		if err := rows.Scan(&d.ID, &d.EventID, &d.EventType, &d.Channel, &d.Status, &d.Attempts, &d.Error, &payload, timeText{&d.CreatedAt}); err != nil {
+			return nil, err
+		}
+		d.Payload = payload
+		out = append(out, d)
+	}
+	return out, rows.Err()
+}
+
+// Optimization runs
+
+func (s *SQLite) CreateOptimizationRun(ctx context.Context, r *models.OptimizationRun) error {
+	instruments, err := sqliteStrings(r.Instruments)
+	if err != nil {
+		return err
+	}
+	id, now := newID(), time.Now().UTC()
+	_, err = s.DB.ExecContext(ctx, `
+        INSERT INTO optimization_runs (id, kind, strategy, instruments, timeframe, range_from, range_to, config, status, created_at)
+        VALUES (?,?,?,?,?,?,?,?,?,?)
+    `, id, string(r.Kind), r.Strategy, instruments, r.Timeframe, sqliteTime(r.From), sqliteTime(r.To), sqliteJSON(r.Config), models.OptimizationRunning, sqliteTime(now))
+	if err != nil {
+		return err
+	}
+	r.ID, r.Status, r.CreatedAt = id, models.OptimizationRunning, now
+	return nil
+}
+
+// CompleteOptimizationRun records the outcome of a run; errMsg is set for failed runs.
+func (s *SQLite) CompleteOptimizationRun(ctx context.Context, id string, status models.OptimizationStatus, metrics, result, equity []byte, errMsg *string) error {
+	_, err := s.DB.ExecContext(ctx, `
+        UPDATE optimization_runs
+        SET status=?, metrics=?, result=?, equity=?, error=?, completed_at=?
+        WHERE id=?
+    `, string(status), sqliteJSON(metrics), sqliteJSON(result), sqliteJSON(equity), errMsg, sqliteTime(time.Now()), id)
+	return err
+}
+
+// GetOptimizationRun returns nil when the run does not exist.
+func (s *SQLite) GetOptimizationRun(ctx context.Context, id string) (*models.OptimizationRun, error) {
+	var r models.OptimizationRun
+	var config, metrics, result, equity []byte
+	err := s.DB.QueryRowContext(ctx, `
+        SELECT id, kind, strategy, instruments, timeframe, range_from, range_to, config, status, metrics, result, equity, error, created_at, completed_at
+        FROM optimization_runs
+        WHERE id=?
+    `, id).Scan(&r.ID, &r.Kind, &r.Strategy, stringsText{&r.Instruments}, &r.Timeframe, timeText{&r.From}, timeText{&r.To}, &config, &r.Status,
+		&metrics, &result, &equity, &r.Error, timeText{&r.CreatedAt}, nullTimeText{&r.CompletedAt})
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	r.Config, r.Metrics, r.Result, r.Equity = config, metrics, result, equity
+	return &r, nil
+}
+
+// ListOptimizationRuns returns runs newest first without their results and equity curves.
+func (s *SQLite) ListOptimizationRuns(ctx context.Context, limit int) ([]models.OptimizationRun, error) {
+	if limit <= 0 || limit > 1000 {
+		limit = 100
+	}
+	rows, err := s.DB.QueryContext(ctx, `
+        SELECT id, kind, strategy, instruments, timeframe, range_from, range_to, config, status, metrics, error, created_at, completed_at
+        FROM optimization_runs
+        ORDER BY created_at DESC
+        LIMIT ?
+    `, limit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var out []models.OptimizationRun
+	for rows.Next() {
+		var r models.OptimizationRun
+		var config, metrics []byte
+		if err := rows.Scan(&r.ID, &r.Kind, &r.Strategy, stringsText{&r.Instruments}, &r.Timeframe, timeText{&r.From}, timeText{&r.To}, &config, &r.Status,
+			&metrics, &r.Error, timeText{&r.CreatedAt}, nullTimeText{&r.CompletedAt}); err != nil {
+			return nil, err
+		}
+		r.Config, r.Metrics = config, metrics
+		out = append(out, r)
+	}
+	return out, rows.Err()
+}
+
+// News archive
+
+// ArchiveNews stores articles not seen before; an article keeps the score it had when first seen.
+func (s *SQLite) ArchiveNews(ctx context.Context, articles []models.NewsArticle) error {
+	if len(articles) == 0 {
+		return nil
+	}
+	tx, err := s.DB.BeginTx(ctx, &sql.TxOptions{})
+	if err != nil {
+		return err
+	}
+	stmt, err := tx.PrepareContext(ctx, `
+        INSERT INTO news_articles (id, url, title, snippet, source, published_at, first_seen_at, sentiment, relevance, currencies)
+        VALUES (?,?,?,?,?,?,?,?,?,?)
+        ON CONFLICT (url) DO NOTHING
+    `)
+	if err != nil {
+		_ = tx.Rollback()
+		return err
+	}
+	defer stmt.Close()
+	now := sqliteTime(time.Now())
+	for _, a := range articles {
+		relevance, err := json.Marshal(a.Relevance)
+		if err != nil {
+			_ = tx.Rollback()
+			return err
+		}
+		currencies, err := sqliteStrings(a.Currencies)
+		if err != nil {
+			_ = tx.Rollback()
+			return err
+		}
+		if _, err := stmt.ExecContext(ctx, newID(), a.URL, a.Title, a.Snippet, a.Source, sqliteNullTime(a.PublishedAt), now, a.Sentiment, string(relevance), currencies); err != nil {
+			_ = tx.Rollback()
+			return err
+		}
+	}
+	return tx.Commit()
+}
+
+// ListNewsArticles returns articles available in [from, to], newest first, optionally
+// restricted to those relevant to one of currencies.
+func (s *SQLite) ListNewsArticles(ctx context.Context, from, to time.Time, currencies []string, limit int) ([]models.NewsArticle, error) {
+	if limit <= 0 || limit > 1000 {
+		limit = 100
+	}
+	filter, err := sqliteStrings(currencies)
+	if err != nil {
+		return nil, err
+	}
+	rows, err := s.DB.QueryContext(ctx, `
+        SELECT id, url, title, snippet, source, published_at, first_seen_at, sentiment, relevance, currencies
+        FROM news_articles
+        WHERE COALESCE(published_at, first_seen_at) BETWEEN ?1 AND ?2
+          AND (json_array_length(?3) = 0 OR EXISTS (SELECT 1 FROM json_each(currencies) c WHERE c.value IN (SELECT value FROM json_each(?3))))
+        ORDER BY COALESCE(published_at, first_seen_at) DESC
+        LIMIT ?4
+    `, sqliteTime(from), sqliteTime(to), filter, limit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var out []models.NewsArticle
+	for rows.Next() {
+		var a models.NewsArticle
+		var relevance []byte
+		if err := rows.Scan(&a.ID, &a.URL, &a.Title, &a.Snippet, &a.Source, nullTimeText{&a.PublishedAt}, timeText{&a.FirstSeenAt}, &a.Sentiment,
+			&relevance, stringsText{&a.Currencies}); err != nil {
+			return nil, err
+		}
+		if len(relevance) > 0 {
+			if err := json.Unmarshal(relevance, &a.Relevance); err != nil {
+				return nil, err
+			}
+		}
+		out = append(out, a)
+	}
+	return out, rows.Err()
+}
+
+// Account snapshots
+
+func (s *SQLite) CreateAccountSnapshot(ctx context.Context, a *models.AccountSnapshot) error {
+	id := newID()
+	_, err := s.DB.ExecContext(ctx, `
+        INSERT INTO account_snapshots (id, account_id, currency, balance, nav, unrealized_pl, margin_used, margin_available, open_trade_count, taken_at)
+        VALUES (?,?,?,?,?,?,?,?,?,?)
+    `, id, a.AccountID, a.Currency, a.Balance, a.NAV, a.UnrealizedPL, a.MarginUsed, a.MarginAvailable, a.OpenTradeCount, sqliteTime(a.TakenAt))
+	if err == nil {
+		a.ID = id
+	}
+	return err
+}
+
+// ListAccountSnapshots returns snapshots taken in [from, to), oldest first, for one account or
+// for all when accountID is empty. A positive resolution keeps only the last snapshot of each
+// interval, with intervals aligned to the Unix epoch; the thinning runs in Go.
+func (s *SQLite) ListAccountSnapshots(ctx context.Context, accountID string, from, to time.Time, resolution time.Duration, limit int) ([]models.AccountSnapshot, error) {
+	if limit <= 0 || limit > 10000 {
+		limit = 1000
+	}
+	query := `SELECT id, account_id, currency, balance, nav, unrealized_pl, margin_used, margin_available, open_trade_count, taken_at
+        FROM account_snapshots
+        WHERE taken_at >= ?1 AND taken_at < ?2 AND (?3 = '' OR account_id = ?3)
+        ORDER BY taken_at`
+	args := []interface{}{sqliteTime(from), sqliteTime(to), accountID}
+	if resolution <= 0 {
+		query += ` LIMIT ?4`
+		args = append(args, limit)
+	}
+	rows, err := s.DB.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var out []models.AccountSnapshot
+	for rows.Next() {
+		var a models.AccountSnapshot
+		if err := rows.Scan(&a.ID, &a.AccountID, &a.Currency, &a.Balance, &a.NAV, &a.UnrealizedPL, &a.MarginUsed, &a.MarginAvailable, &a.OpenTradeCount, timeText{&a.TakenAt}); err != nil {
+			return nil, err
+		}
+		out = append(out, a)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	if resolution > 0 {
+		out = lastPerInterval(out, resolution)
+	}
+	if len(out) > limit {
+		out = out[:limit]
+	}
+	return out, nil
+}
+
+// DeleteAccountSnapshotsBefore removes snapshots taken before the cutoff and returns how many.
+func (s *SQLite) DeleteAccountSnapshotsBefore(ctx context.Context, before time.Time) (int64, error) {
+	res, err := s.DB.ExecContext(ctx, `DELETE FROM account_snapshots WHERE taken_at < ?`, sqliteTime(before))
+	if err != nil {
+		return 0, err
+	}
+	return res.RowsAffected()
+}
+
+// Export
+
+// StreamRows runs a query and hands each row to fn as it is read, so large results are never
+// held in memory. The values slice is reused between rows. Time arguments are bound as stored
+// by sqliteTime.
+func (s *SQLite) StreamRows(ctx context.Context, query string, args []interface{}, fn func(values []interface{}) error) error {
+	bound := make([]interface{}, len(args))
+	for i, a := range args {
+		switch v := a.(type) {
+		case time.Time:
+			bound[i] = sqliteTime(v)
+		case *time.Time:
+			bound[i] = sqliteNullTime(v)
+		default:
+			bound[i] = a
+		}
+	}
+	rows, err := s.DB.QueryContext(ctx, query, bound...)
+	if err != nil {
+		return err
+	}
+	defer rows.Close()
+	cols, err := rows.Columns()
+	if err != nil {
+		return err
+	}
+	values := make([]interface{}, len(cols))
+	ptrs := make([]interface{}, len(cols))
+	for i := range values {
+		ptrs[i] = &values[i]
+	}
+	for rows.Next() {
+		if err := rows.Scan(ptrs...); err != nil {
+			return err
+		}
+		if err := fn(values); err != nil {
+			return err
+		}
+	}
+	return rows.Err()
+}
+
+// Ticks
+
+// EnsureTickPartitions does nothing; SQLite keeps ticks in one table.
+func (s *SQLite) EnsureTickPartitions(ctx context.Context, from, to time.Time) error { return nil }
+
+// DropTickPartitionsBefore deletes the ticks of the UTC days that end at or before the cutoff
+// and returns how many days had ticks.
+func (s *SQLite) DropTickPartitionsBefore(ctx context.Context, before time.Time) (int, error) {
+	cutoff := sqliteTime(before.UTC().Truncate(24 * time.Hour))
+	tx, err := s.DB.BeginTx(ctx, nil)
+	if err != nil {
+		return 0, err
+	}
+	defer func() { _ = tx.Rollback() }()
+	var days int
+	if err := tx.QueryRowContext(ctx, `SELECT COUNT(DISTINCT substr(time, 1, 10)) FROM ticks WHERE time < ?`, cutoff).Scan(&days); err != nil {
+		return 0, err
+	}
+	if _, err := tx.ExecContext(ctx, `DELETE FROM ticks WHERE time < ?`, cutoff); err != nil {
+		return 0, err
+	}
+	return days, tx.Commit()
+}
+
+// InsertTicks stores ticks in one transaction, skipping ones already stored, and returns how
+// many were new.
+func (s *SQLite) InsertTicks(ctx context.Context, ticks []models.Tick) (int64, error) {
+	if len(ticks) == 0 {
+		return 0, nil
+	}
+	tx, err := s.DB.BeginTx(ctx, &sql.TxOptions{})
+	if err != nil {
+		return 0, err
+	}
+	stmt, err := tx.PrepareContext(ctx, `INSERT INTO ticks (instrument, time, bid, ask) VALUES (?,?,?,?) ON CONFLICT (instrument, time) DO NOTHING`)
+	if err != nil {
+		_ = tx.Rollback()
+		return 0, err
+	}
+	defer stmt.Close()
+	var n int64
+	for _, t := range ticks {
+		res, err := stmt.ExecContext(ctx, t.Instrument, sqliteTime(t.Time), t.Bid, t.Ask)
+		if err != nil {
+			_ = tx.Rollback()
+			return 0, err
+		}
+		affected, _ := res.RowsAffected()
+		n += affected
+	}
+	if err := tx.Commit(); err != nil {
+		return 0, err
+	}
+	return n, nil
+}
+
+func (s *SQLite) queryTicks(ctx context.Context, instrument string, from, to time.Time, limit int) ([]models.Tick, error) {
+	rows, err := s.DB.QueryContext(ctx, `
+        SELECT instrument, time, bid, ask FROM ticks
+        WHERE instrument = ? AND time >= ? AND time < ?
+        ORDER BY time LIMIT ?
+    `, instrument, sqliteTime(from), sqliteTime(to), limit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var out []models.Tick
+	for rows.Next() {
+		var t models.Tick
+		if err := rows.Scan(&t.Instrument, timeText{&t.Time}, &t.Bid, &t.Ask); err != nil {
+			return nil, err
+		}
+		out = append(out, t)
+	}
+	return out, rows.Err()
+}
+
+// ListTicks returns an instrument's ticks with from <= time < to, oldest first. limit defaults to
+// 10000 and is capped at 100000.
+func (s *SQLite) ListTicks(ctx context.Context, instrument string, from, to time.Time, limit int) ([]models.Tick, error) {
+	if limit <= 0 || limit > 100000 {
+		limit = 10000
+	}
+	return s.queryTicks(ctx, instrument, from, to, limit)
+}
+
+// TickSpreadStats summarizes an instrument's spreads over [from, to) in intervals aligned to the
+// Unix epoch, oldest first. SQLite has no percentile aggregate, so the ticks are summarized in Go.
+func (s *SQLite) TickSpreadStats(ctx context.Context, instrument string, from, to time.Time, interval time.Duration) ([]models.SpreadStats, error) {
+	ticks, err := s.queryTicks(ctx, instrument, from, to, -1)
+	if err != nil {
+		return nil, err
+	}
+	return spreadStats(ticks, interval), nil
+}
@@ -0,0 +1,4 @@
+package database
+
+// The pure-Go SQLite driver registers itself as "sqlite"; it needs no cgo.
+import _ "modernc.org/sqlite"
@@ -0,0 +1,105 @@
+-- SQLite schema for the repository tables; mirrors scripts/migrations 0001-0005, 0012 and 0015.
+-- UUIDs are generated by the application, times are fixed-width UTC text (see sqliteTime) and
+-- JSON columns are TEXT.
+
+CREATE TABLE IF NOT EXISTS trades (
+    id TEXT PRIMARY KEY,
+    instrument TEXT NOT NULL,
+    direction TEXT NOT NULL CHECK (direction IN ('BUY','SELL')),
+    units REAL NOT NULL,
+    entry_price REAL,
+    exit_price REAL,
+    profit_loss REAL,
+    commission REAL,
+    swap REAL,
+    status TEXT NOT NULL DEFAULT 'OPEN',
+    oanda_trade_id TEXT,
+    source TEXT NOT NULL DEFAULT 'manual',
+    created_at TEXT NOT NULL,
+    updated_at TEXT NOT NULL,
+    closed_at TEXT,
+    deleted_at TEXT
+);
+
+CREATE TABLE IF NOT EXISTS recommendations (
+    id TEXT PRIMARY KEY,
+    instrument TEXT NOT NULL,
+    direction TEXT NOT NULL CHECK (direction IN ('BUY','SELL')),
+    units REAL NOT NULL,
+    rationale TEXT,
+    confidence_score REAL,
+    market_conditions TEXT CHECK (market_conditions IS NULL OR json_valid(market_conditions)),
+    status TEXT NOT NULL DEFAULT 'PENDING',
+    trade_id TEXT,
+    created_at TEXT NOT NULL,
+    executed_at TEXT,
+    deleted_at TEXT
+);
+
+CREATE TABLE IF NOT EXISTS market_data (
+    id TEXT PRIMARY KEY,
+    instrument TEXT NOT NULL,
+    timestamp TEXT NOT NULL,
+    open_price REAL NOT NULL,
+    high_price REAL NOT NULL,
+    low_price REAL NOT NULL,
+    close_price REAL NOT NULL,
+    volume INTEGER,
+    timeframe TEXT NOT NULL,
+    bid_open REAL, bid_high REAL, bid_low REAL, bid_close REAL,
+    ask_open REAL, ask_high REAL, ask_low REAL, ask_close REAL,
+    created_at TEXT NOT NULL,
+    deleted_at TEXT,
+    UNIQUE(instrument, timestamp, timeframe)
+);
+
+CREATE TABLE IF NOT EXISTS audit_logs (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    entity TEXT NOT NULL,
+    entity_id TEXT,
+    action TEXT NOT NULL,
+    details TEXT CHECK (details IS NULL OR json_valid(details)),
+    created_at TEXT NOT NULL
+);
+
+CREATE TABLE IF NOT EXISTS ai_recommendations (
+    id TEXT PRIMARY KEY,
+    instrument TEXT NOT NULL,
+    direction TEXT NOT NULL CHECK (direction IN ('BUY', 'SELL')),
+    units REAL NOT NULL,
+    confidence REAL NOT NULL,
+    rationale TEXT NOT NULL,
+    stop_loss REAL,
+    take_profit REAL,
+    time_to_live TEXT NOT NULL,
+    market_context TEXT NOT NULL CHECK (json_valid(market_context)),
+    news_context TEXT CHECK (news_context IS NULL OR json_valid(news_context)),
+    historical_context TEXT CHECK (historical_context IS NULL OR json_valid(historical_context)),
+    status TEXT NOT NULL DEFAULT 'PENDING',
+    approved_at TEXT,
+    executed_trade_id TEXT,
+    created_at TEXT NOT NULL,
+    updated_at TEXT NOT NULL
+);
+
+CREATE TABLE IF NOT EXISTS ai_usage_logs (
+    id TEXT PRIMARY KEY,
+    recommendation_id TEXT REFERENCES ai_recommendations(id),
+    prompt_tokens INTEGER NOT NULL,
+    completion_tokens INTEGER NOT NULL,
+    cache_creation_tokens INTEGER NOT NULL DEFAULT 0,
+    cache_read_tokens INTEGER NOT NULL DEFAULT 0,
+    total_tokens INTEGER NOT NULL,
+    response_time_ms INTEGER NOT NULL,
+    claude_model TEXT NOT NULL,
+    cost_usd REAL NOT NULL DEFAULT 0,
+    created_at TEXT NOT NULL
+);
+
+CREATE INDEX IF NOT EXISTS idx_trades_instrument ON trades(instrument);
+CREATE INDEX IF NOT EXISTS idx_trades_oanda_trade_id ON trades(oanda_trade_id);
+CREATE INDEX IF NOT EXISTS idx_trades_status_closed ON trades(status, closed_at);
+CREATE INDEX IF NOT EXISTS idx_recs_instrument ON recommendations(instrument);
+CREATE INDEX IF NOT EXISTS idx_market_data_instrument_timeframe_ts ON market_data(instrument, timeframe, timestamp);
+CREATE INDEX IF NOT EXISTS idx_audit_entity ON audit_logs(entity, created_at DESC);
+CREATE INDEX IF NOT EXISTS idx_ai_usage_created ON ai_usage_logs(created_at DESC);
@@ -0,0 +1,157 @@
+-- SQLite schema for the feature tables; mirrors scripts/migrations 0004 and 0006-0017. Text
+-- arrays are stored as JSON arrays and the Postgres tick partitions become one table.
+
+CREATE TABLE IF NOT EXISTS market_analysis_cache (
+    id TEXT PRIMARY KEY,
+    cache_key TEXT NOT NULL,
+    instruments TEXT NOT NULL,
+    analysis_data TEXT NOT NULL CHECK (json_valid(analysis_data)),
+    expires_at TEXT NOT NULL,
+    created_at TEXT NOT NULL
+);
+
+CREATE TABLE IF NOT EXISTS economic_events (
+    id TEXT PRIMARY KEY,
+    title TEXT NOT NULL,
+    currency TEXT NOT NULL,
+    impact TEXT NOT NULL CHECK (impact IN ('LOW','MEDIUM','HIGH')),
+    event_time TEXT NOT NULL,
+    actual TEXT,
+    forecast TEXT,
+    previous TEXT,
+    source TEXT NOT NULL,
+    created_at TEXT NOT NULL,
+    updated_at TEXT NOT NULL,
+    UNIQUE(currency, title, event_time)
+);
+
+CREATE TABLE IF NOT EXISTS account_guardian_state (
+    account_id TEXT PRIMARY KEY,
+    trading_day TEXT NOT NULL,
+    start_of_day_nav REAL NOT NULL,
+    start_of_day_balance REAL NOT NULL,
+    peak_nav REAL NOT NULL,
+    halted INTEGER NOT NULL DEFAULT 0,
+    halt_reason TEXT,
+    halted_at TEXT,
+    reset_by TEXT,
+    reset_at TEXT,
+    updated_at TEXT NOT NULL
+);
+
+CREATE TABLE IF NOT EXISTS queued_orders (
+    id TEXT PRIMARY KEY,
+    instrument TEXT NOT NULL,
+    units REAL NOT NULL,
+    stop_loss REAL,
+    take_profit REAL,
+    source TEXT NOT NULL,
+    status TEXT NOT NULL DEFAULT 'QUEUED' CHECK (status IN ('QUEUED','SUBMITTED','REJECTED','CANCELLED')),
+    error TEXT,
+    oanda_order_id TEXT,
+    created_at TEXT NOT NULL,
+    submitted_at TEXT
+);
+
+CREATE TABLE IF NOT EXISTS alert_rules (
+    id TEXT PRIMARY KEY,
+    name TEXT NOT NULL DEFAULT '',
+    instrument TEXT NOT NULL,
+    kind TEXT NOT NULL CHECK (kind IN ('PRICE','PERCENT_MOVE','SPREAD','INDICATOR')),
+    condition TEXT NOT NULL CHECK (condition IN ('ABOVE','BELOW','CROSS_ABOVE','CROSS_BELOW')),
+    threshold REAL NOT NULL,
+    indicator TEXT,
+    period INTEGER,
+    timeframe TEXT,
+    window_seconds INTEGER NOT NULL DEFAULT 0,
+    repeat INTEGER NOT NULL DEFAULT 0,
+    cooldown_seconds INTEGER NOT NULL DEFAULT 0,
+    active INTEGER NOT NULL DEFAULT 1,
+    fire_count INTEGER NOT NULL DEFAULT 0,
+    last_fired_at TEXT,
+    created_at TEXT NOT NULL,
+    updated_at TEXT NOT NULL,
+    deleted_at TEXT
+);
+
+CREATE TABLE IF NOT EXISTS alert_events (
+    id TEXT PRIMARY KEY,
+    rule_id TEXT NOT NULL REFERENCES alert_rules(id),
+    instrument TEXT NOT NULL,
+    value REAL NOT NULL,
+    message TEXT NOT NULL,
+    fired_at TEXT NOT NULL
+);
+
+CREATE TABLE IF NOT EXISTS notification_deliveries (
+    id TEXT PRIMARY KEY,
+    event_id TEXT NOT NULL,
+    event_type TEXT NOT NULL,
+    channel TEXT NOT NULL,
+    status TEXT NOT NULL CHECK (status IN ('SENT','FAILED')),
+    attempts INTEGER NOT NULL,
+    error TEXT,
+    payload TEXT CHECK (payload IS NULL OR json_valid(payload)),
+    created_at TEXT NOT NULL
+);
+
+CREATE TABLE IF NOT EXISTS optimization_runs (
+    id TEXT PRIMARY KEY,
+    kind TEXT NOT NULL CHECK (kind IN ('search','walk_forward')),
+    strategy TEXT NOT NULL,
+    instruments TEXT NOT NULL CHECK (json_valid(instruments)),
+    timeframe TEXT NOT NULL,
+    range_from TEXT NOT NULL,
+    range_to TEXT NOT NULL,
+    config TEXT NOT NULL CHECK (json_valid(config)),
+    status TEXT NOT NULL DEFAULT 'RUNNING' CHECK (status IN ('RUNNING','COMPLETED','FAILED')),
+    metrics TEXT CHECK (metrics IS NULL OR json_valid(metrics)),
+    result TEXT CHECK (result IS NULL OR json_valid(result)),
+    equity TEXT CHECK (equity IS NULL OR json_valid(equity)),
+    error TEXT,
+    created_at TEXT NOT NULL,
+    completed_at TEXT
+);
+
+CREATE TABLE IF NOT EXISTS news_articles (
+    id TEXT PRIMARY KEY,
+    url TEXT NOT NULL UNIQUE,
+    title TEXT NOT NULL,
+    snippet TEXT NOT NULL,
+    source TEXT NOT NULL,
+    published_at TEXT,
+    first_seen_at TEXT NOT NULL,
+    sentiment REAL NOT NULL,
+    relevance TEXT CHECK (relevance IS NULL OR json_valid(relevance)),
+    currencies TEXT NOT NULL CHECK (json_valid(currencies))
+);
+
+CREATE TABLE IF NOT EXISTS account_snapshots (
+    id TEXT PRIMARY KEY,
+    account_id TEXT NOT NULL,
+    currency TEXT NOT NULL,
+    balance REAL NOT NULL,
+    nav REAL NOT NULL,
+    unrealized_pl REAL NOT NULL,
+    margin_used REAL NOT NULL,
+    margin_available REAL NOT NULL,
+    open_trade_count INTEGER NOT NULL,
+    taken_at TEXT NOT NULL
+);
+
+CREATE TABLE IF NOT EXISTS ticks (
+    instrument TEXT NOT NULL,
+    time TEXT NOT NULL,
+    bid REAL NOT NULL,
+    ask REAL NOT NULL,
+    PRIMARY KEY (instrument, time)
+) WITHOUT ROWID;
+
+CREATE INDEX IF NOT EXISTS idx_analysis_cache_key ON market_analysis_cache(cache_key, expires_at);
+CREATE INDEX IF NOT EXISTS idx_economic_events_time ON economic_events(event_time);
+CREATE INDEX IF NOT EXISTS idx_queued_orders_status ON queued_orders(status, created_at);
+CREATE INDEX IF NOT EXISTS idx_alert_events_rule ON alert_events(rule_id, fired_at DESC);
+CREATE INDEX IF NOT EXISTS idx_notification_deliveries_created ON notification_deliveries(created_at DESC);
+CREATE INDEX IF NOT EXISTS idx_optimization_runs_created ON optimization_runs(created_at DESC);
+CREATE INDEX IF NOT EXISTS idx_news_articles_published ON news_articles(COALESCE(published_at, first_seen_at));
+CREATE INDEX IF NOT EXISTS idx_account_snapshots_taken ON account_snapshots(account_id, taken_at);
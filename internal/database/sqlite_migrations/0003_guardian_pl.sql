@@ -0,0 +1,2 @@
+ALTER TABLE account_guardian_state ADD COLUMN start_of_day_pl REAL;
+ALTER TABLE account_guardian_state ADD COLUMN peak_pl REAL;
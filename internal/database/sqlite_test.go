@@ -0,0 +1,149 @@
+package database
+
+import (
+	"bytes"
+	"context"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/jedi116/go-trader/internal/export"
+	"github.com/jedi116/go-trader/pkg/models"
+)
+
+func openTestSQLite(t *testing.T) (*SQLite, string) {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), "go-trader.db")
+	s, err := NewSQLite(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { _ = s.Close() })
+	return s, path
+}
+
+// TestSQLiteRoundTrip writes trades, recommendations and candles, reopens the file and reads
+// them back, so the migrations, bindings and scanners all meet the real driver.
+func TestSQLiteRoundTrip(t *testing.T) {
+	s, path := openTestSQLite(t)
+	ctx := context.Background()
+
+	entry, oandaID := 1.0851, "1234"
+	trade := &models.Trade{Instrument: "EUR_USD", Direction: "BUY", Units: 1000, EntryPrice: &entry, Status: models.TradeStatusOpen, OandaTradeID: &oandaID}
+	if err := s.CreateTrade(ctx, trade); err != nil {
+		t.Fatal(err)
+	}
+	closedAt := time.Date(2026, 10, 16, 14, 0, 0, 0, time.UTC)
+	if ok, err := s.CloseTrade(ctx, oandaID, 1.0861, 10, -0.2, closedAt); err != nil || !ok {
+		t.Fatalf("CloseTrade = %v, %v", ok, err)
+	}
+	rationale := "breakout"
+	recID, err := s.CreateRecommendation(ctx, &models.Recommendation{Instrument: "GBP_USD", Direction: "SELL", Units: 500, Rationale: &rationale,
+		MarketConditions: []byte(`{"source":"manual"}`), Status: models.RecommendationStatusPending})
+	if err != nil {
+		t.Fatal(err)
+	}
+	at := time.Date(2026, 10, 16, 9, 0, 0, 0, time.UTC)
+	candles := []models.MarketData{
+		{Instrument: "EUR_USD", Timeframe: "H1", Timestamp: at, OpenPrice: 1.08, HighPrice: 1.09, LowPrice: 1.07, ClosePrice: 1.085,
+			Bid: &models.OHLC{Open: 1.0799, High: 1.0899, Low: 1.0699, Close: 1.0849}},
+		{Instrument: "EUR_USD", Timeframe: "H1", Timestamp: at.Add(time.Hour), OpenPrice: 1.085, HighPrice: 1.086, LowPrice: 1.084, ClosePrice: 1.0855},
+	}
+	if err := s.UpsertMarketData(ctx, candles); err != nil {
+		t.Fatal(err)
+	}
+	if err := s.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	s, err = NewSQLite(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer s.Close()
+
+	trades, err := s.ListClosedTrades(ctx, nil, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(trades) != 1 {
+		t.Fatalf("closed trades = %+v", trades)
+	}
+	got := trades[0]
+	if got.ID == "" || got.Instrument != "EUR_USD" || got.Source != models.TradeSourceManual || *got.EntryPrice != entry ||
+		*got.ProfitLoss != 10 || !got.ClosedAt.Equal(closedAt) || got.CreatedAt.IsZero() {
+		t.Errorf("trade = %+v", got)
+	}
+	if pl, n, err := s.SumRealizedPL(ctx, closedAt.Add(-time.Hour)); err != nil || pl != 10 || n != 1 {
+		t.Errorf("SumRealizedPL = %v, %d, %v", pl, n, err)
+	}
+
+	recs, err := s.ListRecommendations(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(recs) != 1 || recs[0].ID != recID || *recs[0].Rationale != rationale || string(recs[0].MarketConditions) != `{"source":"manual"}` {
+		t.Errorf("recommendations = %+v", recs)
+	}
+
+	// Updating the first candle without bid prices keeps the stored ones.
+	candles[0].ClosePrice, candles[0].Bid = 1.086, nil
+	if err := s.UpsertMarketData(ctx, candles[:1]); err != nil {
+		t.Fatal(err)
+	}
+	stored, err := s.ListMarketDataRange(ctx, "EUR_USD", "H1", at, at.Add(2*time.Hour))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(stored) != 2 || !stored[0].Timestamp.Equal(at) || stored[0].ClosePrice != 1.086 || stored[0].Bid == nil || stored[0].Bid.Close != 1.0849 || stored[1].Bid != nil {
+		t.Errorf("candles = %+v", stored)
+	}
+	latest, err := s.ListMarketData(ctx, "EUR_USD", "H1", 1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(latest) != 1 || !latest[0].Timestamp.Equal(at.Add(time.Hour)) {
+		t.Errorf("latest = %+v", latest)
+	}
+}
+
+// TestSQLiteExport streams a dataset through the export package, which builds its queries for
+// Postgres: $n placeholders, CAST and time filters must all work against SQLite too.
+func TestSQLiteExport(t *testing.T) {
+	s, _ := openTestSQLite(t)
+	ctx := context.Background()
+	at := time.Date(2026, 10, 16, 9, 0, 0, 0, time.UTC)
+	ticks := []models.Tick{
+		{Instrument: "EUR_USD", Time: at, Bid: 1.0850, Ask: 1.0851},
+		{Instrument: "EUR_USD", Time: at.Add(time.Second), Bid: 1.0851, Ask: 1.0853},
+		{Instrument: "GBP_USD", Time: at, Bid: 1.2700, Ask: 1.2702},
+	}
+	if _, err := s.InsertTicks(ctx, ticks); err != nil {
+		t.Fatal(err)
+	}
+	if err := s.CreateTrade(ctx, &models.Trade{Instrument: "EUR_USD", Direction: "BUY", Units: 1000, Status: models.TradeStatusOpen}); err != nil {
+		t.Fatal(err)
+	}
+
+	var buf bytes.Buffer
+	from, to := at, at.Add(time.Minute)
+	n, err := export.Export(ctx, s, &buf, "ticks", export.Filter{From: &from, To: &to, Instrument: "EUR_USD"}, export.Options{Format: export.CSV})
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := "instrument,time,bid,ask,spread\n" +
+		"EUR_USD,2026-10-16T09:00:00Z,1.085,1.0851,0.00009999999999998899\n" +
+		"EUR_USD,2026-10-16T09:00:01Z,1.0851,1.0853,0.00019999999999997797\n"
+	if n != 2 || buf.String() != want {
+		t.Errorf("ticks export (%d rows):\n%s", n, buf.String())
+	}
+
+	buf.Reset()
+	if n, err := export.Export(ctx, s, &buf, "audit_logs", export.Filter{Entity: "trades"}, export.Options{Format: export.NDJSON}); err != nil || n != 1 {
+		t.Fatalf("audit export = %d, %v", n, err)
+	}
+	if !strings.Contains(buf.String(), `"entity_id":"`) || !strings.Contains(buf.String(), `"details":{"`) {
+		t.Errorf("audit export = %s", buf.String())
+	}
+}
@@ -0,0 +1,558 @@
+package database
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"math"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/jedi116/go-trader/internal/config"
+	"github.com/jedi116/go-trader/pkg/models"
+)
+
+// The conformance tests run the same checks against every Store: Memory and a temp-file SQLite
+// always, and Postgres when TEST_DATABASE_URL names a migrated database. Each run writes under
+// its own marker and in 2001, so a shared database keeps its other rows.
+
+func forEachStore(t *testing.T, check func(t *testing.T, s Store, mark string)) {
+	t.Run("memory", func(t *testing.T) { check(t, NewMemory(), testMark()) })
+	t.Run("sqlite", func(t *testing.T) {
+		s, err := NewSQLite(filepath.Join(t.TempDir(), "store.db"))
+		if err != nil {
+			t.Fatal(err)
+		}
+		t.Cleanup(func() { _ = s.Close() })
+		check(t, s, testMark())
+	})
+	t.Run("postgres", func(t *testing.T) {
+		dsn := os.Getenv("TEST_DATABASE_URL")
+		if dsn == "" {
+			t.Skip("TEST_DATABASE_URL is not set")
+		}
+		db, err := sql.Open("postgres", dsn)
+		if err != nil {
+			t.Fatal(err)
+		}
+		mark := testMark()
+		t.Cleanup(func() {
+			ctx := context.Background()
+			_, _ = db.ExecContext(ctx, `DELETE FROM alert_events WHERE instrument=$1`, mark)
+			for _, q := range []string{
+				`DELETE FROM trades WHERE instrument=$1`,
+				`DELETE FROM queued_orders WHERE instrument=$1`,
+				`DELETE FROM recommendations WHERE instrument=$1`,
+				`DELETE FROM ai_recommendations WHERE instrument=$1`,
+				`DELETE FROM alert_rules WHERE instrument=$1`,
+				`DELETE FROM notification_deliveries WHERE event_id=$1`,
+				`DELETE FROM optimization_runs WHERE strategy=$1`,
+				`DELETE FROM economic_events WHERE title LIKE $1 || '%'`,
+				`DELETE FROM account_guardian_state WHERE account_id=$1`,
+				`DELETE FROM news_articles WHERE source=$1`,
+				`DELETE FROM market_analysis_cache WHERE cache_key=$1`,
+				`DELETE FROM account_snapshots WHERE account_id=$1`,
+				`DELETE FROM ticks WHERE instrument=$1`,
+			} {
+				_, _ = db.ExecContext(ctx, q, mark)
+			}
+			_ = db.Close()
+		})
+		check(t, &Postgres{DB: db}, mark)
+	})
+}
+
+func TestOpen(t *testing.T) {
+	t.Setenv("DATABASE_URL", "")
+	tests := []struct {
+		name      string
+		db        config.DatabaseConfig
+		notConfig bool
+		fallback  bool
+		openFails bool
+	}{
+		{"memory driver", config.DatabaseConfig{Driver: "memory"}, false, false, false},
+		{"postgres without a host", config.DatabaseConfig{}, true, true, false},
+		{"postgres that is down", config.DatabaseConfig{Host: "127.0.0.1", Port: "1"}, false, false, true},
+		{"unreadable sqlite path", config.DatabaseConfig{Driver: "sqlite", Path: filepath.Join(t.TempDir(), "missing", "x.db")}, false, false, true},
+		{"driver typo", config.DatabaseConfig{Driver: "postgress", Host: "db"}, false, false, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cfg := &config.Config{Database: tt.db}
+			_, err := Open(cfg)
+			if errors.Is(err, ErrNotConfigured) != tt.notConfig {
+				t.Errorf("Open err = %v", err)
+			}
+			s, err := OpenOrMemory(cfg)
+			if (err != nil) != tt.openFails {
+				t.Fatalf("OpenOrMemory err = %v", err)
+			}
+			if _, isMemory := s.(*Memory); !tt.openFails && isMemory != (tt.fallback || tt.db.Driver == "memory") {
+				t.Errorf("OpenOrMemory = %T", s)
+			}
+		})
+	}
+}
+
+func testMark() string { return fmt.Sprintf("TEST_%d", time.Now().UnixNano()) }
+
+// base is the start of the year the conformance tests write their dated rows in.
+var base = time.Date(2001, 3, 5, 0, 0, 0, 0, time.UTC)
+
+func TestStoreTrades(t *testing.T) {
+	forEachStore(t, func(t *testing.T, s Store, mark string) {
+		ctx := context.Background()
+		oandaID := mark
+		if err := s.CreateTrade(ctx, &models.Trade{Instrument: mark, Direction: "BUY", Units: 100, Status: models.TradeStatusOpen, OandaTradeID: &oandaID}); err != nil {
+			t.Fatal(err)
+		}
+		if ok, err := s.CloseTrade(ctx, oandaID, 1.1, 5, 0, base); err != nil || !ok {
+			t.Fatalf("CloseTrade = %v, %v", ok, err)
+		}
+		if ok, err := s.CloseTrade(ctx, oandaID, 1.1, 5, 0, base); err != nil || ok {
+			t.Fatalf("second CloseTrade = %v, %v", ok, err)
+		}
+		from, to := base, base.Add(time.Second)
+		closed, err := s.ListClosedTrades(ctx, &from, &to)
+		if err != nil {
+			t.Fatal(err)
+		}
+		found := false
+		for _, tr := range closed {
+			if tr.Instrument == mark {
+				found = tr.ID != "" && tr.Source == models.TradeSourceManual && *tr.ProfitLoss == 5 && tr.ClosedAt.Equal(base)
+			}
+		}
+		if !found {
+			t.Errorf("closed trades = %+v", closed)
+		}
+	})
+}
+
+func TestStoreRecommendationClaims(t *testing.T) {
+	forEachStore(t, func(t *testing.T, s Store, mark string) {
+		ctx := context.Background()
+		now := time.Now()
+		id, err := s.CreateRecommendation(ctx, &models.Recommendation{Instrument: mark, Direction: "BUY", Units: 1000, Status: models.RecommendationStatusPending})
+		if err != nil {
+			t.Fatal(err)
+		}
+		if ok, err := s.TransitionRecommendation(ctx, id, models.RecommendationStatusPending, models.RecommendationStatusExecuted); err != nil || !ok {
+			t.Fatalf("claim = %v, %v", ok, err)
+		}
+		if ok, err := s.TransitionRecommendation(ctx, id, models.RecommendationStatusPending, models.RecommendationStatusExecuted); err != nil || ok {
+			t.Fatalf("second claim = %v, %v", ok, err)
+		}
+		if ok, err := s.TransitionRecommendation(ctx, id, models.RecommendationStatusExecuted, models.RecommendationStatusPending); err != nil || !ok {
+			t.Fatalf("release = %v, %v", ok, err)
+		}
+		if err := s.SoftDeleteRecommendation(ctx, id); err != nil {
+			t.Fatal(err)
+		}
+		if ok, err := s.TransitionRecommendation(ctx, id, models.RecommendationStatusPending, models.RecommendationStatusExecuted); err != nil || ok {
+			t.Fatalf("claim of a deleted recommendation = %v, %v", ok, err)
+		}
+
+		tests := []struct {
+			name   string
+			status models.AIRecommendationStatus
+			ttl    time.Time
+			want   bool
+		}{
+			{"pending", models.AIRecommendationStatusPending, now.Add(time.Hour), true},
+			{"approved", models.AIRecommendationStatusApproved, now.Add(time.Hour), true},
+			{"rejected", models.AIRecommendationStatusRejected, now.Add(time.Hour), false},
+			{"executed", models.AIRecommendationStatusExecuted, now.Add(time.Hour), false},
+			{"expired", models.AIRecommendationStatusPending, now.Add(-time.Minute), false},
+		}
+		for _, tt := range tests {
+			id, err := s.CreateAIRecommendation(ctx, &models.AIRecommendation{Instrument: mark, Direction: "BUY", Units: 1000, TimeToLive: tt.ttl,
+				MarketContext: []byte(`{}`), Status: tt.status})
+			if err != nil {
+				t.Fatal(err)
+			}
+			if ok, err := s.ClaimAIRecommendation(ctx, id, now); err != nil || ok != tt.want {
+				t.Errorf("%s: claim = %v, %v; want %v", tt.name, ok, err, tt.want)
+			}
+			if ok, err := s.ClaimAIRecommendation(ctx, id, now); err != nil || ok {
+				t.Errorf("%s: second claim = %v, %v", tt.name, ok, err)
+			}
+		}
+	})
+}
+
+func TestStoreQueuedOrders(t *testing.T) {
+	forEachStore(t, func(t *testing.T, s Store, mark string) {
+		ctx := context.Background()
+		o := &models.QueuedOrder{Instrument: mark, Units: 1000, Source: "api"}
+		if err := s.CreateQueuedOrder(ctx, o); err != nil {
+			t.Fatal(err)
+		}
+		if o.ID == "" || o.Status != models.QueuedOrderPending || o.CreatedAt.IsZero() {
+			t.Fatalf("created %+v", o)
+		}
+		if ok, err := s.TransitionQueuedOrder(ctx, o.ID, models.QueuedOrderCancelled, models.QueuedOrderSubmitted, nil, nil); err != nil || ok {
+			t.Fatalf("transition from the wrong status = %v, %v", ok, err)
+		}
+		brokerID := "42"
+		if ok, err := s.TransitionQueuedOrder(ctx, o.ID, models.QueuedOrderPending, models.QueuedOrderSubmitted, &brokerID, nil); err != nil || !ok {
+			t.Fatalf("transition = %v, %v", ok, err)
+		}
+		submitted, err := s.ListQueuedOrders(ctx, models.QueuedOrderSubmitted, 1000)
+		if err != nil {
+			t.Fatal(err)
+		}
+		var got *models.QueuedOrder
+		for i := range submitted {
+			if submitted[i].ID == o.ID {
+				got = &submitted[i]
+			}
+		}
+		if got == nil || got.OandaOrderID == nil || *got.OandaOrderID != brokerID || got.SubmittedAt == nil || got.Error != nil {
+			t.Errorf("submitted order = %+v", got)
+		}
+		pending, err := s.ListQueuedOrders(ctx, models.QueuedOrderPending, 1000)
+		if err != nil {
+			t.Fatal(err)
+		}
+		for _, p := range pending {
+			if p.ID == o.ID {
+				t.Errorf("submitted order still listed as queued")
+			}
+		}
+	})
+}
+
+func TestStoreAlerts(t *testing.T) {
+	forEachStore(t, func(t *testing.T, s Store, mark string) {
+		ctx := context.Background()
+		r := &models.AlertRule{Name: "level", Instrument: mark, Kind: models.AlertPriceCross, Condition: models.AlertAbove, Threshold: 1.1, Active: true}
+		if err := s.CreateAlertRule(ctx, r); err != nil {
+			t.Fatal(err)
+		}
+		if r.ID == "" || r.CreatedAt.IsZero() {
+			t.Fatalf("created %+v", r)
+		}
+		r.Threshold = 1.2
+		if ok, err := s.UpdateAlertRule(ctx, r); err != nil || !ok {
+			t.Fatalf("UpdateAlertRule = %v, %v", ok, err)
+		}
+		e := &models.AlertEvent{RuleID: r.ID, Instrument: mark, Value: 1.21, Message: "above 1.2", FiredAt: base}
+		if err := s.RecordAlertFired(ctx, e, true); err != nil {
+			t.Fatal(err)
+		}
+		got, err := s.GetAlertRule(ctx, r.ID)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got == nil || got.Threshold != 1.2 || got.FireCount != 1 || got.Active || got.LastFiredAt == nil || !got.LastFiredAt.Equal(base) {
+			t.Errorf("fired rule = %+v", got)
+		}
+		active, err := s.ListAlertRules(ctx, true)
+		if err != nil {
+			t.Fatal(err)
+		}
+		for _, a := range active {
+			if a.ID == r.ID {
+				t.Errorf("deactivated rule listed as active")
+			}
+		}
+		events, err := s.ListAlertEvents(ctx, r.ID, 0)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(events) != 1 || events[0].ID != e.ID || events[0].Value != 1.21 || !events[0].FiredAt.Equal(base) {
+			t.Errorf("events = %+v", events)
+		}
+		if ok, err := s.SoftDeleteAlertRule(ctx, r.ID); err != nil || !ok {
+			t.Fatalf("SoftDeleteAlertRule = %v, %v", ok, err)
+		}
+		if got, err := s.GetAlertRule(ctx, r.ID); err != nil || got != nil {
+			t.Errorf("deleted rule = %+v, %v", got, err)
+		}
+		if ok, err := s.UpdateAlertRule(ctx, r); err != nil || ok {
+			t.Errorf("update of a deleted rule = %v, %v", ok, err)
+		}
+	})
+}
+
+func TestStoreNotificationDeliveries(t *testing.T) {
+	forEachStore(t, func(t *testing.T, s Store, mark string) {
+		ctx := context.Background()
+		d := &models.NotificationDelivery{EventID: mark, EventType: "trade.closed", Channel: "webhook", Status: models.NotificationFailed, Attempts: 3, Payload: []byte(`{"a":1}`)}
+		if err := s.LogNotificationDelivery(ctx, d); err != nil {
+			t.Fatal(err)
+		}
+		failed, err := s.ListNotificationDeliveries(ctx, models.NotificationFailed, 1000)
+		if err != nil {
+			t.Fatal(err)
+		}
+		found := false
+		for _, f := range failed {
+			if f.ID == d.ID {
+				found = f.EventID == mark && f.Attempts == 3 && string(f.Payload) == `{"a":1}` && !f.CreatedAt.IsZero()
+			}
+		}
+		if !found {
+			t.Errorf("delivery %s not listed as failed", d.ID)
+		}
+	})
+}
+
+func TestStoreOptimizationRuns(t *testing.T) {
+	forEachStore(t, func(t *testing.T, s Store, mark string) {
+		ctx := context.Background()
+		r := &models.OptimizationRun{Kind: models.OptimizationSearch, Strategy: mark, Instruments: []string{"EUR_USD", "GBP_USD"}, Timeframe: "H1",
+			From: base, To: base.AddDate(0, 1, 0), Config: []byte(`{"trials":10}`)}
+		if err := s.CreateOptimizationRun(ctx, r); err != nil {
+			t.Fatal(err)
+		}
+		if r.ID == "" || r.Status != models.OptimizationRunning {
+			t.Fatalf("created %+v", r)
+		}
+		if err := s.CompleteOptimizationRun(ctx, r.ID, models.OptimizationCompleted, []byte(`{"sharpe":1}`), []byte(`[1]`), []byte(`[2]`), nil); err != nil {
+			t.Fatal(err)
+		}
+		got, err := s.GetOptimizationRun(ctx, r.ID)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got == nil || got.Status != models.OptimizationCompleted || len(got.Instruments) != 2 || got.Instruments[1] != "GBP_USD" || !got.From.Equal(base) ||
+			string(got.Result) != `[1]` || string(got.Equity) != `[2]` || got.CompletedAt == nil {
+			t.Errorf("run = %+v", got)
+		}
+		runs, err := s.ListOptimizationRuns(ctx, 1000)
+		if err != nil {
+			t.Fatal(err)
+		}
+		found := false
+		for _, l := range runs {
+			if l.ID == r.ID {
+				found = l.Result == nil && l.Equity == nil && string(l.Metrics) == `{"sharpe":1}`
+			}
+		}
+		if !found {
+			t.Errorf("run %s not listed without its result", r.ID)
+		}
+		if got, err := s.GetOptimizationRun(ctx, newID()); err != nil || got != nil {
+			t.Errorf("missing run = %+v, %v", got, err)
+		}
+	})
+}
+
+func TestStoreEconomicEvents(t *testing.T) {
+	forEachStore(t, func(t *testing.T, s Store, mark string) {
+		ctx := context.Background()
+		forecast, actual := "0.2%", "0.3%"
+		events := []models.EconomicEvent{
+			{Title: mark + " CPI", Currency: "USD", Impact: models.EventImpactHigh, EventTime: base.Add(time.Hour), Forecast: &forecast},
+			{Title: mark + " PMI", Currency: "EUR", Impact: models.EventImpactLow, EventTime: base},
+		}
+		if err := s.UpsertEconomicEvents(ctx, events); err != nil {
+			t.Fatal(err)
+		}
+		events[0].Actual = &actual
+		if err := s.UpsertEconomicEvents(ctx, events[:1]); err != nil {
+			t.Fatal(err)
+		}
+		all, err := s.ListEconomicEvents(ctx, base, base.Add(time.Hour), nil)
+		if err != nil {
+			t.Fatal(err)
+		}
+		var mine []models.EconomicEvent
+		for _, e := range all {
+			if e.Title == events[0].Title || e.Title == events[1].Title {
+				mine = append(mine, e)
+			}
+		}
+		if len(mine) != 2 || mine[0].Currency != "EUR" || mine[1].Actual == nil || *mine[1].Actual != actual || !mine[1].EventTime.Equal(base.Add(time.Hour)) {
+			t.Errorf("events = %+v", mine)
+		}
+		usd, err := s.ListEconomicEvents(ctx, base, base.Add(time.Hour), []string{"USD"})
+		if err != nil {
+			t.Fatal(err)
+		}
+		for _, e := range usd {
+			if e.Currency != "USD" {
+				t.Errorf("currency filter returned %+v", e)
+			}
+		}
+	})
+}
+
+func TestStoreGuardianState(t *testing.T) {
+	forEachStore(t, func(t *testing.T, s Store, mark string) {
+		ctx := context.Background()
+		if got, err := s.GetGuardianState(ctx, mark); err != nil || got != nil {
+			t.Fatalf("state before saving = %+v, %v", got, err)
+		}
+		reason := "daily loss"
+		haltedAt := base.Add(90 * time.Minute)
+		startPL, peakPL := -25.5, 40.25
+		state := &models.GuardianState{AccountID: mark, TradingDay: base, StartOfDayNAV: 1000, StartOfDayBalance: 1000, StartOfDayPL: &startPL, PeakNAV: 1010, PeakPL: &peakPL, Halted: true, HaltReason: &reason, HaltedAt: &haltedAt}
+		for i := 0; i < 2; i++ {
+			if err := s.SaveGuardianState(ctx, state); err != nil {
+				t.Fatal(err)
+			}
+		}
+		got, err := s.GetGuardianState(ctx, mark)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got == nil || !got.TradingDay.Equal(base) || !got.Halted || *got.HaltReason != reason || !got.HaltedAt.Equal(haltedAt) || got.PeakNAV != 1010 ||
+			got.StartOfDayPL == nil || *got.StartOfDayPL != startPL || got.PeakPL == nil || *got.PeakPL != peakPL {
+			t.Errorf("state = %+v", got)
+		}
+	})
+}
+
+func TestStoreNewsArchive(t *testing.T) {
+	forEachStore(t, func(t *testing.T, s Store, mark string) {
+		ctx := context.Background()
+		published := base.Add(time.Hour)
+		articles := []models.NewsArticle{
+			{URL: "https://example.com/" + mark + "/1", Title: "ECB holds", Source: mark, PublishedAt: &published, Sentiment: 0.5,
+				Relevance: map[string]float64{"EUR": 0.9}, Currencies: []string{"EUR"}},
+			{URL: "https://example.com/" + mark + "/2", Title: "Fed hikes", Source: mark, PublishedAt: &base, Currencies: []string{"USD"}},
+		}
+		if err := s.ArchiveNews(ctx, articles); err != nil {
+			t.Fatal(err)
+		}
+		articles[0].Sentiment = -1
+		if err := s.ArchiveNews(ctx, articles[:1]); err != nil {
+			t.Fatal(err)
+		}
+		eur, err := s.ListNewsArticles(ctx, base, base.Add(time.Hour), []string{"EUR", "JPY"}, 1000)
+		if err != nil {
+			t.Fatal(err)
+		}
+		var mine []models.NewsArticle
+		for _, a := range eur {
+			if a.Source == mark {
+				mine = append(mine, a)
+			}
+		}
+		if len(mine) != 1 || mine[0].Sentiment != 0.5 || mine[0].Relevance["EUR"] != 0.9 || !mine[0].PublishedAt.Equal(published) || mine[0].FirstSeenAt.IsZero() {
+			t.Errorf("EUR articles = %+v", mine)
+		}
+		all, err := s.ListNewsArticles(ctx, base, base.Add(time.Hour), nil, 1000)
+		if err != nil {
+			t.Fatal(err)
+		}
+		mine = mine[:0]
+		for _, a := range all {
+			if a.Source == mark {
+				mine = append(mine, a)
+			}
+		}
+		if len(mine) != 2 || mine[0].Title != "ECB holds" {
+			t.Errorf("articles = %+v, want newest first", mine)
+		}
+	})
+}
+
+func TestStoreAnalysisCache(t *testing.T) {
+	forEachStore(t, func(t *testing.T, s Store, mark string) {
+		ctx := context.Background()
+		if err := s.InsertMarketAnalysisCache(ctx, mark, "EUR_USD", []byte(`{"v":1}`), time.Now().Add(-time.Minute)); err != nil {
+			t.Fatal(err)
+		}
+		if _, ok, err := s.GetMarketAnalysisCache(ctx, mark); err != nil || ok {
+			t.Fatalf("expired entry returned: %v, %v", ok, err)
+		}
+		if err := s.InsertMarketAnalysisCache(ctx, mark, "EUR_USD", []byte(`{"v":2}`), time.Now().Add(time.Hour)); err != nil {
+			t.Fatal(err)
+		}
+		data, ok, err := s.GetMarketAnalysisCache(ctx, mark)
+		if err != nil || !ok || string(data) != `{"v":2}` {
+			t.Fatalf("cached = %s, %v, %v", data, ok, err)
+		}
+		if n, err := s.PurgeExpiredMarketAnalysisCache(ctx); err != nil || n < 1 {
+			t.Errorf("purged %d, %v", n, err)
+		}
+		if _, ok, _ := s.GetMarketAnalysisCache(ctx, mark); !ok {
+			t.Errorf("purge removed an unexpired entry")
+		}
+	})
+}
+
+func TestStoreAccountSnapshots(t *testing.T) {
+	forEachStore(t, func(t *testing.T, s Store, mark string) {
+		ctx := context.Background()
+		for i, at := range []time.Duration{10 * time.Minute, 50 * time.Minute, 70 * time.Minute} {
+			snap := &models.AccountSnapshot{AccountID: mark, Currency: "USD", Balance: 1000, NAV: 1000 + float64(i), OpenTradeCount: i, TakenAt: base.Add(at)}
+			if err := s.CreateAccountSnapshot(ctx, snap); err != nil {
+				t.Fatal(err)
+			}
+			if snap.ID == "" {
+				t.Fatal("snapshot ID not set")
+			}
+		}
+		all, err := s.ListAccountSnapshots(ctx, mark, base, base.Add(70*time.Minute), 0, 0)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(all) != 2 || all[0].NAV != 1000 || !all[1].TakenAt.Equal(base.Add(50*time.Minute)) {
+			t.Errorf("snapshots = %+v, want the two before the end", all)
+		}
+		hourly, err := s.ListAccountSnapshots(ctx, mark, base, base.Add(2*time.Hour), time.Hour, 0)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(hourly) != 2 || hourly[0].NAV != 1001 || hourly[1].NAV != 1002 {
+			t.Errorf("hourly = %+v, want the last of each hour", hourly)
+		}
+		if n, err := s.DeleteAccountSnapshotsBefore(ctx, base.Add(time.Hour)); err != nil || n != 2 {
+			t.Errorf("deleted %d, %v", n, err)
+		}
+	})
+}
+
+func TestStoreTicks(t *testing.T) {
+	forEachStore(t, func(t *testing.T, s Store, mark string) {
+		ctx := context.Background()
+		if err := s.EnsureTickPartitions(ctx, base, base.AddDate(0, 0, 1)); err != nil {
+			t.Fatal(err)
+		}
+		var ticks []models.Tick
+		// Four ticks in the first minute with spreads 1..4 pips and one in the second.
+		for i, spread := range []float64{1, 2, 3, 4} {
+			ticks = append(ticks, models.Tick{Instrument: mark, Time: base.Add(time.Duration(i) * time.Second), Bid: 1, Ask: 1 + spread/1e4})
+		}
+		ticks = append(ticks, models.Tick{Instrument: mark, Time: base.AddDate(0, 0, 1).Add(-time.Second), Bid: 1, Ask: 1.0001})
+		if n, err := s.InsertTicks(ctx, ticks); err != nil || n != 5 {
+			t.Fatalf("inserted %d, %v", n, err)
+		}
+		if n, err := s.InsertTicks(ctx, ticks[:2]); err != nil || n != 0 {
+			t.Fatalf("reinserted %d, %v", n, err)
+		}
+		got, err := s.ListTicks(ctx, mark, base, base.Add(3*time.Second), 0)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(got) != 3 || !got[2].Time.Equal(base.Add(2*time.Second)) || got[2].Ask != ticks[2].Ask {
+			t.Errorf("ticks = %+v", got)
+		}
+		stats, err := s.TickSpreadStats(ctx, mark, base, base.AddDate(0, 0, 1), time.Minute)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(stats) != 2 || !stats[0].Time.Equal(base) || stats[0].Ticks != 4 {
+			t.Fatalf("stats = %+v", stats)
+		}
+		near := func(got, want float64) bool { return math.Abs(got-want) < 1e-9 }
+		if st := stats[0]; !near(st.Min, 1e-4) || !near(st.Avg, 2.5e-4) || !near(st.P50, 2.5e-4) || !near(st.P95, 3.85e-4) || !near(st.Max, 4e-4) {
+			t.Errorf("first minute = %+v", st)
+		}
+		if n, err := s.DropTickPartitionsBefore(ctx, base.AddDate(0, 0, 1).Add(time.Hour)); err != nil || n < 1 {
+			t.Errorf("dropped %d days, %v", n, err)
+		}
+		if left, err := s.ListTicks(ctx, mark, base, base.AddDate(0, 0, 2), 0); err != nil || len(left) != 0 {
+			t.Errorf("ticks left = %+v, %v", left, err)
+		}
+	})
+}
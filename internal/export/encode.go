@@ -0,0 +1,163 @@
+package export
+
+import (
+	"bufio"
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io"
+	"strconv"
+	"time"
+)
+
+type encoder interface {
+	begin() error
+	row(values []interface{}) error
+	flush() error
+	// end writes whatever the format needs after the last row.
+	end() error
+}
+
+// text returns a scanned value as a string; the driver hands numerics, JSON and arrays over as
+// bytes.
+func text(v interface{}) string {
+	switch x := v.(type) {
+	case []byte:
+		return string(x)
+	case string:
+		return x
+	case time.Time:
+		return x.Format(time.RFC3339Nano)
+	}
+	return fmt.Sprint(v)
+}
+
+// formatTime writes a time in loc; SQLite hands times over as the RFC3339 text it stores.
+func formatTime(v interface{}, loc *time.Location) string {
+	t, ok := v.(time.Time)
+	if !ok {
+		var err error
+		if t, err = time.Parse(time.RFC3339Nano, text(v)); err != nil {
+			return text(v)
+		}
+	}
+	return t.In(loc).Format(time.RFC3339Nano)
+}
+
+// number normalizes a numeric value so that trailing zeros of fixed-scale columns are dropped.
+func number(v interface{}, k kind) string {
+	switch x := v.(type) {
+	case int64:
+		return strconv.FormatInt(x, 10)
+	case float64:
+		return strconv.FormatFloat(x, 'f', -1, 64)
+	}
+	s := text(v)
+	if k == kindFloat {
+		if f, err := strconv.ParseFloat(s, 64); err == nil {
+			return strconv.FormatFloat(f, 'f', -1, 64)
+		}
+	}
+	return s
+}
+
+type csvEncoder struct {
+	w    *csv.Writer
+	cols []column
+	loc  *time.Location
+	rec  []string
+}
+
+func newCSVEncoder(w io.Writer, cols []column, loc *time.Location) *csvEncoder {
+	return &csvEncoder{w: csv.NewWriter(w), cols: cols, loc: loc, rec: make([]string, len(cols))}
+}
+
+func (e *csvEncoder) begin() error {
+	for i, c := range e.cols {
+		e.rec[i] = c.name
+	}
+	return e.w.Write(e.rec)
+}
+
+// row writes NULL as an empty field and JSON as its text.
+func (e *csvEncoder) row(values []interface{}) error {
+	for i, c := range e.cols {
+		v := values[i]
+		switch {
+		case v == nil:
+			e.rec[i] = ""
+		case c.kind == kindTime:
+			e.rec[i] = formatTime(v, e.loc)
+		case c.kind == kindFloat || c.kind == kindInt:
+			e.rec[i] = number(v, c.kind)
+		default:
+			e.rec[i] = text(v)
+		}
+	}
+	return e.w.Write(e.rec)
+}
+
+func (e *csvEncoder) flush() error {
+	e.w.Flush()
+	return e.w.Error()
+}
+
+func (e *csvEncoder) end() error { return e.flush() }
+
+// ndjsonEncoder writes one object per row with the keys in column order.
+type ndjsonEncoder struct {
+	w    *bufio.Writer
+	cols []column
+	loc  *time.Location
+	keys [][]byte
+	buf  []byte
+}
+
+func newNDJSONEncoder(w io.Writer, cols []column, loc *time.Location) *ndjsonEncoder {
+	keys := make([][]byte, len(cols))
+	for i, c := range cols {
+		keys[i], _ = json.Marshal(c.name)
+	}
+	return &ndjsonEncoder{w: bufio.NewWriter(w), cols: cols, loc: loc, keys: keys}
+}
+
+func (e *ndjsonEncoder) begin() error { return nil }
+
+// row writes NULL as null, numbers as numbers and JSON columns embedded.
+func (e *ndjsonEncoder) row(values []interface{}) error {
+	b := append(e.buf[:0], '{')
+	for i, c := range e.cols {
+		if i > 0 {
+			b = append(b, ',')
+		}
+		b = append(b, e.keys[i]...)
+		b = append(b, ':')
+		v := values[i]
+		switch {
+		case v == nil:
+			b = append(b, "null"...)
+		case c.kind == kindFloat || c.kind == kindInt:
+			b = append(b, number(v, c.kind)...)
+		case c.kind == kindJSON && json.Valid([]byte(text(v))):
+			b = append(b, text(v)...)
+		default:
+			s := text(v)
+			if c.kind == kindTime {
+				s = formatTime(v, e.loc)
+			}
+			enc, err := json.Marshal(s)
+			if err != nil {
+				return err
+			}
+			b = append(b, enc...)
+		}
+	}
+	b = append(b, '}', '\n')
+	e.buf = b
+	_, err := e.w.Write(b)
+	return err
+}
+
+func (e *ndjsonEncoder) flush() error { return e.w.Flush() }
+
+func (e *ndjsonEncoder) end() error { return e.flush() }
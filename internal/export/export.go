@@ -0,0 +1,345 @@
+// Package export streams stored tables out as CSV, NDJSON or Parquet for analysis elsewhere.
+// Column names are snake_case and the same in every format; times are written as RFC3339 in the
+// requested location, UTC by default, or as UTC timestamps in Parquet.
+package export
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Format is an output encoding.
+type Format string
+
+const (
+	CSV     Format = "csv"
+	NDJSON  Format = "ndjson"
+	Parquet Format = "parquet"
+)
+
+// ParseFormat accepts csv, ndjson (or jsonl) and parquet; empty means csv.
+func ParseFormat(s string) (Format, error) {
+	switch strings.ToLower(strings.TrimSpace(s)) {
+	case "", "csv":
+		return CSV, nil
+	case "ndjson", "jsonl":
+		return NDJSON, nil
+	case "parquet":
+		return Parquet, nil
+	}
+	return "", fmt.Errorf("export: unknown format %q", s)
+}
+
+// ContentType is the MIME type of f.
+func (f Format) ContentType() string {
+	switch f {
+	case NDJSON:
+		return "application/x-ndjson"
+	case Parquet:
+		return "application/vnd.apache.parquet"
+	}
+	return "text/csv"
+}
+
+// Querier streams query results row by row; database.Store implements it.
+type Querier interface {
+	StreamRows(ctx context.Context, query string, args []interface{}, fn func(values []interface{}) error) error
+}
+
+type kind int
+
+const (
+	kindString kind = iota
+	kindFloat
+	kindInt
+	kindTime
+	kindJSON
+)
+
+type column struct {
+	name string
+	expr string
+	kind kind
+}
+
+// dataset describes an exportable table. The filter columns are empty when the table has no
+// such field.
+type dataset struct {
+	table      string
+	timeColumn string
+	where      string
+	instrument string
+	timeframe  string
+	entity     string
+	columns    []column
+}
+
+var datasets = map[string]dataset{
+	"trades": {
+		table: "trades", timeColumn: "created_at", where: "deleted_at IS NULL", instrument: "instrument",
+		columns: []column{
+			{"id", "CAST(id AS TEXT)", kindString},
+			{"instrument", "instrument", kindString},
+			{"direction", "direction", kindString},
+			{"units", "units", kindFloat},
+			{"entry_price", "entry_price", kindFloat},
+			{"exit_price", "exit_price", kindFloat},
+			{"profit_loss", "profit_loss", kindFloat},
+			{"commission", "commission", kindFloat},
+			{"swap", "swap", kindFloat},
+			{"status", "status", kindString},
+			{"source", "source", kindString},
+			{"oanda_trade_id", "oanda_trade_id", kindString},
+			{"created_at", "created_at", kindTime},
+			{"updated_at", "updated_at", kindTime},
+			{"closed_at", "closed_at", kindTime},
+		},
+	},
+	"ai_recommendations": {
+		table: "ai_recommendations", timeColumn: "created_at", instrument: "instrument",
+		columns: []column{
+			{"id", "CAST(id AS TEXT)", kindString},
+			{"instrument", "instrument", kindString},
+			{"direction", "direction", kindString},
+			{"units", "units", kindFloat},
+			{"confidence", "confidence", kindFloat},
+			{"rationale", "rationale", kindString},
+			{"stop_loss", "stop_loss", kindFloat},
+			{"take_profit", "take_profit", kindFloat},
+			{"status", "status", kindString},
+			{"market_context", "market_context", kindJSON},
+			{"news_context", "news_context", kindJSON},
+			{"historical_context", "historical_context", kindJSON},
+			{"executed_trade_id", "CAST(executed_trade_id AS TEXT)", kindString},
+			{"time_to_live", "time_to_live", kindTime},
+			{"approved_at", "approved_at", kindTime},
+			{"created_at", "created_at", kindTime},
+			{"updated_at", "updated_at", kindTime},
+		},
+	},
+	"audit_logs": {
+		table: "audit_logs", timeColumn: "created_at", entity: "entity",
+		columns: []column{
+			{"id", "id", kindInt},
+			{"entity", "entity", kindString},
+			{"entity_id", "CAST(entity_id AS TEXT)", kindString},
+			{"action", "action", kindString},
+			{"details", "details", kindJSON},
+			{"created_at", "created_at", kindTime},
+		},
+	},
+	"ticks": {
+		table: "ticks", timeColumn: "time", instrument: "instrument",
+		columns: []column{
+			{"instrument", "instrument", kindString},
+			{"time", "time", kindTime},
+			{"bid", "bid", kindFloat},
+			{"ask", "ask", kindFloat},
+			{"spread", "ask - bid", kindFloat},
+		},
+	},
+	"market_data": {
+		table: "market_data", timeColumn: "timestamp", where: "deleted_at IS NULL", instrument: "instrument", timeframe: "timeframe",
+		columns: []column{
+			{"instrument", "instrument", kindString},
+			{"timeframe", "timeframe", kindString},
+			{"time", "timestamp", kindTime},
+			{"open", "open_price", kindFloat},
+			{"high", "high_price", kindFloat},
+			{"low", "low_price", kindFloat},
+			{"close", "close_price", kindFloat},
+			{"volume", "volume", kindInt},
+			{"bid_open", "bid_open", kindFloat},
+			{"bid_high", "bid_high", kindFloat},
+			{"bid_low", "bid_low", kindFloat},
+			{"bid_close", "bid_close", kindFloat},
+			{"ask_open", "ask_open", kindFloat},
+			{"ask_high", "ask_high", kindFloat},
+			{"ask_low", "ask_low", kindFloat},
+			{"ask_close", "ask_close", kindFloat},
+		},
+	},
+}
+
+// Datasets lists the exportable dataset names.
+func Datasets() []string {
+	out := make([]string, 0, len(datasets))
+	for name := range datasets {
+		out = append(out, name)
+	}
+	sort.Strings(out)
+	return out
+}
+
+// Columns lists the exported column names of a dataset.
+func Columns(name string) ([]string, error) {
+	ds, ok := datasets[name]
+	if !ok {
+		return nil, fmt.Errorf("export: unknown dataset %q", name)
+	}
+	out := make([]string, len(ds.columns))
+	for i, c := range ds.columns {
+		out[i] = c.name
+	}
+	return out, nil
+}
+
+// Filter bounds an export. From and To form a half-open range on the dataset's time column:
+// created_at, or the candle or tick time for market_data and ticks. Instrument and Timeframe
+// apply to the datasets that have them, Entity to audit_logs; setting one a dataset lacks is an
+// error. Limit zero means no limit.
+type Filter struct {
+	From       *time.Time
+	To         *time.Time
+	Instrument string
+	Timeframe  string
+	Entity     string
+	Limit      int
+}
+
+// query builds the SELECT for a dataset and filter.
+func (ds dataset) query(name string, f Filter) (string, []interface{}, error) {
+	exprs := make([]string, len(ds.columns))
+	for i, c := range ds.columns {
+		exprs[i] = c.expr
+	}
+	var conds []string
+	var args []interface{}
+	add := func(cond string, arg interface{}) {
+		args = append(args, arg)
+		conds = append(conds, fmt.Sprintf(cond, len(args)))
+	}
+	if ds.where != "" {
+		conds = append(conds, ds.where)
+	}
+	if f.From != nil {
+		add(ds.timeColumn+" >= $%d", *f.From)
+	}
+	if f.To != nil {
+		add(ds.timeColumn+" < $%d", *f.To)
+	}
+	for _, field := range []struct{ label, column, value string }{
+		{"instrument", ds.instrument, f.Instrument},
+		{"timeframe", ds.timeframe, f.Timeframe},
+		{"entity", ds.entity, f.Entity},
+	} {
+		if field.value == "" {
+			continue
+		}
+		if field.column == "" {
+			return "", nil, fmt.Errorf("export: %s has no %s filter", name, field.label)
+		}
+		add(field.column+" = $%d", field.value)
+	}
+	q := "SELECT " + strings.Join(exprs, ", ") + " FROM " + ds.table
+	if len(conds) > 0 {
+		q += " WHERE " + strings.Join(conds, " AND ")
+	}
+	q += " ORDER BY " + ds.timeColumn + ", " + ds.columns[0].expr
+	if f.Limit > 0 {
+		q += " LIMIT " + strconv.Itoa(f.Limit)
+	}
+	return q, args, nil
+}
+
+// Check reports whether a dataset exists and accepts the filter, so callers can refuse a request
+// before they start writing a response.
+func Check(name string, f Filter) error {
+	ds, ok := datasets[name]
+	if !ok {
+		return fmt.Errorf("export: unknown dataset %q", name)
+	}
+	_, _, err := ds.query(name, f)
+	return err
+}
+
+// Options selects the encoding and the location times are written in; nil means UTC.
+type Options struct {
+	Format   Format
+	Location *time.Location
+}
+
+// flushEvery is how many rows are written between flushes of the underlying writer.
+const flushEvery = 1000
+
+// Export streams a dataset to w and returns the number of rows written. Stores that are also a
+// Source are read through it rather than SQL. When w has a Flush method, such as an HTTP
+// response, it is flushed as rows go out.
+func Export(ctx context.Context, q Querier, w io.Writer, name string, f Filter, opts Options) (int, error) {
+	ds, ok := datasets[name]
+	if !ok {
+		return 0, fmt.Errorf("export: unknown dataset %q", name)
+	}
+	if opts.Location == nil {
+		opts.Location = time.UTC
+	}
+	query, args, err := ds.query(name, f)
+	if err != nil {
+		return 0, err
+	}
+	var enc encoder
+	switch opts.Format {
+	case "", CSV:
+		enc = newCSVEncoder(w, ds.columns, opts.Location)
+	case NDJSON:
+		enc = newNDJSONEncoder(w, ds.columns, opts.Location)
+	case Parquet:
+		if enc, err = newParquetEncoder(w, name, ds.columns); err != nil {
+			return 0, err
+		}
+	default:
+		return 0, fmt.Errorf("export: unknown format %q", opts.Format)
+	}
+	// The header waits for the first row, so a query that fails up front writes nothing and the
+	// caller can still answer with an error.
+	started := false
+	begin := func() error {
+		if started {
+			return nil
+		}
+		started = true
+		return enc.begin()
+	}
+	flusher, _ := w.(interface{ Flush() })
+	n := 0
+	stream := func(fn func(values []interface{}) error) error { return q.StreamRows(ctx, query, args, fn) }
+	if src, ok := q.(Source); ok {
+		stream = func(fn func(values []interface{}) error) error { return streamSource(ctx, src, name, f, fn) }
+	}
+	err = stream(func(values []interface{}) error {
+		if err := begin(); err != nil {
+			return err
+		}
+		if err := enc.row(values); err != nil {
+			return err
+		}
+		n++
+		if n%flushEvery == 0 {
+			if err := enc.flush(); err != nil {
+				return err
+			}
+			if flusher != nil {
+				flusher.Flush()
+			}
+		}
+		return nil
+	})
+	if err != nil && !started {
+		return 0, err
+	}
+	if err == nil {
+		err = begin()
+	}
+	if ferr := enc.end(); err == nil {
+		err = ferr
+	}
+	if flusher != nil {
+		flusher.Flush()
+	}
+	return n, err
+}
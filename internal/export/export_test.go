@@ -0,0 +1,273 @@
+package export
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"io"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/jedi116/go-trader/internal/database"
+	"github.com/jedi116/go-trader/pkg/models"
+	"github.com/parquet-go/parquet-go"
+)
+
+// fakeQuerier hands out rows the way lib/pq scans them: integers as int64, numerics and JSON as
+// bytes, times as time.Time.
+type fakeQuerier struct {
+	rows  [][]interface{}
+	query string
+	err   error
+}
+
+func (q *fakeQuerier) StreamRows(_ context.Context, query string, _ []interface{}, fn func([]interface{}) error) error {
+	q.query = query
+	if q.err != nil {
+		return q.err
+	}
+	for _, r := range q.rows {
+		if err := fn(r); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func auditRows() [][]interface{} {
+	at := time.Date(2026, 10, 5, 12, 30, 0, 0, time.UTC)
+	return [][]interface{}{
+		{int64(1), "trades", "t-1", "CREATE", []byte(`{"units":1000}`), at},
+		{int64(2), "signals", nil, "SIGNAL_ORDER", nil, at.Add(time.Minute)},
+	}
+}
+
+func TestExportText(t *testing.T) {
+	tests := []struct {
+		format Format
+		want   string
+	}{
+		{CSV, "id,entity,entity_id,action,details,created_at\n" +
+			"1,trades,t-1,CREATE,\"{\"\"units\"\":1000}\",2026-10-05T14:30:00+02:00\n" +
+			"2,signals,,SIGNAL_ORDER,,2026-10-05T14:31:00+02:00\n"},
+		{NDJSON, `{"id":1,"entity":"trades","entity_id":"t-1","action":"CREATE","details":{"units":1000},"created_at":"2026-10-05T14:30:00+02:00"}` + "\n" +
+			`{"id":2,"entity":"signals","entity_id":null,"action":"SIGNAL_ORDER","details":null,"created_at":"2026-10-05T14:31:00+02:00"}` + "\n"},
+	}
+	loc, err := time.LoadLocation("Europe/Berlin")
+	if err != nil {
+		t.Skip("no tzdata")
+	}
+	for _, tt := range tests {
+		t.Run(string(tt.format), func(t *testing.T) {
+			var buf bytes.Buffer
+			n, err := Export(context.Background(), &fakeQuerier{rows: auditRows()}, &buf, "audit_logs", Filter{}, Options{Format: tt.format, Location: loc})
+			if err != nil {
+				t.Fatal(err)
+			}
+			if n != 2 || buf.String() != tt.want {
+				t.Errorf("got %d rows:\n%s\nwant:\n%s", n, buf.String(), tt.want)
+			}
+		})
+	}
+}
+
+// A query that fails before its first row writes nothing, not even the header, and an empty
+// result still gets one.
+func TestExportHeader(t *testing.T) {
+	for _, format := range []Format{CSV, NDJSON, Parquet} {
+		var buf bytes.Buffer
+		failed := errors.New("no SQL here")
+		if n, err := Export(context.Background(), &fakeQuerier{err: failed}, &buf, "audit_logs", Filter{}, Options{Format: format}); !errors.Is(err, failed) || n != 0 || buf.Len() != 0 {
+			t.Errorf("%s: failed query = %d, %v, wrote %q", format, n, err, buf.String())
+		}
+	}
+	var buf bytes.Buffer
+	if _, err := Export(context.Background(), &fakeQuerier{}, &buf, "audit_logs", Filter{}, Options{Format: CSV}); err != nil {
+		t.Fatal(err)
+	}
+	if buf.String() != "id,entity,entity_id,action,details,created_at\n" {
+		t.Errorf("empty export = %q", buf.String())
+	}
+}
+
+func TestExportParquet(t *testing.T) {
+	var buf bytes.Buffer
+	q := &fakeQuerier{rows: auditRows()}
+	n, err := Export(context.Background(), q, &buf, "audit_logs", Filter{}, Options{Format: Parquet})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if n != 2 {
+		t.Fatalf("wrote %d rows, want 2", n)
+	}
+	f, err := parquet.OpenFile(bytes.NewReader(buf.Bytes()), int64(buf.Len()))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if f.NumRows() != 2 {
+		t.Fatalf("file has %d rows, want 2", f.NumRows())
+	}
+	r := parquet.NewReader(f)
+	defer r.Close()
+	rows := make([]parquet.Row, 2)
+	if got, err := r.ReadRows(rows); got != 2 || (err != nil && err != io.EOF) {
+		t.Fatalf("read %d rows: %v", got, err)
+	}
+	col := func(name string) int {
+		lc, ok := f.Schema().Lookup(name)
+		if !ok {
+			t.Fatalf("no column %s in %s", name, f.Schema())
+		}
+		return lc.ColumnIndex
+	}
+
+	first, second := rows[0], rows[1]
+	if v := first[col("id")]; v.Int64() != 1 {
+		t.Errorf("id = %v", v)
+	}
+	if v := first[col("entity")]; string(v.ByteArray()) != "trades" {
+		t.Errorf("entity = %v", v)
+	}
+	if v := first[col("details")]; string(v.ByteArray()) != `{"units":1000}` {
+		t.Errorf("details = %v", v)
+	}
+	if v := first[col("created_at")]; !time.UnixMicro(v.Int64()).Equal(time.Date(2026, 10, 5, 12, 30, 0, 0, time.UTC)) {
+		t.Errorf("created_at = %v", time.UnixMicro(v.Int64()).UTC())
+	}
+	for _, name := range []string{"entity_id", "details"} {
+		if v := second[col(name)]; !v.IsNull() {
+			t.Errorf("%s = %v, want null", name, v)
+		}
+	}
+	if !strings.Contains(f.Schema().String(), "(JSON)") || !strings.Contains(f.Schema().String(), "TIMESTAMP") {
+		t.Errorf("schema lacks logical types:\n%s", f.Schema())
+	}
+}
+
+func TestExportParquetFloats(t *testing.T) {
+	at := time.Date(2026, 10, 5, 12, 0, 0, 0, time.UTC)
+	row := make([]interface{}, len(datasets["trades"].columns))
+	for i, c := range datasets["trades"].columns {
+		switch c.name {
+		case "id":
+			row[i] = "t-1"
+		case "units":
+			row[i] = []byte("1000.00")
+		case "entry_price":
+			row[i] = []byte("1.08512")
+		case "created_at":
+			row[i] = at
+		}
+	}
+	var buf bytes.Buffer
+	if _, err := Export(context.Background(), &fakeQuerier{rows: [][]interface{}{row}}, &buf, "trades", Filter{}, Options{Format: Parquet}); err != nil {
+		t.Fatal(err)
+	}
+	f, err := parquet.OpenFile(bytes.NewReader(buf.Bytes()), int64(buf.Len()))
+	if err != nil {
+		t.Fatal(err)
+	}
+	rows := make([]parquet.Row, 1)
+	r := parquet.NewReader(f)
+	defer r.Close()
+	if got, _ := r.ReadRows(rows); got != 1 {
+		t.Fatalf("read %d rows", got)
+	}
+	for name, want := range map[string]float64{"units": 1000, "entry_price": 1.08512} {
+		lc, _ := f.Schema().Lookup(name)
+		if v := rows[0][lc.ColumnIndex]; v.Double() != want {
+			t.Errorf("%s = %v, want %v", name, v, want)
+		}
+	}
+	if lc, _ := f.Schema().Lookup("exit_price"); !rows[0][lc.ColumnIndex].IsNull() {
+		t.Error("exit_price should be null")
+	}
+}
+
+func TestParseFormat(t *testing.T) {
+	for in, want := range map[string]Format{"": CSV, "CSV": CSV, "jsonl": NDJSON, "ndjson": NDJSON, "parquet": Parquet} {
+		if got, err := ParseFormat(in); err != nil || got != want {
+			t.Errorf("ParseFormat(%q) = %q, %v", in, got, err)
+		}
+	}
+	if _, err := ParseFormat("xlsx"); err == nil {
+		t.Error("expected an error for an unknown format")
+	}
+}
+
+var _ Source = (*database.Memory)(nil)
+
+// Memory is exported through its records with the same filters, order and columns as SQL.
+func TestExportMemory(t *testing.T) {
+	ctx := context.Background()
+	at := time.Date(2026, 10, 5, 12, 0, 0, 0, time.UTC)
+	store := database.NewMemory()
+	if _, err := store.InsertTicks(ctx, []models.Tick{
+		{Instrument: "GBP_USD", Time: at, Bid: 1.25, Ask: 1.5},
+		{Instrument: "EUR_USD", Time: at.Add(time.Second), Bid: 1.5, Ask: 1.75},
+		{Instrument: "EUR_USD", Time: at, Bid: 1.5, Ask: 2},
+	}); err != nil {
+		t.Fatal(err)
+	}
+	volume := int64(42)
+	if err := store.UpsertMarketData(ctx, []models.MarketData{
+		{Instrument: "EUR_USD", Timeframe: "H1", Timestamp: at, OpenPrice: 1.1, HighPrice: 1.2, LowPrice: 1, ClosePrice: 1.15, Volume: &volume,
+			Bid: &models.OHLC{Open: 1.09, High: 1.19, Low: 0.99, Close: 1.14}, Ask: &models.OHLC{Open: 1.11, High: 1.21, Low: 1.01, Close: 1.16}},
+		{Instrument: "EUR_USD", Timeframe: "M1", Timestamp: at, OpenPrice: 1.1, HighPrice: 1.1, LowPrice: 1.1, ClosePrice: 1.1},
+	}); err != nil {
+		t.Fatal(err)
+	}
+	for _, inst := range []string{"EUR_USD", "GBP_USD"} {
+		if err := store.CreateTrade(ctx, &models.Trade{ID: inst, Instrument: inst, Direction: "BUY", Units: 1000, Status: models.TradeStatusOpen}); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := store.SoftDeleteTrade(ctx, "GBP_USD"); err != nil {
+		t.Fatal(err)
+	}
+	from := at.Add(time.Second)
+
+	tests := []struct {
+		name    string
+		dataset string
+		filter  Filter
+		want    string
+	}{
+		{"ticks in time then instrument order", "ticks", Filter{},
+			"instrument,time,bid,ask,spread\n" +
+				"EUR_USD,2026-10-05T12:00:00Z,1.5,2,0.5\n" +
+				"GBP_USD,2026-10-05T12:00:00Z,1.25,1.5,0.25\n" +
+				"EUR_USD,2026-10-05T12:00:01Z,1.5,1.75,0.25\n"},
+		{"ticks by instrument and limit", "ticks", Filter{Instrument: "EUR_USD", Limit: 1},
+			"instrument,time,bid,ask,spread\nEUR_USD,2026-10-05T12:00:00Z,1.5,2,0.5\n"},
+		{"ticks from a time", "ticks", Filter{From: &from},
+			"instrument,time,bid,ask,spread\nEUR_USD,2026-10-05T12:00:01Z,1.5,1.75,0.25\n"},
+		{"candles with bid and ask", "market_data", Filter{Timeframe: "H1"},
+			"instrument,timeframe,time,open,high,low,close,volume,bid_open,bid_high,bid_low,bid_close,ask_open,ask_high,ask_low,ask_close\n" +
+				"EUR_USD,H1,2026-10-05T12:00:00Z,1.1,1.2,1,1.15,42,1.09,1.19,0.99,1.14,1.11,1.21,1.01,1.16\n"},
+		{"candles without bid and ask", "market_data", Filter{Timeframe: "M1"},
+			"instrument,timeframe,time,open,high,low,close,volume,bid_open,bid_high,bid_low,bid_close,ask_open,ask_high,ask_low,ask_close\n" +
+				"EUR_USD,M1,2026-10-05T12:00:00Z,1.1,1.1,1.1,1.1,,,,,,,,,\n"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var buf bytes.Buffer
+			if _, err := Export(ctx, store, &buf, tt.dataset, tt.filter, Options{}); err != nil {
+				t.Fatal(err)
+			}
+			if buf.String() != tt.want {
+				t.Errorf("got:\n%s\nwant:\n%s", buf.String(), tt.want)
+			}
+		})
+	}
+
+	// Deleted trades are left out; the audit log has the creates and the delete.
+	for dataset, want := range map[string]int{"trades": 1, "audit_logs": 3} {
+		var buf bytes.Buffer
+		n, err := Export(ctx, store, &buf, dataset, Filter{}, Options{Format: NDJSON})
+		if err != nil || n != want {
+			t.Errorf("%s: %d rows, %v; want %d", dataset, n, err, want)
+		}
+	}
+}
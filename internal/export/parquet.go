@@ -0,0 +1,107 @@
+package export
+
+import (
+	"fmt"
+	"io"
+	"strconv"
+	"time"
+
+	"github.com/parquet-go/parquet-go"
+)
+
+// parquetEncoder writes every column as optional: numerics as DOUBLE or INT64, times as UTC
+// TIMESTAMP(MICROS), JSON as JSON-annotated byte arrays and the rest as strings. Rows are
+// buffered into row groups by the writer, and the footer is written by end.
+type parquetEncoder struct {
+	w    *parquet.Writer
+	cols []column
+	// leaf is the schema column index of each dataset column; Parquet groups order fields by name.
+	leaf []int
+	buf  parquet.Row
+}
+
+func parquetNode(k kind) parquet.Node {
+	switch k {
+	case kindFloat:
+		return parquet.Optional(parquet.Leaf(parquet.DoubleType))
+	case kindInt:
+		return parquet.Optional(parquet.Int(64))
+	case kindTime:
+		return parquet.Optional(parquet.Timestamp(parquet.Microsecond))
+	case kindJSON:
+		return parquet.Optional(parquet.JSON())
+	}
+	return parquet.Optional(parquet.String())
+}
+
+func newParquetEncoder(w io.Writer, name string, cols []column) (*parquetEncoder, error) {
+	group := make(parquet.Group, len(cols))
+	for _, c := range cols {
+		group[c.name] = parquetNode(c.kind)
+	}
+	schema := parquet.NewSchema(name, group)
+	leaf := make([]int, len(cols))
+	for i, c := range cols {
+		lc, ok := schema.Lookup(c.name)
+		if !ok {
+			return nil, fmt.Errorf("export: parquet schema has no column %s", c.name)
+		}
+		leaf[i] = lc.ColumnIndex
+	}
+	return &parquetEncoder{
+		w:    parquet.NewWriter(w, schema, parquet.Compression(&parquet.Snappy)),
+		cols: cols,
+		leaf: leaf,
+		buf:  make(parquet.Row, len(cols)),
+	}, nil
+}
+
+func (e *parquetEncoder) begin() error { return nil }
+
+func (e *parquetEncoder) row(values []interface{}) error {
+	for i, c := range e.cols {
+		v, ok, err := parquetValue(values[i], c.kind)
+		if err != nil {
+			return fmt.Errorf("export: column %s: %w", c.name, err)
+		}
+		if !ok {
+			e.buf[e.leaf[i]] = parquet.NullValue().Level(0, 0, e.leaf[i])
+			continue
+		}
+		e.buf[e.leaf[i]] = v.Level(0, 1, e.leaf[i])
+	}
+	_, err := e.w.WriteRows([]parquet.Row{e.buf})
+	return err
+}
+
+// parquetValue converts a scanned value to the column's physical type; ok is false for NULL.
+func parquetValue(v interface{}, k kind) (parquet.Value, bool, error) {
+	if v == nil {
+		return parquet.Value{}, false, nil
+	}
+	switch k {
+	case kindFloat:
+		f, err := strconv.ParseFloat(number(v, k), 64)
+		return parquet.ValueOf(f), err == nil, err
+	case kindInt:
+		n, err := strconv.ParseInt(number(v, k), 10, 64)
+		return parquet.ValueOf(n), err == nil, err
+	case kindTime:
+		t, ok := v.(time.Time)
+		if !ok {
+			var err error
+			if t, err = time.Parse(time.RFC3339Nano, text(v)); err != nil {
+				return parquet.Value{}, false, err
+			}
+		}
+		return parquet.ValueOf(t.UnixMicro()), true, nil
+	case kindJSON:
+		return parquet.ValueOf([]byte(text(v))), true, nil
+	}
+	return parquet.ValueOf(text(v)), true, nil
+}
+
+// flush is a no-op: flushing the writer would cut a row group every flushEvery rows.
+func (e *parquetEncoder) flush() error { return nil }
+
+func (e *parquetEncoder) end() error { return e.w.Close() }
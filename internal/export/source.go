@@ -0,0 +1,178 @@
+package export
+
+import (
+	"context"
+	"sort"
+	"time"
+
+	"github.com/jedi116/go-trader/pkg/models"
+)
+
+// Source hands over each dataset's records for stores without SQL; database.Memory implements
+// it. Export prefers it to StreamRows and applies the filter, order and limit itself, with the
+// same results as the SQL query.
+type Source interface {
+	ExportTrades(ctx context.Context) ([]models.Trade, error)
+	ExportAIRecommendations(ctx context.Context) ([]models.AIRecommendation, error)
+	ExportAuditLogs(ctx context.Context) ([]models.AuditLog, error)
+	ExportTicks(ctx context.Context) ([]models.Tick, error)
+	ExportMarketData(ctx context.Context) ([]models.MarketData, error)
+}
+
+// record is one row read from a Source, with the fields its filters compare.
+type record struct {
+	at                            time.Time
+	instrument, timeframe, entity string
+	values                        []interface{}
+}
+
+// streamSource hands the records of a dataset to fn as StreamRows would hand over rows.
+func streamSource(ctx context.Context, src Source, name string, f Filter, fn func(values []interface{}) error) error {
+	recs, err := sourceRecords(ctx, src, name)
+	if err != nil {
+		return err
+	}
+	kept := recs[:0]
+	for _, r := range recs {
+		switch {
+		case f.From != nil && r.at.Before(*f.From),
+			f.To != nil && !r.at.Before(*f.To),
+			f.Instrument != "" && r.instrument != f.Instrument,
+			f.Timeframe != "" && r.timeframe != f.Timeframe,
+			f.Entity != "" && r.entity != f.Entity:
+			continue
+		}
+		kept = append(kept, r)
+	}
+	sort.SliceStable(kept, func(i, j int) bool {
+		if !kept[i].at.Equal(kept[j].at) {
+			return kept[i].at.Before(kept[j].at)
+		}
+		return lessValue(kept[i].values[0], kept[j].values[0])
+	})
+	if f.Limit > 0 && len(kept) > f.Limit {
+		kept = kept[:f.Limit]
+	}
+	for _, r := range kept {
+		if err := fn(r.values); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// lessValue orders the first columns of two rows: audit ids as numbers, the rest as text.
+func lessValue(a, b interface{}) bool {
+	if x, ok := a.(int64); ok {
+		if y, ok := b.(int64); ok {
+			return x < y
+		}
+	}
+	return text(a) < text(b)
+}
+
+// sourceRecords converts a dataset's records to rows in the dataset's column order.
+func sourceRecords(ctx context.Context, src Source, name string) ([]record, error) {
+	var out []record
+	switch name {
+	case "trades":
+		trades, err := src.ExportTrades(ctx)
+		if err != nil {
+			return nil, err
+		}
+		for _, t := range trades {
+			out = append(out, record{at: t.CreatedAt, instrument: t.Instrument, values: []interface{}{
+				t.ID, t.Instrument, t.Direction, t.Units, optFloat(t.EntryPrice), optFloat(t.ExitPrice), optFloat(t.ProfitLoss),
+				optFloat(t.Commission), optFloat(t.Swap), string(t.Status), t.Source, optString(t.OandaTradeID),
+				t.CreatedAt, t.UpdatedAt, optTime(t.ClosedAt),
+			}})
+		}
+	case "ai_recommendations":
+		recs, err := src.ExportAIRecommendations(ctx)
+		if err != nil {
+			return nil, err
+		}
+		for _, r := range recs {
+			out = append(out, record{at: r.CreatedAt, instrument: r.Instrument, values: []interface{}{
+				r.ID, r.Instrument, r.Direction, r.Units, r.Confidence, r.Rationale, optFloat(r.StopLoss), optFloat(r.TakeProfit),
+				string(r.Status), optJSON(r.MarketContext), optJSON(r.NewsContext), optJSON(r.HistoricalContext),
+				optString(r.ExecutedTradeID), r.TimeToLive, optTime(r.ApprovedAt), r.CreatedAt, r.UpdatedAt,
+			}})
+		}
+	case "audit_logs":
+		logs, err := src.ExportAuditLogs(ctx)
+		if err != nil {
+			return nil, err
+		}
+		for _, l := range logs {
+			var entityID interface{}
+			if l.EntityID != "" {
+				entityID = l.EntityID
+			}
+			out = append(out, record{at: l.CreatedAt, entity: l.Entity, values: []interface{}{
+				l.ID, l.Entity, entityID, l.Action, optJSON(l.Details), l.CreatedAt,
+			}})
+		}
+	case "ticks":
+		ticks, err := src.ExportTicks(ctx)
+		if err != nil {
+			return nil, err
+		}
+		for _, t := range ticks {
+			out = append(out, record{at: t.Time, instrument: t.Instrument, values: []interface{}{
+				t.Instrument, t.Time, t.Bid, t.Ask, t.Spread(),
+			}})
+		}
+	case "market_data":
+		candles, err := src.ExportMarketData(ctx)
+		if err != nil {
+			return nil, err
+		}
+		for _, c := range candles {
+			var volume interface{}
+			if c.Volume != nil {
+				volume = *c.Volume
+			}
+			values := []interface{}{c.Instrument, c.Timeframe, c.Timestamp, c.OpenPrice, c.HighPrice, c.LowPrice, c.ClosePrice, volume}
+			values = append(values, ohlcValues(c.Bid)...)
+			values = append(values, ohlcValues(c.Ask)...)
+			out = append(out, record{at: c.Timestamp, instrument: c.Instrument, timeframe: c.Timeframe, values: values})
+		}
+	}
+	return out, nil
+}
+
+func optFloat(v *float64) interface{} {
+	if v == nil {
+		return nil
+	}
+	return *v
+}
+
+func optString(v *string) interface{} {
+	if v == nil {
+		return nil
+	}
+	return *v
+}
+
+func optTime(v *time.Time) interface{} {
+	if v == nil {
+		return nil
+	}
+	return *v
+}
+
+func optJSON(v []byte) interface{} {
+	if len(v) == 0 {
+		return nil
+	}
+	return v
+}
+
+func ohlcValues(o *models.OHLC) []interface{} {
+	if o == nil {
+		return []interface{}{nil, nil, nil, nil}
+	}
+	return []interface{}{o.Open, o.High, o.Low, o.Close}
+}
@@ -0,0 +1,129 @@
+// Package indicators computes technical indicators over close series. Each function returns a
+// series as long as its input, with NaN for the bars before the indicator has warmed up.
+package indicators
+
+import (
+	"fmt"
+	"math"
+	"strings"
+)
+
+// SMA is the simple moving average over period bars.
+func SMA(xs []float64, period int) []float64 {
+	out := nans(len(xs))
+	if period <= 0 {
+		return out
+	}
+	sum := 0.0
+	for i, x := range xs {
+		sum += x
+		if i >= period {
+			sum -= xs[i-period]
+		}
+		if i >= period-1 {
+			out[i] = sum / float64(period)
+		}
+	}
+	return out
+}
+
+// EMA is the exponential moving average seeded with the SMA of the first period bars.
+func EMA(xs []float64, period int) []float64 {
+	out := nans(len(xs))
+	if period <= 0 || len(xs) < period {
+		return out
+	}
+	k := 2 / float64(period+1)
+	seed := 0.0
+	for _, x := range xs[:period] {
+		seed += x
+	}
+	out[period-1] = seed / float64(period)
+	for i := period; i < len(xs); i++ {
+		out[i] = xs[i]*k + out[i-1]*(1-k)
+	}
+	return out
+}
+
+// RSI is Wilder's relative strength index, 0-100.
+func RSI(xs []float64, period int) []float64 {
+	out := nans(len(xs))
+	if period <= 0 || len(xs) <= period {
+		return out
+	}
+	gain, loss := 0.0, 0.0
+	for i := 1; i <= period; i++ {
+		d := xs[i] - xs[i-1]
+		if d > 0 {
+			gain += d
+		} else {
+			loss -= d
+		}
+	}
+	gain /= float64(period)
+	loss /= float64(period)
+	out[period] = rsi(gain, loss)
+	for i := period + 1; i < len(xs); i++ {
+		d := xs[i] - xs[i-1]
+		g, l := 0.0, 0.0
+		if d > 0 {
+			g = d
+		} else {
+			l = -d
+		}
+		gain = (gain*float64(period-1) + g) / float64(period)
+		loss = (loss*float64(period-1) + l) / float64(period)
+		out[i] = rsi(gain, loss)
+	}
+	return out
+}
+
+func rsi(gain, loss float64) float64 {
+	if loss == 0 {
+		if gain == 0 {
+			return 50
+		}
+		return 100
+	}
+	return 100 - 100/(1+gain/loss)
+}
+
+// Compute evaluates an indicator by name (SMA, EMA or RSI, case-insensitive).
+func Compute(name string, xs []float64, period int) ([]float64, error) {
+	switch strings.ToUpper(name) {
+	case "SMA":
+		return SMA(xs, period), nil
+	case "EMA":
+		return EMA(xs, period), nil
+	case "RSI":
+		return RSI(xs, period), nil
+	}
+	return nil, fmt.Errorf("unknown indicator %q", name)
+}
+
+// Warmup is how many bars an indicator needs before its values settle; RSI and EMA are
+// recursive, so a few periods of history are used to damp the seed.
+func Warmup(name string, period int) int {
+	switch strings.ToUpper(name) {
+	case "SMA":
+		return period
+	default:
+		return 3*period + 1
+	}
+}
+
+// Last returns the final value of a series, NaN when empty.
+func Last(xs []float64) float64 {
+	if len(xs) == 0 {
+		return math.NaN()
+	}
+	return xs[len(xs)-1]
+}
+
+func nans(n int) []float64 {
+	out := make([]float64, n)
+	for i := range out {
+		out[i] = math.NaN()
+	}
+	return out
+}
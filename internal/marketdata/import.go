@@ -0,0 +1,554 @@
+package marketdata
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/jedi116/go-trader/pkg/models"
+)
+
+// Layout is an input file layout.
+type Layout string
+
+const (
+	// LayoutAuto sniffs the layout from the first line.
+	LayoutAuto Layout = ""
+	// LayoutMetaTrader is a MetaTrader 4 History Center export (date,time,open,high,low,close,
+	// volume without a header) or a MetaTrader 5 export with its <DATE> <TIME> ... header.
+	LayoutMetaTrader Layout = "metatrader"
+	// LayoutGeneric is delimited text with a header naming the columns, or without one in the
+	// order timestamp,open,high,low,close[,volume].
+	LayoutGeneric Layout = "generic"
+	// LayoutNDJSON is one JSON object per line with the same field names as the generic header.
+	LayoutNDJSON Layout = "ndjson"
+)
+
+// ParseLayout accepts auto (or empty), metatrader (mt4, mt5), generic (csv) and ndjson (jsonl).
+func ParseLayout(s string) (Layout, error) {
+	switch strings.ToLower(strings.TrimSpace(s)) {
+	case "", "auto":
+		return LayoutAuto, nil
+	case "metatrader", "mt4", "mt5":
+		return LayoutMetaTrader, nil
+	case "generic", "csv":
+		return LayoutGeneric, nil
+	case "ndjson", "jsonl":
+		return LayoutNDJSON, nil
+	}
+	return "", fmt.Errorf("unknown layout %q", s)
+}
+
+// ErrStore wraps failures to write candles, as opposed to problems with the input.
+var ErrStore = errors.New("marketdata: store")
+
+// Store upserts candles on (instrument, timestamp, timeframe); database.Store implements it.
+type Store interface {
+	UpsertMarketData(ctx context.Context, rows []models.MarketData) error
+}
+
+type Options struct {
+	Layout Layout
+	// Instrument and Timeframe apply to rows without their own; a file without those columns
+	// needs both.
+	Instrument string
+	Timeframe  string
+	// Symbols maps file symbols onto instruments before NormalizeInstrument, e.g. for broker
+	// suffixes such as EURUSD.m.
+	Symbols map[string]string
+	// Location is the zone of times written without an offset; nil means UTC. MetaTrader exports
+	// are in the broker's server time.
+	Location *time.Location
+	// BatchSize is the number of candles per upsert; zero means 5000.
+	BatchSize int
+	// MaxErrors aborts the import once more rows than this were rejected; zero never aborts.
+	MaxErrors int
+	// DryRun parses and validates without writing.
+	DryRun bool
+}
+
+// RowError is a rejected input line.
+type RowError struct {
+	Line  int    `json:"line"`
+	Error string `json:"error"`
+}
+
+// maxRowErrors bounds the rejected rows listed in a Result; Rejected still counts them all.
+const maxRowErrors = 50
+
+// Result summarizes an import.
+type Result struct {
+	Layout      Layout     `json:"layout"`
+	Read        int        `json:"read"`
+	Imported    int        `json:"imported"`
+	Rejected    int        `json:"rejected"`
+	Errors      []RowError `json:"errors,omitempty"`
+	Instruments []string   `json:"instruments"`
+	Timeframes  []string   `json:"timeframes"`
+	From        *time.Time `json:"from,omitempty"`
+	To          *time.Time `json:"to,omitempty"`
+	DryRun      bool       `json:"dry_run,omitempty"`
+}
+
+// fields maps header names onto the canonical field names; several volume spellings exist
+// because MetaTrader 5 writes both tick and real volume.
+var fields = map[string]string{
+	"time": "time", "timestamp": "time", "datetime": "time", "date_time": "time",
+	"date": "date",
+	"open": "open", "o": "open",
+	"high": "high", "h": "high",
+	"low": "low", "l": "low",
+	"close": "close", "c": "close",
+	"volume": "volume", "tickvol": "tickvol", "tick_volume": "tickvol", "tickvolume": "tickvol",
+	"vol": "vol", "v": "vol",
+	"instrument": "instrument", "symbol": "instrument", "ticker": "instrument",
+	"timeframe": "timeframe", "granularity": "timeframe", "period": "timeframe",
+	"bid_open": "bid_open", "bid_high": "bid_high", "bid_low": "bid_low", "bid_close": "bid_close",
+	"ask_open": "ask_open", "ask_high": "ask_high", "ask_low": "ask_low", "ask_close": "ask_close",
+}
+
+// volumeFields is the order volume columns are preferred in: real volume is zero for FX in
+// MetaTrader 5 exports, so tick volume comes before it.
+var volumeFields = []string{"volume", "tickvol", "vol"}
+
+func canonical(name string) string {
+	name = strings.ToLower(strings.TrimSpace(name))
+	name = strings.Trim(name, "<>\"")
+	return fields[strings.ReplaceAll(name, " ", "_")]
+}
+
+// record is one input row as text by canonical field name.
+type record map[string]string
+
+// importer turns records into candles and writes them in batches.
+type importer struct {
+	ctx   context.Context
+	store Store
+	opts  Options
+	res   *Result
+	batch []models.MarketData
+
+	instruments map[string]bool
+	timeframes  map[string]bool
+}
+
+// Import reads candles from r and upserts them. Rows that fail to parse or validate are skipped
+// and reported; the returned error is for failures that stop the import, with the result so far.
+func Import(ctx context.Context, r io.Reader, store Store, opts Options) (*Result, error) {
+	if opts.Location == nil {
+		opts.Location = time.UTC
+	}
+	if opts.BatchSize <= 0 {
+		opts.BatchSize = 5000
+	}
+	if opts.Timeframe != "" {
+		tf, err := NormalizeTimeframe(opts.Timeframe)
+		if err != nil {
+			return nil, err
+		}
+		opts.Timeframe = tf
+	}
+	imp := &importer{
+		ctx: ctx, store: store, opts: opts,
+		res:         &Result{Layout: opts.Layout, DryRun: opts.DryRun},
+		instruments: make(map[string]bool),
+		timeframes:  make(map[string]bool),
+	}
+	if opts.Instrument != "" {
+		imp.opts.Instrument = imp.instrument(opts.Instrument)
+	}
+
+	br := bufio.NewReaderSize(r, 64*1024)
+	layout := opts.Layout
+	if layout == LayoutAuto {
+		first, err := peekLine(br)
+		if err != nil {
+			return nil, err
+		}
+		layout = sniffLayout(first)
+		imp.res.Layout = layout
+	}
+	var err error
+	if layout == LayoutNDJSON {
+		err = imp.readNDJSON(br)
+	} else {
+		err = imp.readDelimited(br, layout)
+	}
+	if err == nil {
+		err = imp.flush()
+	}
+	imp.finish()
+	return imp.res, err
+}
+
+// peekLine returns the first non-blank line without consuming it.
+func peekLine(br *bufio.Reader) (string, error) {
+	for n := 512; ; n *= 2 {
+		buf, err := br.Peek(n)
+		trimmed := bytes.TrimLeft(buf, " \t\r\n\ufeff")
+		if i := bytes.IndexByte(trimmed, '\n'); i >= 0 {
+			return string(trimmed[:i]), nil
+		}
+		if err != nil {
+			if len(trimmed) == 0 {
+				return "", fmt.Errorf("empty input")
+			}
+			return string(trimmed), nil
+		}
+		if n >= br.Size() {
+			return string(trimmed), nil
+		}
+	}
+}
+
+func sniffLayout(first string) Layout {
+	first = strings.TrimPrefix(strings.TrimSpace(first), "\ufeff")
+	switch {
+	case strings.HasPrefix(first, "{"):
+		return LayoutNDJSON
+	case strings.HasPrefix(first, "<"):
+		return LayoutMetaTrader
+	}
+	f := strings.Split(first, string(sniffDelimiter(first)))
+	if len(f) > 1 && len(f[0]) == 10 && f[0][4] == '.' && strings.Contains(f[1], ":") {
+		return LayoutMetaTrader
+	}
+	return LayoutGeneric
+}
+
+func sniffDelimiter(line string) rune {
+	best, count := ',', strings.Count(line, ",")
+	for _, d := range []rune{'\t', ';'} {
+		if n := strings.Count(line, string(d)); n > count {
+			best, count = d, n
+		}
+	}
+	return best
+}
+
+// isHeader reports whether a first row names columns rather than holding data.
+func isHeader(rec []string) bool {
+	for _, f := range rec {
+		if canonical(f) != "" {
+			return true
+		}
+	}
+	return false
+}
+
+func (imp *importer) readDelimited(br *bufio.Reader, layout Layout) error {
+	first, err := peekLine(br)
+	if err != nil {
+		return err
+	}
+	cr := csv.NewReader(br)
+	cr.Comma = sniffDelimiter(first)
+	cr.FieldsPerRecord = -1
+	cr.LazyQuotes = true
+	cr.TrimLeadingSpace = true
+	cr.ReuseRecord = true
+
+	var columns []string
+	for line := 1; ; line++ {
+		rec, err := cr.Read()
+		if err == io.EOF {
+			return nil
+		}
+		if err != nil {
+			var pe *csv.ParseError
+			if errors.As(err, &pe) {
+				imp.res.Read++
+				if imp.reject(line, err) {
+					return imp.tooManyErrors()
+				}
+				continue
+			}
+			return err
+		}
+		if len(rec) == 1 && strings.TrimSpace(rec[0]) == "" {
+			continue
+		}
+		if columns == nil {
+			rec[0] = strings.TrimPrefix(rec[0], "\ufeff")
+			if isHeader(rec) {
+				columns = make([]string, len(rec))
+				for i, name := range rec {
+					columns[i] = canonical(name)
+				}
+				if err := checkColumns(columns); err != nil {
+					return err
+				}
+				continue
+			}
+			columns = positional(rec, layout)
+		}
+		r := make(record, len(columns))
+		for i, name := range columns {
+			if name != "" && i < len(rec) {
+				r[name] = strings.TrimSpace(rec[i])
+			}
+		}
+		if err := imp.add(line, r); err != nil {
+			return err
+		}
+	}
+}
+
+// positional names the columns of a headerless file: MetaTrader 4 splits date and time, generic
+// files start with one timestamp.
+func positional(first []string, layout Layout) []string {
+	if layout == LayoutMetaTrader || (len(first) > 1 && strings.Contains(first[1], ":")) {
+		return []string{"date", "clock", "open", "high", "low", "close", "vol"}
+	}
+	return []string{"time", "open", "high", "low", "close", "vol"}
+}
+
+// checkColumns requires a time and the four prices. A header with separate date and time
+// columns, as MetaTrader 5 writes, reads the time column as the time of day.
+func checkColumns(columns []string) error {
+	has := make(map[string]bool, len(columns))
+	for _, c := range columns {
+		has[c] = true
+	}
+	if has["date"] && has["time"] {
+		for i, c := range columns {
+			if c == "time" {
+				columns[i] = "clock"
+			}
+		}
+		has["time"] = false
+	}
+	if !has["time"] && !has["date"] {
+		return fmt.Errorf("header has no time column")
+	}
+	for _, c := range []string{"open", "high", "low", "close"} {
+		if !has[c] {
+			return fmt.Errorf("header has no %s column", c)
+		}
+	}
+	return nil
+}
+
+func (imp *importer) readNDJSON(br *bufio.Reader) error {
+	sc := bufio.NewScanner(br)
+	sc.Buffer(make([]byte, 0, 64*1024), 16*1024*1024)
+	for line := 1; sc.Scan(); line++ {
+		raw := bytes.TrimSpace(sc.Bytes())
+		if len(raw) == 0 {
+			continue
+		}
+		var obj map[string]json.RawMessage
+		if err := json.Unmarshal(raw, &obj); err != nil {
+			imp.res.Read++
+			if imp.reject(line, err) {
+				return imp.tooManyErrors()
+			}
+			continue
+		}
+		r := make(record, len(obj))
+		for k, v := range obj {
+			name := canonical(k)
+			if name == "" {
+				// Nested bid/ask candles, as the API returns them.
+				if side := strings.ToLower(k); side == "bid" || side == "ask" {
+					var o map[string]json.RawMessage
+					if json.Unmarshal(v, &o) == nil {
+						for ok, ov := range o {
+							if f := canonical(ok); f == "open" || f == "high" || f == "low" || f == "close" {
+								r[side+"_"+f] = jsonText(ov)
+							}
+						}
+					}
+				}
+				continue
+			}
+			r[name] = jsonText(v)
+		}
+		if r["date"] != "" && r["time"] != "" {
+			r["clock"], r["time"] = r["time"], ""
+		}
+		if err := imp.add(line, r); err != nil {
+			return err
+		}
+	}
+	return sc.Err()
+}
+
+// jsonText returns a JSON string's contents or a number's literal; null is empty.
+func jsonText(v json.RawMessage) string {
+	var s string
+	if json.Unmarshal(v, &s) == nil {
+		return s
+	}
+	if t := string(bytes.TrimSpace(v)); t != "null" {
+		return t
+	}
+	return ""
+}
+
+func (imp *importer) instrument(s string) string {
+	if mapped, ok := imp.opts.Symbols[s]; ok {
+		return mapped
+	}
+	if mapped, ok := imp.opts.Symbols[strings.ToUpper(s)]; ok {
+		return mapped
+	}
+	return NormalizeInstrument(s)
+}
+
+// candle converts a record, applying the defaults and mappings.
+func (imp *importer) candle(r record) (models.MarketData, error) {
+	var c models.MarketData
+	stamp := r["time"]
+	if stamp == "" {
+		stamp = r["date"]
+		if r["clock"] != "" {
+			stamp += " " + r["clock"]
+		}
+	}
+	if stamp == "" {
+		return c, fmt.Errorf("missing time")
+	}
+	t, err := ParseTime(stamp, imp.opts.Location)
+	if err != nil {
+		return c, err
+	}
+	c.Timestamp = t
+
+	c.Instrument = imp.opts.Instrument
+	if v := r["instrument"]; v != "" {
+		c.Instrument = imp.instrument(v)
+	}
+	if c.Instrument == "" {
+		return c, fmt.Errorf("no instrument in the row and none given")
+	}
+	c.Timeframe = imp.opts.Timeframe
+	if v := r["timeframe"]; v != "" {
+		if c.Timeframe, err = NormalizeTimeframe(v); err != nil {
+			return c, err
+		}
+	}
+	if c.Timeframe == "" {
+		return c, fmt.Errorf("no timeframe in the row and none given")
+	}
+
+	prices := map[string]*float64{"open": &c.OpenPrice, "high": &c.HighPrice, "low": &c.LowPrice, "close": &c.ClosePrice}
+	for name, dst := range prices {
+		if *dst, err = strconv.ParseFloat(r[name], 64); err != nil {
+			return c, fmt.Errorf("invalid %s %q", name, r[name])
+		}
+	}
+	// The first non-zero volume column wins; all zeros still record a zero volume.
+	for _, name := range volumeFields {
+		v := r[name]
+		if v == "" {
+			continue
+		}
+		f, err := strconv.ParseFloat(v, 64)
+		if err != nil {
+			return c, fmt.Errorf("invalid volume %q", v)
+		}
+		if n := int64(f); c.Volume == nil || *c.Volume == 0 {
+			c.Volume = &n
+		}
+	}
+	if c.Bid, err = side(r, "bid"); err != nil {
+		return c, err
+	}
+	if c.Ask, err = side(r, "ask"); err != nil {
+		return c, err
+	}
+	return c, Validate(c)
+}
+
+// side reads an optional bid or ask candle; it must be complete when any of it is present.
+func side(r record, name string) (*models.OHLC, error) {
+	vals := [4]string{r[name+"_open"], r[name+"_high"], r[name+"_low"], r[name+"_close"]}
+	if vals == [4]string{} {
+		return nil, nil
+	}
+	var f [4]float64
+	for i, v := range vals {
+		var err error
+		if f[i], err = strconv.ParseFloat(v, 64); err != nil {
+			return nil, fmt.Errorf("incomplete or invalid %s prices", name)
+		}
+	}
+	return &models.OHLC{Open: f[0], High: f[1], Low: f[2], Close: f[3]}, nil
+}
+
+func (imp *importer) add(line int, r record) error {
+	imp.res.Read++
+	c, err := imp.candle(r)
+	if err != nil {
+		if imp.reject(line, err) {
+			return imp.tooManyErrors()
+		}
+		return nil
+	}
+	imp.instruments[c.Instrument] = true
+	imp.timeframes[c.Timeframe] = true
+	if imp.res.From == nil || c.Timestamp.Before(*imp.res.From) {
+		t := c.Timestamp
+		imp.res.From = &t
+	}
+	if imp.res.To == nil || c.Timestamp.After(*imp.res.To) {
+		t := c.Timestamp
+		imp.res.To = &t
+	}
+	imp.batch = append(imp.batch, c)
+	if len(imp.batch) >= imp.opts.BatchSize {
+		return imp.flush()
+	}
+	return nil
+}
+
+// reject records a bad row and reports whether MaxErrors is exceeded.
+func (imp *importer) reject(line int, err error) bool {
+	imp.res.Rejected++
+	if len(imp.res.Errors) < maxRowErrors {
+		imp.res.Errors = append(imp.res.Errors, RowError{Line: line, Error: err.Error()})
+	}
+	return imp.opts.MaxErrors > 0 && imp.res.Rejected > imp.opts.MaxErrors
+}
+
+func (imp *importer) tooManyErrors() error {
+	return fmt.Errorf("import aborted after %d rejected rows", imp.res.Rejected)
+}
+
+func (imp *importer) flush() error {
+	if len(imp.batch) == 0 {
+		return nil
+	}
+	if !imp.opts.DryRun {
+		if err := imp.store.UpsertMarketData(imp.ctx, imp.batch); err != nil {
+			return fmt.Errorf("%w: %w", ErrStore, err)
+		}
+	}
+	imp.res.Imported += len(imp.batch)
+	imp.batch = imp.batch[:0]
+	return nil
+}
+
+func (imp *importer) finish() {
+	imp.res.Instruments = sortedKeys(imp.instruments)
+	imp.res.Timeframes = sortedKeys(imp.timeframes)
+}
+
+func sortedKeys(m map[string]bool) []string {
+	out := make([]string, 0, len(m))
+	for k := range m {
+		out = append(out, k)
+	}
+	sort.Strings(out)
+	return out
+}
@@ -0,0 +1,157 @@
+package marketdata
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/jedi116/go-trader/pkg/models"
+)
+
+// captureStore keeps every upserted candle.
+type captureStore struct{ rows []models.MarketData }
+
+func (s *captureStore) UpsertMarketData(ctx context.Context, rows []models.MarketData) error {
+	s.rows = append(s.rows, rows...)
+	return nil
+}
+
+type wantCandle struct {
+	instrument, timeframe  string
+	at                     time.Time
+	open, high, low, close float64
+	volume                 *int64
+	quoted                 bool
+}
+
+func importFixture(t *testing.T, name string, opts Options) (*Result, []models.MarketData) {
+	t.Helper()
+	f, err := os.Open(filepath.Join("testdata", name))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+	store := &captureStore{}
+	res, err := Import(context.Background(), f, store, opts)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return res, store.rows
+}
+
+func TestImportFixtures(t *testing.T) {
+	helsinki, err := time.LoadLocation("Europe/Helsinki")
+	if err != nil {
+		t.Fatal(err)
+	}
+	newYork, err := time.LoadLocation("America/New_York")
+	if err != nil {
+		t.Fatal(err)
+	}
+	vol := func(n int64) *int64 { return &n }
+	utc := func(day, hour, min int) time.Time { return time.Date(2025, 3, day, hour, min, 0, 0, time.UTC) }
+	h1 := func(at time.Time, o, h, l, c float64, v *int64) wantCandle {
+		return wantCandle{"EUR_USD", "H1", at, o, h, l, c, v, false}
+	}
+	tests := []struct {
+		file     string
+		opts     Options
+		layout   Layout
+		read     int
+		rejected []int
+		candles  []wantCandle
+	}{
+		// MetaTrader 4 without a header, in the server's zone: UTC+2 before the 30 March change
+		// and UTC+3 after it. The last two rows have the high below the close and a zero low.
+		{"mt4.csv", Options{Instrument: "EURUSD", Timeframe: "H1", Location: helsinki}, LayoutMetaTrader, 4, []int{3, 4}, []wantCandle{
+			h1(utc(28, 20, 0), 1.08, 1.082, 1.079, 1.081, vol(1234)),
+			h1(utc(31, 6, 0), 1.081, 1.083, 1.08, 1.0825, vol(987)),
+		}},
+		// MetaTrader 5 with its <DATE> <TIME> header; real volume is zero, so tick volume is used.
+		{"mt5.csv", Options{Instrument: "EUR_USD", Timeframe: "60", Location: helsinki}, LayoutMetaTrader, 2, nil, []wantCandle{
+			h1(utc(31, 6, 0), 1.081, 1.083, 1.08, 1.0825, vol(987)),
+			h1(utc(31, 7, 0), 1.0825, 1.084, 1.082, 1.083, vol(0)),
+		}},
+		// Offsets are kept, bare integers are Unix seconds or milliseconds and the rest is New
+		// York time. The last two rows have the low above the high and an unknown timeframe.
+		{"generic.csv", Options{Location: newYork, Symbols: map[string]string{"EURUSD.m": "EUR_USD"}}, LayoutGeneric, 7, []int{7, 8}, []wantCandle{
+			h1(utc(7, 13, 0), 1.08, 1.082, 1.079, 1.081, vol(100)),
+			h1(utc(7, 14, 0), 1.081, 1.083, 1.08, 1.082, vol(110)),
+			h1(utc(7, 15, 0), 1.082, 1.084, 1.081, 1.083, vol(120)),
+			h1(utc(7, 16, 0), 1.083, 1.085, 1.082, 1.084, vol(130)),
+			h1(utc(7, 17, 0), 1.084, 1.086, 1.083, 1.085, nil),
+		}},
+		// Nested bid and ask as the API returns them, and a MetaTrader date and time. The rejected
+		// lines are not JSON, an incomplete ask and a bid with its high below its open.
+		{"candles.ndjson", Options{Location: helsinki}, LayoutNDJSON, 5, []int{4, 5, 6}, []wantCandle{
+			{"EUR_USD", "M5", utc(31, 6, 0), 1.1, 1.1004, 1.0998, 1.1002, vol(42), true},
+			{"EUR_USD", "M5", utc(31, 6, 5), 1.1002, 1.1006, 1.1, 1.1005, nil, false},
+		}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.file, func(t *testing.T) {
+			res, rows := importFixture(t, tt.file, tt.opts)
+			if res.Layout != tt.layout || res.Read != tt.read || res.Imported != len(tt.candles) || res.Rejected != len(tt.rejected) {
+				t.Errorf("result %+v", res)
+			}
+			for i, e := range res.Errors {
+				if i < len(tt.rejected) && e.Line != tt.rejected[i] {
+					t.Errorf("rejected %+v, want lines %v", res.Errors, tt.rejected)
+					break
+				}
+			}
+			if len(rows) != len(tt.candles) {
+				t.Fatalf("stored %+v", rows)
+			}
+			for i, w := range tt.candles {
+				c := rows[i]
+				volOK := (c.Volume == nil) == (w.volume == nil) && (c.Volume == nil || *c.Volume == *w.volume)
+				if c.Instrument != w.instrument || c.Timeframe != w.timeframe || !c.Timestamp.Equal(w.at) || c.OpenPrice != w.open ||
+					c.HighPrice != w.high || c.LowPrice != w.low || c.ClosePrice != w.close || !volOK || (c.Bid != nil) != w.quoted || (c.Ask != nil) != w.quoted {
+					t.Errorf("candle %d = %+v, want %+v", i, c, w)
+				}
+			}
+			if len(tt.candles) > 0 && (!res.From.Equal(tt.candles[0].at) || !res.To.Equal(tt.candles[len(tt.candles)-1].at)) {
+				t.Errorf("range %v to %v", res.From, res.To)
+			}
+		})
+	}
+}
+
+func TestImportRejectsFiles(t *testing.T) {
+	tests := []struct {
+		name  string
+		input string
+		opts  Options
+	}{
+		{"empty", "", Options{}},
+		{"header without a close", "time,open,high,low\n2025-03-07T13:00:00Z,1,1,1\n", Options{Instrument: "EUR_USD", Timeframe: "H1"}},
+		{"too many rejected rows", "time,open,high,low,close\nx,1,1,1,1\ny,1,1,1,1\n", Options{Instrument: "EUR_USD", Timeframe: "H1", MaxErrors: 1}},
+	}
+	for _, tt := range tests {
+		if _, err := Import(context.Background(), strings.NewReader(tt.input), &captureStore{}, tt.opts); err == nil {
+			t.Errorf("%s: Import succeeded", tt.name)
+		}
+	}
+}
+
+func TestSniffLayout(t *testing.T) {
+	tests := map[string]Layout{
+		`{"time":"2025-03-07T13:00:00Z"}`:                LayoutNDJSON,
+		"<DATE>\t<TIME>\t<OPEN>":                         LayoutMetaTrader,
+		"2025.03.07,13:00,1.08,1.082,1.079,1.081,10":     LayoutMetaTrader,
+		"2025.03.07;13:00;1.08;1.082;1.079;1.081;10":     LayoutMetaTrader,
+		"\ufefftime,open,high,low,close":                 LayoutGeneric,
+		"2025-03-07 13:00,1.08,1.082,1.079,1.081":        LayoutGeneric,
+		"2025-03-07,13:00,1.08,1.082,1.079,1.081,10":     LayoutGeneric,
+		"20250307 130000\t1.08\t1.082\t1.079\t1.081\t10": LayoutGeneric,
+	}
+	for first, want := range tests {
+		if got := sniffLayout(first); got != want {
+			t.Errorf("sniffLayout(%q) = %q, want %q", first, got, want)
+		}
+	}
+}
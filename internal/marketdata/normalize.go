@@ -0,0 +1,145 @@
+package marketdata
+
+import (
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/jedi116/go-trader/pkg/models"
+)
+
+// NormalizeInstrument maps common symbol spellings onto OANDA instruments: EURUSD, EUR/USD and
+// eur-usd all become EUR_USD. Broker suffixes such as EURUSD.m or EURUSDpro are not guessed;
+// map them explicitly.
+func NormalizeInstrument(s string) string {
+	s = strings.ToUpper(strings.TrimSpace(s))
+	s = strings.NewReplacer("/", "_", "-", "_", " ", "_").Replace(s)
+	if len(s) == 6 && !strings.Contains(s, "_") {
+		return s[:3] + "_" + s[3:]
+	}
+	return s
+}
+
+// timeframes maps MetaTrader names, minute counts and short forms onto OANDA granularities.
+var timeframes = map[string]string{
+	"1": "M1", "1M": "M1", "M1": "M1",
+	"2": "M2", "2M": "M2", "M2": "M2",
+	"4": "M4", "4M": "M4", "M4": "M4",
+	"5": "M5", "5M": "M5", "M5": "M5",
+	"10": "M10", "10M": "M10", "M10": "M10",
+	"15": "M15", "15M": "M15", "M15": "M15",
+	"30": "M30", "30M": "M30", "M30": "M30",
+	"60": "H1", "1H": "H1", "H1": "H1",
+	"120": "H2", "2H": "H2", "H2": "H2",
+	"180": "H3", "3H": "H3", "H3": "H3",
+	"240": "H4", "4H": "H4", "H4": "H4",
+	"360": "H6", "6H": "H6", "H6": "H6",
+	"480": "H8", "8H": "H8", "H8": "H8",
+	"720": "H12", "12H": "H12", "H12": "H12",
+	"1440": "D", "1D": "D", "D1": "D", "D": "D",
+	"10080": "W", "1W": "W", "W1": "W", "W": "W",
+	"43200": "M", "MN": "M", "MN1": "M", "1MO": "M", "M": "M",
+}
+
+// NormalizeTimeframe maps a timeframe such as H1, 60, 1h or D1 onto an OANDA granularity.
+func NormalizeTimeframe(s string) (string, error) {
+	if tf, ok := timeframes[strings.ToUpper(strings.TrimSpace(s))]; ok {
+		return tf, nil
+	}
+	return "", fmt.Errorf("unknown timeframe %q", s)
+}
+
+var timeLayouts = []string{
+	time.RFC3339Nano,
+	"2006-01-02T15:04:05",
+	"2006-01-02 15:04:05",
+	"2006-01-02T15:04",
+	"2006-01-02 15:04",
+	"2006.01.02 15:04:05",
+	"2006.01.02 15:04",
+	"2006/01/02 15:04:05",
+	"2006/01/02 15:04",
+	"20060102 150405",
+	"20060102 15:04:05",
+	"2006-01-02",
+	"2006.01.02",
+	"20060102",
+}
+
+// ParseTime reads a candle time. Values carrying an offset keep it; the rest are wall-clock
+// times in loc. Bare integers are Unix seconds, or milliseconds when they are too large to be
+// seconds. The result is in UTC.
+func ParseTime(s string, loc *time.Location) (time.Time, error) {
+	s = strings.TrimSpace(s)
+	if n, err := strconv.ParseInt(s, 10, 64); err == nil && len(s) >= 9 {
+		if n > 1e11 {
+			return time.UnixMilli(n).UTC(), nil
+		}
+		return time.Unix(n, 0).UTC(), nil
+	}
+	if t, err := time.Parse(time.RFC3339Nano, s); err == nil {
+		return t.UTC(), nil
+	}
+	for _, layout := range timeLayouts[1:] {
+		if t, err := time.ParseInLocation(layout, s, loc); err == nil {
+			return t.UTC(), nil
+		}
+	}
+	return time.Time{}, fmt.Errorf("unrecognized time %q", s)
+}
+
+// Validate checks that a candle is internally consistent: positive, finite prices with the high
+// at or above and the low at or below the open and close, and no negative volume.
+func Validate(c models.MarketData) error {
+	if c.Timestamp.IsZero() {
+		return fmt.Errorf("missing time")
+	}
+	if err := validOHLC(c.OpenPrice, c.HighPrice, c.LowPrice, c.ClosePrice); err != nil {
+		return err
+	}
+	for _, side := range []struct {
+		name string
+		o    *models.OHLC
+	}{{"bid", c.Bid}, {"ask", c.Ask}} {
+		if side.o == nil {
+			continue
+		}
+		if err := validOHLC(side.o.Open, side.o.High, side.o.Low, side.o.Close); err != nil {
+			return fmt.Errorf("%s: %w", side.name, err)
+		}
+	}
+	if c.Volume != nil && *c.Volume < 0 {
+		return fmt.Errorf("negative volume %d", *c.Volume)
+	}
+	return nil
+}
+
+func validOHLC(o, h, l, c float64) error {
+	for _, v := range []float64{o, h, l, c} {
+		if !(v > 0) || math.IsInf(v, 0) {
+			return fmt.Errorf("prices must be positive, got open=%v high=%v low=%v close=%v", o, h, l, c)
+		}
+	}
+	if h < math.Max(o, c) || l > math.Min(o, c) || l > h {
+		return fmt.Errorf("inconsistent candle open=%v high=%v low=%v close=%v", o, h, l, c)
+	}
+	return nil
+}
+
+// ParseSymbols reads symbol mappings written as FILE=INSTRUMENT, e.g. EURUSD.m=EUR_USD.
+func ParseSymbols(pairs []string) (map[string]string, error) {
+	out := make(map[string]string, len(pairs))
+	for _, p := range pairs {
+		if p = strings.TrimSpace(p); p == "" {
+			continue
+		}
+		from, to, ok := strings.Cut(p, "=")
+		if !ok || strings.TrimSpace(from) == "" || strings.TrimSpace(to) == "" {
+			return nil, fmt.Errorf("symbol mapping %q must be FILE=INSTRUMENT", p)
+		}
+		out[strings.TrimSpace(from)] = NormalizeInstrument(to)
+	}
+	return out, nil
+}
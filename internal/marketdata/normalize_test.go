@@ -0,0 +1,86 @@
+package marketdata
+
+import (
+	"testing"
+	"time"
+
+	"github.com/jedi116/go-trader/pkg/models"
+)
+
+func TestParseTime(t *testing.T) {
+	helsinki, err := time.LoadLocation("Europe/Helsinki")
+	if err != nil {
+		t.Fatal(err)
+	}
+	tests := []struct {
+		in   string
+		want time.Time
+	}{
+		{"2025-03-07T13:00:00Z", time.Date(2025, 3, 7, 13, 0, 0, 0, time.UTC)},
+		{"2025-03-07T15:00:00.5+02:00", time.Date(2025, 3, 7, 13, 0, 0, 5e8, time.UTC)},
+		// Without an offset the time is in the location: UTC+2 in winter, UTC+3 in summer.
+		{"2025-03-07T15:00:00", time.Date(2025, 3, 7, 13, 0, 0, 0, time.UTC)},
+		{"2025-07-07 15:00:00", time.Date(2025, 7, 7, 12, 0, 0, 0, time.UTC)},
+		{"2025-03-07 15:00", time.Date(2025, 3, 7, 13, 0, 0, 0, time.UTC)},
+		{"2025.03.07 15:00:00", time.Date(2025, 3, 7, 13, 0, 0, 0, time.UTC)},
+		{"2025/03/07 15:00", time.Date(2025, 3, 7, 13, 0, 0, 0, time.UTC)},
+		{"20250307 150000", time.Date(2025, 3, 7, 13, 0, 0, 0, time.UTC)},
+		{"2025.03.07", time.Date(2025, 3, 6, 22, 0, 0, 0, time.UTC)},
+		// Eight digits are a date, not Unix seconds.
+		{"20250307", time.Date(2025, 3, 6, 22, 0, 0, 0, time.UTC)},
+		{" 1741359600 ", time.Date(2025, 3, 7, 15, 0, 0, 0, time.UTC)},
+		{"1741359600500", time.Date(2025, 3, 7, 15, 0, 0, 5e8, time.UTC)},
+		// Up to 1e11 is seconds, which reaches the year 5138; anything larger is milliseconds.
+		{"100000000000", time.Date(5138, 11, 16, 9, 46, 40, 0, time.UTC)},
+		{"100000000001", time.Date(1973, 3, 3, 9, 46, 40, 1e6, time.UTC)},
+	}
+	for _, tt := range tests {
+		got, err := ParseTime(tt.in, helsinki)
+		if err != nil || !got.Equal(tt.want) || got.Location() != time.UTC {
+			t.Errorf("ParseTime(%q) = %v, %v; want %v", tt.in, got, err, tt.want)
+		}
+	}
+	for _, in := range []string{"", "yesterday", "2025-13-07", "07/03/2025 13:00"} {
+		if got, err := ParseTime(in, helsinki); err == nil {
+			t.Errorf("ParseTime(%q) = %v, want an error", in, got)
+		}
+	}
+}
+
+func TestValidate(t *testing.T) {
+	at := time.Date(2025, 3, 7, 13, 0, 0, 0, time.UTC)
+	candle := func(o, h, l, c float64) models.MarketData {
+		return models.MarketData{Timestamp: at, OpenPrice: o, HighPrice: h, LowPrice: l, ClosePrice: c}
+	}
+	with := func(c models.MarketData, fn func(*models.MarketData)) models.MarketData {
+		fn(&c)
+		return c
+	}
+	neg := int64(-1)
+	good := candle(1.08, 1.082, 1.079, 1.081)
+	bid := &models.OHLC{Open: 1.0799, High: 1.0819, Low: 1.0789, Close: 1.0809}
+	ask := &models.OHLC{Open: 1.0801, High: 1.08, Low: 1.0791, Close: 1.0811}
+	tests := []struct {
+		name string
+		c    models.MarketData
+		ok   bool
+	}{
+		{"consistent", good, true},
+		{"flat", candle(1.08, 1.08, 1.08, 1.08), true},
+		{"high below the close", candle(1.08, 1.0805, 1.079, 1.081), false},
+		{"high below the open", candle(1.083, 1.082, 1.079, 1.081), false},
+		{"low above the open", candle(1.079, 1.082, 1.0795, 1.081), false},
+		{"low above the high", candle(1.08, 1.08, 1.081, 1.08), false},
+		{"zero price", candle(1.08, 1.082, 0, 1.081), false},
+		{"negative price", candle(-1.08, 1.082, 1.079, 1.081), false},
+		{"missing time", with(good, func(c *models.MarketData) { c.Timestamp = time.Time{} }), false},
+		{"negative volume", with(good, func(c *models.MarketData) { c.Volume = &neg }), false},
+		{"consistent bid", with(good, func(c *models.MarketData) { c.Bid = bid }), true},
+		{"ask high below its open", with(good, func(c *models.MarketData) { c.Ask = ask }), false},
+	}
+	for _, tt := range tests {
+		if err := Validate(tt.c); (err == nil) != tt.ok {
+			t.Errorf("%s: Validate = %v", tt.name, err)
+		}
+	}
+}
@@ -0,0 +1,37 @@
+package markethours
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/jedi116/go-trader/internal/config"
+)
+
+// FromConfig builds a calendar from the market_hours config section.
+func FromConfig(cfg config.MarketConfig) (*Calendar, error) {
+	c := Config{RolloverWindow: cfg.RolloverWindow}
+	if cfg.Timezone != "" {
+		loc, err := time.LoadLocation(cfg.Timezone)
+		if err != nil {
+			return nil, err
+		}
+		c.Location = loc
+	}
+	rollover, err := ParseClock(cfg.Rollover)
+	if err != nil {
+		return nil, err
+	}
+	c.Rollover = rollover
+	for _, h := range cfg.Holidays {
+		date, err := time.Parse("2006-01-02", h.Date)
+		if err != nil {
+			return nil, fmt.Errorf("holiday %q: %w", h.Name, err)
+		}
+		early, err := ParseClock(h.EarlyClose)
+		if err != nil {
+			return nil, fmt.Errorf("holiday %q: %w", h.Name, err)
+		}
+		c.Holidays = append(c.Holidays, Holiday{Date: date, Name: h.Name, EarlyClose: early})
+	}
+	return New(c), nil
+}
@@ -0,0 +1,324 @@
+// Package markethours models the FX trading week: open from Sunday 17:00 to Friday 17:00
+// New York time, with regional sessions, the daily 17:00 rollover and configured holidays.
+package markethours
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"sort"
+	"strings"
+	"time"
+
+	// Embedded zone data so session times work on hosts without /usr/share/zoneinfo.
+	_ "time/tzdata"
+)
+
+// Session is a regional trading session in its own local time; End before Start wraps midnight.
+type Session struct {
+	Name     string
+	Location *time.Location
+	Start    time.Duration
+	End      time.Duration
+}
+
+func (s Session) active(now time.Time) bool {
+	local := now.In(s.Location)
+	y, m, d := local.Date()
+	tod := local.Sub(time.Date(y, m, d, 0, 0, 0, 0, s.Location))
+	if s.Start <= s.End {
+		return tod >= s.Start && tod < s.End
+	}
+	return tod >= s.Start || tod < s.End
+}
+
+// Holiday closes the trading day that ends at the rollover on Date. With EarlyClose set the
+// market instead trades normally until that time of day on Date and reopens at the rollover.
+type Holiday struct {
+	Date       time.Time
+	Name       string
+	EarlyClose time.Duration
+}
+
+type Config struct {
+	// Location is the reference zone for the week and rollover; nil means America/New_York.
+	Location *time.Location
+	// Rollover is the time of day the trading day (and week) turns over; zero means 17:00.
+	Rollover time.Duration
+	// RolloverWindow is how long either side of the rollover is flagged as rollover.
+	RolloverWindow time.Duration
+	Holidays       []Holiday
+	// Sessions overrides the default Sydney/Tokyo/London/New York sessions.
+	Sessions []Session
+}
+
+// Status describes the market at an instant.
+type Status struct {
+	Time      time.Time  `json:"time"`
+	Open      bool       `json:"open"`
+	Reason    string     `json:"reason,omitempty"`
+	Sessions  []string   `json:"sessions"`
+	Rollover  bool       `json:"rollover"`
+	Holiday   string     `json:"holiday,omitempty"`
+	NextOpen  *time.Time `json:"next_open,omitempty"`
+	NextClose *time.Time `json:"next_close,omitempty"`
+}
+
+// Calendar answers market-hours questions. A nil *Calendar behaves like Default().
+type Calendar struct {
+	loc      *time.Location
+	rollover time.Duration
+	window   time.Duration
+	holidays map[string]Holiday
+	sessions []Session
+}
+
+func mustLoad(name string) *time.Location {
+	loc, err := time.LoadLocation(name)
+	if err != nil {
+		panic(err)
+	}
+	return loc
+}
+
+// DefaultSessions are the four main FX sessions in local time, so DST shifts are handled per region.
+func DefaultSessions() []Session {
+	return []Session{
+		{Name: "Sydney", Location: mustLoad("Australia/Sydney"), Start: 7 * time.Hour, End: 16 * time.Hour},
+		{Name: "Tokyo", Location: mustLoad("Asia/Tokyo"), Start: 9 * time.Hour, End: 18 * time.Hour},
+		{Name: "London", Location: mustLoad("Europe/London"), Start: 8 * time.Hour, End: 17 * time.Hour},
+		{Name: "New York", Location: mustLoad("America/New_York"), Start: 8 * time.Hour, End: 17 * time.Hour},
+	}
+}
+
+func New(cfg Config) *Calendar {
+	c := &Calendar{
+		loc:      cfg.Location,
+		rollover: cfg.Rollover,
+		window:   cfg.RolloverWindow,
+		holidays: make(map[string]Holiday, len(cfg.Holidays)),
+		sessions: cfg.Sessions,
+	}
+	if c.loc == nil {
+		c.loc = mustLoad("America/New_York")
+	}
+	if c.rollover == 0 {
+		c.rollover = 17 * time.Hour
+	}
+	if c.sessions == nil {
+		c.sessions = DefaultSessions()
+	}
+	for _, h := range cfg.Holidays {
+		c.holidays[h.Date.Format("2006-01-02")] = h
+	}
+	return c
+}
+
+var defaultCalendar = New(Config{})
+
+// Default is the standard FX week with no holidays.
+func Default() *Calendar { return defaultCalendar }
+
+func (c *Calendar) orDefault() *Calendar {
+	if c == nil {
+		return defaultCalendar
+	}
+	return c
+}
+
+// tradingDate is the calendar date of the trading day containing t: after the rollover the
+// trading day is already tomorrow's.
+func (c *Calendar) tradingDate(t time.Time) (time.Time, time.Duration) {
+	local := t.In(c.loc)
+	y, m, d := local.Date()
+	midnight := time.Date(y, m, d, 0, 0, 0, 0, c.loc)
+	tod := local.Sub(midnight)
+	if tod >= c.rollover {
+		return midnight.AddDate(0, 0, 1), tod
+	}
+	return midnight, tod
+}
+
+// TradingDay is the date of the trading day containing t, at midnight UTC: a Sunday evening
+// after the rollover belongs to Monday. A nil calendar uses the standard FX week.
+func (c *Calendar) TradingDay(t time.Time) time.Time {
+	d, _ := c.orDefault().tradingDate(t)
+	return time.Date(d.Year(), d.Month(), d.Day(), 0, 0, 0, 0, time.UTC)
+}
+
+// closedReason returns why the market is closed at t, or "" when open.
+func (c *Calendar) closedReason(t time.Time) (string, string) {
+	local := t.In(c.loc)
+	y, m, d := local.Date()
+	tod := local.Sub(time.Date(y, m, d, 0, 0, 0, 0, c.loc))
+	switch local.Weekday() {
+	case time.Saturday:
+		return "weekend", ""
+	case time.Friday:
+		if tod >= c.rollover {
+			return "weekend", ""
+		}
+	case time.Sunday:
+		if tod < c.rollover {
+			return "weekend", ""
+		}
+	}
+	day, _ := c.tradingDate(t)
+	if h, ok := c.holidays[day.Format("2006-01-02")]; ok {
+		if h.EarlyClose == 0 {
+			return "holiday", h.Name
+		}
+		// Early close happens on the calendar date itself, before the rollover.
+		if local.Format("2006-01-02") == day.Format("2006-01-02") && tod >= h.EarlyClose {
+			return "holiday early close", h.Name
+		}
+	}
+	return "", ""
+}
+
+// IsOpen reports whether FX trades at t.
+func (c *Calendar) IsOpen(t time.Time) bool {
+	reason, _ := c.orDefault().closedReason(t)
+	return reason == ""
+}
+
+// transitions lists the instants within two weeks after t at which the open state can change.
+func (c *Calendar) transitions(t time.Time) []time.Time {
+	local := t.In(c.loc)
+	y, m, d := local.Date()
+	start := time.Date(y, m, d, 0, 0, 0, 0, c.loc)
+	var out []time.Time
+	for i := 0; i <= 14; i++ {
+		day := start.AddDate(0, 0, i)
+		out = append(out, day.Add(c.rollover))
+		if h, ok := c.holidays[day.Format("2006-01-02")]; ok && h.EarlyClose > 0 {
+			out = append(out, day.Add(h.EarlyClose))
+		}
+	}
+	sort.Slice(out, func(i, j int) bool { return out[i].Before(out[j]) })
+	return out
+}
+
+func (c *Calendar) next(t time.Time, open bool) time.Time {
+	for _, tr := range c.transitions(t) {
+		if tr.After(t) && c.IsOpen(tr) == open {
+			return tr
+		}
+	}
+	return time.Time{}
+}
+
+// NextOpen is the next instant after t the market opens; t itself when already open.
+func (c *Calendar) NextOpen(t time.Time) time.Time {
+	c = c.orDefault()
+	if c.IsOpen(t) {
+		return t
+	}
+	return c.next(t, true)
+}
+
+// NextClose is the next instant after t the market closes; t itself when already closed.
+func (c *Calendar) NextClose(t time.Time) time.Time {
+	c = c.orDefault()
+	if !c.IsOpen(t) {
+		return t
+	}
+	return c.next(t, false)
+}
+
+// Status describes the market at t.
+func (c *Calendar) Status(t time.Time) Status {
+	c = c.orDefault()
+	reason, holiday := c.closedReason(t)
+	st := Status{Time: t, Open: reason == "", Reason: reason, Holiday: holiday, Sessions: []string{}}
+	if st.Open {
+		for _, s := range c.sessions {
+			if s.active(t) {
+				st.Sessions = append(st.Sessions, s.Name)
+			}
+		}
+		if next := c.next(t, false); !next.IsZero() {
+			st.NextClose = &next
+		}
+	} else if next := c.next(t, true); !next.IsZero() {
+		st.NextOpen = &next
+	}
+	if c.window > 0 {
+		_, tod := c.tradingDate(t)
+		delta := tod - c.rollover
+		if delta < 0 {
+			delta = -delta
+		}
+		// Distance across midnight for windows spanning it.
+		if day := 24 * time.Hour; delta > day/2 {
+			delta = day - delta
+		}
+		st.Rollover = delta <= c.window
+	}
+	return st
+}
+
+// ClosedError is returned when an order arrives while the market is closed.
+type ClosedError struct {
+	Status Status
+}
+
+func (e *ClosedError) Error() string {
+	msg := "market closed (" + e.Status.Reason
+	if e.Status.Holiday != "" {
+		msg += ": " + e.Status.Holiday
+	}
+	msg += ")"
+	if e.Status.NextOpen != nil {
+		msg += ", reopens " + e.Status.NextOpen.UTC().Format(time.RFC3339)
+	}
+	return msg
+}
+
+// Check returns a *ClosedError when the market is closed at t.
+func (c *Calendar) Check(t time.Time) error {
+	st := c.orDefault().Status(t)
+	if !st.Open {
+		return &ClosedError{Status: st}
+	}
+	return nil
+}
+
+// RunWhileOpen calls fn every interval while the market is open and sleeps through closures,
+// waking at the next open. It returns when ctx is done.
+func (c *Calendar) RunWhileOpen(ctx context.Context, interval time.Duration, fn func(context.Context)) {
+	c = c.orDefault()
+	if interval <= 0 {
+		interval = time.Minute
+	}
+	for {
+		now := time.Now()
+		wait := interval
+		if c.IsOpen(now) {
+			fn(ctx)
+		} else if next := c.NextOpen(now); !next.IsZero() {
+			wait = time.Until(next)
+			log.Printf("[MARKET] closed, next open %s", next.UTC().Format(time.RFC3339))
+		}
+		timer := time.NewTimer(wait)
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return
+		case <-timer.C:
+		}
+	}
+}
+
+// ParseClock parses "HH:MM" into a time of day.
+func ParseClock(s string) (time.Duration, error) {
+	s = strings.TrimSpace(s)
+	if s == "" {
+		return 0, nil
+	}
+	t, err := time.Parse("15:04", s)
+	if err != nil {
+		return 0, fmt.Errorf("invalid time of day %q", s)
+	}
+	return time.Duration(t.Hour())*time.Hour + time.Duration(t.Minute())*time.Minute, nil
+}
@@ -15,13 +15,7 @@ type BraveClient struct {
 	http    *http.Client
 }
 
-type BraveNewsItem struct {
-	Title     string `json:"title"`
-	Url       string `json:"url"`
-	Snippet   string `json:"snippet"`
-	Source    string `json:"source"`
-	Published string `json:"published"`
-}
+var _ NewsProvider = (*BraveClient)(nil)
 
 func NewBraveClient(apiKey, baseURL string) *BraveClient {
 	if baseURL == "" {
@@ -34,7 +28,9 @@ func NewBraveClient(apiKey, baseURL string) *BraveClient {
 	}
 }
 
-func (b *BraveClient) SearchNews(ctx context.Context, query string, count int) ([]BraveNewsItem, error) {
+func (b *BraveClient) Name() string { return "brave" }
+
+func (b *BraveClient) SearchNews(ctx context.Context, query string, count int) ([]Article, error) {
 	if count <= 0 {
 		count = 10
 	}
@@ -67,14 +63,18 @@ func (b *BraveClient) SearchNews(ctx context.Context, query string, count int) (
 				Name string `json:"name"`
 			} `json:"source"`
 			Published string `json:"published"`
+			PageAge   string `json:"page_age"`
 		} `json:"results"`
 	}
 	if err := json.NewDecoder(resp.Body).Decode(&payload); err != nil {
 		return nil, err
 	}
-	items := make([]BraveNewsItem, 0, len(payload.Results))
+	items := make([]Article, 0, len(payload.Results))
 	for _, r := range payload.Results {
-		items = append(items, BraveNewsItem{
+		if r.Published == "" {
+			r.Published = r.PageAge
+		}
+		items = append(items, Article{
 			Title:     r.Title,
 			Url:       r.Url,
 			Snippet:   r.Description,
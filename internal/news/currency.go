@@ -0,0 +1,61 @@
+package news
+
+import "strings"
+
+// Currency describes how a currency shows up in financial news.
+type Currency struct {
+	Code        string   `json:"code"`
+	Name        string   `json:"name"`
+	CentralBank string   `json:"central_bank"`
+	Query       string   `json:"query"`
+	Aliases     []string `json:"aliases"`
+}
+
+var currencies = map[string]Currency{
+	"USD": {Code: "USD", Name: "US dollar", CentralBank: "Federal Reserve", Query: "US dollar Federal Reserve",
+		Aliases: []string{"usd", "dollar", "greenback", "federal reserve", "fed", "fomc", "us treasury", "nonfarm payrolls", "us inflation"}},
+	"EUR": {Code: "EUR", Name: "euro", CentralBank: "European Central Bank", Query: "euro ECB",
+		Aliases: []string{"eur", "euro", "eurozone", "euro area", "european central bank", "ecb"}},
+	"GBP": {Code: "GBP", Name: "British pound", CentralBank: "Bank of England", Query: "British pound Bank of England",
+		Aliases: []string{"gbp", "sterling", "pound", "cable", "bank of england", "boe", "uk economy", "uk inflation"}},
+	"JPY": {Code: "JPY", Name: "Japanese yen", CentralBank: "Bank of Japan", Query: "Japanese yen Bank of Japan",
+		Aliases: []string{"jpy", "yen", "bank of japan", "boj", "japan"}},
+	"CHF": {Code: "CHF", Name: "Swiss franc", CentralBank: "Swiss National Bank", Query: "Swiss franc SNB",
+		Aliases: []string{"chf", "swiss franc", "franc", "swiss national bank", "snb", "switzerland"}},
+	"AUD": {Code: "AUD", Name: "Australian dollar", CentralBank: "Reserve Bank of Australia", Query: "Australian dollar RBA",
+		Aliases: []string{"aud", "australian dollar", "aussie", "reserve bank of australia", "rba", "australia"}},
+	"NZD": {Code: "NZD", Name: "New Zealand dollar", CentralBank: "Reserve Bank of New Zealand", Query: "New Zealand dollar RBNZ",
+		Aliases: []string{"nzd", "new zealand dollar", "kiwi", "reserve bank of new zealand", "rbnz", "new zealand"}},
+	"CAD": {Code: "CAD", Name: "Canadian dollar", CentralBank: "Bank of Canada", Query: "Canadian dollar Bank of Canada",
+		Aliases: []string{"cad", "canadian dollar", "loonie", "bank of canada", "boc", "canada"}},
+	"CNH": {Code: "CNH", Name: "Chinese yuan", CentralBank: "People's Bank of China", Query: "Chinese yuan PBOC",
+		Aliases: []string{"cnh", "cny", "yuan", "renminbi", "pboc", "people s bank of china", "china"}},
+	"SEK": {Code: "SEK", Name: "Swedish krona", CentralBank: "Riksbank", Query: "Swedish krona Riksbank",
+		Aliases: []string{"sek", "krona", "riksbank", "sweden"}},
+	"NOK": {Code: "NOK", Name: "Norwegian krone", CentralBank: "Norges Bank", Query: "Norwegian krone Norges Bank",
+		Aliases: []string{"nok", "krone", "norges bank", "norway"}},
+	"MXN": {Code: "MXN", Name: "Mexican peso", CentralBank: "Banxico", Query: "Mexican peso Banxico",
+		Aliases: []string{"mxn", "peso", "banxico", "bank of mexico", "mexico"}},
+	"ZAR": {Code: "ZAR", Name: "South African rand", CentralBank: "South African Reserve Bank", Query: "South African rand SARB",
+		Aliases: []string{"zar", "rand", "sarb", "south african reserve bank", "south africa"}},
+	"SGD": {Code: "SGD", Name: "Singapore dollar", CentralBank: "Monetary Authority of Singapore", Query: "Singapore dollar MAS",
+		Aliases: []string{"sgd", "singapore dollar", "monetary authority of singapore", "singapore"}},
+	"HKD": {Code: "HKD", Name: "Hong Kong dollar", CentralBank: "Hong Kong Monetary Authority", Query: "Hong Kong dollar HKMA",
+		Aliases: []string{"hkd", "hong kong dollar", "hkma", "hong kong"}},
+	// "try" is an English word, so the ISO code is deliberately not an alias.
+	"TRY": {Code: "TRY", Name: "Turkish lira", CentralBank: "Central Bank of the Republic of Turkey", Query: "Turkish lira central bank",
+		Aliases: []string{"lira", "turkish lira", "cbrt", "turkey", "turkiye"}},
+	"XAU": {Code: "XAU", Name: "gold", Query: "gold price",
+		Aliases: []string{"xau", "gold", "bullion"}},
+	"XAG": {Code: "XAG", Name: "silver", Query: "silver price",
+		Aliases: []string{"xag", "silver"}},
+}
+
+// LookupCurrency returns news metadata for an ISO code. Unknown codes get a minimal entry.
+func LookupCurrency(code string) (Currency, bool) {
+	code = strings.ToUpper(code)
+	if c, ok := currencies[code]; ok {
+		return c, true
+	}
+	return Currency{Code: code, Name: code, Query: code + " forex", Aliases: []string{strings.ToLower(code)}}, false
+}
@@ -0,0 +1,207 @@
+package news
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"net/url"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/jedi116/go-trader/pkg/models"
+)
+
+// ScoredItem is a deduplicated article with its sentiment and per-currency relevance.
+type ScoredItem struct {
+	Article
+	PublishedAt *time.Time         `json:"published_at,omitempty"`
+	Sentiment   float64            `json:"sentiment"`
+	Relevance   map[string]float64 `json:"relevance"`
+}
+
+type PipelineOptions struct {
+	// PerCurrency is the number of results requested per currency query.
+	PerCurrency int
+	// MaxAge drops articles published longer ago; articles without a parseable date are kept.
+	MaxAge time.Duration
+	// MinRelevance drops articles whose best relevance to any target currency is below it.
+	MinRelevance float64
+}
+
+// Pipeline queries news per currency, deduplicates, filters by age and scores each item.
+type Pipeline struct {
+	provider NewsProvider
+	scorer   Scorer
+	fallback Scorer
+	opts     PipelineOptions
+	now      func() time.Time
+}
+
+// NewPipeline builds a pipeline. A nil scorer uses the lexicon scorer; the lexicon scorer is
+// also the fallback when the configured scorer fails on an item.
+func NewPipeline(provider NewsProvider, scorer Scorer, opts PipelineOptions) *Pipeline {
+	lexicon := NewLexiconScorer()
+	if scorer == nil {
+		scorer = lexicon
+	}
+	if opts.PerCurrency <= 0 {
+		opts.PerCurrency = 5
+	}
+	return &Pipeline{provider: provider, scorer: scorer, fallback: lexicon, opts: opts, now: time.Now}
+}
+
+// Gather returns scored news for the currencies in instruments, most relevant first.
+func (p *Pipeline) Gather(ctx context.Context, instruments []string) ([]ScoredItem, error) {
+	codes := models.CurrenciesFor(instruments)
+	if len(codes) == 0 {
+		return nil, fmt.Errorf("no currencies in instruments %v", instruments)
+	}
+	var raw []Article
+	var lastErr error
+	failed := 0
+	for _, code := range codes {
+		cur, _ := LookupCurrency(code)
+		items, err := p.provider.SearchNews(ctx, cur.Query, p.opts.PerCurrency)
+		if err != nil {
+			log.Printf("[NEWS] search error currency=%s query=%q: %v", code, cur.Query, err)
+			lastErr = err
+			failed++
+			continue
+		}
+		raw = append(raw, items...)
+	}
+	if failed == len(codes) {
+		return nil, lastErr
+	}
+
+	var out []ScoredItem
+	for _, it := range Dedupe(raw) {
+		published := ParsePublished(it.Published)
+		if p.opts.MaxAge > 0 && published != nil && p.now().Sub(*published) > p.opts.MaxAge {
+			continue
+		}
+		score, err := p.scorer.Score(ctx, it, codes)
+		if err != nil {
+			log.Printf("[NEWS] scorer error url=%s: %v (using lexicon)", it.Url, err)
+			score, _ = p.fallback.Score(ctx, it, codes)
+		}
+		if maxRelevance(score.Relevance) < p.opts.MinRelevance {
+			continue
+		}
+		out = append(out, ScoredItem{Article: it, PublishedAt: published, Sentiment: score.Sentiment, Relevance: score.Relevance})
+	}
+	sort.SliceStable(out, func(i, j int) bool {
+		ri, rj := maxRelevance(out[i].Relevance), maxRelevance(out[j].Relevance)
+		if ri != rj {
+			return ri > rj
+		}
+		if out[i].PublishedAt != nil && out[j].PublishedAt != nil {
+			return out[i].PublishedAt.After(*out[j].PublishedAt)
+		}
+		return out[i].PublishedAt != nil
+	})
+	return out, nil
+}
+
+func maxRelevance(rel map[string]float64) float64 {
+	best := 0.0
+	for _, v := range rel {
+		if v > best {
+			best = v
+		}
+	}
+	return best
+}
+
+// titleSimilarityThreshold is the word-set Jaccard similarity above which two titles are duplicates.
+const titleSimilarityThreshold = 0.8
+
+// Dedupe drops items whose normalized URL or near-identical title was already seen.
+func Dedupe(items []Article) []Article {
+	seenURL := make(map[string]bool)
+	var titles [][]string
+	var out []Article
+	for _, it := range items {
+		if u := normalizeURL(it.Url); u != "" {
+			if seenURL[u] {
+				continue
+			}
+			seenURL[u] = true
+		}
+		words := tokenize(it.Title)
+		dup := false
+		for _, prev := range titles {
+			if jaccard(words, prev) >= titleSimilarityThreshold {
+				dup = true
+				break
+			}
+		}
+		if dup {
+			continue
+		}
+		titles = append(titles, words)
+		out = append(out, it)
+	}
+	return out
+}
+
+func normalizeURL(raw string) string {
+	u, err := url.Parse(strings.TrimSpace(raw))
+	if err != nil || u.Host == "" {
+		return strings.ToLower(strings.TrimSpace(raw))
+	}
+	host := strings.TrimPrefix(strings.ToLower(u.Host), "www.")
+	return host + strings.TrimSuffix(u.Path, "/")
+}
+
+func jaccard(a, b []string) float64 {
+	if len(a) == 0 || len(b) == 0 {
+		return 0
+	}
+	set := make(map[string]bool, len(a))
+	for _, w := range a {
+		set[w] = true
+	}
+	inter := 0
+	union := len(set)
+	seen := make(map[string]bool, len(b))
+	for _, w := range b {
+		if seen[w] {
+			continue
+		}
+		seen[w] = true
+		if set[w] {
+			inter++
+		} else {
+			union++
+		}
+	}
+	return float64(inter) / float64(union)
+}
+
+var publishedLayouts = []string{
+	time.RFC3339,
+	time.RFC1123Z,
+	time.RFC1123,
+	"Mon, 2 Jan 2006 15:04:05 -0700",
+	"Mon, 2 Jan 2006 15:04:05 MST",
+	"2006-01-02T15:04:05",
+	"2006-01-02 15:04:05",
+	"2006-01-02",
+}
+
+// ParsePublished parses the publish timestamps returned by news sources, or returns nil.
+func ParsePublished(s string) *time.Time {
+	s = strings.TrimSpace(s)
+	if s == "" {
+		return nil
+	}
+	for _, layout := range publishedLayouts {
+		if t, err := time.Parse(layout, s); err == nil {
+			t = t.UTC()
+			return &t
+		}
+	}
+	return nil
+}
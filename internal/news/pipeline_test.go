@@ -0,0 +1,300 @@
+package news
+
+import (
+	"context"
+	"errors"
+	"math"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func readFixture(t *testing.T, name string) []byte {
+	t.Helper()
+	data, err := os.ReadFile(filepath.Join("testdata", name))
+	if err != nil {
+		t.Fatal(err)
+	}
+	return data
+}
+
+func fixtureFeeds() []Feed {
+	return []Feed{
+		{Name: "FX Wire", URL: filepath.Join("testdata", "fxwire.rss")},
+		{Name: "Central Bank Watch", URL: filepath.Join("testdata", "centralbanks.atom")},
+	}
+}
+
+func TestParseFeed(t *testing.T) {
+	tests := []struct {
+		file      string
+		count     int
+		title     string
+		url       string
+		snippet   string
+		published string
+	}{
+		{"fxwire.rss", 4, "ECB signals rate hike as euro rallies", "https://www.fxwire.example/ecb-hike/",
+			"The European Central Bank is ready to hike again; the euro gained against the dollar.", "2026-10-05T09:30:00Z"},
+		{"centralbanks.atom", 2, "ECB signals a rate hike as the euro rallies", "https://fxwire.example/ecb-hike",
+			"Syndicated copy of the FX Wire story.", "2026-10-05T09:45:00Z"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.file, func(t *testing.T) {
+			items, err := ParseFeed(readFixture(t, tt.file), "")
+			if err != nil {
+				t.Fatal(err)
+			}
+			if len(items) != tt.count {
+				t.Fatalf("got %d items, want %d", len(items), tt.count)
+			}
+			got := items[0]
+			if got.Title != tt.title || got.Url != tt.url || got.Snippet != tt.snippet || got.Published != tt.published {
+				t.Errorf("first item = %+v", got)
+			}
+			if got.Source == "" {
+				t.Errorf("source not taken from the feed title")
+			}
+		})
+	}
+
+	// dc:date stands in for pubDate; the non-alternate Atom link is skipped; content replaces summary.
+	rss, _ := ParseFeed(readFixture(t, "fxwire.rss"), "wire")
+	if rss[1].Published != "2026-10-05T08:00:00Z" || rss[1].Source != "wire" {
+		t.Errorf("dc:date item = %+v", rss[1])
+	}
+	atom, _ := ParseFeed(readFixture(t, "centralbanks.atom"), "")
+	if atom[1].Url != "https://cbwatch.example/dollar-not-weak" || atom[1].Published != "2026-10-05T06:15:00Z" ||
+		atom[1].Snippet != "Federal Reserve officials pushed back on easing; the dollar held." {
+		t.Errorf("atom entry = %+v", atom[1])
+	}
+
+	if _, err := ParseFeed([]byte("<html></html>"), ""); err == nil {
+		t.Error("expected an error for a non-feed document")
+	}
+}
+
+func TestDedupe(t *testing.T) {
+	tests := []struct {
+		name  string
+		items []Article
+		want  []string
+	}{
+		{"same url modulo www and trailing slash",
+			[]Article{{Title: "A", Url: "https://www.x.example/a/"}, {Title: "B", Url: "https://x.example/a"}},
+			[]string{"A"}},
+		{"near-identical titles",
+			[]Article{{Title: "Euro rallies after ECB hike", Url: "https://x.example/1"}, {Title: "Euro rallies after the ECB hike", Url: "https://y.example/2"}},
+			[]string{"Euro rallies after ECB hike"}},
+		{"distinct stories kept in order",
+			[]Article{{Title: "Euro rallies", Url: "https://x.example/1"}, {Title: "Yen slides", Url: "https://x.example/2"}},
+			[]string{"Euro rallies", "Yen slides"}},
+		{"items without url dedupe on title only",
+			[]Article{{Title: "Dollar steady"}, {Title: "Dollar steady"}, {Title: "Franc firm"}},
+			[]string{"Dollar steady", "Franc firm"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := Dedupe(tt.items)
+			if len(got) != len(tt.want) {
+				t.Fatalf("got %d items, want %d: %+v", len(got), len(tt.want), got)
+			}
+			for i, title := range tt.want {
+				if got[i].Title != title {
+					t.Errorf("item %d = %q, want %q", i, got[i].Title, title)
+				}
+			}
+		})
+	}
+}
+
+func TestLexiconScorer(t *testing.T) {
+	tests := []struct {
+		name      string
+		item      Article
+		sentiment float64
+		relevance map[string]float64
+	}{
+		{"bullish euro headline",
+			Article{Title: "ECB hike lifts euro", Snippet: "Euro gains."},
+			// hike, gains: (2-0)/(2+0+1)
+			2.0 / 3, map[string]float64{"EUR": 1, "USD": 0}},
+		{"negation flips a term",
+			Article{Title: "Dollar not weak", Snippet: ""},
+			// "not weak" counts as bullish: (1-0)/(1+0+1)
+			0.5, map[string]float64{"EUR": 0, "USD": 2.0 / 3}},
+		{"snippet mentions count half as much as the title",
+			Article{Title: "Markets drift", Snippet: "The Fed cut rates."},
+			// cut: (0-1)/(0+1+1); "fed" once in the body
+			-0.5, map[string]float64{"EUR": 0, "USD": 1.0 / 3}},
+		{"no alias matches",
+			Article{Title: "Oil steady", Snippet: "Crude flat."},
+			0, map[string]float64{"EUR": 0, "USD": 0}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			score, err := NewLexiconScorer().Score(context.Background(), tt.item, []string{"EUR", "USD"})
+			if err != nil {
+				t.Fatal(err)
+			}
+			if math.Abs(score.Sentiment-tt.sentiment) > 1e-9 {
+				t.Errorf("sentiment = %v, want %v", score.Sentiment, tt.sentiment)
+			}
+			for code, want := range tt.relevance {
+				if math.Abs(score.Relevance[code]-want) > 1e-9 {
+					t.Errorf("relevance[%s] = %v, want %v", code, score.Relevance[code], want)
+				}
+			}
+		})
+	}
+}
+
+type failingScorer struct{}
+
+func (failingScorer) Score(context.Context, Article, []string) (Score, error) {
+	return Score{}, errors.New("scorer down")
+}
+
+type failingProvider struct{}
+
+func (failingProvider) Name() string { return "down" }
+
+func (failingProvider) SearchNews(context.Context, string, int) ([]Article, error) {
+	return nil, errors.New("provider down")
+}
+
+func TestPipelineGather(t *testing.T) {
+	now := time.Date(2026, 10, 5, 12, 0, 0, 0, time.UTC)
+	tests := []struct {
+		name        string
+		provider    NewsProvider
+		scorer      Scorer
+		instruments []string
+		opts        PipelineOptions
+		wantURLs    []string
+		wantErr     bool
+	}{
+		{
+			// The syndicated ECB story appears in both feeds and under both currency queries; the
+			// recession story is older than MaxAge and the BoJ story matches neither query.
+			name:        "dedup, age filter and ordering",
+			provider:    NewFanOut(NewRSSProvider(fixtureFeeds(), time.Hour)),
+			instruments: []string{"EUR_USD"},
+			opts:        PipelineOptions{PerCurrency: 10, MaxAge: 72 * time.Hour},
+			wantURLs: []string{
+				"https://www.fxwire.example/ecb-hike/",
+				"https://www.fxwire.example/fed-cut",
+				"https://cbwatch.example/dollar-not-weak",
+			},
+		},
+		{
+			name:        "without MaxAge the old story is kept",
+			provider:    NewRSSProvider(fixtureFeeds(), time.Hour),
+			instruments: []string{"EUR_USD"},
+			opts:        PipelineOptions{PerCurrency: 10},
+			wantURLs: []string{
+				"https://www.fxwire.example/ecb-hike/",
+				"https://www.fxwire.example/fed-cut",
+				"https://cbwatch.example/dollar-not-weak",
+				"https://www.fxwire.example/euro-recession",
+			},
+		},
+		{
+			name:        "a failing scorer falls back to the lexicon",
+			provider:    NewRSSProvider(fixtureFeeds(), time.Hour),
+			scorer:      failingScorer{},
+			instruments: []string{"EUR_USD"},
+			opts:        PipelineOptions{PerCurrency: 10, MaxAge: 72 * time.Hour},
+			wantURLs: []string{
+				"https://www.fxwire.example/ecb-hike/",
+				"https://www.fxwire.example/fed-cut",
+				"https://cbwatch.example/dollar-not-weak",
+			},
+		},
+		{
+			name:        "every provider failing is an error",
+			provider:    failingProvider{},
+			instruments: []string{"EUR_USD"},
+			wantErr:     true,
+		},
+		{
+			name:        "instruments without currencies are an error",
+			provider:    NewRSSProvider(fixtureFeeds(), time.Hour),
+			instruments: []string{"SPX500"},
+			wantErr:     true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			p := NewPipeline(tt.provider, tt.scorer, tt.opts)
+			p.now = func() time.Time { return now }
+			items, err := p.Gather(context.Background(), tt.instruments)
+			if tt.wantErr {
+				if err == nil {
+					t.Fatalf("expected an error, got %d items", len(items))
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			var urls []string
+			for _, it := range items {
+				urls = append(urls, it.Url)
+			}
+			if len(urls) != len(tt.wantURLs) {
+				t.Fatalf("got %v, want %v", urls, tt.wantURLs)
+			}
+			for i := range urls {
+				if urls[i] != tt.wantURLs[i] {
+					t.Fatalf("got %v, want %v", urls, tt.wantURLs)
+				}
+			}
+		})
+	}
+}
+
+func TestPipelineCurrencyTagging(t *testing.T) {
+	p := NewPipeline(NewRSSProvider(fixtureFeeds(), time.Hour), nil, PipelineOptions{PerCurrency: 10, MinRelevance: 0.5})
+	p.now = func() time.Time { return time.Date(2026, 10, 5, 12, 0, 0, 0, time.UTC) }
+	items, err := p.Gather(context.Background(), []string{"EUR_USD", "USD_JPY"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	byURL := make(map[string]ScoredItem)
+	for _, it := range items {
+		byURL[it.Url] = it
+		for _, code := range []string{"EUR", "USD", "JPY"} {
+			if _, ok := it.Relevance[code]; !ok {
+				t.Errorf("%s has no relevance for %s", it.Url, code)
+			}
+		}
+	}
+	ecb, ok := byURL["https://www.fxwire.example/ecb-hike/"]
+	if !ok {
+		t.Fatalf("ECB story missing from %v", items)
+	}
+	if ecb.Relevance["EUR"] != 1 || ecb.Relevance["JPY"] != 0 || ecb.Sentiment <= 0 {
+		t.Errorf("ECB story scored %+v", ecb)
+	}
+	boj, ok := byURL["https://www.fxwire.example/boj-hold"]
+	if !ok {
+		t.Fatalf("BoJ story missing once JPY is a target: %v", items)
+	}
+	if boj.Relevance["JPY"] != 1 || boj.Relevance["EUR"] != 0 {
+		t.Errorf("BoJ story scored %+v", boj)
+	}
+	fed := byURL["https://www.fxwire.example/fed-cut"]
+	if fed.Sentiment >= 0 {
+		t.Errorf("Fed cut story should be bearish, got %v", fed.Sentiment)
+	}
+	if fed.PublishedAt == nil || !fed.PublishedAt.Equal(time.Date(2026, 10, 5, 8, 0, 0, 0, time.UTC)) {
+		t.Errorf("Fed cut story published at %v", fed.PublishedAt)
+	}
+	for _, it := range items {
+		if maxRelevance(it.Relevance) < 0.5 {
+			t.Errorf("%s is below MinRelevance: %v", it.Url, it.Relevance)
+		}
+	}
+}
@@ -0,0 +1,128 @@
+package news
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"sort"
+	"strings"
+	"sync"
+)
+
+// Article is a single news result, independent of where it came from.
+type Article struct {
+	Title     string `json:"title"`
+	Url       string `json:"url"`
+	Snippet   string `json:"snippet"`
+	Source    string `json:"source"`
+	Published string `json:"published"`
+}
+
+// NewsProvider is a source of news articles that can be searched by free-text query.
+type NewsProvider interface {
+	Name() string
+	SearchNews(ctx context.Context, query string, count int) ([]Article, error)
+}
+
+// FanOut queries several providers concurrently and merges their results.
+type FanOut struct {
+	providers []NewsProvider
+}
+
+var _ NewsProvider = (*FanOut)(nil)
+
+func NewFanOut(providers ...NewsProvider) *FanOut {
+	return &FanOut{providers: providers}
+}
+
+func (f *FanOut) Name() string {
+	names := make([]string, 0, len(f.providers))
+	for _, p := range f.providers {
+		names = append(names, p.Name())
+	}
+	return "fanout(" + strings.Join(names, ",") + ")"
+}
+
+// SearchNews asks every provider for count results, then dedupes and ranks the union.
+// It fails only when every provider fails.
+func (f *FanOut) SearchNews(ctx context.Context, query string, count int) ([]Article, error) {
+	if len(f.providers) == 0 {
+		return nil, fmt.Errorf("no news providers configured")
+	}
+	results := make([][]Article, len(f.providers))
+	errs := make([]error, len(f.providers))
+	var wg sync.WaitGroup
+	for i, p := range f.providers {
+		wg.Add(1)
+		go func(i int, p NewsProvider) {
+			defer wg.Done()
+			results[i], errs[i] = p.SearchNews(ctx, query, count)
+		}(i, p)
+	}
+	wg.Wait()
+
+	var merged []Article
+	failed := 0
+	for i, err := range errs {
+		if err != nil {
+			log.Printf("[NEWS] provider=%s query=%q error: %v", f.providers[i].Name(), query, err)
+			failed++
+			continue
+		}
+		merged = append(merged, results[i]...)
+	}
+	if failed == len(f.providers) {
+		return nil, errs[0]
+	}
+	ranked := Rank(Dedupe(merged), query)
+	if count > 0 && len(ranked) > count {
+		ranked = ranked[:count]
+	}
+	return ranked, nil
+}
+
+// MatchScore is the fraction of query words found in the article's title and snippet.
+func MatchScore(a Article, query string) float64 {
+	words := tokenize(query)
+	if len(words) == 0 {
+		return 0
+	}
+	text := make(map[string]bool)
+	for _, w := range tokenize(a.Title + " " + a.Snippet) {
+		text[w] = true
+	}
+	hits := 0
+	for _, w := range words {
+		if text[w] {
+			hits++
+		}
+	}
+	return float64(hits) / float64(len(words))
+}
+
+// Rank orders articles by query match, then newest first; undated articles sort last among equals.
+func Rank(items []Article, query string) []Article {
+	type ranked struct {
+		Article
+		score float64
+	}
+	rs := make([]ranked, len(items))
+	for i, it := range items {
+		rs[i] = ranked{Article: it, score: MatchScore(it, query)}
+	}
+	sort.SliceStable(rs, func(i, j int) bool {
+		if rs[i].score != rs[j].score {
+			return rs[i].score > rs[j].score
+		}
+		pi, pj := ParsePublished(rs[i].Published), ParsePublished(rs[j].Published)
+		if pi != nil && pj != nil {
+			return pi.After(*pj)
+		}
+		return pi != nil && pj == nil
+	})
+	out := make([]Article, len(rs))
+	for i, r := range rs {
+		out[i] = r.Article
+	}
+	return out
+}
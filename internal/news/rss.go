@@ -0,0 +1,256 @@
+package news
+
+import (
+	"context"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"os"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Feed is one RSS or Atom feed. URL may be http(s), file:// or a plain local path.
+type Feed struct {
+	Name string `json:"name"`
+	URL  string `json:"url"`
+}
+
+// RSSProvider searches a fixed list of RSS/Atom feeds by matching query words against item text.
+type RSSProvider struct {
+	feeds []Feed
+	http  *http.Client
+	ttl   time.Duration
+
+	mu    sync.Mutex
+	cache map[string]cachedFeed
+}
+
+type cachedFeed struct {
+	items     []Article
+	fetchedAt time.Time
+}
+
+var _ NewsProvider = (*RSSProvider)(nil)
+
+// NewRSSProvider builds a provider; feeds are re-fetched at most once per ttl.
+func NewRSSProvider(feeds []Feed, ttl time.Duration) *RSSProvider {
+	if ttl <= 0 {
+		ttl = 10 * time.Minute
+	}
+	return &RSSProvider{
+		feeds: feeds,
+		http:  &http.Client{Timeout: 15 * time.Second},
+		ttl:   ttl,
+		cache: make(map[string]cachedFeed),
+	}
+}
+
+func (r *RSSProvider) Name() string { return "rss" }
+
+func (r *RSSProvider) SearchNews(ctx context.Context, query string, count int) ([]Article, error) {
+	if count <= 0 {
+		count = 10
+	}
+	var all []Article
+	var lastErr error
+	failed := 0
+	for _, f := range r.feeds {
+		items, err := r.items(ctx, f)
+		if err != nil {
+			log.Printf("[NEWS] rss feed=%s error: %v", f.Name, err)
+			lastErr = err
+			failed++
+			continue
+		}
+		all = append(all, items...)
+	}
+	if len(r.feeds) > 0 && failed == len(r.feeds) {
+		return nil, lastErr
+	}
+	var matched []Article
+	for _, it := range all {
+		if MatchScore(it, query) > 0 {
+			matched = append(matched, it)
+		}
+	}
+	ranked := Rank(Dedupe(matched), query)
+	if len(ranked) > count {
+		ranked = ranked[:count]
+	}
+	return ranked, nil
+}
+
+func (r *RSSProvider) items(ctx context.Context, f Feed) ([]Article, error) {
+	r.mu.Lock()
+	c, ok := r.cache[f.URL]
+	r.mu.Unlock()
+	if ok && time.Since(c.fetchedAt) < r.ttl {
+		return c.items, nil
+	}
+	data, err := r.fetch(ctx, f.URL)
+	if err != nil {
+		return nil, err
+	}
+	items, err := ParseFeed(data, f.Name)
+	if err != nil {
+		return nil, fmt.Errorf("parse %s: %w", f.URL, err)
+	}
+	r.mu.Lock()
+	r.cache[f.URL] = cachedFeed{items: items, fetchedAt: time.Now()}
+	r.mu.Unlock()
+	return items, nil
+}
+
+func (r *RSSProvider) fetch(ctx context.Context, loc string) ([]byte, error) {
+	if !strings.HasPrefix(loc, "http://") && !strings.HasPrefix(loc, "https://") {
+		return os.ReadFile(strings.TrimPrefix(loc, "file://"))
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, loc, nil)
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Accept", "application/rss+xml, application/atom+xml, application/xml, text/xml")
+	resp, err := r.http.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return nil, fmt.Errorf("feed status %d", resp.StatusCode)
+	}
+	return io.ReadAll(resp.Body)
+}
+
+type rssDoc struct {
+	Channel struct {
+		Title string `xml:"title"`
+		Items []struct {
+			Title       string `xml:"title"`
+			Link        string `xml:"link"`
+			GUID        string `xml:"guid"`
+			Description string `xml:"description"`
+			PubDate     string `xml:"pubDate"`
+			Date        string `xml:"http://purl.org/dc/elements/1.1/ date"`
+		} `xml:"item"`
+	} `xml:"channel"`
+}
+
+type atomDoc struct {
+	Title   string `xml:"title"`
+	Entries []struct {
+		Title string `xml:"title"`
+		Links []struct {
+			Href string `xml:"href,attr"`
+			Rel  string `xml:"rel,attr"`
+		} `xml:"link"`
+		Summary   string `xml:"summary"`
+		Content   string `xml:"content"`
+		Published string `xml:"published"`
+		Updated   string `xml:"updated"`
+	} `xml:"entry"`
+}
+
+// ParseFeed decodes an RSS 2.0 or Atom document into articles. source names the feed
+// when the document has no title of its own.
+func ParseFeed(data []byte, source string) ([]Article, error) {
+	var root struct {
+		XMLName xml.Name
+	}
+	if err := xml.Unmarshal(data, &root); err != nil {
+		return nil, err
+	}
+	switch root.XMLName.Local {
+	case "rss":
+		var doc rssDoc
+		if err := xml.Unmarshal(data, &doc); err != nil {
+			return nil, err
+		}
+		if source == "" {
+			source = strings.TrimSpace(doc.Channel.Title)
+		}
+		out := make([]Article, 0, len(doc.Channel.Items))
+		for _, it := range doc.Channel.Items {
+			link := strings.TrimSpace(it.Link)
+			if link == "" {
+				link = strings.TrimSpace(it.GUID)
+			}
+			published := it.PubDate
+			if published == "" {
+				published = it.Date
+			}
+			out = append(out, Article{
+				Title:     strings.TrimSpace(it.Title),
+				Url:       link,
+				Snippet:   stripTags(it.Description),
+				Source:    source,
+				Published: normalizePublished(published),
+			})
+		}
+		return out, nil
+	case "feed":
+		var doc atomDoc
+		if err := xml.Unmarshal(data, &doc); err != nil {
+			return nil, err
+		}
+		if source == "" {
+			source = strings.TrimSpace(doc.Title)
+		}
+		out := make([]Article, 0, len(doc.Entries))
+		for _, e := range doc.Entries {
+			var link string
+			for _, l := range e.Links {
+				if l.Rel == "" || l.Rel == "alternate" {
+					link = l.Href
+					break
+				}
+			}
+			snippet := e.Summary
+			if snippet == "" {
+				snippet = e.Content
+			}
+			published := e.Published
+			if published == "" {
+				published = e.Updated
+			}
+			out = append(out, Article{
+				Title:     strings.TrimSpace(e.Title),
+				Url:       strings.TrimSpace(link),
+				Snippet:   stripTags(snippet),
+				Source:    source,
+				Published: normalizePublished(published),
+			})
+		}
+		return out, nil
+	default:
+		return nil, fmt.Errorf("unsupported feed root <%s>", root.XMLName.Local)
+	}
+}
+
+// normalizePublished rewrites parseable dates as RFC3339 and leaves anything else untouched.
+func normalizePublished(s string) string {
+	if t := ParsePublished(s); t != nil {
+		return t.Format(time.RFC3339)
+	}
+	return strings.TrimSpace(s)
+}
+
+// stripTags removes HTML markup that feeds commonly embed in descriptions.
+func stripTags(s string) string {
+	var b strings.Builder
+	in := false
+	for _, r := range s {
+		switch {
+		case r == '<':
+			in = true
+		case r == '>':
+			in = false
+		case !in:
+			b.WriteRune(r)
+		}
+	}
+	return strings.Join(strings.Fields(b.String()), " ")
+}
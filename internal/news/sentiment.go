@@ -0,0 +1,95 @@
+package news
+
+import (
+	"context"
+	"math"
+	"strings"
+	"unicode"
+)
+
+// Score is the sentiment of an article (-1 bearish .. +1 bullish) and its relevance (0..1) per currency.
+type Score struct {
+	Sentiment float64            `json:"sentiment"`
+	Relevance map[string]float64 `json:"relevance"`
+}
+
+// Scorer rates an article against the currencies we trade.
+type Scorer interface {
+	Score(ctx context.Context, item Article, currencies []string) (Score, error)
+}
+
+// LexiconScorer is an offline scorer built on word lists and currency aliases.
+type LexiconScorer struct{}
+
+func NewLexiconScorer() *LexiconScorer { return &LexiconScorer{} }
+
+var bullishTerms = map[string]bool{
+	"hike": true, "hikes": true, "hiked": true, "hiking": true, "hawkish": true, "tighten": true, "tightening": true,
+	"rally": true, "rallies": true, "rallied": true, "surge": true, "surges": true, "surged": true, "soar": true, "soars": true,
+	"gain": true, "gains": true, "gained": true, "rise": true, "rises": true, "rising": true, "rose": true, "climb": true, "climbs": true,
+	"strong": true, "stronger": true, "strength": true, "strengthens": true, "robust": true, "beat": true, "beats": true,
+	"upbeat": true, "optimism": true, "growth": true, "expansion": true, "recovery": true, "boost": true, "boosts": true,
+	"outperform": true, "bullish": true, "upgrade": true, "upgraded": true,
+}
+
+var bearishTerms = map[string]bool{
+	"cut": true, "cuts": true, "cutting": true, "dovish": true, "easing": true, "ease": true, "stimulus": true,
+	"fall": true, "falls": true, "fell": true, "falling": true, "drop": true, "drops": true, "dropped": true, "slide": true, "slides": true,
+	"plunge": true, "plunges": true, "plunged": true, "slump": true, "slumps": true, "tumble": true, "tumbles": true, "sink": true, "sinks": true,
+	"weak": true, "weaker": true, "weakness": true, "weakens": true, "miss": true, "misses": true, "missed": true,
+	"recession": true, "contraction": true, "slowdown": true, "crisis": true, "default": true, "downgrade": true, "downgraded": true,
+	"fears": true, "concern": true, "concerns": true, "bearish": true, "selloff": true, "deficit": true,
+}
+
+var negations = map[string]bool{"not": true, "no": true, "never": true, "without": true, "despite": true}
+
+func (l *LexiconScorer) Score(_ context.Context, item Article, currencies []string) (Score, error) {
+	title := tokenize(item.Title)
+	body := tokenize(item.Snippet)
+	all := append(append([]string(nil), title...), body...)
+
+	var pos, neg float64
+	for i, tok := range all {
+		sign := 0.0
+		switch {
+		case bullishTerms[tok]:
+			sign = 1
+		case bearishTerms[tok]:
+			sign = -1
+		default:
+			continue
+		}
+		if i > 0 && negations[all[i-1]] {
+			sign = -sign
+		}
+		if sign > 0 {
+			pos++
+		} else {
+			neg++
+		}
+	}
+	// The +1 damps single-word verdicts toward neutral.
+	sentiment := (pos - neg) / (pos + neg + 1)
+
+	titleText := " " + strings.Join(title, " ") + " "
+	bodyText := " " + strings.Join(body, " ") + " "
+	relevance := make(map[string]float64, len(currencies))
+	for _, code := range currencies {
+		cur, _ := LookupCurrency(code)
+		hits := 0.0
+		for _, alias := range cur.Aliases {
+			needle := " " + strings.Join(tokenize(alias), " ") + " "
+			hits += 2 * float64(strings.Count(titleText, needle))
+			hits += float64(strings.Count(bodyText, needle))
+		}
+		relevance[cur.Code] = math.Min(1, hits/3)
+	}
+	return Score{Sentiment: sentiment, Relevance: relevance}, nil
+}
+
+// tokenize lowercases s and splits it on anything that is not a letter or digit.
+func tokenize(s string) []string {
+	return strings.FieldsFunc(strings.ToLower(s), func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsDigit(r)
+	})
+}
@@ -0,0 +1,185 @@
+package notify
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"net/smtp"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// postJSON sends body and classifies the response: 2xx succeeds, 4xx other than 408 and 429 is
+// permanent, anything else is retried.
+func postJSON(ctx context.Context, client *http.Client, url string, body []byte, header http.Header) error {
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, url, bytes.NewReader(body))
+	if err != nil {
+		return &PermanentError{Err: err}
+	}
+	req.Header.Set("Content-Type", "application/json")
+	for k, vs := range header {
+		for _, v := range vs {
+			req.Header.Add(k, v)
+		}
+	}
+	resp, err := client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode >= 200 && resp.StatusCode < 300 {
+		_, _ = io.Copy(io.Discard, resp.Body)
+		return nil
+	}
+	snippet, _ := io.ReadAll(io.LimitReader(resp.Body, 512))
+	err = fmt.Errorf("HTTP %d: %s", resp.StatusCode, strings.TrimSpace(string(snippet)))
+	if resp.StatusCode >= 400 && resp.StatusCode < 500 && resp.StatusCode != http.StatusRequestTimeout && resp.StatusCode != http.StatusTooManyRequests {
+		return &PermanentError{Err: err}
+	}
+	return err
+}
+
+// Webhook posts the event as JSON. With a secret each request carries
+// X-GoTrader-Signature: sha256=<hex HMAC-SHA256 of "<timestamp>.<body>"> and the timestamp in
+// X-GoTrader-Timestamp, so receivers can verify origin and reject replays.
+type Webhook struct {
+	name   string
+	url    string
+	secret string
+	client *http.Client
+}
+
+func NewWebhook(name, url, secret string, client *http.Client) *Webhook {
+	if client == nil {
+		client = http.DefaultClient
+	}
+	return &Webhook{name: name, url: url, secret: secret, client: client}
+}
+
+func (w *Webhook) Name() string { return w.name }
+
+func (w *Webhook) Send(ctx context.Context, e Event) error {
+	body, err := json.Marshal(e)
+	if err != nil {
+		return &PermanentError{Err: err}
+	}
+	h := http.Header{}
+	h.Set("X-GoTrader-Event", e.Type)
+	h.Set("X-GoTrader-Delivery", e.ID)
+	if w.secret != "" {
+		ts := strconv.FormatInt(time.Now().Unix(), 10)
+		h.Set("X-GoTrader-Timestamp", ts)
+		h.Set("X-GoTrader-Signature", "sha256="+Sign(w.secret, ts, body))
+	}
+	return postJSON(ctx, w.client, w.url, body, h)
+}
+
+// Sign is the webhook signature of body sent at timestamp ts.
+func Sign(secret, ts string, body []byte) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write([]byte(ts))
+	mac.Write([]byte("."))
+	mac.Write(body)
+	return hex.EncodeToString(mac.Sum(nil))
+}
+
+// Chat posts to a Slack or Discord incoming webhook, which differ only in the message field.
+type Chat struct {
+	name    string
+	url     string
+	discord bool
+	client  *http.Client
+}
+
+// NewChat builds a chat channel; flavor is "slack" or "discord".
+func NewChat(name, url, flavor string, client *http.Client) (*Chat, error) {
+	if flavor != "slack" && flavor != "discord" {
+		return nil, fmt.Errorf("notify: unknown chat flavor %q", flavor)
+	}
+	if client == nil {
+		client = http.DefaultClient
+	}
+	return &Chat{name: name, url: url, discord: flavor == "discord", client: client}, nil
+}
+
+func (c *Chat) Name() string { return c.name }
+
+// discordLimit is Discord's maximum message length.
+const discordLimit = 2000
+
+func (c *Chat) Send(ctx context.Context, e Event) error {
+	text := "*" + e.Title + "*"
+	if c.discord {
+		text = "**" + e.Title + "**"
+	}
+	if s := summary(e); s != "" {
+		text += "\n" + s
+	}
+	payload := map[string]string{"text": text}
+	if c.discord {
+		if len(text) > discordLimit {
+			text = text[:discordLimit-3] + "..."
+		}
+		payload = map[string]string{"content": text}
+	}
+	body, err := json.Marshal(payload)
+	if err != nil {
+		return &PermanentError{Err: err}
+	}
+	return postJSON(ctx, c.client, c.url, body, nil)
+}
+
+// Email sends a plain-text message through an SMTP relay. Auth is only used when a username is
+// set; net/smtp refuses it over an unencrypted connection to anything but localhost.
+type Email struct {
+	name     string
+	addr     string
+	host     string
+	username string
+	password string
+	from     string
+	to       []string
+}
+
+func NewEmail(name, host string, port int, username, password, from string, to []string) *Email {
+	if port == 0 {
+		port = 25
+	}
+	return &Email{name: name, addr: net.JoinHostPort(host, strconv.Itoa(port)), host: host, username: username, password: password, from: from, to: to}
+}
+
+func (m *Email) Name() string { return m.name }
+
+func (m *Email) Send(ctx context.Context, e Event) error {
+	var auth smtp.Auth
+	if m.username != "" {
+		auth = smtp.PlainAuth("", m.username, m.password, m.host)
+	}
+	var msg bytes.Buffer
+	fmt.Fprintf(&msg, "From: %s\r\n", m.from)
+	fmt.Fprintf(&msg, "To: %s\r\n", strings.Join(m.to, ", "))
+	fmt.Fprintf(&msg, "Subject: [go-trader] %s\r\n", e.Title)
+	fmt.Fprintf(&msg, "Date: %s\r\n", e.Time.Format(time.RFC1123Z))
+	fmt.Fprintf(&msg, "Message-ID: <%s@go-trader>\r\n", e.ID)
+	msg.WriteString("MIME-Version: 1.0\r\nContent-Type: text/plain; charset=utf-8\r\n\r\n")
+	msg.WriteString(strings.ReplaceAll(summary(e), "\n", "\r\n"))
+	msg.WriteString("\r\n")
+
+	// smtp.SendMail takes no context, so honour the attempt timeout from a goroutine.
+	done := make(chan error, 1)
+	go func() { done <- smtp.SendMail(m.addr, auth, m.from, m.to, msg.Bytes()) }()
+	select {
+	case err := <-done:
+		return err
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
@@ -0,0 +1,78 @@
+package notify
+
+import (
+	"fmt"
+	"log"
+	"net/http"
+
+	"github.com/jedi116/go-trader/internal/config"
+)
+
+// FromConfig builds a dispatcher from the notify section. Channels left without a URL or host
+// are skipped and dropped from routes; it returns nil when no route has a channel left.
+func FromConfig(cfg config.NotifyConfig, deliveries DeliveryLog) (*Dispatcher, error) {
+	var channels []Channel
+	skipped := make(map[string]bool)
+	for _, c := range cfg.Channels {
+		if c.Name == "" {
+			return nil, fmt.Errorf("notify: channel without a name")
+		}
+		switch c.Type {
+		case "webhook":
+			if c.URL == "" {
+				skipped[c.Name] = true
+				continue
+			}
+			channels = append(channels, NewWebhook(c.Name, c.URL, c.Secret, http.DefaultClient))
+		case "slack", "discord":
+			if c.URL == "" {
+				skipped[c.Name] = true
+				continue
+			}
+			ch, err := NewChat(c.Name, c.URL, c.Type, http.DefaultClient)
+			if err != nil {
+				return nil, err
+			}
+			channels = append(channels, ch)
+		case "smtp":
+			var to []string
+			for _, addr := range c.To {
+				if addr != "" {
+					to = append(to, addr)
+				}
+			}
+			if c.Host == "" || len(to) == 0 {
+				skipped[c.Name] = true
+				continue
+			}
+			channels = append(channels, NewEmail(c.Name, c.Host, c.Port, c.Username, c.Password, c.From, to))
+		default:
+			return nil, fmt.Errorf("notify: channel %q has unknown type %q", c.Name, c.Type)
+		}
+	}
+	for name := range skipped {
+		log.Printf("[NOTIFY] channel %s not configured, skipping", name)
+	}
+
+	var routes []Route
+	for _, r := range cfg.Routes {
+		route := Route{Events: r.Events}
+		for _, name := range r.Channels {
+			if !skipped[name] {
+				route.Channels = append(route.Channels, name)
+			}
+		}
+		if len(route.Channels) > 0 {
+			routes = append(routes, route)
+		}
+	}
+	if len(routes) == 0 {
+		return nil, nil
+	}
+	return NewDispatcher(channels, routes, Options{
+		Attempts:  cfg.Attempts,
+		Backoff:   cfg.Backoff,
+		Timeout:   cfg.Timeout,
+		QueueSize: cfg.QueueSize,
+	}, deliveries)
+}
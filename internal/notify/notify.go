@@ -0,0 +1,310 @@
+// Package notify delivers trading events to webhooks, chat incoming-webhooks and email, routed
+// by event type, with retries and a delivery log.
+package notify
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"math"
+	"path"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/jedi116/go-trader/pkg/models"
+)
+
+// Event types. Routes match them with shell-style patterns such as "order.*".
+const (
+	OrderExecuted     = "order.executed"
+	OrderRejected     = "order.rejected"
+	KillSwitchEngaged = "kill_switch.engaged"
+	KillSwitchReset   = "kill_switch.reset"
+	AlertFired        = "alert.fired"
+	Test              = "test"
+)
+
+// Event is one notification. Fields carries the structured details sent to webhooks and listed
+// in chat and email bodies.
+type Event struct {
+	ID     string                 `json:"id"`
+	Type   string                 `json:"type"`
+	Time   time.Time              `json:"time"`
+	Title  string                 `json:"title"`
+	Text   string                 `json:"text,omitempty"`
+	Fields map[string]interface{} `json:"fields,omitempty"`
+}
+
+// Executed is the event for a filled market order.
+func Executed(instrument string, units float64, orderID string) Event {
+	side := "BUY"
+	if units < 0 {
+		side = "SELL"
+	}
+	return Event{
+		Type:   OrderExecuted,
+		Title:  fmt.Sprintf("Order executed: %s %.0f %s", side, math.Abs(units), instrument),
+		Fields: map[string]interface{}{"instrument": instrument, "units": units, "order_id": orderID},
+	}
+}
+
+// Notifier accepts events for delivery; *Dispatcher implements it and is safe to use when nil.
+type Notifier interface {
+	Notify(ctx context.Context, e Event)
+}
+
+// Channel sends an event to one destination.
+type Channel interface {
+	Name() string
+	Send(ctx context.Context, e Event) error
+}
+
+// PermanentError marks a failure that retrying will not fix, such as an HTTP 400.
+type PermanentError struct {
+	Err error
+}
+
+func (e *PermanentError) Error() string { return e.Err.Error() }
+func (e *PermanentError) Unwrap() error { return e.Err }
+
+// DeliveryLog records the outcome of each delivery; database.Store implements it.
+type DeliveryLog interface {
+	LogNotificationDelivery(ctx context.Context, d *models.NotificationDelivery) error
+}
+
+// Route sends events whose type matches any of Events to the named channels.
+type Route struct {
+	Events   []string
+	Channels []string
+}
+
+type Options struct {
+	// Attempts is the number of tries per channel, including the first; zero means 3.
+	Attempts int
+	// Backoff is the wait before the first retry, doubled for each further retry; zero means 2s.
+	Backoff time.Duration
+	// Timeout bounds each attempt; zero means 10s.
+	Timeout time.Duration
+	// QueueSize is how many events may wait for delivery before new ones are dropped.
+	QueueSize int
+}
+
+func (o Options) withDefaults() Options {
+	if o.Attempts <= 0 {
+		o.Attempts = 3
+	}
+	if o.Backoff <= 0 {
+		o.Backoff = 2 * time.Second
+	}
+	if o.Timeout <= 0 {
+		o.Timeout = 10 * time.Second
+	}
+	if o.QueueSize <= 0 {
+		o.QueueSize = 100
+	}
+	return o
+}
+
+// Result is the outcome of delivering one event to one channel.
+type Result struct {
+	Channel  string `json:"channel"`
+	Attempts int    `json:"attempts"`
+	Error    string `json:"error,omitempty"`
+}
+
+// Dispatcher queues events and delivers them in the background. A nil *Dispatcher discards
+// everything, so callers need not check whether notifications are configured.
+type Dispatcher struct {
+	channels map[string]Channel
+	routes   []Route
+	opts     Options
+	log      DeliveryLog
+	queue    chan Event
+}
+
+// NewDispatcher builds a dispatcher; deliveries may be nil. Routes naming unknown channels are
+// an error so that a typo does not silently drop notifications.
+func NewDispatcher(channels []Channel, routes []Route, opts Options, deliveries DeliveryLog) (*Dispatcher, error) {
+	opts = opts.withDefaults()
+	d := &Dispatcher{channels: make(map[string]Channel, len(channels)), routes: routes, opts: opts, log: deliveries, queue: make(chan Event, opts.QueueSize)}
+	for _, c := range channels {
+		if _, dup := d.channels[c.Name()]; dup {
+			return nil, fmt.Errorf("notify: duplicate channel %q", c.Name())
+		}
+		d.channels[c.Name()] = c
+	}
+	for _, r := range routes {
+		for _, name := range r.Channels {
+			if _, ok := d.channels[name]; !ok {
+				return nil, fmt.Errorf("notify: route references unknown channel %q", name)
+			}
+		}
+		for _, pattern := range r.Events {
+			if _, err := path.Match(pattern, ""); err != nil {
+				return nil, fmt.Errorf("notify: bad event pattern %q", pattern)
+			}
+		}
+	}
+	return d, nil
+}
+
+// Notify queues an event without blocking; when the queue is full the event is dropped and logged.
+func (d *Dispatcher) Notify(_ context.Context, e Event) {
+	if d == nil {
+		return
+	}
+	d.stamp(&e)
+	select {
+	case d.queue <- e:
+	default:
+		log.Printf("[NOTIFY] queue full, dropped %s %q", e.Type, e.Title)
+	}
+}
+
+func (d *Dispatcher) stamp(e *Event) {
+	if e.ID == "" {
+		b := make([]byte, 16)
+		_, _ = rand.Read(b)
+		e.ID = hex.EncodeToString(b)
+	}
+	if e.Time.IsZero() {
+		e.Time = time.Now().UTC()
+	}
+}
+
+// Run delivers queued events until ctx is done.
+func (d *Dispatcher) Run(ctx context.Context) {
+	if d == nil {
+		return
+	}
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case e := <-d.queue:
+			d.Deliver(ctx, e)
+		}
+	}
+}
+
+// Channels returns the names of the channels an event type is routed to.
+func (d *Dispatcher) Channels(eventType string) []string {
+	if d == nil {
+		return nil
+	}
+	set := make(map[string]bool)
+	for _, r := range d.routes {
+		for _, pattern := range r.Events {
+			if ok, _ := path.Match(pattern, eventType); ok {
+				for _, name := range r.Channels {
+					set[name] = true
+				}
+				break
+			}
+		}
+	}
+	out := make([]string, 0, len(set))
+	for name := range set {
+		out = append(out, name)
+	}
+	sort.Strings(out)
+	return out
+}
+
+// Deliver sends an event to its routed channels concurrently, retrying each with backoff,
+// and returns once every channel has succeeded or given up.
+func (d *Dispatcher) Deliver(ctx context.Context, e Event) []Result {
+	if d == nil {
+		return nil
+	}
+	d.stamp(&e)
+	names := d.Channels(e.Type)
+	results := make([]Result, len(names))
+	var wg sync.WaitGroup
+	for i, name := range names {
+		wg.Add(1)
+		go func(i int, ch Channel) {
+			defer wg.Done()
+			results[i] = d.send(ctx, ch, e)
+		}(i, d.channels[name])
+	}
+	wg.Wait()
+	return results
+}
+
+func (d *Dispatcher) send(ctx context.Context, ch Channel, e Event) Result {
+	res := Result{Channel: ch.Name()}
+	var err error
+	wait := d.opts.Backoff
+	for res.Attempts < d.opts.Attempts {
+		res.Attempts++
+		attemptCtx, cancel := context.WithTimeout(ctx, d.opts.Timeout)
+		err = ch.Send(attemptCtx, e)
+		cancel()
+		var pe *PermanentError
+		if err == nil || errors.As(err, &pe) || res.Attempts == d.opts.Attempts {
+			break
+		}
+		timer := time.NewTimer(wait)
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			err = ctx.Err()
+		case <-timer.C:
+		}
+		if ctx.Err() != nil {
+			break
+		}
+		wait *= 2
+	}
+	status := models.NotificationSent
+	if err != nil {
+		status = models.NotificationFailed
+		res.Error = err.Error()
+		log.Printf("[NOTIFY] %s via %s failed after %d attempts: %v", e.Type, ch.Name(), res.Attempts, err)
+	}
+	d.record(ctx, e, res, status)
+	return res
+}
+
+func (d *Dispatcher) record(ctx context.Context, e Event, res Result, status models.NotificationStatus) {
+	if d.log == nil {
+		return
+	}
+	payload, _ := json.Marshal(e)
+	entry := &models.NotificationDelivery{EventID: e.ID, EventType: e.Type, Channel: res.Channel, Status: status, Attempts: res.Attempts, Payload: payload}
+	if res.Error != "" {
+		entry.Error = &res.Error
+	}
+	// The delivery outcome is worth keeping even when ctx was cancelled during retries.
+	logCtx, cancel := context.WithTimeout(context.WithoutCancel(ctx), 5*time.Second)
+	defer cancel()
+	if err := d.log.LogNotificationDelivery(logCtx, entry); err != nil {
+		log.Printf("[NOTIFY] delivery log error: %v", err)
+	}
+}
+
+// summary renders an event as plain text for chat and email: the text followed by one
+// "key: value" line per field in key order.
+func summary(e Event) string {
+	var b strings.Builder
+	if e.Text != "" {
+		b.WriteString(e.Text)
+		b.WriteString("\n")
+	}
+	keys := make([]string, 0, len(e.Fields))
+	for k := range e.Fields {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	for _, k := range keys {
+		fmt.Fprintf(&b, "%s: %v\n", k, e.Fields[k])
+	}
+	return strings.TrimRight(b.String(), "\n")
+}
@@ -0,0 +1,440 @@
+package notify
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/jedi116/go-trader/pkg/models"
+)
+
+// stubServer answers with the given status codes in turn, repeating the last one, and keeps
+// every request it received.
+type stubServer struct {
+	*httptest.Server
+	mu       sync.Mutex
+	codes    []int
+	requests []stubRequest
+}
+
+type stubRequest struct {
+	header http.Header
+	body   []byte
+}
+
+func newStubServer(t *testing.T, codes ...int) *stubServer {
+	t.Helper()
+	s := &stubServer{codes: codes}
+	s.Server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		s.mu.Lock()
+		s.requests = append(s.requests, stubRequest{header: r.Header.Clone(), body: body})
+		code := s.codes[min(len(s.requests), len(s.codes))-1]
+		s.mu.Unlock()
+		w.WriteHeader(code)
+		_, _ = w.Write([]byte("stub says " + http.StatusText(code)))
+	}))
+	t.Cleanup(s.Close)
+	return s
+}
+
+func (s *stubServer) received() []stubRequest {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return append([]stubRequest(nil), s.requests...)
+}
+
+type memoryLog struct {
+	mu      sync.Mutex
+	entries []models.NotificationDelivery
+}
+
+func (l *memoryLog) LogNotificationDelivery(_ context.Context, d *models.NotificationDelivery) error {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.entries = append(l.entries, *d)
+	return nil
+}
+
+func testEvent() Event {
+	e := Executed("EUR_USD", -1000, "42")
+	e.ID = "evt-1"
+	e.Time = time.Date(2026, 10, 5, 12, 0, 0, 0, time.UTC)
+	return e
+}
+
+func TestWebhookSend(t *testing.T) {
+	srv := newStubServer(t, http.StatusNoContent)
+	if err := NewWebhook("hook", srv.URL, "s3cret", srv.Client()).Send(context.Background(), testEvent()); err != nil {
+		t.Fatal(err)
+	}
+	reqs := srv.received()
+	if len(reqs) != 1 {
+		t.Fatalf("got %d requests, want 1", len(reqs))
+	}
+	r := reqs[0]
+	if r.header.Get("Content-Type") != "application/json" || r.header.Get("X-GoTrader-Event") != OrderExecuted || r.header.Get("X-GoTrader-Delivery") != "evt-1" {
+		t.Errorf("headers = %v", r.header)
+	}
+	ts := r.header.Get("X-GoTrader-Timestamp")
+	if want := "sha256=" + Sign("s3cret", ts, r.body); ts == "" || r.header.Get("X-GoTrader-Signature") != want {
+		t.Errorf("signature %q does not verify (timestamp %q)", r.header.Get("X-GoTrader-Signature"), ts)
+	}
+	var got Event
+	if err := json.Unmarshal(r.body, &got); err != nil {
+		t.Fatal(err)
+	}
+	if got.ID != "evt-1" || got.Type != OrderExecuted || got.Fields["instrument"] != "EUR_USD" {
+		t.Errorf("body = %+v", got)
+	}
+
+	// Without a secret nothing is signed.
+	srv = newStubServer(t, http.StatusOK)
+	if err := NewWebhook("hook", srv.URL, "", srv.Client()).Send(context.Background(), testEvent()); err != nil {
+		t.Fatal(err)
+	}
+	if h := srv.received()[0].header; h.Get("X-GoTrader-Signature") != "" || h.Get("X-GoTrader-Timestamp") != "" {
+		t.Errorf("unsigned webhook sent signature headers: %v", h)
+	}
+}
+
+func TestChatSend(t *testing.T) {
+	tests := []struct {
+		flavor string
+		field  string
+		prefix string
+	}{
+		{"slack", "text", "*Order executed: SELL 1000 EUR_USD*\n"},
+		{"discord", "content", "**Order executed: SELL 1000 EUR_USD**\n"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.flavor, func(t *testing.T) {
+			srv := newStubServer(t, http.StatusOK)
+			ch, err := NewChat("chat", srv.URL, tt.flavor, srv.Client())
+			if err != nil {
+				t.Fatal(err)
+			}
+			if err := ch.Send(context.Background(), testEvent()); err != nil {
+				t.Fatal(err)
+			}
+			var payload map[string]string
+			if err := json.Unmarshal(srv.received()[0].body, &payload); err != nil {
+				t.Fatal(err)
+			}
+			if len(payload) != 1 {
+				t.Errorf("payload = %v, want only %q", payload, tt.field)
+			}
+			want := tt.prefix + "instrument: EUR_USD\norder_id: 42\nunits: -1000"
+			if payload[tt.field] != want {
+				t.Errorf("%s = %q, want %q", tt.field, payload[tt.field], want)
+			}
+		})
+	}
+
+	if _, err := NewChat("chat", "http://x", "teams", nil); err == nil {
+		t.Error("expected an error for an unknown flavor")
+	}
+}
+
+func TestChatDiscordTruncates(t *testing.T) {
+	srv := newStubServer(t, http.StatusOK)
+	ch, _ := NewChat("chat", srv.URL, "discord", srv.Client())
+	e := testEvent()
+	e.Text = strings.Repeat("x", 3*discordLimit)
+	if err := ch.Send(context.Background(), e); err != nil {
+		t.Fatal(err)
+	}
+	var payload map[string]string
+	_ = json.Unmarshal(srv.received()[0].body, &payload)
+	if n := len(payload["content"]); n != discordLimit || !strings.HasSuffix(payload["content"], "...") {
+		t.Errorf("content length %d, want %d ending in ...", n, discordLimit)
+	}
+}
+
+func TestPostJSONClassification(t *testing.T) {
+	tests := []struct {
+		code      int
+		wantErr   bool
+		permanent bool
+	}{
+		{http.StatusOK, false, false},
+		{http.StatusAccepted, false, false},
+		{http.StatusBadRequest, true, true},
+		{http.StatusNotFound, true, true},
+		{http.StatusRequestTimeout, true, false},
+		{http.StatusTooManyRequests, true, false},
+		{http.StatusInternalServerError, true, false},
+		{http.StatusBadGateway, true, false},
+	}
+	for _, tt := range tests {
+		t.Run(http.StatusText(tt.code), func(t *testing.T) {
+			srv := newStubServer(t, tt.code)
+			err := postJSON(context.Background(), srv.Client(), srv.URL, []byte(`{}`), nil)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("err = %v, wantErr %v", err, tt.wantErr)
+			}
+			var pe *PermanentError
+			if errors.As(err, &pe) != tt.permanent {
+				t.Errorf("err = %v, permanent %v", err, tt.permanent)
+			}
+			if err != nil && !strings.Contains(err.Error(), "stub says") {
+				t.Errorf("error %q does not carry the response body", err)
+			}
+		})
+	}
+
+	// A connection failure is retryable.
+	srv := newStubServer(t, http.StatusOK)
+	url := srv.URL
+	srv.Close()
+	err := postJSON(context.Background(), http.DefaultClient, url, []byte(`{}`), nil)
+	var pe *PermanentError
+	if err == nil || errors.As(err, &pe) {
+		t.Errorf("closed server: err = %v, want a retryable error", err)
+	}
+}
+
+func TestDispatcherRetries(t *testing.T) {
+	tests := []struct {
+		name         string
+		codes        []int
+		attempts     int
+		wantAttempts int
+		wantStatus   models.NotificationStatus
+	}{
+		{"succeeds first time", []int{200}, 3, 1, models.NotificationSent},
+		{"succeeds after transient failures", []int{503, 500, 200}, 3, 3, models.NotificationSent},
+		{"gives up after all attempts", []int{500}, 3, 3, models.NotificationFailed},
+		{"permanent failure is not retried", []int{400, 200}, 3, 1, models.NotificationFailed},
+		{"rate limit is retried", []int{429, 200}, 3, 2, models.NotificationSent},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			srv := newStubServer(t, tt.codes...)
+			deliveries := &memoryLog{}
+			d, err := NewDispatcher(
+				[]Channel{NewWebhook("hook", srv.URL, "", srv.Client())},
+				[]Route{{Events: []string{"order.*"}, Channels: []string{"hook"}}},
+				Options{Attempts: tt.attempts, Backoff: time.Millisecond, Timeout: time.Second},
+				deliveries)
+			if err != nil {
+				t.Fatal(err)
+			}
+			results := d.Deliver(context.Background(), testEvent())
+			if len(results) != 1 || results[0].Attempts != tt.wantAttempts {
+				t.Fatalf("results = %+v, want %d attempts", results, tt.wantAttempts)
+			}
+			if got := len(srv.received()); got != tt.wantAttempts {
+				t.Errorf("server saw %d requests, want %d", got, tt.wantAttempts)
+			}
+			if (results[0].Error != "") != (tt.wantStatus == models.NotificationFailed) {
+				t.Errorf("result error = %q", results[0].Error)
+			}
+			if len(deliveries.entries) != 1 {
+				t.Fatalf("logged %d deliveries, want 1", len(deliveries.entries))
+			}
+			entry := deliveries.entries[0]
+			if entry.Status != tt.wantStatus || entry.Attempts != tt.wantAttempts || entry.Channel != "hook" || entry.EventID != "evt-1" {
+				t.Errorf("delivery log = %+v", entry)
+			}
+		})
+	}
+}
+
+func TestDispatcherCancelStopsRetrying(t *testing.T) {
+	srv := newStubServer(t, http.StatusServiceUnavailable)
+	d, err := NewDispatcher(
+		[]Channel{NewWebhook("hook", srv.URL, "", srv.Client())},
+		[]Route{{Events: []string{"*"}, Channels: []string{"hook"}}},
+		Options{Attempts: 5, Backoff: time.Hour, Timeout: time.Second}, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+	results := d.Deliver(ctx, testEvent())
+	if results[0].Attempts != 1 || !strings.Contains(results[0].Error, context.DeadlineExceeded.Error()) {
+		t.Errorf("results = %+v, want one attempt ending in the context error", results)
+	}
+}
+
+func TestDispatcherRouting(t *testing.T) {
+	var hookHits, chatHits atomic.Int32
+	hook := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) { hookHits.Add(1) }))
+	defer hook.Close()
+	chat := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) { chatHits.Add(1) }))
+	defer chat.Close()
+	slack, _ := NewChat("slack", chat.URL, "slack", chat.Client())
+	d, err := NewDispatcher(
+		[]Channel{NewWebhook("hook", hook.URL, "", hook.Client()), slack},
+		[]Route{
+			{Events: []string{"order.*"}, Channels: []string{"hook"}},
+			{Events: []string{OrderRejected, "kill_switch.*"}, Channels: []string{"hook", "slack"}},
+		},
+		Options{Attempts: 1}, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	tests := []struct {
+		eventType string
+		channels  []string
+	}{
+		{OrderExecuted, []string{"hook"}},
+		{OrderRejected, []string{"hook", "slack"}},
+		{KillSwitchEngaged, []string{"hook", "slack"}},
+		{AlertFired, nil},
+	}
+	for _, tt := range tests {
+		got := d.Channels(tt.eventType)
+		if strings.Join(got, ",") != strings.Join(tt.channels, ",") {
+			t.Errorf("Channels(%s) = %v, want %v", tt.eventType, got, tt.channels)
+		}
+	}
+	d.Deliver(context.Background(), Event{Type: OrderRejected, Title: "rejected"})
+	d.Deliver(context.Background(), Event{Type: AlertFired, Title: "alert"})
+	if hookHits.Load() != 1 || chatHits.Load() != 1 {
+		t.Errorf("hook hits %d, chat hits %d, want 1 each", hookHits.Load(), chatHits.Load())
+	}
+
+	if _, err := NewDispatcher(nil, []Route{{Events: []string{"*"}, Channels: []string{"missing"}}}, Options{}, nil); err == nil {
+		t.Error("expected an error for a route to an unknown channel")
+	}
+}
+
+func TestNilDispatcher(t *testing.T) {
+	var d *Dispatcher
+	d.Notify(context.Background(), testEvent())
+	if d.Deliver(context.Background(), testEvent()) != nil || d.Channels(OrderExecuted) != nil {
+		t.Error("nil dispatcher should discard events")
+	}
+	d.Run(context.Background())
+}
+
+// smtpStub is a minimal SMTP server that accepts one message per connection and hands the
+// envelope and data to the test.
+type smtpStub struct {
+	ln       net.Listener
+	messages chan smtpMessage
+}
+
+type smtpMessage struct {
+	from string
+	to   []string
+	data string
+}
+
+func newSMTPStub(t *testing.T) *smtpStub {
+	t.Helper()
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	s := &smtpStub{ln: ln, messages: make(chan smtpMessage, 4)}
+	t.Cleanup(func() { ln.Close() })
+	go func() {
+		for {
+			conn, err := ln.Accept()
+			if err != nil {
+				return
+			}
+			go s.serve(conn)
+		}
+	}()
+	return s
+}
+
+func (s *smtpStub) serve(conn net.Conn) {
+	defer conn.Close()
+	r := bufio.NewReader(conn)
+	reply := func(line string) { _, _ = io.WriteString(conn, line+"\r\n") }
+	reply("220 stub ESMTP")
+	var msg smtpMessage
+	for {
+		line, err := r.ReadString('\n')
+		if err != nil {
+			return
+		}
+		cmd := strings.TrimRight(line, "\r\n")
+		switch upper := strings.ToUpper(cmd); {
+		case strings.HasPrefix(upper, "EHLO"), strings.HasPrefix(upper, "HELO"):
+			reply("250 stub")
+		case strings.HasPrefix(upper, "MAIL FROM:"):
+			msg.from = strings.Trim(cmd[len("MAIL FROM:"):], "<> ")
+			reply("250 OK")
+		case strings.HasPrefix(upper, "RCPT TO:"):
+			msg.to = append(msg.to, strings.Trim(cmd[len("RCPT TO:"):], "<> "))
+			reply("250 OK")
+		case upper == "DATA":
+			reply("354 end with <CRLF>.<CRLF>")
+			var b strings.Builder
+			for {
+				l, err := r.ReadString('\n')
+				if err != nil {
+					return
+				}
+				if l == ".\r\n" {
+					break
+				}
+				b.WriteString(l)
+			}
+			msg.data = b.String()
+			s.messages <- msg
+			reply("250 queued")
+		case upper == "QUIT":
+			reply("221 bye")
+			return
+		default:
+			reply("250 OK")
+		}
+	}
+}
+
+func TestEmailSend(t *testing.T) {
+	stub := newSMTPStub(t)
+	addr := stub.ln.Addr().(*net.TCPAddr)
+	m := NewEmail("mail", addr.IP.String(), addr.Port, "", "", "bot@example.com", []string{"ops@example.com", "desk@example.com"})
+	if err := m.Send(context.Background(), testEvent()); err != nil {
+		t.Fatal(err)
+	}
+	select {
+	case msg := <-stub.messages:
+		if msg.from != "bot@example.com" || strings.Join(msg.to, ",") != "ops@example.com,desk@example.com" {
+			t.Errorf("envelope from %q to %v", msg.from, msg.to)
+		}
+		for _, want := range []string{
+			"Subject: [go-trader] Order executed: SELL 1000 EUR_USD\r\n",
+			"Message-ID: <evt-1@go-trader>\r\n",
+			"To: ops@example.com, desk@example.com\r\n",
+			"\r\n\r\ninstrument: EUR_USD\r\norder_id: 42\r\nunits: -1000\r\n",
+		} {
+			if !strings.Contains(msg.data, want) {
+				t.Errorf("message missing %q:\n%s", want, msg.data)
+			}
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("no message received")
+	}
+}
+
+func TestEmailSendUnreachable(t *testing.T) {
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	addr := ln.Addr().(*net.TCPAddr)
+	ln.Close()
+	m := NewEmail("mail", "127.0.0.1", addr.Port, "", "", "bot@example.com", []string{"ops@example.com"})
+	if err := m.Send(context.Background(), testEvent()); err == nil {
+		t.Error("expected an error when the relay is down")
+	}
+}
@@ -0,0 +1,197 @@
+// Package orders is the one path every order takes to the broker, whatever the entry point:
+// market hours, kill switch, calendar blackout and risk checks, placement with brackets, the
+// execution notification and the trades row.
+package orders
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/jedi116/go-trader/internal/broker"
+	"github.com/jedi116/go-trader/internal/calendar"
+	"github.com/jedi116/go-trader/internal/database"
+	"github.com/jedi116/go-trader/internal/markethours"
+	"github.com/jedi116/go-trader/internal/notify"
+	"github.com/jedi116/go-trader/internal/risk"
+	"github.com/jedi116/go-trader/pkg/models"
+)
+
+// Broker places orders and quotes the price used as entry when a fill carries none.
+type Broker interface {
+	GetPrices(instruments []string) ([]broker.Price, error)
+	PlaceMarketOrder(instrument string, units float64) (*broker.OrderCreateResponse, error)
+	PlaceMarketOrderWithBrackets(instrument string, units float64, stopLoss, takeProfit *float64) (*broker.OrderCreateResponse, error)
+}
+
+// CheckError is a pre-trade check that could not be run, e.g. the account or calendar was
+// unavailable. It is not a refusal of the order itself.
+type CheckError struct {
+	Check string
+	Err   error
+}
+
+func (e *CheckError) Error() string { return fmt.Sprintf("%s check: %v", e.Check, e.Err) }
+
+func (e *CheckError) Unwrap() error { return e.Err }
+
+// Service submits orders. The hours gate is optional: nil lets orders through while the market
+// is closed, for deployments with market_hours.closed_orders "allow".
+type Service struct {
+	broker   Broker
+	store    database.Store
+	hours    *markethours.Calendar
+	calendar *calendar.Guard
+	risk     *risk.Engine
+	guardian *risk.Guardian
+	notifier *notify.Dispatcher
+
+	// mu serializes check, place and record, so concurrent orders from different entry points
+	// are each checked against an account that includes the ones before them.
+	mu sync.Mutex
+}
+
+// New builds a service. calendar, guardian and notifier may be nil.
+func New(b Broker, store database.Store, hours *markethours.Calendar, guard *calendar.Guard, engine *risk.Engine, guardian *risk.Guardian, notifier *notify.Dispatcher) *Service {
+	return &Service{broker: b, store: store, hours: hours, calendar: guard, risk: engine, guardian: guardian, notifier: notifier}
+}
+
+// Result is a placed order and the calendar events it was warned about.
+type Result struct {
+	Order    *broker.OrderCreateResponse
+	Warnings []models.EconomicEvent
+}
+
+// Check runs the pre-trade gates in order. Refusals come back as *markethours.ClosedError,
+// *risk.HaltedError, *calendar.BlackoutError or *risk.RejectionError; a gate that could not be
+// evaluated as *CheckError. Warn-mode calendar events are returned on success.
+func (s *Service) Check(ctx context.Context, o risk.Order) ([]models.EconomicEvent, error) {
+	now := time.Now()
+	if s.hours != nil {
+		if err := s.hours.Check(now); err != nil {
+			return nil, err
+		}
+	}
+	if err := s.guardian.Check(ctx); err != nil {
+		var he *risk.HaltedError
+		if errors.As(err, &he) {
+			return nil, err
+		}
+		return nil, &CheckError{Check: "kill switch", Err: err}
+	}
+	warnings, err := s.calendar.Check(ctx, o.Instrument, now)
+	if err != nil {
+		var be *calendar.BlackoutError
+		if errors.As(err, &be) {
+			return nil, err
+		}
+		return nil, &CheckError{Check: "calendar", Err: err}
+	}
+	if _, err := s.risk.Evaluate(ctx, o); err != nil {
+		var re *risk.RejectionError
+		if errors.As(err, &re) {
+			return nil, err
+		}
+		return nil, &CheckError{Check: "risk", Err: err}
+	}
+	return warnings, nil
+}
+
+// Submit checks, places and records an order; the trade source follows from o.Source.
+func (s *Service) Submit(ctx context.Context, o risk.Order) (*Result, error) {
+	return s.submit(ctx, o, TradeSource(o.Source))
+}
+
+// SubmitClaimed is Submit for orders that must be claimed once they pass the checks, such as
+// queued orders: claim runs just before placement, and when it returns false the order is
+// dropped and SubmitClaimed returns nil, nil.
+func (s *Service) SubmitClaimed(ctx context.Context, o risk.Order, claim func() bool) (*Result, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.submitLocked(ctx, o, TradeSource(o.Source), claim)
+}
+
+func (s *Service) submit(ctx context.Context, o risk.Order, tradeSource string) (*Result, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.submitLocked(ctx, o, tradeSource, nil)
+}
+
+func (s *Service) submitLocked(ctx context.Context, o risk.Order, tradeSource string, claim func() bool) (*Result, error) {
+	warnings, err := s.Check(ctx, o)
+	if err != nil {
+		return nil, err
+	}
+	if claim != nil && !claim() {
+		return nil, nil
+	}
+	resp, err := s.place(o)
+	if err != nil {
+		return nil, err
+	}
+	s.record(ctx, o.Instrument, o.Units, tradeSource, resp)
+	return &Result{Order: resp, Warnings: warnings}, nil
+}
+
+// place sends the order to the broker without any checks, with brackets when it has them.
+func (s *Service) place(o risk.Order) (*broker.OrderCreateResponse, error) {
+	if o.StopLoss != nil || o.TakeProfit != nil {
+		return s.broker.PlaceMarketOrderWithBrackets(o.Instrument, o.Units, o.StopLoss, o.TakeProfit)
+	}
+	return s.broker.PlaceMarketOrder(o.Instrument, o.Units)
+}
+
+// record announces an executed order and stores it in the trades table, using the fill price as
+// entry, or the current mid when the response carries no fill. The trade is keyed by the OANDA
+// trade it opened so the closed-trade sync can find it.
+func (s *Service) record(ctx context.Context, instrument string, units float64, source string, resp *broker.OrderCreateResponse) {
+	if resp == nil {
+		return
+	}
+	s.notifier.Notify(ctx, notify.Executed(instrument, units, resp.OrderCreateTransaction.ID))
+	entry := 0.0
+	if f := resp.OrderFillTransaction; f != nil && f.Price > 0 {
+		entry = f.Price
+	} else if prices, perr := s.broker.GetPrices([]string{instrument}); perr == nil && len(prices) > 0 && len(prices[0].Bids) > 0 && len(prices[0].Asks) > 0 {
+		b, _ := strconv.ParseFloat(prices[0].Bids[0].Price, 64)
+		a, _ := strconv.ParseFloat(prices[0].Asks[0].Price, 64)
+		if b > 0 && a > 0 {
+			entry = (b + a) / 2
+		}
+	}
+	direction := "BUY"
+	if units < 0 {
+		direction = "SELL"
+	}
+	id := resp.OrderCreateTransaction.ID
+	if f := resp.OrderFillTransaction; f != nil && f.TradeOpened != nil && f.TradeOpened.TradeID != "" {
+		id = f.TradeOpened.TradeID
+	}
+	tr := &models.Trade{
+		ID:           "", // let the store assign a UUID
+		Instrument:   instrument,
+		Direction:    direction,
+		Units:        units,
+		EntryPrice:   &entry,
+		Status:       models.TradeStatusOpen,
+		OandaTradeID: &id,
+		Source:       source,
+	}
+	_ = s.store.CreateTrade(ctx, tr)
+}
+
+// TradeSource maps a risk.Order source onto the trade sources kept in the trades table.
+func TradeSource(orderSource string) string {
+	src := strings.TrimPrefix(orderSource, "queue:")
+	switch {
+	case src == "signal" || strings.HasPrefix(src, "signal:"):
+		return models.TradeSourceSignal
+	case strings.HasPrefix(src, "strategy:"):
+		return models.TradeSourceStrategy
+	}
+	return models.TradeSourceManual
+}
@@ -0,0 +1,144 @@
+package orders
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/jedi116/go-trader/internal/broker"
+	"github.com/jedi116/go-trader/internal/database"
+	"github.com/jedi116/go-trader/internal/risk"
+)
+
+// stubBroker is a USD account whose positions grow with each placed order. Placement is slow
+// enough that an unserialized second order would be checked before the first one lands; it fails
+// with err when set.
+type stubBroker struct {
+	mu        sync.Mutex
+	positions map[string]float64
+	placed    int
+	err       error
+}
+
+func newStubBroker() *stubBroker { return &stubBroker{positions: map[string]float64{}} }
+
+func (b *stubBroker) GetAccount() (*broker.Account, error) {
+	return &broker.Account{Currency: "USD", NAV: 100000, Balance: 100000, MarginAvailable: 100000}, nil
+}
+
+func (b *stubBroker) GetPositions() ([]broker.Position, error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	var out []broker.Position
+	for inst, units := range b.positions {
+		p := broker.Position{Instrument: inst}
+		if units > 0 {
+			p.Long.Units = units
+		} else {
+			p.Short.Units = units
+		}
+		out = append(out, p)
+	}
+	return out, nil
+}
+
+func (b *stubBroker) GetPrices(instruments []string) ([]broker.Price, error) {
+	out := make([]broker.Price, 0, len(instruments))
+	for _, inst := range instruments {
+		out = append(out, broker.Price{Instrument: inst, Bids: []broker.Quote{{Price: "1.2000"}}, Asks: []broker.Quote{{Price: "1.2002"}}})
+	}
+	return out, nil
+}
+
+func (b *stubBroker) GetInstruments() ([]broker.Instrument, error) {
+	return []broker.Instrument{{Name: "EUR_USD", MarginRate: 0.02}, {Name: "GBP_USD", MarginRate: 0.02}}, nil
+}
+
+func (b *stubBroker) PlaceMarketOrder(instrument string, units float64) (*broker.OrderCreateResponse, error) {
+	time.Sleep(20 * time.Millisecond)
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if b.err != nil {
+		return nil, b.err
+	}
+	b.positions[instrument] += units
+	b.placed++
+	resp := &broker.OrderCreateResponse{}
+	resp.OrderCreateTransaction.ID = instrument
+	return resp, nil
+}
+
+func (b *stubBroker) PlaceMarketOrderWithBrackets(instrument string, units float64, _, _ *float64) (*broker.OrderCreateResponse, error) {
+	return b.PlaceMarketOrder(instrument, units)
+}
+
+func newTestService(b *stubBroker, limits risk.Limits) *Service {
+	store := database.NewMemory()
+	return New(b, store, nil, nil, risk.NewEngine(b, store, limits), nil, nil)
+}
+
+func TestSubmitSerializesChecks(t *testing.T) {
+	b := newStubBroker()
+	s := newTestService(b, risk.Limits{MaxOpenPositions: 1})
+
+	var wg sync.WaitGroup
+	errs := make([]error, 2)
+	for i, inst := range []string{"EUR_USD", "GBP_USD"} {
+		wg.Add(1)
+		go func(i int, inst string) {
+			defer wg.Done()
+			_, errs[i] = s.Submit(context.Background(), risk.Order{Instrument: inst, Units: 1000, Source: "rest"})
+		}(i, inst)
+	}
+	wg.Wait()
+
+	var re *risk.RejectionError
+	passed, rejected := 0, 0
+	for _, err := range errs {
+		switch {
+		case err == nil:
+			passed++
+		case errors.As(err, &re):
+			rejected++
+		default:
+			t.Fatalf("unexpected error: %v", err)
+		}
+	}
+	if passed != 1 || rejected != 1 || b.placed != 1 {
+		t.Errorf("passed %d, rejected %d, placed %d; want one of each and one placement", passed, rejected, b.placed)
+	}
+}
+
+func TestSubmitClaimed(t *testing.T) {
+	tests := []struct {
+		name       string
+		units      float64
+		claim      bool
+		wantClaim  bool
+		wantPlaced int
+		wantErr    bool
+	}{
+		{"claimed order is placed", 1000, true, true, 1, false},
+		{"order claimed elsewhere is dropped", 1000, false, true, 0, false},
+		{"rejected order is never claimed", 5000, true, false, 0, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			b := newStubBroker()
+			s := newTestService(b, risk.Limits{MaxUnits: 1000})
+			claimed := false
+			res, err := s.SubmitClaimed(context.Background(), risk.Order{Instrument: "EUR_USD", Units: tt.units, Source: "queue:api"}, func() bool {
+				claimed = true
+				return tt.claim
+			})
+			if (err != nil) != tt.wantErr || claimed != tt.wantClaim || b.placed != tt.wantPlaced {
+				t.Fatalf("err %v, claimed %t, placed %d", err, claimed, b.placed)
+			}
+			if (res != nil) != (tt.wantPlaced > 0) {
+				t.Errorf("result = %+v", res)
+			}
+		})
+	}
+}
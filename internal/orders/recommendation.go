@@ -0,0 +1,216 @@
+package orders
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"strings"
+	"time"
+
+	"github.com/jedi116/go-trader/internal/risk"
+	"github.com/jedi116/go-trader/pkg/models"
+)
+
+// ErrNotFound is returned for a recommendation id found in neither table.
+var ErrNotFound = errors.New("recommendation not found")
+
+// ErrNotPending is returned for a recommendation that was already executed or rejected, or whose
+// AI recommendation has passed its time_to_live.
+var ErrNotPending = errors.New("recommendation is no longer pending")
+
+// Recommendation is a pending recommendation from either the recommendations or the
+// ai_recommendations table, with the brackets and trade source its order gets.
+type Recommendation struct {
+	ID         string    `json:"id"`
+	Instrument string    `json:"instrument"`
+	Direction  string    `json:"direction"`
+	Units      float64   `json:"units"`
+	Rationale  string    `json:"rationale"`
+	CreatedAt  time.Time `json:"created_at"`
+	StopLoss   *float64  `json:"stop_loss,omitempty"`
+	TakeProfit *float64  `json:"take_profit,omitempty"`
+	Source     string    `json:"source"`
+	// AI is set for rows of ai_recommendations.
+	AI bool `json:"ai"`
+	// AIID is the ai_recommendations row a mirrored recommendation was copied from.
+	AIID string `json:"ai_recommendation_id,omitempty"`
+	// ExpiresAt is the time_to_live of the AI recommendation, if any.
+	ExpiresAt *time.Time `json:"expires_at,omitempty"`
+}
+
+// SignedUnits is negative for SELL recommendations.
+func (r *Recommendation) SignedUnits() float64 {
+	if strings.ToUpper(r.Direction) == "SELL" {
+		return -r.Units
+	}
+	return r.Units
+}
+
+// FindRecommendation looks id up in the recommendations table, then in ai_recommendations. A
+// recommendation that is not pending, or whose AI recommendation has expired, is ErrNotPending;
+// a deleted one is ErrNotFound.
+func (s *Service) FindRecommendation(ctx context.Context, id string) (*Recommendation, error) {
+	recs, err := s.store.ListRecommendations(ctx)
+	if err != nil {
+		return nil, err
+	}
+	ai, err := s.store.ListAIRecommendations(ctx, 200)
+	if err != nil {
+		return nil, err
+	}
+	now := time.Now()
+	for _, item := range recs {
+		if item.ID != id {
+			continue
+		}
+		if item.Status != models.RecommendationStatusPending {
+			return nil, ErrNotPending
+		}
+		r := &Recommendation{ID: item.ID, Instrument: item.Instrument, Direction: item.Direction, Units: item.Units, CreatedAt: item.CreatedAt}
+		if item.Rationale != nil {
+			r.Rationale = *item.Rationale
+		}
+		r.StopLoss, r.TakeProfit = bracketsFromConditions(item.MarketConditions)
+		r.Source, r.AIID = sourceFromConditions(item.MarketConditions)
+		if r.AIID != "" {
+			for _, a := range ai {
+				if a.ID == r.AIID {
+					if !aiPending(a, now) {
+						return nil, ErrNotPending
+					}
+					r.ExpiresAt = &a.TimeToLive
+				}
+			}
+		}
+		return r, nil
+	}
+	for _, item := range ai {
+		if item.ID != id {
+			continue
+		}
+		if !aiPending(item, now) {
+			return nil, ErrNotPending
+		}
+		return &Recommendation{ID: item.ID, Instrument: item.Instrument, Direction: item.Direction, Units: item.Units,
+			Rationale: item.Rationale, CreatedAt: item.CreatedAt, StopLoss: item.StopLoss, TakeProfit: item.TakeProfit,
+			Source: models.TradeSourceAI, AI: true, ExpiresAt: &item.TimeToLive}, nil
+	}
+	return nil, ErrNotFound
+}
+
+// aiPending reports whether an AI recommendation can still be accepted at now.
+func aiPending(r models.AIRecommendation, now time.Time) bool {
+	switch r.Status {
+	case models.AIRecommendationStatusPending, models.AIRecommendationStatusApproved:
+		return r.TimeToLive.After(now)
+	}
+	return false
+}
+
+// AcceptRecommendation places the recommendation's order, with its brackets, through the same
+// checks as any other order, records the trade under the recommendation's source and marks the
+// recommendation executed. orderSource names the entry point for the risk audit.
+//
+// The recommendation, and the AI recommendation a mirrored one was copied from, are claimed with
+// a status-guarded update once the order passes its checks, so of two concurrent accepts only one
+// places the order; the other, like an accept after the time_to_live, gets ErrNotPending. A
+// claim is released when placement fails.
+func (s *Service) AcceptRecommendation(ctx context.Context, id, orderSource string) (*Recommendation, *Result, error) {
+	rec, err := s.FindRecommendation(ctx, id)
+	if err != nil {
+		return nil, nil, err
+	}
+	claimed := false
+	claim := func() bool {
+		claimed = s.claimRecommendation(ctx, rec)
+		return claimed
+	}
+	o := risk.Order{Instrument: rec.Instrument, Units: rec.SignedUnits(), StopLoss: rec.StopLoss, TakeProfit: rec.TakeProfit, Source: orderSource}
+	s.mu.Lock()
+	res, err := s.submitLocked(ctx, o, rec.Source, claim)
+	s.mu.Unlock()
+	if err != nil {
+		if claimed {
+			s.releaseRecommendation(ctx, rec)
+		}
+		return rec, nil, err
+	}
+	if res == nil {
+		return rec, nil, ErrNotPending
+	}
+	orderID := res.Order.OrderCreateTransaction.ID
+	if rec.AI {
+		_ = s.store.MarkAIRecommendationExecuted(ctx, id, orderID)
+	} else {
+		_ = s.store.MarkRecommendationExecuted(ctx, id, orderID)
+		if rec.AIID != "" {
+			_ = s.store.MarkAIRecommendationExecuted(ctx, rec.AIID, orderID)
+		}
+	}
+	return rec, res, nil
+}
+
+// claimRecommendation sets the recommendation, and the AI recommendation it mirrors, EXECUTED if
+// they are still pending. It reports false, leaving both as they were, when either is not.
+func (s *Service) claimRecommendation(ctx context.Context, rec *Recommendation) bool {
+	now := time.Now()
+	if rec.AI {
+		ok, err := s.store.ClaimAIRecommendation(ctx, rec.ID, now)
+		return err == nil && ok
+	}
+	ok, err := s.store.TransitionRecommendation(ctx, rec.ID, models.RecommendationStatusPending, models.RecommendationStatusExecuted)
+	if err != nil || !ok {
+		return false
+	}
+	if rec.AIID != "" {
+		if ok, err := s.store.ClaimAIRecommendation(ctx, rec.AIID, now); err != nil || !ok {
+			_, _ = s.store.TransitionRecommendation(ctx, rec.ID, models.RecommendationStatusExecuted, models.RecommendationStatusPending)
+			return false
+		}
+	}
+	return true
+}
+
+// releaseRecommendation returns a claimed recommendation to PENDING after its order failed.
+func (s *Service) releaseRecommendation(ctx context.Context, rec *Recommendation) {
+	if rec.AI {
+		_ = s.store.UpdateAIRecommendationStatus(ctx, rec.ID, models.AIRecommendationStatusPending)
+		return
+	}
+	_, _ = s.store.TransitionRecommendation(ctx, rec.ID, models.RecommendationStatusExecuted, models.RecommendationStatusPending)
+	if rec.AIID != "" {
+		_ = s.store.UpdateAIRecommendationStatus(ctx, rec.AIID, models.AIRecommendationStatusPending)
+	}
+}
+
+// bracketsFromConditions reads stop_loss and take_profit from a recommendation's
+// market_conditions, where signal-generated recommendations keep them.
+func bracketsFromConditions(raw []byte) (*float64, *float64) {
+	var mc struct {
+		StopLoss   *float64 `json:"stop_loss"`
+		TakeProfit *float64 `json:"take_profit"`
+	}
+	if len(raw) == 0 || json.Unmarshal(raw, &mc) != nil {
+		return nil, nil
+	}
+	return mc.StopLoss, mc.TakeProfit
+}
+
+// sourceFromConditions is the trade source of a recommendation created by a signal, a strategy
+// or mirrored from an AI recommendation, which note themselves in market_conditions, and the AI
+// recommendation it mirrors, if any; anything else counts as manual.
+func sourceFromConditions(raw []byte) (string, string) {
+	var mc struct {
+		Source string `json:"source"`
+		AIID   string `json:"ai_recommendation_id"`
+	}
+	if len(raw) > 0 && json.Unmarshal(raw, &mc) == nil {
+		switch mc.Source {
+		case models.TradeSourceSignal, models.TradeSourceStrategy:
+			return mc.Source, ""
+		case models.TradeSourceAI:
+			return mc.Source, mc.AIID
+		}
+	}
+	return models.TradeSourceManual, ""
+}
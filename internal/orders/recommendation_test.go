@@ -0,0 +1,211 @@
+package orders
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/jedi116/go-trader/internal/database"
+	"github.com/jedi116/go-trader/internal/risk"
+	"github.com/jedi116/go-trader/pkg/models"
+)
+
+func TestConditions(t *testing.T) {
+	tests := []struct {
+		name     string
+		raw      string
+		source   string
+		aiID     string
+		sl, tp   float64
+		brackets bool
+	}{
+		{"none", ``, models.TradeSourceManual, "", 0, 0, false},
+		{"market data only", `{"bid":1.1,"ask":1.2}`, models.TradeSourceManual, "", 0, 0, false},
+		{"signal", `{"source":"signal","signal_id":"s1","stop_loss":1.09,"take_profit":1.12}`, models.TradeSourceSignal, "", 1.09, 1.12, true},
+		{"strategy", `{"source":"strategy","stop_loss":1.09,"take_profit":1.12}`, models.TradeSourceStrategy, "", 1.09, 1.12, true},
+		{"mirrored ai", `{"bid":1.1,"source":"ai","ai_recommendation_id":"a1","stop_loss":1.09,"take_profit":1.12}`, models.TradeSourceAI, "a1", 1.09, 1.12, true},
+		{"unknown source", `{"source":"grpc"}`, models.TradeSourceManual, "", 0, 0, false},
+		{"not json", `nope`, models.TradeSourceManual, "", 0, 0, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			source, aiID := sourceFromConditions([]byte(tt.raw))
+			if source != tt.source || aiID != tt.aiID {
+				t.Errorf("source = %q, %q; want %q, %q", source, aiID, tt.source, tt.aiID)
+			}
+			sl, tp := bracketsFromConditions([]byte(tt.raw))
+			if (sl != nil) != tt.brackets || (tp != nil) != tt.brackets {
+				t.Fatalf("brackets = %v, %v", sl, tp)
+			}
+			if tt.brackets && (*sl != tt.sl || *tp != tt.tp) {
+				t.Errorf("brackets = %v, %v; want %v, %v", *sl, *tp, tt.sl, tt.tp)
+			}
+		})
+	}
+}
+
+func TestTradeSource(t *testing.T) {
+	tests := map[string]string{
+		"rest":                models.TradeSourceManual,
+		"grpc":                models.TradeSourceManual,
+		"signal":              models.TradeSourceSignal,
+		"signal:breakout":     models.TradeSourceSignal,
+		"queue:signal":        models.TradeSourceSignal,
+		"strategy:eurusd-ema": models.TradeSourceStrategy,
+		"queue:strategy:x":    models.TradeSourceStrategy,
+		"recommendation":      models.TradeSourceManual,
+		"grpc_recommendation": models.TradeSourceManual,
+	}
+	for in, want := range tests {
+		if got := TradeSource(in); got != want {
+			t.Errorf("TradeSource(%q) = %q, want %q", in, got, want)
+		}
+	}
+}
+
+// seedRecommendation stores a recommendation of the given kind and returns the id to accept and
+// the AI recommendation behind it, if any.
+func seedRecommendation(t *testing.T, store database.Store, kind string, units float64, status models.AIRecommendationStatus, ttl time.Duration) (string, string) {
+	t.Helper()
+	ctx := context.Background()
+	var aiID string
+	if kind == "ai" || kind == "mirrored" {
+		var err error
+		aiID, err = store.CreateAIRecommendation(ctx, &models.AIRecommendation{Instrument: "EUR_USD", Direction: "BUY", Units: units,
+			TimeToLive: time.Now().Add(ttl), Status: status})
+		if err != nil {
+			t.Fatal(err)
+		}
+		if kind == "ai" {
+			return aiID, aiID
+		}
+	}
+	recStatus := models.RecommendationStatusPending
+	if kind == "manual" && status == models.AIRecommendationStatusExecuted {
+		recStatus = models.RecommendationStatusExecuted
+	}
+	id, err := store.CreateRecommendation(ctx, &models.Recommendation{Instrument: "EUR_USD", Direction: "BUY", Units: units,
+		MarketConditions: aiConditions(aiID), Status: recStatus})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if kind == "deleted" {
+		_ = store.SoftDeleteRecommendation(ctx, id)
+	}
+	return id, aiID
+}
+
+func aiConditions(aiID string) []byte {
+	if aiID == "" {
+		return nil
+	}
+	return []byte(`{"source":"ai","ai_recommendation_id":"` + aiID + `"}`)
+}
+
+// status reads back the recommendation and AI recommendation statuses.
+func status(t *testing.T, store database.Store, id, aiID string) (models.RecommendationStatus, models.AIRecommendationStatus) {
+	t.Helper()
+	ctx := context.Background()
+	recs, _ := store.ListRecommendations(ctx)
+	ai, _ := store.ListAIRecommendations(ctx, 200)
+	var rs models.RecommendationStatus
+	var as models.AIRecommendationStatus
+	for _, r := range recs {
+		if r.ID == id {
+			rs = r.Status
+		}
+	}
+	for _, r := range ai {
+		if r.ID == aiID {
+			as = r.Status
+		}
+	}
+	return rs, as
+}
+
+func TestAcceptRecommendation(t *testing.T) {
+	const (
+		pending  = models.AIRecommendationStatusPending
+		executed = models.AIRecommendationStatusExecuted
+		rejected = models.AIRecommendationStatusRejected
+	)
+	tests := []struct {
+		name       string
+		kind       string
+		units      float64
+		status     models.AIRecommendationStatus
+		ttl        time.Duration
+		placeErr   error
+		wantErr    error
+		wantPlaced int
+		wantRec    models.RecommendationStatus
+		wantAI     models.AIRecommendationStatus
+	}{
+		{"manual", "manual", 1000, pending, 0, nil, nil, 1, models.RecommendationStatusExecuted, ""},
+		{"manual already executed", "manual", 1000, executed, 0, nil, ErrNotPending, 0, models.RecommendationStatusExecuted, ""},
+		{"deleted", "deleted", 1000, pending, 0, nil, ErrNotFound, 0, "", ""},
+		{"ai", "ai", 1000, pending, time.Hour, nil, nil, 1, "", executed},
+		{"ai past its time to live", "ai", 1000, pending, -time.Minute, nil, ErrNotPending, 0, "", pending},
+		{"ai rejected", "ai", 1000, rejected, time.Hour, nil, ErrNotPending, 0, "", rejected},
+		{"mirrored", "mirrored", 1000, pending, time.Hour, nil, nil, 1, models.RecommendationStatusExecuted, executed},
+		{"mirrored ai expired", "mirrored", 1000, pending, -time.Minute, nil, ErrNotPending, 0, models.RecommendationStatusPending, pending},
+		{"mirrored ai already executed", "mirrored", 1000, executed, time.Hour, nil, ErrNotPending, 0, models.RecommendationStatusPending, executed},
+		{"risk rejection leaves it pending", "mirrored", 5000, pending, time.Hour, nil, nil, 0, models.RecommendationStatusPending, pending},
+		{"failed placement releases the claim", "mirrored", 1000, pending, time.Hour, errors.New("broker down"), nil, 0, models.RecommendationStatusPending, pending},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			b := newStubBroker()
+			b.err = tt.placeErr
+			s := newTestService(b, risk.Limits{MaxUnits: 1000})
+			id, aiID := seedRecommendation(t, s.store, tt.kind, tt.units, tt.status, tt.ttl)
+			_, res, err := s.AcceptRecommendation(context.Background(), id, "recommendation")
+			switch {
+			case tt.wantErr != nil && !errors.Is(err, tt.wantErr):
+				t.Fatalf("err = %v, want %v", err, tt.wantErr)
+			case tt.wantErr == nil && (err == nil) != (tt.wantPlaced > 0):
+				t.Fatalf("err = %v, placed %d", err, tt.wantPlaced)
+			}
+			if b.placed != tt.wantPlaced || (res != nil) != (tt.wantPlaced > 0) {
+				t.Errorf("placed %d, result %+v; want %d", b.placed, res, tt.wantPlaced)
+			}
+			if rs, as := status(t, s.store, id, aiID); rs != tt.wantRec || as != tt.wantAI {
+				t.Errorf("statuses %q, %q; want %q, %q", rs, as, tt.wantRec, tt.wantAI)
+			}
+		})
+	}
+}
+
+// Of two concurrent accepts of the same recommendation only one places its order.
+func TestAcceptRecommendationOnce(t *testing.T) {
+	b := newStubBroker()
+	s := newTestService(b, risk.Limits{})
+	id, _ := seedRecommendation(t, s.store, "mirrored", 1000, models.AIRecommendationStatusPending, time.Hour)
+
+	var wg sync.WaitGroup
+	errs := make([]error, 2)
+	for i := range errs {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			_, _, errs[i] = s.AcceptRecommendation(context.Background(), id, "recommendation")
+		}(i)
+	}
+	wg.Wait()
+	accepted, refused := 0, 0
+	for _, err := range errs {
+		switch {
+		case err == nil:
+			accepted++
+		case errors.Is(err, ErrNotPending):
+			refused++
+		default:
+			t.Fatalf("unexpected error: %v", err)
+		}
+	}
+	if accepted != 1 || refused != 1 || b.placed != 1 {
+		t.Errorf("accepted %d, refused %d, placed %d; want one of each and one placement", accepted, refused, b.placed)
+	}
+}
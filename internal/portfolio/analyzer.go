@@ -0,0 +1,355 @@
+package portfolio
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"math"
+	"sort"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/jedi116/go-trader/internal/broker"
+	"github.com/jedi116/go-trader/pkg/models"
+)
+
+// Broker is the account and market data the analyzer reads.
+type Broker interface {
+	GetAccount() (*broker.Account, error)
+	GetPositions() ([]broker.Position, error)
+	GetPrices(instruments []string) ([]broker.Price, error)
+	GetInstruments() ([]broker.Instrument, error)
+	GetCandles(instrument, granularity string, count int, from, to *time.Time) (*broker.CandlesResponse, error)
+}
+
+// History supplies stored candles, newest first, and keeps the ones fetched to fill it;
+// database.Store implements it.
+type History interface {
+	ListMarketData(ctx context.Context, instrument string, timeframe string, limit int) ([]models.MarketData, error)
+	UpsertMarketData(ctx context.Context, rows []models.MarketData) error
+}
+
+type Options struct {
+	// Timeframe and Lookback select the market_data candles used for returns.
+	Timeframe string
+	Lookback  int
+	// Confidence is the VaR confidence level, e.g. 0.95.
+	Confidence float64
+	// Horizon is the VaR horizon in bars of Timeframe.
+	Horizon int
+	// MinSamples is the fewest aligned returns needed before a correlation or VaR is reported.
+	MinSamples int
+	// MaxStaleBars is how many bars the newest stored candle may lag before the history is
+	// fetched from the broker again.
+	MaxStaleBars int
+}
+
+func (o Options) withDefaults() Options {
+	if o.Timeframe == "" {
+		o.Timeframe = "H1"
+	}
+	if o.Lookback <= 0 {
+		o.Lookback = 250
+	}
+	if o.Confidence <= 0 || o.Confidence >= 1 {
+		o.Confidence = 0.95
+	}
+	if o.Horizon <= 0 {
+		o.Horizon = 1
+	}
+	if o.MinSamples <= 0 {
+		o.MinSamples = 30
+	}
+	if o.MaxStaleBars <= 0 {
+		o.MaxStaleBars = 3
+	}
+	return o
+}
+
+// PairCorrelation is the return correlation of two held instruments.
+type PairCorrelation struct {
+	A           string  `json:"a"`
+	B           string  `json:"b"`
+	Correlation float64 `json:"correlation"`
+	Samples     int     `json:"samples"`
+}
+
+// VaR is the parametric value at risk of the open positions, in account currency.
+type VaR struct {
+	Confidence float64 `json:"confidence"`
+	Horizon    int     `json:"horizon_bars"`
+	Timeframe  string  `json:"timeframe"`
+	Samples    int     `json:"samples"`
+	Value      float64 `json:"value"`
+	PctOfNAV   float64 `json:"pct_of_nav"`
+}
+
+type Report struct {
+	GeneratedAt     time.Time          `json:"generated_at"`
+	AccountCurrency string             `json:"account_currency"`
+	NAV             float64            `json:"nav"`
+	GrossExposure   float64            `json:"gross_exposure"`
+	Leverage        float64            `json:"leverage"`
+	Positions       []PositionExposure `json:"positions"`
+	Currencies      []CurrencyExposure `json:"currencies"`
+	Correlations    []PairCorrelation  `json:"correlations"`
+	VaR             *VaR               `json:"var,omitempty"`
+	// Unpriced lists instruments or currencies that could not be converted to account currency.
+	Unpriced []string `json:"unpriced,omitempty"`
+	// Warnings explains anything left out, e.g. too little history for VaR.
+	Warnings []string `json:"warnings,omitempty"`
+}
+
+// Analyzer builds portfolio reports from live positions and stored candles.
+type Analyzer struct {
+	broker  Broker
+	history History
+	opts    Options
+
+	mu            sync.Mutex
+	instruments   map[string]broker.Instrument
+	instrumentsAt time.Time
+}
+
+// NewAnalyzer builds an analyzer; with a nil history only exposures are reported.
+func NewAnalyzer(b Broker, history History, opts Options) *Analyzer {
+	return &Analyzer{broker: b, history: history, opts: opts.withDefaults()}
+}
+
+// Options returns the effective options after defaults.
+func (a *Analyzer) Options() Options { return a.opts }
+
+func (a *Analyzer) tradeable() (map[string]broker.Instrument, error) {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	if a.instruments != nil && time.Since(a.instrumentsAt) < time.Hour {
+		return a.instruments, nil
+	}
+	list, err := a.broker.GetInstruments()
+	if err != nil {
+		return nil, err
+	}
+	m := make(map[string]broker.Instrument, len(list))
+	for _, in := range list {
+		m[in.Name] = in
+	}
+	a.instruments = m
+	a.instrumentsAt = time.Now()
+	return m, nil
+}
+
+// Analyze values the open positions, decomposes them by currency and, when enough history is
+// stored, adds pairwise correlations and parametric VaR.
+func (a *Analyzer) Analyze(ctx context.Context) (*Report, error) {
+	account, err := a.broker.GetAccount()
+	if err != nil {
+		return nil, fmt.Errorf("portfolio: load account: %w", err)
+	}
+	positions, err := a.broker.GetPositions()
+	if err != nil {
+		return nil, fmt.Errorf("portfolio: load positions: %w", err)
+	}
+	tradeable, err := a.tradeable()
+	if err != nil {
+		return nil, fmt.Errorf("portfolio: load instruments: %w", err)
+	}
+	wanted := make(map[string]bool)
+	var currencies []string
+	for _, p := range positions {
+		if NetUnits(p) == 0 {
+			continue
+		}
+		wanted[p.Instrument] = true
+		if b, q, ok := models.SplitInstrument(p.Instrument); ok {
+			currencies = append(currencies, b, q)
+		}
+	}
+	for _, inst := range ConversionInstruments(currencies, account.Currency, tradeable) {
+		wanted[inst] = true
+	}
+	rates := NewRates(nil)
+	if len(wanted) > 0 {
+		names := make([]string, 0, len(wanted))
+		for n := range wanted {
+			names = append(names, n)
+		}
+		prices, err := a.broker.GetPrices(names)
+		if err != nil {
+			return nil, fmt.Errorf("portfolio: load prices: %w", err)
+		}
+		rates = NewRates(prices)
+	}
+
+	held, byCurrency, unpriced := Decompose(positions, rates, account.Currency)
+	r := &Report{
+		GeneratedAt:     time.Now().UTC(),
+		AccountCurrency: account.Currency,
+		NAV:             account.NAV,
+		Positions:       held,
+		Currencies:      byCurrency,
+		Unpriced:        unpriced,
+	}
+	for _, p := range held {
+		r.GrossExposure += math.Abs(p.Notional)
+	}
+	if account.NAV > 0 {
+		r.Leverage = r.GrossExposure / account.NAV
+	}
+	if a.history == nil || len(held) == 0 {
+		return r, nil
+	}
+
+	names := make([]string, 0, len(held))
+	for _, p := range held {
+		names = append(names, p.Instrument)
+	}
+	history, err := a.loadHistory(ctx, names)
+	if err != nil {
+		return nil, err
+	}
+	returns := AlignedReturns(history)
+	samples := len(returns[names[0]])
+	for i := 0; i < len(names); i++ {
+		for j := i + 1; j < len(names); j++ {
+			pair := AlignedReturns(map[string][]models.MarketData{names[i]: history[names[i]], names[j]: history[names[j]]})
+			pc := PairCorrelation{A: names[i], B: names[j], Samples: len(pair[names[i]])}
+			if pc.Samples >= a.opts.MinSamples {
+				pc.Correlation = Correlation(pair[names[i]], pair[names[j]])
+			}
+			r.Correlations = append(r.Correlations, pc)
+		}
+	}
+	sort.Slice(r.Correlations, func(i, j int) bool {
+		return math.Abs(r.Correlations[i].Correlation) > math.Abs(r.Correlations[j].Correlation)
+	})
+
+	if samples < a.opts.MinSamples {
+		r.Warnings = append(r.Warnings, fmt.Sprintf("VaR needs %d aligned %s returns across all positions, have %d", a.opts.MinSamples, a.opts.Timeframe, samples))
+		return r, nil
+	}
+	notionals := make([]float64, len(held))
+	cov := make([][]float64, len(held))
+	for i, p := range held {
+		notionals[i] = p.Notional
+		cov[i] = make([]float64, len(held))
+		for j, q := range held {
+			cov[i][j] = Covariance(returns[p.Instrument], returns[q.Instrument])
+		}
+	}
+	v := &VaR{
+		Confidence: a.opts.Confidence,
+		Horizon:    a.opts.Horizon,
+		Timeframe:  a.opts.Timeframe,
+		Samples:    samples,
+		Value:      ParametricVaR(notionals, cov, a.opts.Confidence, a.opts.Horizon),
+	}
+	if account.NAV > 0 {
+		v.PctOfNAV = v.Value / account.NAV
+	}
+	r.VaR = v
+	return r, nil
+}
+
+func (a *Analyzer) loadHistory(ctx context.Context, instruments []string) (map[string][]models.MarketData, error) {
+	history := make(map[string][]models.MarketData, len(instruments))
+	for _, inst := range instruments {
+		rows, err := a.history.ListMarketData(ctx, inst, a.opts.Timeframe, a.opts.Lookback+1)
+		if err != nil {
+			return nil, fmt.Errorf("portfolio: load history %s: %w", inst, err)
+		}
+		if len(rows) <= a.opts.Lookback || a.stale(rows[0].Timestamp) {
+			rows = a.backfill(ctx, inst, rows)
+		}
+		history[inst] = rows
+	}
+	return history, nil
+}
+
+// stale reports whether a newest candle at t lags more than MaxStaleBars bars behind now.
+func (a *Analyzer) stale(t time.Time) bool {
+	bar, ok := models.Granularity(a.opts.Timeframe)
+	if !ok {
+		return false
+	}
+	return time.Since(t) > time.Duration(a.opts.MaxStaleBars+1)*bar
+}
+
+// backfill replaces short or stale stored history with the broker's latest complete candles and
+// stores them. The broker's candles are current by definition, so over a weekend its last
+// Friday bar counts as fresh. If the fetch fails, stored history that is still fresh enough is
+// kept and stale history is dropped, so it cannot pass for a current correlation.
+func (a *Analyzer) backfill(ctx context.Context, instrument string, stored []models.MarketData) []models.MarketData {
+	resp, err := a.broker.GetCandles(instrument, a.opts.Timeframe, a.opts.Lookback+1, nil, nil)
+	if err != nil || resp == nil {
+		log.Printf("[PORTFOLIO] backfill %s %s: %v", instrument, a.opts.Timeframe, err)
+		if len(stored) > 0 && a.stale(stored[0].Timestamp) {
+			return nil
+		}
+		return stored
+	}
+	rows := candleRows(instrument, a.opts.Timeframe, resp.Candles)
+	if len(rows) == 0 {
+		return stored
+	}
+	if err := a.history.UpsertMarketData(ctx, rows); err != nil {
+		log.Printf("[PORTFOLIO] store backfill %s %s: %v", instrument, a.opts.Timeframe, err)
+	}
+	// Candles arrive oldest first; history is newest first.
+	for i, j := 0, len(rows)-1; i < j; i, j = i+1, j-1 {
+		rows[i], rows[j] = rows[j], rows[i]
+	}
+	return rows
+}
+
+// candleRows converts OANDA mid candles, skipping the incomplete current bar and unparsable ones.
+func candleRows(instrument, timeframe string, in []broker.Candle) []models.MarketData {
+	out := make([]models.MarketData, 0, len(in))
+	for _, c := range in {
+		if !c.Complete {
+			continue
+		}
+		o, err1 := strconv.ParseFloat(c.Mid.Open, 64)
+		hi, err2 := strconv.ParseFloat(c.Mid.High, 64)
+		lo, err3 := strconv.ParseFloat(c.Mid.Low, 64)
+		cl, err4 := strconv.ParseFloat(c.Mid.Close, 64)
+		if err1 != nil || err2 != nil || err3 != nil || err4 != nil {
+			continue
+		}
+		vol := int64(c.Volume)
+		out = append(out, models.MarketData{
+			Instrument: instrument,
+			Timestamp:  c.Time,
+			OpenPrice:  o,
+			HighPrice:  hi,
+			LowPrice:   lo,
+			ClosePrice: cl,
+			Volume:     &vol,
+			Timeframe:  timeframe,
+		})
+	}
+	return out
+}
+
+// ErrInsufficientHistory is returned by Correlation when too few candles are stored to judge it.
+var ErrInsufficientHistory = errors.New("portfolio: insufficient candle history")
+
+// Correlation returns the return correlation of two instruments and the number of aligned
+// samples. Without a history store, or with fewer than MinSamples, it returns
+// ErrInsufficientHistory.
+func (a *Analyzer) Correlation(ctx context.Context, x, y string) (float64, int, error) {
+	if a.history == nil {
+		return 0, 0, fmt.Errorf("%w: no candle store", ErrInsufficientHistory)
+	}
+	history, err := a.loadHistory(ctx, []string{x, y})
+	if err != nil {
+		return 0, 0, err
+	}
+	rets := AlignedReturns(history)
+	n := len(rets[x])
+	if n < a.opts.MinSamples {
+		return 0, n, fmt.Errorf("%w: %d of %d returns for %s/%s", ErrInsufficientHistory, n, a.opts.MinSamples, x, y)
+	}
+	return Correlation(rets[x], rets[y]), n, nil
+}
@@ -0,0 +1,110 @@
+package portfolio
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math"
+	"testing"
+	"time"
+
+	"github.com/jedi116/go-trader/internal/broker"
+	"github.com/jedi116/go-trader/pkg/models"
+)
+
+type stubBroker struct {
+	candles map[string][]broker.Candle
+	err     error
+	fetched []string
+}
+
+func (b *stubBroker) GetAccount() (*broker.Account, error)         { return &broker.Account{}, nil }
+func (b *stubBroker) GetPositions() ([]broker.Position, error)     { return nil, nil }
+func (b *stubBroker) GetPrices([]string) ([]broker.Price, error)   { return nil, nil }
+func (b *stubBroker) GetInstruments() ([]broker.Instrument, error) { return nil, nil }
+func (b *stubBroker) GetCandles(inst, tf string, count int, from, to *time.Time) (*broker.CandlesResponse, error) {
+	b.fetched = append(b.fetched, inst)
+	if b.err != nil {
+		return nil, b.err
+	}
+	cs := b.candles[inst]
+	if len(cs) > count {
+		cs = cs[len(cs)-count:]
+	}
+	return &broker.CandlesResponse{Instrument: inst, Granularity: tf, Candles: cs}, nil
+}
+
+type stubHistory struct {
+	rows     map[string][]models.MarketData
+	upserted int
+}
+
+func (h *stubHistory) ListMarketData(ctx context.Context, inst, tf string, limit int) ([]models.MarketData, error) {
+	rows := h.rows[inst]
+	if len(rows) > limit {
+		rows = rows[:limit]
+	}
+	return rows, nil
+}
+
+func (h *stubHistory) UpsertMarketData(ctx context.Context, rows []models.MarketData) error {
+	h.upserted += len(rows)
+	return nil
+}
+
+// series builds n M5 closes ending one bar before now, oldest first, from f.
+func series(n int, end time.Time, f func(i int) float64) ([]broker.Candle, []models.MarketData) {
+	var cs []broker.Candle
+	var rows []models.MarketData
+	for i := 0; i < n; i++ {
+		t := end.Add(-time.Duration(n-1-i) * 5 * time.Minute)
+		p := fmt.Sprintf("%.5f", f(i))
+		cs = append(cs, broker.Candle{Complete: true, Time: t, Mid: broker.OHLC{Open: p, High: p, Low: p, Close: p}})
+		rows = append([]models.MarketData{{Timestamp: t, ClosePrice: f(i), Timeframe: "M5"}}, rows...)
+	}
+	return cs, rows
+}
+
+func TestAnalyzerCorrelationHistory(t *testing.T) {
+	now := time.Now().Truncate(5 * time.Minute).Add(-5 * time.Minute)
+	wave := func(i int) float64 { return 1 + 0.01*math.Sin(float64(i)) }
+	freshCandles, freshRows := series(40, now, wave)
+	_, staleRows := series(40, now.Add(-time.Hour), wave)
+	opts := Options{Timeframe: "M5", Lookback: 39, MinSamples: 30}
+
+	tests := []struct {
+		name      string
+		stored    []models.MarketData
+		brokerErr error
+		wantFetch bool
+		wantErr   bool
+	}{
+		{"fresh stored history is used as is", freshRows, nil, false, false},
+		{"missing history is fetched", nil, nil, true, false},
+		{"stale history is refetched", staleRows, nil, true, false},
+		{"stale history is dropped when the fetch fails", staleRows, errors.New("down"), true, true},
+		{"short history is kept when the fetch fails", freshRows[:35], errors.New("down"), true, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			b := &stubBroker{candles: map[string][]broker.Candle{"EUR_USD": freshCandles, "GBP_USD": freshCandles}, err: tt.brokerErr}
+			h := &stubHistory{rows: map[string][]models.MarketData{"EUR_USD": tt.stored, "GBP_USD": freshRows}}
+			corr, n, err := NewAnalyzer(b, h, opts).Correlation(context.Background(), "EUR_USD", "GBP_USD")
+			if fetched := len(b.fetched) > 0; fetched != tt.wantFetch {
+				t.Errorf("fetched %v, want fetch %t", b.fetched, tt.wantFetch)
+			}
+			if tt.wantFetch && tt.brokerErr == nil && h.upserted == 0 {
+				t.Errorf("fetched candles were not stored")
+			}
+			if tt.wantErr {
+				if !errors.Is(err, ErrInsufficientHistory) {
+					t.Errorf("err = %v, want ErrInsufficientHistory", err)
+				}
+				return
+			}
+			if err != nil || n < opts.MinSamples || math.Abs(corr-1) > 1e-4 {
+				t.Errorf("Correlation = %v, %d, %v", corr, n, err)
+			}
+		})
+	}
+}
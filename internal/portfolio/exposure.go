@@ -0,0 +1,90 @@
+package portfolio
+
+import (
+	"math"
+	"sort"
+
+	"github.com/jedi116/go-trader/internal/broker"
+	"github.com/jedi116/go-trader/pkg/models"
+)
+
+// PositionExposure is one open position valued in account currency. Notional is signed: positive
+// for net long, negative for net short.
+type PositionExposure struct {
+	Instrument   string  `json:"instrument"`
+	Units        float64 `json:"units"`
+	Price        float64 `json:"price"`
+	Notional     float64 `json:"notional"`
+	UnrealizedPL float64 `json:"unrealized_pl"`
+}
+
+// CurrencyExposure is the net amount held in one currency across all positions.
+type CurrencyExposure struct {
+	Currency string  `json:"currency"`
+	Amount   float64 `json:"amount"`
+	// Value is Amount converted to account currency.
+	Value float64 `json:"value"`
+	// Share is |Value| over the sum of |Value| across currencies.
+	Share float64 `json:"share"`
+}
+
+// NetUnits is the signed net size of a position.
+func NetUnits(p broker.Position) float64 {
+	return p.Long.Units + p.Short.Units
+}
+
+// Decompose values each open position and splits it into its two currency legs: a long
+// EUR_USD position is long EUR and short USD at the current price. Positions whose currencies
+// cannot be converted into account are left out of the currency totals and listed in missing.
+func Decompose(positions []broker.Position, rates *Rates, account string) ([]PositionExposure, []CurrencyExposure, []string) {
+	var out []PositionExposure
+	var missing []string
+	amounts := make(map[string]float64)
+	for _, p := range positions {
+		units := NetUnits(p)
+		if units == 0 {
+			continue
+		}
+		base, quote, ok := models.SplitInstrument(p.Instrument)
+		if !ok {
+			missing = append(missing, p.Instrument)
+			continue
+		}
+		price, ok := rates.Mid(p.Instrument)
+		if !ok {
+			price = p.Long.AveragePrice + p.Short.AveragePrice
+		}
+		pe := PositionExposure{Instrument: p.Instrument, Units: units, Price: price, UnrealizedPL: p.UnrealizedPL}
+		r, ok := rates.Rate(base, account)
+		if !ok || price <= 0 {
+			missing = append(missing, p.Instrument)
+			out = append(out, pe)
+			continue
+		}
+		pe.Notional = units * r
+		out = append(out, pe)
+		amounts[base] += units
+		amounts[quote] -= units * price
+	}
+
+	var currencies []CurrencyExposure
+	total := 0.0
+	for cur, amt := range amounts {
+		r, ok := rates.Rate(cur, account)
+		if !ok {
+			missing = append(missing, cur)
+			continue
+		}
+		ce := CurrencyExposure{Currency: cur, Amount: amt, Value: amt * r}
+		total += math.Abs(ce.Value)
+		currencies = append(currencies, ce)
+	}
+	for i := range currencies {
+		if total > 0 {
+			currencies[i].Share = math.Abs(currencies[i].Value) / total
+		}
+	}
+	sort.Slice(currencies, func(i, j int) bool { return math.Abs(currencies[i].Value) > math.Abs(currencies[j].Value) })
+	sort.Slice(out, func(i, j int) bool { return math.Abs(out[i].Notional) > math.Abs(out[j].Notional) })
+	return out, currencies, missing
+}
@@ -0,0 +1,99 @@
+package portfolio
+
+import (
+	"strconv"
+
+	"github.com/jedi116/go-trader/internal/broker"
+)
+
+// Rates holds mid prices keyed by instrument and converts between currencies with them.
+type Rates struct {
+	mids map[string]float64
+}
+
+func NewRates(prices []broker.Price) *Rates {
+	q := &Rates{mids: make(map[string]float64, len(prices))}
+	for _, p := range prices {
+		if len(p.Bids) == 0 || len(p.Asks) == 0 {
+			continue
+		}
+		b := parseFloat(p.Bids[0].Price)
+		a := parseFloat(p.Asks[0].Price)
+		if b > 0 && a > 0 {
+			q.mids[p.Instrument] = (b + a) / 2
+		}
+	}
+	return q
+}
+
+// RatesFromMids builds rates from mid prices keyed by instrument, e.g. replayed candle closes.
+func RatesFromMids(mids map[string]float64) *Rates {
+	q := &Rates{mids: make(map[string]float64, len(mids))}
+	for inst, m := range mids {
+		q.mids[inst] = m
+	}
+	return q
+}
+
+// Mid returns the mid price of instrument, if quoted.
+func (q *Rates) Mid(instrument string) (float64, bool) {
+	m, ok := q.mids[instrument]
+	return m, ok && m > 0
+}
+
+// Rate returns how many units of to one unit of from is worth, directly or crossed via USD.
+func (q *Rates) Rate(from, to string) (float64, bool) {
+	if from == to {
+		return 1, true
+	}
+	if m, ok := q.Mid(from + "_" + to); ok {
+		return m, true
+	}
+	if m, ok := q.Mid(to + "_" + from); ok {
+		return 1 / m, true
+	}
+	if from != "USD" && to != "USD" {
+		a, okA := q.Rate(from, "USD")
+		b, okB := q.Rate("USD", to)
+		if okA && okB {
+			return a * b, true
+		}
+	}
+	return 0, false
+}
+
+// ConversionInstruments lists tradeable instruments needed to convert each currency into account.
+func ConversionInstruments(currencies []string, account string, tradeable map[string]broker.Instrument) []string {
+	var out []string
+	add := func(a, b string) bool {
+		if _, ok := tradeable[a+"_"+b]; ok {
+			out = append(out, a+"_"+b)
+			return true
+		}
+		if _, ok := tradeable[b+"_"+a]; ok {
+			out = append(out, b+"_"+a)
+			return true
+		}
+		return false
+	}
+	for _, c := range currencies {
+		if c == account || add(c, account) {
+			continue
+		}
+		if c != "USD" {
+			add(c, "USD")
+		}
+		if account != "USD" {
+			add("USD", account)
+		}
+	}
+	return out
+}
+
+func parseFloat(s string) float64 {
+	v, err := strconv.ParseFloat(s, 64)
+	if err != nil {
+		return 0
+	}
+	return v
+}
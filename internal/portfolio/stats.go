@@ -0,0 +1,123 @@
+package portfolio
+
+import (
+	"math"
+	"sort"
+	"time"
+
+	"github.com/jedi116/go-trader/pkg/models"
+)
+
+// AlignedReturns turns close histories into log returns over the timestamps every instrument
+// shares, so that return i of each instrument covers the same bar.
+func AlignedReturns(history map[string][]models.MarketData) map[string][]float64 {
+	if len(history) == 0 {
+		return nil
+	}
+	counts := make(map[time.Time]int)
+	closes := make(map[string]map[time.Time]float64, len(history))
+	for inst, rows := range history {
+		m := make(map[time.Time]float64, len(rows))
+		for _, r := range rows {
+			if r.ClosePrice > 0 {
+				m[r.Timestamp.UTC()] = r.ClosePrice
+			}
+		}
+		closes[inst] = m
+		for t := range m {
+			counts[t]++
+		}
+	}
+	var common []time.Time
+	for t, n := range counts {
+		if n == len(history) {
+			common = append(common, t)
+		}
+	}
+	sort.Slice(common, func(i, j int) bool { return common[i].Before(common[j]) })
+	out := make(map[string][]float64, len(history))
+	for inst, m := range closes {
+		rets := make([]float64, 0, len(common))
+		for i := 1; i < len(common); i++ {
+			rets = append(rets, math.Log(m[common[i]]/m[common[i-1]]))
+		}
+		out[inst] = rets
+	}
+	return out
+}
+
+func mean(xs []float64) float64 {
+	if len(xs) == 0 {
+		return 0
+	}
+	s := 0.0
+	for _, x := range xs {
+		s += x
+	}
+	return s / float64(len(xs))
+}
+
+// Covariance is the sample covariance of two equally long series.
+func Covariance(a, b []float64) float64 {
+	n := len(a)
+	if n != len(b) || n < 2 {
+		return 0
+	}
+	ma, mb := mean(a), mean(b)
+	s := 0.0
+	for i := range a {
+		s += (a[i] - ma) * (b[i] - mb)
+	}
+	return s / float64(n-1)
+}
+
+// Correlation is the Pearson correlation of two equally long series; 0 when either is flat.
+func Correlation(a, b []float64) float64 {
+	va, vb := Covariance(a, a), Covariance(b, b)
+	if va <= 0 || vb <= 0 {
+		return 0
+	}
+	return Covariance(a, b) / math.Sqrt(va*vb)
+}
+
+// ParametricVaR is the variance-covariance value at risk of positions with the given notionals
+// (account currency, signed) over horizon bars: z * sqrt(w' S w) * sqrt(horizon).
+func ParametricVaR(notionals []float64, cov [][]float64, confidence float64, horizon int) float64 {
+	if horizon < 1 {
+		horizon = 1
+	}
+	variance := 0.0
+	for i := range notionals {
+		for j := range notionals {
+			variance += notionals[i] * notionals[j] * cov[i][j]
+		}
+	}
+	if variance <= 0 {
+		return 0
+	}
+	return NormalQuantile(confidence) * math.Sqrt(variance) * math.Sqrt(float64(horizon))
+}
+
+// NormalQuantile is the inverse standard normal CDF (Acklam's rational approximation,
+// relative error below 1.2e-9).
+func NormalQuantile(p float64) float64 {
+	if p <= 0 || p >= 1 {
+		return math.NaN()
+	}
+	a := [...]float64{-3.969683028665376e+01, 2.209460984245205e+02, -2.759285104469687e+02, 1.383577518672690e+02, -3.066479806614716e+01, 2.506628277459239e+00}
+	b := [...]float64{-5.447609879822406e+01, 1.615858368580409e+02, -1.556989798598866e+02, 6.680131188771972e+01, -1.328068155288572e+01}
+	c := [...]float64{-7.784894002430293e-03, -3.223964580411365e-01, -2.400758277161838e+00, -2.549732539343734e+00, 4.374664141464968e+00, 2.938163982698783e+00}
+	d := [...]float64{7.784695709041462e-03, 3.224671290700398e-01, 2.445134137142996e+00, 3.754408661907416e+00}
+	const low = 0.02425
+	switch {
+	case p < low:
+		q := math.Sqrt(-2 * math.Log(p))
+		return (((((c[0]*q+c[1])*q+c[2])*q+c[3])*q+c[4])*q + c[5]) / ((((d[0]*q+d[1])*q+d[2])*q+d[3])*q + 1)
+	case p > 1-low:
+		q := math.Sqrt(-2 * math.Log(1-p))
+		return -(((((c[0]*q+c[1])*q+c[2])*q+c[3])*q+c[4])*q + c[5]) / ((((d[0]*q+d[1])*q+d[2])*q+d[3])*q + 1)
+	}
+	q := p - 0.5
+	r := q * q
+	return (((((a[0]*r+a[1])*r+a[2])*r+a[3])*r+a[4])*r + a[5]) * q / (((((b[0]*r+b[1])*r+b[2])*r+b[3])*r+b[4])*r + 1)
+}
@@ -0,0 +1,50 @@
+package risk
+
+import (
+	"strings"
+	"time"
+
+	"github.com/jedi116/go-trader/internal/config"
+)
+
+// LimitsFromConfig converts the risk config section. Viper lowercases map keys, so
+// instrument and currency keys are upper-cased back to OANDA form.
+func LimitsFromConfig(cfg config.RiskConfig) Limits {
+	l := Limits{
+		MaxUnits:              cfg.MaxUnits,
+		MaxUnitsByInstrument:  make(map[string]float64, len(cfg.MaxUnitsByInstrument)),
+		MaxNotional:           cfg.MaxNotional,
+		MaxOpenPositions:      cfg.MaxOpenPositions,
+		MarginBuffer:          cfg.MarginBuffer,
+		RequireStopLoss:       cfg.RequireStopLoss,
+		MaxRiskPerTrade:       cfg.MaxRiskPerTrade,
+		MaxCurrencyExposure:   cfg.MaxCurrencyExposure,
+		MaxExposureByCurrency: make(map[string]float64, len(cfg.MaxExposureByCurrency)),
+		MaxCorrelation:        cfg.MaxCorrelation,
+		CorrelationFailOpen:   cfg.CorrelationFailOpen,
+	}
+	for k, v := range cfg.MaxUnitsByInstrument {
+		l.MaxUnitsByInstrument[strings.ToUpper(k)] = v
+	}
+	for k, v := range cfg.MaxExposureByCurrency {
+		l.MaxExposureByCurrency[strings.ToUpper(k)] = v
+	}
+	return l
+}
+
+// GuardianOptionsFromConfig converts the risk.guardian config section; an unknown timezone is an error.
+func GuardianOptionsFromConfig(cfg config.GuardianConfig) (GuardianOptions, error) {
+	opts := GuardianOptions{
+		MaxDailyLoss:  cfg.MaxDailyLoss,
+		MaxDrawdown:   cfg.MaxDrawdown,
+		FlattenOnHalt: cfg.FlattenOnHalt,
+	}
+	if cfg.Timezone != "" {
+		loc, err := time.LoadLocation(cfg.Timezone)
+		if err != nil {
+			return opts, err
+		}
+		opts.Location = loc
+	}
+	return opts, nil
+}
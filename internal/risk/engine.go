@@ -0,0 +1,498 @@
+package risk
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"math"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/jedi116/go-trader/internal/broker"
+	"github.com/jedi116/go-trader/internal/notify"
+	"github.com/jedi116/go-trader/internal/portfolio"
+	"github.com/jedi116/go-trader/pkg/models"
+)
+
+// Broker is the account and market data the risk checks need.
+type Broker interface {
+	GetAccount() (*broker.Account, error)
+	GetPositions() ([]broker.Position, error)
+	GetPrices(instruments []string) ([]broker.Price, error)
+	GetInstruments() ([]broker.Instrument, error)
+}
+
+// Auditor records each evaluation; database.Store implements it.
+type Auditor interface {
+	LogAudit(ctx context.Context, entity string, entityID string, action string, details map[string]interface{}) error
+}
+
+// Limits configures the pre-trade checks. A zero value disables the corresponding check.
+type Limits struct {
+	MaxUnits             float64
+	MaxUnitsByInstrument map[string]float64
+	// MaxNotional is in account currency.
+	MaxNotional      float64
+	MaxOpenPositions int
+	// MarginBuffer is the fraction of available margin that must stay unused after the order.
+	MarginBuffer    float64
+	RequireStopLoss bool
+	// MaxRiskPerTrade is the loss to the stop as a fraction of NAV, e.g. 0.02 for 2%.
+	MaxRiskPerTrade float64
+	// MaxCurrencyExposure caps net exposure per currency, in account currency.
+	MaxCurrencyExposure   float64
+	MaxExposureByCurrency map[string]float64
+	// MaxCorrelation refuses adding to a position whose return correlation with an existing
+	// same-direction position is at or above this absolute value; needs a Correlator.
+	MaxCorrelation float64
+	// CorrelationFailOpen lets the order through when a correlation cannot be computed, e.g. too
+	// little stored history; by default the check fails.
+	CorrelationFailOpen bool
+}
+
+// Correlator reports return correlation between two instruments and how many samples it rests
+// on; *portfolio.Analyzer implements it.
+type Correlator interface {
+	Correlation(ctx context.Context, a, b string) (float64, int, error)
+}
+
+// Order is a proposed market order. Units are signed: positive buys, negative sells.
+type Order struct {
+	Instrument string   `json:"instrument"`
+	Units      float64  `json:"units"`
+	StopLoss   *float64 `json:"stop_loss,omitempty"`
+	TakeProfit *float64 `json:"take_profit,omitempty"`
+	// Source identifies the order path, e.g. "rest", "grpc" or "recommendation".
+	Source string `json:"source"`
+}
+
+// CheckResult is the outcome of one check.
+type CheckResult struct {
+	Name   string  `json:"name"`
+	Passed bool    `json:"passed"`
+	Value  float64 `json:"value"`
+	Limit  float64 `json:"limit"`
+	Reason string  `json:"reason,omitempty"`
+}
+
+// Decision is the outcome of all checks for an order.
+type Decision struct {
+	Approved bool          `json:"approved"`
+	Order    Order         `json:"order"`
+	Checks   []CheckResult `json:"checks"`
+}
+
+// Rejections returns the failed checks.
+func (d *Decision) Rejections() []CheckResult {
+	var out []CheckResult
+	for _, c := range d.Checks {
+		if !c.Passed {
+			out = append(out, c)
+		}
+	}
+	return out
+}
+
+// RejectionError is returned by Evaluate when at least one check fails.
+type RejectionError struct {
+	Decision *Decision
+}
+
+func (e *RejectionError) Error() string {
+	reasons := make([]string, 0)
+	for _, c := range e.Decision.Rejections() {
+		reasons = append(reasons, c.Reason)
+	}
+	return "order rejected by risk checks: " + strings.Join(reasons, "; ")
+}
+
+// Engine runs pre-trade checks against live account state.
+type Engine struct {
+	broker     Broker
+	auditor    Auditor
+	limits     Limits
+	correlator Correlator
+	notifier   notify.Notifier
+
+	mu            sync.Mutex
+	instruments   map[string]broker.Instrument
+	instrumentsAt time.Time
+}
+
+// NewEngine builds an engine; auditor may be nil.
+func NewEngine(b Broker, auditor Auditor, limits Limits) *Engine {
+	return &Engine{broker: b, auditor: auditor, limits: limits}
+}
+
+// WithCorrelator enables the correlated-stacking check.
+func (e *Engine) WithCorrelator(c Correlator) *Engine {
+	e.correlator = c
+	return e
+}
+
+// WithNotifier announces rejected orders.
+func (e *Engine) WithNotifier(n notify.Notifier) *Engine {
+	e.notifier = n
+	return e
+}
+
+// instrumentTTL bounds how long the tradeable-instrument list (margin rates) is reused.
+const instrumentTTL = time.Hour
+
+func (e *Engine) tradeable() (map[string]broker.Instrument, error) {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	if e.instruments != nil && time.Since(e.instrumentsAt) < instrumentTTL {
+		return e.instruments, nil
+	}
+	list, err := e.broker.GetInstruments()
+	if err != nil {
+		return nil, err
+	}
+	m := make(map[string]broker.Instrument, len(list))
+	for _, in := range list {
+		m[in.Name] = in
+	}
+	e.instruments = m
+	e.instrumentsAt = time.Now()
+	return m, nil
+}
+
+// Evaluate runs every check. It returns a *RejectionError alongside the decision when any check
+// fails, and a plain error when account or market state cannot be loaded.
+func (e *Engine) Evaluate(ctx context.Context, o Order) (*Decision, error) {
+	base, quote, ok := models.SplitInstrument(o.Instrument)
+	if !ok || o.Units == 0 {
+		d := &Decision{Order: o, Checks: []CheckResult{fail("order", o.Units, 0, "order needs an instrument like EUR_USD and non-zero units")}}
+		e.audit(ctx, d)
+		return d, &RejectionError{Decision: d}
+	}
+	account, err := e.broker.GetAccount()
+	if err != nil {
+		return nil, fmt.Errorf("risk: load account: %w", err)
+	}
+	positions, err := e.broker.GetPositions()
+	if err != nil {
+		return nil, fmt.Errorf("risk: load positions: %w", err)
+	}
+	tradeable, err := e.tradeable()
+	if err != nil {
+		return nil, fmt.Errorf("risk: load instruments: %w", err)
+	}
+
+	currencies := []string{base, quote}
+	wanted := map[string]bool{o.Instrument: true}
+	for _, p := range positions {
+		wanted[p.Instrument] = true
+		if b, q, ok := models.SplitInstrument(p.Instrument); ok {
+			currencies = append(currencies, b, q)
+		}
+	}
+	for _, inst := range portfolio.ConversionInstruments(currencies, account.Currency, tradeable) {
+		wanted[inst] = true
+	}
+	names := make([]string, 0, len(wanted))
+	for n := range wanted {
+		names = append(names, n)
+	}
+	prices, err := e.broker.GetPrices(names)
+	if err != nil {
+		return nil, fmt.Errorf("risk: load prices: %w", err)
+	}
+	q := portfolio.NewRates(prices)
+	price, ok := q.Mid(o.Instrument)
+	if !ok {
+		return nil, fmt.Errorf("risk: no price for %s", o.Instrument)
+	}
+
+	s := &state{ctx: ctx, order: o, base: base, quote: quote, price: price, account: account, positions: positions, quotes: q, tradeable: tradeable}
+	d := &Decision{Approved: true, Order: o}
+	for _, check := range []func(*state) CheckResult{
+		e.checkUnits,
+		e.checkNotional,
+		e.checkOpenPositions,
+		e.checkMargin,
+		e.checkStopLoss,
+		e.checkRiskPerTrade,
+		e.checkCurrencyExposure,
+		e.checkCorrelation,
+	} {
+		r := check(s)
+		if !r.Passed {
+			d.Approved = false
+		}
+		d.Checks = append(d.Checks, r)
+	}
+	e.audit(ctx, d)
+	if !d.Approved {
+		return d, &RejectionError{Decision: d}
+	}
+	return d, nil
+}
+
+func (e *Engine) audit(ctx context.Context, d *Decision) {
+	if !d.Approved && e.notifier != nil {
+		reasons := make([]string, 0)
+		for _, c := range d.Rejections() {
+			reasons = append(reasons, c.Name+": "+c.Reason)
+		}
+		e.notifier.Notify(ctx, notify.Event{
+			Type:   notify.OrderRejected,
+			Title:  fmt.Sprintf("Order rejected: %s %.0f units", d.Order.Instrument, d.Order.Units),
+			Text:   strings.Join(reasons, "\n"),
+			Fields: map[string]interface{}{"instrument": d.Order.Instrument, "units": d.Order.Units, "source": d.Order.Source},
+		})
+	}
+	if e.auditor == nil {
+		return
+	}
+	action := "RISK_APPROVE"
+	if !d.Approved {
+		action = "RISK_REJECT"
+	}
+	details := map[string]interface{}{"order": d.Order, "checks": d.Checks}
+	if err := e.auditor.LogAudit(ctx, "risk_checks", "", action, details); err != nil {
+		log.Printf("[RISK] audit error: %v", err)
+	}
+}
+
+// state is the account snapshot shared by the checks of one evaluation.
+type state struct {
+	ctx       context.Context
+	order     Order
+	base      string
+	quote     string
+	price     float64
+	account   *broker.Account
+	positions []broker.Position
+	quotes    *portfolio.Rates
+	tradeable map[string]broker.Instrument
+}
+
+// notional is the order size in account currency.
+func (s *state) notional() (float64, bool) {
+	r, ok := s.quotes.Rate(s.base, s.account.Currency)
+	if !ok {
+		return 0, false
+	}
+	return math.Abs(s.order.Units) * r, true
+}
+
+func (s *state) netUnits(instrument string) float64 {
+	for _, p := range s.positions {
+		if p.Instrument == instrument {
+			return p.Long.Units + p.Short.Units
+		}
+	}
+	return 0
+}
+
+// reducesPosition reports whether the order only shrinks an existing opposite position.
+func (s *state) reducesPosition() bool {
+	net := s.netUnits(s.order.Instrument)
+	return net != 0 && (net > 0) != (s.order.Units > 0) && math.Abs(s.order.Units) <= math.Abs(net)
+}
+
+func pass(name string, value, limit float64) CheckResult {
+	return CheckResult{Name: name, Passed: true, Value: value, Limit: limit}
+}
+
+func fail(name string, value, limit float64, format string, args ...interface{}) CheckResult {
+	return CheckResult{Name: name, Passed: false, Value: value, Limit: limit, Reason: fmt.Sprintf(format, args...)}
+}
+
+func (e *Engine) checkUnits(s *state) CheckResult {
+	const name = "max_units"
+	limit := e.limits.MaxUnits
+	if v, ok := e.limits.MaxUnitsByInstrument[s.order.Instrument]; ok {
+		limit = v
+	}
+	units := math.Abs(s.order.Units)
+	if limit > 0 && units > limit {
+		return fail(name, units, limit, "units %.0f exceed limit %.0f for %s", units, limit, s.order.Instrument)
+	}
+	return pass(name, units, limit)
+}
+
+func (e *Engine) checkNotional(s *state) CheckResult {
+	const name = "max_notional"
+	limit := e.limits.MaxNotional
+	if limit <= 0 {
+		return pass(name, 0, 0)
+	}
+	n, ok := s.notional()
+	if !ok {
+		return fail(name, 0, limit, "cannot convert %s to %s", s.base, s.account.Currency)
+	}
+	if n > limit {
+		return fail(name, n, limit, "notional %.2f %s exceeds limit %.2f", n, s.account.Currency, limit)
+	}
+	return pass(name, n, limit)
+}
+
+func (e *Engine) checkOpenPositions(s *state) CheckResult {
+	const name = "max_open_positions"
+	limit := float64(e.limits.MaxOpenPositions)
+	open := 0
+	for _, p := range s.positions {
+		if p.Long.Units+p.Short.Units != 0 {
+			open++
+		}
+	}
+	if limit <= 0 || s.netUnits(s.order.Instrument) != 0 {
+		return pass(name, float64(open), limit)
+	}
+	if float64(open+1) > limit {
+		return fail(name, float64(open+1), limit, "opening %s would make %d open positions, limit %d", s.order.Instrument, open+1, e.limits.MaxOpenPositions)
+	}
+	return pass(name, float64(open+1), limit)
+}
+
+func (e *Engine) checkMargin(s *state) CheckResult {
+	const name = "margin_available"
+	available := s.account.MarginAvailable * (1 - e.limits.MarginBuffer)
+	if s.reducesPosition() {
+		return pass(name, 0, available)
+	}
+	n, ok := s.notional()
+	if !ok {
+		return fail(name, 0, available, "cannot convert %s to %s", s.base, s.account.Currency)
+	}
+	rate := 0.0
+	if in, found := s.tradeable[s.order.Instrument]; found {
+		rate = in.MarginRate
+	}
+	if rate <= 0 {
+		return fail(name, 0, available, "unknown margin rate for %s", s.order.Instrument)
+	}
+	required := n * rate
+	if required > available {
+		return fail(name, required, available, "required margin %.2f exceeds available %.2f", required, available)
+	}
+	return pass(name, required, available)
+}
+
+func (e *Engine) checkStopLoss(s *state) CheckResult {
+	const name = "stop_loss"
+	sl := s.order.StopLoss
+	if sl == nil || *sl <= 0 {
+		if e.limits.RequireStopLoss && !s.reducesPosition() {
+			return fail(name, 0, 0, "stop loss is required")
+		}
+		return pass(name, 0, 0)
+	}
+	if s.order.Units > 0 && *sl >= s.price {
+		return fail(name, *sl, s.price, "stop loss %.5f must be below price %.5f for a buy", *sl, s.price)
+	}
+	if s.order.Units < 0 && *sl <= s.price {
+		return fail(name, *sl, s.price, "stop loss %.5f must be above price %.5f for a sell", *sl, s.price)
+	}
+	return pass(name, *sl, s.price)
+}
+
+func (e *Engine) checkRiskPerTrade(s *state) CheckResult {
+	const name = "max_risk_per_trade"
+	limit := e.limits.MaxRiskPerTrade
+	if limit <= 0 || s.reducesPosition() {
+		return pass(name, 0, limit)
+	}
+	if s.order.StopLoss == nil || *s.order.StopLoss <= 0 {
+		return fail(name, 0, limit, "risk cannot be bounded without a stop loss")
+	}
+	if s.account.NAV <= 0 {
+		return fail(name, 0, limit, "account NAV is %.2f", s.account.NAV)
+	}
+	r, ok := s.quotes.Rate(s.quote, s.account.Currency)
+	if !ok {
+		return fail(name, 0, limit, "cannot convert %s to %s", s.quote, s.account.Currency)
+	}
+	loss := math.Abs(s.price-*s.order.StopLoss) * math.Abs(s.order.Units) * r
+	frac := loss / s.account.NAV
+	if frac > limit {
+		return fail(name, frac, limit, "risk %.2f%% of NAV exceeds limit %.2f%%", frac*100, limit*100)
+	}
+	return pass(name, frac, limit)
+}
+
+func (e *Engine) checkCurrencyExposure(s *state) CheckResult {
+	const name = "currency_exposure"
+	if e.limits.MaxCurrencyExposure <= 0 && len(e.limits.MaxExposureByCurrency) == 0 {
+		return pass(name, 0, 0)
+	}
+	before := make(map[string]float64)
+	for _, p := range s.positions {
+		b, q, ok := models.SplitInstrument(p.Instrument)
+		if !ok {
+			continue
+		}
+		net := p.Long.Units + p.Short.Units
+		mid, ok := s.quotes.Mid(p.Instrument)
+		if !ok {
+			mid = math.Abs(p.Long.AveragePrice + p.Short.AveragePrice)
+		}
+		before[b] += net
+		before[q] -= net * mid
+	}
+	after := map[string]float64{s.base: before[s.base] + s.order.Units, s.quote: before[s.quote] - s.order.Units*s.price}
+
+	worst := pass(name, 0, 0)
+	for _, cur := range []string{s.base, s.quote} {
+		limit := e.limits.MaxCurrencyExposure
+		if v, ok := e.limits.MaxExposureByCurrency[cur]; ok {
+			limit = v
+		}
+		if limit <= 0 || cur == s.account.Currency {
+			continue
+		}
+		r, ok := s.quotes.Rate(cur, s.account.Currency)
+		if !ok {
+			return fail(name, 0, limit, "cannot convert %s to %s", cur, s.account.Currency)
+		}
+		was := math.Abs(before[cur] * r)
+		now := math.Abs(after[cur] * r)
+		if now > limit && now > was {
+			return fail(name, now, limit, "net %s exposure %.2f %s exceeds limit %.2f", cur, now, s.account.Currency, limit)
+		}
+		if now > worst.Value {
+			worst = pass(name, now, limit)
+		}
+	}
+	return worst
+}
+
+func (e *Engine) checkCorrelation(s *state) CheckResult {
+	const name = "correlated_stacking"
+	limit := e.limits.MaxCorrelation
+	if limit <= 0 || e.correlator == nil || s.reducesPosition() {
+		return pass(name, 0, limit)
+	}
+	worst := pass(name, 0, limit)
+	for _, p := range s.positions {
+		net := portfolio.NetUnits(p)
+		if net == 0 || p.Instrument == s.order.Instrument {
+			continue
+		}
+		corr, _, err := e.correlator.Correlation(s.ctx, s.order.Instrument, p.Instrument)
+		if err != nil {
+			log.Printf("[RISK] correlation %s/%s unavailable (fail_open=%t): %v", s.order.Instrument, p.Instrument, e.limits.CorrelationFailOpen, err)
+			if e.limits.CorrelationFailOpen {
+				continue
+			}
+			return fail(name, 0, limit, "correlation of %s with open %s position unavailable: %v", s.order.Instrument, p.Instrument, err)
+		}
+		// Same effective direction: a long in a positively correlated pair, or a long against a
+		// short in a negatively correlated one, adds to the same bet.
+		stacking := (net > 0) == (s.order.Units > 0)
+		if corr < 0 {
+			stacking = !stacking
+		}
+		if stacking && math.Abs(corr) >= limit {
+			return fail(name, math.Abs(corr), limit, "%s is %.2f correlated with open %s position", s.order.Instrument, corr, p.Instrument)
+		}
+		if stacking && math.Abs(corr) > worst.Value {
+			worst = pass(name, math.Abs(corr), limit)
+		}
+	}
+	return worst
+}
@@ -0,0 +1,185 @@
+package risk
+
+import (
+	"context"
+	"errors"
+	"math"
+	"strconv"
+	"testing"
+
+	"github.com/jedi116/go-trader/internal/broker"
+)
+
+// stubBroker serves a fixed account, positions and mid prices, and records closed positions.
+type stubBroker struct {
+	account   broker.Account
+	positions []broker.Position
+	trades    []broker.Trade
+	mids      map[string]float64
+	closed    []string
+}
+
+func (b *stubBroker) GetAccount() (*broker.Account, error) {
+	a := b.account
+	return &a, nil
+}
+
+func (b *stubBroker) GetPositions() ([]broker.Position, error) { return b.positions, nil }
+
+func (b *stubBroker) GetTrades() ([]broker.Trade, error) { return b.trades, nil }
+
+func (b *stubBroker) ClosePosition(instrument string) error {
+	b.closed = append(b.closed, instrument)
+	return nil
+}
+
+func (b *stubBroker) GetPrices(instruments []string) ([]broker.Price, error) {
+	var out []broker.Price
+	for _, inst := range instruments {
+		if mid, ok := b.mids[inst]; ok {
+			q := []broker.Quote{{Price: strconv.FormatFloat(mid, 'f', -1, 64)}}
+			out = append(out, broker.Price{Instrument: inst, Bids: q, Asks: q})
+		}
+	}
+	return out, nil
+}
+
+func (b *stubBroker) GetInstruments() ([]broker.Instrument, error) {
+	var out []broker.Instrument
+	for inst := range b.mids {
+		out = append(out, broker.Instrument{Name: inst, MarginRate: 0.02})
+	}
+	return out, nil
+}
+
+func position(instrument string, units, price float64) broker.Position {
+	p := broker.Position{Instrument: instrument}
+	if units > 0 {
+		p.Long = broker.PosSide{Units: units, AveragePrice: price}
+	} else {
+		p.Short = broker.PosSide{Units: units, AveragePrice: price}
+	}
+	return p
+}
+
+func result(d *Decision, name string) CheckResult {
+	for _, c := range d.Checks {
+		if c.Name == name {
+			return c
+		}
+	}
+	return CheckResult{}
+}
+
+func float(v float64) *float64 { return &v }
+
+func TestEngineChecks(t *testing.T) {
+	mids := map[string]float64{"EUR_USD": 1.10, "GBP_USD": 1.25, "USD_JPY": 150}
+	tests := []struct {
+		name      string
+		limits    Limits
+		margin    float64
+		positions []broker.Position
+		order     Order
+		check     string
+		pass      bool
+		value     float64
+	}{
+		{"units over the instrument limit", Limits{MaxUnits: 100000, MaxUnitsByInstrument: map[string]float64{"EUR_USD": 1000}}, 1e6, nil,
+			Order{Instrument: "EUR_USD", Units: 2000}, "max_units", false, 2000},
+		{"notional in account currency", Limits{MaxNotional: 10000}, 1e6, nil,
+			Order{Instrument: "EUR_USD", Units: 10000}, "max_notional", false, 11000},
+		{"new position over the open limit", Limits{MaxOpenPositions: 1}, 1e6, []broker.Position{position("GBP_USD", 1000, 1.25)},
+			Order{Instrument: "EUR_USD", Units: 1000}, "max_open_positions", false, 2},
+		{"adding to an open position under the open limit", Limits{MaxOpenPositions: 1}, 1e6, []broker.Position{position("EUR_USD", 1000, 1.1)},
+			Order{Instrument: "EUR_USD", Units: 1000}, "max_open_positions", true, 1},
+		{"margin within the buffer", Limits{MarginBuffer: 0.5}, 1000, nil,
+			Order{Instrument: "EUR_USD", Units: 10000}, "margin_available", true, 220},
+		{"margin beyond the buffer", Limits{MarginBuffer: 0.5}, 1000, nil,
+			Order{Instrument: "EUR_USD", Units: 30000}, "margin_available", false, 660},
+		{"stop loss required", Limits{RequireStopLoss: true}, 1e6, nil,
+			Order{Instrument: "EUR_USD", Units: 1000}, "stop_loss", false, 0},
+		{"stop loss on the wrong side", Limits{}, 1e6, nil,
+			Order{Instrument: "EUR_USD", Units: 1000, StopLoss: float(1.2)}, "stop_loss", false, 1.2},
+		// A 1.00 JPY stop on 10000 units risks 10000 JPY, 66.67 USD at 150, of a 10000 NAV.
+		{"risk per trade converted from JPY", Limits{MaxRiskPerTrade: 0.01}, 1e6, nil,
+			Order{Instrument: "USD_JPY", Units: 10000, StopLoss: float(149)}, "max_risk_per_trade", true, 10000.0 / 150 / 10000},
+		{"risk per trade over the limit", Limits{MaxRiskPerTrade: 0.005}, 1e6, nil,
+			Order{Instrument: "USD_JPY", Units: 10000, StopLoss: float(149)}, "max_risk_per_trade", false, 10000.0 / 150 / 10000},
+		{"reducing skips the stop loss", Limits{RequireStopLoss: true}, 1e6, []broker.Position{position("EUR_USD", 10000, 1.1)},
+			Order{Instrument: "EUR_USD", Units: -5000}, "stop_loss", true, 0},
+		{"reducing skips risk per trade", Limits{MaxRiskPerTrade: 0.001}, 1e6, []broker.Position{position("EUR_USD", 10000, 1.1)},
+			Order{Instrument: "EUR_USD", Units: -5000}, "max_risk_per_trade", true, 0},
+		{"reducing skips margin", Limits{}, 0, []broker.Position{position("EUR_USD", 10000, 1.1)},
+			Order{Instrument: "EUR_USD", Units: -5000}, "margin_available", true, 0},
+		{"reversing is not reducing", Limits{}, 0, []broker.Position{position("EUR_USD", 10000, 1.1)},
+			Order{Instrument: "EUR_USD", Units: -15000}, "margin_available", false, 330},
+		{"exposure over the limit that grows", Limits{MaxCurrencyExposure: 50000}, 1e6, []broker.Position{position("EUR_USD", 100000, 1.1)},
+			Order{Instrument: "EUR_USD", Units: 1000}, "currency_exposure", false, 111100},
+		{"exposure over the limit that shrinks", Limits{MaxCurrencyExposure: 50000}, 1e6, []broker.Position{position("EUR_USD", 100000, 1.1)},
+			Order{Instrument: "EUR_USD", Units: -1000}, "currency_exposure", true, 108900},
+		{"exposure limit per currency", Limits{MaxExposureByCurrency: map[string]float64{"GBP": 1000}}, 1e6, nil,
+			Order{Instrument: "GBP_USD", Units: 1000}, "currency_exposure", false, 1250},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			b := &stubBroker{account: broker.Account{Currency: "USD", NAV: 10000, Balance: 10000, MarginAvailable: tt.margin}, positions: tt.positions, mids: mids}
+			d, err := NewEngine(b, nil, tt.limits).Evaluate(context.Background(), tt.order)
+			var re *RejectionError
+			if err != nil && !errors.As(err, &re) {
+				t.Fatal(err)
+			}
+			r := result(d, tt.check)
+			if r.Name != tt.check || r.Passed != tt.pass || math.Abs(r.Value-tt.value) > 1e-6 {
+				t.Errorf("%s = %+v; want passed %t, value %v", tt.check, r, tt.pass, tt.value)
+			}
+			if tt.pass && err != nil {
+				t.Errorf("rejected by %+v", d.Rejections())
+			}
+		})
+	}
+}
+
+type stubCorrelator map[string]float64
+
+func (c stubCorrelator) Correlation(ctx context.Context, a, b string) (float64, int, error) {
+	v, ok := c[a+"/"+b]
+	if !ok {
+		return 0, 0, errors.New("no history")
+	}
+	return v, 100, nil
+}
+
+func TestEngineCorrelation(t *testing.T) {
+	mids := map[string]float64{"EUR_USD": 1.10, "GBP_USD": 1.25, "USD_CHF": 0.9, "USD_JPY": 150}
+	corr := stubCorrelator{"EUR_USD/GBP_USD": 0.9, "USD_CHF/GBP_USD": -0.9}
+	tests := []struct {
+		name     string
+		open     float64
+		order    Order
+		failOpen bool
+		pass     bool
+	}{
+		{"same direction in a correlated pair", 1000, Order{Instrument: "EUR_USD", Units: 1000}, false, false},
+		{"opposite direction in a correlated pair", -1000, Order{Instrument: "EUR_USD", Units: 1000}, false, true},
+		{"same bet through a negative correlation", 1000, Order{Instrument: "USD_CHF", Units: -1000}, false, false},
+		{"hedge through a negative correlation", 1000, Order{Instrument: "USD_CHF", Units: 1000}, false, true},
+		{"missing history fails closed", 1000, Order{Instrument: "USD_JPY", Units: 1000}, false, false},
+		{"missing history fails open when configured", 1000, Order{Instrument: "USD_JPY", Units: 1000}, true, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			b := &stubBroker{account: broker.Account{Currency: "USD", NAV: 10000, Balance: 10000, MarginAvailable: 1e6},
+				positions: []broker.Position{position("GBP_USD", tt.open, 1.25)}, mids: mids}
+			e := NewEngine(b, nil, Limits{MaxCorrelation: 0.85, CorrelationFailOpen: tt.failOpen}).WithCorrelator(corr)
+			d, err := e.Evaluate(context.Background(), tt.order)
+			var re *RejectionError
+			if err != nil && !errors.As(err, &re) {
+				t.Fatal(err)
+			}
+			if r := result(d, "correlated_stacking"); r.Passed != tt.pass {
+				t.Errorf("correlated_stacking = %+v; want passed %t", r, tt.pass)
+			}
+		})
+	}
+}
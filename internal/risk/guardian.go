@@ -0,0 +1,393 @@
+package risk
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"math"
+	"sync"
+	"time"
+
+	"github.com/jedi116/go-trader/internal/broker"
+	"github.com/jedi116/go-trader/internal/notify"
+	"github.com/jedi116/go-trader/pkg/models"
+)
+
+// GuardianBroker is the account access the guardian needs, including closing positions.
+type GuardianBroker interface {
+	GetAccount() (*broker.Account, error)
+	GetTrades() ([]broker.Trade, error)
+	GetPositions() ([]broker.Position, error)
+	ClosePosition(instrument string) error
+}
+
+// GuardianStore persists kill-switch state so it survives restarts and is shared by the REST
+// and gRPC servers; database.Store implements it.
+type GuardianStore interface {
+	GetGuardianState(ctx context.Context, accountID string) (*models.GuardianState, error)
+	SaveGuardianState(ctx context.Context, s *models.GuardianState) error
+}
+
+// GuardianOptions holds the loss thresholds as fractions, e.g. 0.03 for 3%; zero disables one.
+type GuardianOptions struct {
+	// MaxDailyLoss is the day's realized plus change in unrealized P&L, measured against the
+	// start-of-day NAV.
+	MaxDailyLoss float64
+	// MaxDrawdown is measured against the peak NAV seen since the last reset, adjusted for
+	// deposits and withdrawals.
+	MaxDrawdown float64
+	// FlattenOnHalt closes every open position when the switch trips.
+	FlattenOnHalt bool
+	// Location defines the trading-day boundary; nil means UTC.
+	Location *time.Location
+}
+
+// GuardianStatus is the state plus the live figures it was evaluated against.
+type GuardianStatus struct {
+	models.GuardianState
+	NAV          float64 `json:"nav"`
+	RealizedPL   float64 `json:"realized_pl"`
+	UnrealizedPL float64 `json:"unrealized_pl"`
+	DailyPL      float64 `json:"daily_pl"`
+	DailyLoss    float64 `json:"daily_loss"`
+	Drawdown     float64 `json:"drawdown"`
+	MaxDailyLoss float64 `json:"max_daily_loss"`
+	MaxDrawdown  float64 `json:"max_drawdown"`
+}
+
+// HaltedError is returned for new orders while the kill switch is engaged.
+type HaltedError struct {
+	Reason string
+	Since  *time.Time
+}
+
+func (e *HaltedError) Error() string {
+	return "trading halted by kill switch: " + e.Reason
+}
+
+// Guardian tracks daily P&L and drawdown and trips a persisted kill switch on breach.
+type Guardian struct {
+	broker   GuardianBroker
+	store    GuardianStore
+	auditor  Auditor
+	notifier notify.Notifier
+	opts     GuardianOptions
+	now      func() time.Time
+
+	mu    sync.Mutex
+	state *models.GuardianState
+}
+
+// NewGuardian builds a guardian; store and auditor may be nil, in which case state lives in memory.
+func NewGuardian(b GuardianBroker, store GuardianStore, auditor Auditor, opts GuardianOptions) *Guardian {
+	if opts.Location == nil {
+		opts.Location = time.UTC
+	}
+	return &Guardian{broker: b, store: store, auditor: auditor, opts: opts, now: time.Now}
+}
+
+// WithNotifier announces kill-switch trips and resets.
+func (g *Guardian) WithNotifier(n notify.Notifier) *Guardian {
+	g.notifier = n
+	return g
+}
+
+func (g *Guardian) tradingDay(now time.Time) time.Time {
+	y, m, d := now.In(g.opts.Location).Date()
+	return time.Date(y, m, d, 0, 0, 0, 0, time.UTC)
+}
+
+// DayStart is the instant the trading day containing now began. A nil guardian uses UTC days.
+func (g *Guardian) DayStart(now time.Time) time.Time {
+	loc := time.UTC
+	if g != nil {
+		loc = g.opts.Location
+	}
+	y, m, d := now.In(loc).Date()
+	return time.Date(y, m, d, 0, 0, 0, 0, loc)
+}
+
+// load returns the current state, preferring the store so that a halt or reset made by another
+// process is seen immediately.
+func (g *Guardian) load(ctx context.Context, accountID string) (*models.GuardianState, error) {
+	if g.store != nil {
+		s, err := g.store.GetGuardianState(ctx, accountID)
+		if err != nil {
+			return nil, err
+		}
+		if s != nil {
+			return s, nil
+		}
+	}
+	if g.state != nil && g.state.AccountID == accountID {
+		cp := *g.state
+		return &cp, nil
+	}
+	return nil, nil
+}
+
+func (g *Guardian) save(ctx context.Context, s *models.GuardianState) error {
+	s.UpdatedAt = time.Now().UTC()
+	cp := *s
+	g.state = &cp
+	if g.store == nil {
+		return nil
+	}
+	return g.store.SaveGuardianState(ctx, s)
+}
+
+// Evaluate refreshes P&L from the broker, rolls the trading day, and trips the kill switch when a
+// threshold is breached. It is safe to call from the order path and from the monitor loop.
+func (g *Guardian) Evaluate(ctx context.Context) (*GuardianStatus, error) {
+	account, err := g.broker.GetAccount()
+	if err != nil {
+		return nil, fmt.Errorf("guardian: load account: %w", err)
+	}
+	trades, err := g.broker.GetTrades()
+	if err != nil {
+		return nil, fmt.Errorf("guardian: load trades: %w", err)
+	}
+	unrealized := 0.0
+	for _, t := range trades {
+		unrealized += t.UnrealizedPL
+	}
+
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	s, err := g.load(ctx, account.ID)
+	if err != nil {
+		return nil, fmt.Errorf("guardian: load state: %w", err)
+	}
+	day := g.tradingDay(g.now())
+	// P&L comes from the broker's lifetime realized pl rather than NAV or balance, so deposits and
+	// withdrawals are neither profit nor loss.
+	pl := account.PL
+	perf := account.PL + account.NAV - account.Balance
+	dirty := false
+	if s == nil {
+		s = &models.GuardianState{AccountID: account.ID, TradingDay: day, StartOfDayNAV: account.NAV, StartOfDayBalance: account.Balance,
+			StartOfDayPL: &pl, PeakNAV: account.NAV, PeakPL: &perf}
+		dirty = true
+	} else if !s.TradingDay.Equal(day) {
+		// A new day resets the daily baseline but not the halt: only an admin reset clears it.
+		s.TradingDay = day
+		s.StartOfDayNAV = account.NAV
+		s.StartOfDayBalance = account.Balance
+		s.StartOfDayPL = &pl
+		dirty = true
+	}
+	if s.StartOfDayPL == nil {
+		// State saved before pl was tracked: take today's balance change so far as realized.
+		v := pl - (account.Balance - s.StartOfDayBalance)
+		s.StartOfDayPL = &v
+		dirty = true
+	}
+	if s.PeakPL == nil {
+		// Likewise keep the current distance below the old peak.
+		v := perf + math.Max(0, s.PeakNAV-account.NAV)
+		s.PeakPL = &v
+		dirty = true
+	}
+	if perf >= *s.PeakPL {
+		if perf > *s.PeakPL || account.NAV != s.PeakNAV {
+			s.PeakPL = &perf
+			s.PeakNAV = account.NAV
+			dirty = true
+		}
+	} else if peak := account.NAV + *s.PeakPL - perf; math.Abs(peak-s.PeakNAV) >= 0.005 {
+		// NAV minus P&L moved since the peak: a transfer, which shifts the peak with it.
+		s.PeakNAV = peak
+		dirty = true
+	}
+
+	st := &GuardianStatus{
+		NAV:          account.NAV,
+		RealizedPL:   pl - *s.StartOfDayPL,
+		UnrealizedPL: unrealized,
+		MaxDailyLoss: g.opts.MaxDailyLoss,
+		MaxDrawdown:  g.opts.MaxDrawdown,
+	}
+	st.DailyPL = st.RealizedPL + unrealized - (s.StartOfDayNAV - s.StartOfDayBalance)
+	if s.StartOfDayNAV > 0 && st.DailyPL < 0 {
+		st.DailyLoss = -st.DailyPL / s.StartOfDayNAV
+	}
+	if s.PeakNAV > 0 && account.NAV < s.PeakNAV {
+		st.Drawdown = (s.PeakNAV - account.NAV) / s.PeakNAV
+	}
+
+	if !s.Halted {
+		var reason string
+		switch {
+		case g.opts.MaxDailyLoss > 0 && st.DailyLoss >= g.opts.MaxDailyLoss:
+			reason = fmt.Sprintf("daily loss %.2f%% reached limit %.2f%%", st.DailyLoss*100, g.opts.MaxDailyLoss*100)
+		case g.opts.MaxDrawdown > 0 && st.Drawdown >= g.opts.MaxDrawdown:
+			reason = fmt.Sprintf("drawdown %.2f%% from peak %.2f reached limit %.2f%%", st.Drawdown*100, s.PeakNAV, g.opts.MaxDrawdown*100)
+		}
+		if reason != "" {
+			g.halt(ctx, s, reason, st)
+			dirty = true
+		}
+	}
+	if dirty {
+		if err := g.save(ctx, s); err != nil {
+			return nil, fmt.Errorf("guardian: save state: %w", err)
+		}
+	}
+	st.GuardianState = *s
+	return st, nil
+}
+
+func (g *Guardian) halt(ctx context.Context, s *models.GuardianState, reason string, st *GuardianStatus) {
+	at := time.Now().UTC()
+	s.Halted = true
+	s.HaltReason = &reason
+	s.HaltedAt = &at
+	log.Printf("[GUARDIAN] kill switch engaged account=%s: %s", s.AccountID, reason)
+	details := map[string]interface{}{"reason": reason, "nav": st.NAV, "daily_pl": st.DailyPL, "drawdown": st.Drawdown, "flatten": g.opts.FlattenOnHalt}
+	if g.opts.FlattenOnHalt {
+		closed, errs := g.flatten()
+		details["closed"] = closed
+		if len(errs) > 0 {
+			details["close_errors"] = errs
+		}
+	}
+	g.audit(ctx, "KILL_SWITCH_ENGAGED", details)
+	g.notify(ctx, notify.Event{Type: notify.KillSwitchEngaged, Title: "Kill switch engaged for " + s.AccountID, Text: reason, Fields: details})
+}
+
+func (g *Guardian) notify(ctx context.Context, e notify.Event) {
+	if g.notifier != nil {
+		g.notifier.Notify(ctx, e)
+	}
+}
+
+// flatten closes every open position, continuing past individual failures.
+func (g *Guardian) flatten() ([]string, []string) {
+	positions, err := g.broker.GetPositions()
+	if err != nil {
+		log.Printf("[GUARDIAN] flatten: load positions: %v", err)
+		return nil, []string{err.Error()}
+	}
+	var closed, errs []string
+	for _, p := range positions {
+		if p.Long.Units == 0 && p.Short.Units == 0 {
+			continue
+		}
+		if err := g.broker.ClosePosition(p.Instrument); err != nil {
+			log.Printf("[GUARDIAN] flatten %s: %v", p.Instrument, err)
+			errs = append(errs, fmt.Sprintf("%s: %v", p.Instrument, err))
+			continue
+		}
+		closed = append(closed, p.Instrument)
+	}
+	return closed, errs
+}
+
+func (g *Guardian) audit(ctx context.Context, action string, details map[string]interface{}) {
+	if g.auditor == nil {
+		return
+	}
+	if err := g.auditor.LogAudit(ctx, "kill_switch", "", action, details); err != nil {
+		log.Printf("[GUARDIAN] audit error: %v", err)
+	}
+}
+
+// Check returns a *HaltedError when new orders must be refused. A nil guardian allows everything.
+func (g *Guardian) Check(ctx context.Context) error {
+	if g == nil {
+		return nil
+	}
+	st, err := g.Evaluate(ctx)
+	if err != nil {
+		return err
+	}
+	if st.Halted {
+		reason := ""
+		if st.HaltReason != nil {
+			reason = *st.HaltReason
+		}
+		return &HaltedError{Reason: reason, Since: st.HaltedAt}
+	}
+	return nil
+}
+
+// Halt engages the kill switch manually.
+func (g *Guardian) Halt(ctx context.Context, by, reason string) (*GuardianStatus, error) {
+	st, err := g.Evaluate(ctx)
+	if err != nil {
+		return nil, err
+	}
+	if st.Halted {
+		return st, nil
+	}
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	s := st.GuardianState
+	g.halt(ctx, &s, fmt.Sprintf("manual halt by %s: %s", by, reason), st)
+	if err := g.save(ctx, &s); err != nil {
+		return nil, fmt.Errorf("guardian: save state: %w", err)
+	}
+	st.GuardianState = s
+	return st, nil
+}
+
+// Reset clears the kill switch. The daily baseline and peak are rebased to the current NAV so the
+// same loss does not trip the switch again immediately.
+func (g *Guardian) Reset(ctx context.Context, by, reason string) (*GuardianStatus, error) {
+	account, err := g.broker.GetAccount()
+	if err != nil {
+		return nil, fmt.Errorf("guardian: load account: %w", err)
+	}
+	g.mu.Lock()
+	s, err := g.load(ctx, account.ID)
+	if err != nil {
+		g.mu.Unlock()
+		return nil, fmt.Errorf("guardian: load state: %w", err)
+	}
+	if s == nil {
+		s = &models.GuardianState{AccountID: account.ID}
+	}
+	at := g.now().UTC()
+	wasHalted := s.Halted
+	s.TradingDay = g.tradingDay(at)
+	s.StartOfDayNAV = account.NAV
+	s.StartOfDayBalance = account.Balance
+	pl, perf := account.PL, account.PL+account.NAV-account.Balance
+	s.StartOfDayPL = &pl
+	s.PeakNAV = account.NAV
+	s.PeakPL = &perf
+	s.Halted = false
+	s.HaltReason = nil
+	s.HaltedAt = nil
+	s.ResetBy = &by
+	s.ResetAt = &at
+	err = g.save(ctx, s)
+	g.mu.Unlock()
+	if err != nil {
+		return nil, fmt.Errorf("guardian: save state: %w", err)
+	}
+	log.Printf("[GUARDIAN] kill switch reset account=%s by=%s", account.ID, by)
+	details := map[string]interface{}{"by": by, "reason": reason, "was_halted": wasHalted, "nav": account.NAV}
+	g.audit(ctx, "KILL_SWITCH_RESET", details)
+	g.notify(ctx, notify.Event{Type: notify.KillSwitchReset, Title: "Kill switch reset for " + account.ID + " by " + by, Text: reason, Fields: details})
+	return g.Evaluate(ctx)
+}
+
+// Run evaluates every interval until ctx is done so breaches are caught between orders.
+func (g *Guardian) Run(ctx context.Context, interval time.Duration) {
+	if interval <= 0 {
+		interval = time.Minute
+	}
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		if _, err := g.Evaluate(ctx); err != nil {
+			log.Printf("[GUARDIAN] evaluate error: %v", err)
+		}
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
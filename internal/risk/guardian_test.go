@@ -0,0 +1,152 @@
+package risk
+
+import (
+	"context"
+	"math"
+	"testing"
+	"time"
+
+	"github.com/jedi116/go-trader/internal/broker"
+	"github.com/jedi116/go-trader/pkg/models"
+)
+
+// step moves the account and clock, optionally resets the switch, then evaluates.
+type step struct {
+	balance, pl, unrealized float64
+	days                    int
+	reset                   bool
+
+	halted        bool
+	dailyPL       float64
+	drawdown      float64
+	peak          float64
+	startOfDayNAV float64
+}
+
+func TestGuardian(t *testing.T) {
+	tests := []struct {
+		name  string
+		opts  GuardianOptions
+		steps []step
+	}{
+		{"daily loss trips at the threshold", GuardianOptions{MaxDailyLoss: 0.03}, []step{
+			{balance: 10000, halted: false, peak: 10000, startOfDayNAV: 10000},
+			{balance: 9800, pl: -200, unrealized: -99, halted: false, dailyPL: -299, drawdown: 0.0299, peak: 10000, startOfDayNAV: 10000},
+			{balance: 9800, pl: -200, unrealized: -100, halted: true, dailyPL: -300, drawdown: 0.03, peak: 10000, startOfDayNAV: 10000},
+		}},
+		{"withdrawal is not a loss", GuardianOptions{MaxDailyLoss: 0.03, MaxDrawdown: 0.05}, []step{
+			{balance: 10000, peak: 10000, startOfDayNAV: 10000},
+			{balance: 5000, halted: false, peak: 5000, startOfDayNAV: 10000},
+		}},
+		{"deposit does not hide a loss", GuardianOptions{MaxDailyLoss: 0.03}, []step{
+			{balance: 10000, peak: 10000, startOfDayNAV: 10000},
+			{balance: 14700, pl: -300, halted: true, dailyPL: -300, drawdown: 300.0 / 15000, peak: 15000, startOfDayNAV: 10000},
+		}},
+		{"drawdown trips at the threshold across days", GuardianOptions{MaxDrawdown: 0.1}, []step{
+			{balance: 10000, peak: 10000, startOfDayNAV: 10000},
+			{balance: 9500, pl: -500, dailyPL: -500, drawdown: 0.05, peak: 10000, startOfDayNAV: 10000},
+			{balance: 9500, pl: -500, days: 1, drawdown: 0.05, peak: 10000, startOfDayNAV: 9500},
+			{balance: 9000, pl: -1000, halted: true, dailyPL: -500, drawdown: 0.1, peak: 10000, startOfDayNAV: 9500},
+		}},
+		{"new peak raises the peak", GuardianOptions{MaxDrawdown: 0.1}, []step{
+			{balance: 10000, peak: 10000, startOfDayNAV: 10000},
+			{balance: 10000, unrealized: 500, dailyPL: 500, peak: 10500, startOfDayNAV: 10000},
+			{balance: 10200, pl: 200, dailyPL: 200, drawdown: 300.0 / 10500, peak: 10500, startOfDayNAV: 10000},
+		}},
+		{"day rollover keeps the halt", GuardianOptions{MaxDailyLoss: 0.03}, []step{
+			{balance: 10000, peak: 10000, startOfDayNAV: 10000},
+			{balance: 9600, pl: -400, halted: true, dailyPL: -400, drawdown: 0.04, peak: 10000, startOfDayNAV: 10000},
+			{balance: 9600, pl: -400, days: 1, halted: true, drawdown: 0.04, peak: 10000, startOfDayNAV: 9600},
+		}},
+		{"reset rebases start of day and peak", GuardianOptions{MaxDailyLoss: 0.03, MaxDrawdown: 0.05}, []step{
+			{balance: 10000, peak: 10000, startOfDayNAV: 10000},
+			{balance: 9400, pl: -600, halted: true, dailyPL: -600, drawdown: 0.06, peak: 10000, startOfDayNAV: 10000},
+			{balance: 9400, pl: -600, reset: true, peak: 9400, startOfDayNAV: 9400},
+			{balance: 9300, pl: -700, dailyPL: -100, drawdown: 100.0 / 9400, peak: 9400, startOfDayNAV: 9400},
+		}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			b := &stubBroker{}
+			now := time.Date(2026, 3, 2, 12, 0, 0, 0, time.UTC)
+			g := NewGuardian(b, nil, nil, tt.opts)
+			g.now = func() time.Time { return now }
+			ctx := context.Background()
+			for i, s := range tt.steps {
+				now = now.AddDate(0, 0, s.days)
+				b.account = broker.Account{ID: "acct", Currency: "USD", Balance: s.balance, PL: s.pl, NAV: s.balance + s.unrealized, UnrealizedPL: s.unrealized}
+				b.trades = []broker.Trade{{UnrealizedPL: s.unrealized}}
+				var st *GuardianStatus
+				var err error
+				if s.reset {
+					st, err = g.Reset(ctx, "test", "")
+				} else {
+					st, err = g.Evaluate(ctx)
+				}
+				if err != nil {
+					t.Fatal(err)
+				}
+				if st.Halted != s.halted || !near(st.DailyPL, s.dailyPL) || !near(st.Drawdown, s.drawdown) || !near(st.PeakNAV, s.peak) || !near(st.StartOfDayNAV, s.startOfDayNAV) {
+					t.Fatalf("step %d: halted %t, daily P&L %.2f, drawdown %.4f, peak %.2f, start of day %.2f", i, st.Halted, st.DailyPL, st.Drawdown, st.PeakNAV, st.StartOfDayNAV)
+				}
+			}
+		})
+	}
+}
+
+func TestGuardianFlattensOnHalt(t *testing.T) {
+	b := &stubBroker{positions: []broker.Position{position("EUR_USD", 1000, 1.1), position("GBP_USD", 0, 0), position("USD_JPY", -500, 150)}}
+	g := NewGuardian(b, nil, nil, GuardianOptions{MaxDailyLoss: 0.01, FlattenOnHalt: true})
+	b.account = broker.Account{ID: "acct", Balance: 10000, NAV: 10000}
+	if _, err := g.Evaluate(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	b.account = broker.Account{ID: "acct", Balance: 9800, PL: -200, NAV: 9800}
+	if err := g.Check(context.Background()); err == nil {
+		t.Fatal("Check passed after a 2% loss")
+	}
+	if len(b.closed) != 2 || b.closed[0] != "EUR_USD" || b.closed[1] != "USD_JPY" {
+		t.Errorf("closed %v", b.closed)
+	}
+}
+
+type stubGuardianStore struct{ state *models.GuardianState }
+
+func (s *stubGuardianStore) GetGuardianState(ctx context.Context, accountID string) (*models.GuardianState, error) {
+	if s.state == nil {
+		return nil, nil
+	}
+	cp := *s.state
+	return &cp, nil
+}
+
+func (s *stubGuardianStore) SaveGuardianState(ctx context.Context, st *models.GuardianState) error {
+	cp := *st
+	s.state = &cp
+	return nil
+}
+
+// State saved before pl was tracked keeps today's balance change as realized P&L and its
+// distance below the peak.
+func TestGuardianUpgradesState(t *testing.T) {
+	now := time.Date(2026, 3, 2, 12, 0, 0, 0, time.UTC)
+	store := &stubGuardianStore{state: &models.GuardianState{AccountID: "acct", TradingDay: time.Date(2026, 3, 2, 0, 0, 0, 0, time.UTC),
+		StartOfDayNAV: 10000, StartOfDayBalance: 10000, PeakNAV: 10500}}
+	b := &stubBroker{account: broker.Account{ID: "acct", Balance: 9900, PL: -50, NAV: 9900}}
+	g := NewGuardian(b, store, nil, GuardianOptions{})
+	g.now = func() time.Time { return now }
+	st, err := g.Evaluate(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !near(st.DailyPL, -100) || !near(st.PeakNAV, 10500) || store.state.StartOfDayPL == nil || store.state.PeakPL == nil {
+		t.Fatalf("daily P&L %.2f, peak %.2f, state %+v", st.DailyPL, st.PeakNAV, store.state)
+	}
+	// A later withdrawal moves the upgraded peak with it.
+	b.account = broker.Account{ID: "acct", Balance: 8900, PL: -50, NAV: 8900}
+	if st, err = g.Evaluate(context.Background()); err != nil || !near(st.PeakNAV, 9500) || !near(st.DailyPL, -100) {
+		t.Fatalf("after withdrawal: %+v, %v", st, err)
+	}
+}
+
+func near(a, b float64) bool { return math.Abs(a-b) < 1e-4 }
@@ -0,0 +1,181 @@
+package signals
+
+import (
+	"fmt"
+	"math"
+	"sync"
+	"time"
+
+	"github.com/jedi116/go-trader/internal/broker"
+	"github.com/jedi116/go-trader/internal/portfolio"
+	"github.com/jedi116/go-trader/internal/risk"
+	"github.com/jedi116/go-trader/pkg/models"
+)
+
+// Broker is the account and market data needed to size orders and place pip stops.
+type Broker interface {
+	GetAccount() (*broker.Account, error)
+	GetPrices(instruments []string) ([]broker.Price, error)
+	GetInstruments() ([]broker.Instrument, error)
+}
+
+// Resolver turns signals into concrete orders at the current price.
+type Resolver struct {
+	broker Broker
+
+	mu            sync.Mutex
+	instruments   map[string]broker.Instrument
+	instrumentsAt time.Time
+}
+
+func NewResolver(b Broker) *Resolver {
+	return &Resolver{broker: b}
+}
+
+func (r *Resolver) tradeable() (map[string]broker.Instrument, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if r.instruments != nil && time.Since(r.instrumentsAt) < time.Hour {
+		return r.instruments, nil
+	}
+	list, err := r.broker.GetInstruments()
+	if err != nil {
+		return nil, err
+	}
+	m := make(map[string]broker.Instrument, len(list))
+	for _, in := range list {
+		m[in.Name] = in
+	}
+	r.instruments = m
+	r.instrumentsAt = time.Now()
+	return m, nil
+}
+
+// Resolve converts pip stops to prices around the current mid and sizes risk-based signals so
+// that hitting the stop loses Risk of NAV. Units are signed by action.
+func (r *Resolver) Resolve(s *Signal) (risk.Order, error) {
+	o := risk.Order{Instrument: s.Instrument, StopLoss: s.StopLoss, TakeProfit: s.TakeProfit, Source: "signal"}
+	if s.Strategy != "" {
+		o.Source = "signal:" + s.Strategy
+	}
+	tradeable, err := r.tradeable()
+	if err != nil {
+		return o, fmt.Errorf("signals: load instruments: %w", err)
+	}
+	inst, ok := tradeable[s.Instrument]
+	if !ok {
+		return o, invalid("unknown instrument %s", s.Instrument)
+	}
+	base, quote, ok := models.SplitInstrument(s.Instrument)
+	if !ok {
+		return o, invalid("unsupported instrument %s", s.Instrument)
+	}
+	needPrice := s.SLPips != nil || s.TPPips != nil || s.Risk > 0
+	var account *broker.Account
+	var rates *portfolio.Rates
+	if s.Risk > 0 {
+		if account, err = r.broker.GetAccount(); err != nil {
+			return o, fmt.Errorf("signals: load account: %w", err)
+		}
+	}
+	var price float64
+	if needPrice {
+		names := []string{s.Instrument}
+		if account != nil {
+			names = append(names, portfolio.ConversionInstruments([]string{base, quote}, account.Currency, tradeable)...)
+		}
+		prices, err := r.broker.GetPrices(names)
+		if err != nil {
+			return o, fmt.Errorf("signals: load prices: %w", err)
+		}
+		rates = portfolio.NewRates(prices)
+		if price, ok = rates.Mid(s.Instrument); !ok {
+			return o, fmt.Errorf("signals: no price for %s", s.Instrument)
+		}
+	}
+
+	dir := 1.0
+	if s.Action == Sell {
+		dir = -1
+	}
+	pip := models.PipFromLocation(inst.PipLocation)
+	if s.SLPips != nil {
+		sl := round(price-dir**s.SLPips*pip, inst.DisplayPrecision)
+		o.StopLoss = &sl
+	}
+	if s.TPPips != nil {
+		tp := round(price+dir**s.TPPips*pip, inst.DisplayPrecision)
+		o.TakeProfit = &tp
+	}
+	if o.StopLoss != nil && needPrice && (*o.StopLoss-price)*dir >= 0 {
+		return o, invalid("stop loss %.5f is on the wrong side of the price %.5f", *o.StopLoss, price)
+	}
+
+	units := s.Units
+	if s.Risk > 0 {
+		conv, ok := rates.Rate(quote, account.Currency)
+		if !ok {
+			return o, fmt.Errorf("signals: cannot convert %s to %s", quote, account.Currency)
+		}
+		perUnit := math.Abs(price-*o.StopLoss) * conv
+		units = s.Risk * account.NAV / perUnit
+	}
+	step := math.Pow(10, -float64(inst.TradeUnitsPrecision))
+	// The epsilon keeps float noise in the stop distance from costing a whole unit.
+	units = math.Floor(units/step+1e-9) * step
+	if units <= 0 || units < inst.MinimumTradeSize {
+		return o, invalid("order size %.2f units is below the minimum for %s", units, s.Instrument)
+	}
+	o.Units = dir * units
+	return o, nil
+}
+
+func round(x float64, digits int) float64 {
+	p := math.Pow(10, float64(digits))
+	return math.Round(x*p) / p
+}
+
+// DefaultMaxAge is how far a signal's time may be from now when signals.max_age is unset.
+const DefaultMaxAge = 2 * time.Minute
+
+// Seen remembers signal IDs for a while so that retried webhook deliveries are not executed twice.
+type Seen struct {
+	ttl time.Duration
+
+	mu  sync.Mutex
+	ids map[string]time.Time
+}
+
+func NewSeen(ttl time.Duration) *Seen {
+	if ttl <= 0 {
+		ttl = 10 * time.Minute
+	}
+	return &Seen{ttl: ttl, ids: make(map[string]time.Time)}
+}
+
+// Add records id and reports whether it was new; an empty id is never accepted.
+func (s *Seen) Add(id string) bool {
+	if id == "" {
+		return false
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	now := time.Now()
+	for k, t := range s.ids {
+		if now.Sub(t) > s.ttl {
+			delete(s.ids, k)
+		}
+	}
+	if _, dup := s.ids[id]; dup {
+		return false
+	}
+	s.ids[id] = now
+	return true
+}
+
+// Forget drops id so a signal that failed can be retried.
+func (s *Seen) Forget(id string) {
+	s.mu.Lock()
+	delete(s.ids, id)
+	s.mu.Unlock()
+}
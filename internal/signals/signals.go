@@ -0,0 +1,242 @@
+// Package signals parses inbound trading signals, either go-trader's own JSON format or a
+// TradingView alert template, authenticates them and resolves them into orders.
+package signals
+
+import (
+	"bytes"
+	"crypto/hmac"
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Signal is a normalized inbound signal. Exactly one of Units and Risk is set; stops are given
+// either as prices or as pip distances from the current price.
+type Signal struct {
+	ID         string    `json:"id"`
+	Instrument string    `json:"instrument"`
+	Action     string    `json:"action"`
+	Units      float64   `json:"units,omitempty"`
+	Risk       float64   `json:"risk,omitempty"`
+	StopLoss   *float64  `json:"stop_loss,omitempty"`
+	TakeProfit *float64  `json:"take_profit,omitempty"`
+	SLPips     *float64  `json:"stop_loss_pips,omitempty"`
+	TPPips     *float64  `json:"take_profit_pips,omitempty"`
+	Strategy   string    `json:"strategy,omitempty"`
+	Comment    string    `json:"comment,omitempty"`
+	Time       time.Time `json:"time"`
+}
+
+const (
+	Buy  = "BUY"
+	Sell = "SELL"
+)
+
+// InvalidError describes a payload that cannot be turned into an order.
+type InvalidError struct {
+	Message string
+}
+
+func (e *InvalidError) Error() string { return "invalid signal: " + e.Message }
+
+func invalid(format string, args ...interface{}) error {
+	return &InvalidError{Message: fmt.Sprintf(format, args...)}
+}
+
+// number accepts JSON numbers and numeric strings, since TradingView placeholders are
+// substituted into the template as text; an empty string is treated as absent.
+type number struct {
+	v   float64
+	set bool
+}
+
+func (n *number) UnmarshalJSON(b []byte) error {
+	if bytes.Equal(b, []byte("null")) {
+		return nil
+	}
+	s := string(b)
+	if uq, err := strconv.Unquote(s); err == nil {
+		s = strings.TrimSpace(uq)
+		if s == "" {
+			return nil
+		}
+	}
+	v, err := strconv.ParseFloat(s, 64)
+	if err != nil {
+		return fmt.Errorf("not a number: %s", b)
+	}
+	n.v, n.set = v, true
+	return nil
+}
+
+func (n number) ptr() *float64 {
+	if !n.set {
+		return nil
+	}
+	v := n.v
+	return &v
+}
+
+// nativePayload is the documented go-trader format.
+type nativePayload struct {
+	ID         string `json:"id"`
+	Instrument string `json:"instrument"`
+	Action     string `json:"action"`
+	Units      number `json:"units"`
+	Risk       number `json:"risk"`
+	StopLoss   number `json:"stop_loss"`
+	TakeProfit number `json:"take_profit"`
+	SLPips     number `json:"stop_loss_pips"`
+	TPPips     number `json:"take_profit_pips"`
+	Strategy   string `json:"strategy"`
+	Comment    string `json:"comment"`
+	Time       string `json:"time"`
+	Passphrase string `json:"passphrase"`
+}
+
+// tradingViewPayload is the alert-message template documented in the README; field names
+// follow TradingView's placeholders.
+type tradingViewPayload struct {
+	ID         string `json:"id"`
+	Ticker     string `json:"ticker"`
+	Action     string `json:"action"`
+	Contracts  number `json:"contracts"`
+	Risk       number `json:"risk"`
+	StopLoss   number `json:"sl"`
+	TakeProfit number `json:"tp"`
+	SLPips     number `json:"sl_pips"`
+	TPPips     number `json:"tp_pips"`
+	Strategy   string `json:"strategy"`
+	Comment    string `json:"comment"`
+	Time       string `json:"time"`
+	Passphrase string `json:"passphrase"`
+}
+
+// Parse decodes a native payload, or a TradingView one when tradingView is set, and returns the
+// signal with the passphrase it carried, if any.
+func Parse(body []byte, tradingView bool) (*Signal, string, error) {
+	var sig Signal
+	var passphrase, ts string
+	if tradingView {
+		var p tradingViewPayload
+		if err := json.Unmarshal(body, &p); err != nil {
+			return nil, "", invalid("%v", err)
+		}
+		sig = Signal{ID: p.ID, Instrument: NormalizeTicker(p.Ticker), Action: p.Action, Units: p.Contracts.v, Risk: p.Risk.v,
+			StopLoss: p.StopLoss.ptr(), TakeProfit: p.TakeProfit.ptr(), SLPips: p.SLPips.ptr(), TPPips: p.TPPips.ptr(),
+			Strategy: p.Strategy, Comment: p.Comment}
+		passphrase, ts = p.Passphrase, p.Time
+	} else {
+		var p nativePayload
+		if err := json.Unmarshal(body, &p); err != nil {
+			return nil, "", invalid("%v", err)
+		}
+		sig = Signal{ID: p.ID, Instrument: strings.ToUpper(strings.TrimSpace(p.Instrument)), Action: p.Action, Units: p.Units.v, Risk: p.Risk.v,
+			StopLoss: p.StopLoss.ptr(), TakeProfit: p.TakeProfit.ptr(), SLPips: p.SLPips.ptr(), TPPips: p.TPPips.ptr(),
+			Strategy: p.Strategy, Comment: p.Comment}
+		passphrase, ts = p.Passphrase, p.Time
+	}
+	if ts != "" {
+		t, err := time.Parse(time.RFC3339, ts)
+		if err != nil {
+			return nil, "", invalid("time must be RFC3339")
+		}
+		sig.Time = t
+	}
+	if err := sig.validate(); err != nil {
+		return nil, "", err
+	}
+	return &sig, passphrase, nil
+}
+
+func (s *Signal) validate() error {
+	switch strings.ToUpper(strings.TrimSpace(s.Action)) {
+	case "BUY", "LONG":
+		s.Action = Buy
+	case "SELL", "SHORT":
+		s.Action = Sell
+	default:
+		return invalid("action must be buy or sell")
+	}
+	if s.Instrument == "" {
+		return invalid("instrument is required")
+	}
+	// Both are needed to refuse replays: the id for redeliveries, the time for old bodies.
+	if strings.TrimSpace(s.ID) == "" {
+		return invalid("id is required")
+	}
+	if s.Time.IsZero() {
+		return invalid("time is required")
+	}
+	s.Units = abs(s.Units)
+	if (s.Units > 0) == (s.Risk > 0) {
+		return invalid("give exactly one of units or risk")
+	}
+	if s.Risk >= 1 {
+		return invalid("risk is a fraction of NAV, e.g. 0.01 for 1%%")
+	}
+	if s.StopLoss != nil && s.SLPips != nil {
+		return invalid("give stop loss as a price or in pips, not both")
+	}
+	if s.TakeProfit != nil && s.TPPips != nil {
+		return invalid("give take profit as a price or in pips, not both")
+	}
+	if s.Risk > 0 && s.StopLoss == nil && s.SLPips == nil {
+		return invalid("risk-based sizing needs a stop loss")
+	}
+	for _, p := range []*float64{s.StopLoss, s.TakeProfit, s.SLPips, s.TPPips} {
+		if p != nil && *p <= 0 {
+			return invalid("stops must be positive")
+		}
+	}
+	return nil
+}
+
+// NormalizeTicker turns TradingView tickers such as "OANDA:EURUSD", "EURUSD" or "EUR/USD" into
+// OANDA instrument names.
+func NormalizeTicker(t string) string {
+	t = strings.ToUpper(strings.TrimSpace(t))
+	if i := strings.LastIndex(t, ":"); i >= 0 {
+		t = t[i+1:]
+	}
+	t = strings.NewReplacer("/", "_", "-", "_").Replace(t)
+	if len(t) == 6 && !strings.Contains(t, "_") {
+		t = t[:3] + "_" + t[3:]
+	}
+	return t
+}
+
+// SignatureHeader carries "sha256=<hex HMAC-SHA256 of the raw body>".
+const SignatureHeader = "X-Signature"
+
+// VerifyHMAC checks a SignatureHeader value against the body.
+func VerifyHMAC(secret string, body []byte, header string) bool {
+	got, ok := strings.CutPrefix(strings.TrimSpace(header), "sha256=")
+	if !ok {
+		return false
+	}
+	sig, err := hex.DecodeString(got)
+	if err != nil {
+		return false
+	}
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write(body)
+	return hmac.Equal(sig, mac.Sum(nil))
+}
+
+// VerifySecret compares a shared secret in constant time.
+func VerifySecret(secret, got string) bool {
+	return got != "" && subtle.ConstantTimeCompare([]byte(secret), []byte(got)) == 1
+}
+
+func abs(x float64) float64 {
+	if x < 0 {
+		return -x
+	}
+	return x
+}
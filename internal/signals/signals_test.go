@@ -0,0 +1,82 @@
+package signals
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"testing"
+	"time"
+)
+
+func TestParseRequiresIDAndTime(t *testing.T) {
+	tests := []struct {
+		name        string
+		body        string
+		tradingView bool
+		wantErr     string
+	}{
+		{"native", `{"id":"a1","instrument":"eur_usd","action":"buy","units":1000,"time":"2026-10-18T10:00:00Z"}`, false, ""},
+		{"tradingview", `{"id":"tv-1","ticker":"OANDA:EURUSD","action":"sell","contracts":"1000","time":"2026-10-18T10:00:00Z"}`, true, ""},
+		{"missing id", `{"instrument":"EUR_USD","action":"buy","units":1000,"time":"2026-10-18T10:00:00Z"}`, false, "id is required"},
+		{"blank id", `{"id":"  ","instrument":"EUR_USD","action":"buy","units":1000,"time":"2026-10-18T10:00:00Z"}`, false, "id is required"},
+		{"missing time", `{"id":"a1","instrument":"EUR_USD","action":"buy","units":1000}`, false, "time is required"},
+		{"tradingview without time", `{"id":"tv-1","ticker":"EURUSD","action":"buy","contracts":"1000","time":""}`, true, "time is required"},
+		{"bad time", `{"id":"a1","instrument":"EUR_USD","action":"buy","units":1000,"time":"yesterday"}`, false, "time must be RFC3339"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			sig, _, err := Parse([]byte(tt.body), tt.tradingView)
+			if tt.wantErr == "" {
+				if err != nil {
+					t.Fatal(err)
+				}
+				if sig.ID == "" || sig.Time.IsZero() || sig.Instrument != "EUR_USD" {
+					t.Errorf("signal = %+v", sig)
+				}
+				return
+			}
+			var ie *InvalidError
+			if !errors.As(err, &ie) || ie.Message != tt.wantErr {
+				t.Errorf("err = %v, want %q", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestSeen(t *testing.T) {
+	s := NewSeen(time.Minute)
+	if s.Add("") {
+		t.Error("an empty id must never be accepted")
+	}
+	if !s.Add("a") {
+		t.Fatal("first delivery refused")
+	}
+	if s.Add("a") {
+		t.Error("redelivery accepted")
+	}
+	s.Forget("a")
+	if !s.Add("a") {
+		t.Error("forgotten id refused")
+	}
+
+	s = NewSeen(time.Nanosecond)
+	s.Add("b")
+	time.Sleep(time.Millisecond)
+	if !s.Add("b") {
+		t.Error("id still remembered after the ttl")
+	}
+}
+
+func TestVerifyHMAC(t *testing.T) {
+	body := []byte(`{"id":"a1"}`)
+	mac := hmac.New(sha256.New, []byte("s3cret"))
+	mac.Write(body)
+	sig := "sha256=" + hex.EncodeToString(mac.Sum(nil))
+	if !VerifyHMAC("s3cret", body, sig) {
+		t.Error("valid signature refused")
+	}
+	if VerifyHMAC("s3cret", []byte(`{"id":"a2"}`), sig) || VerifyHMAC("other", body, sig) || VerifyHMAC("s3cret", body, "") {
+		t.Error("invalid signature accepted")
+	}
+}
@@ -0,0 +1,135 @@
+package snapshots
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/jedi116/go-trader/internal/broker"
+	"github.com/jedi116/go-trader/pkg/models"
+)
+
+// Broker is the account access the snapshotter needs.
+type Broker interface {
+	GetAccount() (*broker.Account, error)
+}
+
+// Store persists snapshots; database.Store implements it.
+type Store interface {
+	CreateAccountSnapshot(ctx context.Context, s *models.AccountSnapshot) error
+	DeleteAccountSnapshotsBefore(ctx context.Context, before time.Time) (int64, error)
+}
+
+type Options struct {
+	// Interval is the time between snapshots; zero means five minutes.
+	Interval time.Duration
+	// Retention is how long snapshots are kept; zero keeps them forever.
+	Retention time.Duration
+}
+
+// pruneEvery bounds how often expired snapshots are deleted.
+const pruneEvery = time.Hour
+
+// Snapshotter records the account at a fixed interval and prunes readings past retention.
+type Snapshotter struct {
+	broker Broker
+	store  Store
+	opts   Options
+
+	lastPrune time.Time
+}
+
+func New(b Broker, store Store, opts Options) *Snapshotter {
+	if opts.Interval <= 0 {
+		opts.Interval = 5 * time.Minute
+	}
+	return &Snapshotter{broker: b, store: store, opts: opts}
+}
+
+// Take reads the account and stores it.
+func (s *Snapshotter) Take(ctx context.Context) (*models.AccountSnapshot, error) {
+	acct, err := s.broker.GetAccount()
+	if err != nil {
+		return nil, err
+	}
+	snap := &models.AccountSnapshot{
+		AccountID:       acct.ID,
+		Currency:        acct.Currency,
+		Balance:         acct.Balance,
+		NAV:             acct.NAV,
+		UnrealizedPL:    acct.UnrealizedPL,
+		MarginUsed:      acct.MarginUsed,
+		MarginAvailable: acct.MarginAvailable,
+		OpenTradeCount:  acct.OpenTradeCount,
+		TakenAt:         time.Now().UTC(),
+	}
+	if err := s.store.CreateAccountSnapshot(ctx, snap); err != nil {
+		return nil, err
+	}
+	return snap, nil
+}
+
+// Prune deletes snapshots older than the retention period as of now.
+func (s *Snapshotter) Prune(ctx context.Context, now time.Time) (int64, error) {
+	if s.opts.Retention <= 0 {
+		return 0, nil
+	}
+	return s.store.DeleteAccountSnapshotsBefore(ctx, now.Add(-s.opts.Retention))
+}
+
+// Run takes a snapshot every interval, around the clock so weekends keep their readings too,
+// until ctx is cancelled.
+func (s *Snapshotter) Run(ctx context.Context) {
+	ticker := time.NewTicker(s.opts.Interval)
+	defer ticker.Stop()
+	for {
+		if _, err := s.Take(ctx); err != nil {
+			log.Printf("[SNAPSHOT] account snapshot: %v", err)
+		}
+		if now := time.Now(); now.Sub(s.lastPrune) >= pruneEvery {
+			s.lastPrune = now
+			if n, err := s.Prune(ctx, now); err != nil {
+				log.Printf("[SNAPSHOT] prune: %v", err)
+			} else if n > 0 {
+				log.Printf("[SNAPSHOT] pruned %d snapshots", n)
+			}
+		}
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// ParseResolution reads a query resolution: "raw" or empty for every snapshot, a Go duration
+// such as "15m" or "4h", or a whole number of days or weeks such as "1d" or "1w".
+func ParseResolution(s string) (time.Duration, error) {
+	s = strings.ToLower(strings.TrimSpace(s))
+	if s == "" || s == "raw" {
+		return 0, nil
+	}
+	var d time.Duration
+	if unit := s[len(s)-1]; unit == 'd' || unit == 'w' {
+		n, err := strconv.Atoi(s[:len(s)-1])
+		if err != nil {
+			return 0, fmt.Errorf("invalid resolution %q", s)
+		}
+		d = time.Duration(n) * 24 * time.Hour
+		if unit == 'w' {
+			d *= 7
+		}
+	} else {
+		var err error
+		if d, err = time.ParseDuration(s); err != nil {
+			return 0, fmt.Errorf("invalid resolution %q", s)
+		}
+	}
+	if d < time.Minute {
+		return 0, fmt.Errorf("resolution %q is below one minute", s)
+	}
+	return d, nil
+}
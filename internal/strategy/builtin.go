@@ -0,0 +1,145 @@
+package strategy
+
+import (
+	"fmt"
+	"math"
+)
+
+func init() {
+	Register("ema_cross", newEMACross)
+	Register("rsi_reversion", newRSIReversion)
+}
+
+// brackets places stop and target percentages away from price for a position in direction dir.
+func brackets(price, dir, slPct, tpPct float64) (*float64, *float64) {
+	var sl, tp *float64
+	if slPct > 0 {
+		v := price * (1 - dir*slPct/100)
+		sl = &v
+	}
+	if tpPct > 0 {
+		v := price * (1 + dir*tpPct/100)
+		tp = &v
+	}
+	return sl, tp
+}
+
+// crossed reports whether a moved from at-or-below b to above it (up) or the reverse over the
+// last two bars.
+func crossed(a, b []float64) (up, down bool) {
+	n := len(a)
+	if n < 2 || len(b) != n {
+		return false, false
+	}
+	for _, v := range []float64{a[n-2], a[n-1], b[n-2], b[n-1]} {
+		if math.IsNaN(v) {
+			return false, false
+		}
+	}
+	up = a[n-2] <= b[n-2] && a[n-1] > b[n-1]
+	down = a[n-2] >= b[n-2] && a[n-1] < b[n-1]
+	return up, down
+}
+
+// emaCross is always in the market once the averages first cross: long while the fast EMA is
+// above the slow one, short while below.
+type emaCross struct {
+	Base
+	fast, slow   int
+	units        float64
+	slPct, tpPct float64
+}
+
+func newEMACross(p Params) (Strategy, error) {
+	s := &emaCross{
+		fast:  p.Int("fast", 9),
+		slow:  p.Int("slow", 21),
+		units: p.Float("units", 1000),
+		slPct: p.Float("stop_loss_pct", 0),
+		tpPct: p.Float("take_profit_pct", 0),
+	}
+	if s.fast <= 0 || s.slow <= s.fast {
+		return nil, fmt.Errorf("ema_cross: need 0 < fast < slow, got %d and %d", s.fast, s.slow)
+	}
+	if s.units <= 0 {
+		return nil, fmt.Errorf("ema_cross: units must be positive")
+	}
+	return s, nil
+}
+
+func (s *emaCross) OnCandle(ctx Context, c Candle) error {
+	up, down := crossed(ctx.Indicator(c.Instrument, "EMA", s.fast), ctx.Indicator(c.Instrument, "EMA", s.slow))
+	if !up && !down {
+		return nil
+	}
+	dir := 1.0
+	if down {
+		dir = -1
+	}
+	delta := dir*s.units - ctx.Position(c.Instrument).Units
+	if delta == 0 {
+		return nil
+	}
+	side := "above"
+	if down {
+		side = "below"
+	}
+	sl, tp := brackets(c.Close, dir, s.slPct, s.tpPct)
+	return ctx.Submit(OrderRequest{Instrument: c.Instrument, Units: delta, StopLoss: sl, TakeProfit: tp,
+		Reason: fmt.Sprintf("EMA(%d) crossed %s EMA(%d)", s.fast, side, s.slow)})
+}
+
+// rsiReversion fades extremes: it buys when RSI climbs back above the oversold level, sells when
+// it falls back below overbought, and exits once RSI reaches the exit level.
+type rsiReversion struct {
+	Base
+	period               int
+	oversold, overbought float64
+	exit, units          float64
+	slPct, tpPct         float64
+}
+
+func newRSIReversion(p Params) (Strategy, error) {
+	s := &rsiReversion{
+		period:     p.Int("period", 14),
+		oversold:   p.Float("oversold", 30),
+		overbought: p.Float("overbought", 70),
+		exit:       p.Float("exit", 50),
+		units:      p.Float("units", 1000),
+		slPct:      p.Float("stop_loss_pct", 0),
+		tpPct:      p.Float("take_profit_pct", 0),
+	}
+	if s.period <= 1 {
+		return nil, fmt.Errorf("rsi_reversion: period must be above 1")
+	}
+	if !(0 < s.oversold && s.oversold < s.exit && s.exit < s.overbought && s.overbought < 100) {
+		return nil, fmt.Errorf("rsi_reversion: need 0 < oversold < exit < overbought < 100")
+	}
+	if s.units <= 0 {
+		return nil, fmt.Errorf("rsi_reversion: units must be positive")
+	}
+	return s, nil
+}
+
+func (s *rsiReversion) OnCandle(ctx Context, c Candle) error {
+	rsi := ctx.Indicator(c.Instrument, "RSI", s.period)
+	n := len(rsi)
+	if n < 2 || math.IsNaN(rsi[n-2]) || math.IsNaN(rsi[n-1]) {
+		return nil
+	}
+	prev, cur := rsi[n-2], rsi[n-1]
+	pos := ctx.Position(c.Instrument).Units
+	switch {
+	case pos > 0 && cur >= s.exit, pos < 0 && cur <= s.exit:
+		return ClosePosition(ctx, c.Instrument, fmt.Sprintf("RSI %.1f reached exit %.0f", cur, s.exit))
+	case pos == 0 && prev < s.oversold && cur >= s.oversold:
+		sl, tp := brackets(c.Close, 1, s.slPct, s.tpPct)
+		return ctx.Submit(OrderRequest{Instrument: c.Instrument, Units: s.units, StopLoss: sl, TakeProfit: tp,
+			Reason: fmt.Sprintf("RSI %.1f left oversold", cur)})
+	case pos == 0 && prev > s.overbought && cur <= s.overbought:
+		sl, tp := brackets(c.Close, -1, s.slPct, s.tpPct)
+		return ctx.Submit(OrderRequest{Instrument: c.Instrument, Units: -s.units, StopLoss: sl, TakeProfit: tp,
+			Reason: fmt.Sprintf("RSI %.1f left overbought", cur)})
+	}
+	return nil
+}
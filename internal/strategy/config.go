@@ -0,0 +1,55 @@
+package strategy
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/jedi116/go-trader/internal/config"
+	"github.com/jedi116/go-trader/pkg/models"
+)
+
+// FromConfig builds the configured instances. Instances default to the section's execution
+// mode, which itself defaults to recommendations, and to the H1 timeframe.
+func FromConfig(cfg config.StrategiesConfig) ([]*Instance, error) {
+	defaultExec := strings.ToLower(cfg.Execution)
+	if defaultExec == "" {
+		defaultExec = ExecRecommendation
+	}
+	var out []*Instance
+	for _, c := range cfg.Instances {
+		if c.Name == "" {
+			return nil, fmt.Errorf("strategy: instance without a name")
+		}
+		in := &Instance{Name: c.Name, Type: c.Type, Timeframe: strings.ToUpper(c.Timeframe), Execution: strings.ToLower(c.Execution), Params: Params(c.Params), enabled: c.Enabled}
+		if in.Params == nil {
+			in.Params = Params{}
+		}
+		if in.Timeframe == "" {
+			in.Timeframe = "H1"
+		}
+		if _, ok := models.Granularity(in.Timeframe); !ok {
+			return nil, fmt.Errorf("strategy %s: unsupported timeframe %q", c.Name, c.Timeframe)
+		}
+		if in.Execution == "" {
+			in.Execution = defaultExec
+		}
+		if in.Execution != ExecRecommendation && in.Execution != ExecOrder {
+			return nil, fmt.Errorf("strategy %s: execution must be %q or %q", c.Name, ExecRecommendation, ExecOrder)
+		}
+		for _, inst := range c.Instruments {
+			if inst = strings.ToUpper(strings.TrimSpace(inst)); inst != "" {
+				in.Instruments = append(in.Instruments, inst)
+			}
+		}
+		if len(in.Instruments) == 0 {
+			return nil, fmt.Errorf("strategy %s: no instruments", c.Name)
+		}
+		s, err := New(c.Type, in.Params)
+		if err != nil {
+			return nil, fmt.Errorf("strategy %s: %w", c.Name, err)
+		}
+		in.Strategy = s
+		out = append(out, in)
+	}
+	return out, nil
+}
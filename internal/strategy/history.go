@@ -0,0 +1,99 @@
+package strategy
+
+import (
+	"sort"
+	"strconv"
+	"time"
+
+	"github.com/jedi116/go-trader/internal/broker"
+	"github.com/jedi116/go-trader/internal/indicators"
+)
+
+// DefaultHistory is how many closed candles are kept per instrument and timeframe.
+const DefaultHistory = 500
+
+// History keeps the most recent closed candles per instrument and timeframe. It is not safe
+// for concurrent use.
+type History struct {
+	max  int
+	bars map[string][]Candle
+}
+
+func NewHistory(max int) *History {
+	if max <= 0 {
+		max = DefaultHistory
+	}
+	return &History{max: max, bars: make(map[string][]Candle)}
+}
+
+func historyKey(instrument, tf string) string { return instrument + "|" + tf }
+
+// Add appends c if it is newer than the last candle held and reports whether it did.
+func (h *History) Add(c Candle) bool {
+	key := historyKey(c.Instrument, c.Timeframe)
+	bars := h.bars[key]
+	if n := len(bars); n > 0 && !c.Time.After(bars[n-1].Time) {
+		return false
+	}
+	bars = append(bars, c)
+	if len(bars) > h.max {
+		bars = append(bars[:0:0], bars[len(bars)-h.max:]...)
+	}
+	h.bars[key] = bars
+	return true
+}
+
+// Candles returns the held candles, oldest first. The slice must not be modified.
+func (h *History) Candles(instrument, tf string) []Candle {
+	return h.bars[historyKey(instrument, tf)]
+}
+
+// Last returns the newest candle held.
+func (h *History) Last(instrument, tf string) (Candle, bool) {
+	bars := h.bars[historyKey(instrument, tf)]
+	if len(bars) == 0 {
+		return Candle{}, false
+	}
+	return bars[len(bars)-1], true
+}
+
+// Until returns the held candles up to and including t; a zero t returns them all.
+func (h *History) Until(instrument, tf string, t time.Time) []Candle {
+	bars := h.Candles(instrument, tf)
+	if t.IsZero() {
+		return bars
+	}
+	return bars[:sort.Search(len(bars), func(i int) bool { return bars[i].Time.After(t) })]
+}
+
+// Indicator computes an indicator over the closes of bars; unknown names give an all-NaN series.
+func Indicator(bars []Candle, name string, period int) []float64 {
+	closes := make([]float64, len(bars))
+	for i, c := range bars {
+		closes[i] = c.Close
+	}
+	values, err := indicators.Compute(name, closes, period)
+	if err != nil {
+		return indicators.SMA(closes, 0)
+	}
+	return values
+}
+
+// FromBroker converts OANDA candles, skipping the incomplete current bar and unparsable ones.
+func FromBroker(instrument, tf string, in []broker.Candle) []Candle {
+	out := make([]Candle, 0, len(in))
+	for _, c := range in {
+		if !c.Complete {
+			continue
+		}
+		o, err1 := strconv.ParseFloat(c.Mid.Open, 64)
+		hi, err2 := strconv.ParseFloat(c.Mid.High, 64)
+		lo, err3 := strconv.ParseFloat(c.Mid.Low, 64)
+		cl, err4 := strconv.ParseFloat(c.Mid.Close, 64)
+		if err1 != nil || err2 != nil || err3 != nil || err4 != nil {
+			continue
+		}
+		out = append(out, Candle{Instrument: instrument, Timeframe: tf, Time: c.Time, Open: o, High: hi, Low: lo, Close: cl, Volume: c.Volume})
+	}
+	return out
+}
@@ -0,0 +1,489 @@
+package strategy
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/jedi116/go-trader/internal/broker"
+	"github.com/jedi116/go-trader/pkg/models"
+)
+
+// Execution modes: strategy orders become pending recommendations or are traded directly.
+const (
+	ExecRecommendation = "recommendation"
+	ExecOrder          = "order"
+)
+
+// Broker is the market data the runtime follows; *broker.OandaMT4Client implements it.
+type Broker interface {
+	StreamPrices(ctx context.Context, instruments []string, fn func(broker.Price) error) error
+	GetCandles(instrument, granularity string, count int, from, to *time.Time) (*broker.CandlesResponse, error)
+	GetPositions() ([]broker.Position, error)
+}
+
+// Executor carries out strategy orders according to the instance's execution mode. It returns
+// nil without error when the order did not fill immediately, e.g. when it was stored as a
+// recommendation.
+type Executor interface {
+	Execute(ctx context.Context, in *Instance, o OrderRequest) (*Fill, error)
+}
+
+// Instance is one configured strategy.
+type Instance struct {
+	Name        string
+	Type        string
+	Instruments []string
+	Timeframe   string
+	Execution   string
+	Params      Params
+	Strategy    Strategy
+
+	mu          sync.Mutex
+	enabled     bool
+	orders      int
+	fills       int
+	lastError   string
+	lastErrorAt time.Time
+
+	// Guarded by the runtime's eventMu. busy is set while the instance's orders are executing;
+	// candles that close meanwhile wait in deferred.
+	busy     bool
+	deferred []Candle
+}
+
+// Status is an instance's configuration and counters as reported by the API.
+type Status struct {
+	Name        string     `json:"name"`
+	Type        string     `json:"type"`
+	Enabled     bool       `json:"enabled"`
+	Instruments []string   `json:"instruments"`
+	Timeframe   string     `json:"timeframe"`
+	Execution   string     `json:"execution"`
+	Params      Params     `json:"params,omitempty"`
+	Orders      int        `json:"orders"`
+	Fills       int        `json:"fills"`
+	LastError   string     `json:"last_error,omitempty"`
+	LastErrorAt *time.Time `json:"last_error_at,omitempty"`
+}
+
+func (in *Instance) Enabled() bool {
+	in.mu.Lock()
+	defer in.mu.Unlock()
+	return in.enabled
+}
+
+func (in *Instance) Status() Status {
+	in.mu.Lock()
+	defer in.mu.Unlock()
+	st := Status{Name: in.Name, Type: in.Type, Enabled: in.enabled, Instruments: in.Instruments, Timeframe: in.Timeframe,
+		Execution: in.Execution, Params: in.Params, Orders: in.orders, Fills: in.fills, LastError: in.lastError}
+	if !in.lastErrorAt.IsZero() {
+		t := in.lastErrorAt
+		st.LastErrorAt = &t
+	}
+	return st
+}
+
+func (in *Instance) fail(err error) {
+	in.mu.Lock()
+	in.lastError, in.lastErrorAt = err.Error(), time.Now().UTC()
+	in.mu.Unlock()
+	log.Printf("[STRATEGY] %s: %v", in.Name, err)
+}
+
+func (in *Instance) trades(instrument string) bool {
+	for _, inst := range in.Instruments {
+		if inst == instrument {
+			return true
+		}
+	}
+	return false
+}
+
+func (in *Instance) count(orders, fills int) {
+	in.mu.Lock()
+	in.orders += orders
+	in.fills += fills
+	in.mu.Unlock()
+}
+
+// ErrUnknownStrategy is returned for instance names that are not configured.
+var ErrUnknownStrategy = errors.New("strategy: unknown instance")
+
+// maxFillRounds bounds how many times orders submitted from OnFill are executed in one event,
+// so a strategy that answers every fill with another order cannot loop forever.
+const maxFillRounds = 5
+
+// Runtime dispatches events to the enabled instances: quotes from the pricing stream as they
+// arrive, and candles as PollCandles finds them closed. History loaded on the first check is
+// warm-up and is not replayed to strategies.
+type Runtime struct {
+	broker      Broker
+	exec        Executor
+	byName      map[string]*Instance
+	list        []*Instance
+	instruments []string
+
+	// eventMu serializes handlers; everything below it, and each instance's busy state, is only
+	// touched while it is held. Orders are sent to the broker with it released.
+	eventMu sync.Mutex
+	history *History
+	prices  map[string]Tick
+	// positions is loaded on first use and kept up to date by fills until the next PollCandles.
+	positions map[string]Position
+}
+
+// NewRuntime builds a runtime; instance names must be unique.
+func NewRuntime(b Broker, exec Executor, instances []*Instance) (*Runtime, error) {
+	r := &Runtime{broker: b, exec: exec, byName: make(map[string]*Instance, len(instances)), history: NewHistory(DefaultHistory), prices: make(map[string]Tick)}
+	seen := make(map[string]bool)
+	for _, in := range instances {
+		if _, dup := r.byName[in.Name]; dup {
+			return nil, fmt.Errorf("strategy: duplicate instance name %q", in.Name)
+		}
+		r.byName[in.Name] = in
+		r.list = append(r.list, in)
+		// Disabled instances may be switched on later, so their instruments are streamed too.
+		for _, inst := range in.Instruments {
+			if !seen[inst] {
+				seen[inst] = true
+				r.instruments = append(r.instruments, inst)
+			}
+		}
+	}
+	return r, nil
+}
+
+// Instances reports every configured instance in config order.
+func (r *Runtime) Instances() []Status {
+	out := make([]Status, 0, len(r.list))
+	for _, in := range r.list {
+		out = append(out, in.Status())
+	}
+	return out
+}
+
+// Instance reports one instance.
+func (r *Runtime) Instance(name string) (Status, error) {
+	in, ok := r.byName[name]
+	if !ok {
+		return Status{}, ErrUnknownStrategy
+	}
+	return in.Status(), nil
+}
+
+// SetEnabled switches an instance on or off until restart; config decides the initial state.
+func (r *Runtime) SetEnabled(name string, enabled bool) (Status, error) {
+	in, ok := r.byName[name]
+	if !ok {
+		return Status{}, ErrUnknownStrategy
+	}
+	in.mu.Lock()
+	in.enabled = enabled
+	in.mu.Unlock()
+	log.Printf("[STRATEGY] %s enabled=%t", name, enabled)
+	return in.Status(), nil
+}
+
+func (r *Runtime) active() []*Instance {
+	var out []*Instance
+	for _, in := range r.list {
+		if in.Enabled() {
+			out = append(out, in)
+		}
+	}
+	return out
+}
+
+// Stream follows the pricing stream until ctx is done, reconnecting after one second up to a
+// minute between attempts; a connection that delivered prices resets the wait.
+func (r *Runtime) Stream(ctx context.Context) {
+	if len(r.instruments) == 0 {
+		return
+	}
+	wait := time.Second
+	for {
+		delivered := false
+		err := r.broker.StreamPrices(ctx, r.instruments, func(p broker.Price) error {
+			if t, ok := toTick(p); ok {
+				delivered = true
+				r.onPrice(ctx, t)
+			}
+			return ctx.Err()
+		})
+		if ctx.Err() != nil {
+			return
+		}
+		if delivered {
+			wait = time.Second
+		}
+		log.Printf("[STRATEGY] pricing stream: %v (reconnecting in %s)", err, wait)
+		select {
+		case <-ctx.Done():
+			return
+		case <-time.After(wait):
+		}
+		wait = min(2*wait, time.Minute)
+	}
+}
+
+// onPrice delivers a streamed quote to every enabled instance trading its instrument, except
+// those whose orders are still executing: they see the latest quote through Price at their next
+// event.
+func (r *Runtime) onPrice(ctx context.Context, t Tick) {
+	r.eventMu.Lock()
+	r.prices[t.Instrument] = t
+	now := time.Now()
+	var batches []*eventContext
+	for _, in := range r.active() {
+		if in.trades(t.Instrument) && !in.busy {
+			batches = r.handle(batches, in, now, time.Time{}, func(ec *eventContext) error { return in.Strategy.OnTick(ec, t) })
+		}
+	}
+	r.eventMu.Unlock()
+	r.execute(ctx, batches)
+}
+
+// PollCandles delivers candles that closed since the last check to every enabled instance. It
+// also drops the cached positions, picking up trades made outside the strategies.
+func (r *Runtime) PollCandles(ctx context.Context) {
+	r.eventMu.Lock()
+	r.positions = nil
+	now := time.Now()
+	// Candle series shared by several instances are fetched once.
+	fresh := make(map[string][]Candle)
+	var batches []*eventContext
+	for _, in := range r.active() {
+		for _, inst := range in.Instruments {
+			key := historyKey(inst, in.Timeframe)
+			if _, done := fresh[key]; !done {
+				fresh[key] = r.closedCandles(inst, in.Timeframe, now)
+			}
+			for _, c := range fresh[key] {
+				if in.busy {
+					in.deferred = append(in.deferred, c)
+					continue
+				}
+				batches = r.handle(batches, in, c.Time, c.Time, func(ec *eventContext) error { return in.Strategy.OnCandle(ec, c) })
+			}
+		}
+	}
+	r.eventMu.Unlock()
+	r.execute(ctx, batches)
+}
+
+// closedCandles adds candles completed since the last check to the history and returns them.
+// Like the alert engine it only asks the broker once a new bar can have closed.
+func (r *Runtime) closedCandles(instrument, tf string, now time.Time) []Candle {
+	bar, _ := models.Granularity(tf)
+	last, warm := r.history.Last(instrument, tf)
+	if warm && now.Before(last.Time.Add(2*bar)) {
+		return nil
+	}
+	count := DefaultHistory
+	if warm {
+		// Enough to catch up after a few missed checks without refetching the full history.
+		count = 100
+	}
+	resp, err := r.broker.GetCandles(instrument, tf, count, nil, nil)
+	if err != nil {
+		log.Printf("[STRATEGY] candles %s %s: %v", instrument, tf, err)
+		return nil
+	}
+	var added []Candle
+	for _, c := range FromBroker(instrument, tf, resp.Candles) {
+		if r.history.Add(c) && warm {
+			added = append(added, c)
+		}
+	}
+	return added
+}
+
+// handle runs one handler with eventMu held. If it submitted orders, the instance is marked busy
+// and its context is appended to batches for execute. A non-zero asOf hides candles after it, so
+// catching up on several closed bars shows each one as the latest.
+func (r *Runtime) handle(batches []*eventContext, in *Instance, at, asOf time.Time, handler func(*eventContext) error) []*eventContext {
+	ec := &eventContext{r: r, in: in, now: at, asOf: asOf}
+	if err := ec.run(handler); err != nil {
+		in.fail(err)
+	}
+	if len(ec.pending) == 0 {
+		return batches
+	}
+	in.busy = true
+	return append(batches, ec)
+}
+
+// execute sends the queued orders of each batch without holding eventMu, so a slow broker does
+// not hold up other events. Fills are applied and delivered to OnFill under the lock, and
+// orders submitted from OnFill are executed in turn, up to maxFillRounds. Candles deferred
+// while the instance was busy are then handled the same way before it takes events again.
+func (r *Runtime) execute(ctx context.Context, batches []*eventContext) {
+	for _, ec := range batches {
+		in := ec.in
+		round := 0
+		for {
+			r.eventMu.Lock()
+			if len(ec.pending) > 0 && round == maxFillRounds {
+				in.fail(fmt.Errorf("dropped %d orders submitted after %d rounds of fills", len(ec.pending), maxFillRounds))
+				ec.pending = nil
+			}
+			if len(ec.pending) == 0 && len(in.deferred) > 0 {
+				c := in.deferred[0]
+				in.deferred = in.deferred[1:]
+				ec, round = &eventContext{r: r, in: in, now: c.Time, asOf: c.Time}, 0
+				if err := ec.run(func(ec *eventContext) error { return in.Strategy.OnCandle(ec, c) }); err != nil {
+					in.fail(err)
+				}
+				r.eventMu.Unlock()
+				continue
+			}
+			if len(ec.pending) == 0 {
+				in.busy = false
+				r.eventMu.Unlock()
+				break
+			}
+			orders := ec.pending
+			ec.pending = nil
+			r.eventMu.Unlock()
+			round++
+			for _, o := range orders {
+				in.count(1, 0)
+				fill, err := r.exec.Execute(ctx, in, o)
+				if err != nil {
+					in.fail(fmt.Errorf("order %s %.0f: %w", o.Instrument, o.Units, err))
+					continue
+				}
+				if fill == nil {
+					continue
+				}
+				in.count(0, 1)
+				f := *fill
+				r.eventMu.Lock()
+				r.applyFill(f)
+				if err := ec.run(func(ec *eventContext) error { return in.Strategy.OnFill(ec, f) }); err != nil {
+					in.fail(err)
+				}
+				r.eventMu.Unlock()
+			}
+		}
+	}
+}
+
+func (r *Runtime) loadPositions() {
+	if r.positions != nil {
+		return
+	}
+	r.positions = make(map[string]Position)
+	list, err := r.broker.GetPositions()
+	if err != nil {
+		log.Printf("[STRATEGY] load positions: %v", err)
+		return
+	}
+	for _, p := range list {
+		pos := Position{Instrument: p.Instrument, Units: p.Long.Units + p.Short.Units, UnrealizedPL: p.UnrealizedPL}
+		if p.Long.Units != 0 {
+			pos.AvgPrice = p.Long.AveragePrice
+		} else {
+			pos.AvgPrice = p.Short.AveragePrice
+		}
+		r.positions[p.Instrument] = pos
+	}
+}
+
+// applyFill updates the cached position so later handlers see the trade.
+func (r *Runtime) applyFill(f Fill) {
+	r.loadPositions()
+	pos := r.positions[f.Instrument]
+	pos.Instrument = f.Instrument
+	switch units := pos.Units + f.Units; {
+	case units == 0:
+		pos.AvgPrice = 0
+	case pos.Units == 0 || (pos.Units > 0) != (units > 0):
+		pos.AvgPrice = f.Price
+	case (pos.Units > 0) == (f.Units > 0):
+		pos.AvgPrice = (pos.AvgPrice*pos.Units + f.Price*f.Units) / units
+	}
+	pos.Units += f.Units
+	r.positions[f.Instrument] = pos
+}
+
+func toTick(p broker.Price) (Tick, bool) {
+	if len(p.Bids) == 0 || len(p.Asks) == 0 {
+		return Tick{}, false
+	}
+	bid, err1 := strconv.ParseFloat(p.Bids[0].Price, 64)
+	ask, err2 := strconv.ParseFloat(p.Asks[0].Price, 64)
+	if err1 != nil || err2 != nil || bid <= 0 || ask <= 0 {
+		return Tick{}, false
+	}
+	return Tick{Instrument: p.Instrument, Time: p.Time, Bid: bid, Ask: ask}, true
+}
+
+// eventContext is the Context handed to one handler call.
+type eventContext struct {
+	r       *Runtime
+	in      *Instance
+	now     time.Time
+	asOf    time.Time
+	pending []OrderRequest
+}
+
+// run calls handler, turning a panic into an error so one broken strategy cannot stop the rest.
+func (ec *eventContext) run(handler func(*eventContext) error) (err error) {
+	defer func() {
+		if p := recover(); p != nil {
+			err = fmt.Errorf("panic: %v", p)
+		}
+	}()
+	return handler(ec)
+}
+
+func (ec *eventContext) Now() time.Time { return ec.now }
+func (ec *eventContext) Name() string   { return ec.in.Name }
+func (ec *eventContext) Params() Params { return ec.in.Params }
+
+func (ec *eventContext) Candles(instrument string) []Candle {
+	return ec.r.history.Until(instrument, ec.in.Timeframe, ec.asOf)
+}
+
+func (ec *eventContext) Indicator(instrument, name string, period int) []float64 {
+	return Indicator(ec.Candles(instrument), name, period)
+}
+
+func (ec *eventContext) Position(instrument string) Position {
+	ec.r.loadPositions()
+	pos := ec.r.positions[instrument]
+	pos.Instrument = instrument
+	return pos
+}
+
+func (ec *eventContext) Price(instrument string) (Tick, bool) {
+	t, ok := ec.r.prices[instrument]
+	return t, ok
+}
+
+func (ec *eventContext) Submit(o OrderRequest) error {
+	return submit(ec.in, &ec.pending, o)
+}
+
+func (ec *eventContext) Logf(format string, args ...interface{}) {
+	log.Printf("[STRATEGY] %s: %s", ec.in.Name, fmt.Sprintf(format, args...))
+}
+
+// submit validates an order against the instance and queues it.
+func submit(in *Instance, pending *[]OrderRequest, o OrderRequest) error {
+	if o.Units == 0 {
+		return fmt.Errorf("strategy: order needs non-zero units")
+	}
+	if !in.trades(o.Instrument) {
+		return fmt.Errorf("strategy: %s does not trade %s", in.Name, o.Instrument)
+	}
+	*pending = append(*pending, o)
+	return nil
+}
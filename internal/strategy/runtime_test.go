@@ -0,0 +1,142 @@
+package strategy
+
+import (
+	"context"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/jedi116/go-trader/internal/broker"
+)
+
+// stubBroker serves candles from a slice the test grows; it has no positions.
+type stubBroker struct {
+	mu      sync.Mutex
+	candles []broker.Candle
+}
+
+func (b *stubBroker) StreamPrices(ctx context.Context, instruments []string, fn func(broker.Price) error) error {
+	<-ctx.Done()
+	return ctx.Err()
+}
+
+func (b *stubBroker) GetCandles(instrument, granularity string, count int, from, to *time.Time) (*broker.CandlesResponse, error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return &broker.CandlesResponse{Candles: append([]broker.Candle(nil), b.candles...)}, nil
+}
+
+func (b *stubBroker) GetPositions() ([]broker.Position, error) { return nil, nil }
+
+func (b *stubBroker) addCandle(t time.Time) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.candles = append(b.candles, broker.Candle{Complete: true, Time: t, Mid: broker.OHLC{Open: "1.1", High: "1.1", Low: "1.1", Close: "1.1"}})
+}
+
+// gatedExecutor fills every order at 1.1 once release is closed, signalling started first.
+type gatedExecutor struct {
+	started chan struct{}
+	once    sync.Once
+	release chan struct{}
+}
+
+func (e *gatedExecutor) Execute(ctx context.Context, in *Instance, o OrderRequest) (*Fill, error) {
+	e.once.Do(func() { close(e.started) })
+	<-e.release
+	return &Fill{Instrument: o.Instrument, Units: o.Units, Price: 1.1}, nil
+}
+
+// counter counts its events and, when trading, buys on every tick and candle.
+type counter struct {
+	trade                 bool
+	ticks, candles, fills atomic.Int32
+}
+
+func (c *counter) OnTick(ctx Context, t Tick) error {
+	c.ticks.Add(1)
+	if c.trade {
+		return ctx.Submit(OrderRequest{Instrument: t.Instrument, Units: 1000})
+	}
+	return nil
+}
+
+func (c *counter) OnCandle(ctx Context, k Candle) error {
+	c.candles.Add(1)
+	if c.trade {
+		return ctx.Submit(OrderRequest{Instrument: k.Instrument, Units: 1000})
+	}
+	return nil
+}
+
+func (c *counter) OnFill(ctx Context, f Fill) error {
+	c.fills.Add(1)
+	return nil
+}
+
+// An instance waiting on the broker must not hold up events for the others; it skips quotes
+// meanwhile and gets the candles that closed once its orders are done.
+func TestRuntimeExecutesOutsideTheEventLock(t *testing.T) {
+	b := &stubBroker{}
+	start := time.Now().Add(-time.Hour).Truncate(time.Minute)
+	for i := 0; i < 10; i++ {
+		b.addCandle(start.Add(time.Duration(i) * time.Minute))
+	}
+	exec := &gatedExecutor{started: make(chan struct{}), release: make(chan struct{})}
+	trader, watcher := &counter{trade: true}, &counter{}
+	r, err := NewRuntime(b, exec, []*Instance{
+		{Name: "trader", Instruments: []string{"EUR_USD"}, Timeframe: "M1", Strategy: trader, enabled: true},
+		{Name: "watcher", Instruments: []string{"EUR_USD"}, Timeframe: "M1", Strategy: watcher, enabled: true},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	ctx := context.Background()
+	r.PollCandles(ctx) // warm-up only
+
+	tick := Tick{Instrument: "EUR_USD", Time: time.Now(), Bid: 1.1, Ask: 1.1002}
+	first := make(chan struct{})
+	go func() {
+		r.onPrice(ctx, tick)
+		close(first)
+	}()
+	<-exec.started
+
+	returned := make(chan struct{})
+	go func() {
+		r.onPrice(ctx, tick)
+		b.addCandle(start.Add(10 * time.Minute))
+		r.PollCandles(ctx)
+		close(returned)
+	}()
+	select {
+	case <-returned:
+	case <-time.After(time.Second):
+		close(exec.release)
+		t.Fatal("events waited for the pending order")
+	}
+	if got := trader.candles.Load(); got != 0 {
+		t.Errorf("busy instance handled %d candles before its order filled", got)
+	}
+	close(exec.release)
+	<-first
+
+	tests := []struct {
+		name                  string
+		c                     *counter
+		ticks, candles, fills int32
+	}{
+		{"trader", trader, 1, 1, 2},
+		{"watcher", watcher, 2, 1, 0},
+	}
+	for _, tt := range tests {
+		if tt.c.ticks.Load() != tt.ticks || tt.c.candles.Load() != tt.candles || tt.c.fills.Load() != tt.fills {
+			t.Errorf("%s: %d ticks, %d candles, %d fills; want %d, %d, %d", tt.name,
+				tt.c.ticks.Load(), tt.c.candles.Load(), tt.c.fills.Load(), tt.ticks, tt.candles, tt.fills)
+		}
+	}
+	if st, _ := r.Instance("trader"); st.Orders != 2 || st.Fills != 2 {
+		t.Errorf("trader status %+v", st)
+	}
+}
@@ -0,0 +1,180 @@
+// Package strategy runs rule-based trading strategies written in Go. A Strategy reacts to closed
+// candles, price ticks and its own fills through a Context that exposes history, indicators,
+// positions and an order API; the Runtime feeds registered strategies from the broker.
+package strategy
+
+import (
+	"fmt"
+	"sort"
+	"strconv"
+	"sync"
+	"time"
+)
+
+// Candle is a completed mid-price bar.
+type Candle struct {
+	Instrument string    `json:"instrument"`
+	Timeframe  string    `json:"timeframe"`
+	Time       time.Time `json:"time"`
+	Open       float64   `json:"open"`
+	High       float64   `json:"high"`
+	Low        float64   `json:"low"`
+	Close      float64   `json:"close"`
+	Volume     int       `json:"volume"`
+}
+
+// Tick is a top-of-book quote.
+type Tick struct {
+	Instrument string    `json:"instrument"`
+	Time       time.Time `json:"time"`
+	Bid        float64   `json:"bid"`
+	Ask        float64   `json:"ask"`
+}
+
+func (t Tick) Mid() float64 { return (t.Bid + t.Ask) / 2 }
+
+// Fill reports an executed order back to the strategy that submitted it.
+type Fill struct {
+	OrderID    string    `json:"order_id"`
+	Instrument string    `json:"instrument"`
+	Units      float64   `json:"units"`
+	Price      float64   `json:"price"`
+	Time       time.Time `json:"time"`
+	Reason     string    `json:"reason,omitempty"`
+}
+
+// Position is the account's net position in an instrument; Units is negative when short.
+type Position struct {
+	Instrument   string  `json:"instrument"`
+	Units        float64 `json:"units"`
+	AvgPrice     float64 `json:"avg_price"`
+	UnrealizedPL float64 `json:"unrealized_pl"`
+}
+
+// OrderRequest is a market order from a strategy. Units are signed: positive buys, negative sells.
+type OrderRequest struct {
+	Instrument string   `json:"instrument"`
+	Units      float64  `json:"units"`
+	StopLoss   *float64 `json:"stop_loss,omitempty"`
+	TakeProfit *float64 `json:"take_profit,omitempty"`
+	Reason     string   `json:"reason,omitempty"`
+}
+
+// Context is a strategy's view of the market during one event. Orders passed to Submit are
+// executed after the handler returns; executions come back through OnFill.
+type Context interface {
+	Now() time.Time
+	// Name is the configured instance name.
+	Name() string
+	Params() Params
+	// Candles returns the closed candles of the instance timeframe, oldest first.
+	Candles(instrument string) []Candle
+	// Indicator computes SMA, EMA or RSI over the closes, aligned with Candles; values before
+	// warm-up are NaN.
+	Indicator(instrument, name string, period int) []float64
+	Position(instrument string) Position
+	// Price is the latest quote, false before the first one.
+	Price(instrument string) (Tick, bool)
+	Submit(o OrderRequest) error
+	Logf(format string, args ...interface{})
+}
+
+// Strategy reacts to market events. Handlers run one at a time per instance.
+type Strategy interface {
+	OnCandle(ctx Context, c Candle) error
+	OnTick(ctx Context, t Tick) error
+	OnFill(ctx Context, f Fill) error
+}
+
+// Base provides no-op handlers to embed in strategies that only need some of them.
+type Base struct{}
+
+func (Base) OnCandle(Context, Candle) error { return nil }
+func (Base) OnTick(Context, Tick) error     { return nil }
+func (Base) OnFill(Context, Fill) error     { return nil }
+
+// ClosePosition submits the order that flattens the current position, if any.
+func ClosePosition(ctx Context, instrument, reason string) error {
+	units := ctx.Position(instrument).Units
+	if units == 0 {
+		return nil
+	}
+	return ctx.Submit(OrderRequest{Instrument: instrument, Units: -units, Reason: reason})
+}
+
+// Params are the per-instance settings from config. Numbers may arrive as ints, floats or
+// strings depending on how the YAML was written, so read them through the helpers.
+type Params map[string]interface{}
+
+func (p Params) Float(key string, def float64) float64 {
+	switch v := p[key].(type) {
+	case float64:
+		return v
+	case float32:
+		return float64(v)
+	case int:
+		return float64(v)
+	case int64:
+		return float64(v)
+	case string:
+		if f, err := strconv.ParseFloat(v, 64); err == nil {
+			return f
+		}
+	}
+	return def
+}
+
+func (p Params) Int(key string, def int) int {
+	if _, ok := p[key]; !ok {
+		return def
+	}
+	return int(p.Float(key, float64(def)))
+}
+
+func (p Params) String(key, def string) string {
+	if v, ok := p[key]; ok && v != nil {
+		return fmt.Sprint(v)
+	}
+	return def
+}
+
+// Factory builds a strategy from its parameters, rejecting invalid ones.
+type Factory func(p Params) (Strategy, error)
+
+var (
+	registryMu sync.RWMutex
+	registry   = make(map[string]Factory)
+)
+
+// Register makes a strategy type available to config; it panics on duplicates.
+func Register(name string, f Factory) {
+	registryMu.Lock()
+	defer registryMu.Unlock()
+	if _, dup := registry[name]; dup {
+		panic("strategy: duplicate type " + name)
+	}
+	registry[name] = f
+}
+
+// New builds a registered strategy type.
+func New(name string, p Params) (Strategy, error) {
+	registryMu.RLock()
+	f, ok := registry[name]
+	registryMu.RUnlock()
+	if !ok {
+		return nil, fmt.Errorf("strategy: unknown type %q", name)
+	}
+	return f(p)
+}
+
+// Types lists the registered strategy types.
+func Types() []string {
+	registryMu.RLock()
+	defer registryMu.RUnlock()
+	out := make([]string, 0, len(registry))
+	for name := range registry {
+		out = append(out, name)
+	}
+	sort.Strings(out)
+	return out
+}
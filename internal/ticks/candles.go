@@ -0,0 +1,210 @@
+package ticks
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/jedi116/go-trader/pkg/models"
+)
+
+// timeframes are the granularities built from ticks. Up to an hour OANDA aligns candles to the
+// clock, so these buckets line up with its candles; longer ones follow its daily alignment and
+// are left to the stored candles.
+var timeframes = map[string]time.Duration{
+	"M1": time.Minute, "M2": 2 * time.Minute, "M4": 4 * time.Minute, "M5": 5 * time.Minute,
+	"M10": 10 * time.Minute, "M15": 15 * time.Minute, "M30": 30 * time.Minute, "H1": time.Hour,
+}
+
+// Timeframe returns the candle length of a granularity that can be built from ticks.
+func Timeframe(tf string) (time.Duration, error) {
+	if d, ok := timeframes[strings.ToUpper(tf)]; ok {
+		return d, nil
+	}
+	return 0, fmt.Errorf("timeframe %q cannot be built from ticks; use M1 to H1", tf)
+}
+
+type candleKey struct {
+	instrument string
+	timeframe  string
+}
+
+type openCandle struct {
+	models.MarketData
+	end time.Time
+}
+
+// Builder folds ticks into mid, bid and ask candles for a set of timeframes. Volume is the tick
+// count, as in OANDA candles. It expects each instrument's ticks in time order and ignores ticks
+// older than the candle being built.
+type Builder struct {
+	timeframes []string
+	lengths    []time.Duration
+	open       map[candleKey]*openCandle
+}
+
+// NewBuilder builds the given timeframes; none means M1.
+func NewBuilder(tfs []string) (*Builder, error) {
+	if len(tfs) == 0 {
+		tfs = []string{"M1"}
+	}
+	b := &Builder{open: make(map[candleKey]*openCandle)}
+	for _, tf := range tfs {
+		d, err := Timeframe(tf)
+		if err != nil {
+			return nil, err
+		}
+		b.timeframes = append(b.timeframes, strings.ToUpper(tf))
+		b.lengths = append(b.lengths, d)
+	}
+	return b, nil
+}
+
+// Add folds a tick into its candles and returns the candles it completes: those of the
+// instrument whose period ended before the tick.
+func (b *Builder) Add(t models.Tick) []models.MarketData {
+	var done []models.MarketData
+	mid := roundPrice(t.Mid())
+	for i, tf := range b.timeframes {
+		key := candleKey{t.Instrument, tf}
+		c := b.open[key]
+		if c != nil && !t.Time.Before(c.end) {
+			done = append(done, c.MarketData)
+			c = nil
+		}
+		if c == nil {
+			start := t.Time.Truncate(b.lengths[i]).UTC()
+			vol := int64(0)
+			c = &openCandle{
+				MarketData: models.MarketData{
+					Instrument: t.Instrument, Timeframe: tf, Timestamp: start,
+					OpenPrice: mid, HighPrice: mid, LowPrice: mid,
+					Bid:    &models.OHLC{Open: t.Bid, High: t.Bid, Low: t.Bid},
+					Ask:    &models.OHLC{Open: t.Ask, High: t.Ask, Low: t.Ask},
+					Volume: &vol,
+				},
+				end: start.Add(b.lengths[i]),
+			}
+			b.open[key] = c
+		} else if t.Time.Before(c.Timestamp) {
+			continue
+		}
+		c.ClosePrice = mid
+		c.HighPrice = max(c.HighPrice, mid)
+		c.LowPrice = min(c.LowPrice, mid)
+		extend(c.Bid, t.Bid)
+		extend(c.Ask, t.Ask)
+		*c.Volume++
+	}
+	return done
+}
+
+// roundPrice drops the float noise of a computed mid beyond the eight decimals prices are
+// stored with.
+func roundPrice(p float64) float64 {
+	return math.Round(p*1e8) / 1e8
+}
+
+func extend(o *models.OHLC, price float64) {
+	o.Close = price
+	o.High = max(o.High, price)
+	o.Low = min(o.Low, price)
+}
+
+// Close removes and returns the candles whose period ended at or before now, oldest first, so
+// quiet instruments still complete their candles.
+func (b *Builder) Close(now time.Time) []models.MarketData {
+	var done []models.MarketData
+	for key, c := range b.open {
+		if !now.Before(c.end) {
+			done = append(done, c.MarketData)
+			delete(b.open, key)
+		}
+	}
+	sortCandles(done)
+	return done
+}
+
+// Open returns copies of the candles still being built.
+func (b *Builder) Open() []models.MarketData {
+	out := make([]models.MarketData, 0, len(b.open))
+	for _, c := range b.open {
+		md := c.MarketData
+		bid, ask, vol := *md.Bid, *md.Ask, *md.Volume
+		md.Bid, md.Ask, md.Volume = &bid, &ask, &vol
+		out = append(out, md)
+	}
+	sortCandles(out)
+	return out
+}
+
+func sortCandles(cs []models.MarketData) {
+	sort.Slice(cs, func(i, j int) bool {
+		if !cs[i].Timestamp.Equal(cs[j].Timestamp) {
+			return cs[i].Timestamp.Before(cs[j].Timestamp)
+		}
+		if cs[i].Instrument != cs[j].Instrument {
+			return cs[i].Instrument < cs[j].Instrument
+		}
+		return cs[i].Timeframe < cs[j].Timeframe
+	})
+}
+
+// endOfTime closes every open candle.
+var endOfTime = time.Date(9999, 1, 1, 0, 0, 0, 0, time.UTC)
+
+// BuildCandles turns ticks in time order into candles of one timeframe, including the last,
+// possibly partial, candle.
+func BuildCandles(ticks []models.Tick, timeframe string) ([]models.MarketData, error) {
+	b, err := NewBuilder([]string{timeframe})
+	if err != nil {
+		return nil, err
+	}
+	var out []models.MarketData
+	for _, t := range ticks {
+		out = append(out, b.Add(t)...)
+	}
+	return append(out, b.Close(endOfTime)...), nil
+}
+
+// TickReader reads stored ticks; database.Store implements it.
+type TickReader interface {
+	ListTicks(ctx context.Context, instrument string, from, to time.Time, limit int) ([]models.Tick, error)
+}
+
+// pageSize is how many ticks CandleStore reads per query.
+const pageSize = 100000
+
+// CandleStore serves candles built from stored ticks in place of market_data, so backtests can
+// replay the recorded bid and ask instead of a spread model. It satisfies backtest.Store.
+type CandleStore struct {
+	Ticks TickReader
+}
+
+// ListMarketDataRange builds the candles with from <= time < to from the ticks in that range,
+// reading them a page at a time.
+func (s CandleStore) ListMarketDataRange(ctx context.Context, instrument, timeframe string, from, to time.Time) ([]models.MarketData, error) {
+	b, err := NewBuilder([]string{timeframe})
+	if err != nil {
+		return nil, err
+	}
+	var out []models.MarketData
+	for {
+		page, err := s.Ticks.ListTicks(ctx, instrument, from, to, pageSize)
+		if err != nil {
+			return nil, err
+		}
+		for _, t := range page {
+			out = append(out, b.Add(t)...)
+		}
+		if len(page) < pageSize {
+			break
+		}
+		// Stored times have microsecond precision and are unique per instrument.
+		from = page[len(page)-1].Time.Add(time.Microsecond)
+	}
+	return append(out, b.Close(endOfTime)...), nil
+}
@@ -0,0 +1,89 @@
+package ticks
+
+import (
+	"math"
+	"strconv"
+	"time"
+
+	"github.com/jedi116/go-trader/internal/broker"
+	"github.com/jedi116/go-trader/pkg/models"
+)
+
+// CandleSource fetches OANDA candles; *broker.OandaMT4Client implements it.
+type CandleSource interface {
+	GetCandles(instrument, granularity string, count int, from, to *time.Time) (*broker.CandlesResponse, error)
+}
+
+// Comparison sets a candle built from ticks against OANDA's mid candle for the same period.
+type Comparison struct {
+	Instrument string      `json:"instrument"`
+	Timeframe  string      `json:"timeframe"`
+	Time       time.Time   `json:"time"`
+	Built      models.OHLC `json:"built"`
+	BuiltTicks int64       `json:"built_ticks"`
+	// Missing is set when OANDA has no candle for the period; the remaining fields are then zero.
+	Missing     bool        `json:"missing,omitempty"`
+	OANDA       models.OHLC `json:"oanda"`
+	OANDATicks  int64       `json:"oanda_ticks"`
+	MaxDiffPips float64     `json:"max_diff_pips"`
+}
+
+// ComparisonStats accumulates the comparisons of one instrument and timeframe.
+type ComparisonStats struct {
+	Instrument   string      `json:"instrument"`
+	Timeframe    string      `json:"timeframe"`
+	Candles      int         `json:"candles"`
+	Missing      int         `json:"missing"`
+	MeanDiffPips float64     `json:"mean_diff_pips"`
+	MaxDiffPips  float64     `json:"max_diff_pips"`
+	Last         *Comparison `json:"last,omitempty"`
+}
+
+func (s *ComparisonStats) add(c Comparison) {
+	s.Last = &c
+	if c.Missing {
+		s.Missing++
+		return
+	}
+	s.MeanDiffPips = (s.MeanDiffPips*float64(s.Candles) + c.MaxDiffPips) / float64(s.Candles+1)
+	s.Candles++
+	s.MaxDiffPips = max(s.MaxDiffPips, c.MaxDiffPips)
+}
+
+// compare fetches OANDA's candle for built. It reports false when OANDA has not completed the
+// candle yet, so the caller can try again later.
+func compare(src CandleSource, built models.MarketData) (Comparison, bool, error) {
+	c := Comparison{
+		Instrument: built.Instrument,
+		Timeframe:  built.Timeframe,
+		Time:       built.Timestamp,
+		Built:      models.OHLC{Open: built.OpenPrice, High: built.HighPrice, Low: built.LowPrice, Close: built.ClosePrice},
+	}
+	if built.Volume != nil {
+		c.BuiltTicks = *built.Volume
+	}
+	from := built.Timestamp
+	resp, err := src.GetCandles(built.Instrument, built.Timeframe, 1, &from, nil)
+	if err != nil {
+		return c, false, err
+	}
+	if len(resp.Candles) == 0 || !resp.Candles[0].Time.Equal(built.Timestamp) {
+		c.Missing = true
+		return c, true, nil
+	}
+	oc := resp.Candles[0]
+	if !oc.Complete {
+		return c, false, nil
+	}
+	c.OANDA = models.OHLC{Open: parseFloat(oc.Mid.Open), High: parseFloat(oc.Mid.High), Low: parseFloat(oc.Mid.Low), Close: parseFloat(oc.Mid.Close)}
+	c.OANDATicks = int64(oc.Volume)
+	diff := math.Max(math.Max(math.Abs(c.Built.Open-c.OANDA.Open), math.Abs(c.Built.High-c.OANDA.High)),
+		math.Max(math.Abs(c.Built.Low-c.OANDA.Low), math.Abs(c.Built.Close-c.OANDA.Close)))
+	c.MaxDiffPips = math.Round(diff/models.PipSize(built.Instrument)*100) / 100
+	return c, true, nil
+}
+
+func parseFloat(s string) float64 {
+	f, _ := strconv.ParseFloat(s, 64)
+	return f
+}
@@ -0,0 +1,417 @@
+// Package ticks records bid/ask ticks from the OANDA pricing stream, builds candles from them as
+// they arrive and checks those candles against OANDA's own.
+package ticks
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/jedi116/go-trader/internal/broker"
+	"github.com/jedi116/go-trader/pkg/models"
+)
+
+// Streamer follows the pricing stream; *broker.OandaMT4Client implements it.
+type Streamer interface {
+	StreamPrices(ctx context.Context, instruments []string, fn func(broker.Price) error) error
+}
+
+// Store persists ticks by day; database.Store implements it.
+type Store interface {
+	EnsureTickPartitions(ctx context.Context, from, to time.Time) error
+	InsertTicks(ctx context.Context, ticks []models.Tick) (int64, error)
+	DropTickPartitionsBefore(ctx context.Context, before time.Time) (int, error)
+}
+
+type Options struct {
+	Instruments []string
+	// Timeframes are built live from the ticks; none means M1.
+	Timeframes []string
+	// BatchSize is how many ticks are written per insert; zero means 500.
+	BatchSize int
+	// FlushInterval bounds how long ticks wait for a full batch; zero means one second.
+	FlushInterval time.Duration
+	// MaxBuffer bounds the ticks held while the store is failing, dropping the oldest; zero
+	// means 100000.
+	MaxBuffer int
+	// Retention is how long ticks are kept, rounded up to whole days; zero keeps them forever.
+	Retention time.Duration
+	// Compare fetches OANDA's candle for every built candle and records the difference.
+	Compare bool
+	// CompareDelay is how long after a candle ends OANDA's is fetched; zero means ten seconds.
+	CompareDelay time.Duration
+}
+
+// maintainEvery bounds how often partitions are created ahead and expired ones dropped.
+const maintainEvery = time.Hour
+
+// recentComparisons is how many comparisons Status keeps.
+const recentComparisons = 50
+
+// Status describes the recorder for monitoring.
+type Status struct {
+	Instruments []string `json:"instruments"`
+	Timeframes  []string `json:"timeframes"`
+	// Connected is set once the current connection has delivered a tick.
+	Connected  bool   `json:"connected"`
+	Reconnects int    `json:"reconnects"`
+	LastError  string `json:"last_error,omitempty"`
+	Received   int64  `json:"received"`
+	Stored     int64  `json:"stored"`
+	Dropped    int64  `json:"dropped"`
+	Buffered   int    `json:"buffered"`
+	// LastTicks holds the latest tick of each instrument.
+	LastTicks   map[string]models.Tick `json:"last_ticks"`
+	OpenCandles []models.MarketData    `json:"open_candles"`
+	Comparisons []ComparisonStats      `json:"comparisons"`
+	Recent      []Comparison           `json:"recent_comparisons"`
+}
+
+type pendingCandle struct {
+	candle models.MarketData
+	due    time.Time
+}
+
+// Recorder streams ticks into the store in batches and builds candles from them.
+type Recorder struct {
+	stream  Streamer
+	candles CandleSource
+	store   Store
+	opts    Options
+
+	ticks   chan models.Tick
+	builder *Builder
+	// built carries completed candles from Run to compareLoop.
+	built chan models.MarketData
+
+	// Owned by Run.
+	buffer     []models.Tick
+	partitions map[string]bool
+	lastMaint  time.Time
+	// failing leaves retries to the flush ticker while the store is down.
+	failing bool
+
+	// Owned by compareLoop.
+	pending []pendingCandle
+
+	mu     sync.Mutex
+	status Status
+	stats  map[candleKey]*ComparisonStats
+	recent []Comparison
+	open   []models.MarketData
+}
+
+// New builds a recorder. candles may be nil, which turns comparison off.
+func New(stream Streamer, candles CandleSource, store Store, opts Options) (*Recorder, error) {
+	if len(opts.Instruments) == 0 {
+		return nil, fmt.Errorf("ticks: no instruments configured")
+	}
+	for i, inst := range opts.Instruments {
+		opts.Instruments[i] = strings.ToUpper(strings.TrimSpace(inst))
+	}
+	if opts.BatchSize <= 0 {
+		opts.BatchSize = 500
+	}
+	if opts.FlushInterval <= 0 {
+		opts.FlushInterval = time.Second
+	}
+	if opts.MaxBuffer <= 0 {
+		opts.MaxBuffer = 100000
+	}
+	if opts.CompareDelay <= 0 {
+		opts.CompareDelay = 10 * time.Second
+	}
+	if candles == nil {
+		opts.Compare = false
+	}
+	b, err := NewBuilder(opts.Timeframes)
+	if err != nil {
+		return nil, fmt.Errorf("ticks: %w", err)
+	}
+	return &Recorder{
+		stream:     stream,
+		candles:    candles,
+		store:      store,
+		opts:       opts,
+		ticks:      make(chan models.Tick, 4096),
+		builder:    b,
+		built:      make(chan models.MarketData, 1024),
+		partitions: make(map[string]bool),
+		status:     Status{Instruments: opts.Instruments, Timeframes: b.timeframes, LastTicks: make(map[string]models.Tick)},
+		stats:      make(map[candleKey]*ComparisonStats),
+	}, nil
+}
+
+// Run streams and records ticks until ctx is cancelled, reconnecting with backoff. Ticks still
+// buffered when it stops are written before it returns.
+func (r *Recorder) Run(ctx context.Context) {
+	go r.follow(ctx)
+	if r.opts.Compare {
+		go r.compareLoop(ctx)
+	}
+	ticker := time.NewTicker(r.opts.FlushInterval)
+	defer ticker.Stop()
+	r.maintain(ctx, time.Now())
+	for {
+		select {
+		case <-ctx.Done():
+			r.flush(context.Background())
+			return
+		case t := <-r.ticks:
+			r.add(t)
+			if len(r.buffer) >= r.opts.BatchSize && !r.failing {
+				r.flush(ctx)
+			}
+		case now := <-ticker.C:
+			r.flush(ctx)
+			r.completed(r.builder.Close(now))
+			if now.Sub(r.lastMaint) >= maintainEvery {
+				r.maintain(ctx, now)
+			}
+			r.mu.Lock()
+			r.open = r.builder.Open()
+			r.mu.Unlock()
+		}
+	}
+}
+
+// follow keeps the pricing stream connected, waiting from one second up to a minute between
+// attempts; a connection that delivered ticks resets the wait.
+func (r *Recorder) follow(ctx context.Context) {
+	wait := time.Second
+	for {
+		delivered := false
+		err := r.stream.StreamPrices(ctx, r.opts.Instruments, func(p broker.Price) error {
+			t, ok := tickFromPrice(p)
+			if !ok {
+				return nil
+			}
+			if !delivered {
+				delivered = true
+				r.mu.Lock()
+				r.status.Connected = true
+				r.mu.Unlock()
+			}
+			select {
+			case r.ticks <- t:
+			case <-ctx.Done():
+				return ctx.Err()
+			}
+			return nil
+		})
+		if ctx.Err() != nil {
+			return
+		}
+		if delivered {
+			wait = time.Second
+		}
+		log.Printf("[TICKS] pricing stream: %v (reconnecting in %s)", err, wait)
+		r.mu.Lock()
+		r.status.Connected = false
+		r.status.Reconnects++
+		r.status.LastError = err.Error()
+		r.mu.Unlock()
+		select {
+		case <-ctx.Done():
+			return
+		case <-time.After(wait):
+		}
+		wait = min(2*wait, time.Minute)
+	}
+}
+
+// tickFromPrice takes the top of book of a streamed price.
+func tickFromPrice(p broker.Price) (models.Tick, bool) {
+	if len(p.Bids) == 0 || len(p.Asks) == 0 {
+		return models.Tick{}, false
+	}
+	bid, err1 := strconv.ParseFloat(p.Bids[0].Price, 64)
+	ask, err2 := strconv.ParseFloat(p.Asks[0].Price, 64)
+	if err1 != nil || err2 != nil || bid <= 0 || ask <= 0 {
+		return models.Tick{}, false
+	}
+	return models.Tick{Instrument: p.Instrument, Time: p.Time.UTC(), Bid: bid, Ask: ask}, true
+}
+
+func (r *Recorder) add(t models.Tick) {
+	r.buffer = append(r.buffer, t)
+	r.completed(r.builder.Add(t))
+	r.mu.Lock()
+	r.status.Received++
+	r.status.LastTicks[t.Instrument] = t
+	r.mu.Unlock()
+}
+
+// flush writes the buffered ticks. On failure they stay buffered for the next attempt, up to
+// MaxBuffer.
+func (r *Recorder) flush(ctx context.Context) {
+	if len(r.buffer) == 0 {
+		return
+	}
+	n, err := r.write(ctx, r.buffer)
+	r.failing = err != nil
+	var dropped int
+	if err != nil {
+		log.Printf("[TICKS] store %d ticks: %v", len(r.buffer), err)
+		if over := len(r.buffer) - r.opts.MaxBuffer; over > 0 {
+			dropped = over
+			r.buffer = append(r.buffer[:0], r.buffer[over:]...)
+		}
+	} else {
+		r.buffer = r.buffer[:0]
+	}
+	r.mu.Lock()
+	r.status.Stored += n
+	r.status.Dropped += int64(dropped)
+	r.status.Buffered = len(r.buffer)
+	if err != nil {
+		r.status.LastError = err.Error()
+	}
+	r.mu.Unlock()
+}
+
+// write creates any missing daily partitions and inserts the ticks.
+func (r *Recorder) write(ctx context.Context, ticks []models.Tick) (int64, error) {
+	for _, t := range ticks {
+		day := t.Time.UTC().Format("2006-01-02")
+		if r.partitions[day] {
+			continue
+		}
+		if err := r.store.EnsureTickPartitions(ctx, t.Time, t.Time); err != nil {
+			return 0, err
+		}
+		r.partitions[day] = true
+	}
+	return r.store.InsertTicks(ctx, ticks)
+}
+
+// maintain creates today's and tomorrow's partitions and drops those past retention.
+func (r *Recorder) maintain(ctx context.Context, now time.Time) {
+	r.lastMaint = now
+	tomorrow := now.AddDate(0, 0, 1)
+	if err := r.store.EnsureTickPartitions(ctx, now, tomorrow); err != nil {
+		log.Printf("[TICKS] create partitions: %v", err)
+	} else {
+		r.partitions[now.UTC().Format("2006-01-02")] = true
+		r.partitions[tomorrow.UTC().Format("2006-01-02")] = true
+	}
+	if r.opts.Retention <= 0 {
+		return
+	}
+	cutoff := now.Add(-r.opts.Retention)
+	n, err := r.store.DropTickPartitionsBefore(ctx, cutoff)
+	if err != nil {
+		log.Printf("[TICKS] drop partitions: %v", err)
+		return
+	}
+	if n > 0 {
+		log.Printf("[TICKS] dropped %d daily partitions before %s", n, cutoff.UTC().Format("2006-01-02"))
+		for day := range r.partitions {
+			if d, _ := time.Parse("2006-01-02", day); d.AddDate(0, 0, 1).Before(cutoff) {
+				delete(r.partitions, day)
+			}
+		}
+	}
+}
+
+// completed hands finished candles to compareLoop. Should comparison fall a full queue behind,
+// candles are skipped rather than holding up the ticks.
+func (r *Recorder) completed(cs []models.MarketData) {
+	if !r.opts.Compare {
+		return
+	}
+	for _, c := range cs {
+		select {
+		case r.built <- c:
+		default:
+			log.Printf("[TICKS] comparison queue full, skipping %s %s %s", c.Instrument, c.Timeframe, c.Timestamp.Format(time.RFC3339))
+		}
+	}
+}
+
+// compareLoop queues the completed candles and fetches OANDA's once they are due, away from Run
+// so a slow candles request never delays recording.
+func (r *Recorder) compareLoop(ctx context.Context) {
+	ticker := time.NewTicker(r.opts.FlushInterval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case c := <-r.built:
+			d, _ := Timeframe(c.Timeframe)
+			r.pending = append(r.pending, pendingCandle{candle: c, due: c.Timestamp.Add(d + r.opts.CompareDelay)})
+		case now := <-ticker.C:
+			r.compareDue(ctx, now)
+		}
+	}
+}
+
+// compareDue compares the queued candles that are due. Candles OANDA has not completed, or that
+// failed to fetch, wait for the next round until they are an hour overdue.
+func (r *Recorder) compareDue(ctx context.Context, now time.Time) {
+	kept := r.pending[:0]
+	for _, p := range r.pending {
+		if now.Before(p.due) || ctx.Err() != nil {
+			kept = append(kept, p)
+			continue
+		}
+		c, ok, err := compare(r.candles, p.candle)
+		if err != nil {
+			log.Printf("[TICKS] fetch %s %s candle: %v", p.candle.Instrument, p.candle.Timeframe, err)
+		}
+		if !ok {
+			if now.Sub(p.due) < time.Hour {
+				kept = append(kept, p)
+			}
+			continue
+		}
+		r.record(c)
+	}
+	r.pending = kept
+}
+
+func (r *Recorder) record(c Comparison) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	key := candleKey{c.Instrument, c.Timeframe}
+	s := r.stats[key]
+	if s == nil {
+		s = &ComparisonStats{Instrument: c.Instrument, Timeframe: c.Timeframe}
+		r.stats[key] = s
+	}
+	s.add(c)
+	r.recent = append(r.recent, c)
+	if len(r.recent) > recentComparisons {
+		r.recent = r.recent[len(r.recent)-recentComparisons:]
+	}
+}
+
+// Status returns a copy of the recorder's counters, open candles and comparison results.
+func (r *Recorder) Status() Status {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	st := r.status
+	st.LastTicks = make(map[string]models.Tick, len(r.status.LastTicks))
+	for k, v := range r.status.LastTicks {
+		st.LastTicks[k] = v
+	}
+	st.OpenCandles = append([]models.MarketData{}, r.open...)
+	st.Comparisons = make([]ComparisonStats, 0, len(r.stats))
+	for _, s := range r.stats {
+		st.Comparisons = append(st.Comparisons, *s)
+	}
+	sort.Slice(st.Comparisons, func(i, j int) bool {
+		if st.Comparisons[i].Instrument != st.Comparisons[j].Instrument {
+			return st.Comparisons[i].Instrument < st.Comparisons[j].Instrument
+		}
+		return st.Comparisons[i].Timeframe < st.Comparisons[j].Timeframe
+	})
+	st.Recent = append([]Comparison{}, r.recent...)
+	return st
+}
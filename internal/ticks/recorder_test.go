@@ -0,0 +1,132 @@
+package ticks
+
+import (
+	"context"
+	"strconv"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/jedi116/go-trader/internal/broker"
+	"github.com/jedi116/go-trader/pkg/models"
+)
+
+// stubStreamer sends its first ticks, waits for next, then sends the last one.
+type stubStreamer struct {
+	first []models.Tick
+	next  chan struct{}
+	last  models.Tick
+}
+
+func price(t models.Tick) broker.Price {
+	return broker.Price{Instrument: t.Instrument, Time: t.Time,
+		Bids: []broker.Quote{{Price: strconv.FormatFloat(t.Bid, 'f', -1, 64)}}, Asks: []broker.Quote{{Price: strconv.FormatFloat(t.Ask, 'f', -1, 64)}}}
+}
+
+func (s *stubStreamer) StreamPrices(ctx context.Context, instruments []string, fn func(broker.Price) error) error {
+	for _, t := range s.first {
+		if err := fn(price(t)); err != nil {
+			return err
+		}
+	}
+	select {
+	case <-s.next:
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+	if err := fn(price(s.last)); err != nil {
+		return err
+	}
+	<-ctx.Done()
+	return ctx.Err()
+}
+
+// blockingCandles holds every request until release is closed.
+type blockingCandles struct {
+	called  chan struct{}
+	once    sync.Once
+	release chan struct{}
+}
+
+func (c *blockingCandles) GetCandles(instrument, granularity string, count int, from, to *time.Time) (*broker.CandlesResponse, error) {
+	c.once.Do(func() { close(c.called) })
+	<-c.release
+	return &broker.CandlesResponse{}, nil
+}
+
+type countingStore struct {
+	mu     sync.Mutex
+	stored int64
+}
+
+func (s *countingStore) EnsureTickPartitions(ctx context.Context, from, to time.Time) error {
+	return nil
+}
+
+func (s *countingStore) DropTickPartitionsBefore(ctx context.Context, before time.Time) (int, error) {
+	return 0, nil
+}
+
+func (s *countingStore) InsertTicks(ctx context.Context, ticks []models.Tick) (int64, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.stored += int64(len(ticks))
+	return int64(len(ticks)), nil
+}
+
+func (s *countingStore) count() int64 {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.stored
+}
+
+// waitFor polls cond until it holds or a second passes.
+func waitFor(cond func() bool) bool {
+	deadline := time.Now().Add(time.Second)
+	for !cond() {
+		if time.Now().After(deadline) {
+			return false
+		}
+		time.Sleep(5 * time.Millisecond)
+	}
+	return true
+}
+
+// A candles request that hangs must not hold up recording the ticks that follow.
+func TestRecorderComparesOffTheRecordingPath(t *testing.T) {
+	start := time.Now().Add(-10 * time.Minute).Truncate(time.Minute).UTC()
+	tick := func(offset time.Duration) models.Tick {
+		return models.Tick{Instrument: "EUR_USD", Time: start.Add(offset), Bid: 1.1, Ask: 1.1002}
+	}
+	candles := &blockingCandles{called: make(chan struct{}), release: make(chan struct{})}
+	// The second tick completes the first minute's candle, which is already due.
+	stream := &stubStreamer{first: []models.Tick{tick(10 * time.Second), tick(70 * time.Second)}, next: candles.called, last: tick(80 * time.Second)}
+	store := &countingStore{}
+	r, err := New(stream, candles, store, Options{Instruments: []string{"EUR_USD"}, FlushInterval: 10 * time.Millisecond, Compare: true, CompareDelay: time.Second})
+	if err != nil {
+		t.Fatal(err)
+	}
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan struct{})
+	go func() {
+		r.Run(ctx)
+		close(done)
+	}()
+	var release sync.Once
+	defer func() {
+		release.Do(func() { close(candles.release) })
+		cancel()
+		<-done
+	}()
+
+	if !waitFor(func() bool { return store.count() == 3 }) {
+		t.Fatalf("stored %d ticks while the candles request was pending, want 3", store.count())
+	}
+	release.Do(func() { close(candles.release) })
+	if !waitFor(func() bool { return len(r.Status().Recent) > 0 }) {
+		t.Fatalf("comparisons = %+v", r.Status().Recent)
+	}
+	if c := r.Status().Recent[0]; !c.Missing || !c.Time.Equal(start) || c.BuiltTicks != 1 {
+		t.Errorf("comparison = %+v", c)
+	}
+}
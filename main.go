@@ -5,12 +5,17 @@ import (
 	"log"
 	"net/http"
 	"os"
+	"os/signal"
+	"sort"
 	"strconv"
+	"strings"
+	"syscall"
 	"time"
 
 	"github.com/jedi116/go-trader/internal/ai"
 	"github.com/jedi116/go-trader/internal/api"
 	"github.com/jedi116/go-trader/internal/broker"
+	"github.com/jedi116/go-trader/internal/calendar"
 	"github.com/jedi116/go-trader/internal/config"
 	"github.com/jedi116/go-trader/internal/database"
 	"github.com/jedi116/go-trader/internal/news"
@@ -31,27 +36,51 @@ func main() {
 
 	oandaMT4Client := broker.NewOandaMT4Client(oandaAPIKey, oandaAccountID, false)
 	braveClient := news.NewBraveClient(braveAPIKey, braveBaseURL)
+	newsProviders := []news.NewsProvider{braveClient}
+	newsSources := []string{braveClient.Name()}
+	if len(cfg.News.RSS.Feeds) > 0 {
+		feeds := make([]news.Feed, 0, len(cfg.News.RSS.Feeds))
+		for _, f := range cfg.News.RSS.Feeds {
+			feeds = append(feeds, news.Feed{Name: f.Name, URL: f.URL})
+			newsSources = append(newsSources, "rss:"+f.URL)
+		}
+		newsProviders = append(newsProviders, news.NewRSSProvider(feeds, cfg.News.RSS.RefreshInterval))
+	}
+	newsProvider := news.NewFanOut(newsProviders...)
 
-	// Initialize database if configured
-	pg, err := database.NewPostgres(cfg)
+	// A configured database that cannot be opened stops startup; only an unconfigured one, or
+	// driver "memory", runs on the in-memory store.
+	store, err := database.OpenOrMemory(cfg)
 	if err != nil {
-		log.Printf("database init failed: %v (continuing without DB)", err)
-		pg = nil
+		log.Fatalf("[DB] %v", err)
+	}
+	// One meter records and budgets every model call, recommendations and news scoring alike.
+	aiMeter := ai.NewMeter(store, ai.PriceTableFromConfig(cfg.AI.Pricing), ai.Budget{DailyUSD: cfg.AI.Budget.DailyUSD, MonthlyUSD: cfg.AI.Budget.MonthlyUSD})
+
+	var newsScorer news.Scorer
+	if cfg.News.Scorer == "llm" {
+		newsScorer = ai.NewClaudeNewsScorer(http.DefaultClient, aiMeter)
 	}
+	newsPipeline := news.NewPipeline(newsProvider, newsScorer, news.PipelineOptions{
+		PerCurrency:  cfg.News.PerCurrency,
+		MaxAge:       cfg.News.MaxAge,
+		MinRelevance: cfg.News.MinRelevance,
+	})
 
 	// Wire AI service with real market/news aggregation and logging
+	const aiGranularity, aiCandleCount = "M5", 50
 	agg := ai.NewAggregator(
 		func(ctx context.Context, instruments []string) (*ai.MarketContext, error) {
 			start := time.Now()
-			log.Printf("[AI] Gathering market data for instruments=%v granularity=M5 count=50", instruments)
+			log.Printf("[AI] Gathering market data for instruments=%v granularity=%s count=%d", instruments, aiGranularity, aiCandleCount)
 			marketInfo := map[string]interface{}{"list": instruments}
 			for _, inst := range instruments {
-				candles, err := oandaMT4Client.GetCandles(inst, "M5", 50, nil, nil)
+				candles, err := oandaMT4Client.GetCandles(inst, aiGranularity, aiCandleCount, nil, nil)
 				if err != nil {
 					log.Printf("[AI] GetCandles error instrument=%s: %v", inst, err)
 					continue
 				}
-				if pg != nil && candles != nil {
+				if candles != nil {
 					rows := make([]models.MarketData, 0, len(candles.Candles))
 					for _, cdl := range candles.Candles {
 						rows = append(rows, models.MarketData{
@@ -66,7 +95,7 @@ func main() {
 							Timeframe:  candles.Granularity,
 						})
 					}
-					if err := pg.UpsertMarketData(ctx, rows); err != nil {
+					if err := store.UpsertMarketData(ctx, rows); err != nil {
 						log.Printf("[AI] UpsertMarketData error instrument=%s: %v", inst, err)
 					} else {
 						log.Printf("[AI] UpsertMarketData ok instrument=%s rows=%d", inst, len(rows))
@@ -87,15 +116,31 @@ func main() {
 		},
 		func(ctx context.Context, instruments []string) ([]ai.NewsItem, error) {
 			start := time.Now()
-			query := instruments[0] + " forex"
-			log.Printf("[AI] Fetching news via Brave query=%q", query)
-			items, err := braveClient.SearchNews(ctx, query, 5)
+			log.Printf("[AI] Fetching news for currencies=%v", models.CurrenciesFor(instruments))
+			items, err := newsPipeline.Gather(ctx, instruments)
 			if err != nil {
 				return nil, err
 			}
 			out := make([]ai.NewsItem, 0, len(items))
+			archive := make([]models.NewsArticle, 0, len(items))
 			for _, it := range items {
-				out = append(out, ai.NewsItem{Title: it.Title, Url: it.Url, Snippet: it.Snippet, Source: it.Source, Published: it.Published})
+				out = append(out, ai.NewsItem{Title: it.Title, Url: it.Url, Snippet: it.Snippet, Source: it.Source, Published: it.Published, Sentiment: it.Sentiment, Relevance: it.Relevance})
+				if it.Url == "" {
+					continue
+				}
+				var currencies []string
+				for code, rel := range it.Relevance {
+					if rel > 0 {
+						currencies = append(currencies, code)
+					}
+				}
+				sort.Strings(currencies)
+				archive = append(archive, models.NewsArticle{URL: it.Url, Title: it.Title, Snippet: it.Snippet, Source: it.Source,
+					PublishedAt: it.PublishedAt, Sentiment: it.Sentiment, Relevance: it.Relevance, Currencies: currencies})
+			}
+			// Archived news lets AI replays rebuild this context later.
+			if err := store.ArchiveNews(ctx, archive); err != nil {
+				log.Printf("[AI] ArchiveNews error: %v", err)
 			}
 			log.Printf("[AI] News fetched count=%d in %s", len(out), time.Since(start))
 			return out, nil
@@ -103,13 +148,74 @@ func main() {
 		func(ctx context.Context, instruments []string) (*ai.HistoricalContext, error) {
 			return &ai.HistoricalContext{Notes: "pending"}, nil
 		},
+		func(ctx context.Context, instruments []string) ([]ai.EconomicEvent, error) {
+			var currencies []string
+			for _, inst := range instruments {
+				currencies = append(currencies, models.InstrumentCurrencies(inst)...)
+			}
+			lookahead := cfg.Calendar.Lookahead
+			if lookahead <= 0 {
+				lookahead = 48 * time.Hour
+			}
+			now := time.Now()
+			events, err := store.ListEconomicEvents(ctx, now, now.Add(lookahead), currencies)
+			if err != nil {
+				return nil, err
+			}
+			out := make([]ai.EconomicEvent, 0, len(events))
+			for _, e := range events {
+				ev := ai.EconomicEvent{Title: e.Title, Currency: e.Currency, Impact: string(e.Impact), Time: e.EventTime}
+				if e.Forecast != nil {
+					ev.Forecast = *e.Forecast
+				}
+				if e.Previous != nil {
+					ev.Previous = *e.Previous
+				}
+				out = append(out, ev)
+			}
+			return out, nil
+		},
 	)
+	newsScorerName := cfg.News.Scorer
+	if newsScorerName == "" {
+		newsScorerName = "lexicon"
+	}
+	agg = ai.NewCachedAggregator(agg, store, ai.CacheOptions{
+		Granularity:      aiGranularity,
+		CandleCount:      aiCandleCount,
+		NewsSources:      strings.Join(newsSources, ","),
+		NewsScorer:       newsScorerName,
+		NewsPerCurrency:  cfg.News.PerCurrency,
+		NewsMaxAge:       cfg.News.MaxAge,
+		NewsMinRelevance: cfg.News.MinRelevance,
+		MarketTTL:        cfg.AI.Cache.MarketTTL,
+		NewsTTL:          cfg.AI.Cache.NewsTTL,
+	})
+	go ai.RunCacheJanitor(context.Background(), store, cfg.AI.Cache.JanitorInterval)
+	if cfg.Calendar.File != "" {
+		go calendar.RunIngester(context.Background(), calendar.NewFileProvider(cfg.Calendar.File), store, cfg.Calendar.RefreshInterval)
+	}
 	claude := ai.NewClaudeClient(http.DefaultClient)
 	aiSvc := ai.NewService(agg, claude)
 
-	server := api.NewServer(cfg, oandaMT4Client, braveClient, pg, aiSvc)
-	if err := server.Run(); err != nil {
-		log.Fatal("Failed to start server:", err)
+	server, err := api.NewServer(cfg, oandaMT4Client, newsProvider, store, aiSvc, aiMeter)
+	if err != nil {
+		log.Fatal(err)
+	}
+	go func() {
+		if err := server.Run(); err != nil {
+			log.Fatal("Failed to start server:", err)
+		}
+	}()
+
+	sigs := make(chan os.Signal, 1)
+	signal.Notify(sigs, os.Interrupt, syscall.SIGTERM)
+	<-sigs
+	log.Println("Shutting down")
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	defer cancel()
+	if err := server.Shutdown(ctx); err != nil {
+		log.Printf("shutdown: %v", err)
 	}
 }
 
@@ -0,0 +1,17 @@
+package models
+
+import "time"
+
+// AccountSnapshot is a reading of the broker account; amounts are in account currency.
+type AccountSnapshot struct {
+	ID              string    `db:"id" json:"id"`
+	AccountID       string    `db:"account_id" json:"account_id"`
+	Currency        string    `db:"currency" json:"currency"`
+	Balance         float64   `db:"balance" json:"balance"`
+	NAV             float64   `db:"nav" json:"nav"`
+	UnrealizedPL    float64   `db:"unrealized_pl" json:"unrealized_pl"`
+	MarginUsed      float64   `db:"margin_used" json:"margin_used"`
+	MarginAvailable float64   `db:"margin_available" json:"margin_available"`
+	OpenTradeCount  int       `db:"open_trade_count" json:"open_trade_count"`
+	TakenAt         time.Time `db:"taken_at" json:"taken_at"`
+}
@@ -30,3 +30,29 @@ type AIRecommendation struct {
 	CreatedAt         time.Time              `db:"created_at" json:"created_at"`
 	UpdatedAt         time.Time              `db:"updated_at" json:"updated_at"`
 }
+
+type AIUsageLog struct {
+	ID                  string    `db:"id" json:"id"`
+	RecommendationID    *string   `db:"recommendation_id" json:"recommendation_id,omitempty"`
+	PromptTokens        int       `db:"prompt_tokens" json:"prompt_tokens"`
+	CompletionTokens    int       `db:"completion_tokens" json:"completion_tokens"`
+	CacheCreationTokens int       `db:"cache_creation_tokens" json:"cache_creation_tokens"`
+	CacheReadTokens     int       `db:"cache_read_tokens" json:"cache_read_tokens"`
+	TotalTokens         int       `db:"total_tokens" json:"total_tokens"`
+	ResponseTimeMs      int       `db:"response_time_ms" json:"response_time_ms"`
+	Model               string    `db:"claude_model" json:"model"`
+	CostUSD             float64   `db:"cost_usd" json:"cost_usd"`
+	CreatedAt           time.Time `db:"created_at" json:"created_at"`
+}
+
+// AIUsageTotals aggregates ai_usage_logs per model over a period.
+type AIUsageTotals struct {
+	Model               string  `json:"model"`
+	Requests            int     `json:"requests"`
+	PromptTokens        int     `json:"prompt_tokens"`
+	CompletionTokens    int     `json:"completion_tokens"`
+	CacheCreationTokens int     `json:"cache_creation_tokens"`
+	CacheReadTokens     int     `json:"cache_read_tokens"`
+	TotalTokens         int     `json:"total_tokens"`
+	CostUSD             float64 `json:"cost_usd"`
+}
@@ -0,0 +1,57 @@
+package models
+
+import "time"
+
+type AlertKind string
+
+const (
+	AlertPriceCross  AlertKind = "PRICE"
+	AlertPercentMove AlertKind = "PERCENT_MOVE"
+	AlertSpread      AlertKind = "SPREAD"
+	AlertIndicator   AlertKind = "INDICATOR"
+)
+
+type AlertCondition string
+
+const (
+	AlertAbove      AlertCondition = "ABOVE"
+	AlertBelow      AlertCondition = "BELOW"
+	AlertCrossAbove AlertCondition = "CROSS_ABOVE"
+	AlertCrossBelow AlertCondition = "CROSS_BELOW"
+)
+
+// AlertRule is a persisted condition on a price, price move, spread or indicator value.
+// Threshold is a price for PRICE, a percentage for PERCENT_MOVE, pips for SPREAD and the
+// indicator's own scale for INDICATOR.
+type AlertRule struct {
+	ID         string         `db:"id" json:"id"`
+	Name       string         `db:"name" json:"name"`
+	Instrument string         `db:"instrument" json:"instrument"`
+	Kind       AlertKind      `db:"kind" json:"kind"`
+	Condition  AlertCondition `db:"condition" json:"condition"`
+	Threshold  float64        `db:"threshold" json:"threshold"`
+	// Indicator, Period and Timeframe apply to INDICATOR rules, e.g. RSI 14 on H1.
+	Indicator *string `db:"indicator" json:"indicator,omitempty"`
+	Period    *int    `db:"period" json:"period,omitempty"`
+	Timeframe *string `db:"timeframe" json:"timeframe,omitempty"`
+	// WindowSeconds is the lookback for PERCENT_MOVE rules.
+	WindowSeconds int `db:"window_seconds" json:"window_seconds,omitempty"`
+	// Repeat keeps the rule active after it fires; CooldownSeconds spaces repeated firings.
+	Repeat          bool       `db:"repeat" json:"repeat"`
+	CooldownSeconds int        `db:"cooldown_seconds" json:"cooldown_seconds"`
+	Active          bool       `db:"active" json:"active"`
+	FireCount       int        `db:"fire_count" json:"fire_count"`
+	LastFiredAt     *time.Time `db:"last_fired_at" json:"last_fired_at,omitempty"`
+	CreatedAt       time.Time  `db:"created_at" json:"created_at"`
+	UpdatedAt       time.Time  `db:"updated_at" json:"updated_at"`
+}
+
+// AlertEvent records one firing of a rule.
+type AlertEvent struct {
+	ID         string    `db:"id" json:"id"`
+	RuleID     string    `db:"rule_id" json:"rule_id"`
+	Instrument string    `db:"instrument" json:"instrument"`
+	Value      float64   `db:"value" json:"value"`
+	Message    string    `db:"message" json:"message"`
+	FiredAt    time.Time `db:"fired_at" json:"fired_at"`
+}
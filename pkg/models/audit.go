@@ -0,0 +1,17 @@
+package models
+
+import (
+	"encoding/json"
+	"time"
+)
+
+// AuditLog is an entry of audit_logs.
+type AuditLog struct {
+	ID       int64           `db:"id" json:"id"`
+	Entity   string          `db:"entity" json:"entity"`
+	EntityID string          `db:"entity_id" json:"entity_id,omitempty"`
+	Action   string          `db:"action" json:"action"`
+	Details  json.RawMessage `db:"details" json:"details"`
+	// CreatedAt is when the entry was written.
+	CreatedAt time.Time `db:"created_at" json:"created_at"`
+}
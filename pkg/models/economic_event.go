@@ -0,0 +1,37 @@
+package models
+
+import "time"
+
+type EventImpact string
+
+const (
+	EventImpactLow    EventImpact = "LOW"
+	EventImpactMedium EventImpact = "MEDIUM"
+	EventImpactHigh   EventImpact = "HIGH"
+)
+
+// Rank orders impacts so they can be compared; unknown values rank lowest.
+func (i EventImpact) Rank() int {
+	switch i {
+	case EventImpactHigh:
+		return 3
+	case EventImpactMedium:
+		return 2
+	case EventImpactLow:
+		return 1
+	}
+	return 0
+}
+
+type EconomicEvent struct {
+	ID        string      `db:"id" json:"id"`
+	Title     string      `db:"title" json:"title"`
+	Currency  string      `db:"currency" json:"currency"`
+	Impact    EventImpact `db:"impact" json:"impact"`
+	EventTime time.Time   `db:"event_time" json:"event_time"`
+	Actual    *string     `db:"actual" json:"actual,omitempty"`
+	Forecast  *string     `db:"forecast" json:"forecast,omitempty"`
+	Previous  *string     `db:"previous" json:"previous,omitempty"`
+	Source    string      `db:"source" json:"source"`
+	CreatedAt time.Time   `db:"created_at" json:"created_at"`
+}
@@ -0,0 +1,18 @@
+package models
+
+import (
+	"strings"
+	"time"
+)
+
+// granularities maps OANDA candle granularities to their bar length.
+var granularities = map[string]time.Duration{
+	"M1": time.Minute, "M5": 5 * time.Minute, "M15": 15 * time.Minute, "M30": 30 * time.Minute,
+	"H1": time.Hour, "H4": 4 * time.Hour, "D": 24 * time.Hour,
+}
+
+// Granularity returns the bar length of an OANDA granularity such as "H1".
+func Granularity(tf string) (time.Duration, bool) {
+	d, ok := granularities[strings.ToUpper(tf)]
+	return d, ok
+}
@@ -0,0 +1,25 @@
+package models
+
+import "time"
+
+// GuardianState is the persisted daily-loss/drawdown tracking and kill-switch state for an account.
+type GuardianState struct {
+	AccountID         string    `db:"account_id" json:"account_id"`
+	TradingDay        time.Time `db:"trading_day" json:"trading_day"`
+	StartOfDayNAV     float64   `db:"start_of_day_nav" json:"start_of_day_nav"`
+	StartOfDayBalance float64   `db:"start_of_day_balance" json:"start_of_day_balance"`
+	// StartOfDayPL is the broker's lifetime realized P&L when the trading day began; nil in
+	// state saved before it was tracked.
+	StartOfDayPL *float64 `db:"start_of_day_pl" json:"start_of_day_pl,omitempty"`
+	// PeakNAV is the highest NAV since the last reset, shifted by deposits and withdrawals made
+	// since, so transfers move neither the peak's distance from NAV nor the drawdown.
+	PeakNAV float64 `db:"peak_nav" json:"peak_nav"`
+	// PeakPL is the lifetime realized plus unrealized P&L at the peak; nil in older state.
+	PeakPL     *float64   `db:"peak_pl" json:"peak_pl,omitempty"`
+	Halted     bool       `db:"halted" json:"halted"`
+	HaltReason *string    `db:"halt_reason" json:"halt_reason,omitempty"`
+	HaltedAt   *time.Time `db:"halted_at" json:"halted_at,omitempty"`
+	ResetBy    *string    `db:"reset_by" json:"reset_by,omitempty"`
+	ResetAt    *time.Time `db:"reset_at" json:"reset_at,omitempty"`
+	UpdatedAt  time.Time  `db:"updated_at" json:"updated_at"`
+}
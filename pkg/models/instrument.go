@@ -0,0 +1,62 @@
+package models
+
+import (
+	"math"
+	"strings"
+)
+
+// SplitInstrument splits an OANDA instrument such as EUR_USD into base and quote codes.
+func SplitInstrument(instrument string) (string, string, bool) {
+	parts := strings.Split(strings.ToUpper(instrument), "_")
+	if len(parts) != 2 || parts[0] == "" || parts[1] == "" {
+		return "", "", false
+	}
+	return parts[0], parts[1], true
+}
+
+// InstrumentCurrencies returns the base and quote codes of an instrument, or nil when it is not
+// a pair.
+func InstrumentCurrencies(instrument string) []string {
+	base, quote, ok := SplitInstrument(instrument)
+	if !ok {
+		return nil
+	}
+	return []string{base, quote}
+}
+
+// CurrenciesFor returns the distinct currencies of the instruments in first-seen order.
+func CurrenciesFor(instruments []string) []string {
+	seen := make(map[string]bool)
+	var out []string
+	for _, inst := range instruments {
+		for _, code := range InstrumentCurrencies(inst) {
+			if !seen[code] {
+				seen[code] = true
+				out = append(out, code)
+			}
+		}
+	}
+	return out
+}
+
+// PipSize is an instrument's pip as OANDA defines it, for when its pipLocation is not at hand:
+// 0.01 for JPY and HUF quotes and for gold, platinum and palladium, 0.0001 otherwise (silver
+// included).
+func PipSize(instrument string) float64 {
+	base, quote, ok := SplitInstrument(instrument)
+	if !ok {
+		return 0.0001
+	}
+	switch {
+	case quote == "JPY", quote == "HUF":
+		return 0.01
+	case base == "XAU", base == "XPT", base == "XPD":
+		return 0.01
+	}
+	return 0.0001
+}
+
+// PipFromLocation is the pip for an OANDA pipLocation, e.g. -4 for 0.0001.
+func PipFromLocation(location int) float64 {
+	return math.Pow10(location)
+}
@@ -0,0 +1,52 @@
+package models
+
+import "testing"
+
+func TestPipSize(t *testing.T) {
+	// pipLocation as OANDA reports it for each instrument.
+	tests := []struct {
+		instrument string
+		location   int
+	}{
+		{"EUR_USD", -4},
+		{"GBP_CHF", -4},
+		{"USD_JPY", -2},
+		{"EUR_JPY", -2},
+		{"eur_jpy", -2},
+		{"EUR_HUF", -2},
+		{"XAU_USD", -2},
+		{"XAU_JPY", -2},
+		{"XPT_USD", -2},
+		{"XPD_USD", -2},
+		{"XAG_USD", -4},
+		{"JPY_USD", -4},
+	}
+	for _, tt := range tests {
+		if got, want := PipSize(tt.instrument), PipFromLocation(tt.location); got != want {
+			t.Errorf("PipSize(%s) = %v, want %v", tt.instrument, got, want)
+		}
+	}
+	if PipSize("SPX500") != 0.0001 {
+		t.Errorf("non-pair instruments default to 0.0001")
+	}
+}
+
+func TestSplitInstrument(t *testing.T) {
+	tests := []struct {
+		in          string
+		base, quote string
+		ok          bool
+	}{
+		{"EUR_USD", "EUR", "USD", true},
+		{"eur_usd", "EUR", "USD", true},
+		{"EURUSD", "", "", false},
+		{"EUR_", "", "", false},
+		{"A_B_C", "", "", false},
+	}
+	for _, tt := range tests {
+		base, quote, ok := SplitInstrument(tt.in)
+		if base != tt.base || quote != tt.quote || ok != tt.ok {
+			t.Errorf("SplitInstrument(%q) = %q, %q, %v", tt.in, base, quote, ok)
+		}
+	}
+}
@@ -12,5 +12,15 @@ type MarketData struct {
 	ClosePrice float64   `db:"close_price" json:"close_price"`
 	Volume     *int64    `db:"volume" json:"volume,omitempty"`
 	Timeframe  string    `db:"timeframe" json:"timeframe"`
-	CreatedAt  time.Time `db:"created_at" json:"created_at"`
+	// Bid and Ask are set when the candle was stored with both sides.
+	Bid       *OHLC     `db:"-" json:"bid,omitempty"`
+	Ask       *OHLC     `db:"-" json:"ask,omitempty"`
+	CreatedAt time.Time `db:"created_at" json:"created_at"`
+}
+
+type OHLC struct {
+	Open  float64 `json:"open"`
+	High  float64 `json:"high"`
+	Low   float64 `json:"low"`
+	Close float64 `json:"close"`
 }
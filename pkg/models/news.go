@@ -0,0 +1,18 @@
+package models
+
+import "time"
+
+// NewsArticle is an archived, scored news item. Currencies lists the codes it is relevant to.
+// An article counts as available from PublishedAt, or FirstSeenAt when it had no date.
+type NewsArticle struct {
+	ID          string             `db:"id" json:"id"`
+	URL         string             `db:"url" json:"url"`
+	Title       string             `db:"title" json:"title"`
+	Snippet     string             `db:"snippet" json:"snippet"`
+	Source      string             `db:"source" json:"source"`
+	PublishedAt *time.Time         `db:"published_at" json:"published_at,omitempty"`
+	FirstSeenAt time.Time          `db:"first_seen_at" json:"first_seen_at"`
+	Sentiment   float64            `db:"sentiment" json:"sentiment"`
+	Relevance   map[string]float64 `db:"relevance" json:"relevance,omitempty"`
+	Currencies  []string           `db:"currencies" json:"currencies"`
+}
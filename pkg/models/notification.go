@@ -0,0 +1,26 @@
+package models
+
+import (
+	"encoding/json"
+	"time"
+)
+
+type NotificationStatus string
+
+const (
+	NotificationSent   NotificationStatus = "SENT"
+	NotificationFailed NotificationStatus = "FAILED"
+)
+
+// NotificationDelivery is the outcome of sending one event to one channel.
+type NotificationDelivery struct {
+	ID        string             `db:"id" json:"id"`
+	EventID   string             `db:"event_id" json:"event_id"`
+	EventType string             `db:"event_type" json:"event_type"`
+	Channel   string             `db:"channel" json:"channel"`
+	Status    NotificationStatus `db:"status" json:"status"`
+	Attempts  int                `db:"attempts" json:"attempts"`
+	Error     *string            `db:"error" json:"error,omitempty"`
+	Payload   json.RawMessage    `db:"payload" json:"payload"`
+	CreatedAt time.Time          `db:"created_at" json:"created_at"`
+}
@@ -0,0 +1,43 @@
+package models
+
+import (
+	"encoding/json"
+	"time"
+)
+
+type OptimizationKind string
+
+const (
+	OptimizationSearch      OptimizationKind = "search"
+	OptimizationWalkForward OptimizationKind = "walk_forward"
+)
+
+type OptimizationStatus string
+
+const (
+	OptimizationRunning   OptimizationStatus = "RUNNING"
+	OptimizationCompleted OptimizationStatus = "COMPLETED"
+	OptimizationFailed    OptimizationStatus = "FAILED"
+)
+
+// OptimizationRun is a stored parameter search or walk-forward analysis. Config is the request
+// that started it; Metrics, Result and Equity are set when it completes. Result holds the ranked
+// candidates of a search or the windows of a walk-forward analysis, and Equity the curve of the
+// best parameters or the stitched out-of-sample curve.
+type OptimizationRun struct {
+	ID          string             `db:"id" json:"id"`
+	Kind        OptimizationKind   `db:"kind" json:"kind"`
+	Strategy    string             `db:"strategy" json:"strategy"`
+	Instruments []string           `db:"instruments" json:"instruments"`
+	Timeframe   string             `db:"timeframe" json:"timeframe"`
+	From        time.Time          `db:"range_from" json:"from"`
+	To          time.Time          `db:"range_to" json:"to"`
+	Config      json.RawMessage    `db:"config" json:"config"`
+	Status      OptimizationStatus `db:"status" json:"status"`
+	Metrics     json.RawMessage    `db:"metrics" json:"metrics,omitempty"`
+	Result      json.RawMessage    `db:"result" json:"result,omitempty"`
+	Equity      json.RawMessage    `db:"equity" json:"equity,omitempty"`
+	Error       *string            `db:"error" json:"error,omitempty"`
+	CreatedAt   time.Time          `db:"created_at" json:"created_at"`
+	CompletedAt *time.Time         `db:"completed_at" json:"completed_at,omitempty"`
+}
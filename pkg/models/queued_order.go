@@ -0,0 +1,27 @@
+package models
+
+import "time"
+
+type QueuedOrderStatus string
+
+const (
+	QueuedOrderPending   QueuedOrderStatus = "QUEUED"
+	QueuedOrderSubmitted QueuedOrderStatus = "SUBMITTED"
+	QueuedOrderRejected  QueuedOrderStatus = "REJECTED"
+	QueuedOrderCancelled QueuedOrderStatus = "CANCELLED"
+)
+
+// QueuedOrder is a market order received while the market was closed, submitted at the next open.
+type QueuedOrder struct {
+	ID           string            `db:"id" json:"id"`
+	Instrument   string            `db:"instrument" json:"instrument"`
+	Units        float64           `db:"units" json:"units"`
+	StopLoss     *float64          `db:"stop_loss" json:"stop_loss,omitempty"`
+	TakeProfit   *float64          `db:"take_profit" json:"take_profit,omitempty"`
+	Source       string            `db:"source" json:"source"`
+	Status       QueuedOrderStatus `db:"status" json:"status"`
+	Error        *string           `db:"error" json:"error,omitempty"`
+	OandaOrderID *string           `db:"oanda_order_id" json:"oanda_order_id,omitempty"`
+	CreatedAt    time.Time         `db:"created_at" json:"created_at"`
+	SubmittedAt  *time.Time        `db:"submitted_at" json:"submitted_at,omitempty"`
+}
@@ -0,0 +1,28 @@
+package models
+
+import "time"
+
+// Tick is one bid/ask quote from the pricing stream.
+type Tick struct {
+	Instrument string    `db:"instrument" json:"instrument"`
+	Time       time.Time `db:"time" json:"time"`
+	Bid        float64   `db:"bid" json:"bid"`
+	Ask        float64   `db:"ask" json:"ask"`
+}
+
+// Mid is the midpoint of bid and ask.
+func (t Tick) Mid() float64 { return (t.Bid + t.Ask) / 2 }
+
+// Spread is ask minus bid in price units.
+func (t Tick) Spread() float64 { return t.Ask - t.Bid }
+
+// SpreadStats summarizes the spreads of the ticks in one interval, in price units.
+type SpreadStats struct {
+	Time  time.Time `json:"time"`
+	Ticks int64     `json:"ticks"`
+	Min   float64   `json:"min"`
+	Avg   float64   `json:"avg"`
+	P50   float64   `json:"p50"`
+	P95   float64   `json:"p95"`
+	Max   float64   `json:"max"`
+}
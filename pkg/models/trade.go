@@ -9,6 +9,14 @@ const (
 	TradeStatusClosed TradeStatus = "CLOSED"
 )
 
+// Trade sources.
+const (
+	TradeSourceManual   = "manual"
+	TradeSourceAI       = "ai"
+	TradeSourceStrategy = "strategy"
+	TradeSourceSignal   = "signal"
+)
+
 type Trade struct {
 	ID           string      `db:"id" json:"id"`
 	Instrument   string      `db:"instrument" json:"instrument"`
@@ -21,7 +29,9 @@ type Trade struct {
 	Swap         *float64    `db:"swap" json:"swap,omitempty"`
 	Status       TradeStatus `db:"status" json:"status"`
 	OandaTradeID *string     `db:"oanda_trade_id" json:"oanda_trade_id,omitempty"`
-	CreatedAt    time.Time   `db:"created_at" json:"created_at"`
-	UpdatedAt    time.Time   `db:"updated_at" json:"updated_at"`
-	ClosedAt     *time.Time  `db:"closed_at" json:"closed_at,omitempty"`
+	// Source is one of the TradeSource constants.
+	Source    string     `db:"source" json:"source"`
+	CreatedAt time.Time  `db:"created_at" json:"created_at"`
+	UpdatedAt time.Time  `db:"updated_at" json:"updated_at"`
+	ClosedAt  *time.Time `db:"closed_at" json:"closed_at,omitempty"`
 }
@@ -0,0 +1,434 @@
+// Code generated by protoc-gen-go. DO NOT EDIT.
+// versions:
+// 	protoc-gen-go v1.36.6
+// 	protoc        v5.27.3
+// source: proto/account.proto
+
+package v1
+
+import (
+	protoreflect "google.golang.org/protobuf/reflect/protoreflect"
+	protoimpl "google.golang.org/protobuf/runtime/protoimpl"
+	reflect "reflect"
+	sync "sync"
+	unsafe "unsafe"
+)
+
+const (
+	// Verify that this generated code is sufficiently up-to-date.
+	_ = protoimpl.EnforceVersion(20 - protoimpl.MinVersion)
+	// Verify that runtime/protoimpl is sufficiently up-to-date.
+	_ = protoimpl.EnforceVersion(protoimpl.MaxVersion - 20)
+)
+
+type GetAccountSummaryRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *GetAccountSummaryRequest) Reset() {
+	*x = GetAccountSummaryRequest{}
+	mi := &file_proto_account_proto_msgTypes[0]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *GetAccountSummaryRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*GetAccountSummaryRequest) ProtoMessage() {}
+
+func (x *GetAccountSummaryRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_proto_account_proto_msgTypes[0]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use GetAccountSummaryRequest.ProtoReflect.Descriptor instead.
+func (*GetAccountSummaryRequest) Descriptor() ([]byte, []int) {
+	return file_proto_account_proto_rawDescGZIP(), []int{0}
+}
+
+type InstrumentPL struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Instrument    string                 `protobuf:"bytes,1,opt,name=instrument,proto3" json:"instrument,omitempty"`
+	LongUnits     float64                `protobuf:"fixed64,2,opt,name=long_units,json=longUnits,proto3" json:"long_units,omitempty"`
+	ShortUnits    float64                `protobuf:"fixed64,3,opt,name=short_units,json=shortUnits,proto3" json:"short_units,omitempty"`
+	UnrealizedPl  float64                `protobuf:"fixed64,4,opt,name=unrealized_pl,json=unrealizedPl,proto3" json:"unrealized_pl,omitempty"`
+	MarginUsed    float64                `protobuf:"fixed64,5,opt,name=margin_used,json=marginUsed,proto3" json:"margin_used,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *InstrumentPL) Reset() {
+	*x = InstrumentPL{}
+	mi := &file_proto_account_proto_msgTypes[1]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *InstrumentPL) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*InstrumentPL) ProtoMessage() {}
+
+func (x *InstrumentPL) ProtoReflect() protoreflect.Message {
+	mi := &file_proto_account_proto_msgTypes[1]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use InstrumentPL.ProtoReflect.Descriptor instead.
+func (*InstrumentPL) Descriptor() ([]byte, []int) {
+	return file_proto_account_proto_rawDescGZIP(), []int{1}
+}
+
+func (x *InstrumentPL) GetInstrument() string {
+	if x != nil {
+		return x.Instrument
+	}
+	return ""
+}
+
+func (x *InstrumentPL) GetLongUnits() float64 {
+	if x != nil {
+		return x.LongUnits
+	}
+	return 0
+}
+
+func (x *InstrumentPL) GetShortUnits() float64 {
+	if x != nil {
+		return x.ShortUnits
+	}
+	return 0
+}
+
+func (x *InstrumentPL) GetUnrealizedPl() float64 {
+	if x != nil {
+		return x.UnrealizedPl
+	}
+	return 0
+}
+
+func (x *InstrumentPL) GetMarginUsed() float64 {
+	if x != nil {
+		return x.MarginUsed
+	}
+	return 0
+}
+
+// Amounts are in account currency.
+type AccountSummary struct {
+	state             protoimpl.MessageState `protogen:"open.v1"`
+	AccountId         string                 `protobuf:"bytes,1,opt,name=account_id,json=accountId,proto3" json:"account_id,omitempty"`
+	Currency          string                 `protobuf:"bytes,2,opt,name=currency,proto3" json:"currency,omitempty"`
+	Balance           float64                `protobuf:"fixed64,3,opt,name=balance,proto3" json:"balance,omitempty"`
+	Nav               float64                `protobuf:"fixed64,4,opt,name=nav,proto3" json:"nav,omitempty"`
+	Equity            float64                `protobuf:"fixed64,5,opt,name=equity,proto3" json:"equity,omitempty"`
+	UnrealizedPl      float64                `protobuf:"fixed64,6,opt,name=unrealized_pl,json=unrealizedPl,proto3" json:"unrealized_pl,omitempty"`
+	MarginUsed        float64                `protobuf:"fixed64,7,opt,name=margin_used,json=marginUsed,proto3" json:"margin_used,omitempty"`
+	MarginAvailable   float64                `protobuf:"fixed64,8,opt,name=margin_available,json=marginAvailable,proto3" json:"margin_available,omitempty"`
+	FreeMargin        float64                `protobuf:"fixed64,9,opt,name=free_margin,json=freeMargin,proto3" json:"free_margin,omitempty"`
+	MarginLevel       *float64               `protobuf:"fixed64,10,opt,name=margin_level,json=marginLevel,proto3,oneof" json:"margin_level,omitempty"` // percent; unset when no margin is used
+	MarginUsedPercent float64                `protobuf:"fixed64,11,opt,name=margin_used_percent,json=marginUsedPercent,proto3" json:"margin_used_percent,omitempty"`
+	PositionValue     float64                `protobuf:"fixed64,12,opt,name=position_value,json=positionValue,proto3" json:"position_value,omitempty"`
+	Leverage          float64                `protobuf:"fixed64,13,opt,name=leverage,proto3" json:"leverage,omitempty"`
+	OpenTrades        int32                  `protobuf:"varint,14,opt,name=open_trades,json=openTrades,proto3" json:"open_trades,omitempty"`
+	OpenPositions     int32                  `protobuf:"varint,15,opt,name=open_positions,json=openPositions,proto3" json:"open_positions,omitempty"`
+	TotalProfit       float64                `protobuf:"fixed64,16,opt,name=total_profit,json=totalProfit,proto3" json:"total_profit,omitempty"`
+	TotalLoss         float64                `protobuf:"fixed64,17,opt,name=total_loss,json=totalLoss,proto3" json:"total_loss,omitempty"`
+	Instruments       []*InstrumentPL        `protobuf:"bytes,18,rep,name=instruments,proto3" json:"instruments,omitempty"`
+	DailyRealizedPl   *float64               `protobuf:"fixed64,19,opt,name=daily_realized_pl,json=dailyRealizedPl,proto3,oneof" json:"daily_realized_pl,omitempty"` // unset without a database
+	DailyClosedTrades int32                  `protobuf:"varint,20,opt,name=daily_closed_trades,json=dailyClosedTrades,proto3" json:"daily_closed_trades,omitempty"`
+	unknownFields     protoimpl.UnknownFields
+	sizeCache         protoimpl.SizeCache
+}
+
+func (x *AccountSummary) Reset() {
+	*x = AccountSummary{}
+	mi := &file_proto_account_proto_msgTypes[2]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *AccountSummary) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*AccountSummary) ProtoMessage() {}
+
+func (x *AccountSummary) ProtoReflect() protoreflect.Message {
+	mi := &file_proto_account_proto_msgTypes[2]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use AccountSummary.ProtoReflect.Descriptor instead.
+func (*AccountSummary) Descriptor() ([]byte, []int) {
+	return file_proto_account_proto_rawDescGZIP(), []int{2}
+}
+
+func (x *AccountSummary) GetAccountId() string {
+	if x != nil {
+		return x.AccountId
+	}
+	return ""
+}
+
+func (x *AccountSummary) GetCurrency() string {
+	if x != nil {
+		return x.Currency
+	}
+	return ""
+}
+
+func (x *AccountSummary) GetBalance() float64 {
+	if x != nil {
+		return x.Balance
+	}
+	return 0
+}
+
+func (x *AccountSummary) GetNav() float64 {
+	if x != nil {
+		return x.Nav
+	}
+	return 0
+}
+
+func (x *AccountSummary) GetEquity() float64 {
+	if x != nil {
+		return x.Equity
+	}
+	return 0
+}
+
+func (x *AccountSummary) GetUnrealizedPl() float64 {
+	if x != nil {
+		return x.UnrealizedPl
+	}
+	return 0
+}
+
+func (x *AccountSummary) GetMarginUsed() float64 {
+	if x != nil {
+		return x.MarginUsed
+	}
+	return 0
+}
+
+func (x *AccountSummary) GetMarginAvailable() float64 {
+	if x != nil {
+		return x.MarginAvailable
+	}
+	return 0
+}
+
+func (x *AccountSummary) GetFreeMargin() float64 {
+	if x != nil {
+		return x.FreeMargin
+	}
+	return 0
+}
+
+func (x *AccountSummary) GetMarginLevel() float64 {
+	if x != nil && x.MarginLevel != nil {
+		return *x.MarginLevel
+	}
+	return 0
+}
+
+func (x *AccountSummary) GetMarginUsedPercent() float64 {
+	if x != nil {
+		return x.MarginUsedPercent
+	}
+	return 0
+}
+
+func (x *AccountSummary) GetPositionValue() float64 {
+	if x != nil {
+		return x.PositionValue
+	}
+	return 0
+}
+
+func (x *AccountSummary) GetLeverage() float64 {
+	if x != nil {
+		return x.Leverage
+	}
+	return 0
+}
+
+func (x *AccountSummary) GetOpenTrades() int32 {
+	if x != nil {
+		return x.OpenTrades
+	}
+	return 0
+}
+
+func (x *AccountSummary) GetOpenPositions() int32 {
+	if x != nil {
+		return x.OpenPositions
+	}
+	return 0
+}
+
+func (x *AccountSummary) GetTotalProfit() float64 {
+	if x != nil {
+		return x.TotalProfit
+	}
+	return 0
+}
+
+func (x *AccountSummary) GetTotalLoss() float64 {
+	if x != nil {
+		return x.TotalLoss
+	}
+	return 0
+}
+
+func (x *AccountSummary) GetInstruments() []*InstrumentPL {
+	if x != nil {
+		return x.Instruments
+	}
+	return nil
+}
+
+func (x *AccountSummary) GetDailyRealizedPl() float64 {
+	if x != nil && x.DailyRealizedPl != nil {
+		return *x.DailyRealizedPl
+	}
+	return 0
+}
+
+func (x *AccountSummary) GetDailyClosedTrades() int32 {
+	if x != nil {
+		return x.DailyClosedTrades
+	}
+	return 0
+}
+
+var File_proto_account_proto protoreflect.FileDescriptor
+
+const file_proto_account_proto_rawDesc = "" +
+	"\n" +
+	"\x13proto/account.proto\x12\vgotrader.v1\"\x1a\n" +
+	"\x18GetAccountSummaryRequest\"\xb4\x01\n" +
+	"\fInstrumentPL\x12\x1e\n" +
+	"\n" +
+	"instrument\x18\x01 \x01(\tR\n" +
+	"instrument\x12\x1d\n" +
+	"\n" +
+	"long_units\x18\x02 \x01(\x01R\tlongUnits\x12\x1f\n" +
+	"\vshort_units\x18\x03 \x01(\x01R\n" +
+	"shortUnits\x12#\n" +
+	"\runrealized_pl\x18\x04 \x01(\x01R\funrealizedPl\x12\x1f\n" +
+	"\vmargin_used\x18\x05 \x01(\x01R\n" +
+	"marginUsed\"\x8b\x06\n" +
+	"\x0eAccountSummary\x12\x1d\n" +
+	"\n" +
+	"account_id\x18\x01 \x01(\tR\taccountId\x12\x1a\n" +
+	"\bcurrency\x18\x02 \x01(\tR\bcurrency\x12\x18\n" +
+	"\abalance\x18\x03 \x01(\x01R\abalance\x12\x10\n" +
+	"\x03nav\x18\x04 \x01(\x01R\x03nav\x12\x16\n" +
+	"\x06equity\x18\x05 \x01(\x01R\x06equity\x12#\n" +
+	"\runrealized_pl\x18\x06 \x01(\x01R\funrealizedPl\x12\x1f\n" +
+	"\vmargin_used\x18\a \x01(\x01R\n" +
+	"marginUsed\x12)\n" +
+	"\x10margin_available\x18\b \x01(\x01R\x0fmarginAvailable\x12\x1f\n" +
+	"\vfree_margin\x18\t \x01(\x01R\n" +
+	"freeMargin\x12&\n" +
+	"\fmargin_level\x18\n" +
+	" \x01(\x01H\x00R\vmarginLevel\x88\x01\x01\x12.\n" +
+	"\x13margin_used_percent\x18\v \x01(\x01R\x11marginUsedPercent\x12%\n" +
+	"\x0eposition_value\x18\f \x01(\x01R\rpositionValue\x12\x1a\n" +
+	"\bleverage\x18\r \x01(\x01R\bleverage\x12\x1f\n" +
+	"\vopen_trades\x18\x0e \x01(\x05R\n" +
+	"openTrades\x12%\n" +
+	"\x0eopen_positions\x18\x0f \x01(\x05R\ropenPositions\x12!\n" +
+	"\ftotal_profit\x18\x10 \x01(\x01R\vtotalProfit\x12\x1d\n" +
+	"\n" +
+	"total_loss\x18\x11 \x01(\x01R\ttotalLoss\x12;\n" +
+	"\vinstruments\x18\x12 \x03(\v2\x19.gotrader.v1.InstrumentPLR\vinstruments\x12/\n" +
+	"\x11daily_realized_pl\x18\x13 \x01(\x01H\x01R\x0fdailyRealizedPl\x88\x01\x01\x12.\n" +
+	"\x13daily_closed_trades\x18\x14 \x01(\x05R\x11dailyClosedTradesB\x0f\n" +
+	"\r_margin_levelB\x14\n" +
+	"\x12_daily_realized_pl2i\n" +
+	"\x0eAccountService\x12W\n" +
+	"\x11GetAccountSummary\x12%.gotrader.v1.GetAccountSummaryRequest\x1a\x1b.gotrader.v1.AccountSummaryB3Z1github.com/jedi116/go-trader/proto/gotrader/v1;v1b\x06proto3"
+
+var (
+	file_proto_account_proto_rawDescOnce sync.Once
+	file_proto_account_proto_rawDescData []byte
+)
+
+func file_proto_account_proto_rawDescGZIP() []byte {
+	file_proto_account_proto_rawDescOnce.Do(func() {
+		file_proto_account_proto_rawDescData = protoimpl.X.CompressGZIP(unsafe.Slice(unsafe.StringData(file_proto_account_proto_rawDesc), len(file_proto_account_proto_rawDesc)))
+	})
+	return file_proto_account_proto_rawDescData
+}
+
+var file_proto_account_proto_msgTypes = make([]protoimpl.MessageInfo, 3)
+var file_proto_account_proto_goTypes = []any{
+	(*GetAccountSummaryRequest)(nil), // 0: gotrader.v1.GetAccountSummaryRequest
+	(*InstrumentPL)(nil),             // 1: gotrader.v1.InstrumentPL
+	(*AccountSummary)(nil),           // 2: gotrader.v1.AccountSummary
+}
+var file_proto_account_proto_depIdxs = []int32{
+	1, // 0: gotrader.v1.AccountSummary.instruments:type_name -> gotrader.v1.InstrumentPL
+	0, // 1: gotrader.v1.AccountService.GetAccountSummary:input_type -> gotrader.v1.GetAccountSummaryRequest
+	2, // 2: gotrader.v1.AccountService.GetAccountSummary:output_type -> gotrader.v1.AccountSummary
+	2, // [2:3] is the sub-list for method output_type
+	1, // [1:2] is the sub-list for method input_type
+	1, // [1:1] is the sub-list for extension type_name
+	1, // [1:1] is the sub-list for extension extendee
+	0, // [0:1] is the sub-list for field type_name
+}
+
+func init() { file_proto_account_proto_init() }
+func file_proto_account_proto_init() {
+	if File_proto_account_proto != nil {
+		return
+	}
+	file_proto_account_proto_msgTypes[2].OneofWrappers = []any{}
+	type x struct{}
+	out := protoimpl.TypeBuilder{
+		File: protoimpl.DescBuilder{
+			GoPackagePath: reflect.TypeOf(x{}).PkgPath(),
+			RawDescriptor: unsafe.Slice(unsafe.StringData(file_proto_account_proto_rawDesc), len(file_proto_account_proto_rawDesc)),
+			NumEnums:      0,
+			NumMessages:   3,
+			NumExtensions: 0,
+			NumServices:   1,
+		},
+		GoTypes:           file_proto_account_proto_goTypes,
+		DependencyIndexes: file_proto_account_proto_depIdxs,
+		MessageInfos:      file_proto_account_proto_msgTypes,
+	}.Build()
+	File_proto_account_proto = out.File
+	file_proto_account_proto_goTypes = nil
+	file_proto_account_proto_depIdxs = nil
+}
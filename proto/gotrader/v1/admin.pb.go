@@ -0,0 +1,293 @@
+// Code generated by protoc-gen-go. DO NOT EDIT.
+// versions:
+// 	protoc-gen-go v1.36.6
+// 	protoc        v5.27.3
+// source: proto/admin.proto
+
+package v1
+
+import (
+	protoreflect "google.golang.org/protobuf/reflect/protoreflect"
+	protoimpl "google.golang.org/protobuf/runtime/protoimpl"
+	reflect "reflect"
+	sync "sync"
+	unsafe "unsafe"
+)
+
+const (
+	// Verify that this generated code is sufficiently up-to-date.
+	_ = protoimpl.EnforceVersion(20 - protoimpl.MinVersion)
+	// Verify that runtime/protoimpl is sufficiently up-to-date.
+	_ = protoimpl.EnforceVersion(protoimpl.MaxVersion - 20)
+)
+
+type KillSwitchRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	By            string                 `protobuf:"bytes,1,opt,name=by,proto3" json:"by,omitempty"`
+	Reason        string                 `protobuf:"bytes,2,opt,name=reason,proto3" json:"reason,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *KillSwitchRequest) Reset() {
+	*x = KillSwitchRequest{}
+	mi := &file_proto_admin_proto_msgTypes[0]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *KillSwitchRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*KillSwitchRequest) ProtoMessage() {}
+
+func (x *KillSwitchRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_proto_admin_proto_msgTypes[0]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use KillSwitchRequest.ProtoReflect.Descriptor instead.
+func (*KillSwitchRequest) Descriptor() ([]byte, []int) {
+	return file_proto_admin_proto_rawDescGZIP(), []int{0}
+}
+
+func (x *KillSwitchRequest) GetBy() string {
+	if x != nil {
+		return x.By
+	}
+	return ""
+}
+
+func (x *KillSwitchRequest) GetReason() string {
+	if x != nil {
+		return x.Reason
+	}
+	return ""
+}
+
+type KillSwitchStatus struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Halted        bool                   `protobuf:"varint,1,opt,name=halted,proto3" json:"halted,omitempty"`
+	HaltReason    string                 `protobuf:"bytes,2,opt,name=halt_reason,json=haltReason,proto3" json:"halt_reason,omitempty"`
+	HaltedAt      string                 `protobuf:"bytes,3,opt,name=halted_at,json=haltedAt,proto3" json:"halted_at,omitempty"`       // RFC3339
+	TradingDay    string                 `protobuf:"bytes,4,opt,name=trading_day,json=tradingDay,proto3" json:"trading_day,omitempty"` // YYYY-MM-DD
+	Nav           float64                `protobuf:"fixed64,5,opt,name=nav,proto3" json:"nav,omitempty"`
+	StartOfDayNav float64                `protobuf:"fixed64,6,opt,name=start_of_day_nav,json=startOfDayNav,proto3" json:"start_of_day_nav,omitempty"`
+	PeakNav       float64                `protobuf:"fixed64,7,opt,name=peak_nav,json=peakNav,proto3" json:"peak_nav,omitempty"`
+	DailyPl       float64                `protobuf:"fixed64,8,opt,name=daily_pl,json=dailyPl,proto3" json:"daily_pl,omitempty"`
+	DailyLoss     float64                `protobuf:"fixed64,9,opt,name=daily_loss,json=dailyLoss,proto3" json:"daily_loss,omitempty"`
+	Drawdown      float64                `protobuf:"fixed64,10,opt,name=drawdown,proto3" json:"drawdown,omitempty"`
+	MaxDailyLoss  float64                `protobuf:"fixed64,11,opt,name=max_daily_loss,json=maxDailyLoss,proto3" json:"max_daily_loss,omitempty"`
+	MaxDrawdown   float64                `protobuf:"fixed64,12,opt,name=max_drawdown,json=maxDrawdown,proto3" json:"max_drawdown,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *KillSwitchStatus) Reset() {
+	*x = KillSwitchStatus{}
+	mi := &file_proto_admin_proto_msgTypes[1]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *KillSwitchStatus) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*KillSwitchStatus) ProtoMessage() {}
+
+func (x *KillSwitchStatus) ProtoReflect() protoreflect.Message {
+	mi := &file_proto_admin_proto_msgTypes[1]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use KillSwitchStatus.ProtoReflect.Descriptor instead.
+func (*KillSwitchStatus) Descriptor() ([]byte, []int) {
+	return file_proto_admin_proto_rawDescGZIP(), []int{1}
+}
+
+func (x *KillSwitchStatus) GetHalted() bool {
+	if x != nil {
+		return x.Halted
+	}
+	return false
+}
+
+func (x *KillSwitchStatus) GetHaltReason() string {
+	if x != nil {
+		return x.HaltReason
+	}
+	return ""
+}
+
+func (x *KillSwitchStatus) GetHaltedAt() string {
+	if x != nil {
+		return x.HaltedAt
+	}
+	return ""
+}
+
+func (x *KillSwitchStatus) GetTradingDay() string {
+	if x != nil {
+		return x.TradingDay
+	}
+	return ""
+}
+
+func (x *KillSwitchStatus) GetNav() float64 {
+	if x != nil {
+		return x.Nav
+	}
+	return 0
+}
+
+func (x *KillSwitchStatus) GetStartOfDayNav() float64 {
+	if x != nil {
+		return x.StartOfDayNav
+	}
+	return 0
+}
+
+func (x *KillSwitchStatus) GetPeakNav() float64 {
+	if x != nil {
+		return x.PeakNav
+	}
+	return 0
+}
+
+func (x *KillSwitchStatus) GetDailyPl() float64 {
+	if x != nil {
+		return x.DailyPl
+	}
+	return 0
+}
+
+func (x *KillSwitchStatus) GetDailyLoss() float64 {
+	if x != nil {
+		return x.DailyLoss
+	}
+	return 0
+}
+
+func (x *KillSwitchStatus) GetDrawdown() float64 {
+	if x != nil {
+		return x.Drawdown
+	}
+	return 0
+}
+
+func (x *KillSwitchStatus) GetMaxDailyLoss() float64 {
+	if x != nil {
+		return x.MaxDailyLoss
+	}
+	return 0
+}
+
+func (x *KillSwitchStatus) GetMaxDrawdown() float64 {
+	if x != nil {
+		return x.MaxDrawdown
+	}
+	return 0
+}
+
+var File_proto_admin_proto protoreflect.FileDescriptor
+
+const file_proto_admin_proto_rawDesc = "" +
+	"\n" +
+	"\x11proto/admin.proto\x12\vgotrader.v1\x1a\x12proto/common.proto\";\n" +
+	"\x11KillSwitchRequest\x12\x0e\n" +
+	"\x02by\x18\x01 \x01(\tR\x02by\x12\x16\n" +
+	"\x06reason\x18\x02 \x01(\tR\x06reason\"\xfe\x02\n" +
+	"\x10KillSwitchStatus\x12\x16\n" +
+	"\x06halted\x18\x01 \x01(\bR\x06halted\x12\x1f\n" +
+	"\vhalt_reason\x18\x02 \x01(\tR\n" +
+	"haltReason\x12\x1b\n" +
+	"\thalted_at\x18\x03 \x01(\tR\bhaltedAt\x12\x1f\n" +
+	"\vtrading_day\x18\x04 \x01(\tR\n" +
+	"tradingDay\x12\x10\n" +
+	"\x03nav\x18\x05 \x01(\x01R\x03nav\x12'\n" +
+	"\x10start_of_day_nav\x18\x06 \x01(\x01R\rstartOfDayNav\x12\x19\n" +
+	"\bpeak_nav\x18\a \x01(\x01R\apeakNav\x12\x19\n" +
+	"\bdaily_pl\x18\b \x01(\x01R\adailyPl\x12\x1d\n" +
+	"\n" +
+	"daily_loss\x18\t \x01(\x01R\tdailyLoss\x12\x1a\n" +
+	"\bdrawdown\x18\n" +
+	" \x01(\x01R\bdrawdown\x12$\n" +
+	"\x0emax_daily_loss\x18\v \x01(\x01R\fmaxDailyLoss\x12!\n" +
+	"\fmax_drawdown\x18\f \x01(\x01R\vmaxDrawdown2\xf2\x01\n" +
+	"\fAdminService\x12B\n" +
+	"\rGetKillSwitch\x12\x12.gotrader.v1.Empty\x1a\x1d.gotrader.v1.KillSwitchStatus\x12L\n" +
+	"\vHaltTrading\x12\x1e.gotrader.v1.KillSwitchRequest\x1a\x1d.gotrader.v1.KillSwitchStatus\x12P\n" +
+	"\x0fResetKillSwitch\x12\x1e.gotrader.v1.KillSwitchRequest\x1a\x1d.gotrader.v1.KillSwitchStatusB3Z1github.com/jedi116/go-trader/proto/gotrader/v1;v1b\x06proto3"
+
+var (
+	file_proto_admin_proto_rawDescOnce sync.Once
+	file_proto_admin_proto_rawDescData []byte
+)
+
+func file_proto_admin_proto_rawDescGZIP() []byte {
+	file_proto_admin_proto_rawDescOnce.Do(func() {
+		file_proto_admin_proto_rawDescData = protoimpl.X.CompressGZIP(unsafe.Slice(unsafe.StringData(file_proto_admin_proto_rawDesc), len(file_proto_admin_proto_rawDesc)))
+	})
+	return file_proto_admin_proto_rawDescData
+}
+
+var file_proto_admin_proto_msgTypes = make([]protoimpl.MessageInfo, 2)
+var file_proto_admin_proto_goTypes = []any{
+	(*KillSwitchRequest)(nil), // 0: gotrader.v1.KillSwitchRequest
+	(*KillSwitchStatus)(nil),  // 1: gotrader.v1.KillSwitchStatus
+	(*Empty)(nil),             // 2: gotrader.v1.Empty
+}
+var file_proto_admin_proto_depIdxs = []int32{
+	2, // 0: gotrader.v1.AdminService.GetKillSwitch:input_type -> gotrader.v1.Empty
+	0, // 1: gotrader.v1.AdminService.HaltTrading:input_type -> gotrader.v1.KillSwitchRequest
+	0, // 2: gotrader.v1.AdminService.ResetKillSwitch:input_type -> gotrader.v1.KillSwitchRequest
+	1, // 3: gotrader.v1.AdminService.GetKillSwitch:output_type -> gotrader.v1.KillSwitchStatus
+	1, // 4: gotrader.v1.AdminService.HaltTrading:output_type -> gotrader.v1.KillSwitchStatus
+	1, // 5: gotrader.v1.AdminService.ResetKillSwitch:output_type -> gotrader.v1.KillSwitchStatus
+	3, // [3:6] is the sub-list for method output_type
+	0, // [0:3] is the sub-list for method input_type
+	0, // [0:0] is the sub-list for extension type_name
+	0, // [0:0] is the sub-list for extension extendee
+	0, // [0:0] is the sub-list for field type_name
+}
+
+func init() { file_proto_admin_proto_init() }
+func file_proto_admin_proto_init() {
+	if File_proto_admin_proto != nil {
+		return
+	}
+	file_proto_common_proto_init()
+	type x struct{}
+	out := protoimpl.TypeBuilder{
+		File: protoimpl.DescBuilder{
+			GoPackagePath: reflect.TypeOf(x{}).PkgPath(),
+			RawDescriptor: unsafe.Slice(unsafe.StringData(file_proto_admin_proto_rawDesc), len(file_proto_admin_proto_rawDesc)),
+			NumEnums:      0,
+			NumMessages:   2,
+			NumExtensions: 0,
+			NumServices:   1,
+		},
+		GoTypes:           file_proto_admin_proto_goTypes,
+		DependencyIndexes: file_proto_admin_proto_depIdxs,
+		MessageInfos:      file_proto_admin_proto_msgTypes,
+	}.Build()
+	File_proto_admin_proto = out.File
+	file_proto_admin_proto_goTypes = nil
+	file_proto_admin_proto_depIdxs = nil
+}
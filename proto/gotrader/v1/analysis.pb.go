@@ -0,0 +1,217 @@
+// Code generated by protoc-gen-go. DO NOT EDIT.
+// versions:
+// 	protoc-gen-go v1.36.6
+// 	protoc        v5.27.3
+// source: proto/analysis.proto
+
+package v1
+
+import (
+	protoreflect "google.golang.org/protobuf/reflect/protoreflect"
+	protoimpl "google.golang.org/protobuf/runtime/protoimpl"
+	reflect "reflect"
+	sync "sync"
+	unsafe "unsafe"
+)
+
+const (
+	// Verify that this generated code is sufficiently up-to-date.
+	_ = protoimpl.EnforceVersion(20 - protoimpl.MinVersion)
+	// Verify that runtime/protoimpl is sufficiently up-to-date.
+	_ = protoimpl.EnforceVersion(protoimpl.MaxVersion - 20)
+)
+
+type GetCandlesRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Instrument    string                 `protobuf:"bytes,1,opt,name=instrument,proto3" json:"instrument,omitempty"`
+	Granularity   string                 `protobuf:"bytes,2,opt,name=granularity,proto3" json:"granularity,omitempty"` // M1, M5, H1, D
+	Count         int32                  `protobuf:"varint,3,opt,name=count,proto3" json:"count,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *GetCandlesRequest) Reset() {
+	*x = GetCandlesRequest{}
+	mi := &file_proto_analysis_proto_msgTypes[0]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *GetCandlesRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*GetCandlesRequest) ProtoMessage() {}
+
+func (x *GetCandlesRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_proto_analysis_proto_msgTypes[0]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use GetCandlesRequest.ProtoReflect.Descriptor instead.
+func (*GetCandlesRequest) Descriptor() ([]byte, []int) {
+	return file_proto_analysis_proto_rawDescGZIP(), []int{0}
+}
+
+func (x *GetCandlesRequest) GetInstrument() string {
+	if x != nil {
+		return x.Instrument
+	}
+	return ""
+}
+
+func (x *GetCandlesRequest) GetGranularity() string {
+	if x != nil {
+		return x.Granularity
+	}
+	return ""
+}
+
+func (x *GetCandlesRequest) GetCount() int32 {
+	if x != nil {
+		return x.Count
+	}
+	return 0
+}
+
+type GetCandlesResponse struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Instrument    string                 `protobuf:"bytes,1,opt,name=instrument,proto3" json:"instrument,omitempty"`
+	Granularity   string                 `protobuf:"bytes,2,opt,name=granularity,proto3" json:"granularity,omitempty"`
+	Candles       []*Candle              `protobuf:"bytes,3,rep,name=candles,proto3" json:"candles,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *GetCandlesResponse) Reset() {
+	*x = GetCandlesResponse{}
+	mi := &file_proto_analysis_proto_msgTypes[1]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *GetCandlesResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*GetCandlesResponse) ProtoMessage() {}
+
+func (x *GetCandlesResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_proto_analysis_proto_msgTypes[1]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use GetCandlesResponse.ProtoReflect.Descriptor instead.
+func (*GetCandlesResponse) Descriptor() ([]byte, []int) {
+	return file_proto_analysis_proto_rawDescGZIP(), []int{1}
+}
+
+func (x *GetCandlesResponse) GetInstrument() string {
+	if x != nil {
+		return x.Instrument
+	}
+	return ""
+}
+
+func (x *GetCandlesResponse) GetGranularity() string {
+	if x != nil {
+		return x.Granularity
+	}
+	return ""
+}
+
+func (x *GetCandlesResponse) GetCandles() []*Candle {
+	if x != nil {
+		return x.Candles
+	}
+	return nil
+}
+
+var File_proto_analysis_proto protoreflect.FileDescriptor
+
+const file_proto_analysis_proto_rawDesc = "" +
+	"\n" +
+	"\x14proto/analysis.proto\x12\vgotrader.v1\x1a\x12proto/common.proto\"k\n" +
+	"\x11GetCandlesRequest\x12\x1e\n" +
+	"\n" +
+	"instrument\x18\x01 \x01(\tR\n" +
+	"instrument\x12 \n" +
+	"\vgranularity\x18\x02 \x01(\tR\vgranularity\x12\x14\n" +
+	"\x05count\x18\x03 \x01(\x05R\x05count\"\x85\x01\n" +
+	"\x12GetCandlesResponse\x12\x1e\n" +
+	"\n" +
+	"instrument\x18\x01 \x01(\tR\n" +
+	"instrument\x12 \n" +
+	"\vgranularity\x18\x02 \x01(\tR\vgranularity\x12-\n" +
+	"\acandles\x18\x03 \x03(\v2\x13.gotrader.v1.CandleR\acandles2`\n" +
+	"\x0fAnalysisService\x12M\n" +
+	"\n" +
+	"GetCandles\x12\x1e.gotrader.v1.GetCandlesRequest\x1a\x1f.gotrader.v1.GetCandlesResponseB3Z1github.com/jedi116/go-trader/proto/gotrader/v1;v1b\x06proto3"
+
+var (
+	file_proto_analysis_proto_rawDescOnce sync.Once
+	file_proto_analysis_proto_rawDescData []byte
+)
+
+func file_proto_analysis_proto_rawDescGZIP() []byte {
+	file_proto_analysis_proto_rawDescOnce.Do(func() {
+		file_proto_analysis_proto_rawDescData = protoimpl.X.CompressGZIP(unsafe.Slice(unsafe.StringData(file_proto_analysis_proto_rawDesc), len(file_proto_analysis_proto_rawDesc)))
+	})
+	return file_proto_analysis_proto_rawDescData
+}
+
+var file_proto_analysis_proto_msgTypes = make([]protoimpl.MessageInfo, 2)
+var file_proto_analysis_proto_goTypes = []any{
+	(*GetCandlesRequest)(nil),  // 0: gotrader.v1.GetCandlesRequest
+	(*GetCandlesResponse)(nil), // 1: gotrader.v1.GetCandlesResponse
+	(*Candle)(nil),             // 2: gotrader.v1.Candle
+}
+var file_proto_analysis_proto_depIdxs = []int32{
+	2, // 0: gotrader.v1.GetCandlesResponse.candles:type_name -> gotrader.v1.Candle
+	0, // 1: gotrader.v1.AnalysisService.GetCandles:input_type -> gotrader.v1.GetCandlesRequest
+	1, // 2: gotrader.v1.AnalysisService.GetCandles:output_type -> gotrader.v1.GetCandlesResponse
+	2, // [2:3] is the sub-list for method output_type
+	1, // [1:2] is the sub-list for method input_type
+	1, // [1:1] is the sub-list for extension type_name
+	1, // [1:1] is the sub-list for extension extendee
+	0, // [0:1] is the sub-list for field type_name
+}
+
+func init() { file_proto_analysis_proto_init() }
+func file_proto_analysis_proto_init() {
+	if File_proto_analysis_proto != nil {
+		return
+	}
+	file_proto_common_proto_init()
+	type x struct{}
+	out := protoimpl.TypeBuilder{
+		File: protoimpl.DescBuilder{
+			GoPackagePath: reflect.TypeOf(x{}).PkgPath(),
+			RawDescriptor: unsafe.Slice(unsafe.StringData(file_proto_analysis_proto_rawDesc), len(file_proto_analysis_proto_rawDesc)),
+			NumEnums:      0,
+			NumMessages:   2,
+			NumExtensions: 0,
+			NumServices:   1,
+		},
+		GoTypes:           file_proto_analysis_proto_goTypes,
+		DependencyIndexes: file_proto_analysis_proto_depIdxs,
+		MessageInfos:      file_proto_analysis_proto_msgTypes,
+	}.Build()
+	File_proto_analysis_proto = out.File
+	file_proto_analysis_proto_goTypes = nil
+	file_proto_analysis_proto_depIdxs = nil
+}
@@ -1,3 +0,0 @@
-package v1
-
-// Placeholder package to satisfy imports until protobufs are generated.
@@ -0,0 +1,414 @@
+// Code generated by protoc-gen-go. DO NOT EDIT.
+// versions:
+// 	protoc-gen-go v1.36.6
+// 	protoc        v5.27.3
+// source: proto/recommendation.proto
+
+package v1
+
+import (
+	protoreflect "google.golang.org/protobuf/reflect/protoreflect"
+	protoimpl "google.golang.org/protobuf/runtime/protoimpl"
+	reflect "reflect"
+	sync "sync"
+	unsafe "unsafe"
+)
+
+const (
+	// Verify that this generated code is sufficiently up-to-date.
+	_ = protoimpl.EnforceVersion(20 - protoimpl.MinVersion)
+	// Verify that runtime/protoimpl is sufficiently up-to-date.
+	_ = protoimpl.EnforceVersion(protoimpl.MaxVersion - 20)
+)
+
+type CreateRecommendationRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Instrument    string                 `protobuf:"bytes,1,opt,name=instrument,proto3" json:"instrument,omitempty"`
+	Direction     Direction              `protobuf:"varint,2,opt,name=direction,proto3,enum=gotrader.v1.Direction" json:"direction,omitempty"`
+	Units         float64                `protobuf:"fixed64,3,opt,name=units,proto3" json:"units,omitempty"`
+	Rationale     string                 `protobuf:"bytes,4,opt,name=rationale,proto3" json:"rationale,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *CreateRecommendationRequest) Reset() {
+	*x = CreateRecommendationRequest{}
+	mi := &file_proto_recommendation_proto_msgTypes[0]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *CreateRecommendationRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*CreateRecommendationRequest) ProtoMessage() {}
+
+func (x *CreateRecommendationRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_proto_recommendation_proto_msgTypes[0]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use CreateRecommendationRequest.ProtoReflect.Descriptor instead.
+func (*CreateRecommendationRequest) Descriptor() ([]byte, []int) {
+	return file_proto_recommendation_proto_rawDescGZIP(), []int{0}
+}
+
+func (x *CreateRecommendationRequest) GetInstrument() string {
+	if x != nil {
+		return x.Instrument
+	}
+	return ""
+}
+
+func (x *CreateRecommendationRequest) GetDirection() Direction {
+	if x != nil {
+		return x.Direction
+	}
+	return Direction_DIRECTION_UNSPECIFIED
+}
+
+func (x *CreateRecommendationRequest) GetUnits() float64 {
+	if x != nil {
+		return x.Units
+	}
+	return 0
+}
+
+func (x *CreateRecommendationRequest) GetRationale() string {
+	if x != nil {
+		return x.Rationale
+	}
+	return ""
+}
+
+type CreateRecommendationResponse struct {
+	state          protoimpl.MessageState `protogen:"open.v1"`
+	Recommendation *Recommendation        `protobuf:"bytes,1,opt,name=recommendation,proto3" json:"recommendation,omitempty"`
+	unknownFields  protoimpl.UnknownFields
+	sizeCache      protoimpl.SizeCache
+}
+
+func (x *CreateRecommendationResponse) Reset() {
+	*x = CreateRecommendationResponse{}
+	mi := &file_proto_recommendation_proto_msgTypes[1]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *CreateRecommendationResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*CreateRecommendationResponse) ProtoMessage() {}
+
+func (x *CreateRecommendationResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_proto_recommendation_proto_msgTypes[1]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use CreateRecommendationResponse.ProtoReflect.Descriptor instead.
+func (*CreateRecommendationResponse) Descriptor() ([]byte, []int) {
+	return file_proto_recommendation_proto_rawDescGZIP(), []int{1}
+}
+
+func (x *CreateRecommendationResponse) GetRecommendation() *Recommendation {
+	if x != nil {
+		return x.Recommendation
+	}
+	return nil
+}
+
+type ListRecommendationsRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Limit         int32                  `protobuf:"varint,1,opt,name=limit,proto3" json:"limit,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *ListRecommendationsRequest) Reset() {
+	*x = ListRecommendationsRequest{}
+	mi := &file_proto_recommendation_proto_msgTypes[2]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *ListRecommendationsRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ListRecommendationsRequest) ProtoMessage() {}
+
+func (x *ListRecommendationsRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_proto_recommendation_proto_msgTypes[2]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ListRecommendationsRequest.ProtoReflect.Descriptor instead.
+func (*ListRecommendationsRequest) Descriptor() ([]byte, []int) {
+	return file_proto_recommendation_proto_rawDescGZIP(), []int{2}
+}
+
+func (x *ListRecommendationsRequest) GetLimit() int32 {
+	if x != nil {
+		return x.Limit
+	}
+	return 0
+}
+
+type ListRecommendationsResponse struct {
+	state           protoimpl.MessageState `protogen:"open.v1"`
+	Recommendations []*Recommendation      `protobuf:"bytes,1,rep,name=recommendations,proto3" json:"recommendations,omitempty"`
+	unknownFields   protoimpl.UnknownFields
+	sizeCache       protoimpl.SizeCache
+}
+
+func (x *ListRecommendationsResponse) Reset() {
+	*x = ListRecommendationsResponse{}
+	mi := &file_proto_recommendation_proto_msgTypes[3]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *ListRecommendationsResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ListRecommendationsResponse) ProtoMessage() {}
+
+func (x *ListRecommendationsResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_proto_recommendation_proto_msgTypes[3]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ListRecommendationsResponse.ProtoReflect.Descriptor instead.
+func (*ListRecommendationsResponse) Descriptor() ([]byte, []int) {
+	return file_proto_recommendation_proto_rawDescGZIP(), []int{3}
+}
+
+func (x *ListRecommendationsResponse) GetRecommendations() []*Recommendation {
+	if x != nil {
+		return x.Recommendations
+	}
+	return nil
+}
+
+type AcceptRecommendationRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Id            string                 `protobuf:"bytes,1,opt,name=id,proto3" json:"id,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *AcceptRecommendationRequest) Reset() {
+	*x = AcceptRecommendationRequest{}
+	mi := &file_proto_recommendation_proto_msgTypes[4]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *AcceptRecommendationRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*AcceptRecommendationRequest) ProtoMessage() {}
+
+func (x *AcceptRecommendationRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_proto_recommendation_proto_msgTypes[4]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use AcceptRecommendationRequest.ProtoReflect.Descriptor instead.
+func (*AcceptRecommendationRequest) Descriptor() ([]byte, []int) {
+	return file_proto_recommendation_proto_rawDescGZIP(), []int{4}
+}
+
+func (x *AcceptRecommendationRequest) GetId() string {
+	if x != nil {
+		return x.Id
+	}
+	return ""
+}
+
+type AcceptRecommendationResponse struct {
+	state          protoimpl.MessageState `protogen:"open.v1"`
+	Trade          *Trade                 `protobuf:"bytes,1,opt,name=trade,proto3" json:"trade,omitempty"`
+	Recommendation *Recommendation        `protobuf:"bytes,2,opt,name=recommendation,proto3" json:"recommendation,omitempty"`
+	unknownFields  protoimpl.UnknownFields
+	sizeCache      protoimpl.SizeCache
+}
+
+func (x *AcceptRecommendationResponse) Reset() {
+	*x = AcceptRecommendationResponse{}
+	mi := &file_proto_recommendation_proto_msgTypes[5]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *AcceptRecommendationResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*AcceptRecommendationResponse) ProtoMessage() {}
+
+func (x *AcceptRecommendationResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_proto_recommendation_proto_msgTypes[5]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use AcceptRecommendationResponse.ProtoReflect.Descriptor instead.
+func (*AcceptRecommendationResponse) Descriptor() ([]byte, []int) {
+	return file_proto_recommendation_proto_rawDescGZIP(), []int{5}
+}
+
+func (x *AcceptRecommendationResponse) GetTrade() *Trade {
+	if x != nil {
+		return x.Trade
+	}
+	return nil
+}
+
+func (x *AcceptRecommendationResponse) GetRecommendation() *Recommendation {
+	if x != nil {
+		return x.Recommendation
+	}
+	return nil
+}
+
+var File_proto_recommendation_proto protoreflect.FileDescriptor
+
+const file_proto_recommendation_proto_rawDesc = "" +
+	"\n" +
+	"\x1aproto/recommendation.proto\x12\vgotrader.v1\x1a\x12proto/common.proto\"\xa7\x01\n" +
+	"\x1bCreateRecommendationRequest\x12\x1e\n" +
+	"\n" +
+	"instrument\x18\x01 \x01(\tR\n" +
+	"instrument\x124\n" +
+	"\tdirection\x18\x02 \x01(\x0e2\x16.gotrader.v1.DirectionR\tdirection\x12\x14\n" +
+	"\x05units\x18\x03 \x01(\x01R\x05units\x12\x1c\n" +
+	"\trationale\x18\x04 \x01(\tR\trationale\"c\n" +
+	"\x1cCreateRecommendationResponse\x12C\n" +
+	"\x0erecommendation\x18\x01 \x01(\v2\x1b.gotrader.v1.RecommendationR\x0erecommendation\"2\n" +
+	"\x1aListRecommendationsRequest\x12\x14\n" +
+	"\x05limit\x18\x01 \x01(\x05R\x05limit\"d\n" +
+	"\x1bListRecommendationsResponse\x12E\n" +
+	"\x0frecommendations\x18\x01 \x03(\v2\x1b.gotrader.v1.RecommendationR\x0frecommendations\"-\n" +
+	"\x1bAcceptRecommendationRequest\x12\x0e\n" +
+	"\x02id\x18\x01 \x01(\tR\x02id\"\x8d\x01\n" +
+	"\x1cAcceptRecommendationResponse\x12(\n" +
+	"\x05trade\x18\x01 \x01(\v2\x12.gotrader.v1.TradeR\x05trade\x12C\n" +
+	"\x0erecommendation\x18\x02 \x01(\v2\x1b.gotrader.v1.RecommendationR\x0erecommendation2\xdb\x02\n" +
+	"\x15RecommendationService\x12k\n" +
+	"\x14CreateRecommendation\x12(.gotrader.v1.CreateRecommendationRequest\x1a).gotrader.v1.CreateRecommendationResponse\x12h\n" +
+	"\x13ListRecommendations\x12'.gotrader.v1.ListRecommendationsRequest\x1a(.gotrader.v1.ListRecommendationsResponse\x12k\n" +
+	"\x14AcceptRecommendation\x12(.gotrader.v1.AcceptRecommendationRequest\x1a).gotrader.v1.AcceptRecommendationResponseB3Z1github.com/jedi116/go-trader/proto/gotrader/v1;v1b\x06proto3"
+
+var (
+	file_proto_recommendation_proto_rawDescOnce sync.Once
+	file_proto_recommendation_proto_rawDescData []byte
+)
+
+func file_proto_recommendation_proto_rawDescGZIP() []byte {
+	file_proto_recommendation_proto_rawDescOnce.Do(func() {
+		file_proto_recommendation_proto_rawDescData = protoimpl.X.CompressGZIP(unsafe.Slice(unsafe.StringData(file_proto_recommendation_proto_rawDesc), len(file_proto_recommendation_proto_rawDesc)))
+	})
+	return file_proto_recommendation_proto_rawDescData
+}
+
+var file_proto_recommendation_proto_msgTypes = make([]protoimpl.MessageInfo, 6)
+var file_proto_recommendation_proto_goTypes = []any{
+	(*CreateRecommendationRequest)(nil),  // 0: gotrader.v1.CreateRecommendationRequest
+	(*CreateRecommendationResponse)(nil), // 1: gotrader.v1.CreateRecommendationResponse
+	(*ListRecommendationsRequest)(nil),   // 2: gotrader.v1.ListRecommendationsRequest
+	(*ListRecommendationsResponse)(nil),  // 3: gotrader.v1.ListRecommendationsResponse
+	(*AcceptRecommendationRequest)(nil),  // 4: gotrader.v1.AcceptRecommendationRequest
+	(*AcceptRecommendationResponse)(nil), // 5: gotrader.v1.AcceptRecommendationResponse
+	(Direction)(0),                       // 6: gotrader.v1.Direction
+	(*Recommendation)(nil),               // 7: gotrader.v1.Recommendation
+	(*Trade)(nil),                        // 8: gotrader.v1.Trade
+}
+var file_proto_recommendation_proto_depIdxs = []int32{
+	6, // 0: gotrader.v1.CreateRecommendationRequest.direction:type_name -> gotrader.v1.Direction
+	7, // 1: gotrader.v1.CreateRecommendationResponse.recommendation:type_name -> gotrader.v1.Recommendation
+	7, // 2: gotrader.v1.ListRecommendationsResponse.recommendations:type_name -> gotrader.v1.Recommendation
+	8, // 3: gotrader.v1.AcceptRecommendationResponse.trade:type_name -> gotrader.v1.Trade
+	7, // 4: gotrader.v1.AcceptRecommendationResponse.recommendation:type_name -> gotrader.v1.Recommendation
+	0, // 5: gotrader.v1.RecommendationService.CreateRecommendation:input_type -> gotrader.v1.CreateRecommendationRequest
+	2, // 6: gotrader.v1.RecommendationService.ListRecommendations:input_type -> gotrader.v1.ListRecommendationsRequest
+	4, // 7: gotrader.v1.RecommendationService.AcceptRecommendation:input_type -> gotrader.v1.AcceptRecommendationRequest
+	1, // 8: gotrader.v1.RecommendationService.CreateRecommendation:output_type -> gotrader.v1.CreateRecommendationResponse
+	3, // 9: gotrader.v1.RecommendationService.ListRecommendations:output_type -> gotrader.v1.ListRecommendationsResponse
+	5, // 10: gotrader.v1.RecommendationService.AcceptRecommendation:output_type -> gotrader.v1.AcceptRecommendationResponse
+	8, // [8:11] is the sub-list for method output_type
+	5, // [5:8] is the sub-list for method input_type
+	5, // [5:5] is the sub-list for extension type_name
+	5, // [5:5] is the sub-list for extension extendee
+	0, // [0:5] is the sub-list for field type_name
+}
+
+func init() { file_proto_recommendation_proto_init() }
+func file_proto_recommendation_proto_init() {
+	if File_proto_recommendation_proto != nil {
+		return
+	}
+	file_proto_common_proto_init()
+	type x struct{}
+	out := protoimpl.TypeBuilder{
+		File: protoimpl.DescBuilder{
+			GoPackagePath: reflect.TypeOf(x{}).PkgPath(),
+			RawDescriptor: unsafe.Slice(unsafe.StringData(file_proto_recommendation_proto_rawDesc), len(file_proto_recommendation_proto_rawDesc)),
+			NumEnums:      0,
+			NumMessages:   6,
+			NumExtensions: 0,
+			NumServices:   1,
+		},
+		GoTypes:           file_proto_recommendation_proto_goTypes,
+		DependencyIndexes: file_proto_recommendation_proto_depIdxs,
+		MessageInfos:      file_proto_recommendation_proto_msgTypes,
+	}.Build()
+	File_proto_recommendation_proto = out.File
+	file_proto_recommendation_proto_goTypes = nil
+	file_proto_recommendation_proto_depIdxs = nil
+}
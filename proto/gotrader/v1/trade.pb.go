@@ -0,0 +1,310 @@
+// Code generated by protoc-gen-go. DO NOT EDIT.
+// versions:
+// 	protoc-gen-go v1.36.6
+// 	protoc        v5.27.3
+// source: proto/trade.proto
+
+package v1
+
+import (
+	protoreflect "google.golang.org/protobuf/reflect/protoreflect"
+	protoimpl "google.golang.org/protobuf/runtime/protoimpl"
+	reflect "reflect"
+	sync "sync"
+	unsafe "unsafe"
+)
+
+const (
+	// Verify that this generated code is sufficiently up-to-date.
+	_ = protoimpl.EnforceVersion(20 - protoimpl.MinVersion)
+	// Verify that runtime/protoimpl is sufficiently up-to-date.
+	_ = protoimpl.EnforceVersion(protoimpl.MaxVersion - 20)
+)
+
+type PlaceOrderRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Instrument    string                 `protobuf:"bytes,1,opt,name=instrument,proto3" json:"instrument,omitempty"`
+	Units         float64                `protobuf:"fixed64,2,opt,name=units,proto3" json:"units,omitempty"` // positive buy, negative sell
+	StopLoss      *float64               `protobuf:"fixed64,3,opt,name=stop_loss,json=stopLoss,proto3,oneof" json:"stop_loss,omitempty"`
+	TakeProfit    *float64               `protobuf:"fixed64,4,opt,name=take_profit,json=takeProfit,proto3,oneof" json:"take_profit,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *PlaceOrderRequest) Reset() {
+	*x = PlaceOrderRequest{}
+	mi := &file_proto_trade_proto_msgTypes[0]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *PlaceOrderRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*PlaceOrderRequest) ProtoMessage() {}
+
+func (x *PlaceOrderRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_proto_trade_proto_msgTypes[0]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use PlaceOrderRequest.ProtoReflect.Descriptor instead.
+func (*PlaceOrderRequest) Descriptor() ([]byte, []int) {
+	return file_proto_trade_proto_rawDescGZIP(), []int{0}
+}
+
+func (x *PlaceOrderRequest) GetInstrument() string {
+	if x != nil {
+		return x.Instrument
+	}
+	return ""
+}
+
+func (x *PlaceOrderRequest) GetUnits() float64 {
+	if x != nil {
+		return x.Units
+	}
+	return 0
+}
+
+func (x *PlaceOrderRequest) GetStopLoss() float64 {
+	if x != nil && x.StopLoss != nil {
+		return *x.StopLoss
+	}
+	return 0
+}
+
+func (x *PlaceOrderRequest) GetTakeProfit() float64 {
+	if x != nil && x.TakeProfit != nil {
+		return *x.TakeProfit
+	}
+	return 0
+}
+
+type PlaceOrderResponse struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Trade         *Trade                 `protobuf:"bytes,1,opt,name=trade,proto3" json:"trade,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *PlaceOrderResponse) Reset() {
+	*x = PlaceOrderResponse{}
+	mi := &file_proto_trade_proto_msgTypes[1]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *PlaceOrderResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*PlaceOrderResponse) ProtoMessage() {}
+
+func (x *PlaceOrderResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_proto_trade_proto_msgTypes[1]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use PlaceOrderResponse.ProtoReflect.Descriptor instead.
+func (*PlaceOrderResponse) Descriptor() ([]byte, []int) {
+	return file_proto_trade_proto_rawDescGZIP(), []int{1}
+}
+
+func (x *PlaceOrderResponse) GetTrade() *Trade {
+	if x != nil {
+		return x.Trade
+	}
+	return nil
+}
+
+type ListTradesRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Limit         int32                  `protobuf:"varint,1,opt,name=limit,proto3" json:"limit,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *ListTradesRequest) Reset() {
+	*x = ListTradesRequest{}
+	mi := &file_proto_trade_proto_msgTypes[2]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *ListTradesRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ListTradesRequest) ProtoMessage() {}
+
+func (x *ListTradesRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_proto_trade_proto_msgTypes[2]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ListTradesRequest.ProtoReflect.Descriptor instead.
+func (*ListTradesRequest) Descriptor() ([]byte, []int) {
+	return file_proto_trade_proto_rawDescGZIP(), []int{2}
+}
+
+func (x *ListTradesRequest) GetLimit() int32 {
+	if x != nil {
+		return x.Limit
+	}
+	return 0
+}
+
+type ListTradesResponse struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Trades        []*Trade               `protobuf:"bytes,1,rep,name=trades,proto3" json:"trades,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *ListTradesResponse) Reset() {
+	*x = ListTradesResponse{}
+	mi := &file_proto_trade_proto_msgTypes[3]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *ListTradesResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ListTradesResponse) ProtoMessage() {}
+
+func (x *ListTradesResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_proto_trade_proto_msgTypes[3]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ListTradesResponse.ProtoReflect.Descriptor instead.
+func (*ListTradesResponse) Descriptor() ([]byte, []int) {
+	return file_proto_trade_proto_rawDescGZIP(), []int{3}
+}
+
+func (x *ListTradesResponse) GetTrades() []*Trade {
+	if x != nil {
+		return x.Trades
+	}
+	return nil
+}
+
+var File_proto_trade_proto protoreflect.FileDescriptor
+
+const file_proto_trade_proto_rawDesc = "" +
+	"\n" +
+	"\x11proto/trade.proto\x12\vgotrader.v1\x1a\x12proto/common.proto\"\xaf\x01\n" +
+	"\x11PlaceOrderRequest\x12\x1e\n" +
+	"\n" +
+	"instrument\x18\x01 \x01(\tR\n" +
+	"instrument\x12\x14\n" +
+	"\x05units\x18\x02 \x01(\x01R\x05units\x12 \n" +
+	"\tstop_loss\x18\x03 \x01(\x01H\x00R\bstopLoss\x88\x01\x01\x12$\n" +
+	"\vtake_profit\x18\x04 \x01(\x01H\x01R\n" +
+	"takeProfit\x88\x01\x01B\f\n" +
+	"\n" +
+	"_stop_lossB\x0e\n" +
+	"\f_take_profit\">\n" +
+	"\x12PlaceOrderResponse\x12(\n" +
+	"\x05trade\x18\x01 \x01(\v2\x12.gotrader.v1.TradeR\x05trade\")\n" +
+	"\x11ListTradesRequest\x12\x14\n" +
+	"\x05limit\x18\x01 \x01(\x05R\x05limit\"@\n" +
+	"\x12ListTradesResponse\x12*\n" +
+	"\x06trades\x18\x01 \x03(\v2\x12.gotrader.v1.TradeR\x06trades2\xac\x01\n" +
+	"\fTradeService\x12M\n" +
+	"\n" +
+	"PlaceOrder\x12\x1e.gotrader.v1.PlaceOrderRequest\x1a\x1f.gotrader.v1.PlaceOrderResponse\x12M\n" +
+	"\n" +
+	"ListTrades\x12\x1e.gotrader.v1.ListTradesRequest\x1a\x1f.gotrader.v1.ListTradesResponseB3Z1github.com/jedi116/go-trader/proto/gotrader/v1;v1b\x06proto3"
+
+var (
+	file_proto_trade_proto_rawDescOnce sync.Once
+	file_proto_trade_proto_rawDescData []byte
+)
+
+func file_proto_trade_proto_rawDescGZIP() []byte {
+	file_proto_trade_proto_rawDescOnce.Do(func() {
+		file_proto_trade_proto_rawDescData = protoimpl.X.CompressGZIP(unsafe.Slice(unsafe.StringData(file_proto_trade_proto_rawDesc), len(file_proto_trade_proto_rawDesc)))
+	})
+	return file_proto_trade_proto_rawDescData
+}
+
+var file_proto_trade_proto_msgTypes = make([]protoimpl.MessageInfo, 4)
+var file_proto_trade_proto_goTypes = []any{
+	(*PlaceOrderRequest)(nil),  // 0: gotrader.v1.PlaceOrderRequest
+	(*PlaceOrderResponse)(nil), // 1: gotrader.v1.PlaceOrderResponse
+	(*ListTradesRequest)(nil),  // 2: gotrader.v1.ListTradesRequest
+	(*ListTradesResponse)(nil), // 3: gotrader.v1.ListTradesResponse
+	(*Trade)(nil),              // 4: gotrader.v1.Trade
+}
+var file_proto_trade_proto_depIdxs = []int32{
+	4, // 0: gotrader.v1.PlaceOrderResponse.trade:type_name -> gotrader.v1.Trade
+	4, // 1: gotrader.v1.ListTradesResponse.trades:type_name -> gotrader.v1.Trade
+	0, // 2: gotrader.v1.TradeService.PlaceOrder:input_type -> gotrader.v1.PlaceOrderRequest
+	2, // 3: gotrader.v1.TradeService.ListTrades:input_type -> gotrader.v1.ListTradesRequest
+	1, // 4: gotrader.v1.TradeService.PlaceOrder:output_type -> gotrader.v1.PlaceOrderResponse
+	3, // 5: gotrader.v1.TradeService.ListTrades:output_type -> gotrader.v1.ListTradesResponse
+	4, // [4:6] is the sub-list for method output_type
+	2, // [2:4] is the sub-list for method input_type
+	2, // [2:2] is the sub-list for extension type_name
+	2, // [2:2] is the sub-list for extension extendee
+	0, // [0:2] is the sub-list for field type_name
+}
+
+func init() { file_proto_trade_proto_init() }
+func file_proto_trade_proto_init() {
+	if File_proto_trade_proto != nil {
+		return
+	}
+	file_proto_common_proto_init()
+	file_proto_trade_proto_msgTypes[0].OneofWrappers = []any{}
+	type x struct{}
+	out := protoimpl.TypeBuilder{
+		File: protoimpl.DescBuilder{
+			GoPackagePath: reflect.TypeOf(x{}).PkgPath(),
+			RawDescriptor: unsafe.Slice(unsafe.StringData(file_proto_trade_proto_rawDesc), len(file_proto_trade_proto_rawDesc)),
+			NumEnums:      0,
+			NumMessages:   4,
+			NumExtensions: 0,
+			NumServices:   1,
+		},
+		GoTypes:           file_proto_trade_proto_goTypes,
+		DependencyIndexes: file_proto_trade_proto_depIdxs,
+		MessageInfos:      file_proto_trade_proto_msgTypes,
+	}.Build()
+	File_proto_trade_proto = out.File
+	file_proto_trade_proto_goTypes = nil
+	file_proto_trade_proto_depIdxs = nil
+}
@@ -1,11 +1,11 @@
 #!/usr/bin/env bash
 set -euo pipefail
 
+# Generates the Go stubs into proto/gotrader/v1 (the go_package of every file).
 protoc \
-  --go_out=. --go_opt=paths=source_relative \
-  --go-grpc_out=. --go-grpc_opt=paths=source_relative \
-  proto/common.proto proto/trade.proto proto/recommendation.proto proto/analysis.proto
+  --go_out=. --go_opt=module=github.com/jedi116/go-trader \
+  --go-grpc_out=. --go-grpc_opt=module=github.com/jedi116/go-trader \
+  proto/common.proto proto/trade.proto proto/recommendation.proto proto/analysis.proto \
+  proto/account.proto proto/admin.proto
 
 echo "Protobuf generation complete"
-
-
@@ -0,0 +1,6 @@
+-- real token accounting
+ALTER TABLE IF EXISTS ai_usage_logs ADD COLUMN IF NOT EXISTS cache_creation_tokens INTEGER NOT NULL DEFAULT 0;
+ALTER TABLE IF EXISTS ai_usage_logs ADD COLUMN IF NOT EXISTS cache_read_tokens INTEGER NOT NULL DEFAULT 0;
+ALTER TABLE IF EXISTS ai_usage_logs ADD COLUMN IF NOT EXISTS cost_usd DECIMAL(12,6) NOT NULL DEFAULT 0;
+
+CREATE INDEX IF NOT EXISTS idx_ai_usage_created ON ai_usage_logs(created_at DESC);
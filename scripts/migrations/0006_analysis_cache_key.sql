@@ -0,0 +1,5 @@
+-- keyed lookups for market_analysis_cache
+ALTER TABLE IF EXISTS market_analysis_cache ADD COLUMN IF NOT EXISTS cache_key TEXT;
+
+CREATE INDEX IF NOT EXISTS idx_analysis_cache_key ON market_analysis_cache(cache_key, expires_at DESC);
+CREATE INDEX IF NOT EXISTS idx_analysis_cache_expires ON market_analysis_cache(expires_at);
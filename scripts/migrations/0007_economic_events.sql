@@ -0,0 +1,18 @@
+-- economic calendar
+CREATE TABLE IF NOT EXISTS economic_events (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    title VARCHAR(200) NOT NULL,
+    currency VARCHAR(3) NOT NULL,
+    impact VARCHAR(10) NOT NULL CHECK (impact IN ('LOW','MEDIUM','HIGH')),
+    event_time TIMESTAMPTZ NOT NULL,
+    actual VARCHAR(50),
+    forecast VARCHAR(50),
+    previous VARCHAR(50),
+    source VARCHAR(100) NOT NULL DEFAULT '',
+    created_at TIMESTAMPTZ DEFAULT NOW(),
+    updated_at TIMESTAMPTZ DEFAULT NOW(),
+    UNIQUE(currency, title, event_time)
+);
+
+CREATE INDEX IF NOT EXISTS idx_economic_events_time ON economic_events(event_time);
+CREATE INDEX IF NOT EXISTS idx_economic_events_currency_time ON economic_events(currency, event_time);
@@ -0,0 +1,14 @@
+-- daily loss / drawdown tracking and kill switch, one row per broker account
+CREATE TABLE IF NOT EXISTS account_guardian_state (
+    account_id VARCHAR(50) PRIMARY KEY,
+    trading_day DATE NOT NULL,
+    start_of_day_nav DECIMAL(15,2) NOT NULL,
+    start_of_day_balance DECIMAL(15,2) NOT NULL,
+    peak_nav DECIMAL(15,2) NOT NULL,
+    halted BOOLEAN NOT NULL DEFAULT FALSE,
+    halt_reason TEXT,
+    halted_at TIMESTAMPTZ,
+    reset_by VARCHAR(100),
+    reset_at TIMESTAMPTZ,
+    updated_at TIMESTAMPTZ DEFAULT NOW()
+);
@@ -0,0 +1,16 @@
+-- orders received while the market is closed, submitted at the next open
+CREATE TABLE IF NOT EXISTS queued_orders (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    instrument VARCHAR(50) NOT NULL,
+    units DECIMAL(15,2) NOT NULL,
+    stop_loss DECIMAL(15,8),
+    take_profit DECIMAL(15,8),
+    source VARCHAR(50) NOT NULL DEFAULT '',
+    status VARCHAR(20) NOT NULL DEFAULT 'QUEUED' CHECK (status IN ('QUEUED','SUBMITTED','REJECTED','CANCELLED')),
+    error TEXT,
+    oanda_order_id VARCHAR(100),
+    created_at TIMESTAMPTZ DEFAULT NOW(),
+    submitted_at TIMESTAMPTZ
+);
+
+CREATE INDEX IF NOT EXISTS idx_queued_orders_status ON queued_orders(status, created_at);
@@ -0,0 +1,34 @@
+-- price and indicator alert rules and their firings
+CREATE TABLE IF NOT EXISTS alert_rules (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    name VARCHAR(100) NOT NULL DEFAULT '',
+    instrument VARCHAR(50) NOT NULL,
+    kind VARCHAR(20) NOT NULL CHECK (kind IN ('PRICE','PERCENT_MOVE','SPREAD','INDICATOR')),
+    condition VARCHAR(20) NOT NULL CHECK (condition IN ('ABOVE','BELOW','CROSS_ABOVE','CROSS_BELOW')),
+    threshold DECIMAL(20,8) NOT NULL,
+    indicator VARCHAR(20),
+    period INT,
+    timeframe VARCHAR(10),
+    window_seconds INT NOT NULL DEFAULT 0,
+    repeat BOOLEAN NOT NULL DEFAULT FALSE,
+    cooldown_seconds INT NOT NULL DEFAULT 0,
+    active BOOLEAN NOT NULL DEFAULT TRUE,
+    fire_count INT NOT NULL DEFAULT 0,
+    last_fired_at TIMESTAMPTZ,
+    created_at TIMESTAMPTZ DEFAULT NOW(),
+    updated_at TIMESTAMPTZ DEFAULT NOW(),
+    deleted_at TIMESTAMPTZ
+);
+
+CREATE INDEX IF NOT EXISTS idx_alert_rules_active ON alert_rules(active) WHERE deleted_at IS NULL;
+
+CREATE TABLE IF NOT EXISTS alert_events (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    rule_id UUID NOT NULL REFERENCES alert_rules(id),
+    instrument VARCHAR(50) NOT NULL,
+    value DECIMAL(20,8) NOT NULL,
+    message TEXT NOT NULL,
+    fired_at TIMESTAMPTZ DEFAULT NOW()
+);
+
+CREATE INDEX IF NOT EXISTS idx_alert_events_rule ON alert_events(rule_id, fired_at DESC);
@@ -0,0 +1,11 @@
+-- optional bid/ask candles alongside the mid prices, used by the backtester when present
+ALTER TABLE IF EXISTS market_data ADD COLUMN IF NOT EXISTS bid_open DECIMAL(15,8);
+ALTER TABLE IF EXISTS market_data ADD COLUMN IF NOT EXISTS bid_high DECIMAL(15,8);
+ALTER TABLE IF EXISTS market_data ADD COLUMN IF NOT EXISTS bid_low DECIMAL(15,8);
+ALTER TABLE IF EXISTS market_data ADD COLUMN IF NOT EXISTS bid_close DECIMAL(15,8);
+ALTER TABLE IF EXISTS market_data ADD COLUMN IF NOT EXISTS ask_open DECIMAL(15,8);
+ALTER TABLE IF EXISTS market_data ADD COLUMN IF NOT EXISTS ask_high DECIMAL(15,8);
+ALTER TABLE IF EXISTS market_data ADD COLUMN IF NOT EXISTS ask_low DECIMAL(15,8);
+ALTER TABLE IF EXISTS market_data ADD COLUMN IF NOT EXISTS ask_close DECIMAL(15,8);
+
+CREATE INDEX IF NOT EXISTS idx_market_data_instrument_timeframe_ts ON market_data(instrument, timeframe, timestamp);
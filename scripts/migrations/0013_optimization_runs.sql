@@ -0,0 +1,20 @@
+-- parameter searches and walk-forward analyses, with their ranked results and equity curves
+CREATE TABLE IF NOT EXISTS optimization_runs (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    kind VARCHAR(20) NOT NULL CHECK (kind IN ('search','walk_forward')),
+    strategy VARCHAR(100) NOT NULL,
+    instruments TEXT[] NOT NULL,
+    timeframe VARCHAR(10) NOT NULL,
+    range_from TIMESTAMPTZ NOT NULL,
+    range_to TIMESTAMPTZ NOT NULL,
+    config JSONB NOT NULL,
+    status VARCHAR(20) NOT NULL DEFAULT 'RUNNING' CHECK (status IN ('RUNNING','COMPLETED','FAILED')),
+    metrics JSONB,
+    result JSONB,
+    equity JSONB,
+    error TEXT,
+    created_at TIMESTAMPTZ DEFAULT NOW(),
+    completed_at TIMESTAMPTZ
+);
+
+CREATE INDEX IF NOT EXISTS idx_optimization_runs_created ON optimization_runs(created_at DESC);
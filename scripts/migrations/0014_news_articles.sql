@@ -0,0 +1,16 @@
+-- scored news kept as it was seen, so AI replays can rebuild the news context as of a past time
+CREATE TABLE IF NOT EXISTS news_articles (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    url TEXT NOT NULL UNIQUE,
+    title TEXT NOT NULL,
+    snippet TEXT NOT NULL DEFAULT '',
+    source VARCHAR(100) NOT NULL DEFAULT '',
+    published_at TIMESTAMPTZ,
+    first_seen_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    sentiment DOUBLE PRECISION NOT NULL DEFAULT 0,
+    relevance JSONB,
+    currencies TEXT[] NOT NULL DEFAULT '{}'
+);
+
+CREATE INDEX IF NOT EXISTS idx_news_articles_available ON news_articles((COALESCE(published_at, first_seen_at)));
+CREATE INDEX IF NOT EXISTS idx_news_articles_currencies ON news_articles USING GIN (currencies);
@@ -0,0 +1,5 @@
+-- where each trade came from, and lookups for closing trades and analytics
+ALTER TABLE IF EXISTS trades ADD COLUMN IF NOT EXISTS source VARCHAR(50) NOT NULL DEFAULT 'manual';
+
+CREATE INDEX IF NOT EXISTS idx_trades_oanda_trade_id ON trades(oanda_trade_id);
+CREATE INDEX IF NOT EXISTS idx_trades_status_closed ON trades(status, closed_at);
@@ -0,0 +1,15 @@
+-- periodic account readings, the history behind equity curves and drawdown
+CREATE TABLE IF NOT EXISTS account_snapshots (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    account_id VARCHAR(50) NOT NULL,
+    currency VARCHAR(10) NOT NULL DEFAULT '',
+    balance DECIMAL(15,2) NOT NULL,
+    nav DECIMAL(15,2) NOT NULL,
+    unrealized_pl DECIMAL(15,2) NOT NULL DEFAULT 0,
+    margin_used DECIMAL(15,2) NOT NULL DEFAULT 0,
+    margin_available DECIMAL(15,2) NOT NULL DEFAULT 0,
+    open_trade_count INTEGER NOT NULL DEFAULT 0,
+    taken_at TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
+
+CREATE INDEX IF NOT EXISTS idx_account_snapshots_taken_at ON account_snapshots(account_id, taken_at);
@@ -0,0 +1,9 @@
+-- bid/ask ticks captured from the OANDA pricing stream, partitioned by day so retention drops
+-- whole partitions; the recorder creates partitions ahead of the ticks that need them
+CREATE TABLE IF NOT EXISTS ticks (
+    instrument VARCHAR(50) NOT NULL,
+    time TIMESTAMPTZ NOT NULL,
+    bid DECIMAL(15,8) NOT NULL,
+    ask DECIMAL(15,8) NOT NULL,
+    PRIMARY KEY (instrument, time)
+) PARTITION BY RANGE (time);
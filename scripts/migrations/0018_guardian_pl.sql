@@ -0,0 +1,4 @@
+-- the broker's lifetime realized P&L at the start of the day and at the peak, so deposits and
+-- withdrawals count neither as profit nor as loss
+ALTER TABLE IF EXISTS account_guardian_state ADD COLUMN IF NOT EXISTS start_of_day_pl DECIMAL(15,2);
+ALTER TABLE IF EXISTS account_guardian_state ADD COLUMN IF NOT EXISTS peak_pl DECIMAL(15,2);